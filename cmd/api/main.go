@@ -20,6 +20,10 @@ import (
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 
 	_ "github.com/Ulpio/vergo/docs/swagger"
+	"github.com/Ulpio/vergo/internal/domain/billing"
+	"github.com/Ulpio/vergo/internal/domain/export"
+	"github.com/Ulpio/vergo/internal/domain/idempotency"
+	"github.com/Ulpio/vergo/internal/domain/purge"
 	"github.com/Ulpio/vergo/internal/domain/webhook"
 	"github.com/Ulpio/vergo/internal/http/middleware"
 	"github.com/Ulpio/vergo/internal/http/router"
@@ -27,8 +31,10 @@ import (
 	"github.com/Ulpio/vergo/internal/pkg/config"
 	"github.com/Ulpio/vergo/internal/pkg/db"
 	"github.com/Ulpio/vergo/internal/pkg/logging"
+	"github.com/Ulpio/vergo/internal/pkg/notify"
 	"github.com/Ulpio/vergo/internal/pkg/ratelimit"
 	"github.com/Ulpio/vergo/internal/pkg/telemetry"
+	s3store "github.com/Ulpio/vergo/internal/storage/s3"
 )
 
 const shutdownTimeout = 30 * time.Second
@@ -138,7 +144,7 @@ func main() {
 			c.JSON(http.StatusOK, gin.H{"pong": true})
 		})
 
-		router.Register(api)
+		router.Register(r, api)
 	}
 
 	// Prometheus metrics server (separate port for scraping)
@@ -168,6 +174,50 @@ func main() {
 		}
 	}()
 
+	// Data export runner (background goroutine)
+	s3c, err := s3store.NewFromConfig(cfg)
+	if err != nil {
+		slog.Error("s3 client init failed", "error", err)
+		os.Exit(1)
+	}
+	exportRunner := export.NewRunner(repo.New(database), s3c, notify.NewLogNotifier())
+	exportTicker := time.NewTicker(10 * time.Second)
+	go func() {
+		for range exportTicker.C {
+			exportRunner.ProcessPending()
+		}
+	}()
+	exportSweepTicker := time.NewTicker(time.Hour)
+	go func() {
+		for range exportSweepTicker.C {
+			exportRunner.Sweep()
+		}
+	}()
+
+	// Purge of deleted orgs/projects past the restore window (background goroutine)
+	purgeRunner := purge.NewRunner(database, repo.New(database), s3c,
+		billing.NewService(repo.New(database), cfg.StripeSecretKey),
+		time.Duration(cfg.DeletionRestoreDays)*24*time.Hour)
+	purgeTicker := time.NewTicker(time.Hour)
+	go func() {
+		for range purgeTicker.C {
+			purgeRunner.ProcessPending()
+		}
+	}()
+
+	// Expired idempotency keys (background goroutine)
+	idemSvc := idempotency.NewPostgresService(repo.New(database), time.Duration(cfg.IdempotencyTTLHours)*time.Hour)
+	idemTicker := time.NewTicker(time.Hour)
+	go func() {
+		for range idemTicker.C {
+			if n, err := idemSvc.PurgeExpired(); err != nil {
+				slog.Error("idempotency: purge expired", "error", err)
+			} else if n > 0 {
+				slog.Info("idempotency: purged expired keys", "count", n)
+			}
+		}
+	}()
+
 	// HTTP server
 	srv := &http.Server{
 		Addr:         ":" + strconv.Itoa(port),
@@ -191,8 +241,12 @@ func main() {
 	sig := <-quit
 	slog.Info("shutdown signal received", "signal", sig.String())
 
-	// Graceful shutdown: ticker → HTTP → telemetry → DB (ordered, not deferred)
+	// Graceful shutdown: tickers → HTTP → telemetry → DB (ordered, not deferred)
 	whTicker.Stop()
+	exportTicker.Stop()
+	exportSweepTicker.Stop()
+	purgeTicker.Stop()
+	idemTicker.Stop()
 	gracefulShutdown(srv, otelResult.Shutdown, database)
 }
 
@@ -1,6 +1,6 @@
 CREATE TABLE api_keys (
   id TEXT PRIMARY KEY DEFAULT gen_random_uuid()::TEXT,
-  org_id TEXT NOT NULL REFERENCES orgs (id) ON DELETE CASCADE,
+  org_id TEXT NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
   name TEXT NOT NULL,
   key_prefix TEXT NOT NULL,           -- first 8 chars of sk_... for display
   key_hash TEXT NOT NULL UNIQUE,     -- SHA-256 of full key
@@ -1,6 +1,6 @@
 CREATE TABLE webhook_endpoints (
   id TEXT PRIMARY KEY DEFAULT gen_random_uuid()::TEXT,
-  org_id TEXT NOT NULL REFERENCES orgs (id) ON DELETE CASCADE,
+  org_id TEXT NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
   url TEXT NOT NULL,
   secret TEXT NOT NULL,
   events TEXT [] NOT NULL DEFAULT '{}',
@@ -1,6 +1,6 @@
 CREATE TABLE subscriptions (
   id TEXT PRIMARY KEY DEFAULT gen_random_uuid()::TEXT,
-  org_id TEXT NOT NULL UNIQUE REFERENCES orgs (id) ON DELETE CASCADE,
+  org_id TEXT NOT NULL UNIQUE REFERENCES organizations (id) ON DELETE CASCADE,
   stripe_customer_id TEXT NOT NULL,
   stripe_subscription_id TEXT UNIQUE,
   status TEXT NOT NULL DEFAULT 'incomplete',
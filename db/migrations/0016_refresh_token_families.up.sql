@@ -0,0 +1,25 @@
+-- Refresh token families: every token rotated from a login shares the
+-- family_id of the first token, so reuse of a rotated token can revoke the
+-- whole chain at once.
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS family_id TEXT;
+
+WITH RECURSIVE chain AS (
+  SELECT id, id AS family_id
+  FROM refresh_tokens
+  WHERE rotated_from IS NULL
+  UNION ALL
+  SELECT r.id, c.family_id
+  FROM refresh_tokens r
+  JOIN chain c ON r.rotated_from = c.id
+)
+UPDATE refresh_tokens t
+SET family_id = chain.family_id
+FROM chain
+WHERE t.id = chain.id AND t.family_id IS NULL;
+
+UPDATE refresh_tokens SET family_id = id WHERE family_id IS NULL;
+
+ALTER TABLE refresh_tokens ALTER COLUMN family_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens (family_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_rotated_from ON refresh_tokens (rotated_from);
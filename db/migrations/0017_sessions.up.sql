@@ -0,0 +1,21 @@
+-- Sessions: one row per refresh token family (a login on one device), with
+-- the client details shown in the session list. A session is active while
+-- its family still has an unrevoked, unexpired refresh token.
+CREATE TABLE IF NOT EXISTS sessions (
+  id TEXT PRIMARY KEY, -- refresh_tokens.family_id
+  user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  user_agent TEXT NOT NULL DEFAULT '',
+  ip TEXT NOT NULL DEFAULT '',
+  geo_label TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  last_used_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions (user_id);
+
+-- Families created before this migration get a session without client details
+INSERT INTO sessions (id, user_id, created_at, last_used_at)
+SELECT family_id, user_id, MIN(created_at), MAX(created_at)
+FROM refresh_tokens
+GROUP BY family_id, user_id
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,16 @@
+-- Data export jobs (GDPR access requests). A background runner picks up
+-- pending jobs, uploads a ZIP of JSON files and records its object key.
+CREATE TABLE IF NOT EXISTS data_exports (
+  id TEXT PRIMARY KEY DEFAULT gen_random_uuid()::TEXT,
+  kind TEXT NOT NULL,           -- user | org
+  subject_id TEXT NOT NULL,     -- users.id or organizations.id
+  requested_by TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  status TEXT NOT NULL DEFAULT 'pending', -- pending | running | done | failed
+  object_key TEXT NOT NULL DEFAULT '',
+  error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_data_exports_pending ON data_exports (created_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_data_exports_requester ON data_exports (requested_by);
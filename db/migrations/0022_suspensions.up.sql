@@ -0,0 +1,16 @@
+-- Platform admins can suspend orgs and users. Suspended users are rejected
+-- by the Auth middleware and suspended orgs by Tenant.
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMPTZ;
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS suspended_reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_reason TEXT NOT NULL DEFAULT '';
+
+-- Plan forced by a platform admin (comps, extended trials, incidents). It
+-- wins over the Stripe subscription until removed.
+CREATE TABLE IF NOT EXISTS plan_overrides (
+  org_id TEXT PRIMARY KEY REFERENCES organizations (id) ON DELETE CASCADE,
+  plan TEXT NOT NULL,
+  reason TEXT NOT NULL DEFAULT '',
+  set_by TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,9 @@
+-- Pending ownership transfers: the owner proposes a member, who accepts to
+-- become owner. One per org; a new proposal replaces the previous one.
+CREATE TABLE IF NOT EXISTS ownership_transfers (
+  org_id TEXT PRIMARY KEY REFERENCES organizations (id) ON DELETE CASCADE,
+  from_user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  to_user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  expires_at TIMESTAMPTZ NOT NULL
+);
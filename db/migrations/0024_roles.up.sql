@@ -0,0 +1,30 @@
+-- Roles are named sets of permissions. Rows without an org are the built-in
+-- presets shared by every org; orgs add their own custom roles. Memberships
+-- and API keys refer to a role by name, so custom roles cannot be renamed
+-- and are only deleted once nothing is assigned to them.
+CREATE TABLE IF NOT EXISTS roles (
+  id TEXT PRIMARY KEY DEFAULT gen_random_uuid()::TEXT,
+  org_id TEXT REFERENCES organizations (id) ON DELETE CASCADE, -- NULL = built-in preset
+  name TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  permissions TEXT[] NOT NULL DEFAULT '{}',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_roles_org_name ON roles (COALESCE(org_id, ''), name);
+
+-- The presets grant what the fixed member < admin < owner ladder allowed.
+INSERT INTO roles (org_id, name, description, permissions) VALUES
+  (NULL, 'owner', 'Full access, including deleting and transferring the org', ARRAY[
+    'projects.write', 'projects.delete', 'api_keys.manage', 'webhooks.manage', 'billing.manage',
+    'members.invite', 'members.manage', 'roles.manage', 'audit.read', 'sso.manage']),
+  (NULL, 'admin', 'Manages members, roles, SSO and the audit log', ARRAY[
+    'projects.write', 'projects.delete', 'api_keys.manage', 'webhooks.manage', 'billing.manage',
+    'members.invite', 'members.manage', 'roles.manage', 'audit.read', 'sso.manage']),
+  (NULL, 'member', 'Works on projects, API keys, webhooks and billing', ARRAY[
+    'projects.write', 'projects.delete', 'api_keys.manage', 'webhooks.manage', 'billing.manage'])
+ON CONFLICT DO NOTHING;
+
+-- API keys act with a role too; existing keys get the member preset.
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'member';
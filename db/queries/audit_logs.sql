@@ -1,11 +1,13 @@
 -- name: InsertAuditLog :exec
-INSERT INTO audit_logs (org_id, actor_id, action, entity, entity_id, metadata, created_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7);
+INSERT INTO audit_logs (org_id, actor_id, action, entity, entity_id, metadata, created_at, impersonator_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
 
 -- name: ListAuditLogs :many
-SELECT org_id, actor_id, action, entity, entity_id,
+-- Newest first; the page starts after the cursor (@after_time, @after_id)
+-- unless @after_id is 0.
+SELECT id, org_id, actor_id, action, entity, entity_id,
        COALESCE(metadata, '{}') AS metadata,
-       created_at
+       created_at, impersonator_id
 FROM audit_logs
 WHERE org_id = @org_id
   AND (CAST(sqlc.narg('filter_actor_id') AS text) IS NULL OR actor_id = CAST(sqlc.narg('filter_actor_id') AS text))
@@ -13,5 +15,6 @@ WHERE org_id = @org_id
   AND (CAST(sqlc.narg('filter_entity') AS text) IS NULL OR entity = CAST(sqlc.narg('filter_entity') AS text))
   AND (CAST(sqlc.narg('filter_since') AS timestamptz) IS NULL OR created_at >= CAST(sqlc.narg('filter_since') AS timestamptz))
   AND (CAST(sqlc.narg('filter_until') AS timestamptz) IS NULL OR created_at <= CAST(sqlc.narg('filter_until') AS timestamptz))
-ORDER BY created_at DESC
-LIMIT @query_limit OFFSET @query_offset;
+  AND (@after_id::BIGINT = 0 OR (created_at, id) < (@after_time::TIMESTAMPTZ, @after_id::BIGINT))
+ORDER BY created_at DESC, id DESC
+LIMIT @query_limit;
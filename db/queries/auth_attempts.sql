@@ -0,0 +1,25 @@
+-- name: GetAuthAttempt :one
+SELECT scope, key, failures, last_failure_at, locked_until
+FROM auth_attempts
+WHERE scope = $1 AND key = $2;
+
+-- name: RecordAuthFailure :one
+INSERT INTO auth_attempts (scope, key, failures, last_failure_at)
+VALUES (@scope, @key, 1, NOW())
+ON CONFLICT (scope, key) DO UPDATE
+SET failures = CASE
+      WHEN GREATEST(auth_attempts.last_failure_at, auth_attempts.locked_until) < @reset_before THEN 1
+      ELSE auth_attempts.failures + 1
+    END,
+    last_failure_at = NOW()
+RETURNING scope, key, failures, last_failure_at, locked_until;
+
+-- name: LockAuthAttempt :exec
+UPDATE auth_attempts SET locked_until = $3 WHERE scope = $1 AND key = $2;
+
+-- name: DeleteAuthAttempt :exec
+DELETE FROM auth_attempts WHERE scope = $1 AND key = $2;
+
+-- name: DeleteStaleAuthAttempts :exec
+DELETE FROM auth_attempts
+WHERE GREATEST(last_failure_at, locked_until) < @before;
@@ -0,0 +1,15 @@
+-- name: CreateEmailChangeToken :exec
+INSERT INTO email_change_tokens (user_id, new_email, token_hash, expires_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: InvalidateEmailChangeTokens :exec
+-- Retires the user's pending tokens so only the newest link works.
+UPDATE email_change_tokens SET used_at = now()
+WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: ConsumeEmailChangeToken :one
+-- Marks the token used in the same statement that checks it, so two
+-- concurrent confirmations cannot both succeed.
+UPDATE email_change_tokens SET used_at = now()
+WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+RETURNING user_id, new_email;
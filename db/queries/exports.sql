@@ -0,0 +1,117 @@
+-- name: CreateExportJob :one
+INSERT INTO data_exports (kind, subject_id, requested_by)
+VALUES ($1, $2, $3)
+RETURNING id, kind, subject_id, requested_by, status, object_key, error, created_at, finished_at, started_at;
+
+-- name: GetExportJob :one
+SELECT id, kind, subject_id, requested_by, status, object_key, error, created_at, finished_at, started_at
+FROM data_exports
+WHERE id = $1 AND requested_by = $2;
+
+-- name: ClaimExportJobs :many
+-- Marks up to @query_limit pending jobs as running, along with running
+-- jobs started before @stale_before whose runner never finished them;
+-- concurrent runners skip each other's rows.
+UPDATE data_exports
+SET status = 'running', started_at = now()
+WHERE id IN (
+  SELECT id FROM data_exports
+  WHERE status = 'pending'
+     OR (status = 'running' AND COALESCE(started_at, created_at) < @stale_before)
+  ORDER BY created_at
+  LIMIT @query_limit
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, kind, subject_id, requested_by, status, object_key, error, created_at, finished_at, started_at;
+
+-- name: FinishExportJob :exec
+UPDATE data_exports SET status = 'done', object_key = $2, finished_at = now() WHERE id = $1;
+
+-- name: FailExportJob :exec
+UPDATE data_exports SET status = 'failed', error = $2, finished_at = now() WHERE id = $1;
+
+-- name: ListExpiredExports :many
+-- Finished jobs older than @finished_before, whose archives are due for
+-- deletion.
+SELECT id, object_key
+FROM data_exports
+WHERE finished_at < @finished_before
+ORDER BY finished_at
+LIMIT @query_limit;
+
+-- name: DeleteExportJob :exec
+DELETE FROM data_exports WHERE id = $1;
+
+-- name: ExportUser :one
+-- Export queries return JSON documents ready to be written to the archive.
+SELECT (to_jsonb(u) - 'password_hash')::jsonb
+FROM users u
+WHERE u.id = $1;
+
+-- name: ExportUserMemberships :one
+SELECT COALESCE(jsonb_agg(t ORDER BY t.org_id), '[]')::jsonb
+FROM (
+  SELECT m.org_id, o.name AS org_name, m.role
+  FROM memberships m
+  JOIN organizations o ON o.id = m.org_id
+  WHERE m.user_id = $1
+) t;
+
+-- name: ExportUserProjects :one
+SELECT COALESCE(jsonb_agg(to_jsonb(p) ORDER BY p.created_at), '[]')::jsonb
+FROM projects p
+WHERE p.created_by = $1;
+
+-- name: ExportUserFiles :one
+SELECT COALESCE(jsonb_agg(to_jsonb(f) ORDER BY f.created_at), '[]')::jsonb
+FROM files f
+WHERE f.uploaded_by = $1;
+
+-- name: ExportUserAuditLogs :one
+SELECT COALESCE(jsonb_agg(to_jsonb(a) ORDER BY a.id), '[]')::jsonb
+FROM audit_logs a
+WHERE a.actor_id = $1;
+
+-- name: ExportUserAPIKeys :one
+SELECT COALESCE(jsonb_agg(to_jsonb(k) - 'key_hash' ORDER BY k.created_at), '[]')::jsonb
+FROM api_keys k
+WHERE k.created_by = $1;
+
+-- name: ExportOrg :one
+SELECT to_jsonb(o)::jsonb
+FROM organizations o
+WHERE o.id = $1;
+
+-- name: ExportOrgMembers :one
+SELECT COALESCE(jsonb_agg(t ORDER BY t.user_id), '[]')::jsonb
+FROM (
+  SELECT m.user_id, u.email, u.display_name, m.role
+  FROM memberships m
+  JOIN users u ON u.id = m.user_id
+  WHERE m.org_id = $1
+) t;
+
+-- name: ExportOrgProjects :one
+SELECT COALESCE(jsonb_agg(to_jsonb(p) ORDER BY p.created_at), '[]')::jsonb
+FROM projects p
+WHERE p.org_id = $1;
+
+-- name: ExportOrgFiles :one
+SELECT COALESCE(jsonb_agg(to_jsonb(f) ORDER BY f.created_at), '[]')::jsonb
+FROM files f
+WHERE f.org_id = $1;
+
+-- name: ExportOrgAuditLogs :one
+SELECT COALESCE(jsonb_agg(to_jsonb(a) ORDER BY a.id), '[]')::jsonb
+FROM audit_logs a
+WHERE a.org_id = $1;
+
+-- name: ExportOrgAPIKeys :one
+SELECT COALESCE(jsonb_agg(to_jsonb(k) - 'key_hash' ORDER BY k.created_at), '[]')::jsonb
+FROM api_keys k
+WHERE k.org_id = $1;
+
+-- name: ExportOrgWebhooks :one
+SELECT COALESCE(jsonb_agg(to_jsonb(w) - 'secret' ORDER BY w.created_at), '[]')::jsonb
+FROM webhook_endpoints w
+WHERE w.org_id = $1;
@@ -1,20 +1,53 @@
 -- name: ListFiles :many
-SELECT id, org_id, uploaded_by, bucket, object_key, size_bytes, content_type, created_at, metadata
+-- Files outside projects, plus those in project_ids (or in any project
+-- with all_projects). A non-empty project_id narrows to that project.
+-- Files of soft-deleted projects are hidden. Newest first; the page
+-- starts after the cursor (@after_time, @after_id) unless @after_id is
+-- empty.
+SELECT id, org_id, uploaded_by, bucket, object_key, size_bytes, content_type, created_at, metadata, project_id, version
 FROM files
-WHERE org_id = $1
-ORDER BY created_at DESC
-LIMIT $2 OFFSET $3;
+WHERE org_id = @org_id
+  AND (project_id IS NULL OR @all_projects::BOOLEAN OR project_id = ANY(@project_ids::TEXT[]))
+  AND NOT EXISTS (SELECT 1 FROM projects p WHERE p.id = files.project_id AND p.deleted_at IS NOT NULL)
+  AND (@project_id::TEXT = '' OR project_id = @project_id)
+  AND (@after_id::TEXT = '' OR (created_at, id) < (@after_time::TIMESTAMPTZ, @after_id::TEXT))
+ORDER BY created_at DESC, id DESC
+LIMIT @query_limit;
 
 -- name: InsertFile :one
-INSERT INTO files (id, org_id, uploaded_by, bucket, object_key, size_bytes, content_type, created_at, metadata)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-RETURNING id, org_id, uploaded_by, bucket, object_key, size_bytes, content_type, created_at, metadata;
+INSERT INTO files (id, org_id, uploaded_by, bucket, object_key, size_bytes, content_type, created_at, metadata, project_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id, org_id, uploaded_by, bucket, object_key, size_bytes, content_type, created_at, metadata, project_id, version;
 
 -- name: GetFile :one
-SELECT id, org_id, uploaded_by, bucket, object_key, size_bytes, content_type, created_at, metadata
+SELECT id, org_id, uploaded_by, bucket, object_key, size_bytes, content_type, created_at, metadata, project_id, version
 FROM files
 WHERE id = $1 AND org_id = $2;
 
+-- name: ListFileProjectsByKey :many
+-- Projects holding a registered file with this object key.
+SELECT DISTINCT project_id::TEXT
+FROM files
+WHERE org_id = $1 AND object_key = $2 AND project_id IS NOT NULL;
+
+-- name: ListOrgFileObjects :many
+-- Every stored object of the org, for the purge worker.
+SELECT bucket, object_key
+FROM files
+WHERE org_id = $1;
+
+-- name: ListProjectFileObjects :many
+-- Every stored object of the project, for the purge worker.
+SELECT bucket, object_key
+FROM files
+WHERE project_id = $1;
+
 -- name: DeleteFile :execresult
+-- Applies only while the file is at @if_version, unless that is 0.
 DELETE FROM files
-WHERE id = $1 AND org_id = $2;
+WHERE id = @id AND org_id = @org_id AND (@if_version::BIGINT = 0 OR version = @if_version);
+
+-- name: IsFileUploadedBy :one
+SELECT EXISTS (
+  SELECT 1 FROM files WHERE id = $1 AND uploaded_by = $2
+);
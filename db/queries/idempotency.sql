@@ -0,0 +1,28 @@
+-- name: ClaimIdempotencyKey :execrows
+-- Claims the key for a new request. An expired entry, or one still
+-- unfinished since @stale_before (its request died), is taken over.
+INSERT INTO idempotency_keys (principal, org_id, key, fingerprint, expires_at)
+VALUES (@principal, @org_id, @key, @fingerprint, @expires_at)
+ON CONFLICT (principal, org_id, key) DO UPDATE
+SET fingerprint = EXCLUDED.fingerprint, status_code = NULL, content_type = '', response_body = '',
+    created_at = NOW(), expires_at = EXCLUDED.expires_at
+WHERE idempotency_keys.expires_at <= NOW()
+   OR (idempotency_keys.status_code IS NULL AND idempotency_keys.created_at < @stale_before);
+
+-- name: GetIdempotencyKey :one
+SELECT principal, org_id, key, fingerprint, status_code, content_type, response_body, created_at, expires_at
+FROM idempotency_keys
+WHERE principal = $1 AND org_id = $2 AND key = $3;
+
+-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+SET status_code = $4, content_type = $5, response_body = $6
+WHERE principal = $1 AND org_id = $2 AND key = $3;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE principal = $1 AND org_id = $2 AND key = $3;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE expires_at <= NOW();
@@ -0,0 +1,26 @@
+-- name: InsertIdentity :exec
+INSERT INTO identities (user_id, provider, subject, email)
+VALUES ($1, $2, $3, $4);
+
+-- name: GetIdentity :one
+SELECT id, user_id, provider, subject, email, created_at
+FROM identities
+WHERE provider = $1 AND subject = $2;
+
+-- name: ListIdentitiesByUser :many
+SELECT id, user_id, provider, subject, email, created_at
+FROM identities
+WHERE user_id = $1
+ORDER BY created_at ASC;
+
+-- name: InsertOIDCAuthRequest :exec
+INSERT INTO oidc_auth_requests (state_hash, provider, code_verifier, nonce, expires_at)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: ConsumeOIDCAuthRequest :one
+DELETE FROM oidc_auth_requests
+WHERE state_hash = $1
+RETURNING state_hash, provider, code_verifier, nonce, expires_at, created_at;
+
+-- name: DeleteExpiredOIDCAuthRequests :exec
+DELETE FROM oidc_auth_requests WHERE expires_at < now();
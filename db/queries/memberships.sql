@@ -1,11 +1,12 @@
 -- name: UpsertMember :exec
 INSERT INTO memberships (org_id, user_id, role)
 VALUES ($1, $2, $3)
-ON CONFLICT (org_id, user_id) DO UPDATE SET role = EXCLUDED.role;
+ON CONFLICT (org_id, user_id) DO UPDATE
+SET role = EXCLUDED.role, version = nextval('membership_version_seq');
 
 -- name: UpdateMemberRole :execresult
 UPDATE memberships
-SET role = $3
+SET role = $3, version = nextval('membership_version_seq')
 WHERE org_id = $1 AND user_id = $2;
 
 -- name: DeleteMember :exec
@@ -16,3 +17,37 @@ WHERE org_id = $1 AND user_id = $2;
 SELECT role
 FROM memberships
 WHERE org_id = $1 AND user_id = $2;
+
+-- name: GetMembership :one
+SELECT org_id, user_id, role, version
+FROM memberships
+WHERE org_id = $1 AND user_id = $2;
+
+-- name: InsertMemberIfAbsent :execresult
+INSERT INTO memberships (org_id, user_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (org_id, user_id) DO NOTHING;
+
+-- name: ListUserOrgs :many
+SELECT o.id, o.name, m.role, o.created_at
+FROM memberships m
+JOIN organizations o ON o.id = m.org_id
+WHERE m.user_id = @user_id AND o.deleted_at IS NULL
+ORDER BY o.name, o.id
+LIMIT @query_limit OFFSET @query_offset;
+
+-- name: ListOrgMembers :many
+SELECT m.user_id, u.email, u.display_name, u.avatar_file_id, m.role
+FROM memberships m
+JOIN users u ON u.id = m.user_id
+WHERE m.org_id = @org_id
+ORDER BY u.email
+LIMIT @query_limit OFFSET @query_offset;
+
+-- name: LockOrgOwners :many
+-- Serializes changes that could leave the org without an owner.
+SELECT user_id
+FROM memberships
+WHERE org_id = $1 AND role = 'owner'
+ORDER BY user_id
+FOR UPDATE;
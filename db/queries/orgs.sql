@@ -1,12 +1,76 @@
 -- name: InsertOrg :exec
-INSERT INTO organizations (id, name, owner_user_id, created_at)
-VALUES ($1, $2, $3, $4);
+INSERT INTO organizations (id, name, owner_user_id, created_at, slug)
+VALUES ($1, $2, $3, $4, $5);
 
 -- name: GetOrg :one
-SELECT id, name, owner_user_id, created_at
+SELECT id, name, owner_user_id, created_at, allow_impersonation, suspended_at, suspended_reason,
+       slug, settings, logo_file_id, primary_color, updated_at, deleted_at, deleted_by, version
 FROM organizations
 WHERE id = $1;
 
--- name: DeleteOrg :exec
+-- name: OrgSlugTaken :one
+-- Whether another org already uses the slug.
+SELECT EXISTS (
+  SELECT 1 FROM organizations WHERE slug = $1 AND id <> $2
+);
+
+-- name: UpdateOrgProfile :execrows
+-- Applies only while the org is at @if_version, unless that is 0.
+UPDATE organizations
+SET name = @name, slug = @slug, settings = @settings, logo_file_id = @logo_file_id, primary_color = @primary_color,
+    updated_at = NOW(), version = version + 1
+WHERE id = @id AND (@if_version::BIGINT = 0 OR version = @if_version);
+
+-- name: SoftDeleteOrg :execrows
+-- Applies only while the org is at @if_version, unless that is 0.
+UPDATE organizations
+SET deleted_at = NOW(), deleted_by = @deleted_by, version = version + 1
+WHERE id = @id AND deleted_at IS NULL AND (@if_version::BIGINT = 0 OR version = @if_version);
+
+-- name: RestoreOrg :execrows
+-- Undoes a soft delete made after @deleted_since.
+UPDATE organizations
+SET deleted_at = NULL, deleted_by = NULL, updated_at = NOW(), version = version + 1
+WHERE id = @id AND deleted_at > @deleted_since;
+
+-- name: ListPurgeableOrgs :many
+-- Orgs soft-deleted before @deleted_before, oldest first.
+SELECT id
+FROM organizations
+WHERE deleted_at < @deleted_before
+ORDER BY deleted_at
+LIMIT @query_limit;
+
+-- name: PurgeOrg :execrows
+-- Removes an org soft-deleted before @deleted_before. Memberships,
+-- projects and files cascade.
 DELETE FROM organizations
-WHERE id = $1;
+WHERE id = @id AND deleted_at < @deleted_before;
+
+-- name: ListSoleOwnedOrgs :many
+-- Orgs the user owns with no other member holding the owner role.
+SELECT o.id
+FROM organizations o
+WHERE (o.owner_user_id = $1
+       OR EXISTS (SELECT 1 FROM memberships m WHERE m.org_id = o.id AND m.user_id = $1 AND m.role = 'owner'))
+  AND NOT EXISTS (
+    SELECT 1 FROM memberships m WHERE m.org_id = o.id AND m.role = 'owner' AND m.user_id <> $1
+  )
+ORDER BY o.id;
+
+-- name: ReassignOrgOwner :exec
+-- Hands organizations.owner_user_id to another owner before the user leaves.
+UPDATE organizations o
+SET owner_user_id = (
+  SELECT m.user_id FROM memberships m
+  WHERE m.org_id = o.id AND m.role = 'owner' AND m.user_id <> $1
+  ORDER BY m.user_id
+  LIMIT 1
+), version = version + 1
+WHERE o.owner_user_id = $1;
+
+-- name: SetOrgAllowImpersonation :execrows
+UPDATE organizations SET allow_impersonation = $2, version = version + 1 WHERE id = $1;
+
+-- name: SetOrgOwner :exec
+UPDATE organizations SET owner_user_id = $2, version = version + 1 WHERE id = $1;
@@ -0,0 +1,24 @@
+-- name: UpsertOwnershipTransfer :one
+INSERT INTO ownership_transfers (org_id, from_user_id, to_user_id, expires_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (org_id) DO UPDATE SET
+    from_user_id = EXCLUDED.from_user_id,
+    to_user_id = EXCLUDED.to_user_id,
+    created_at = now(),
+    expires_at = EXCLUDED.expires_at
+RETURNING org_id, from_user_id, to_user_id, created_at, expires_at;
+
+-- name: GetOwnershipTransfer :one
+SELECT org_id, from_user_id, to_user_id, created_at, expires_at
+FROM ownership_transfers
+WHERE org_id = $1 AND expires_at > now();
+
+-- name: ClaimOwnershipTransfer :one
+-- Consumes the pending transfer to to_user_id, once.
+DELETE FROM ownership_transfers
+WHERE org_id = $1 AND to_user_id = $2 AND expires_at > now()
+RETURNING org_id, from_user_id, to_user_id, created_at, expires_at;
+
+-- name: DeleteOwnershipTransfer :execrows
+DELETE FROM ownership_transfers
+WHERE org_id = $1 AND (from_user_id = @user_id OR to_user_id = @user_id);
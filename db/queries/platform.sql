@@ -0,0 +1,84 @@
+-- name: IsPlatformAdmin :one
+SELECT EXISTS (SELECT 1 FROM platform_admins WHERE user_id = $1);
+
+-- name: GrantPlatformAdminByEmail :execrows
+INSERT INTO platform_admins (user_id)
+SELECT id FROM users WHERE email = $1
+ON CONFLICT (user_id) DO NOTHING;
+
+-- name: IsUserSuspended :one
+SELECT EXISTS (SELECT 1 FROM users WHERE id = $1 AND suspended_at IS NOT NULL);
+
+-- name: GetScopedAccess :one
+-- What an org-scoped token is checked against, in one round trip: the
+-- user's suspension, the membership and the org's state. Membership and
+-- org columns are NULL once the membership is gone.
+SELECT (u.suspended_at IS NOT NULL)::boolean AS user_suspended,
+       m.role, m.version,
+       o.deleted_at, o.suspended_at, o.allow_impersonation
+FROM users u
+LEFT JOIN memberships m ON m.user_id = u.id AND m.org_id = @org_id
+LEFT JOIN organizations o ON o.id = m.org_id
+WHERE u.id = @user_id;
+
+-- name: AdminListOrgs :many
+SELECT o.id, o.name, o.owner_user_id, o.created_at, o.suspended_at, o.suspended_reason,
+       COALESCE(po.plan, s.plan, 'free') AS plan,
+       (SELECT COUNT(*) FROM memberships m WHERE m.org_id = o.id) AS member_count
+FROM organizations o
+LEFT JOIN subscriptions s ON s.org_id = o.id
+LEFT JOIN plan_overrides po ON po.org_id = o.id
+WHERE @search::text = '' OR o.id = @search OR o.name ILIKE '%' || @search || '%'
+ORDER BY o.created_at DESC
+LIMIT @query_limit OFFSET @query_offset;
+
+-- name: AdminListUsers :many
+SELECT u.id, u.email, u.display_name, u.email_verified_at, u.created_at, u.suspended_at, u.suspended_reason,
+       EXISTS (SELECT 1 FROM platform_admins pa WHERE pa.user_id = u.id) AS platform_admin
+FROM users u
+WHERE u.id <> 'deleted-user'
+  AND (@search::text = '' OR u.id = @search OR u.email ILIKE '%' || @search || '%' OR u.display_name ILIKE '%' || @search || '%')
+ORDER BY u.created_at DESC
+LIMIT @query_limit OFFSET @query_offset;
+
+-- name: GetOrgUsage :one
+SELECT (SELECT COUNT(*) FROM projects p WHERE p.org_id = @org_id) AS projects,
+       (SELECT COUNT(*) FROM memberships m WHERE m.org_id = @org_id) AS members,
+       (SELECT COALESCE(SUM(f.size_bytes), 0) FROM files f WHERE f.org_id = @org_id)::bigint AS storage_bytes;
+
+-- name: SuspendOrg :execrows
+UPDATE organizations
+SET suspended_at = COALESCE(suspended_at, now()), suspended_reason = $2, version = version + 1
+WHERE id = $1;
+
+-- name: UnsuspendOrg :execrows
+UPDATE organizations SET suspended_at = NULL, suspended_reason = '', version = version + 1 WHERE id = $1;
+
+-- name: SuspendUser :execrows
+UPDATE users
+SET suspended_at = COALESCE(suspended_at, now()), suspended_reason = $2
+WHERE id = $1 AND id <> 'deleted-user';
+
+-- name: UnsuspendUser :execrows
+UPDATE users SET suspended_at = NULL, suspended_reason = '' WHERE id = $1;
+
+-- name: GetWebhookBacklog :one
+-- Undelivered webhooks across all orgs: pending ones are still retried,
+-- failed ones ran out of attempts.
+SELECT COUNT(*) FILTER (WHERE attempts < 5) AS pending,
+       COUNT(*) FILTER (WHERE attempts >= 5) AS failed,
+       MIN(created_at) FILTER (WHERE attempts < 5) AS oldest_pending
+FROM webhook_deliveries
+WHERE NOT delivered;
+
+-- name: ListWebhookBacklogByOrg :many
+SELECT e.org_id,
+       COUNT(*) FILTER (WHERE d.attempts < 5) AS pending,
+       COUNT(*) FILTER (WHERE d.attempts >= 5) AS failed,
+       MIN(d.created_at)::timestamptz AS oldest
+FROM webhook_deliveries d
+JOIN webhook_endpoints e ON e.id = d.endpoint_id
+WHERE NOT d.delivered
+GROUP BY e.org_id
+ORDER BY pending DESC, failed DESC
+LIMIT $1;
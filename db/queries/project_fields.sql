@@ -0,0 +1,42 @@
+-- name: ListProjectFields :many
+SELECT id, org_id, key, label, type, options, created_at, updated_at
+FROM project_fields
+WHERE org_id = $1
+ORDER BY key;
+
+-- name: CreateProjectField :one
+-- Returns no row when the org already has a field with that key.
+INSERT INTO project_fields (org_id, key, label, type, options)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT DO NOTHING
+RETURNING id, org_id, key, label, type, options, created_at, updated_at;
+
+-- name: GetProjectField :one
+SELECT id, org_id, key, label, type, options, created_at, updated_at
+FROM project_fields
+WHERE id = $1 AND org_id = $2;
+
+-- name: UpdateProjectField :one
+UPDATE project_fields
+SET label = $3, options = $4, updated_at = NOW()
+WHERE id = $1 AND org_id = $2
+RETURNING id, org_id, key, label, type, options, created_at, updated_at;
+
+-- name: DeleteProjectField :one
+DELETE FROM project_fields
+WHERE id = $1 AND org_id = $2
+RETURNING key;
+
+-- name: DeleteProjectFieldValues :exec
+-- Drops the field's values from every project of the org, deleted ones
+-- included.
+UPDATE projects
+SET custom_fields = custom_fields - @key::TEXT, version = version + 1
+WHERE org_id = @org_id AND custom_fields ? @key::TEXT;
+
+-- name: ProjectFieldValueInUse :one
+-- Whether any project of the org holds one of @field_values in the field.
+SELECT EXISTS (
+  SELECT 1 FROM projects
+  WHERE org_id = @org_id AND custom_fields ->> @key::TEXT = ANY(@field_values::TEXT[])
+);
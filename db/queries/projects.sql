@@ -1,27 +1,226 @@
--- name: ListProjects :many
-SELECT id, org_id, name, description, created_by, created_at, updated_at
-FROM projects
-WHERE org_id = $1
-ORDER BY created_at ASC;
+-- name: ListProjectsByNameAsc :many
+-- One page of the projects the user can see (every one with
+-- all_projects), filtered, in name ASC order then by id. The page
+-- starts after the cursor (@after_id, @after_name) unless @after_id is empty.
+-- Each sort has its own query so the keyset and the ORDER BY can use the
+-- matching (org_id, column, id) index.
+SELECT p.id, p.org_id, p.name, p.description, p.created_by, p.created_at, p.updated_at, p.deleted_at, p.tags, p.custom_fields, p.archived_at, p.version
+FROM projects p
+WHERE p.org_id = @org_id AND p.deleted_at IS NULL
+  AND (@all_projects::BOOLEAN OR EXISTS (
+    SELECT 1 FROM project_grants g
+    WHERE g.project_id = p.id
+      AND (g.user_id = @user_id OR g.team_id IN (SELECT tm.team_id FROM team_members tm WHERE tm.user_id = @user_id))
+  ))
+  AND (@q::TEXT = '' OR to_tsvector('simple', p.name || ' ' || COALESCE(p.description, '')) @@ websearch_to_tsquery('simple', @q::TEXT))
+  AND (@tag::TEXT = '' OR p.tags @> ARRAY[@tag::TEXT])
+  AND (@created_by::TEXT = '' OR p.created_by = @created_by::TEXT)
+  AND (sqlc.narg('archived')::BOOLEAN IS NULL OR (p.archived_at IS NOT NULL) = sqlc.narg('archived')::BOOLEAN)
+  AND (@after_id::TEXT = '' OR (p.name, p.id) > (@after_name::TEXT, @after_id::TEXT))
+ORDER BY p.name ASC, p.id ASC
+LIMIT @query_limit;
+
+-- name: ListProjectsByNameDesc :many
+-- Like ListProjectsByNameAsc, in name DESC order.
+SELECT p.id, p.org_id, p.name, p.description, p.created_by, p.created_at, p.updated_at, p.deleted_at, p.tags, p.custom_fields, p.archived_at, p.version
+FROM projects p
+WHERE p.org_id = @org_id AND p.deleted_at IS NULL
+  AND (@all_projects::BOOLEAN OR EXISTS (
+    SELECT 1 FROM project_grants g
+    WHERE g.project_id = p.id
+      AND (g.user_id = @user_id OR g.team_id IN (SELECT tm.team_id FROM team_members tm WHERE tm.user_id = @user_id))
+  ))
+  AND (@q::TEXT = '' OR to_tsvector('simple', p.name || ' ' || COALESCE(p.description, '')) @@ websearch_to_tsquery('simple', @q::TEXT))
+  AND (@tag::TEXT = '' OR p.tags @> ARRAY[@tag::TEXT])
+  AND (@created_by::TEXT = '' OR p.created_by = @created_by::TEXT)
+  AND (sqlc.narg('archived')::BOOLEAN IS NULL OR (p.archived_at IS NOT NULL) = sqlc.narg('archived')::BOOLEAN)
+  AND (@after_id::TEXT = '' OR (p.name, p.id) < (@after_name::TEXT, @after_id::TEXT))
+ORDER BY p.name DESC, p.id DESC
+LIMIT @query_limit;
+
+-- name: ListProjectsByCreatedAsc :many
+-- Like ListProjectsByNameAsc, in created_at ASC order.
+SELECT p.id, p.org_id, p.name, p.description, p.created_by, p.created_at, p.updated_at, p.deleted_at, p.tags, p.custom_fields, p.archived_at, p.version
+FROM projects p
+WHERE p.org_id = @org_id AND p.deleted_at IS NULL
+  AND (@all_projects::BOOLEAN OR EXISTS (
+    SELECT 1 FROM project_grants g
+    WHERE g.project_id = p.id
+      AND (g.user_id = @user_id OR g.team_id IN (SELECT tm.team_id FROM team_members tm WHERE tm.user_id = @user_id))
+  ))
+  AND (@q::TEXT = '' OR to_tsvector('simple', p.name || ' ' || COALESCE(p.description, '')) @@ websearch_to_tsquery('simple', @q::TEXT))
+  AND (@tag::TEXT = '' OR p.tags @> ARRAY[@tag::TEXT])
+  AND (@created_by::TEXT = '' OR p.created_by = @created_by::TEXT)
+  AND (sqlc.narg('archived')::BOOLEAN IS NULL OR (p.archived_at IS NOT NULL) = sqlc.narg('archived')::BOOLEAN)
+  AND (@after_id::TEXT = '' OR (p.created_at, p.id) > (@after_time::TIMESTAMPTZ, @after_id::TEXT))
+ORDER BY p.created_at ASC, p.id ASC
+LIMIT @query_limit;
+
+-- name: ListProjectsByCreatedDesc :many
+-- Like ListProjectsByNameAsc, in created_at DESC order.
+SELECT p.id, p.org_id, p.name, p.description, p.created_by, p.created_at, p.updated_at, p.deleted_at, p.tags, p.custom_fields, p.archived_at, p.version
+FROM projects p
+WHERE p.org_id = @org_id AND p.deleted_at IS NULL
+  AND (@all_projects::BOOLEAN OR EXISTS (
+    SELECT 1 FROM project_grants g
+    WHERE g.project_id = p.id
+      AND (g.user_id = @user_id OR g.team_id IN (SELECT tm.team_id FROM team_members tm WHERE tm.user_id = @user_id))
+  ))
+  AND (@q::TEXT = '' OR to_tsvector('simple', p.name || ' ' || COALESCE(p.description, '')) @@ websearch_to_tsquery('simple', @q::TEXT))
+  AND (@tag::TEXT = '' OR p.tags @> ARRAY[@tag::TEXT])
+  AND (@created_by::TEXT = '' OR p.created_by = @created_by::TEXT)
+  AND (sqlc.narg('archived')::BOOLEAN IS NULL OR (p.archived_at IS NOT NULL) = sqlc.narg('archived')::BOOLEAN)
+  AND (@after_id::TEXT = '' OR (p.created_at, p.id) < (@after_time::TIMESTAMPTZ, @after_id::TEXT))
+ORDER BY p.created_at DESC, p.id DESC
+LIMIT @query_limit;
+
+-- name: ListProjectsByUpdatedAsc :many
+-- Like ListProjectsByNameAsc, in updated_at ASC order.
+SELECT p.id, p.org_id, p.name, p.description, p.created_by, p.created_at, p.updated_at, p.deleted_at, p.tags, p.custom_fields, p.archived_at, p.version
+FROM projects p
+WHERE p.org_id = @org_id AND p.deleted_at IS NULL
+  AND (@all_projects::BOOLEAN OR EXISTS (
+    SELECT 1 FROM project_grants g
+    WHERE g.project_id = p.id
+      AND (g.user_id = @user_id OR g.team_id IN (SELECT tm.team_id FROM team_members tm WHERE tm.user_id = @user_id))
+  ))
+  AND (@q::TEXT = '' OR to_tsvector('simple', p.name || ' ' || COALESCE(p.description, '')) @@ websearch_to_tsquery('simple', @q::TEXT))
+  AND (@tag::TEXT = '' OR p.tags @> ARRAY[@tag::TEXT])
+  AND (@created_by::TEXT = '' OR p.created_by = @created_by::TEXT)
+  AND (sqlc.narg('archived')::BOOLEAN IS NULL OR (p.archived_at IS NOT NULL) = sqlc.narg('archived')::BOOLEAN)
+  AND (@after_id::TEXT = '' OR (p.updated_at, p.id) > (@after_time::TIMESTAMPTZ, @after_id::TEXT))
+ORDER BY p.updated_at ASC, p.id ASC
+LIMIT @query_limit;
+
+-- name: ListProjectsByUpdatedDesc :many
+-- Like ListProjectsByNameAsc, in updated_at DESC order.
+SELECT p.id, p.org_id, p.name, p.description, p.created_by, p.created_at, p.updated_at, p.deleted_at, p.tags, p.custom_fields, p.archived_at, p.version
+FROM projects p
+WHERE p.org_id = @org_id AND p.deleted_at IS NULL
+  AND (@all_projects::BOOLEAN OR EXISTS (
+    SELECT 1 FROM project_grants g
+    WHERE g.project_id = p.id
+      AND (g.user_id = @user_id OR g.team_id IN (SELECT tm.team_id FROM team_members tm WHERE tm.user_id = @user_id))
+  ))
+  AND (@q::TEXT = '' OR to_tsvector('simple', p.name || ' ' || COALESCE(p.description, '')) @@ websearch_to_tsquery('simple', @q::TEXT))
+  AND (@tag::TEXT = '' OR p.tags @> ARRAY[@tag::TEXT])
+  AND (@created_by::TEXT = '' OR p.created_by = @created_by::TEXT)
+  AND (sqlc.narg('archived')::BOOLEAN IS NULL OR (p.archived_at IS NOT NULL) = sqlc.narg('archived')::BOOLEAN)
+  AND (@after_id::TEXT = '' OR (p.updated_at, p.id) < (@after_time::TIMESTAMPTZ, @after_id::TEXT))
+ORDER BY p.updated_at DESC, p.id DESC
+LIMIT @query_limit;
+
+-- name: ListGrantedProjectIDs :many
+-- Projects granted to the user directly or through one of their teams.
+SELECT p.id
+FROM projects p
+WHERE p.org_id = @org_id AND p.deleted_at IS NULL AND EXISTS (
+  SELECT 1 FROM project_grants g
+  WHERE g.project_id = p.id
+    AND (g.user_id = @user_id OR g.team_id IN (SELECT tm.team_id FROM team_members tm WHERE tm.user_id = @user_id))
+);
 
 -- name: InsertProject :one
-INSERT INTO projects (id, org_id, name, description, created_by, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
-RETURNING id, org_id, name, description, created_by, created_at, updated_at;
+INSERT INTO projects (id, org_id, name, description, created_by, tags, custom_fields, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+RETURNING id, org_id, name, description, created_by, created_at, updated_at, deleted_at, tags, custom_fields, archived_at, version;
 
 -- name: GetProject :one
-SELECT id, org_id, name, description, created_by, created_at, updated_at
+SELECT id, org_id, name, description, created_by, created_at, updated_at, deleted_at, tags, custom_fields, archived_at, version
 FROM projects
-WHERE id = $1 AND org_id = $2;
+WHERE id = $1 AND org_id = $2 AND deleted_at IS NULL;
+
+-- name: GetProjectForUpdate :one
+-- Locks the project until the end of the transaction.
+SELECT id, org_id, name, description, created_by, created_at, updated_at, deleted_at, tags, custom_fields, archived_at, version
+FROM projects
+WHERE id = $1 AND org_id = $2 AND deleted_at IS NULL
+FOR UPDATE;
 
 -- name: UpdateProject :one
 UPDATE projects
-SET name = COALESCE(NULLIF($3, ''), name),
+SET name = $3,
     description = $4,
-    updated_at = NOW()
-WHERE id = $1 AND org_id = $2
-RETURNING id, org_id, name, description, created_by, created_at, updated_at;
+    tags = $5,
+    custom_fields = $6,
+    updated_at = NOW(),
+    version = version + 1
+WHERE id = $1 AND org_id = $2 AND deleted_at IS NULL
+RETURNING id, org_id, name, description, created_by, created_at, updated_at, deleted_at, tags, custom_fields, archived_at, version;
+
+-- name: ArchiveProject :one
+-- Archiving an archived project keeps its first archived_at.
+UPDATE projects
+SET archived_at = COALESCE(archived_at, NOW()), updated_at = NOW(), version = version + 1
+WHERE id = $1 AND org_id = $2 AND deleted_at IS NULL
+RETURNING id, org_id, name, description, created_by, created_at, updated_at, deleted_at, tags, custom_fields, archived_at, version;
+
+-- name: UnarchiveProject :one
+UPDATE projects
+SET archived_at = NULL, updated_at = NOW(), version = version + 1
+WHERE id = $1 AND org_id = $2 AND deleted_at IS NULL
+RETURNING id, org_id, name, description, created_by, created_at, updated_at, deleted_at, tags, custom_fields, archived_at, version;
 
--- name: DeleteProject :execresult
+-- name: SoftDeleteProject :execrows
+-- Applies only while the project is at @if_version, unless that is 0.
+UPDATE projects
+SET deleted_at = NOW(), version = version + 1
+WHERE id = @id AND org_id = @org_id AND deleted_at IS NULL
+  AND (@if_version::BIGINT = 0 OR version = @if_version);
+
+-- name: RestoreProject :one
+-- Undoes a soft delete made after @deleted_since.
+UPDATE projects
+SET deleted_at = NULL, updated_at = NOW(), version = version + 1
+WHERE id = @id AND org_id = @org_id AND deleted_at > @deleted_since
+RETURNING id, org_id, name, description, created_by, created_at, updated_at, deleted_at, tags, custom_fields, archived_at, version;
+
+-- name: ListPurgeableProjects :many
+-- Projects soft-deleted before @deleted_before, oldest first.
+SELECT id, org_id
+FROM projects
+WHERE deleted_at < @deleted_before
+ORDER BY deleted_at
+LIMIT @query_limit;
+
+-- name: PurgeProject :execrows
+-- Removes a project soft-deleted before @deleted_before; its files and
+-- grants cascade.
 DELETE FROM projects
-WHERE id = $1 AND org_id = $2;
+WHERE id = @id AND deleted_at < @deleted_before;
+
+-- name: GetProjectAccess :one
+-- The highest grant the user holds on the project, directly or through a
+-- team: 0 none, 1 viewer, 2 editor, 3 admin.
+SELECT COALESCE(MAX(CASE g.access WHEN 'admin' THEN 3 WHEN 'editor' THEN 2 ELSE 1 END), 0)::INT AS level
+FROM project_grants g
+WHERE g.project_id = @project_id
+  AND (g.user_id = @user_id OR g.team_id IN (SELECT tm.team_id FROM team_members tm WHERE tm.user_id = @user_id));
+
+-- name: ListProjectGrants :many
+SELECT id, project_id, user_id, team_id, access, created_at
+FROM project_grants
+WHERE project_id = $1
+ORDER BY created_at;
+
+-- name: UpsertProjectUserGrant :one
+INSERT INTO project_grants (project_id, user_id, access)
+VALUES ($1, $2, $3)
+ON CONFLICT (project_id, user_id) WHERE user_id IS NOT NULL DO UPDATE SET access = EXCLUDED.access
+RETURNING id, project_id, user_id, team_id, access, created_at;
+
+-- name: UpsertProjectTeamGrant :one
+INSERT INTO project_grants (project_id, team_id, access)
+VALUES ($1, $2, $3)
+ON CONFLICT (project_id, team_id) WHERE team_id IS NOT NULL DO UPDATE SET access = EXCLUDED.access
+RETURNING id, project_id, user_id, team_id, access, created_at;
+
+-- name: DeleteProjectGrant :execrows
+DELETE FROM project_grants
+WHERE id = $1 AND project_id = $2;
+
+-- name: DeleteUserProjectGrants :exec
+-- Drops the user's grants on the org's projects, once they leave it.
+DELETE FROM project_grants g
+USING projects p
+WHERE g.project_id = p.id AND p.org_id = $1 AND g.user_id = $2;
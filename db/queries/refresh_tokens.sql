@@ -1,17 +1,24 @@
 -- name: InsertRefreshToken :exec
-INSERT INTO refresh_tokens (id, user_id, token_hash, expires_at, rotated_from)
-VALUES ($1, $2, $3, $4, $5);
+INSERT INTO refresh_tokens (id, user_id, token_hash, expires_at, rotated_from, family_id)
+VALUES ($1, $2, $3, $4, $5, $6);
 
--- name: GetRefreshToken :one
-SELECT user_id, token_hash, expires_at, revoked_at
-FROM refresh_tokens
-WHERE id = $1;
+-- name: GetRefreshTokenForUpdate :one
+SELECT r.user_id, r.token_hash, r.expires_at, r.revoked_at, r.family_id,
+       EXISTS (SELECT 1 FROM refresh_tokens c WHERE c.rotated_from = r.id) AS rotated
+FROM refresh_tokens r
+WHERE r.id = $1
+FOR UPDATE;
 
 -- name: RevokeToken :exec
 UPDATE refresh_tokens
 SET revoked_at = NOW()
 WHERE id = $1 AND revoked_at IS NULL;
 
+-- name: RevokeTokenFamily :execresult
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE family_id = $1 AND revoked_at IS NULL;
+
 -- name: RevokeAllUserTokens :exec
 UPDATE refresh_tokens
 SET revoked_at = NOW()
@@ -0,0 +1,30 @@
+-- name: ListRoles :many
+-- Built-in presets first, then the org's custom roles.
+SELECT id, org_id, name, description, permissions, created_at, updated_at
+FROM roles
+WHERE org_id = @org_id::TEXT OR org_id IS NULL
+ORDER BY org_id IS NOT NULL, name;
+
+-- name: GetRole :one
+SELECT id, org_id, name, description, permissions, created_at, updated_at
+FROM roles
+WHERE (org_id = @org_id::TEXT OR org_id IS NULL) AND name = @name;
+
+-- name: CreateRole :one
+-- Returns no row when the org already has a role by that name.
+INSERT INTO roles (org_id, name, description, permissions)
+VALUES (@org_id::TEXT, @name, @description, @permissions)
+ON CONFLICT DO NOTHING
+RETURNING id, org_id, name, description, permissions, created_at, updated_at;
+
+-- name: UpdateRole :one
+UPDATE roles SET description = @description, permissions = @permissions, updated_at = now()
+WHERE org_id = @org_id::TEXT AND name = @name
+RETURNING id, org_id, name, description, permissions, created_at, updated_at;
+
+-- name: DeleteRole :execrows
+-- Only deletes roles no member or active API key holds.
+DELETE FROM roles r
+WHERE r.org_id = @org_id::TEXT AND r.name = @name
+  AND NOT EXISTS (SELECT 1 FROM memberships m WHERE m.org_id = r.org_id AND m.role = r.name)
+  AND NOT EXISTS (SELECT 1 FROM api_keys k WHERE k.org_id = r.org_id AND k.role = r.name AND k.revoked_at IS NULL);
@@ -0,0 +1,117 @@
+-- name: CreateSCIMToken :one
+INSERT INTO scim_tokens (org_id, name, token_prefix, token_hash, created_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, org_id, name, token_prefix, created_by, created_at;
+
+-- name: ListSCIMTokens :many
+SELECT id, org_id, name, token_prefix, created_by, created_at, last_used_at
+FROM scim_tokens
+WHERE org_id = $1 AND revoked_at IS NULL
+ORDER BY created_at DESC;
+
+-- name: RevokeSCIMToken :execresult
+UPDATE scim_tokens SET revoked_at = now()
+WHERE id = $1 AND org_id = $2 AND revoked_at IS NULL;
+
+-- name: GetSCIMTokenByHash :one
+SELECT t.id, t.org_id,
+       (o.deleted_at IS NOT NULL)::boolean AS org_deleted,
+       (o.suspended_at IS NOT NULL)::boolean AS org_suspended
+FROM scim_tokens t
+JOIN organizations o ON o.id = t.org_id
+WHERE t.token_hash = $1 AND t.revoked_at IS NULL;
+
+-- name: TouchSCIMTokenLastUsed :exec
+UPDATE scim_tokens SET last_used_at = now() WHERE id = $1;
+
+-- name: InsertSCIMUser :one
+INSERT INTO scim_users (org_id, user_id, user_name, external_id, display_name, given_name, family_name, role, active)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING org_id, user_id, user_name, external_id, display_name, given_name, family_name, role, active, created_at, updated_at;
+
+-- name: GetSCIMUser :one
+SELECT org_id, user_id, user_name, external_id, display_name, given_name, family_name, role, active, created_at, updated_at
+FROM scim_users
+WHERE org_id = $1 AND user_id = $2;
+
+-- name: ListSCIMUsers :many
+SELECT su.org_id, su.user_id, su.user_name, su.external_id, su.display_name, su.given_name, su.family_name, su.role, su.active, su.created_at, su.updated_at, u.email
+FROM scim_users su
+JOIN users u ON u.id = su.user_id
+WHERE su.org_id = $1
+ORDER BY su.created_at, su.user_id;
+
+-- name: UpdateSCIMUser :one
+UPDATE scim_users
+SET user_name = $3,
+    external_id = $4,
+    display_name = $5,
+    given_name = $6,
+    family_name = $7,
+    role = $8,
+    active = $9,
+    updated_at = now()
+WHERE org_id = $1 AND user_id = $2
+RETURNING org_id, user_id, user_name, external_id, display_name, given_name, family_name, role, active, created_at, updated_at;
+
+-- name: DeleteSCIMUser :execresult
+DELETE FROM scim_users
+WHERE org_id = $1 AND user_id = $2;
+
+-- name: InsertSCIMGroup :one
+INSERT INTO scim_groups (org_id, display_name, external_id, role)
+VALUES ($1, $2, $3, $4)
+RETURNING id, org_id, display_name, external_id, role, created_at, updated_at;
+
+-- name: GetSCIMGroup :one
+SELECT id, org_id, display_name, external_id, role, created_at, updated_at
+FROM scim_groups
+WHERE org_id = $1 AND id = $2;
+
+-- name: ListSCIMGroups :many
+SELECT id, org_id, display_name, external_id, role, created_at, updated_at
+FROM scim_groups
+WHERE org_id = $1
+ORDER BY created_at, id;
+
+-- name: UpdateSCIMGroup :one
+UPDATE scim_groups
+SET display_name = $3,
+    external_id = $4,
+    role = $5,
+    updated_at = now()
+WHERE org_id = $1 AND id = $2
+RETURNING id, org_id, display_name, external_id, role, created_at, updated_at;
+
+-- name: DeleteSCIMGroup :execresult
+DELETE FROM scim_groups
+WHERE org_id = $1 AND id = $2;
+
+-- name: ListSCIMGroupMembers :many
+SELECT m.group_id, m.user_id, su.user_name
+FROM scim_group_members m
+JOIN scim_groups g ON g.id = m.group_id
+JOIN scim_users su ON su.org_id = g.org_id AND su.user_id = m.user_id
+WHERE g.org_id = $1
+ORDER BY su.user_name;
+
+-- name: AddSCIMGroupMember :exec
+INSERT INTO scim_group_members (group_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveSCIMGroupMember :exec
+DELETE FROM scim_group_members
+WHERE group_id = $1 AND user_id = $2;
+
+-- name: ListSCIMGroupsForUser :many
+SELECT g.id, g.display_name, g.role
+FROM scim_group_members m
+JOIN scim_groups g ON g.id = m.group_id
+WHERE g.org_id = $1 AND m.user_id = $2
+ORDER BY g.display_name;
+
+-- name: DeleteSCIMGroupMembershipsForUser :exec
+DELETE FROM scim_group_members m
+USING scim_groups g
+WHERE g.id = m.group_id AND g.org_id = $1 AND m.user_id = $2;
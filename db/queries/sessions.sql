@@ -0,0 +1,23 @@
+-- name: InsertSession :exec
+INSERT INTO sessions (id, user_id, user_agent, ip, geo_label)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: TouchSession :exec
+UPDATE sessions
+SET user_agent = $2, ip = $3, geo_label = $4, last_used_at = NOW()
+WHERE id = $1;
+
+-- name: ListActiveSessions :many
+SELECT s.id, s.user_id, s.user_agent, s.ip, s.geo_label, s.created_at, s.last_used_at
+FROM sessions s
+WHERE s.user_id = $1
+  AND EXISTS (
+    SELECT 1 FROM refresh_tokens r
+    WHERE r.family_id = s.id AND r.revoked_at IS NULL AND r.expires_at > NOW()
+  )
+ORDER BY s.last_used_at DESC;
+
+-- name: RevokeUserTokenFamily :execresult
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE family_id = $1 AND user_id = $2 AND revoked_at IS NULL;
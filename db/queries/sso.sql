@@ -0,0 +1,69 @@
+-- name: UpsertSAMLConfig :one
+INSERT INTO org_saml_configs (org_id, idp_entity_id, idp_sso_url, idp_certificate, attribute_mapping, enforce_sso, enabled)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (org_id) DO UPDATE
+SET idp_entity_id = EXCLUDED.idp_entity_id,
+    idp_sso_url = EXCLUDED.idp_sso_url,
+    idp_certificate = EXCLUDED.idp_certificate,
+    attribute_mapping = EXCLUDED.attribute_mapping,
+    enforce_sso = EXCLUDED.enforce_sso,
+    enabled = EXCLUDED.enabled,
+    updated_at = now()
+RETURNING org_id, idp_entity_id, idp_sso_url, idp_certificate, attribute_mapping, enforce_sso, enabled, created_at, updated_at;
+
+-- name: GetSAMLConfig :one
+SELECT org_id, idp_entity_id, idp_sso_url, idp_certificate, attribute_mapping, enforce_sso, enabled, created_at, updated_at
+FROM org_saml_configs
+WHERE org_id = $1;
+
+-- name: InsertOrgDomain :one
+INSERT INTO org_domains (org_id, domain, verification_token)
+VALUES ($1, $2, $3)
+RETURNING org_id, domain, verification_token, verified_at, created_at;
+
+-- name: GetOrgDomain :one
+SELECT org_id, domain, verification_token, verified_at, created_at
+FROM org_domains
+WHERE org_id = $1 AND domain = $2;
+
+-- name: ListOrgDomains :many
+SELECT org_id, domain, verification_token, verified_at, created_at
+FROM org_domains
+WHERE org_id = $1
+ORDER BY domain;
+
+-- name: MarkOrgDomainVerified :one
+UPDATE org_domains
+SET verified_at = COALESCE(verified_at, now())
+WHERE org_id = $1 AND domain = $2
+RETURNING org_id, domain, verification_token, verified_at, created_at;
+
+-- name: DeleteOrgDomain :execresult
+DELETE FROM org_domains
+WHERE org_id = $1 AND domain = $2;
+
+-- name: GetVerifiedDomainSSO :one
+SELECT d.org_id, c.enforce_sso, c.enabled
+FROM org_domains d
+LEFT JOIN org_saml_configs c ON c.org_id = d.org_id
+WHERE d.domain = $1 AND d.verified_at IS NOT NULL;
+
+-- name: InsertSAMLRequest :exec
+INSERT INTO saml_requests (id, org_id, expires_at)
+VALUES ($1, $2, $3);
+
+-- name: ConsumeSAMLRequest :one
+DELETE FROM saml_requests
+WHERE id = $1 AND org_id = $2 AND expires_at > now()
+RETURNING id, org_id, expires_at, created_at;
+
+-- name: InsertSAMLAssertionSeen :execresult
+INSERT INTO saml_assertions_seen (id, expires_at)
+VALUES ($1, $2)
+ON CONFLICT (id) DO NOTHING;
+
+-- name: DeleteExpiredSAMLRequests :exec
+DELETE FROM saml_requests WHERE expires_at < now();
+
+-- name: DeleteExpiredSAMLAssertions :exec
+DELETE FROM saml_assertions_seen WHERE expires_at < now();
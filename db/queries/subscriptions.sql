@@ -28,3 +28,24 @@ WHERE stripe_subscription_id = $1;
 UPDATE subscriptions
 SET status = 'canceled', updated_at = now()
 WHERE stripe_subscription_id = $1;
+
+-- name: GetPlanOverride :one
+SELECT org_id, plan, reason, set_by, created_at
+FROM plan_overrides
+WHERE org_id = $1;
+
+-- name: UpsertPlanOverride :exec
+INSERT INTO plan_overrides (org_id, plan, reason, set_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (org_id) DO UPDATE SET
+    plan = EXCLUDED.plan,
+    reason = EXCLUDED.reason,
+    set_by = EXCLUDED.set_by,
+    created_at = now();
+
+-- name: DeletePlanOverride :execrows
+DELETE FROM plan_overrides WHERE org_id = $1;
+
+-- name: DeleteOrgSubscription :exec
+DELETE FROM subscriptions
+WHERE org_id = $1;
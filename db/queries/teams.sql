@@ -0,0 +1,44 @@
+-- name: CreateTeam :one
+-- Returns no row when the org already has a team by that name.
+INSERT INTO teams (org_id, name, description)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING
+RETURNING id, org_id, name, description, created_at;
+
+-- name: ListTeams :many
+SELECT t.id, t.org_id, t.name, t.description, t.created_at,
+       (SELECT count(*) FROM team_members tm WHERE tm.team_id = t.id) AS member_count
+FROM teams t
+WHERE t.org_id = $1
+ORDER BY t.name;
+
+-- name: GetTeam :one
+SELECT id, org_id, name, description, created_at
+FROM teams
+WHERE id = $1 AND org_id = $2;
+
+-- name: DeleteTeam :execrows
+DELETE FROM teams
+WHERE id = $1 AND org_id = $2;
+
+-- name: AddTeamMember :exec
+INSERT INTO team_members (team_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveTeamMember :execrows
+DELETE FROM team_members
+WHERE team_id = $1 AND user_id = $2;
+
+-- name: ListTeamMembers :many
+SELECT tm.user_id, u.email, u.display_name, tm.created_at
+FROM team_members tm
+JOIN users u ON u.id = tm.user_id
+WHERE tm.team_id = $1
+ORDER BY u.email;
+
+-- name: DeleteUserTeamMemberships :exec
+-- Drops the user from every team of the org, once they leave it.
+DELETE FROM team_members tm
+USING teams t
+WHERE tm.team_id = t.id AND t.org_id = $1 AND tm.user_id = $2;
@@ -3,11 +3,47 @@ INSERT INTO users (id, email, password_hash, created_at)
 VALUES ($1, $2, $3, $4);
 
 -- name: GetUserByEmail :one
-SELECT id, email, password_hash
+SELECT id, email, password_hash, email_verified_at, display_name, avatar_file_id, locale, timezone, suspended_at
 FROM users
 WHERE email = $1;
 
 -- name: GetUserByID :one
-SELECT id, email, password_hash
+SELECT id, email, password_hash, email_verified_at, display_name, avatar_file_id, locale, timezone, suspended_at
 FROM users
 WHERE id = $1;
+
+-- name: MarkUserEmailVerified :exec
+UPDATE users SET email_verified_at = COALESCE(email_verified_at, now()) WHERE id = $1;
+
+-- name: UpdateUserProfile :exec
+UPDATE users
+SET display_name = $2, avatar_file_id = $3, locale = $4, timezone = $5
+WHERE id = $1;
+
+-- name: UpdateUserEmail :exec
+UPDATE users SET email = $2, email_verified_at = now() WHERE id = $1;
+
+-- name: AnonymizeProjectsCreatedBy :exec
+-- Account deletion: rows that outlive the user move to the 'deleted-user'
+-- placeholder; everything else cascades from DELETE FROM users.
+UPDATE projects SET created_by = 'deleted-user', version = version + 1 WHERE created_by = $1;
+
+-- name: AnonymizeFilesUploadedBy :exec
+UPDATE files SET uploaded_by = 'deleted-user', version = version + 1 WHERE uploaded_by = $1;
+
+-- name: AnonymizeAPIKeysCreatedBy :exec
+UPDATE api_keys SET created_by = 'deleted-user' WHERE created_by = $1;
+
+-- name: AnonymizeAuditActor :exec
+UPDATE audit_logs
+SET actor_id = 'deleted-user',
+    metadata = metadata #- '{before,ip}' #- '{before,user_agent}' #- '{after,ip}' #- '{after,user_agent}'
+WHERE actor_id = $1;
+
+-- name: AnonymizeAuditSubject :exec
+UPDATE audit_logs
+SET entity_id = 'deleted-user'
+WHERE entity_id = @user_id OR (entity = 'account' AND entity_id = @email);
+
+-- name: DeleteUser :exec
+DELETE FROM users WHERE id = $1;
@@ -1,21 +1,22 @@
 -- name: CreateWebhookEndpoint :one
 INSERT INTO webhook_endpoints (org_id, url, secret, events)
 VALUES ($1, $2, $3, $4)
-RETURNING id, org_id, url, events, active, created_at, updated_at;
+RETURNING id, org_id, url, events, active, created_at, updated_at, version;
 
 -- name: ListWebhookEndpoints :many
-SELECT id, org_id, url, events, active, created_at, updated_at
+SELECT id, org_id, url, events, active, created_at, updated_at, version
 FROM webhook_endpoints
 WHERE org_id = $1
 ORDER BY created_at DESC;
 
--- name: UpdateWebhookEndpoint :exec
+-- name: UpdateWebhookEndpoint :execrows
+-- Applies only while the endpoint is at @if_version, unless that is 0.
 UPDATE webhook_endpoints
-SET url = $3, events = $4, active = $5, updated_at = now()
-WHERE id = $1 AND org_id = $2;
+SET url = @url, events = @events, active = @active, updated_at = now(), version = version + 1
+WHERE id = @id AND org_id = @org_id AND (@if_version::BIGINT = 0 OR version = @if_version);
 
 -- name: GetWebhookEndpoint :one
-SELECT id, org_id, url, secret, events, active, created_at, updated_at
+SELECT id, org_id, url, secret, events, active, created_at, updated_at, version
 FROM webhook_endpoints
 WHERE id = $1 AND org_id = $2;
 
@@ -47,3 +48,8 @@ WHERE id = $1;
 UPDATE webhook_deliveries
 SET attempts = attempts + 1, status_code = $2, response = $3, next_retry = $4
 WHERE id = $1;
+
+-- name: DeleteOrgWebhookEndpoints :exec
+-- Their deliveries cascade.
+DELETE FROM webhook_endpoints
+WHERE org_id = $1;
@@ -2596,9 +2596,6 @@ const docTemplate = `{
                 "key"
             ],
             "properties": {
-                "bucket": {
-                    "type": "string"
-                },
                 "content_type": {
                     "type": "string"
                 },
@@ -2644,9 +2641,6 @@ const docTemplate = `{
                 "key"
             ],
             "properties": {
-                "bucket": {
-                    "type": "string"
-                },
                 "expires": {
                     "type": "integer"
                 },
@@ -2662,9 +2656,6 @@ const docTemplate = `{
                 "key"
             ],
             "properties": {
-                "bucket": {
-                    "type": "string"
-                },
                 "content_type": {
                     "type": "string"
                 },
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+var (
+	ErrEmailChangeTokenInvalid = errors.New("invalid or expired email change token")
+)
+
+// EmailChange is a pending email change confirmed by its token.
+type EmailChange struct {
+	UserID   string
+	NewEmail string
+}
+
+// EmailChangeStore keeps email change requests until the new address is
+// verified. Tokens are stored hashed, like password reset tokens.
+type EmailChangeStore interface {
+	Create(userID, newEmail string) (plainToken string, err error)
+	Consume(token string) (EmailChange, error)
+}
+
+type emailChangeStore struct {
+	q *repo.Queries
+}
+
+func NewEmailChangeStore(q *repo.Queries) EmailChangeStore {
+	return &emailChangeStore{q: q}
+}
+
+func (s *emailChangeStore) Create(userID, newEmail string) (string, error) {
+	plain, err := generateResetToken()
+	if err != nil {
+		return "", err
+	}
+	ctx := context.Background()
+	// only the newest link may confirm a change
+	if err := s.q.InvalidateEmailChangeTokens(ctx, userID); err != nil {
+		return "", err
+	}
+	err = s.q.CreateEmailChangeToken(ctx, repo.CreateEmailChangeTokenParams{
+		UserID:    userID,
+		NewEmail:  newEmail,
+		TokenHash: hashResetToken(plain),
+		ExpiresAt: time.Now().Add(24 * time.Hour),
+	})
+	if err != nil {
+		return "", err
+	}
+	return plain, nil
+}
+
+func (s *emailChangeStore) Consume(token string) (EmailChange, error) {
+	row, err := s.q.ConsumeEmailChangeToken(context.Background(), hashResetToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return EmailChange{}, ErrEmailChangeTokenInvalid
+	}
+	if err != nil {
+		return EmailChange{}, err
+	}
+	return EmailChange{UserID: row.UserID, NewEmail: row.NewEmail}, nil
+}
@@ -15,22 +15,78 @@ type TokenPair struct {
 type Claims struct {
 	UserID    string `json:"uid"`
 	TokenType string `json:"typ"`
+
+	// Org-scoped access tokens only: the org the token was minted for, the
+	// caller's role in it and the membership version at that time.
+	OrgID             string `json:"org_id,omitempty"`
+	Role              string `json:"role,omitempty"`
+	MembershipVersion int64  `json:"mv,omitempty"`
+
+	// Impersonation tokens only: the platform admin acting as UserID.
+	Act *Actor `json:"act,omitempty"`
+
 	jwt.RegisteredClaims
 }
 
-func NewAccessToken(userID, secret string, ttlMinutes int) (string, error) {
+// Actor is the "act" claim of RFC 8693: the party acting on behalf of the
+// token's subject.
+type Actor struct {
+	Subject string `json:"sub"`
+}
+
+// OrgScoped reports whether the token was minted for a single org.
+func (c *Claims) OrgScoped() bool {
+	return c.OrgID != ""
+}
+
+// Impersonated reports whether the token was minted for a platform admin
+// acting as the user.
+func (c *Claims) Impersonated() bool {
+	return c.Act != nil && c.Act.Subject != ""
+}
+
+// NewAccessToken signs an access token with the keyring's active key and
+// sets its kid header.
+func NewAccessToken(userID string, kr *Keyring, ttlMinutes int) (string, error) {
+	return signAccess(Claims{UserID: userID}, kr, ttlMinutes)
+}
+
+// NewOrgAccessToken signs an access token bound to one org. version is the
+// membership version it was minted against; a later membership change makes
+// the token stale.
+func NewOrgAccessToken(userID, orgID, role string, version int64, kr *Keyring, ttlMinutes int) (string, error) {
+	return signAccess(Claims{
+		UserID:            userID,
+		OrgID:             orgID,
+		Role:              role,
+		MembershipVersion: version,
+	}, kr, ttlMinutes)
+}
+
+// NewImpersonationToken signs an access token for userID on behalf of the
+// platform admin actorID. No refresh token goes with it, so the session ends
+// when it expires.
+func NewImpersonationToken(userID, actorID string, kr *Keyring, ttlMinutes int) (string, error) {
+	return signAccess(Claims{UserID: userID, Act: &Actor{Subject: actorID}}, kr, ttlMinutes)
+}
+
+func signAccess(claims Claims, kr *Keyring, ttlMinutes int) (string, error) {
+	key, err := kr.signer()
+	if err != nil {
+		return "", err
+	}
 	now := time.Now()
-	claims := Claims{
-		UserID:    userID,
-		TokenType: "access",
-		RegisteredClaims: jwt.RegisteredClaims{
-			ID:        uuid.NewString(), // jti (opcional para access)
-			IssuedAt:  jwt.NewNumericDate(now),
-			ExpiresAt: jwt.NewNumericDate(now.Add(time.Duration(ttlMinutes) * time.Minute)),
-		},
+	claims.TokenType = "access"
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        uuid.NewString(), // jti (opcional para access)
+		Issuer:    kr.issuer,
+		Audience:  kr.audience,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(time.Duration(ttlMinutes) * time.Minute)),
 	}
-	t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return t.SignedString([]byte(secret))
+	t := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	t.Header["kid"] = key.ID
+	return t.SignedString(key.Private)
 }
 
 func NewRefreshToken(userID, secret string, ttlDays int) (string, string, time.Time, error) {
@@ -52,10 +108,36 @@ func NewRefreshToken(userID, secret string, ttlDays int) (string, string, time.T
 	return signed, jti, exp, err
 }
 
-func Parse(tokenStr, secret string) (*Claims, error) {
+// Parse verifies an access token against the keyring. Only RS256 and EdDSA
+// are accepted and the kid header must name a verification key. When the
+// keyring has an issuer or audience, the iss and aud claims must match.
+func Parse(tokenStr string, kr *Keyring) (*Claims, error) {
+	opts := []jwt.ParserOption{jwt.WithValidMethods([]string{AlgRS256, AlgEdDSA})}
+	if kr.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(kr.issuer))
+	}
+	if len(kr.audience) > 0 {
+		opts = append(opts, jwt.WithAudience(kr.audience...))
+	}
+	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return kr.verificationKey(kid, t.Method.Alg())
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := token.Claims.(*Claims); ok && token.Valid {
+		return c, nil
+	}
+	return nil, jwt.ErrTokenInvalidClaims
+}
+
+// ParseRefresh verifies a refresh token. Refresh tokens never leave Vergo,
+// so they stay HMAC-signed; the algorithm is pinned to HS256.
+func ParseRefresh(tokenStr, secret string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(t *jwt.Token) (interface{}, error) {
 		return []byte(secret), nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 	if err != nil {
 		return nil, err
 	}
@@ -4,40 +4,68 @@ import (
 	"testing"
 )
 
+func fuzzKeyring(f *testing.F) *Keyring {
+	f.Helper()
+	kr, err := GenerateKeyring()
+	if err != nil {
+		f.Fatalf("GenerateKeyring: %v", err)
+	}
+	return kr
+}
+
 // FuzzParse feeds arbitrary strings into auth.Parse to ensure it never panics
 // and always returns a clean error for invalid input.
 func FuzzParse(f *testing.F) {
 	// Seed corpus: valid-looking tokens, partial tokens, edge cases
+	f.Add("")
+	f.Add("not.a.token")
+	f.Add("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJ1aWQiOiIxMjMiLCJ0eXAiOiJhY2Nlc3MifQ.invalid")
+	f.Add("eyJhbGciOiJub25lIiwidHlwIjoiSldUIn0.eyJ1aWQiOiIxMjMifQ.")
+	f.Add("eyJhbGciOiJFZERTQSIsImtpZCI6Im5vcGUifQ.eyJ1aWQiOiIxMjMifQ.AAAA")
+	f.Add("....")
+	f.Add("a]]]")
+	f.Add("\x00\xff\xfe")
+
+	kr := fuzzKeyring(f)
+	f.Fuzz(func(t *testing.T, tokenStr string) {
+		// Must never panic regardless of input
+		claims, err := Parse(tokenStr, kr)
+		if err == nil && claims == nil {
+			t.Error("Parse returned nil error and nil claims")
+		}
+	})
+}
+
+// FuzzParseRefresh does the same for HMAC refresh tokens.
+func FuzzParseRefresh(f *testing.F) {
 	f.Add("", "secret")
 	f.Add("not.a.token", "secret")
-	f.Add("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJ1aWQiOiIxMjMiLCJ0eXAiOiJhY2Nlc3MifQ.invalid", "secret")
+	f.Add("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJ1aWQiOiIxMjMiLCJ0eXAiOiJyZWZyZXNoIn0.invalid", "secret")
 	f.Add("eyJhbGciOiJub25lIiwidHlwIjoiSldUIn0.eyJ1aWQiOiIxMjMifQ.", "secret")
-	f.Add("....", "secret")
-	f.Add("a]]]", "")
-	f.Add("\x00\xff\xfe", "secret")
+	f.Add("\x00\xff\xfe", "")
 
 	f.Fuzz(func(t *testing.T, tokenStr, secret string) {
-		// Must never panic regardless of input
-		claims, err := Parse(tokenStr, secret)
+		claims, err := ParseRefresh(tokenStr, secret)
 		if err == nil && claims == nil {
-			t.Error("Parse returned nil error and nil claims")
+			t.Error("ParseRefresh returned nil error and nil claims")
 		}
 	})
 }
 
-// FuzzNewAccessToken feeds arbitrary user IDs and secrets to ensure token
+// FuzzNewAccessToken feeds arbitrary user IDs and TTLs to ensure token
 // generation never panics.
 func FuzzNewAccessToken(f *testing.F) {
-	f.Add("user-123", "my-secret", 15)
-	f.Add("", "", 0)
-	f.Add("\x00\xff", "s", 1)
-	f.Add("a]]]{}\"", "secret-with-special!@#$", 525600)
+	f.Add("user-123", 15)
+	f.Add("", 0)
+	f.Add("\x00\xff", 1)
+	f.Add("a]]]{}\"", 525600)
 
-	f.Fuzz(func(t *testing.T, userID, secret string, ttl int) {
+	kr := fuzzKeyring(f)
+	f.Fuzz(func(t *testing.T, userID string, ttl int) {
 		if ttl < 0 || ttl > 525600 {
 			t.Skip()
 		}
-		token, err := NewAccessToken(userID, secret, ttl)
+		token, err := NewAccessToken(userID, kr, ttl)
 		if err != nil {
 			return // signing errors are acceptable
 		}
@@ -50,19 +78,20 @@ func FuzzNewAccessToken(f *testing.F) {
 // FuzzRoundTrip generates a token and parses it back, verifying that valid
 // tokens always round-trip correctly.
 func FuzzRoundTrip(f *testing.F) {
-	f.Add("user-abc", "test-secret", 15)
-	f.Add("", "s", 1)
-	f.Add("uid-with-special/chars", "long-secret-key-for-hmac-256", 60)
+	f.Add("user-abc", 15)
+	f.Add("", 1)
+	f.Add("uid-with-special/chars", 60)
 
-	f.Fuzz(func(t *testing.T, userID, secret string, ttl int) {
-		if ttl <= 0 || ttl > 525600 || secret == "" {
+	kr := fuzzKeyring(f)
+	f.Fuzz(func(t *testing.T, userID string, ttl int) {
+		if ttl <= 0 || ttl > 525600 {
 			t.Skip()
 		}
-		token, err := NewAccessToken(userID, secret, ttl)
+		token, err := NewAccessToken(userID, kr, ttl)
 		if err != nil {
 			t.Fatalf("NewAccessToken failed: %v", err)
 		}
-		claims, err := Parse(token, secret)
+		claims, err := Parse(token, kr)
 		if err != nil {
 			t.Fatalf("Parse failed on valid token: %v", err)
 		}
@@ -75,22 +104,38 @@ func FuzzRoundTrip(f *testing.F) {
 	})
 }
 
-// FuzzParseWrongSecret verifies that parsing a valid token with the wrong
-// secret always fails.
-func FuzzParseWrongSecret(f *testing.F) {
+// FuzzParseWrongKeyring verifies that a token signed by one keyring never
+// verifies against another.
+func FuzzParseWrongKeyring(f *testing.F) {
+	f.Add("user-1")
+
+	signer, other := fuzzKeyring(f), fuzzKeyring(f)
+	f.Fuzz(func(t *testing.T, userID string) {
+		token, err := NewAccessToken(userID, signer, 15)
+		if err != nil {
+			t.Skip()
+		}
+		if _, err := Parse(token, other); err == nil {
+			t.Error("Parse should fail with a foreign keyring")
+		}
+	})
+}
+
+// FuzzParseRefreshWrongSecret verifies that parsing a valid refresh token
+// with the wrong secret always fails.
+func FuzzParseRefreshWrongSecret(f *testing.F) {
 	f.Add("user-1", "correct-secret", "wrong-secret")
 
 	f.Fuzz(func(t *testing.T, userID, signSecret, parseSecret string) {
 		if signSecret == parseSecret || signSecret == "" {
 			t.Skip()
 		}
-		token, err := NewAccessToken(userID, signSecret, 15)
+		token, _, _, err := NewRefreshToken(userID, signSecret, 1)
 		if err != nil {
 			t.Skip()
 		}
-		_, err = Parse(token, parseSecret)
-		if err == nil {
-			t.Error("Parse should fail with wrong secret")
+		if _, err := ParseRefresh(token, parseSecret); err == nil {
+			t.Error("ParseRefresh should fail with wrong secret")
 		}
 	})
 }
@@ -0,0 +1,246 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Signing algorithms accepted for access tokens. Parsing pins these, so
+// "none" and HMAC tokens (including ones forged with a public key as the
+// HMAC secret) are rejected.
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+var (
+	ErrNoSigningKey = errors.New("no active signing key")
+	ErrUnknownKey   = errors.New("unknown signing key")
+)
+
+// SigningKey is one entry of the keyring. A key signs new tokens from
+// ActiveFrom until a newer key becomes active, and verifies tokens until
+// VerifyUntil (zero means no end). Publishing the next key with a future
+// ActiveFrom lets verifiers cache it before it is used: scheduled rotation.
+type SigningKey struct {
+	ID          string
+	Algorithm   string
+	Private     crypto.Signer
+	ActiveFrom  time.Time
+	VerifyUntil time.Time
+}
+
+// Keyring holds the access-token signing keys and the iss/aud values that
+// tokens are minted with and validated against.
+type Keyring struct {
+	keys     []SigningKey // sorted by ActiveFrom
+	now      func() time.Time
+	issuer   string
+	audience []string
+}
+
+// NewKeyring validates the keys and builds a keyring.
+func NewKeyring(keys ...SigningKey) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoSigningKey
+	}
+	seen := map[string]bool{}
+	for _, k := range keys {
+		if k.ID == "" {
+			return nil, errors.New("keyring: key without kid")
+		}
+		if seen[k.ID] {
+			return nil, fmt.Errorf("keyring: duplicate kid %q", k.ID)
+		}
+		seen[k.ID] = true
+		if err := checkKeyType(k); err != nil {
+			return nil, err
+		}
+	}
+	sorted := append([]SigningKey(nil), keys...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ActiveFrom.Before(sorted[j].ActiveFrom) })
+	return &Keyring{keys: sorted, now: time.Now}, nil
+}
+
+// WithIssuer returns a copy of the keyring that stamps new tokens with iss
+// and aud, and requires both when parsing. An empty issuer or audience
+// disables the corresponding check.
+func (k *Keyring) WithIssuer(iss string, aud ...string) *Keyring {
+	cp := *k
+	cp.issuer = iss
+	cp.audience = append([]string(nil), aud...)
+	return &cp
+}
+
+func checkKeyType(k SigningKey) error {
+	switch k.Algorithm {
+	case AlgRS256:
+		rk, ok := k.Private.(*rsa.PrivateKey)
+		if !ok {
+			return fmt.Errorf("keyring: key %q: RS256 requires an RSA private key", k.ID)
+		}
+		if rk.N.BitLen() < 2048 {
+			return fmt.Errorf("keyring: key %q: RSA keys must be at least 2048 bits", k.ID)
+		}
+	case AlgEdDSA:
+		if _, ok := k.Private.(ed25519.PrivateKey); !ok {
+			return fmt.Errorf("keyring: key %q: EdDSA requires an Ed25519 private key", k.ID)
+		}
+	default:
+		return fmt.Errorf("keyring: key %q: unsupported alg %q", k.ID, k.Algorithm)
+	}
+	return nil
+}
+
+// GenerateKeyring returns a keyring with a single random Ed25519 key. Tokens
+// do not survive a restart and are not shared across instances, so it is
+// only meant for development and tests.
+func GenerateKeyring() (*Keyring, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(priv.Public().(ed25519.PublicKey))
+	kid := "dev-" + hex.EncodeToString(sum[:8])
+	return NewKeyring(SigningKey{ID: kid, Algorithm: AlgEdDSA, Private: priv})
+}
+
+type keyringFile struct {
+	Keys []struct {
+		Kid            string    `json:"kid"`
+		Alg            string    `json:"alg"`
+		PrivateKey     string    `json:"private_key"`      // inline PEM
+		PrivateKeyFile string    `json:"private_key_file"` // relative to the keyring file
+		ActiveFrom     time.Time `json:"active_from"`
+		VerifyUntil    time.Time `json:"verify_until"`
+	} `json:"keys"`
+}
+
+// LoadKeyring reads a JSON keyring file:
+//
+//	{"keys": [
+//	  {"kid": "2026-09", "alg": "EdDSA", "private_key_file": "2026-09.pem",
+//	   "active_from": "2026-09-01T00:00:00Z", "verify_until": "2026-10-02T00:00:00Z"},
+//	  {"kid": "2026-10", "alg": "RS256", "private_key_file": "2026-10.pem",
+//	   "active_from": "2026-10-01T00:00:00Z"}
+//	]}
+//
+// Private keys are PEM encoded (PKCS#8, or PKCS#1 for RSA).
+func LoadKeyring(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("keyring: %w", err)
+	}
+	var f keyringFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("keyring: %w", err)
+	}
+	keys := make([]SigningKey, 0, len(f.Keys))
+	for _, e := range f.Keys {
+		pemData := []byte(e.PrivateKey)
+		if e.PrivateKeyFile != "" {
+			p := e.PrivateKeyFile
+			if !filepath.IsAbs(p) {
+				p = filepath.Join(filepath.Dir(path), p)
+			}
+			if pemData, err = os.ReadFile(p); err != nil {
+				return nil, fmt.Errorf("keyring: key %q: %w", e.Kid, err)
+			}
+		}
+		priv, err := ParsePrivateKeyPEM(pemData)
+		if err != nil {
+			return nil, fmt.Errorf("keyring: key %q: %w", e.Kid, err)
+		}
+		keys = append(keys, SigningKey{
+			ID:          e.Kid,
+			Algorithm:   e.Alg,
+			Private:     priv,
+			ActiveFrom:  e.ActiveFrom,
+			VerifyUntil: e.VerifyUntil,
+		})
+	}
+	return NewKeyring(keys...)
+}
+
+// ParsePrivateKeyPEM decodes an RSA or Ed25519 private key.
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		switch k := k.(type) {
+		case *rsa.PrivateKey:
+			return k, nil
+		case ed25519.PrivateKey:
+			return k, nil
+		}
+		return nil, ErrUnsupportedKey
+	}
+	return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+}
+
+// signer returns the key that signs new tokens: the most recently activated
+// one that is still valid for verification.
+func (k *Keyring) signer() (SigningKey, error) {
+	now := k.now()
+	for i := len(k.keys) - 1; i >= 0; i-- {
+		key := k.keys[i]
+		if !key.ActiveFrom.After(now) && k.verifiable(key, now) {
+			return key, nil
+		}
+	}
+	return SigningKey{}, ErrNoSigningKey
+}
+
+func (k *Keyring) verifiable(key SigningKey, now time.Time) bool {
+	return key.VerifyUntil.IsZero() || now.Before(key.VerifyUntil)
+}
+
+// verificationKey returns the public key for kid if it may verify tokens
+// signed with alg.
+func (k *Keyring) verificationKey(kid, alg string) (crypto.PublicKey, error) {
+	now := k.now()
+	for _, key := range k.keys {
+		if key.ID == kid && key.Algorithm == alg && k.verifiable(key, now) {
+			return key.Private.Public(), nil
+		}
+	}
+	return nil, ErrUnknownKey
+}
+
+// JWKS returns the public keys that currently verify tokens, including keys
+// scheduled to become active.
+func (k *Keyring) JWKS() JWKSet {
+	now := k.now()
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range k.keys {
+		if !k.verifiable(key, now) {
+			continue
+		}
+		if jwk, err := NewJWK(key.ID, key.Algorithm, key.Private.Public()); err == nil {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	return set
+}
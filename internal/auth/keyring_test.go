@@ -0,0 +1,301 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa key: %v", err)
+	}
+	return k
+}
+
+func newEdKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, k, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ed25519 key: %v", err)
+	}
+	return k
+}
+
+func kidOf(t *testing.T, token string) string {
+	t.Helper()
+	tok, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+	if err != nil {
+		t.Fatalf("parse unverified: %v", err)
+	}
+	kid, _ := tok.Header["kid"].(string)
+	return kid
+}
+
+func TestKeyring_ScheduledRotation(t *testing.T) {
+	t0 := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	kr, err := NewKeyring(
+		SigningKey{ID: "old", Algorithm: AlgEdDSA, Private: newEdKey(t), ActiveFrom: t0, VerifyUntil: t0.Add(48 * time.Hour)},
+		SigningKey{ID: "new", Algorithm: AlgRS256, Private: newRSAKey(t), ActiveFrom: t0.Add(24 * time.Hour)},
+	)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+
+	now := t0.Add(time.Hour)
+	kr.now = func() time.Time { return now }
+	oldTok, err := NewAccessToken("u1", kr, 15)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if kid := kidOf(t, oldTok); kid != "old" {
+		t.Fatalf("kid before rotation = %q, want old", kid)
+	}
+	// The upcoming key is published ahead of its activation.
+	if n := len(kr.JWKS().Keys); n != 2 {
+		t.Errorf("JWKS before rotation has %d keys, want 2", n)
+	}
+
+	now = t0.Add(25 * time.Hour)
+	newTok, err := NewAccessToken("u1", kr, 15)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if kid := kidOf(t, newTok); kid != "new" {
+		t.Fatalf("kid after rotation = %q, want new", kid)
+	}
+
+	// During the overlap both keys verify.
+	for _, tok := range []string{oldTok, newTok} {
+		if _, err := Parse(tok, kr); err != nil {
+			t.Errorf("verify during overlap: %v", err)
+		}
+	}
+
+	// Once retired, the old key neither verifies nor is published.
+	kr.now = func() time.Time { return t0.Add(49 * time.Hour) }
+	if _, err := kr.verificationKey("old", AlgEdDSA); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("retired key still verifies: %v", err)
+	}
+	set := kr.JWKS()
+	if len(set.Keys) != 1 || set.Keys[0].Kid != "new" || set.Keys[0].Kty != "RSA" {
+		t.Errorf("JWKS after retirement = %+v", set.Keys)
+	}
+}
+
+func TestKeyring_NoActiveKey(t *testing.T) {
+	kr, err := NewKeyring(SigningKey{ID: "future", Algorithm: AlgEdDSA, Private: newEdKey(t), ActiveFrom: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	if _, err := NewAccessToken("u1", kr, 15); !errors.Is(err, ErrNoSigningKey) {
+		t.Errorf("err = %v, want ErrNoSigningKey", err)
+	}
+}
+
+func TestNewKeyring_Validates(t *testing.T) {
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed := newEdKey(t)
+	cases := [][]SigningKey{
+		{},
+		{{ID: "", Algorithm: AlgEdDSA, Private: ed}},
+		{{ID: "a", Algorithm: AlgRS256, Private: ed}},
+		{{ID: "a", Algorithm: AlgRS256, Private: small}},
+		{{ID: "a", Algorithm: "HS256", Private: ed}},
+		{{ID: "a", Algorithm: AlgEdDSA, Private: ed}, {ID: "a", Algorithm: AlgEdDSA, Private: ed}},
+	}
+	for i, keys := range cases {
+		if _, err := NewKeyring(keys...); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+}
+
+func TestParse_PinsAlgorithms(t *testing.T) {
+	rk := newRSAKey(t)
+	kr, err := NewKeyring(SigningKey{ID: "k1", Algorithm: AlgRS256, Private: rk})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := Claims{UserID: "u1", TokenType: "access", RegisteredClaims: jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}}
+
+	// alg=none
+	none := jwt.NewWithClaims(jwt.SigningMethodNone, claims)
+	none.Header["kid"] = "k1"
+	s, _ := none.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if _, err := Parse(s, kr); err == nil {
+		t.Error("alg=none accepted")
+	}
+
+	// HS256 using the public key bytes as the HMAC secret (key confusion)
+	pubDER, _ := x509.MarshalPKIXPublicKey(&rk.PublicKey)
+	hs := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	hs.Header["kid"] = "k1"
+	s, _ = hs.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}))
+	if _, err := Parse(s, kr); err == nil {
+		t.Error("HS256 accepted")
+	}
+
+	// Right key, missing or unknown kid
+	rs := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	s, _ = rs.SignedString(rk)
+	if _, err := Parse(s, kr); err == nil {
+		t.Error("token without kid accepted")
+	}
+	rs.Header["kid"] = "other"
+	s, _ = rs.SignedString(rk)
+	if _, err := Parse(s, kr); err == nil {
+		t.Error("token with unknown kid accepted")
+	}
+
+	// Refresh tokens cannot be parsed as access tokens and vice versa.
+	refresh, _, _, _ := NewRefreshToken("u1", "secret", 1)
+	if _, err := Parse(refresh, kr); err == nil {
+		t.Error("refresh token accepted as access token")
+	}
+	access, _ := NewAccessToken("u1", kr, 15)
+	if _, err := ParseRefresh(access, "secret"); err == nil {
+		t.Error("access token accepted as refresh token")
+	}
+}
+
+func TestJWKS_VerifiesTokens(t *testing.T) {
+	for _, key := range []SigningKey{
+		{ID: "rsa", Algorithm: AlgRS256, Private: newRSAKey(t)},
+		{ID: "ed", Algorithm: AlgEdDSA, Private: newEdKey(t)},
+	} {
+		kr, err := NewKeyring(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		token, err := NewAccessToken("u1", kr, 15)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// A third party only has the published JWKS.
+		set := kr.JWKS()
+		_, err = jwt.ParseWithClaims(token, &Claims{}, func(tk *jwt.Token) (any, error) {
+			jwk, ok := set.Find(tk.Header["kid"].(string))
+			if !ok {
+				return nil, ErrUnknownKey
+			}
+			return jwk.PublicKey()
+		}, jwt.WithValidMethods([]string{set.Keys[0].Alg}))
+		if err != nil {
+			t.Errorf("%s: verify with JWKS: %v", key.ID, err)
+		}
+	}
+}
+
+func TestLoadKeyring(t *testing.T) {
+	dir := t.TempDir()
+	rk := newRSAKey(t)
+	rsaPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rk)})
+	if err := os.WriteFile(filepath.Join(dir, "rsa.pem"), rsaPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	edDER, err := x509.MarshalPKCS8PrivateKey(newEdKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	edJSON, _ := json.Marshal(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: edDER})))
+
+	cfg := `{"keys": [
+		{"kid": "a", "alg": "RS256", "private_key_file": "rsa.pem", "active_from": "2026-01-01T00:00:00Z"},
+		{"kid": "b", "alg": "EdDSA", "private_key": ` + string(edJSON) + `, "active_from": "2026-02-01T00:00:00Z"}
+	]}`
+	path := filepath.Join(dir, "keys.json")
+	if err := os.WriteFile(path, []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	kr, err := LoadKeyring(path)
+	if err != nil {
+		t.Fatalf("LoadKeyring: %v", err)
+	}
+	key, err := kr.signer()
+	if err != nil || key.ID != "b" {
+		t.Fatalf("signer = %q, %v; want b", key.ID, err)
+	}
+	if n := len(kr.JWKS().Keys); n != 2 {
+		t.Errorf("JWKS has %d keys, want 2", n)
+	}
+}
+
+func TestParse_IssuerAndAudience(t *testing.T) {
+	base, err := NewKeyring(SigningKey{ID: "k1", Algorithm: AlgEdDSA, Private: newEdKey(t)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kr := base.WithIssuer("https://api.vergo.test", "vergo-api", "reports")
+
+	token, err := NewAccessToken("u1", kr, 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := Parse(token, kr)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if claims.Issuer != "https://api.vergo.test" || len(claims.Audience) != 2 {
+		t.Errorf("iss/aud = %q/%v", claims.Issuer, claims.Audience)
+	}
+
+	// A service that only accepts one of the audiences still verifies it.
+	if _, err := Parse(token, base.WithIssuer("https://api.vergo.test", "reports")); err != nil {
+		t.Errorf("single matching audience rejected: %v", err)
+	}
+	if _, err := Parse(token, base.WithIssuer("https://other.test", "vergo-api")); !errors.Is(err, jwt.ErrTokenInvalidIssuer) {
+		t.Errorf("wrong issuer: err = %v", err)
+	}
+	if _, err := Parse(token, base.WithIssuer("https://api.vergo.test", "billing")); !errors.Is(err, jwt.ErrTokenInvalidAudience) {
+		t.Errorf("wrong audience: err = %v", err)
+	}
+
+	// Tokens minted without iss/aud are rejected once they are required.
+	bare, _ := NewAccessToken("u1", base, 15)
+	if _, err := Parse(bare, kr); err == nil {
+		t.Error("token without iss/aud accepted")
+	}
+}
+
+func TestNewOrgAccessToken(t *testing.T) {
+	kr, err := GenerateKeyring()
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := NewOrgAccessToken("u1", "org-1", "admin", 42, kr, 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := Parse(token, kr)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !claims.OrgScoped() || claims.OrgID != "org-1" || claims.Role != "admin" || claims.MembershipVersion != 42 {
+		t.Errorf("claims = %+v", claims)
+	}
+
+	plain, _ := NewAccessToken("u1", kr, 15)
+	if claims, _ := Parse(plain, kr); claims.OrgScoped() {
+		t.Error("plain access token is org-scoped")
+	}
+}
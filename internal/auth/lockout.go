@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+// Lockout scopes: each endpoint counts its failures separately.
+const (
+	ScopeLogin          = "login"
+	ScopeForgotPassword = "forgot_password"
+	ScopeVerifyEmail    = "verify_email"
+)
+
+// LockoutPolicy configures the failed-attempt tracker. Once a key reaches its
+// failure limit it is locked for BaseLockout, doubling with every further
+// failure up to MaxLockout.
+type LockoutPolicy struct {
+	AccountMaxFailures int           // failures per account (email) before lockout
+	IPMaxFailures      int           // failures per client IP before lockout
+	CaptchaAfter       int           // account failures before clients must solve a CAPTCHA (0 = never)
+	BaseLockout        time.Duration // first lockout
+	MaxLockout         time.Duration // lockout cap
+	Window             time.Duration // failures are forgotten after this long without one
+}
+
+// Lock is a key that the last failure locked.
+type Lock struct {
+	Kind     string // "account" | "ip"
+	Failures int
+	Until    time.Time
+}
+
+// Attempt is the lockout state of a request, combining its account and IP.
+type Attempt struct {
+	LockedUntil     time.Time // zero when neither key is locked
+	CaptchaRequired bool
+	NewLocks        []Lock // keys locked by this failure (Fail only)
+}
+
+// Locked reports whether the request must be rejected at now.
+func (a Attempt) Locked(now time.Time) bool {
+	return now.Before(a.LockedUntil)
+}
+
+// LockoutTracker counts failed attempts per account and per IP.
+type LockoutTracker interface {
+	// Check returns the current state without counting an attempt. An empty
+	// account only checks the IP.
+	Check(ctx context.Context, scope, account, ip string) (Attempt, error)
+	// Fail counts a failed attempt for both keys and locks those over
+	// their limit.
+	Fail(ctx context.Context, scope, account, ip string) (Attempt, error)
+	// Reset clears the account's failures after a success. The IP counter
+	// is kept, so one valid account cannot reset a password spray.
+	Reset(ctx context.Context, scope, account string) error
+}
+
+type pgLockoutTracker struct {
+	q      *repo.Queries
+	policy LockoutPolicy
+}
+
+func NewLockoutTracker(q *repo.Queries, policy LockoutPolicy) LockoutTracker {
+	return &pgLockoutTracker{q: q, policy: policy}
+}
+
+func accountKey(account string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(account))
+}
+
+func ipKey(ip string) string { return "ip:" + ip }
+
+// keys returns the counters of a request; without an account (e.g. token
+// verification) only the IP is tracked.
+func keys(account, ip string) []string {
+	if strings.TrimSpace(account) == "" {
+		return []string{ipKey(ip)}
+	}
+	return []string{accountKey(account), ipKey(ip)}
+}
+
+func (t *pgLockoutTracker) Check(ctx context.Context, scope, account, ip string) (Attempt, error) {
+	var a Attempt
+	for _, key := range keys(account, ip) {
+		row, err := t.q.GetAuthAttempt(ctx, repo.GetAuthAttemptParams{Scope: scope, Key: key})
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return Attempt{}, err
+		}
+		t.merge(&a, key, row)
+	}
+	return a, nil
+}
+
+func (t *pgLockoutTracker) Fail(ctx context.Context, scope, account, ip string) (Attempt, error) {
+	// best-effort cleanup of forgotten counters
+	now := time.Now()
+	_ = t.q.DeleteStaleAuthAttempts(ctx, now.Add(-t.policy.Window))
+
+	var a Attempt
+	for _, key := range keys(account, ip) {
+		row, err := t.q.RecordAuthFailure(ctx, repo.RecordAuthFailureParams{
+			Scope:       scope,
+			Key:         key,
+			ResetBefore: now.Add(-t.policy.Window),
+		})
+		if err != nil {
+			return Attempt{}, err
+		}
+		kind, maxFailures := t.limit(key)
+		if d := LockoutDuration(t.policy, maxFailures, int(row.Failures)); d > 0 {
+			until := now.Add(d)
+			err := t.q.LockAuthAttempt(ctx, repo.LockAuthAttemptParams{
+				Scope:       scope,
+				Key:         key,
+				LockedUntil: sql.NullTime{Time: until, Valid: true},
+			})
+			if err != nil {
+				return Attempt{}, err
+			}
+			row.LockedUntil = sql.NullTime{Time: until, Valid: true}
+			a.NewLocks = append(a.NewLocks, Lock{Kind: kind, Failures: int(row.Failures), Until: until})
+		}
+		t.merge(&a, key, row)
+	}
+	return a, nil
+}
+
+func (t *pgLockoutTracker) Reset(ctx context.Context, scope, account string) error {
+	return t.q.DeleteAuthAttempt(ctx, repo.DeleteAuthAttemptParams{Scope: scope, Key: accountKey(account)})
+}
+
+func (t *pgLockoutTracker) limit(key string) (kind string, maxFailures int) {
+	if strings.HasPrefix(key, "ip:") {
+		return "ip", t.policy.IPMaxFailures
+	}
+	return "account", t.policy.AccountMaxFailures
+}
+
+func (t *pgLockoutTracker) merge(a *Attempt, key string, row repo.AuthAttempt) {
+	if row.LockedUntil.Valid && row.LockedUntil.Time.After(a.LockedUntil) {
+		a.LockedUntil = row.LockedUntil.Time
+	}
+	if kind, _ := t.limit(key); kind == "account" && t.policy.CaptchaAfter > 0 &&
+		int(row.Failures) >= t.policy.CaptchaAfter && !time.Now().After(row.LastFailureAt.Add(t.policy.Window)) {
+		a.CaptchaRequired = true
+	}
+}
+
+// LockoutDuration is how long a key with the given failure count is locked:
+// zero below maxFailures, then BaseLockout doubling per failure up to
+// MaxLockout.
+func LockoutDuration(p LockoutPolicy, maxFailures, failures int) time.Duration {
+	if maxFailures <= 0 || failures < maxFailures {
+		return 0
+	}
+	d := p.BaseLockout
+	for i := maxFailures; i < failures && d < p.MaxLockout; i++ {
+		d *= 2
+	}
+	if d > p.MaxLockout {
+		d = p.MaxLockout
+	}
+	return d
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	p := LockoutPolicy{BaseLockout: time.Minute, MaxLockout: 10 * time.Minute}
+	cases := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{4, 0},
+		{5, time.Minute},
+		{6, 2 * time.Minute},
+		{8, 8 * time.Minute},
+		{9, 10 * time.Minute},
+		{1000, 10 * time.Minute},
+	}
+	for _, tc := range cases {
+		if got := LockoutDuration(p, 5, tc.failures); got != tc.want {
+			t.Errorf("LockoutDuration(%d) = %v, want %v", tc.failures, got, tc.want)
+		}
+	}
+	if got := LockoutDuration(p, 0, 100); got != 0 {
+		t.Errorf("disabled limit locked for %v", got)
+	}
+}
+
+func TestAttemptLocked(t *testing.T) {
+	now := time.Now()
+	if (Attempt{}).Locked(now) {
+		t.Error("zero attempt is locked")
+	}
+	if !(Attempt{LockedUntil: now.Add(time.Second)}).Locked(now) {
+		t.Error("future lock not enforced")
+	}
+	if (Attempt{LockedUntil: now.Add(-time.Second)}).Locked(now) {
+		t.Error("expired lock still enforced")
+	}
+}
@@ -0,0 +1,383 @@
+// Package oidc implements the OAuth2 authorization code flow with PKCE
+// against external identity providers: any OIDC issuer (Google, Okta,
+// Azure AD, Keycloak...) and GitHub, which speaks plain OAuth2.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/Ulpio/vergo/internal/auth"
+	"github.com/Ulpio/vergo/internal/pkg/config"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown identity provider")
+	ErrInvalidState    = errors.New("invalid or expired oidc state")
+	ErrInvalidIDToken  = errors.New("invalid id_token")
+	ErrNonceMismatch   = errors.New("id_token nonce mismatch")
+)
+
+const jwksMinRefresh = time.Minute
+
+// Identity is the normalized external identity returned after a successful
+// code exchange.
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type endpoints struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider is a configured identity provider.
+type Provider struct {
+	cfg    config.OIDCProvider
+	client *http.Client
+
+	// githubAPI is the REST base used by "github" providers.
+	githubAPI string
+
+	mu     sync.Mutex
+	ep     *endpoints
+	jwks   auth.JWKSet
+	jwksAt time.Time
+}
+
+func NewProvider(cfg config.OIDCProvider, client *http.Client) *Provider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	p := &Provider{cfg: cfg, client: client}
+	if cfg.Type == "github" {
+		p.githubAPI = "https://api.github.com"
+		p.ep = &endpoints{
+			AuthorizationEndpoint: "https://github.com/login/oauth/authorize",
+			TokenEndpoint:         "https://github.com/login/oauth/access_token",
+		}
+	}
+	return p
+}
+
+func (p *Provider) Name() string { return p.cfg.Name }
+
+func (p *Provider) scopes() []string {
+	if len(p.cfg.Scopes) > 0 {
+		return p.cfg.Scopes
+	}
+	if p.cfg.Type == "github" {
+		return []string{"read:user", "user:email"}
+	}
+	return []string{"openid", "email", "profile"}
+}
+
+// endpoints resolves (and caches) the provider's OIDC discovery document.
+func (p *Provider) endpoints(ctx context.Context) (*endpoints, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ep != nil {
+		return p.ep, nil
+	}
+
+	u := strings.TrimRight(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	var ep endpoints
+	if err := p.getJSON(ctx, u, "", &ep); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if strings.TrimRight(ep.Issuer, "/") != strings.TrimRight(p.cfg.Issuer, "/") {
+		return nil, fmt.Errorf("oidc discovery: issuer mismatch %q", ep.Issuer)
+	}
+	p.ep = &ep
+	return p.ep, nil
+}
+
+// AuthCodeURL builds the authorization redirect for the code flow with PKCE.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	ep, err := p.endpoints(ctx)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", p.cfg.RedirectURL)
+	q.Set("scope", strings.Join(p.scopes(), " "))
+	q.Set("state", state)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+	if p.cfg.Type != "github" {
+		q.Set("nonce", nonce)
+	}
+
+	sep := "?"
+	if strings.Contains(ep.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return ep.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	TokenType   string `json:"token_type"`
+	Error       string `json:"error"`
+}
+
+// Exchange redeems an authorization code and returns the verified identity.
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (Identity, error) {
+	ep, err := p.endpoints(ctx)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("client_id", p.cfg.ClientID)
+	form.Set("client_secret", p.cfg.ClientSecret)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Identity{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Identity{}, fmt.Errorf("token exchange: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var tr tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&tr); err != nil {
+		return Identity{}, fmt.Errorf("token exchange: decode: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || tr.Error != "" {
+		return Identity{}, fmt.Errorf("token exchange: status %d %s", resp.StatusCode, tr.Error)
+	}
+
+	if p.cfg.Type == "github" {
+		return p.githubIdentity(ctx, tr.AccessToken)
+	}
+	return p.oidcIdentity(ctx, ep, tr, nonce)
+}
+
+// flexBool accepts both JSON booleans and "true"/"false" strings, since some
+// providers serialize email_verified as a string.
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return nil
+	}
+	*b = flexBool(v)
+	return nil
+}
+
+type idClaims struct {
+	Nonce         string   `json:"nonce"`
+	Email         string   `json:"email"`
+	EmailVerified flexBool `json:"email_verified"`
+	Name          string   `json:"name"`
+	jwt.RegisteredClaims
+}
+
+func (p *Provider) oidcIdentity(ctx context.Context, ep *endpoints, tr tokenResponse, nonce string) (Identity, error) {
+	if tr.IDToken == "" {
+		return Identity{}, ErrInvalidIDToken
+	}
+
+	var claims idClaims
+	_, err := jwt.ParseWithClaims(tr.IDToken, &claims, p.keyfunc(ctx, ep),
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(ep.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30*time.Second),
+	)
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if claims.Nonce != nonce {
+		return Identity{}, ErrNonceMismatch
+	}
+	if claims.Subject == "" {
+		return Identity{}, ErrInvalidIDToken
+	}
+
+	id := Identity{
+		Provider:      p.cfg.Name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+	}
+
+	// Some providers keep the email out of the id_token; fall back to userinfo.
+	if id.Email == "" && ep.UserinfoEndpoint != "" && tr.AccessToken != "" {
+		var ui idClaims
+		if err := p.getJSON(ctx, ep.UserinfoEndpoint, tr.AccessToken, &ui); err == nil && ui.Subject == id.Subject {
+			id.Email = ui.Email
+			id.EmailVerified = bool(ui.EmailVerified)
+			if id.Name == "" {
+				id.Name = ui.Name
+			}
+		}
+	}
+	return id, nil
+}
+
+func (p *Provider) keyfunc(ctx context.Context, ep *endpoints) jwt.Keyfunc {
+	return func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		k, err := p.lookupKey(ctx, ep, kid)
+		if err != nil {
+			return nil, err
+		}
+		return k.PublicKey()
+	}
+}
+
+// lookupKey finds a signing key by kid, refreshing the cached JWKS when the
+// kid is unknown (provider key rotation).
+func (p *Provider) lookupKey(ctx context.Context, ep *endpoints, kid string) (auth.JWK, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	find := func() (auth.JWK, bool) {
+		if kid == "" && len(p.jwks.Keys) == 1 {
+			return p.jwks.Keys[0], true
+		}
+		return p.jwks.Find(kid)
+	}
+	if k, ok := find(); ok {
+		return k, nil
+	}
+	if time.Since(p.jwksAt) < jwksMinRefresh {
+		return auth.JWK{}, fmt.Errorf("unknown kid %q", kid)
+	}
+
+	var set auth.JWKSet
+	if err := p.getJSON(ctx, ep.JWKSURI, "", &set); err != nil {
+		return auth.JWK{}, fmt.Errorf("fetch jwks: %w", err)
+	}
+	p.jwks = set
+	p.jwksAt = time.Now()
+
+	if k, ok := find(); ok {
+		return k, nil
+	}
+	return auth.JWK{}, fmt.Errorf("unknown kid %q", kid)
+}
+
+type githubUser struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+	Name  string `json:"name"`
+}
+
+type githubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+func (p *Provider) githubIdentity(ctx context.Context, accessToken string) (Identity, error) {
+	if accessToken == "" {
+		return Identity{}, errors.New("github: missing access_token")
+	}
+	var u githubUser
+	if err := p.getJSON(ctx, p.githubAPI+"/user", accessToken, &u); err != nil {
+		return Identity{}, fmt.Errorf("github user: %w", err)
+	}
+	var emails []githubEmail
+	if err := p.getJSON(ctx, p.githubAPI+"/user/emails", accessToken, &emails); err != nil {
+		return Identity{}, fmt.Errorf("github emails: %w", err)
+	}
+
+	id := Identity{Provider: p.cfg.Name, Subject: strconv.FormatInt(u.ID, 10), Name: u.Name}
+	for _, e := range emails {
+		if e.Primary {
+			id.Email = e.Email
+			id.EmailVerified = e.Verified
+			break
+		}
+	}
+	return id, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, u, bearer string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", u, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out)
+}
+
+// Registry holds the configured providers by name.
+type Registry struct {
+	providers map[string]*Provider
+}
+
+func NewRegistry(cfgs []config.OIDCProvider) *Registry {
+	r := &Registry{providers: make(map[string]*Provider, len(cfgs))}
+	for _, c := range cfgs {
+		r.providers[c.Name] = NewProvider(c, nil)
+	}
+	return r
+}
+
+func (r *Registry) Get(name string) (*Provider, error) {
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return p, nil
+}
+
+// Names lists the enabled providers, sorted.
+func (r *Registry) Names() []string {
+	out := make([]string, 0, len(r.providers))
+	for n := range r.providers {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
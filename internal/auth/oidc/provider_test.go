@@ -0,0 +1,240 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/Ulpio/vergo/internal/auth"
+	"github.com/Ulpio/vergo/internal/pkg/config"
+)
+
+// fakeIdP is a minimal in-process OIDC provider: discovery, JWKS and a token
+// endpoint that enforces PKCE.
+type fakeIdP struct {
+	srv *httptest.Server
+	key *rsa.PrivateKey
+	kid string
+
+	mu    sync.Mutex
+	codes map[string]issuedCode
+}
+
+type issuedCode struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	idp := &fakeIdP{key: key, kid: "test-key", codes: map[string]issuedCode{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.srv.URL,
+			"authorization_endpoint": idp.srv.URL + "/authorize",
+			"token_endpoint":         idp.srv.URL + "/token",
+			"jwks_uri":               idp.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(auth.JWKSet{Keys: []auth.JWK{{
+			Kty: "RSA",
+			Kid: idp.kid,
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		idp.mu.Lock()
+		ic, ok := idp.codes[r.PostForm.Get("code")]
+		delete(idp.codes, r.PostForm.Get("code"))
+		idp.mu.Unlock()
+
+		if !ok || S256Challenge(r.PostForm.Get("code_verifier")) != ic.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "at",
+			"token_type":   "Bearer",
+			"id_token":     idp.sign(t, ic.claims, key),
+		})
+	})
+	idp.srv = httptest.NewServer(mux)
+	t.Cleanup(idp.srv.Close)
+	return idp
+}
+
+func (f *fakeIdP) sign(t *testing.T, claims jwt.MapClaims, key *rsa.PrivateKey) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tok.Header["kid"] = f.kid
+	s, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign id_token: %v", err)
+	}
+	return s
+}
+
+func (f *fakeIdP) issue(code, challenge string, claims jwt.MapClaims) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.codes[code] = issuedCode{challenge: challenge, claims: claims}
+}
+
+func (f *fakeIdP) claims(nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            f.srv.URL,
+		"aud":            "vergo-client",
+		"sub":            "ext-123",
+		"email":          "alice@example.com",
+		"email_verified": true,
+		"nonce":          nonce,
+		"exp":            time.Now().Add(5 * time.Minute).Unix(),
+		"iat":            time.Now().Unix(),
+	}
+}
+
+func newTestProvider(idp *fakeIdP) *Provider {
+	return NewProvider(config.OIDCProvider{
+		Name:        "acme",
+		Type:        "oidc",
+		Issuer:      idp.srv.URL,
+		ClientID:    "vergo-client",
+		RedirectURL: "http://localhost/callback",
+	}, idp.srv.Client())
+}
+
+func TestProvider_AuthCodeURL(t *testing.T) {
+	idp := newFakeIdP(t)
+	p := newTestProvider(idp)
+
+	raw, err := p.AuthCodeURL(context.Background(), "st", "nn", "ch")
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	u, _ := url.Parse(raw)
+	q := u.Query()
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             "vergo-client",
+		"redirect_uri":          "http://localhost/callback",
+		"state":                 "st",
+		"nonce":                 "nn",
+		"code_challenge":        "ch",
+		"code_challenge_method": "S256",
+		"scope":                 "openid email profile",
+	}
+	for k, v := range want {
+		if q.Get(k) != v {
+			t.Errorf("%s = %q, want %q", k, q.Get(k), v)
+		}
+	}
+}
+
+func TestProvider_ExchangeWithPKCE(t *testing.T) {
+	idp := newFakeIdP(t)
+	p := newTestProvider(idp)
+
+	verifier, challenge, err := NewPKCE()
+	if err != nil {
+		t.Fatalf("NewPKCE: %v", err)
+	}
+	idp.issue("code-1", challenge, idp.claims("nonce-1"))
+
+	id, err := p.Exchange(context.Background(), "code-1", verifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if id.Subject != "ext-123" || id.Email != "alice@example.com" || !id.EmailVerified {
+		t.Errorf("unexpected identity: %+v", id)
+	}
+	if id.Provider != "acme" {
+		t.Errorf("provider = %q, want acme", id.Provider)
+	}
+}
+
+func TestProvider_ExchangeRejectsWrongVerifier(t *testing.T) {
+	idp := newFakeIdP(t)
+	p := newTestProvider(idp)
+
+	_, challenge, _ := NewPKCE()
+	idp.issue("code-2", challenge, idp.claims("n"))
+
+	if _, err := p.Exchange(context.Background(), "code-2", "not-the-verifier", "n"); err == nil {
+		t.Error("expected exchange to fail with wrong code_verifier")
+	}
+}
+
+func TestProvider_ExchangeRejectsNonceMismatch(t *testing.T) {
+	idp := newFakeIdP(t)
+	p := newTestProvider(idp)
+
+	verifier, challenge, _ := NewPKCE()
+	idp.issue("code-3", challenge, idp.claims("issued-nonce"))
+
+	_, err := p.Exchange(context.Background(), "code-3", verifier, "other-nonce")
+	if err != ErrNonceMismatch {
+		t.Errorf("err = %v, want ErrNonceMismatch", err)
+	}
+}
+
+func TestProvider_ExchangeRejectsWrongAudience(t *testing.T) {
+	idp := newFakeIdP(t)
+	p := newTestProvider(idp)
+
+	verifier, challenge, _ := NewPKCE()
+	claims := idp.claims("n")
+	claims["aud"] = "someone-else"
+	idp.issue("code-4", challenge, claims)
+
+	if _, err := p.Exchange(context.Background(), "code-4", verifier, "n"); err == nil {
+		t.Error("expected exchange to fail for foreign audience")
+	}
+}
+
+func TestProvider_ExchangeRejectsForgedSignature(t *testing.T) {
+	idp := newFakeIdP(t)
+	p := newTestProvider(idp)
+
+	// Same kid, different key: must not verify against the published JWKS.
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	verifier, challenge, _ := NewPKCE()
+	forged := idp.sign(t, idp.claims("n"), other)
+
+	var parsed idClaims
+	ep, err := p.endpoints(context.Background())
+	if err != nil {
+		t.Fatalf("endpoints: %v", err)
+	}
+	_, err = jwt.ParseWithClaims(forged, &parsed, p.keyfunc(context.Background(), ep))
+	if err == nil {
+		t.Fatal("forged id_token verified")
+	}
+
+	// And the genuine flow still works afterwards.
+	idp.issue("code-5", challenge, idp.claims("n"))
+	if _, err := p.Exchange(context.Background(), "code-5", verifier, "n"); err != nil {
+		t.Errorf("Exchange after forged attempt: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+// PendingAuth is the server-side half of an authorization request, keyed by
+// the state parameter sent to the provider.
+type PendingAuth struct {
+	Provider     string
+	CodeVerifier string
+	Nonce        string
+}
+
+type StateStore interface {
+	Save(ctx context.Context, state string, p PendingAuth, ttl time.Duration) error
+	Consume(ctx context.Context, state string) (PendingAuth, error)
+}
+
+type pgStateStore struct {
+	q *repo.Queries
+}
+
+func NewStateStore(q *repo.Queries) StateStore {
+	return &pgStateStore{q: q}
+}
+
+func (s *pgStateStore) Save(ctx context.Context, state string, p PendingAuth, ttl time.Duration) error {
+	// best-effort cleanup of abandoned flows
+	_ = s.q.DeleteExpiredOIDCAuthRequests(ctx)
+
+	return s.q.InsertOIDCAuthRequest(ctx, repo.InsertOIDCAuthRequestParams{
+		StateHash:    hashState(state),
+		Provider:     p.Provider,
+		CodeVerifier: p.CodeVerifier,
+		Nonce:        p.Nonce,
+		ExpiresAt:    time.Now().Add(ttl),
+	})
+}
+
+// Consume deletes the pending request so a state can only be redeemed once.
+func (s *pgStateStore) Consume(ctx context.Context, state string) (PendingAuth, error) {
+	row, err := s.q.ConsumeOIDCAuthRequest(ctx, hashState(state))
+	if errors.Is(err, sql.ErrNoRows) {
+		return PendingAuth{}, ErrInvalidState
+	}
+	if err != nil {
+		return PendingAuth{}, err
+	}
+	if time.Now().After(row.ExpiresAt) {
+		return PendingAuth{}, ErrInvalidState
+	}
+	return PendingAuth{
+		Provider:     row.Provider,
+		CodeVerifier: row.CodeVerifier,
+		Nonce:        row.Nonce,
+	}, nil
+}
+
+func hashState(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
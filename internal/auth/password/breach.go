@@ -0,0 +1,79 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// prefixLen is the k-anonymity bucket size: five hex characters of the
+// SHA-1, as in the Have I Been Pwned range API.
+const prefixLen = 5
+
+// Corpus is an offline set of breached password hashes, bucketed by SHA-1
+// prefix. Lookups only ever compare suffixes within one bucket, so the
+// corpus can be the downloaded HIBP file or any subset of it.
+type Corpus struct {
+	buckets map[string][]string // prefix -> sorted suffixes
+	size    int
+}
+
+// LoadCorpus reads a corpus file with one uppercase or lowercase SHA-1 hex
+// digest per line, optionally followed by ":<count>" (the HIBP format).
+// Blank lines and lines starting with '#' are skipped.
+func LoadCorpus(path string) (*Corpus, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadCorpus(f)
+}
+
+// ReadCorpus parses a corpus from r; see LoadCorpus for the format.
+func ReadCorpus(r io.Reader) (*Corpus, error) {
+	c := &Corpus{buckets: make(map[string][]string)}
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		digest, _, _ := strings.Cut(text, ":")
+		digest = strings.ToUpper(digest)
+		if len(digest) != sha1.Size*2 {
+			return nil, fmt.Errorf("breach corpus line %d: not a SHA-1 digest", line)
+		}
+		if _, err := hex.DecodeString(digest); err != nil {
+			return nil, fmt.Errorf("breach corpus line %d: %w", line, err)
+		}
+		prefix := digest[:prefixLen]
+		c.buckets[prefix] = append(c.buckets[prefix], digest[prefixLen:])
+		c.size++
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	for _, suffixes := range c.buckets {
+		sort.Strings(suffixes)
+	}
+	return c, nil
+}
+
+// Len returns the number of hashes in the corpus.
+func (c *Corpus) Len() int { return c.size }
+
+// Contains reports whether password appears in the corpus.
+func (c *Corpus) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+	suffixes := c.buckets[digest[:prefixLen]]
+	suffix := digest[prefixLen:]
+	i := sort.SearchStrings(suffixes, suffix)
+	return i < len(suffixes) && suffixes[i] == suffix
+}
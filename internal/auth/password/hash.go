@@ -0,0 +1,135 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrMalformedHash means a stored hash claims a known algorithm but cannot
+// be parsed.
+var ErrMalformedHash = errors.New("malformed password hash")
+
+// Hasher hashes passwords into self-describing PHC strings and verifies
+// them, including hashes from older algorithms.
+type Hasher interface {
+	Hash(password string) (string, error)
+	// Verify reports whether password matches encoded. rehash is true when
+	// encoded uses an outdated algorithm or parameters and should be
+	// replaced by Hash(password) now that the password is known.
+	Verify(password, encoded string) (ok, rehash bool, err error)
+}
+
+// Argon2Params tunes argon2id. Memory is in KiB.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLen     uint32
+	KeyLen      uint32
+}
+
+// DefaultArgon2Params follow the OWASP baseline for argon2id.
+var DefaultArgon2Params = Argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLen:     16,
+	KeyLen:      32,
+}
+
+type argon2Hasher struct {
+	p Argon2Params
+}
+
+// ErrInvalidParams means argon2id cannot run with the given parameters.
+var ErrInvalidParams = errors.New("invalid argon2 parameters")
+
+// NewHasher returns a Hasher that hashes with argon2id and still verifies
+// legacy bcrypt hashes, flagging them for rehash. Parameters argon2id would
+// panic on are refused here, at startup, instead of on every hash.
+func NewHasher(p Argon2Params) (Hasher, error) {
+	switch {
+	case p.Iterations < 1:
+		return nil, fmt.Errorf("%w: iterations must be at least 1", ErrInvalidParams)
+	case p.Parallelism < 1:
+		return nil, fmt.Errorf("%w: parallelism must be 1-255", ErrInvalidParams)
+	case p.Memory < 8*uint32(p.Parallelism):
+		return nil, fmt.Errorf("%w: memory must be at least 8 KiB per lane (%d KiB)", ErrInvalidParams, 8*uint32(p.Parallelism))
+	case p.SaltLen == 0 || p.KeyLen == 0:
+		return nil, fmt.Errorf("%w: salt and key length must be positive", ErrInvalidParams)
+	}
+	return argon2Hasher{p: p}, nil
+}
+
+// Hash returns $argon2id$v=19$m=<mem>,t=<iter>,p=<par>$<salt>$<key>, with
+// salt and key in unpadded base64.
+func (h argon2Hasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.p.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.p.Iterations, h.p.Memory, h.p.Parallelism, h.p.KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.p.Memory, h.p.Iterations, h.p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h argon2Hasher) Verify(password, encoded string) (bool, bool, error) {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return h.verifyArgon2(password, encoded)
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, fmt.Errorf("%w: %v", ErrMalformedHash, err)
+		}
+		return true, true, nil
+	default:
+		// no password set (e.g. SSO-only accounts) or unknown scheme
+		return false, false, nil
+	}
+}
+
+func (h argon2Hasher) verifyArgon2(password, encoded string) (bool, bool, error) {
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return false, false, ErrMalformedHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, false, ErrMalformedHash
+	}
+	var p Argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil ||
+		p.Iterations == 0 || p.Parallelism == 0 {
+		return false, false, ErrMalformedHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false, ErrMalformedHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return false, false, ErrMalformedHash
+	}
+	p.SaltLen, p.KeyLen = uint32(len(salt)), uint32(len(key))
+
+	got := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLen)
+	if subtle.ConstantTimeCompare(got, key) != 1 {
+		return false, false, nil
+	}
+	return true, p != h.p, nil
+}
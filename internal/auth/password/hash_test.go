@@ -0,0 +1,80 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fastParams keeps the tests quick; production uses DefaultArgon2Params.
+var fastParams = Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLen: 16, KeyLen: 32}
+
+func TestArgon2RoundTrip(t *testing.T) {
+	h, _ := NewHasher(fastParams)
+	enc, err := h.Hash("s3cret-pass")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if !strings.HasPrefix(enc, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("not a PHC argon2id string: %s", enc)
+	}
+	if ok, rehash, err := h.Verify("s3cret-pass", enc); !ok || rehash || err != nil {
+		t.Errorf("Verify(correct) = %v, %v, %v", ok, rehash, err)
+	}
+	if ok, _, err := h.Verify("wrong", enc); ok || err != nil {
+		t.Errorf("Verify(wrong) = %v, %v", ok, err)
+	}
+
+	// Parameters are read from the hash, so a tuned hasher still verifies
+	// old hashes and asks for a rehash.
+	tuned, _ := NewHasher(Argon2Params{Memory: 2048, Iterations: 1, Parallelism: 1, SaltLen: 16, KeyLen: 32})
+	if ok, rehash, err := tuned.Verify("s3cret-pass", enc); !ok || !rehash || err != nil {
+		t.Errorf("Verify(old params) = %v, %v, %v", ok, rehash, err)
+	}
+}
+
+func TestVerifyLegacyBcrypt(t *testing.T) {
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("old-pass"), bcrypt.MinCost)
+	h, _ := NewHasher(fastParams)
+	if ok, rehash, err := h.Verify("old-pass", string(legacy)); !ok || !rehash || err != nil {
+		t.Errorf("Verify(bcrypt) = %v, %v, %v", ok, rehash, err)
+	}
+	if ok, _, _ := h.Verify("nope", string(legacy)); ok {
+		t.Error("wrong password accepted for bcrypt hash")
+	}
+}
+
+func TestVerifyRejectsUnknownAndMalformed(t *testing.T) {
+	h, _ := NewHasher(fastParams)
+	if ok, _, err := h.Verify("", ""); ok || err != nil {
+		t.Errorf("empty hash: %v, %v", ok, err)
+	}
+	for _, enc := range []string{
+		"$argon2id$v=19$m=1024,t=1,p=1$bad",
+		"$argon2id$v=18$m=1024,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=1024,t=0,p=1$c2FsdA$a2V5",
+	} {
+		if ok, _, err := h.Verify("x", enc); ok || err == nil {
+			t.Errorf("Verify(%q) = %v, %v; want ErrMalformedHash", enc, ok, err)
+		}
+	}
+}
+
+func TestNewHasherRejectsInvalidParams(t *testing.T) {
+	for name, p := range map[string]Argon2Params{
+		"no iterations":     {Memory: 1024, Iterations: 0, Parallelism: 1, SaltLen: 16, KeyLen: 32},
+		"no parallelism":    {Memory: 1024, Iterations: 1, Parallelism: 0, SaltLen: 16, KeyLen: 32},
+		"too little memory": {Memory: 15, Iterations: 1, Parallelism: 2, SaltLen: 16, KeyLen: 32},
+		"no salt":           {Memory: 1024, Iterations: 1, Parallelism: 1, SaltLen: 0, KeyLen: 32},
+		"no key":            {Memory: 1024, Iterations: 1, Parallelism: 1, SaltLen: 16, KeyLen: 0},
+	} {
+		if _, err := NewHasher(p); !errors.Is(err, ErrInvalidParams) {
+			t.Errorf("%s: err = %v, want ErrInvalidParams", name, err)
+		}
+	}
+	if _, err := NewHasher(fastParams); err != nil {
+		t.Errorf("fastParams: %v", err)
+	}
+}
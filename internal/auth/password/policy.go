@@ -0,0 +1,87 @@
+// Package password decides whether a new password is acceptable: minimum
+// length, an estimated strength score, no reuse of the account email, and an
+// optional offline check against a corpus of breached password hashes.
+package password
+
+import (
+	"errors"
+	"strings"
+)
+
+// Rejection reasons, returned to clients as-is.
+const (
+	ReasonTooShort      = "too_short"
+	ReasonTooWeak       = "too_weak"
+	ReasonContainsEmail = "contains_email"
+	ReasonBreached      = "breached"
+)
+
+// PolicyError lists every rule a password failed.
+type PolicyError struct {
+	Reasons []string
+}
+
+func (e *PolicyError) Error() string {
+	return "password rejected: " + strings.Join(e.Reasons, ", ")
+}
+
+// Reasons returns the rejection reasons of err, if it is a *PolicyError.
+func Reasons(err error) ([]string, bool) {
+	var pe *PolicyError
+	if errors.As(err, &pe) {
+		return pe.Reasons, true
+	}
+	return nil, false
+}
+
+// Policy is the set of rules applied on signup, reset and password change.
+type Policy struct {
+	MinLength int     // in characters
+	MinScore  int     // 0..4, see Score
+	Breached  *Corpus // optional; nil disables the breach check
+}
+
+// Check validates password for the account with the given email. It returns
+// a *PolicyError when the password is rejected.
+func (p Policy) Check(password, email string) error {
+	var reasons []string
+	if len([]rune(password)) < p.MinLength {
+		reasons = append(reasons, ReasonTooShort)
+	}
+	if containsEmail(password, email) {
+		reasons = append(reasons, ReasonContainsEmail)
+	}
+	if Score(password, emailTokens(email)...) < p.MinScore {
+		reasons = append(reasons, ReasonTooWeak)
+	}
+	if p.Breached != nil && p.Breached.Contains(password) {
+		reasons = append(reasons, ReasonBreached)
+	}
+	if len(reasons) > 0 {
+		return &PolicyError{Reasons: reasons}
+	}
+	return nil
+}
+
+// containsEmail reports whether password contains the email or its local
+// part (case-insensitive). Local parts shorter than 3 characters are ignored.
+func containsEmail(password, email string) bool {
+	pw := strings.ToLower(password)
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return false
+	}
+	if strings.Contains(pw, email) {
+		return true
+	}
+	local, _, _ := strings.Cut(email, "@")
+	return len(local) >= 3 && strings.Contains(pw, local)
+}
+
+// emailTokens splits an email into words the strength estimator treats as
+// known to an attacker.
+func emailTokens(email string) []string {
+	return strings.FieldsFunc(strings.ToLower(email), func(r rune) bool {
+		return r == '@' || r == '.' || r == '_' || r == '-' || r == '+'
+	})
+}
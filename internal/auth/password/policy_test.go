@@ -0,0 +1,77 @@
+package password
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestScore(t *testing.T) {
+	cases := []struct {
+		pw       string
+		min, max int
+	}{
+		{"password", 0, 0},
+		{"P@ssw0rd", 0, 1},
+		{"12345678", 0, 0},
+		{"aaaaaaaaaa", 0, 0},
+		{"abcdefghij", 0, 0},
+		{"qwertyuiop", 0, 0},
+		{"Summer2024", 1, 2},
+		{"kx7#Vq2!mZ9p", 4, 4},
+		{"correct horse battery staple", 4, 4},
+	}
+	for _, tc := range cases {
+		if got := Score(tc.pw); got < tc.min || got > tc.max {
+			t.Errorf("Score(%q) = %d, want %d..%d", tc.pw, got, tc.min, tc.max)
+		}
+	}
+	// Known user inputs are cheap to guess.
+	if Score("ulpioulpio", "ulpio") > 1 {
+		t.Error("user input not treated as known")
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	corpus, err := ReadCorpus(strings.NewReader(
+		"# test corpus\n" + sha1Hex("Tr0ub4dor&3") + ":42\n\n" + strings.ToLower(sha1Hex("hunter2")) + "\n"))
+	if err != nil {
+		t.Fatalf("ReadCorpus: %v", err)
+	}
+	if corpus.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", corpus.Len())
+	}
+	p := Policy{MinLength: 10, MinScore: 3, Breached: corpus}
+
+	cases := []struct {
+		pw      string
+		reasons []string
+	}{
+		{"kx7#Vq2!mZ9p", nil},
+		{"short", []string{ReasonTooShort, ReasonTooWeak}},
+		{"xX-ana.silva-Xx91", []string{ReasonContainsEmail}},
+		{"Tr0ub4dor&3", []string{ReasonBreached}},
+	}
+	for _, tc := range cases {
+		got, _ := Reasons(p.Check(tc.pw, "Ana.Silva@example.com"))
+		if !slices.Equal(got, tc.reasons) {
+			t.Errorf("Check(%q) reasons = %v, want %v", tc.pw, got, tc.reasons)
+		}
+	}
+	if !corpus.Contains("hunter2") || corpus.Contains("hunter3") {
+		t.Error("corpus lookup is wrong")
+	}
+}
+
+func TestReadCorpusRejectsGarbage(t *testing.T) {
+	if _, err := ReadCorpus(strings.NewReader("not-a-hash\n")); err == nil {
+		t.Error("invalid line accepted")
+	}
+}
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,227 @@
+package password
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Score estimates password strength on zxcvbn's 0..4 scale from the number
+// of guesses an attacker needs: under 10^3, 10^6, 10^8, 10^10, and above.
+// Like zxcvbn, it splits the password into the cheapest sequence of known
+// patterns (common passwords and words, l33t variants, repeats, sequences,
+// keyboard runs) and brute-forced characters. userInputs are words the
+// attacker is assumed to know, such as parts of the email.
+func Score(password string, userInputs ...string) int {
+	g := log10Guesses(password, userInputs)
+	switch {
+	case g < 3:
+		return 0
+	case g < 6:
+		return 1
+	case g < 8:
+		return 2
+	case g < 10:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// maxScored caps the part of the password that is pattern-matched; anything
+// longer is strong enough on length alone.
+const maxScored = 64
+
+// match is a pattern covering runes [i, j) of the password.
+type match struct {
+	i, j    int
+	guesses float64 // log10
+}
+
+func log10Guesses(password string, userInputs []string) float64 {
+	pw := []rune(password)
+	if len(pw) > maxScored {
+		pw = pw[:maxScored]
+	}
+	n := len(pw)
+	if n == 0 {
+		return 0
+	}
+
+	var matches []match
+	matches = append(matches, dictionaryMatches(pw, userInputs)...)
+	matches = append(matches, repeatMatches(pw)...)
+	matches = append(matches, sequenceMatches(pw)...)
+	matches = append(matches, keyboardMatches(pw)...)
+
+	// best[j] is the cheapest way to guess pw[:j]
+	best := make([]float64, n+1)
+	for j := 1; j <= n; j++ {
+		best[j] = best[j-1] + math.Log10(bruteforceCardinality(pw[j-1]))
+		for _, m := range matches {
+			if m.j == j && best[m.i]+m.guesses < best[j] {
+				best[j] = best[m.i] + m.guesses
+			}
+		}
+	}
+	return best[n]
+}
+
+func bruteforceCardinality(r rune) float64 {
+	switch {
+	case unicode.IsDigit(r):
+		return 10
+	case unicode.IsLower(r), unicode.IsUpper(r):
+		return 26
+	default:
+		return 33
+	}
+}
+
+var leet = map[rune]rune{
+	'4': 'a', '@': 'a', '8': 'b', '(': 'c', '3': 'e', '6': 'g', '1': 'i',
+	'!': 'i', '0': 'o', '$': 's', '5': 's', '7': 't', '+': 't', '2': 'z',
+}
+
+// dictionaryMatches finds common passwords, words and user inputs, also
+// when capitalised or written in l33t.
+func dictionaryMatches(pw []rune, userInputs []string) []match {
+	user := make(map[string]bool, len(userInputs))
+	for _, w := range userInputs {
+		if len(w) >= 3 {
+			user[w] = true
+		}
+	}
+	var out []match
+	for i := 0; i < len(pw); i++ {
+		for j := i + 3; j <= len(pw) && j-i <= maxWordLen; j++ {
+			word := pw[i:j]
+			lower := strings.ToLower(string(word))
+			unleeted := unleet(lower)
+
+			var rank float64
+			switch {
+			case user[lower]:
+				rank = 1
+			case ranks[lower] > 0:
+				rank = float64(ranks[lower])
+			case user[unleeted]:
+				rank = 2
+			case ranks[unleeted] > 0:
+				rank = float64(ranks[unleeted]) * 2
+			default:
+				continue
+			}
+			out = append(out, match{i: i, j: j, guesses: math.Log10(rank * caseVariations(word))})
+		}
+	}
+	return out
+}
+
+func unleet(s string) string {
+	return strings.Map(func(r rune) rune {
+		if l, ok := leet[r]; ok {
+			return l
+		}
+		return r
+	}, s)
+}
+
+// caseVariations is the guess multiplier for the capitalisation of a word.
+func caseVariations(word []rune) float64 {
+	var upper, lower int
+	for _, r := range word {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+	switch {
+	case upper == 0:
+		return 1
+	case lower == 0, upper == 1 && unicode.IsUpper(word[0]), upper == 1 && unicode.IsUpper(word[len(word)-1]):
+		return 2
+	default:
+		return math.Pow(2, float64(min(upper, lower)+1))
+	}
+}
+
+// repeatMatches finds runs of one repeated character ("aaaa").
+func repeatMatches(pw []rune) []match {
+	var out []match
+	for i := 0; i < len(pw); {
+		j := i + 1
+		for j < len(pw) && pw[j] == pw[i] {
+			j++
+		}
+		if j-i >= 3 {
+			out = append(out, match{i: i, j: j, guesses: math.Log10(bruteforceCardinality(pw[i]) * float64(j-i))})
+		}
+		i = j
+	}
+	return out
+}
+
+// sequenceMatches finds ascending or descending runs ("abcd", "9876").
+func sequenceMatches(pw []rune) []match {
+	var out []match
+	for i := 0; i < len(pw)-2; {
+		delta := pw[i+1] - pw[i]
+		if delta != 1 && delta != -1 || !sameClass(pw[i], pw[i+1]) {
+			i++
+			continue
+		}
+		j := i + 2
+		for j < len(pw) && pw[j]-pw[j-1] == delta && sameClass(pw[j-1], pw[j]) {
+			j++
+		}
+		if j-i >= 3 {
+			base := bruteforceCardinality(pw[i])
+			if strings.ContainsRune("aA019zZ", pw[i]) {
+				base = 4 // obvious starting points
+			}
+			if delta < 0 {
+				base *= 2
+			}
+			out = append(out, match{i: i, j: j, guesses: math.Log10(base * float64(j-i))})
+		}
+		i = j - 1
+	}
+	return out
+}
+
+func sameClass(a, b rune) bool {
+	return unicode.IsDigit(a) && unicode.IsDigit(b) ||
+		unicode.IsLower(a) && unicode.IsLower(b) ||
+		unicode.IsUpper(a) && unicode.IsUpper(b)
+}
+
+var keyboardRows = []string{"`1234567890-=", "qwertyuiop[]\\", "asdfghjkl;'", "zxcvbnm,./"}
+
+// keyboardMatches finds runs of horizontally adjacent keys ("qwer", "lkjh").
+func keyboardMatches(pw []rune) []match {
+	var out []match
+	for i := 0; i < len(pw)-3; {
+		j := i + 1
+		for j < len(pw) && adjacentKeys(unicode.ToLower(pw[j-1]), unicode.ToLower(pw[j])) {
+			j++
+		}
+		if j-i >= 4 {
+			out = append(out, match{i: i, j: j, guesses: math.Log10(40 * float64(j-i))})
+		}
+		i = j
+	}
+	return out
+}
+
+func adjacentKeys(a, b rune) bool {
+	for _, row := range keyboardRows {
+		ia, ib := strings.IndexRune(row, a), strings.IndexRune(row, b)
+		if ia >= 0 && ib >= 0 && (ia-ib == 1 || ib-ia == 1) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package password
+
+import "strings"
+
+// common lists frequently used passwords and words, most common first. The
+// rank of an entry is its guess count in the strength estimate.
+var common = strings.Fields(`
+password 123456 12345678 qwerty 123456789 12345 1234 111111 1234567 dragon
+123123 baseball abc123 football monkey letmein 696969 shadow master 666666
+qwertyuiop 123321 mustang 1234567890 michael 654321 superman 1qaz2wsx 7777777
+121212 000000 qazwsx 123qwe killer trustno1 jordan jennifer zxcvbnm asdfgh
+hunter buster soccer harley batman andrew tigger sunshine iloveyou 2000 charlie
+robert thomas hockey ranger daniel starwars klaster 112233 george computer
+michelle jessica pepper 1111 zxcvbn 555555 11111111 131313 freedom 777777 pass
+maggie 159753 aaaaaa ginger princess joshua cheese amanda summer love ashley
+nicole chelsea biteme matthew access yankees 987654321 dallas austin thunder
+taylor matrix welcome admin login secret dragon changeme passw0rd administrator
+root toor qwerty123 password1 welcome1 abcdef abcd1234 football1 monkey1
+winter spring autumn fall january february march april may june july august
+september october november december monday tuesday wednesday thursday friday
+saturday sunday hello world love secret money flower orange purple banana apple
+chicken cookie coffee family friend friends happy heart angel baby lucky
+tiger lion eagle phoenix wizard merlin silver golden diamond blue red green
+black white yellow pink company office server database letmein access
+vergo
+`)
+
+var (
+	ranks      = make(map[string]int, len(common))
+	maxWordLen int
+)
+
+func init() {
+	for i, w := range common {
+		if _, dup := ranks[w]; !dup {
+			ranks[w] = i + 1
+		}
+		maxWordLen = max(maxWordLen, len(w))
+	}
+}
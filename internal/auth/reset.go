@@ -18,6 +18,8 @@ var (
 
 type ResetStore interface {
 	CreateResetToken(userID string) (plainToken string, err error)
+	// Lookup validates a token without consuming it.
+	Lookup(token string) (userID string, err error)
 	ValidateAndConsume(token string) (userID string, err error)
 }
 
@@ -49,24 +51,40 @@ func (s *resetStore) CreateResetToken(userID string) (string, error) {
 	return plain, nil
 }
 
+func (s *resetStore) Lookup(token string) (string, error) {
+	row, err := s.lookup(token)
+	if err != nil {
+		return "", err
+	}
+	return row.UserID, nil
+}
+
 func (s *resetStore) ValidateAndConsume(token string) (string, error) {
+	row, err := s.lookup(token)
+	if err != nil {
+		return "", err
+	}
+
+	_ = s.q.MarkPasswordResetUsed(context.Background(), row.ID)
+
+	return row.UserID, nil
+}
+
+func (s *resetStore) lookup(token string) (repo.GetPasswordResetByHashRow, error) {
 	hash := hashResetToken(token)
 
 	row, err := s.q.GetPasswordResetByHash(context.Background(), hash)
 	if errors.Is(err, sql.ErrNoRows) {
-		return "", ErrResetTokenInvalid
+		return repo.GetPasswordResetByHashRow{}, ErrResetTokenInvalid
 	}
 	if err != nil {
-		return "", err
+		return repo.GetPasswordResetByHashRow{}, err
 	}
 
 	if time.Now().After(row.ExpiresAt) {
-		return "", ErrResetTokenInvalid
+		return repo.GetPasswordResetByHashRow{}, ErrResetTokenInvalid
 	}
-
-	_ = s.q.MarkPasswordResetUsed(context.Background(), row.ID)
-
-	return row.UserID, nil
+	return row, nil
 }
 
 func generateResetToken() (string, error) {
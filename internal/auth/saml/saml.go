@@ -0,0 +1,376 @@
+// Package saml implements the service-provider side of SAML 2.0 Web Browser
+// SSO: HTTP-Redirect AuthnRequests, SP metadata, IdP metadata parsing and
+// validation of signed responses posted to the ACS endpoint.
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+)
+
+const (
+	nsProtocol  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	nsAssertion = "urn:oasis:names:tc:SAML:2.0:assertion"
+	nsMetadata  = "urn:oasis:names:tc:SAML:2.0:metadata"
+
+	bindingRedirect = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+	bindingPOST     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	statusSuccess   = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	nameIDEmail     = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+	cmBearer        = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+
+	// MaxClockSkew tolerated when checking assertion validity windows.
+	MaxClockSkew = 2 * time.Minute
+)
+
+var (
+	ErrInvalidResponse = errors.New("saml: invalid response")
+	ErrStatus          = errors.New("saml: idp returned a non-success status")
+	ErrExpired         = errors.New("saml: assertion is not valid at this time")
+	ErrAudience        = errors.New("saml: assertion audience mismatch")
+	ErrIssuer          = errors.New("saml: unexpected issuer")
+	ErrEncrypted       = errors.New("saml: encrypted assertions are not supported")
+)
+
+// ServiceProvider describes this application for a single organization.
+type ServiceProvider struct {
+	EntityID string
+	ACSURL   string
+}
+
+// IdentityProvider is the trusted configuration of an organization's IdP.
+type IdentityProvider struct {
+	EntityID    string
+	SSOURL      string
+	Certificate *x509.Certificate
+}
+
+// Assertion holds the validated contents of a SAML assertion.
+type Assertion struct {
+	ID           string
+	Issuer       string
+	NameID       string
+	NameIDFormat string
+	SessionIndex string
+	InResponseTo string
+	NotOnOrAfter time.Time
+	Attributes   map[string][]string
+}
+
+// Attribute returns the first value of the named attribute.
+func (a *Assertion) Attribute(name string) string {
+	if v := a.Attributes[name]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// NewRequestID returns an xs:ID compatible identifier (must not start with a digit).
+func NewRequestID() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "_" + hex.EncodeToString(b), nil
+}
+
+// AuthnRequestURL builds the HTTP-Redirect binding URL for an AuthnRequest
+// with the given ID. The request is unsigned; IdPs bind it to the SP through
+// the registered ACS URL.
+func AuthnRequestURL(sp ServiceProvider, idp IdentityProvider, id, relayState string, now time.Time) (string, error) {
+	var req bytes.Buffer
+	fmt.Fprintf(&req,
+		`<samlp:AuthnRequest xmlns:samlp="%s" xmlns:saml="%s" ID="%s" Version="2.0" IssueInstant="%s" Destination="%s" AssertionConsumerServiceURL="%s" ProtocolBinding="%s">`,
+		nsProtocol, nsAssertion, escapeAttr(id), now.UTC().Format(time.RFC3339), escapeAttr(idp.SSOURL), escapeAttr(sp.ACSURL), bindingPOST)
+	fmt.Fprintf(&req, `<saml:Issuer>%s</saml:Issuer>`, escapeText(sp.EntityID))
+	fmt.Fprintf(&req, `<samlp:NameIDPolicy Format="%s" AllowCreate="true"/>`, nameIDEmail)
+	req.WriteString(`</samlp:AuthnRequest>`)
+
+	var deflated bytes.Buffer
+	w, err := flate.NewWriter(&deflated, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(req.Bytes()); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(idp.SSOURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("SAMLRequest", base64.StdEncoding.EncodeToString(deflated.Bytes()))
+	if relayState != "" {
+		q.Set("RelayState", relayState)
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// Metadata renders the SP metadata document to hand to the IdP administrator.
+func Metadata(sp ServiceProvider) []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	fmt.Fprintf(&b, `<md:EntityDescriptor xmlns:md="%s" entityID="%s">`, nsMetadata, escapeAttr(sp.EntityID))
+	fmt.Fprintf(&b, `<md:SPSSODescriptor AuthnRequestsSigned="false" WantAssertionsSigned="true" protocolSupportEnumeration="%s">`, nsProtocol)
+	fmt.Fprintf(&b, `<md:NameIDFormat>%s</md:NameIDFormat>`, nameIDEmail)
+	fmt.Fprintf(&b, `<md:AssertionConsumerService Binding="%s" Location="%s" index="0" isDefault="true"/>`, bindingPOST, escapeAttr(sp.ACSURL))
+	b.WriteString(`</md:SPSSODescriptor></md:EntityDescriptor>`)
+	return b.Bytes()
+}
+
+type idpMetadata struct {
+	EntityID string `xml:"entityID,attr"`
+	IDPSSO   struct {
+		KeyDescriptors []struct {
+			Use  string `xml:"use,attr"`
+			Cert string `xml:"KeyInfo>X509Data>X509Certificate"`
+		} `xml:"KeyDescriptor"`
+		SSOServices []struct {
+			Binding  string `xml:"Binding,attr"`
+			Location string `xml:"Location,attr"`
+		} `xml:"SingleSignOnService"`
+	} `xml:"IDPSSODescriptor"`
+}
+
+// ParseIdPMetadata extracts the entity ID, HTTP-Redirect SSO URL and signing
+// certificate from an IdP EntityDescriptor.
+func ParseIdPMetadata(data []byte) (IdentityProvider, string, error) {
+	var md idpMetadata
+	if err := xml.Unmarshal(data, &md); err != nil {
+		return IdentityProvider{}, "", fmt.Errorf("saml: parse metadata: %w", err)
+	}
+	var idp IdentityProvider
+	idp.EntityID = md.EntityID
+	for _, s := range md.IDPSSO.SSOServices {
+		if s.Binding == bindingRedirect {
+			idp.SSOURL = s.Location
+			break
+		}
+	}
+	var certB64 string
+	for _, k := range md.IDPSSO.KeyDescriptors {
+		if k.Use == "" || k.Use == "signing" {
+			certB64 = k.Cert
+			break
+		}
+	}
+	if idp.EntityID == "" || idp.SSOURL == "" || certB64 == "" {
+		return IdentityProvider{}, "", errors.New("saml: metadata lacks entityID, redirect SSO service or signing certificate")
+	}
+	der, err := decodeB64(certB64)
+	if err != nil {
+		return IdentityProvider{}, "", fmt.Errorf("saml: metadata certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return IdentityProvider{}, "", fmt.Errorf("saml: metadata certificate: %w", err)
+	}
+	idp.Certificate = cert
+	return idp, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})), nil
+}
+
+// ParseCertificate accepts a PEM block or bare base64 DER certificate.
+func ParseCertificate(s string) (*x509.Certificate, error) {
+	s = strings.TrimSpace(s)
+	if blk, _ := pem.Decode([]byte(s)); blk != nil {
+		return x509.ParseCertificate(blk.Bytes)
+	}
+	der, err := decodeB64(s)
+	if err != nil {
+		return nil, fmt.Errorf("saml: certificate encoding: %w", err)
+	}
+	return x509.ParseCertificate(der)
+}
+
+// ParseResponse decodes a base64 SAMLResponse from the HTTP-POST binding,
+// verifies its signature against the IdP certificate and validates the
+// assertion conditions. The caller must still check InResponseTo against
+// its outstanding requests and reject replayed assertion IDs.
+func ParseResponse(samlResponse string, sp ServiceProvider, idp IdentityProvider, now time.Time) (*Assertion, error) {
+	raw, err := decodeB64(samlResponse)
+	if err != nil {
+		return nil, fmt.Errorf("%w: encoding", ErrInvalidResponse)
+	}
+	root, err := parseDocument(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	if !is(root, nsProtocol, "Response") {
+		return nil, fmt.Errorf("%w: not a Response", ErrInvalidResponse)
+	}
+
+	// XML signature wrapping defence: IDs must be unique, the response must
+	// carry exactly one assertion as a direct child, and everything we read
+	// comes from the signed copy goxmldsig hands back.
+	ids := map[string]bool{}
+	var dup bool
+	walk(root, func(e *etree.Element) {
+		if id := attr(e, "ID"); id != "" {
+			dup = dup || ids[id]
+			ids[id] = true
+		}
+	})
+	if dup {
+		return nil, fmt.Errorf("%w: duplicate ID attributes", ErrInvalidResponse)
+	}
+	if child(root, nsAssertion, "EncryptedAssertion") != nil {
+		return nil, ErrEncrypted
+	}
+	assertions := childrenNamed(root, nsAssertion, "Assertion")
+	if len(assertions) != 1 {
+		return nil, fmt.Errorf("%w: expected exactly one assertion", ErrInvalidResponse)
+	}
+	as := assertions[0]
+
+	switch {
+	case child(as, nsDSig, "Signature") != nil:
+		if as, err = verifyEnveloped(as, idp.Certificate, now); err != nil {
+			return nil, err
+		}
+	case child(root, nsDSig, "Signature") != nil:
+		if root, err = verifyEnveloped(root, idp.Certificate, now); err != nil {
+			return nil, err
+		}
+		if assertions = childrenNamed(root, nsAssertion, "Assertion"); len(assertions) != 1 {
+			return nil, fmt.Errorf("%w: expected exactly one assertion", ErrInvalidResponse)
+		}
+		as = assertions[0]
+	default:
+		return nil, fmt.Errorf("%w: unsigned response", ErrSignature)
+	}
+
+	if d := attr(root, "Destination"); d != "" && d != sp.ACSURL {
+		return nil, fmt.Errorf("%w: destination mismatch", ErrInvalidResponse)
+	}
+	if st := child(root, nsProtocol, "Status"); st != nil {
+		if sc := child(st, nsProtocol, "StatusCode"); sc == nil || attr(sc, "Value") != statusSuccess {
+			return nil, ErrStatus
+		}
+	}
+	if ri := child(root, nsAssertion, "Issuer"); ri != nil && text(ri) != idp.EntityID {
+		return nil, ErrIssuer
+	}
+
+	out := &Assertion{ID: attr(as, "ID"), InResponseTo: attr(root, "InResponseTo"), Attributes: map[string][]string{}}
+	iss := child(as, nsAssertion, "Issuer")
+	if iss == nil || text(iss) != idp.EntityID {
+		return nil, ErrIssuer
+	}
+	out.Issuer = text(iss)
+
+	if err := checkConditions(as, sp, now); err != nil {
+		return nil, err
+	}
+	if err := readSubject(as, sp, now, out); err != nil {
+		return nil, err
+	}
+
+	if stmt := child(as, nsAssertion, "AuthnStatement"); stmt != nil {
+		out.SessionIndex = attr(stmt, "SessionIndex")
+	}
+	for _, stmt := range childrenNamed(as, nsAssertion, "AttributeStatement") {
+		for _, a := range childrenNamed(stmt, nsAssertion, "Attribute") {
+			name := attr(a, "Name")
+			for _, v := range childrenNamed(a, nsAssertion, "AttributeValue") {
+				out.Attributes[name] = append(out.Attributes[name], text(v))
+			}
+		}
+	}
+	return out, nil
+}
+
+func checkConditions(as *etree.Element, sp ServiceProvider, now time.Time) error {
+	cond := child(as, nsAssertion, "Conditions")
+	if cond == nil {
+		return fmt.Errorf("%w: missing conditions", ErrInvalidResponse)
+	}
+	if err := checkWindow(attr(cond, "NotBefore"), attr(cond, "NotOnOrAfter"), now); err != nil {
+		return err
+	}
+	restrictions := childrenNamed(cond, nsAssertion, "AudienceRestriction")
+	if len(restrictions) == 0 {
+		return ErrAudience
+	}
+	// Every AudienceRestriction must include us.
+	for _, r := range restrictions {
+		ok := false
+		for _, a := range childrenNamed(r, nsAssertion, "Audience") {
+			if text(a) == sp.EntityID {
+				ok = true
+			}
+		}
+		if !ok {
+			return ErrAudience
+		}
+	}
+	return nil
+}
+
+func readSubject(as *etree.Element, sp ServiceProvider, now time.Time, out *Assertion) error {
+	subj := child(as, nsAssertion, "Subject")
+	if subj == nil {
+		return fmt.Errorf("%w: missing subject", ErrInvalidResponse)
+	}
+	nid := child(subj, nsAssertion, "NameID")
+	if nid == nil || text(nid) == "" {
+		return fmt.Errorf("%w: missing NameID", ErrInvalidResponse)
+	}
+	out.NameID = text(nid)
+	out.NameIDFormat = attr(nid, "Format")
+
+	for _, sc := range childrenNamed(subj, nsAssertion, "SubjectConfirmation") {
+		if attr(sc, "Method") != cmBearer {
+			continue
+		}
+		data := child(sc, nsAssertion, "SubjectConfirmationData")
+		if data == nil || attr(data, "Recipient") != sp.ACSURL || attr(data, "NotOnOrAfter") == "" {
+			continue
+		}
+		if checkWindow(attr(data, "NotBefore"), attr(data, "NotOnOrAfter"), now) != nil {
+			continue
+		}
+		if irt := attr(data, "InResponseTo"); irt != "" && out.InResponseTo != "" && irt != out.InResponseTo {
+			continue
+		}
+		if out.InResponseTo == "" {
+			out.InResponseTo = attr(data, "InResponseTo")
+		}
+		out.NotOnOrAfter, _ = time.Parse(time.RFC3339, attr(data, "NotOnOrAfter"))
+		return nil
+	}
+	return fmt.Errorf("%w: no valid bearer subject confirmation", ErrInvalidResponse)
+}
+
+func checkWindow(notBefore, notOnOrAfter string, now time.Time) error {
+	if notBefore != "" {
+		t, err := time.Parse(time.RFC3339, notBefore)
+		if err != nil || now.Add(MaxClockSkew).Before(t) {
+			return ErrExpired
+		}
+	}
+	if notOnOrAfter != "" {
+		t, err := time.Parse(time.RFC3339, notOnOrAfter)
+		if err != nil || !now.Add(-MaxClockSkew).Before(t) {
+			return ErrExpired
+		}
+	}
+	return nil
+}
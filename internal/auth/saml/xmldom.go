@@ -0,0 +1,122 @@
+package saml
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+// parseDocument reads a SAML message into an etree document. Signatures are
+// verified by goxmldsig on the same tree; these helpers only add the strict
+// checks and namespace-aware lookups the response parser needs.
+func parseDocument(data []byte) (*etree.Element, error) {
+	doc := etree.NewDocument()
+	doc.ReadSettings.PreserveDuplicateAttrs = true
+	if err := doc.ReadFromBytes(data); err != nil {
+		return nil, err
+	}
+
+	var root *etree.Element
+	for _, tok := range doc.Child {
+		switch t := tok.(type) {
+		case *etree.Element:
+			if root != nil {
+				return nil, errors.New("saml: multiple root elements")
+			}
+			root = t
+		case *etree.Directive:
+			// DTDs are never legitimate in SAML messages.
+			return nil, errors.New("saml: xml directives are not allowed")
+		case *etree.CharData:
+			if !t.IsWhitespace() {
+				return nil, errors.New("saml: malformed xml")
+			}
+		}
+	}
+	if root == nil {
+		return nil, errors.New("saml: malformed xml")
+	}
+
+	var bad error
+	walk(root, func(e *etree.Element) {
+		seen := map[string]bool{}
+		for i := range e.Attr {
+			k := e.Attr[i].FullKey()
+			if seen[k] {
+				bad = errors.New("saml: duplicate attributes")
+			}
+			seen[k] = true
+		}
+		for _, c := range e.Child {
+			if _, ok := c.(*etree.Directive); ok {
+				bad = errors.New("saml: xml directives are not allowed")
+			}
+		}
+	})
+	if bad != nil {
+		return nil, bad
+	}
+	return root, nil
+}
+
+func is(e *etree.Element, ns, local string) bool {
+	return e.Tag == local && e.NamespaceURI() == ns
+}
+
+func attr(e *etree.Element, local string) string {
+	for _, a := range e.Attr {
+		if a.Space == "" && a.Key == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func child(e *etree.Element, ns, local string) *etree.Element {
+	for _, c := range e.ChildElements() {
+		if is(c, ns, local) {
+			return c
+		}
+	}
+	return nil
+}
+
+func childrenNamed(e *etree.Element, ns, local string) []*etree.Element {
+	var out []*etree.Element
+	for _, c := range e.ChildElements() {
+		if is(c, ns, local) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// text joins every character data child of e. Comments are skipped rather
+// than ending the value, so "alice@acme.com<!---->.evil.com" reads as the
+// address that was signed, not as "alice@acme.com".
+func text(e *etree.Element) string {
+	var b strings.Builder
+	for _, c := range e.Child {
+		if cd, ok := c.(*etree.CharData); ok {
+			b.WriteString(cd.Data)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// walk visits every element of the subtree in document order.
+func walk(e *etree.Element, fn func(*etree.Element)) {
+	fn(e)
+	for _, c := range e.ChildElements() {
+		walk(c, fn)
+	}
+}
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+)
+
+func escapeText(s string) string { return textEscaper.Replace(s) }
+func escapeAttr(s string) string { return attrEscaper.Replace(s) }
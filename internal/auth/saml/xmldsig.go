@@ -0,0 +1,70 @@
+package saml
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/russellhaering/goxmldsig/etreeutils"
+)
+
+const nsDSig = dsig.Namespace
+
+var ErrSignature = errors.New("saml: invalid xml signature")
+
+// allowedAlgorithms are the signature and digest methods accepted on top of
+// what goxmldsig supports; SHA-1 is refused.
+var allowedAlgorithms = map[string]bool{
+	"http://www.w3.org/2001/04/xmldsig-more#rsa-sha256": true,
+	"http://www.w3.org/2001/04/xmldsig-more#rsa-sha512": true,
+	"http://www.w3.org/2001/04/xmlenc#sha256":           true,
+	"http://www.w3.org/2001/04/xmlenc#sha512":           true,
+}
+
+// verifyEnveloped checks the enveloped ds:Signature that is a direct child of
+// target and covers target itself, using goxmldsig. It returns the signed
+// copy of target with the signature removed and namespaces canonicalized;
+// callers must read from that copy, never from target, so nothing outside
+// what was signed can be smuggled in.
+func verifyEnveloped(target *etree.Element, cert *x509.Certificate, now time.Time) (*etree.Element, error) {
+	if n := len(childrenNamed(target, nsDSig, "Signature")); n != 1 {
+		return nil, fmt.Errorf("%w: expected one signature, found %d", ErrSignature, n)
+	}
+	var weak string
+	walk(target, func(e *etree.Element) {
+		if (is(e, nsDSig, "SignatureMethod") || is(e, nsDSig, "DigestMethod")) && !allowedAlgorithms[attr(e, "Algorithm")] {
+			weak = attr(e, "Algorithm")
+		}
+	})
+	if weak != "" {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrSignature, weak)
+	}
+
+	// Detach target with the namespace declarations it inherits, so a signed
+	// assertion verifies the same way inside and outside its Response.
+	ctx, err := etreeutils.NSBuildParentContext(target)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSignature, err)
+	}
+	detached, err := etreeutils.NSDetatch(ctx, target)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSignature, err)
+	}
+
+	vc := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{Roots: []*x509.Certificate{cert}})
+	vc.Clock = dsig.NewFakeClockAt(now)
+	signed, err := vc.Validate(detached)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSignature, err)
+	}
+	return signed, nil
+}
+
+func decodeB64(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+}
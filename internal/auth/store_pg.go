@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"crypto/sha256"
+	"crypto/subtle"
 	"database/sql"
 	"encoding/hex"
 	"errors"
@@ -11,11 +12,60 @@ import (
 	"github.com/Ulpio/vergo/internal/repo"
 )
 
+var (
+	ErrRefreshInvalid = errors.New("invalid or expired refresh token")
+	// ErrRefreshReused means an already rotated refresh token was presented
+	// again, so someone else may hold a copy; its whole family is revoked.
+	ErrRefreshReused   = errors.New("refresh token reuse detected")
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// SessionInfo describes the client behind a login or refresh.
+type SessionInfo struct {
+	UserAgent string
+	IP        string
+	GeoLabel  string // approximate location, e.g. "Lisbon, PT"; may be empty
+}
+
+// Session is one login on one device: a refresh token family plus the
+// client that last used it.
+type Session struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	GeoLabel   string    `json:"geo_label,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+// MintRefresh signs a new refresh token for userID.
+type MintRefresh func(userID string) (jti, token string, expiresAt time.Time, err error)
+
+// Rotation describes the outcome of RefreshStore.Rotate.
+type Rotation struct {
+	UserID   string
+	FamilyID string
+	Token    string // the new refresh token; empty when reuse was detected
+	Revoked  int64  // tokens revoked because of reuse
+}
+
 type RefreshStore interface {
-	SaveRefresh(ctx context.Context, jti, userID, token string, expiresAt time.Time, rotatedFrom *string) error
-	IsValid(ctx context.Context, jti, token string) (bool, string, time.Time, error)
+	// SaveRefresh persists the first token of a new family (a login) and
+	// opens its session.
+	SaveRefresh(ctx context.Context, jti, userID, token string, expiresAt time.Time, info SessionInfo) error
+	// Rotate exchanges a valid refresh token for a new one in the same
+	// family, in a single transaction, and records the session's use.
+	// Presenting a token that was already rotated revokes the whole family
+	// and returns ErrRefreshReused along with the affected user and family.
+	Rotate(ctx context.Context, jti, token string, info SessionInfo, mint MintRefresh) (Rotation, error)
 	Revoke(ctx context.Context, jti string) error
 	RevokeAllForUser(ctx context.Context, userID string) error
+
+	// ListSessions returns the user's sessions that can still refresh,
+	// most recently used first.
+	ListSessions(ctx context.Context, userID string) ([]Session, error)
+	// RevokeSession revokes every token of one of the user's sessions.
+	RevokeSession(ctx context.Context, userID, sessionID string) error
 }
 
 type pgStore struct {
@@ -32,37 +82,111 @@ func hash(token string) string {
 	return hex.EncodeToString(sum[:])
 }
 
-func (s *pgStore) SaveRefresh(ctx context.Context, jti, userID, token string, expiresAt time.Time, rotatedFrom *string) error {
-	params := repo.InsertRefreshTokenParams{
+func (s *pgStore) SaveRefresh(ctx context.Context, jti, userID, token string, expiresAt time.Time, info SessionInfo) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := s.q.WithTx(tx)
+
+	err = qtx.InsertSession(ctx, repo.InsertSessionParams{
+		ID:        jti,
+		UserID:    userID,
+		UserAgent: info.UserAgent,
+		IP:        info.IP,
+		GeoLabel:  info.GeoLabel,
+	})
+	if err != nil {
+		return err
+	}
+	err = qtx.InsertRefreshToken(ctx, repo.InsertRefreshTokenParams{
 		ID:        jti,
 		UserID:    userID,
 		TokenHash: hash(token),
 		ExpiresAt: expiresAt,
+		FamilyID:  jti,
+	})
+	if err != nil {
+		return err
 	}
-	if rotatedFrom != nil {
-		params.RotatedFrom = sql.NullString{String: *rotatedFrom, Valid: true}
-	}
-	return s.q.InsertRefreshToken(ctx, params)
+	return tx.Commit()
 }
 
-func (s *pgStore) IsValid(ctx context.Context, jti, token string) (bool, string, time.Time, error) {
-	row, err := s.q.GetRefreshToken(ctx, jti)
+func (s *pgStore) Rotate(ctx context.Context, jti, token string, info SessionInfo, mint MintRefresh) (Rotation, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Rotation{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := s.q.WithTx(tx)
+
+	// The row lock serializes concurrent refreshes of the same token: the
+	// loser sees it revoked and rotated, which is treated as reuse.
+	row, err := qtx.GetRefreshTokenForUpdate(ctx, jti)
 	if errors.Is(err, sql.ErrNoRows) {
-		return false, "", time.Time{}, nil
+		return Rotation{}, ErrRefreshInvalid
 	}
 	if err != nil {
-		return false, "", time.Time{}, err
+		return Rotation{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(row.TokenHash), []byte(hash(token))) != 1 {
+		return Rotation{}, ErrRefreshInvalid
 	}
+	rot := Rotation{UserID: row.UserID, FamilyID: row.FamilyID}
+
 	if row.RevokedAt.Valid {
-		return false, "", time.Time{}, nil
+		// Logged-out tokens are simply dead; only a token that already has a
+		// successor signals that a copy is in someone else's hands.
+		if !row.Rotated {
+			return Rotation{}, ErrRefreshInvalid
+		}
+		res, err := qtx.RevokeTokenFamily(ctx, row.FamilyID)
+		if err != nil {
+			return Rotation{}, err
+		}
+		rot.Revoked, _ = res.RowsAffected()
+		if err := tx.Commit(); err != nil {
+			return Rotation{}, err
+		}
+		return rot, ErrRefreshReused
 	}
 	if time.Now().After(row.ExpiresAt) {
-		return false, "", time.Time{}, nil
+		return Rotation{}, ErrRefreshInvalid
+	}
+
+	newJTI, newToken, exp, err := mint(row.UserID)
+	if err != nil {
+		return Rotation{}, err
+	}
+	if err := qtx.RevokeToken(ctx, jti); err != nil {
+		return Rotation{}, err
+	}
+	err = qtx.InsertRefreshToken(ctx, repo.InsertRefreshTokenParams{
+		ID:          newJTI,
+		UserID:      row.UserID,
+		TokenHash:   hash(newToken),
+		ExpiresAt:   exp,
+		RotatedFrom: sql.NullString{String: jti, Valid: true},
+		FamilyID:    row.FamilyID,
+	})
+	if err != nil {
+		return Rotation{}, err
+	}
+	err = qtx.TouchSession(ctx, repo.TouchSessionParams{
+		ID:        row.FamilyID,
+		UserAgent: info.UserAgent,
+		IP:        info.IP,
+		GeoLabel:  info.GeoLabel,
+	})
+	if err != nil {
+		return Rotation{}, err
 	}
-	if row.TokenHash != hash(token) {
-		return false, "", time.Time{}, nil
+	if err := tx.Commit(); err != nil {
+		return Rotation{}, err
 	}
-	return true, row.UserID, row.ExpiresAt, nil
+	rot.Token = newToken
+	return rot, nil
 }
 
 func (s *pgStore) Revoke(ctx context.Context, jti string) error {
@@ -72,3 +196,36 @@ func (s *pgStore) Revoke(ctx context.Context, jti string) error {
 func (s *pgStore) RevokeAllForUser(ctx context.Context, userID string) error {
 	return s.q.RevokeAllUserTokens(ctx, userID)
 }
+
+func (s *pgStore) ListSessions(ctx context.Context, userID string) ([]Session, error) {
+	rows, err := s.q.ListActiveSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Session, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, Session{
+			ID:         r.ID,
+			UserAgent:  r.UserAgent,
+			IP:         r.IP,
+			GeoLabel:   r.GeoLabel,
+			CreatedAt:  r.CreatedAt,
+			LastUsedAt: r.LastUsedAt,
+		})
+	}
+	return out, nil
+}
+
+func (s *pgStore) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	res, err := s.q.RevokeUserTokenFamily(ctx, repo.RevokeUserTokenFamilyParams{
+		FamilyID: sessionID,
+		UserID:   userID,
+	})
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+//go:build integration
+
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Ulpio/vergo/internal/auth"
+	"github.com/Ulpio/vergo/internal/domain/user"
+	"github.com/Ulpio/vergo/internal/pkg/testutil"
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+func mintRefresh(userID string) (string, string, time.Time, error) {
+	rt, jti, exp, err := auth.NewRefreshToken(userID, "secret", 1)
+	return jti, rt, exp, err
+}
+
+func TestRefreshStore_RotateAndReuse(t *testing.T) {
+	db := testutil.PGContainer(t)
+	q := repo.New(db)
+	u, err := user.NewPostgresService(db, q, nil).Signup("rotate@test.com", "pass123")
+	if err != nil {
+		t.Fatalf("signup: %v", err)
+	}
+	store := auth.NewRefreshStore(db, q)
+	ctx := context.Background()
+
+	jti0, rt0, exp, _ := mintRefresh(u.ID)
+	if err := store.SaveRefresh(ctx, jti0, u.ID, rt0, exp, auth.SessionInfo{}); err != nil {
+		t.Fatalf("SaveRefresh: %v", err)
+	}
+
+	rot1, err := store.Rotate(ctx, jti0, rt0, auth.SessionInfo{}, mintRefresh)
+	if err != nil || rot1.UserID != u.ID || rot1.FamilyID != jti0 {
+		t.Fatalf("first rotation: %+v, %v", rot1, err)
+	}
+	claims1, _ := auth.ParseRefresh(rot1.Token, "secret")
+	rot2, err := store.Rotate(ctx, claims1.ID, rot1.Token, auth.SessionInfo{}, mintRefresh)
+	if err != nil {
+		t.Fatalf("second rotation: %v", err)
+	}
+	claims2, _ := auth.ParseRefresh(rot2.Token, "secret")
+
+	// Presenting the original token again revokes the live descendant.
+	reuse, err := store.Rotate(ctx, jti0, rt0, auth.SessionInfo{}, mintRefresh)
+	if !errors.Is(err, auth.ErrRefreshReused) {
+		t.Fatalf("reuse: err = %v, want ErrRefreshReused", err)
+	}
+	if reuse.UserID != u.ID || reuse.FamilyID != jti0 || reuse.Revoked != 1 {
+		t.Errorf("reuse = %+v", reuse)
+	}
+	if _, err := store.Rotate(ctx, claims2.ID, rot2.Token, auth.SessionInfo{}, mintRefresh); err == nil {
+		t.Error("descendant still valid after reuse")
+	}
+
+	// A wrong token for a known jti is invalid, not reuse.
+	if _, err := store.Rotate(ctx, jti0, "forged", auth.SessionInfo{}, mintRefresh); !errors.Is(err, auth.ErrRefreshInvalid) {
+		t.Errorf("forged token: err = %v, want ErrRefreshInvalid", err)
+	}
+}
+
+func TestRefreshStore_LogoutIsNotReuse(t *testing.T) {
+	db := testutil.PGContainer(t)
+	q := repo.New(db)
+	u, _ := user.NewPostgresService(db, q, nil).Signup("logout@test.com", "pass123")
+	store := auth.NewRefreshStore(db, q)
+	ctx := context.Background()
+
+	jti, rt, exp, _ := mintRefresh(u.ID)
+	_ = store.SaveRefresh(ctx, jti, u.ID, rt, exp, auth.SessionInfo{})
+	_ = store.Revoke(ctx, jti)
+
+	if _, err := store.Rotate(ctx, jti, rt, auth.SessionInfo{}, mintRefresh); !errors.Is(err, auth.ErrRefreshInvalid) {
+		t.Errorf("revoked token: err = %v, want ErrRefreshInvalid", err)
+	}
+}
+
+func TestRefreshStore_Sessions(t *testing.T) {
+	db := testutil.PGContainer(t)
+	q := repo.New(db)
+	users := user.NewPostgresService(db, q, nil)
+	u, _ := users.Signup("sessions@test.com", "pass123")
+	other, _ := users.Signup("other@test.com", "pass123")
+	store := auth.NewRefreshStore(db, q)
+	ctx := context.Background()
+
+	laptop := auth.SessionInfo{UserAgent: "Firefox", IP: "203.0.113.7", GeoLabel: "PT"}
+	jtiA, rtA, exp, _ := mintRefresh(u.ID)
+	if err := store.SaveRefresh(ctx, jtiA, u.ID, rtA, exp, laptop); err != nil {
+		t.Fatalf("SaveRefresh: %v", err)
+	}
+	jtiB, rtB, _, _ := mintRefresh(u.ID)
+	_ = store.SaveRefresh(ctx, jtiB, u.ID, rtB, exp, auth.SessionInfo{UserAgent: "curl"})
+
+	// Rotating keeps the session and records the client that used it.
+	phone := auth.SessionInfo{UserAgent: "Safari", IP: "198.51.100.2", GeoLabel: "BR"}
+	if _, err := store.Rotate(ctx, jtiA, rtA, phone, mintRefresh); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	sessions, err := store.ListSessions(ctx, u.ID)
+	if err != nil || len(sessions) != 2 {
+		t.Fatalf("ListSessions = %+v, %v", sessions, err)
+	}
+	got := sessions[0]
+	if got.ID != jtiA || got.UserAgent != "Safari" || got.IP != "198.51.100.2" || got.GeoLabel != "BR" {
+		t.Errorf("most recent session = %+v", got)
+	}
+
+	// Another user cannot revoke the session.
+	if err := store.RevokeSession(ctx, other.ID, jtiA); !errors.Is(err, auth.ErrSessionNotFound) {
+		t.Errorf("foreign revoke: err = %v, want ErrSessionNotFound", err)
+	}
+	if err := store.RevokeSession(ctx, u.ID, jtiA); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	sessions, _ = store.ListSessions(ctx, u.ID)
+	if len(sessions) != 1 || sessions[0].ID != jtiB {
+		t.Errorf("after revoke = %+v", sessions)
+	}
+	if err := store.RevokeSession(ctx, u.ID, jtiA); !errors.Is(err, auth.ErrSessionNotFound) {
+		t.Errorf("second revoke: err = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestLockoutTracker(t *testing.T) {
+	db := testutil.PGContainer(t)
+	q := repo.New(db)
+	tracker := auth.NewLockoutTracker(q, auth.LockoutPolicy{
+		AccountMaxFailures: 3,
+		IPMaxFailures:      5,
+		CaptchaAfter:       2,
+		BaseLockout:        time.Minute,
+		MaxLockout:         time.Hour,
+		Window:             15 * time.Minute,
+	})
+	ctx := context.Background()
+	now := time.Now()
+
+	att, _ := tracker.Fail(ctx, auth.ScopeLogin, "Victim@Test.com", "203.0.113.1")
+	if att.Locked(now) || att.CaptchaRequired {
+		t.Fatalf("after 1 failure: %+v", att)
+	}
+	att, _ = tracker.Fail(ctx, auth.ScopeLogin, "victim@test.com", "203.0.113.2")
+	if att.Locked(now) || !att.CaptchaRequired {
+		t.Fatalf("after 2 failures: %+v", att)
+	}
+	// A third failure from yet another IP locks the account.
+	att, err := tracker.Fail(ctx, auth.ScopeLogin, "victim@test.com", "203.0.113.3")
+	if err != nil || !att.Locked(now) || len(att.NewLocks) != 1 || att.NewLocks[0].Kind != "account" {
+		t.Fatalf("after 3 failures: %+v, %v", att, err)
+	}
+	att, _ = tracker.Check(ctx, auth.ScopeLogin, "victim@test.com", "198.51.100.9")
+	if !att.Locked(time.Now()) {
+		t.Error("locked account usable from a fresh IP")
+	}
+	// Scopes are counted separately.
+	if att, _ := tracker.Check(ctx, auth.ScopeForgotPassword, "victim@test.com", "203.0.113.3"); att.Locked(time.Now()) {
+		t.Error("login lockout leaked into forgot-password")
+	}
+
+	// One IP spraying many accounts is locked too, and a success on one
+	// account does not reset the IP counter.
+	for i := 0; i < 5; i++ {
+		att, _ = tracker.Fail(ctx, auth.ScopeLogin, fmt.Sprintf("user%d@test.com", i), "192.0.2.7")
+	}
+	_ = tracker.Reset(ctx, auth.ScopeLogin, "user0@test.com")
+	att, _ = tracker.Check(ctx, auth.ScopeLogin, "someone@test.com", "192.0.2.7")
+	if !att.Locked(time.Now()) {
+		t.Error("spraying IP not locked")
+	}
+
+	// Reset clears the account counter.
+	_ = tracker.Reset(ctx, auth.ScopeLogin, "victim@test.com")
+	if att, _ := tracker.Check(ctx, auth.ScopeLogin, "victim@test.com", "198.51.100.9"); att.Locked(time.Now()) || att.CaptchaRequired {
+		t.Errorf("after reset: %+v", att)
+	}
+}
+
+func TestEmailChangeStore_SingleUse(t *testing.T) {
+	db := testutil.PGContainer(t)
+	q := repo.New(db)
+	u, err := user.NewPostgresService(db, q, nil).Signup("change@test.com", "pass123")
+	if err != nil {
+		t.Fatalf("signup: %v", err)
+	}
+	store := auth.NewEmailChangeStore(q)
+
+	old, err := store.Create(u.ID, "first@test.com")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	tok, err := store.Create(u.ID, "second@test.com")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := store.Consume(old); !errors.Is(err, auth.ErrEmailChangeTokenInvalid) {
+		t.Errorf("superseded token: err = %v, want ErrEmailChangeTokenInvalid", err)
+	}
+
+	const n = 8
+	wins := make(chan auth.EmailChange, n)
+	errs := make(chan error, n)
+	for range n {
+		go func() {
+			ch, err := store.Consume(tok)
+			if err != nil {
+				errs <- err
+				return
+			}
+			wins <- ch
+		}()
+	}
+	var got []auth.EmailChange
+	for range n {
+		select {
+		case ch := <-wins:
+			got = append(got, ch)
+		case err := <-errs:
+			if !errors.Is(err, auth.ErrEmailChangeTokenInvalid) {
+				t.Errorf("Consume: %v", err)
+			}
+		}
+	}
+	if len(got) != 1 || got[0].UserID != u.ID || got[0].NewEmail != "second@test.com" {
+		t.Errorf("successful consumes = %+v, want exactly one for second@test.com", got)
+	}
+}
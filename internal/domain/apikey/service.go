@@ -18,6 +18,7 @@ type APIKey struct {
 	OrgID      string     `json:"org_id"`
 	Name       string     `json:"name"`
 	KeyPrefix  string     `json:"key_prefix"`
+	Role       string     `json:"role"`
 	CreatedBy  string     `json:"created_by"`
 	CreatedAt  time.Time  `json:"created_at"`
 	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
@@ -32,11 +33,18 @@ type CreateResult struct {
 type LookupResult struct {
 	KeyID string
 	OrgID string
+	Role  string
 }
 
+var ErrNotFound = errors.New("api key not found")
+
 type Service interface {
-	Create(orgID, userID, name string, expiresAt *time.Time) (CreateResult, error)
+	// Create issues a key that acts in orgID with role; check the role with
+	// org.Service.AuthorizeRoleChange first.
+	Create(orgID, userID, name, role string, expiresAt *time.Time) (CreateResult, error)
 	List(orgID string) ([]APIKey, error)
+	Get(orgID, keyID string) (APIKey, error)
+	SetRole(orgID, keyID, role string) (APIKey, error)
 	Revoke(orgID, keyID string) error
 	Validate(key string) (*LookupResult, error)
 }
@@ -49,7 +57,7 @@ func NewService(q *repo.Queries) Service {
 	return &service{q: q}
 }
 
-func (s *service) Create(orgID, userID, name string, expiresAt *time.Time) (CreateResult, error) {
+func (s *service) Create(orgID, userID, name, role string, expiresAt *time.Time) (CreateResult, error) {
 	plaintext, err := generateKey()
 	if err != nil {
 		return CreateResult{}, fmt.Errorf("generate key: %w", err)
@@ -70,6 +78,7 @@ func (s *service) Create(orgID, userID, name string, expiresAt *time.Time) (Crea
 		KeyHash:   hash,
 		CreatedBy: userID,
 		ExpiresAt: expSQL,
+		Role:      role,
 	})
 	if err != nil {
 		return CreateResult{}, fmt.Errorf("insert api key: %w", err)
@@ -80,6 +89,7 @@ func (s *service) Create(orgID, userID, name string, expiresAt *time.Time) (Crea
 		OrgID:     row.OrgID,
 		Name:      row.Name,
 		KeyPrefix: row.KeyPrefix,
+		Role:      row.Role,
 		CreatedBy: row.CreatedBy,
 		CreatedAt: row.CreatedAt,
 	}
@@ -98,24 +108,52 @@ func (s *service) List(orgID string) ([]APIKey, error) {
 
 	out := make([]APIKey, len(rows))
 	for i, r := range rows {
-		out[i] = APIKey{
-			ID:        r.ID,
-			OrgID:     r.OrgID,
-			Name:      r.Name,
-			KeyPrefix: r.KeyPrefix,
-			CreatedBy: r.CreatedBy,
-			CreatedAt: r.CreatedAt,
-		}
-		if r.ExpiresAt.Valid {
-			out[i].ExpiresAt = &r.ExpiresAt.Time
-		}
-		if r.LastUsedAt.Valid {
-			out[i].LastUsedAt = &r.LastUsedAt.Time
-		}
+		out[i] = toAPIKey(repo.GetAPIKeyRow(r))
 	}
 	return out, nil
 }
 
+func (s *service) Get(orgID, keyID string) (APIKey, error) {
+	row, err := s.q.GetAPIKey(context.Background(), repo.GetAPIKeyParams{ID: keyID, OrgID: orgID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIKey{}, ErrNotFound
+	}
+	if err != nil {
+		return APIKey{}, err
+	}
+	return toAPIKey(row), nil
+}
+
+func (s *service) SetRole(orgID, keyID, role string) (APIKey, error) {
+	row, err := s.q.SetAPIKeyRole(context.Background(), repo.SetAPIKeyRoleParams{ID: keyID, OrgID: orgID, Role: role})
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIKey{}, ErrNotFound
+	}
+	if err != nil {
+		return APIKey{}, err
+	}
+	return toAPIKey(repo.GetAPIKeyRow(row)), nil
+}
+
+func toAPIKey(r repo.GetAPIKeyRow) APIKey {
+	k := APIKey{
+		ID:        r.ID,
+		OrgID:     r.OrgID,
+		Name:      r.Name,
+		KeyPrefix: r.KeyPrefix,
+		Role:      r.Role,
+		CreatedBy: r.CreatedBy,
+		CreatedAt: r.CreatedAt,
+	}
+	if r.ExpiresAt.Valid {
+		k.ExpiresAt = &r.ExpiresAt.Time
+	}
+	if r.LastUsedAt.Valid {
+		k.LastUsedAt = &r.LastUsedAt.Time
+	}
+	return k
+}
+
 func (s *service) Revoke(orgID, keyID string) error {
 	return s.q.RevokeAPIKey(context.Background(), repo.RevokeAPIKeyParams{
 		ID:    keyID,
@@ -142,7 +180,7 @@ func (s *service) Validate(key string) (*LookupResult, error) {
 		_ = s.q.TouchAPIKeyLastUsed(context.Background(), row.ID)
 	}()
 
-	return &LookupResult{KeyID: row.ID, OrgID: row.OrgID}, nil
+	return &LookupResult{KeyID: row.ID, OrgID: row.OrgID, Role: row.Role}, nil
 }
 
 func generateKey() (string, error) {
@@ -3,16 +3,22 @@ package audit
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/Ulpio/vergo/internal/pkg/pagination"
 )
 
+// Event is one audit log entry. Account-level security events, which are not
+// tied to an org, have an empty OrgID. Events recorded while a platform admin
+// impersonates ActorID carry the admin in ImpersonatorID.
 type Event struct {
-	OrgID     string    `json:"org_id"`
-	ActorID   string    `json:"actor_id"`
-	Action    string    `json:"action"`
-	Entity    string    `json:"entity"`
-	EntityID  string    `json:"entity_id"`
-	Timestamp time.Time `json:"timestamp"`
-	Metadata  Metadata  `json:"metadata,omitempty"`
+	OrgID          string    `json:"org_id"`
+	ActorID        string    `json:"actor_id"`
+	ImpersonatorID string    `json:"impersonator_id,omitempty"`
+	Action         string    `json:"action"`
+	Entity         string    `json:"entity"`
+	EntityID       string    `json:"entity_id"`
+	Timestamp      time.Time `json:"timestamp"`
+	Metadata       Metadata  `json:"metadata,omitempty"`
 }
 
 // Metadata holds optional before/after state for mutations.
@@ -21,6 +27,7 @@ type Metadata struct {
 	After  json.RawMessage `json:"after,omitempty"`
 }
 
+// ListParams filters List, which returns events newest first.
 type ListParams struct {
 	OrgID   string
 	ActorID *string
@@ -29,10 +36,12 @@ type ListParams struct {
 	Since   *time.Time
 	Until   *time.Time
 	Limit   int
-	Offset  int
+	Cursor  pagination.Cursor
 }
 
 type Service interface {
 	Record(e Event) error
-	List(p ListParams) ([]Event, error)
+	// List returns a page of events; a cursor whose ID is not an event ID
+	// is pagination.ErrInvalidCursor.
+	List(p ListParams) (pagination.Page[Event], error)
 }
@@ -4,10 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"strconv"
 	"time"
 
 	"github.com/sqlc-dev/pqtype"
 
+	"github.com/Ulpio/vergo/internal/pkg/pagination"
 	"github.com/Ulpio/vergo/internal/repo"
 )
 
@@ -27,25 +29,27 @@ func (s *pgService) Record(e Event) error {
 	}
 
 	return s.q.InsertAuditLog(context.Background(), repo.InsertAuditLogParams{
-		OrgID:     e.OrgID,
-		ActorID:   e.ActorID,
-		Action:    e.Action,
-		Entity:    e.Entity,
-		EntityID:  e.EntityID,
-		Metadata:  pqtype.NullRawMessage{RawMessage: metaJSON, Valid: true},
-		CreatedAt: time.Now(),
+		OrgID:          e.OrgID,
+		ActorID:        e.ActorID,
+		Action:         e.Action,
+		Entity:         e.Entity,
+		EntityID:       e.EntityID,
+		Metadata:       pqtype.NullRawMessage{RawMessage: metaJSON, Valid: true},
+		CreatedAt:      time.Now(),
+		ImpersonatorID: e.ImpersonatorID,
 	})
 }
 
-func (s *pgService) List(p ListParams) ([]Event, error) {
-	limit := p.Limit
-	if limit <= 0 || limit > 100 {
-		limit = 20
-	}
-	offset := p.Offset
-	if offset < 0 {
-		offset = 0
+func (s *pgService) List(p ListParams) (pagination.Page[Event], error) {
+	var afterID int64
+	if !p.Cursor.IsZero() {
+		id, err := strconv.ParseInt(p.Cursor.ID, 10, 64)
+		if err != nil {
+			return pagination.Page[Event]{}, pagination.ErrInvalidCursor
+		}
+		afterID = id
 	}
+	limit := pagination.Limit(p.Limit)
 
 	rows, err := s.q.ListAuditLogs(context.Background(), repo.ListAuditLogsParams{
 		OrgID:         p.OrgID,
@@ -54,30 +58,33 @@ func (s *pgService) List(p ListParams) ([]Event, error) {
 		FilterEntity:  toNullString(p.Entity),
 		FilterSince:   toNullTime(p.Since),
 		FilterUntil:   toNullTime(p.Until),
-		QueryLimit:    int32(limit),
-		QueryOffset:   int32(offset),
+		AfterID:       afterID,
+		AfterTime:     p.Cursor.Time,
+		QueryLimit:    int32(limit + 1),
 	})
 	if err != nil {
-		return nil, err
+		return pagination.Page[Event]{}, err
 	}
 
-	out := make([]Event, 0, len(rows))
-	for _, r := range rows {
+	page := pagination.New(rows, limit, func(r repo.ListAuditLogsRow) pagination.Cursor {
+		return pagination.Cursor{Time: r.CreatedAt, ID: strconv.FormatInt(r.ID, 10)}
+	})
+	return pagination.Map(page, func(r repo.ListAuditLogsRow) Event {
 		var meta Metadata
 		if len(r.Metadata) > 0 {
 			_ = json.Unmarshal(r.Metadata, &meta)
 		}
-		out = append(out, Event{
-			OrgID:     r.OrgID,
-			ActorID:   r.ActorID,
-			Action:    r.Action,
-			Entity:    r.Entity,
-			EntityID:  r.EntityID,
-			Timestamp: r.CreatedAt,
-			Metadata:  meta,
-		})
-	}
-	return out, nil
+		return Event{
+			OrgID:          r.OrgID,
+			ActorID:        r.ActorID,
+			Action:         r.Action,
+			Entity:         r.Entity,
+			EntityID:       r.EntityID,
+			Timestamp:      r.CreatedAt,
+			Metadata:       meta,
+			ImpersonatorID: r.ImpersonatorID,
+		}
+	}), nil
 }
 
 func toNullString(s *string) sql.NullString {
@@ -4,10 +4,13 @@ package audit_test
 
 import (
 	"encoding/json"
+	"errors"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/Ulpio/vergo/internal/domain/audit"
+	"github.com/Ulpio/vergo/internal/pkg/pagination"
 	"github.com/Ulpio/vergo/internal/pkg/testutil"
 	"github.com/Ulpio/vergo/internal/repo"
 )
@@ -31,13 +34,14 @@ func TestPGService_RecordAndList(t *testing.T) {
 		t.Fatalf("Record: %v", err)
 	}
 
-	events, err := svc.List(audit.ListParams{
+	page, err := svc.List(audit.ListParams{
 		OrgID: "org-1",
 		Limit: 10,
 	})
 	if err != nil {
 		t.Fatalf("List: %v", err)
 	}
+	events := page.Items
 	if len(events) != 1 {
 		t.Fatalf("expected 1 event, got %d", len(events))
 	}
@@ -64,7 +68,7 @@ func TestPGService_ListFilters(t *testing.T) {
 
 	// Filter by action
 	action := "project.created"
-	events, err := svc.List(audit.ListParams{
+	page, err := svc.List(audit.ListParams{
 		OrgID:  "org-filter",
 		Action: &action,
 		Limit:  10,
@@ -72,8 +76,8 @@ func TestPGService_ListFilters(t *testing.T) {
 	if err != nil {
 		t.Fatalf("List with filter: %v", err)
 	}
-	if len(events) != 1 {
-		t.Errorf("expected 1 filtered event, got %d", len(events))
+	if len(page.Items) != 1 {
+		t.Errorf("expected 1 filtered event, got %d", len(page.Items))
 	}
 }
 
@@ -87,19 +91,40 @@ func TestPGService_ListPagination(t *testing.T) {
 			ActorID:   "user-1",
 			Action:    "test.action",
 			Entity:    "test",
-			EntityID:  "ent-1",
+			EntityID:  strconv.Itoa(i),
 			Timestamp: time.Now(),
 		})
 	}
 
-	page1, _ := svc.List(audit.ListParams{OrgID: "org-page", Limit: 2, Offset: 0})
-	page2, _ := svc.List(audit.ListParams{OrgID: "org-page", Limit: 2, Offset: 2})
-
-	if len(page1) != 2 {
-		t.Errorf("page1: expected 2, got %d", len(page1))
+	// walk the cursors; every event shows up once
+	seen := map[string]bool{}
+	var pages int
+	cursor := pagination.Cursor{}
+	for {
+		page, err := svc.List(audit.ListParams{OrgID: "org-page", Limit: 2, Cursor: cursor})
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		pages++
+		for _, e := range page.Items {
+			if seen[e.EntityID] {
+				t.Errorf("event %s listed twice", e.EntityID)
+			}
+			seen[e.EntityID] = true
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		if cursor, err = pagination.Decode(page.NextCursor); err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
 	}
-	if len(page2) != 2 {
-		t.Errorf("page2: expected 2, got %d", len(page2))
+	if pages != 3 || len(seen) != 5 {
+		t.Errorf("pages = %d, events = %d; want 3 and 5", pages, len(seen))
+	}
+
+	if _, err := svc.List(audit.ListParams{OrgID: "org-page", Cursor: pagination.Cursor{ID: "x"}}); !errors.Is(err, pagination.ErrInvalidCursor) {
+		t.Errorf("non-numeric cursor: err = %v, want ErrInvalidCursor", err)
 	}
 }
 
@@ -120,7 +145,8 @@ func TestPGService_MetadataRoundTrip(t *testing.T) {
 		Metadata:  audit.Metadata{Before: before, After: after},
 	})
 
-	events, _ := svc.List(audit.ListParams{OrgID: "org-meta", Limit: 1})
+	page, _ := svc.List(audit.ListParams{OrgID: "org-meta", Limit: 1})
+	events := page.Items
 	if len(events) == 0 {
 		t.Fatal("expected 1 event")
 	}
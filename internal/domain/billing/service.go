@@ -10,17 +10,33 @@ import (
 	"github.com/stripe/stripe-go/v82"
 	"github.com/stripe/stripe-go/v82/checkout/session"
 	"github.com/stripe/stripe-go/v82/customer"
+	"github.com/stripe/stripe-go/v82/subscription"
 
 	"github.com/Ulpio/vergo/internal/repo"
 )
 
+var (
+	ErrUnknownPlan = errors.New("unknown plan")
+	ErrNoOverride  = errors.New("no plan override")
+)
+
 type Subscription struct {
-	ID                   string     `json:"id"`
-	OrgID                string     `json:"org_id"`
-	Status               string     `json:"status"`
-	Plan                 string     `json:"plan"`
-	CurrentPeriodEnd     *time.Time `json:"current_period_end,omitempty"`
-	StripeSubscriptionID string     `json:"stripe_subscription_id,omitempty"`
+	ID                   string        `json:"id"`
+	OrgID                string        `json:"org_id"`
+	Status               string        `json:"status"`
+	Plan                 string        `json:"plan"` // effective plan: Override.Plan when set
+	CurrentPeriodEnd     *time.Time    `json:"current_period_end,omitempty"`
+	StripeSubscriptionID string        `json:"stripe_subscription_id,omitempty"`
+	Override             *PlanOverride `json:"override,omitempty"`
+}
+
+// PlanOverride is a plan forced by a platform admin. It wins over the
+// Stripe subscription, which keeps syncing underneath, until cleared.
+type PlanOverride struct {
+	Plan      string    `json:"plan"`
+	Reason    string    `json:"reason,omitempty"`
+	SetBy     string    `json:"set_by"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type Service interface {
@@ -29,6 +45,11 @@ type Service interface {
 	HandleCheckoutCompleted(stripeCustomerID, stripeSubID, status, plan string, periodEnd time.Time, orgID string) error
 	HandleSubscriptionUpdated(stripeSubID, status, plan string, periodEnd time.Time) error
 	HandleSubscriptionDeleted(stripeSubID string) error
+	// CancelForOrg cancels the org's Stripe subscription immediately. Orgs
+	// without one are a no-op.
+	CancelForOrg(orgID string) error
+	SetPlanOverride(orgID, plan, reason, setBy string) error
+	ClearPlanOverride(orgID string) error
 }
 
 type service struct {
@@ -94,7 +115,7 @@ func (s *service) CreateCheckoutSession(orgID, orgName, successURL, cancelURL, p
 func (s *service) GetSubscription(orgID string) (*Subscription, error) {
 	row, err := s.q.GetSubscriptionByOrg(context.Background(), orgID)
 	if errors.Is(err, sql.ErrNoRows) {
-		return &Subscription{OrgID: orgID, Status: "none", Plan: "free"}, nil
+		return s.withOverride(&Subscription{OrgID: orgID, Status: "none", Plan: "free"})
 	}
 	if err != nil {
 		return nil, err
@@ -112,6 +133,19 @@ func (s *service) GetSubscription(orgID string) (*Subscription, error) {
 	if row.CurrentPeriodEnd.Valid {
 		sub.CurrentPeriodEnd = &row.CurrentPeriodEnd.Time
 	}
+	return s.withOverride(sub)
+}
+
+func (s *service) withOverride(sub *Subscription) (*Subscription, error) {
+	o, err := s.q.GetPlanOverride(context.Background(), sub.OrgID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return sub, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sub.Plan = o.Plan
+	sub.Override = &PlanOverride{Plan: o.Plan, Reason: o.Reason, SetBy: o.SetBy, CreatedAt: o.CreatedAt}
 	return sub, nil
 }
 
@@ -138,3 +172,49 @@ func (s *service) HandleSubscriptionUpdated(stripeSubID, status, plan string, pe
 func (s *service) HandleSubscriptionDeleted(stripeSubID string) error {
 	return s.q.CancelSubscription(context.Background(), sql.NullString{String: stripeSubID, Valid: true})
 }
+
+func (s *service) CancelForOrg(orgID string) error {
+	ctx := context.Background()
+	row, err := s.q.GetSubscriptionByOrg(ctx, orgID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !row.StripeSubscriptionID.Valid || row.Status == "canceled" {
+		return nil
+	}
+	_, err = subscription.Cancel(row.StripeSubscriptionID.String, nil)
+	var se *stripe.Error
+	if errors.As(err, &se) && se.Code == stripe.ErrorCodeResourceMissing {
+		err = nil // already gone on Stripe's side
+	}
+	if err != nil {
+		return fmt.Errorf("stripe cancel: %w", err)
+	}
+	return s.q.CancelSubscription(ctx, row.StripeSubscriptionID)
+}
+
+func (s *service) SetPlanOverride(orgID, plan, reason, setBy string) error {
+	if _, ok := Plans[plan]; !ok {
+		return ErrUnknownPlan
+	}
+	return s.q.UpsertPlanOverride(context.Background(), repo.UpsertPlanOverrideParams{
+		OrgID:  orgID,
+		Plan:   plan,
+		Reason: reason,
+		SetBy:  setBy,
+	})
+}
+
+func (s *service) ClearPlanOverride(orgID string) error {
+	n, err := s.q.DeletePlanOverride(context.Background(), orgID)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNoOverride
+	}
+	return nil
+}
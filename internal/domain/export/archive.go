@@ -0,0 +1,113 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+// document is one JSON file of an archive and the query that fills it.
+type document struct {
+	name  string
+	query func(ctx context.Context, id string) (json.RawMessage, error)
+}
+
+// userDocuments are the rows about a user: their account (without the
+// password hash), memberships and what they created or did in any org.
+func userDocuments(q *repo.Queries) []document {
+	return []document{
+		{"user.json", q.ExportUser},
+		{"memberships.json", q.ExportUserMemberships},
+		{"projects.json", q.ExportUserProjects},
+		{"files.json", q.ExportUserFiles},
+		{"audit_logs.json", q.ExportUserAuditLogs},
+		{"api_keys.json", q.ExportUserAPIKeys},
+	}
+}
+
+// orgDocuments are the rows of an org. API key hashes and webhook secrets
+// are left out.
+func orgDocuments(q *repo.Queries) []document {
+	return []document{
+		{"organization.json", q.ExportOrg},
+		{"members.json", q.ExportOrgMembers},
+		{"projects.json", q.ExportOrgProjects},
+		{"files.json", q.ExportOrgFiles},
+		{"audit_logs.json", q.ExportOrgAuditLogs},
+		{"api_keys.json", q.ExportOrgAPIKeys},
+		{"webhooks.json", q.ExportOrgWebhooks},
+	}
+}
+
+// entry is a file in the archive.
+type entry struct {
+	Name string
+	Data json.RawMessage
+}
+
+// manifest describes an archive; it is written as manifest.json.
+type manifest struct {
+	ExportID    string    `json:"export_id"`
+	Kind        string    `json:"kind"`
+	SubjectID   string    `json:"subject_id"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Files       []string  `json:"files"`
+}
+
+// collect runs the queries of docs for subjectID.
+func collect(ctx context.Context, docs []document, subjectID string) ([]entry, error) {
+	out := make([]entry, 0, len(docs))
+	for _, d := range docs {
+		data, err := d.query(ctx, subjectID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", d.name, err)
+		}
+		out = append(out, entry{Name: d.name, Data: data})
+	}
+	return out, nil
+}
+
+// buildArchive returns a ZIP with manifest.json followed by the entries,
+// each as indented JSON.
+func buildArchive(m manifest, entries []entry) ([]byte, error) {
+	m.Files = make([]string, len(entries))
+	for i, e := range entries {
+		m.Files[i] = e.Name
+	}
+	mb, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range append([]entry{{Name: "manifest.json", Data: mb}}, entries...) {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: e.Name, Method: zip.Deflate, Modified: m.GeneratedAt})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeIndented(w, e.Data); err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeIndented(w io.Writer, data json.RawMessage) error {
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		return err
+	}
+	out.WriteByte('\n')
+	_, err := out.WriteTo(w)
+	return err
+}
@@ -0,0 +1,56 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBuildArchive(t *testing.T) {
+	entries := []entry{
+		{Name: "user.json", Data: json.RawMessage(`{"id":"u1","email":"a@example.com"}`)},
+		{Name: "projects.json", Data: json.RawMessage(`[]`)},
+	}
+	b, err := buildArchive(manifest{ExportID: "e1", Kind: KindUser, SubjectID: "u1", GeneratedAt: time.Now()}, entries)
+	if err != nil {
+		t.Fatalf("buildArchive: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatalf("zip: %v", err)
+	}
+	var names []string
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		rc, _ := f.Open()
+		files[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+	if want := []string{"manifest.json", "user.json", "projects.json"}; !slices.Equal(names, want) {
+		t.Fatalf("files = %v, want %v", names, want)
+	}
+
+	var m manifest
+	if err := json.Unmarshal(files["manifest.json"], &m); err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+	if m.ExportID != "e1" || !slices.Equal(m.Files, []string{"user.json", "projects.json"}) {
+		t.Errorf("manifest = %+v", m)
+	}
+	if !bytes.Contains(files["user.json"], []byte("\n  \"email\": \"a@example.com\"")) {
+		t.Errorf("user.json not indented: %s", files["user.json"])
+	}
+}
+
+func TestBuildArchiveRejectsInvalidJSON(t *testing.T) {
+	_, err := buildArchive(manifest{}, []entry{{Name: "bad.json", Data: json.RawMessage(`{`)}})
+	if err == nil {
+		t.Error("invalid JSON accepted")
+	}
+}
@@ -0,0 +1,149 @@
+package export
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Ulpio/vergo/internal/pkg/notify"
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+// batchSize caps the jobs one ProcessPending call claims.
+const batchSize = 5
+
+// sweepSize caps the expired jobs one Sweep call deletes.
+const sweepSize = 100
+
+// staleAfter is how long a job may stay running before another runner
+// claims it again; building an archive takes seconds, so the first runner
+// is assumed dead.
+const staleAfter = 30 * time.Minute
+
+// Runner builds pending exports in the background.
+type Runner struct {
+	q      *repo.Queries
+	store  Storage
+	notify notify.Notifier
+}
+
+// NewRunner returns a Runner that uploads archives to store and sends the
+// requester a download link through n.
+func NewRunner(q *repo.Queries, store Storage, n notify.Notifier) *Runner {
+	return &Runner{q: q, store: store, notify: n}
+}
+
+// ProcessPending claims pending jobs and runs them one by one.
+func (r *Runner) ProcessPending() {
+	ctx := context.Background()
+	rows, err := r.q.ClaimExportJobs(ctx, repo.ClaimExportJobsParams{
+		StaleBefore: time.Now().Add(-staleAfter),
+		QueryLimit:  batchSize,
+	})
+	if err != nil {
+		slog.Error("export: claim jobs", "error", err)
+		return
+	}
+	for _, row := range rows {
+		j := toJob(row)
+		if err := r.run(ctx, j); err != nil {
+			slog.Error("export: job failed", "export_id", j.ID, "kind", j.Kind, "error", err)
+			_ = r.q.FailExportJob(ctx, repo.FailExportJobParams{ID: j.ID, Error: err.Error()})
+		}
+	}
+}
+
+// Sweep deletes the jobs that finished more than ArchiveTTL ago, archive
+// first, so a failed delete is retried on the next call.
+func (r *Runner) Sweep() {
+	ctx := context.Background()
+	cutoff := sql.NullTime{Time: time.Now().Add(-ArchiveTTL), Valid: true}
+	rows, err := r.q.ListExpiredExports(ctx, repo.ListExpiredExportsParams{FinishedBefore: cutoff, QueryLimit: sweepSize})
+	if err != nil {
+		slog.Error("export: list expired", "error", err)
+		return
+	}
+	for _, row := range rows {
+		if row.ObjectKey != "" {
+			if err := r.store.DeleteObject(ctx, "", row.ObjectKey); err != nil {
+				slog.Error("export: delete archive", "export_id", row.ID, "error", err)
+				continue
+			}
+		}
+		if err := r.q.DeleteExportJob(ctx, row.ID); err != nil {
+			slog.Error("export: delete job", "export_id", row.ID, "error", err)
+		}
+	}
+	if len(rows) > 0 {
+		slog.Info("export: expired jobs deleted", "count", len(rows))
+	}
+}
+
+func (r *Runner) run(ctx context.Context, j Job) error {
+	var docs []document
+	switch j.Kind {
+	case KindUser:
+		docs = userDocuments(r.q)
+	case KindOrg:
+		docs = orgDocuments(r.q)
+	default:
+		return fmt.Errorf("unknown export kind %q", j.Kind)
+	}
+	entries, err := collect(ctx, docs, j.SubjectID)
+	if err != nil {
+		return err
+	}
+	archive, err := buildArchive(manifest{
+		ExportID:    j.ID,
+		Kind:        j.Kind,
+		SubjectID:   j.SubjectID,
+		GeneratedAt: time.Now().UTC(),
+	}, entries)
+	if err != nil {
+		return err
+	}
+
+	key := ArchivePrefix(j.Kind, j.SubjectID) + j.ID + ".zip"
+	if err := r.store.PutObject(ctx, "", key, "application/zip", archive); err != nil {
+		return fmt.Errorf("upload: %w", err)
+	}
+	if err := r.q.FinishExportJob(ctx, repo.FinishExportJobParams{ID: j.ID, ObjectKey: key}); err != nil {
+		return err
+	}
+	slog.Info("export: job done", "export_id", j.ID, "kind", j.Kind, "bytes", len(archive))
+
+	j.Status, j.objectKey = StatusDone, key
+	r.sendLink(ctx, j)
+	return nil
+}
+
+// sendLink tells the requester where to download the archive. The job is
+// already done, so failures are only logged; GET /me/exports/:id presigns
+// a fresh link at any time.
+func (r *Runner) sendLink(ctx context.Context, j Job) {
+	if r.notify == nil {
+		return
+	}
+	u, err := r.q.GetUserByID(ctx, j.RequestedBy)
+	if err != nil {
+		slog.Error("export: load requester", "export_id", j.ID, "error", err)
+		return
+	}
+	url, err := r.store.PresignGet(ctx, "", j.objectKey, int64(LinkTTL/time.Second))
+	if err != nil {
+		slog.Error("export: presign", "export_id", j.ID, "error", err)
+		return
+	}
+	err = r.notify.Notify(ctx, notify.Notice{
+		UserID:  u.ID,
+		Email:   u.Email,
+		Subject: "Your data export is ready",
+		Body: "Download it within " + LinkTTL.String() + " from " + url +
+			" or request a new link from /me/exports/" + j.ID + ".",
+	})
+	if err != nil {
+		slog.Error("export: notify", "export_id", j.ID, "error", err)
+	}
+}
@@ -0,0 +1,218 @@
+//go:build integration
+
+package export_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Ulpio/vergo/internal/domain/apikey"
+	"github.com/Ulpio/vergo/internal/domain/export"
+	"github.com/Ulpio/vergo/internal/domain/org"
+	"github.com/Ulpio/vergo/internal/domain/user"
+	"github.com/Ulpio/vergo/internal/domain/webhook"
+	"github.com/Ulpio/vergo/internal/pkg/testutil"
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+type memStorage struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (s *memStorage) PutObject(_ context.Context, _, key, _ string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = body
+	return nil
+}
+
+func (s *memStorage) PresignGet(_ context.Context, _, key string, _ int64) (string, error) {
+	return "https://storage.test/" + key, nil
+}
+
+func (s *memStorage) DeleteObject(_ context.Context, _, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+	return nil
+}
+
+func (s *memStorage) DeletePrefix(_ context.Context, _, prefix string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k := range s.objects {
+		if strings.HasPrefix(k, prefix) {
+			delete(s.objects, k)
+		}
+	}
+	return nil
+}
+
+func TestRunner_UserExport(t *testing.T) {
+	db := testutil.PGContainer(t)
+	q := repo.New(db)
+	store := &memStorage{objects: map[string][]byte{}}
+	svc := export.NewService(q, store)
+
+	u, _ := user.NewPostgresService(db, q, nil).Signup("gdpr@example.com", "password")
+	job, err := svc.RequestUser(u.ID)
+	if err != nil {
+		t.Fatalf("RequestUser: %v", err)
+	}
+	if job.Status != export.StatusPending {
+		t.Fatalf("status = %q, want pending", job.Status)
+	}
+
+	export.NewRunner(q, store, nil).ProcessPending()
+
+	job, err = svc.Get(job.ID, u.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if job.Status != export.StatusDone {
+		t.Fatalf("status = %q (%s), want done", job.Status, job.Error)
+	}
+	if _, err := svc.Get(job.ID, "someone-else"); !errors.Is(err, export.ErrNotFound) {
+		t.Errorf("Get by other user: err = %v, want ErrNotFound", err)
+	}
+	url, err := svc.DownloadURL(context.Background(), job)
+	if err != nil || !strings.HasSuffix(url, job.ID+".zip") {
+		t.Errorf("DownloadURL = %q, %v", url, err)
+	}
+
+	b := archiveFile(t, store, "user.json")
+	if !bytes.Contains(b, []byte("gdpr@example.com")) || bytes.Contains(b, []byte("password_hash")) {
+		t.Errorf("user.json = %s", b)
+	}
+}
+
+func TestRunner_OrgExport(t *testing.T) {
+	db := testutil.PGContainer(t)
+	q := repo.New(db)
+	store := &memStorage{objects: map[string][]byte{}}
+	svc := export.NewService(q, store)
+
+	u, _ := user.NewPostgresService(db, q, nil).Signup("admin@example.com", "password")
+	o, err := org.NewPostgresService(db, q).Create("Acme", u.ID)
+	if err != nil {
+		t.Fatalf("create org: %v", err)
+	}
+	if _, err := apikey.NewService(q).Create(o.ID, u.ID, "ci", "member", nil); err != nil {
+		t.Fatalf("create api key: %v", err)
+	}
+	if _, err := webhook.NewService(q).CreateEndpoint(o.ID, "https://hooks.example.com", []string{"project.created"}); err != nil {
+		t.Fatalf("create webhook: %v", err)
+	}
+
+	job, err := svc.RequestOrg(o.ID, u.ID)
+	if err != nil {
+		t.Fatalf("RequestOrg: %v", err)
+	}
+	export.NewRunner(q, store, nil).ProcessPending()
+	if job, _ = svc.Get(job.ID, u.ID); job.Status != export.StatusDone {
+		t.Fatalf("status = %q (%s), want done", job.Status, job.Error)
+	}
+
+	if b := archiveFile(t, store, "api_keys.json"); !bytes.Contains(b, []byte(`"ci"`)) || bytes.Contains(b, []byte("key_hash")) {
+		t.Errorf("api_keys.json = %s", b)
+	}
+	if b := archiveFile(t, store, "webhooks.json"); !bytes.Contains(b, []byte("hooks.example.com")) || bytes.Contains(b, []byte("secret")) {
+		t.Errorf("webhooks.json = %s", b)
+	}
+}
+
+func TestRunner_Retention(t *testing.T) {
+	db := testutil.PGContainer(t)
+	q := repo.New(db)
+	store := &memStorage{objects: map[string][]byte{}}
+	svc := export.NewService(q, store)
+	runner := export.NewRunner(q, store, nil)
+	u, _ := user.NewPostgresService(db, q, nil).Signup("retention@example.com", "password")
+
+	// a job whose runner died mid-way is claimed again once stale
+	stuck, _ := svc.RequestUser(u.ID)
+	if _, err := db.Exec(`UPDATE data_exports SET status = 'running', started_at = now() - interval '1 hour' WHERE id = $1`, stuck.ID); err != nil {
+		t.Fatalf("mark running: %v", err)
+	}
+	runner.ProcessPending()
+	if j, _ := svc.Get(stuck.ID, u.ID); j.Status != export.StatusDone {
+		t.Fatalf("stale job status = %q (%s), want done", j.Status, j.Error)
+	}
+	if len(store.objects) != 1 {
+		t.Fatalf("objects = %d, want 1", len(store.objects))
+	}
+
+	// a fresh running job belongs to a live runner
+	busy, _ := svc.RequestUser(u.ID)
+	if _, err := db.Exec(`UPDATE data_exports SET status = 'running', started_at = now() WHERE id = $1`, busy.ID); err != nil {
+		t.Fatalf("mark running: %v", err)
+	}
+	runner.ProcessPending()
+	if j, _ := svc.Get(busy.ID, u.ID); j.Status != export.StatusRunning {
+		t.Errorf("busy job status = %q, want running", j.Status)
+	}
+
+	runner.Sweep()
+	if _, err := svc.Get(stuck.ID, u.ID); err != nil {
+		t.Fatalf("recent job swept: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE data_exports SET finished_at = now() - interval '8 days' WHERE id = $1`, stuck.ID); err != nil {
+		t.Fatalf("age job: %v", err)
+	}
+	runner.Sweep()
+	if _, err := svc.Get(stuck.ID, u.ID); !errors.Is(err, export.ErrNotFound) {
+		t.Errorf("Get after sweep: err = %v, want ErrNotFound", err)
+	}
+	if len(store.objects) != 0 {
+		t.Errorf("objects after sweep = %v, want none", len(store.objects))
+	}
+}
+
+func TestService_DeleteArchives(t *testing.T) {
+	db := testutil.PGContainer(t)
+	q := repo.New(db)
+	store := &memStorage{objects: map[string][]byte{
+		"exports/user/u1/a.zip": nil,
+		"exports/user/u2/b.zip": nil,
+	}}
+	if err := export.NewService(q, store).DeleteArchives(context.Background(), export.KindUser, "u1"); err != nil {
+		t.Fatalf("DeleteArchives: %v", err)
+	}
+	if _, ok := store.objects["exports/user/u1/a.zip"]; ok {
+		t.Error("u1 archive kept")
+	}
+	if _, ok := store.objects["exports/user/u2/b.zip"]; !ok {
+		t.Error("u2 archive deleted")
+	}
+}
+
+// archiveFile returns the named file of the only archive in store.
+func archiveFile(t *testing.T, store *memStorage, name string) []byte {
+	t.Helper()
+	var archive []byte
+	for _, b := range store.objects {
+		archive = b
+	}
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("zip: %v", err)
+	}
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		rc, _ := f.Open()
+		defer rc.Close()
+		b, _ := io.ReadAll(rc)
+		return b
+	}
+	t.Fatalf("%s missing from archive", name)
+	return nil
+}
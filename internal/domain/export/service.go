@@ -0,0 +1,142 @@
+package export
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+// Export kinds.
+const (
+	KindUser = "user"
+	KindOrg  = "org"
+)
+
+// Job statuses.
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// LinkTTL is how long a presigned download link stays valid.
+const LinkTTL = time.Hour
+
+// ArchiveTTL is how long a finished job and its archive are kept.
+const ArchiveTTL = 7 * 24 * time.Hour
+
+var ErrNotFound = errors.New("export not found")
+
+// Job is an asynchronous data export of a user or an org.
+type Job struct {
+	ID          string     `json:"id"`
+	Kind        string     `json:"kind"`
+	SubjectID   string     `json:"subject_id"`
+	RequestedBy string     `json:"requested_by"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+
+	objectKey string
+}
+
+// Storage keeps the archives; *s3store.S3 implements it.
+type Storage interface {
+	PutObject(ctx context.Context, bucket, key, contentType string, body []byte) error
+	PresignGet(ctx context.Context, bucket, key string, expiresSeconds int64) (string, error)
+	DeleteObject(ctx context.Context, bucket, key string) error
+	DeletePrefix(ctx context.Context, bucket, prefix string) error
+}
+
+// ArchivePrefix is where the archives of a subject's exports live.
+func ArchivePrefix(kind, subjectID string) string {
+	return "exports/" + kind + "/" + subjectID + "/"
+}
+
+type Service interface {
+	// RequestUser queues an export of everything stored about the user.
+	RequestUser(userID string) (Job, error)
+	// RequestOrg queues an export of the org's data for requestedBy.
+	RequestOrg(orgID, requestedBy string) (Job, error)
+	// Get returns a job, but only to the user who requested it.
+	Get(id, requestedBy string) (Job, error)
+	// DownloadURL presigns the archive of a finished job for LinkTTL.
+	DownloadURL(ctx context.Context, j Job) (string, error)
+	// DeleteArchives removes every archive exported for a subject, for
+	// when the subject itself goes away.
+	DeleteArchives(ctx context.Context, kind, subjectID string) error
+}
+
+type service struct {
+	q     *repo.Queries
+	store Storage
+}
+
+func NewService(q *repo.Queries, store Storage) Service {
+	return &service{q: q, store: store}
+}
+
+func (s *service) RequestUser(userID string) (Job, error) {
+	return s.create(KindUser, userID, userID)
+}
+
+func (s *service) RequestOrg(orgID, requestedBy string) (Job, error) {
+	return s.create(KindOrg, orgID, requestedBy)
+}
+
+func (s *service) create(kind, subjectID, requestedBy string) (Job, error) {
+	row, err := s.q.CreateExportJob(context.Background(), repo.CreateExportJobParams{
+		Kind:        kind,
+		SubjectID:   subjectID,
+		RequestedBy: requestedBy,
+	})
+	if err != nil {
+		return Job{}, err
+	}
+	return toJob(row), nil
+}
+
+func (s *service) Get(id, requestedBy string) (Job, error) {
+	row, err := s.q.GetExportJob(context.Background(), repo.GetExportJobParams{ID: id, RequestedBy: requestedBy})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Job{}, ErrNotFound
+	}
+	if err != nil {
+		return Job{}, err
+	}
+	return toJob(row), nil
+}
+
+func (s *service) DownloadURL(ctx context.Context, j Job) (string, error) {
+	if j.Status != StatusDone || j.objectKey == "" {
+		return "", ErrNotFound
+	}
+	return s.store.PresignGet(ctx, "", j.objectKey, int64(LinkTTL/time.Second))
+}
+
+func (s *service) DeleteArchives(ctx context.Context, kind, subjectID string) error {
+	return s.store.DeletePrefix(ctx, "", ArchivePrefix(kind, subjectID))
+}
+
+func toJob(r repo.DataExport) Job {
+	j := Job{
+		ID:          r.ID,
+		Kind:        r.Kind,
+		SubjectID:   r.SubjectID,
+		RequestedBy: r.RequestedBy,
+		Status:      r.Status,
+		Error:       r.Error,
+		CreatedAt:   r.CreatedAt,
+		objectKey:   r.ObjectKey,
+	}
+	if r.FinishedAt.Valid {
+		t := r.FinishedAt.Time
+		j.FinishedAt = &t
+	}
+	return j
+}
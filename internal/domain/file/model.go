@@ -5,6 +5,7 @@ import "time"
 type File struct {
 	ID          string    `json:"id"`
 	OrgID       string    `json:"org_id"`
+	ProjectID   string    `json:"project_id,omitempty"` // inherits the project's access
 	UploadedBy  string    `json:"uploaded_by"`
 	Bucket      string    `json:"bucket"`
 	ObjectKey   string    `json:"object_key"`
@@ -12,4 +13,5 @@ type File struct {
 	ContentType string    `json:"content_type,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 	Metadata    any       `json:"metadata,omitempty"` // map[string]any serializado em JSONB
+	Version     int64     `json:"version"`            // servido como ETag
 }
@@ -10,22 +10,35 @@ import (
 	"github.com/google/uuid"
 	"github.com/sqlc-dev/pqtype"
 
+	"github.com/Ulpio/vergo/internal/pkg/pagination"
+	"github.com/Ulpio/vergo/internal/pkg/precondition"
 	"github.com/Ulpio/vergo/internal/repo"
 )
 
 var ErrNotFound = errors.New("file not found")
 
+// ListParams lists files outside projects plus those in ProjectIDs, or in
+// every project with AllProjects. A non-empty ProjectID narrows to one.
+// Files come newest first.
 type ListParams struct {
-	OrgID  string
-	Limit  int
-	Offset int
+	OrgID       string
+	AllProjects bool
+	ProjectIDs  []string
+	ProjectID   string
+	Limit       int
+	Cursor      pagination.Cursor
 }
 
 type Service interface {
-	List(p ListParams) ([]File, error)
-	Create(orgID, userID, bucket, key string, size *int64, contentType string, metadata any) (File, error)
+	List(p ListParams) (pagination.Page[File], error)
+	// Create registers an uploaded object; projectID is optional.
+	Create(orgID, userID, projectID, bucket, key string, size *int64, contentType string, metadata any) (File, error)
 	Get(orgID, id string) (File, error)
-	Delete(orgID, id string) error
+	// Delete removes the file at ifVersion, or at any version with
+	// precondition.Any; otherwise it is precondition.ErrFailed.
+	Delete(orgID, id string, ifVersion int64) error
+	// ProjectsForKey returns the projects holding a file with the object key.
+	ProjectsForKey(orgID, key string) ([]string, error)
 }
 
 type pgService struct {
@@ -41,11 +54,13 @@ func repoToFile(r repo.File) File {
 	f := File{
 		ID:          r.ID,
 		OrgID:       r.OrgID,
+		ProjectID:   r.ProjectID.String,
 		UploadedBy:  r.UploadedBy,
 		Bucket:      r.Bucket,
 		ObjectKey:   r.ObjectKey,
 		ContentType: r.ContentType.String,
 		CreatedAt:   r.CreatedAt,
+		Version:     r.Version,
 	}
 	if r.SizeBytes.Valid {
 		v := r.SizeBytes.Int64
@@ -60,31 +75,27 @@ func repoToFile(r repo.File) File {
 	return f
 }
 
-func (s *pgService) List(p ListParams) ([]File, error) {
-	if p.Limit <= 0 || p.Limit > 100 {
-		p.Limit = 20
-	}
-	if p.Offset < 0 {
-		p.Offset = 0
-	}
-
+func (s *pgService) List(p ListParams) (pagination.Page[File], error) {
+	limit := pagination.Limit(p.Limit)
 	rows, err := s.q.ListFiles(context.Background(), repo.ListFilesParams{
-		OrgID:  p.OrgID,
-		Limit:  int32(p.Limit),
-		Offset: int32(p.Offset),
+		OrgID:       p.OrgID,
+		AllProjects: p.AllProjects,
+		ProjectIds:  p.ProjectIDs,
+		ProjectID:   p.ProjectID,
+		AfterID:     p.Cursor.ID,
+		AfterTime:   p.Cursor.Time,
+		QueryLimit:  int32(limit + 1),
 	})
 	if err != nil {
-		return nil, err
+		return pagination.Page[File]{}, err
 	}
-
-	out := make([]File, len(rows))
-	for i, r := range rows {
-		out[i] = repoToFile(r)
-	}
-	return out, nil
+	page := pagination.New(rows, limit, func(r repo.File) pagination.Cursor {
+		return pagination.Cursor{Time: r.CreatedAt, ID: r.ID}
+	})
+	return pagination.Map(page, repoToFile), nil
 }
 
-func (s *pgService) Create(orgID, userID, bucket, key string, size *int64, contentType string, metadata any) (File, error) {
+func (s *pgService) Create(orgID, userID, projectID, bucket, key string, size *int64, contentType string, metadata any) (File, error) {
 	id := uuid.NewString()
 
 	sizeBytes := sql.NullInt64{}
@@ -112,6 +123,7 @@ func (s *pgService) Create(orgID, userID, bucket, key string, size *int64, conte
 		ContentType: ct,
 		CreatedAt:   time.Now(),
 		Metadata:    metaNRM,
+		ProjectID:   sql.NullString{String: projectID, Valid: projectID != ""},
 	})
 	if err != nil {
 		return File{}, err
@@ -133,17 +145,28 @@ func (s *pgService) Get(orgID, id string) (File, error) {
 	return repoToFile(r), nil
 }
 
-func (s *pgService) Delete(orgID, id string) error {
+func (s *pgService) Delete(orgID, id string, ifVersion int64) error {
 	res, err := s.q.DeleteFile(context.Background(), repo.DeleteFileParams{
-		ID:    id,
-		OrgID: orgID,
+		ID:        id,
+		OrgID:     orgID,
+		IfVersion: ifVersion,
 	})
 	if err != nil {
 		return err
 	}
 	aff, _ := res.RowsAffected()
-	if aff == 0 {
-		return ErrNotFound
+	if aff > 0 {
+		return nil
+	}
+	if _, err := s.Get(orgID, id); err != nil {
+		return err
 	}
-	return nil
+	return precondition.ErrFailed
+}
+
+func (s *pgService) ProjectsForKey(orgID, key string) ([]string, error) {
+	return s.q.ListFileProjectsByKey(context.Background(), repo.ListFileProjectsByKeyParams{
+		OrgID:     orgID,
+		ObjectKey: key,
+	})
 }
@@ -0,0 +1,117 @@
+// Package idempotency stores the outcome of POST requests sent with an
+// Idempotency-Key, so that a client retrying after a timeout gets the
+// original response instead of creating the resource twice.
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+var (
+	ErrMismatch = errors.New("idempotency key reused with a different request")
+	ErrInFlight = errors.New("a request with this idempotency key is in progress")
+)
+
+// lockTimeout is how long a claimed key may stay unfinished before another
+// request may take it over, in case the first one died without releasing it.
+const lockTimeout = time.Minute
+
+// Key scopes an Idempotency-Key to who sent it and in which org.
+type Key struct {
+	Principal string
+	OrgID     string
+	Key       string
+}
+
+// Response is what is replayed to a retry.
+type Response struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+type Service interface {
+	// Begin claims k for a request with fingerprint. It returns the stored
+	// response when k already completed for the same request, ErrMismatch
+	// when k was used for a different one and ErrInFlight while it runs.
+	// A nil response and error means the caller owns k and must Complete
+	// or Release it.
+	Begin(k Key, fingerprint string) (*Response, error)
+	Complete(k Key, r Response) error
+	// Release forgets k so the request can be retried, e.g. after a 5xx.
+	Release(k Key) error
+	// PurgeExpired deletes the keys older than the TTL.
+	PurgeExpired() (int64, error)
+}
+
+type pgService struct {
+	q   *repo.Queries
+	ttl time.Duration
+	now func() time.Time
+}
+
+// NewPostgresService keeps responses for ttl after the first request.
+func NewPostgresService(q *repo.Queries, ttl time.Duration) Service {
+	return &pgService{q: q, ttl: ttl, now: time.Now}
+}
+
+func (s *pgService) Begin(k Key, fingerprint string) (*Response, error) {
+	ctx := context.Background()
+	// A key deleted between the claim and the read is claimed again once.
+	for attempt := 0; ; attempt++ {
+		now := s.now()
+		n, err := s.q.ClaimIdempotencyKey(ctx, repo.ClaimIdempotencyKeyParams{
+			Principal:   k.Principal,
+			OrgID:       k.OrgID,
+			Key:         k.Key,
+			Fingerprint: fingerprint,
+			ExpiresAt:   now.Add(s.ttl),
+			StaleBefore: now.Add(-lockTimeout),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if n == 1 {
+			return nil, nil
+		}
+
+		row, err := s.q.GetIdempotencyKey(ctx, repo.GetIdempotencyKeyParams{Principal: k.Principal, OrgID: k.OrgID, Key: k.Key})
+		if errors.Is(err, sql.ErrNoRows) && attempt == 0 {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if row.Fingerprint != fingerprint {
+			return nil, ErrMismatch
+		}
+		if !row.StatusCode.Valid {
+			return nil, ErrInFlight
+		}
+		return &Response{Status: int(row.StatusCode.Int32), ContentType: row.ContentType, Body: row.ResponseBody}, nil
+	}
+}
+
+func (s *pgService) Complete(k Key, r Response) error {
+	return s.q.CompleteIdempotencyKey(context.Background(), repo.CompleteIdempotencyKeyParams{
+		Principal:    k.Principal,
+		OrgID:        k.OrgID,
+		Key:          k.Key,
+		StatusCode:   sql.NullInt32{Int32: int32(r.Status), Valid: true},
+		ContentType:  r.ContentType,
+		ResponseBody: r.Body,
+	})
+}
+
+func (s *pgService) Release(k Key) error {
+	return s.q.DeleteIdempotencyKey(context.Background(), repo.DeleteIdempotencyKeyParams{Principal: k.Principal, OrgID: k.OrgID, Key: k.Key})
+}
+
+func (s *pgService) PurgeExpired() (int64, error) {
+	return s.q.DeleteExpiredIdempotencyKeys(context.Background())
+}
@@ -0,0 +1,101 @@
+//go:build integration
+
+package idempotency
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ulpio/vergo/internal/domain/org"
+	"github.com/Ulpio/vergo/internal/domain/user"
+	"github.com/Ulpio/vergo/internal/pkg/testutil"
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+func setupIdempotency(t *testing.T) (*pgService, Key) {
+	t.Helper()
+	db := testutil.PGContainer(t)
+	q := repo.New(db)
+	u, err := user.NewPostgresService(db, q, nil).Signup("owner@test.com", "pass123")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	o, err := org.NewPostgresService(db, q).Create("Acme", u.ID)
+	if err != nil {
+		t.Fatalf("create org: %v", err)
+	}
+	svc := NewPostgresService(q, time.Hour).(*pgService)
+	return svc, Key{Principal: "user:" + u.ID, OrgID: o.ID, Key: "k1"}
+}
+
+func TestPGService_BeginCompleteReplay(t *testing.T) {
+	svc, k := setupIdempotency(t)
+
+	if r, err := svc.Begin(k, "fp1"); r != nil || err != nil {
+		t.Fatalf("first Begin: %+v, %v", r, err)
+	}
+	if _, err := svc.Begin(k, "fp1"); !errors.Is(err, ErrInFlight) {
+		t.Fatalf("Begin while in flight: err = %v", err)
+	}
+	if err := svc.Complete(k, Response{Status: 201, ContentType: "application/json", Body: []byte(`{"id":"p1"}`)}); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	r, err := svc.Begin(k, "fp1")
+	if err != nil || r == nil {
+		t.Fatalf("replay: %+v, %v", r, err)
+	}
+	if r.Status != 201 || r.ContentType != "application/json" || string(r.Body) != `{"id":"p1"}` {
+		t.Errorf("replayed %+v", r)
+	}
+	if _, err := svc.Begin(k, "fp2"); !errors.Is(err, ErrMismatch) {
+		t.Errorf("Begin with other fingerprint: err = %v", err)
+	}
+
+	other := k
+	other.Principal = "key:k-1"
+	if r, err := svc.Begin(other, "fp2"); r != nil || err != nil {
+		t.Errorf("other principal: %+v, %v", r, err)
+	}
+}
+
+func TestPGService_ReleaseAndStaleClaims(t *testing.T) {
+	svc, k := setupIdempotency(t)
+
+	if _, err := svc.Begin(k, "fp1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Release(k); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if r, err := svc.Begin(k, "fp2"); r != nil || err != nil {
+		t.Fatalf("Begin after Release: %+v, %v", r, err)
+	}
+
+	// An unfinished claim older than lockTimeout is taken over.
+	svc.now = func() time.Time { return time.Now().Add(2 * lockTimeout) }
+	if r, err := svc.Begin(k, "fp3"); r != nil || err != nil {
+		t.Fatalf("Begin over stale claim: %+v, %v", r, err)
+	}
+}
+
+func TestPGService_Expiry(t *testing.T) {
+	svc, k := setupIdempotency(t)
+
+	svc.now = func() time.Time { return time.Now().Add(-2 * time.Hour) }
+	if _, err := svc.Begin(k, "fp1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Complete(k, Response{Status: 201}); err != nil {
+		t.Fatal(err)
+	}
+	svc.now = time.Now
+
+	if n, err := svc.PurgeExpired(); err != nil || n != 1 {
+		t.Fatalf("PurgeExpired = %d, %v; want 1", n, err)
+	}
+	if r, err := svc.Begin(k, "fp2"); r != nil || err != nil {
+		t.Fatalf("Begin after expiry: %+v, %v", r, err)
+	}
+}
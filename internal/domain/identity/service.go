@@ -0,0 +1,176 @@
+package identity
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/Ulpio/vergo/internal/domain/user"
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+var (
+	ErrEmailRequired = errors.New("identity provider did not return an email")
+	// ErrAccountExists is returned when a local account already uses the
+	// email but linking is not allowed (either side unverified). Password
+	// signups are unverified until the user follows the link mailed to them.
+	ErrAccountExists = errors.New("an account with this email already exists")
+)
+
+type Identity struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// External is an identity asserted by an external provider.
+type External struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+type Service interface {
+	// Resolve maps an external identity to a local user, creating the user
+	// or linking to an existing verified account when needed. Concurrent
+	// first logins resolve to the same user.
+	Resolve(ext External) (user.User, bool, error) // (user, created)
+	ListForUser(userID string) ([]Identity, error)
+}
+
+type pgService struct {
+	db *sql.DB
+	q  *repo.Queries
+}
+
+func NewPostgresService(db *sql.DB, q *repo.Queries) Service {
+	return &pgService{db: db, q: q}
+}
+
+func (s *pgService) Resolve(ext External) (user.User, bool, error) {
+	u, created, err := s.resolve(ext)
+	if isUniqueViolation(err) {
+		// a concurrent login created the user or identity first
+		return s.resolve(ext)
+	}
+	return u, created, err
+}
+
+func (s *pgService) resolve(ext External) (user.User, bool, error) {
+	ctx := context.Background()
+
+	// 1) identity already linked
+	ident, err := s.q.GetIdentity(ctx, repo.GetIdentityParams{Provider: ext.Provider, Subject: ext.Subject})
+	if err == nil {
+		u, err := s.getUser(ctx, ident.UserID)
+		return u, false, err
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return user.User{}, false, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(ext.Email))
+	if email == "" {
+		return user.User{}, false, ErrEmailRequired
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return user.User{}, false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := s.q.WithTx(tx)
+
+	// 2) local account with the same email: link only when both sides are verified
+	existing, err := qtx.GetUserByEmail(ctx, email)
+	switch {
+	case err == nil:
+		if !existing.EmailVerifiedAt.Valid || !ext.EmailVerified {
+			return user.User{}, false, ErrAccountExists
+		}
+		if err := qtx.InsertIdentity(ctx, identityParams(existing.ID, ext, email)); err != nil {
+			return user.User{}, false, err
+		}
+		if err := tx.Commit(); err != nil {
+			return user.User{}, false, err
+		}
+		return user.User{ID: existing.ID, Email: existing.Email, EmailVerified: true, PasswordHash: existing.PasswordHash}, false, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return user.User{}, false, err
+	}
+
+	// 3) new account without a local password
+	id := uuid.NewString()
+	if err := qtx.InsertUser(ctx, repo.InsertUserParams{
+		ID:        id,
+		Email:     email,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		return user.User{}, false, err
+	}
+	if ext.EmailVerified {
+		if err := qtx.MarkUserEmailVerified(ctx, id); err != nil {
+			return user.User{}, false, err
+		}
+	}
+	if err := qtx.InsertIdentity(ctx, identityParams(id, ext, email)); err != nil {
+		return user.User{}, false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return user.User{}, false, err
+	}
+	return user.User{ID: id, Email: email, EmailVerified: ext.EmailVerified}, true, nil
+}
+
+func (s *pgService) ListForUser(userID string) ([]Identity, error) {
+	rows, err := s.q.ListIdentitiesByUser(context.Background(), userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Identity, len(rows))
+	for i, r := range rows {
+		out[i] = Identity{
+			ID:        r.ID,
+			UserID:    r.UserID,
+			Provider:  r.Provider,
+			Subject:   r.Subject,
+			Email:     r.Email.String,
+			CreatedAt: r.CreatedAt,
+		}
+	}
+	return out, nil
+}
+
+func (s *pgService) getUser(ctx context.Context, id string) (user.User, error) {
+	row, err := s.q.GetUserByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user.User{}, user.ErrNotFound
+	}
+	if err != nil {
+		return user.User{}, err
+	}
+	return user.User{ID: row.ID, Email: row.Email, EmailVerified: row.EmailVerifiedAt.Valid, PasswordHash: row.PasswordHash}, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func identityParams(userID string, ext External, email string) repo.InsertIdentityParams {
+	return repo.InsertIdentityParams{
+		UserID:   userID,
+		Provider: ext.Provider,
+		Subject:  ext.Subject,
+		Email:    sql.NullString{String: email, Valid: email != ""},
+	}
+}
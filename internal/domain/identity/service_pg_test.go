@@ -0,0 +1,95 @@
+//go:build integration
+
+package identity_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/Ulpio/vergo/internal/auth"
+	"github.com/Ulpio/vergo/internal/domain/identity"
+	"github.com/Ulpio/vergo/internal/domain/user"
+	"github.com/Ulpio/vergo/internal/pkg/testutil"
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+func TestPGService_ResolveLinksVerifiedPasswordAccount(t *testing.T) {
+	db := testutil.PGContainer(t)
+	q := repo.New(db)
+	users := user.NewPostgresService(db, q, nil)
+	svc := identity.NewPostgresService(db, q)
+
+	u, err := users.Signup("carol@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Signup: %v", err)
+	}
+	ext := identity.External{Provider: "google", Subject: "g-carol", Email: "Carol@example.com", EmailVerified: true}
+
+	// a password account nobody verified cannot be taken over by an IdP
+	if _, _, err := svc.Resolve(ext); !errors.Is(err, identity.ErrAccountExists) {
+		t.Fatalf("Resolve before verification err = %v, want ErrAccountExists", err)
+	}
+
+	// the signup link: an email change to the address the account has
+	emails := auth.NewEmailChangeStore(q)
+	token, err := emails.Create(u.ID, u.Email)
+	if err != nil {
+		t.Fatalf("Create token: %v", err)
+	}
+	ch, err := emails.Consume(token)
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	if err := users.ChangeEmail(ch.UserID, ch.NewEmail); err != nil {
+		t.Fatalf("ChangeEmail: %v", err)
+	}
+
+	got, created, err := svc.Resolve(ext)
+	if err != nil || created || got.ID != u.ID {
+		t.Fatalf("Resolve after verification = %+v, %v, %v; want user %s linked", got, created, err, u.ID)
+	}
+	ids, _ := svc.ListForUser(u.ID)
+	if len(ids) != 1 || ids[0].Subject != "g-carol" {
+		t.Errorf("identities = %+v", ids)
+	}
+}
+
+func TestPGService_ResolveConcurrentFirstLogin(t *testing.T) {
+	db := testutil.PGContainer(t)
+	svc := identity.NewPostgresService(db, repo.New(db))
+	ext := identity.External{Provider: "google", Subject: "g-dave", Email: "dave@example.com", EmailVerified: true}
+
+	const n = 8
+	var (
+		wg      sync.WaitGroup
+		ids     [n]string
+		created [n]bool
+		errs    [n]error
+	)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u, c, err := svc.Resolve(ext)
+			ids[i], created[i], errs[i] = u.ID, c, err
+		}()
+	}
+	wg.Wait()
+
+	creations := 0
+	for i := range n {
+		if errs[i] != nil {
+			t.Fatalf("Resolve %d: %v", i, errs[i])
+		}
+		if ids[i] != ids[0] {
+			t.Errorf("Resolve %d = user %s, want %s", i, ids[i], ids[0])
+		}
+		if created[i] {
+			creations++
+		}
+	}
+	if creations != 1 {
+		t.Errorf("created = %d users, want 1", creations)
+	}
+}
@@ -0,0 +1,57 @@
+package org
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Ulpio/vergo/internal/pkg/precondition"
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+var (
+	ErrNotDeleted     = errors.New("org is not deleted")
+	ErrRestoreExpired = errors.New("org restore window has passed")
+)
+
+func (s *pgService) Delete(orgID, actorID string, ifVersion int64) error {
+	n, err := s.q.SoftDeleteOrg(context.Background(), repo.SoftDeleteOrgParams{
+		DeletedBy: sql.NullString{String: actorID, Valid: actorID != ""},
+		ID:        orgID,
+		IfVersion: ifVersion,
+	})
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	if ifVersion != precondition.Any {
+		if o, err := s.Get(orgID); err == nil && o.DeletedAt == nil {
+			return precondition.ErrFailed
+		}
+	}
+	return ErrNotFound
+}
+
+func (s *pgService) Restore(orgID string, deletedSince time.Time) error {
+	n, err := s.q.RestoreOrg(context.Background(), repo.RestoreOrgParams{
+		ID:           orgID,
+		DeletedSince: sql.NullTime{Time: deletedSince, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	o, err := s.Get(orgID)
+	if err != nil {
+		return err
+	}
+	if o.DeletedAt == nil {
+		return ErrNotDeleted
+	}
+	return ErrRestoreExpired
+}
@@ -3,14 +3,41 @@ package org
 import "time"
 
 type Organization struct {
+	ID                 string     `json:"id"`
+	Name               string     `json:"name"`
+	Slug               string     `json:"slug"` // URL-safe, unique
+	OwnerUser          string     `json:"owner_user_id"`
+	Settings           Settings   `json:"settings"`
+	Branding           Branding   `json:"branding"`
+	AllowImpersonation bool       `json:"allow_impersonation"`    // platform admins may act as members
+	SuspendedAt        *time.Time `json:"suspended_at,omitempty"` // set by a platform admin; blocks all tenant access
+	SuspendedReason    string     `json:"-"`
+	DeletedAt          *time.Time `json:"deleted_at,omitempty"` // soft-deleted; restorable until purged
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+	Version            int64      `json:"version"` // served as the ETag
+}
+
+type Membership struct {
+	OrgID   string `json:"org_id"`
+	UserID  string `json:"user_id"`
+	Role    string `json:"role"`    // owner | admin | member
+	Version int64  `json:"version"` // muda a cada alteração de role/re-adição
+}
+
+// UserOrg is an org as listed for one of its members.
+type UserOrg struct {
 	ID        string    `json:"id"`
 	Name      string    `json:"name"`
-	OwnerUser string    `json:"owner_user_id"`
+	Role      string    `json:"role"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
-type Membership struct {
-	OrgID  string `json:"org_id"`
-	UserID string `json:"user_id"`
-	Role   string `json:"role"` // owner | admin | member
+// Member is a membership with the member's user profile.
+type Member struct {
+	UserID       string `json:"user_id"`
+	Email        string `json:"email"`
+	DisplayName  string `json:"display_name"`
+	AvatarFileID string `json:"avatar_file_id,omitempty"`
+	Role         string `json:"role"`
 }
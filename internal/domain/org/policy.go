@@ -0,0 +1,45 @@
+package org
+
+import "errors"
+
+var (
+	ErrInvalidRole    = errors.New("invalid role")
+	ErrRoleAboveActor = errors.New("cannot grant a role above your own")
+	ErrMemberOutranks = errors.New("member has a higher role than you")
+	ErrLastOwner      = errors.New("org must keep at least one owner")
+)
+
+// roleRank orders the built-in roles; custom roles rank as member and an
+// empty role ranks 0.
+var roleRank = map[string]int{"member": 1, "admin": 2, "owner": 3}
+
+func (r Role) rank() int {
+	if r.Name == "" {
+		return 0
+	}
+	if n, ok := roleRank[r.Name]; ok {
+		return n
+	}
+	return roleRank["member"]
+}
+
+// outranks reports whether r is above actor: higher on the built-in ladder,
+// or granting a permission actor lacks.
+func (r Role) outranks(actor Role) bool {
+	return r.Name != "" && (r.rank() > actor.rank() || !actor.covers(r))
+}
+
+// CheckRoleChange is the membership policy for an actor moving a member or
+// API key from role current to next. current is the zero Role when adding
+// and next is the zero Role when removing. Nobody grants a role above their
+// own, or changes or removes a member who outranks them. Keeping an owner is
+// enforced by the service, which sees all memberships.
+func CheckRoleChange(actor, current, next Role) error {
+	if next.outranks(actor) {
+		return ErrRoleAboveActor
+	}
+	if current.outranks(actor) {
+		return ErrMemberOutranks
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package org
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckRoleChange(t *testing.T) {
+	roles := map[string]Role{
+		"":       {},
+		"member": {Name: "member", Permissions: []string{PermProjectsWrite}},
+		"admin":  {Name: "admin", Permissions: []string{PermProjectsWrite, PermMembersManage, PermAuditRead}},
+		"owner":  {Name: "owner", Permissions: []string{PermProjectsWrite, PermMembersManage, PermAuditRead}},
+		// custom roles rank as member but must not grant more than the actor has
+		"auditor": {Name: "auditor", Permissions: []string{PermAuditRead}},
+		"viewer":  {Name: "viewer"},
+	}
+	cases := []struct {
+		actor, current, next string
+		want                 error
+	}{
+		{"admin", "", "member", nil},
+		{"admin", "", "admin", nil},
+		{"admin", "", "owner", ErrRoleAboveActor},
+		{"admin", "member", "owner", ErrRoleAboveActor},
+		{"admin", "owner", "member", ErrMemberOutranks},
+		{"admin", "owner", "", ErrMemberOutranks},
+		{"admin", "admin", "", nil},
+		{"owner", "owner", "admin", nil},
+		{"owner", "member", "owner", nil},
+		{"member", "", "member", nil},
+		{"", "", "member", ErrRoleAboveActor},
+		{"admin", "", "auditor", nil},
+		{"member", "", "auditor", ErrRoleAboveActor},
+		{"member", "auditor", "member", ErrMemberOutranks},
+		{"member", "", "viewer", nil},
+		{"auditor", "", "member", ErrRoleAboveActor},
+		{"auditor", "", "admin", ErrRoleAboveActor},
+	}
+	for _, tc := range cases {
+		got := CheckRoleChange(roles[tc.actor], roles[tc.current], roles[tc.next])
+		if !errors.Is(got, tc.want) {
+			t.Errorf("CheckRoleChange(%q, %q, %q) = %v, want %v", tc.actor, tc.current, tc.next, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package org
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+// Permissions a role can grant. Routes check them with
+// middleware.RequirePermission.
+const (
+	PermProjectsWrite  = "projects.write"
+	PermProjectsDelete = "projects.delete"
+	PermAPIKeysManage  = "api_keys.manage"
+	PermWebhooksManage = "webhooks.manage"
+	PermBillingManage  = "billing.manage"
+	PermMembersInvite  = "members.invite"
+	PermMembersManage  = "members.manage"
+	PermRolesManage    = "roles.manage"
+	PermAuditRead      = "audit.read"
+	PermSSOManage      = "sso.manage"
+	PermTeamsManage    = "teams.manage"
+	PermOrgManage      = "org.manage" // name, slug, settings, branding and project fields
+	// PermProjectsAll reaches every project without a grant.
+	PermProjectsAll = "projects.access_all"
+)
+
+// Permissions lists every known permission.
+var Permissions = []string{
+	PermProjectsWrite, PermProjectsDelete, PermAPIKeysManage, PermWebhooksManage, PermBillingManage,
+	PermMembersInvite, PermMembersManage, PermRolesManage, PermAuditRead, PermSSOManage,
+	PermTeamsManage, PermProjectsAll, PermOrgManage,
+}
+
+var (
+	ErrRoleNotFound       = errors.New("role not found")
+	ErrRoleExists         = errors.New("role already exists")
+	ErrRoleInUse          = errors.New("role is assigned to members or API keys")
+	ErrBuiltinRole        = errors.New("built-in roles cannot be changed")
+	ErrInvalidRoleName    = errors.New("invalid role name")
+	ErrUnknownPermission  = errors.New("unknown permission")
+	ErrPermissionAboveOwn = errors.New("cannot grant a permission you do not have")
+)
+
+var roleName = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,31}$`)
+
+// Role is a named set of permissions. Built-in presets (owner, admin,
+// member) are shared by every org and have no OrgID.
+type Role struct {
+	ID          string    `json:"id"`
+	OrgID       string    `json:"org_id,omitempty"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	Builtin     bool      `json:"builtin"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Grants reports whether the role includes perm.
+func (r Role) Grants(perm string) bool {
+	return slices.Contains(r.Permissions, perm)
+}
+
+// covers reports whether r grants every permission of other.
+func (r Role) covers(other Role) bool {
+	for _, p := range other.Permissions {
+		if !r.Grants(p) {
+			return false
+		}
+	}
+	return true
+}
+
+func toRole(r repo.Role) Role {
+	return Role{
+		ID:          r.ID,
+		OrgID:       r.OrgID.String,
+		Name:        r.Name,
+		Description: r.Description,
+		Permissions: r.Permissions,
+		Builtin:     !r.OrgID.Valid,
+		CreatedAt:   r.CreatedAt,
+		UpdatedAt:   r.UpdatedAt,
+	}
+}
+
+// validPermissions reports ErrUnknownPermission for anything outside
+// Permissions and returns perms sorted and deduplicated.
+func validPermissions(perms []string) ([]string, error) {
+	out := slices.Clone(perms)
+	for _, p := range out {
+		if !slices.Contains(Permissions, p) {
+			return nil, ErrUnknownPermission
+		}
+	}
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
+
+func (s *pgService) ListRoles(orgID string) ([]Role, error) {
+	rows, err := s.q.ListRoles(context.Background(), orgID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Role, len(rows))
+	for i, r := range rows {
+		out[i] = toRole(r)
+	}
+	return out, nil
+}
+
+func (s *pgService) GetRole(orgID, name string) (Role, error) {
+	r, err := s.q.GetRole(context.Background(), repo.GetRoleParams{OrgID: orgID, Name: name})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Role{}, ErrRoleNotFound
+	}
+	if err != nil {
+		return Role{}, err
+	}
+	return toRole(r), nil
+}
+
+func (s *pgService) CreateRole(orgID, actorRole string, in Role) (Role, error) {
+	if !roleName.MatchString(in.Name) {
+		return Role{}, ErrInvalidRoleName
+	}
+	perms, err := validPermissions(in.Permissions)
+	if err != nil {
+		return Role{}, err
+	}
+	if err := s.checkGrantable(orgID, actorRole, perms); err != nil {
+		return Role{}, err
+	}
+	if roleRank[in.Name] > 0 {
+		return Role{}, ErrRoleExists // a built-in preset
+	}
+	r, err := s.q.CreateRole(context.Background(), repo.CreateRoleParams{
+		OrgID:       orgID,
+		Name:        in.Name,
+		Description: in.Description,
+		Permissions: perms,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Role{}, ErrRoleExists
+	}
+	if err != nil {
+		return Role{}, err
+	}
+	return toRole(r), nil
+}
+
+func (s *pgService) UpdateRole(orgID, actorRole string, in Role) (Role, error) {
+	current, err := s.GetRole(orgID, in.Name)
+	if err != nil {
+		return Role{}, err
+	}
+	if current.Builtin {
+		return Role{}, ErrBuiltinRole
+	}
+	perms, err := validPermissions(in.Permissions)
+	if err != nil {
+		return Role{}, err
+	}
+	// both what the role grants now and what it will grant
+	if err := s.checkGrantable(orgID, actorRole, append(perms, current.Permissions...)); err != nil {
+		return Role{}, err
+	}
+	r, err := s.q.UpdateRole(context.Background(), repo.UpdateRoleParams{
+		Description: in.Description,
+		Permissions: perms,
+		OrgID:       orgID,
+		Name:        in.Name,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Role{}, ErrRoleNotFound
+	}
+	if err != nil {
+		return Role{}, err
+	}
+	return toRole(r), nil
+}
+
+func (s *pgService) DeleteRole(orgID, actorRole, name string) error {
+	current, err := s.GetRole(orgID, name)
+	if err != nil {
+		return err
+	}
+	if current.Builtin {
+		return ErrBuiltinRole
+	}
+	if err := s.checkGrantable(orgID, actorRole, current.Permissions); err != nil {
+		return err
+	}
+	n, err := s.q.DeleteRole(context.Background(), repo.DeleteRoleParams{OrgID: orgID, Name: name})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrRoleInUse
+	}
+	return nil
+}
+
+// checkGrantable refuses role changes touching permissions the actor's own
+// role lacks, so a custom role never becomes a way up.
+func (s *pgService) checkGrantable(orgID, actorRole string, perms []string) error {
+	actor, err := s.GetRole(orgID, actorRole)
+	if errors.Is(err, ErrRoleNotFound) {
+		return ErrPermissionAboveOwn
+	}
+	if err != nil {
+		return err
+	}
+	if !actor.covers(Role{Permissions: perms}) {
+		return ErrPermissionAboveOwn
+	}
+	return nil
+}
+
+func (s *pgService) AuthorizeRoleChange(orgID, actorRole, current, next string) error {
+	var roles [3]Role
+	for i, name := range []string{actorRole, current, next} {
+		if name == "" {
+			continue
+		}
+		r, err := s.GetRole(orgID, name)
+		if errors.Is(err, ErrRoleNotFound) {
+			if i == 2 {
+				return ErrInvalidRole
+			}
+			continue // a role that no longer exists grants nothing
+		}
+		if err != nil {
+			return err
+		}
+		roles[i] = r
+	}
+	return CheckRoleChange(roles[0], roles[1], roles[2])
+}
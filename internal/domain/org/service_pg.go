@@ -3,7 +3,9 @@ package org
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,19 +14,71 @@ import (
 )
 
 var (
-	ErrNotFound = errors.New("org not found")
+	ErrNotFound  = errors.New("org not found")
+	ErrNotMember = errors.New("not a member")
 )
 
 type Service interface {
+	// Create derives the slug from the name, suffixed when taken.
 	Create(name, ownerUserID string) (Organization, error)
 	Get(id string) (Organization, error)
+	// UpdateProfile changes name, slug (ErrSlugTaken), settings
+	// (*SettingsError) and branding of the org at ifVersion, or at any
+	// version with precondition.Any; otherwise it is
+	// precondition.ErrFailed.
+	UpdateProfile(orgID string, in ProfileUpdate, ifVersion int64) (Organization, error)
 
+	// AddMember, UpdateMember and RemoveMember never leave the org without
+	// an owner (ErrLastOwner) and refuse roles the org does not have
+	// (ErrInvalidRole); check AuthorizeRoleChange for the actor first.
+	// AddMember also refuses emails outside the org's allowed domains
+	// (ErrEmailDomainForbidden).
 	AddMember(orgID, userID, role string) error
 	UpdateMember(orgID, userID, role string) error
 	RemoveMember(orgID, userID string) error
 	IsMember(orgID, userID string) (bool, string, error) // (ok, role)
+	GetMembership(orgID, userID string) (Membership, error)
+	// ListForUser returns the orgs userID belongs to, by name.
+	ListForUser(userID string, limit, offset int) ([]UserOrg, error)
+	// ListMembers returns the org's members, by email.
+	ListMembers(orgID string, limit, offset int) ([]Member, error)
 
-	Delete(orgID string) error
+	// SetImpersonationAllowed lets or stops platform admins acting as members.
+	SetImpersonationAllowed(orgID string, allowed bool) error
+
+	// StartOwnershipTransfer proposes toUserID, a member, as owner. Only the
+	// current owner may; a new proposal replaces a pending one.
+	StartOwnershipTransfer(orgID, fromUserID, toUserID string) (Transfer, error)
+	// GetOwnershipTransfer returns the pending transfer or ErrNoTransfer.
+	GetOwnershipTransfer(orgID string) (Transfer, error)
+	// AcceptOwnershipTransfer completes the transfer proposed to userID: they
+	// become the owner and the previous owner stays on as admin.
+	AcceptOwnershipTransfer(orgID, userID string) (Transfer, error)
+	// CancelOwnershipTransfer withdraws (owner) or declines (new owner) the
+	// pending transfer.
+	CancelOwnershipTransfer(orgID, userID string) error
+
+	// ListRoles returns the built-in presets and the org's custom roles.
+	ListRoles(orgID string) ([]Role, error)
+	// GetRole looks up a preset or custom role by name (ErrRoleNotFound).
+	GetRole(orgID, name string) (Role, error)
+	// CreateRole, UpdateRole and DeleteRole manage custom roles on behalf
+	// of a member with actorRole, who cannot touch permissions they lack
+	// (ErrPermissionAboveOwn). Presets are read-only (ErrBuiltinRole) and
+	// roles still assigned cannot be deleted (ErrRoleInUse).
+	CreateRole(orgID, actorRole string, in Role) (Role, error)
+	UpdateRole(orgID, actorRole string, in Role) (Role, error)
+	DeleteRole(orgID, actorRole, name string) error
+	// AuthorizeRoleChange resolves the roles by name and applies
+	// CheckRoleChange. An unknown next role is ErrInvalidRole.
+	AuthorizeRoleChange(orgID, actorRole, current, next string) error
+
+	// Delete soft-deletes the org at ifVersion, as UpdateProfile; the purge
+	// worker removes it for good once the restore window has passed.
+	Delete(orgID, actorID string, ifVersion int64) error
+	// Restore undoes a deletion made after deletedSince (ErrNotDeleted,
+	// ErrRestoreExpired).
+	Restore(orgID string, deletedSince time.Time) error
 }
 
 type pgService struct {
@@ -49,11 +103,16 @@ func (s *pgService) Create(name, ownerUserID string) (Organization, error) {
 
 	qtx := s.q.WithTx(tx)
 
+	slug, err := uniqueSlug(ctx, qtx, name, id)
+	if err != nil {
+		return Organization{}, err
+	}
 	err = qtx.InsertOrg(ctx, repo.InsertOrgParams{
 		ID:          id,
 		Name:        name,
 		OwnerUserID: ownerUserID,
 		CreatedAt:   now,
+		Slug:        slug,
 	})
 	if err != nil {
 		return Organization{}, err
@@ -72,7 +131,10 @@ func (s *pgService) Create(name, ownerUserID string) (Organization, error) {
 		return Organization{}, err
 	}
 
-	return Organization{ID: id, Name: name, OwnerUser: ownerUserID, CreatedAt: now}, nil
+	return Organization{
+		ID: id, Name: name, Slug: slug, OwnerUser: ownerUserID,
+		AllowImpersonation: true, CreatedAt: now, UpdatedAt: now,
+	}, nil
 }
 
 func (s *pgService) Get(id string) (Organization, error) {
@@ -83,43 +145,184 @@ func (s *pgService) Get(id string) (Organization, error) {
 	if err != nil {
 		return Organization{}, err
 	}
-	return Organization{
-		ID:        r.ID,
-		Name:      r.Name,
-		OwnerUser: r.OwnerUserID,
-		CreatedAt: r.CreatedAt,
-	}, nil
+	o := Organization{
+		ID:                 r.ID,
+		Name:               r.Name,
+		Slug:               r.Slug,
+		OwnerUser:          r.OwnerUserID,
+		Branding:           Branding{LogoFileID: r.LogoFileID.String, PrimaryColor: r.PrimaryColor},
+		AllowImpersonation: r.AllowImpersonation,
+		SuspendedReason:    r.SuspendedReason,
+		CreatedAt:          r.CreatedAt,
+		UpdatedAt:          r.UpdatedAt,
+		Version:            r.Version,
+	}
+	// stored settings were validated on write
+	_ = json.Unmarshal(r.Settings, &o.Settings)
+	if r.SuspendedAt.Valid {
+		o.SuspendedAt = &r.SuspendedAt.Time
+	}
+	if r.DeletedAt.Valid {
+		o.DeletedAt = &r.DeletedAt.Time
+	}
+	return o, nil
+}
+
+func (s *pgService) SetImpersonationAllowed(orgID string, allowed bool) error {
+	n, err := s.q.SetOrgAllowImpersonation(context.Background(), repo.SetOrgAllowImpersonationParams{
+		ID:                 orgID,
+		AllowImpersonation: allowed,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
 }
 
 func (s *pgService) AddMember(orgID, userID, role string) error {
-	return s.q.UpsertMember(context.Background(), repo.UpsertMemberParams{
-		OrgID:  orgID,
-		UserID: userID,
-		Role:   role,
+	return s.inTx(func(ctx context.Context, q *repo.Queries) error {
+		if err := roleExists(ctx, q, orgID, role); err != nil {
+			return err
+		}
+		if err := EmailAllowed(ctx, q, orgID, userID); err != nil {
+			return err
+		}
+		if role != "owner" {
+			if err := keepOwner(ctx, q, orgID, userID); err != nil {
+				return err
+			}
+		}
+		return q.UpsertMember(ctx, repo.UpsertMemberParams{
+			OrgID:  orgID,
+			UserID: userID,
+			Role:   role,
+		})
 	})
 }
 
 func (s *pgService) UpdateMember(orgID, userID, role string) error {
-	res, err := s.q.UpdateMemberRole(context.Background(), repo.UpdateMemberRoleParams{
-		OrgID:  orgID,
-		UserID: userID,
-		Role:   role,
+	return s.inTx(func(ctx context.Context, q *repo.Queries) error {
+		if err := roleExists(ctx, q, orgID, role); err != nil {
+			return err
+		}
+		if role != "owner" {
+			if err := keepOwner(ctx, q, orgID, userID); err != nil {
+				return err
+			}
+		}
+		res, err := q.UpdateMemberRole(ctx, repo.UpdateMemberRoleParams{
+			OrgID:  orgID,
+			UserID: userID,
+			Role:   role,
+		})
+		if err != nil {
+			return err
+		}
+		n, _ := res.RowsAffected()
+		if n == 0 {
+			return ErrNotMember
+		}
+		return nil
 	})
+}
+
+func (s *pgService) RemoveMember(orgID, userID string) error {
+	return s.inTx(func(ctx context.Context, q *repo.Queries) error {
+		if err := keepOwner(ctx, q, orgID, userID); err != nil {
+			return err
+		}
+		// teams and project grants do not survive leaving the org
+		if err := q.DeleteUserTeamMemberships(ctx, repo.DeleteUserTeamMembershipsParams{OrgID: orgID, UserID: userID}); err != nil {
+			return err
+		}
+		if err := q.DeleteUserProjectGrants(ctx, repo.DeleteUserProjectGrantsParams{
+			OrgID:  orgID,
+			UserID: sql.NullString{String: userID, Valid: true},
+		}); err != nil {
+			return err
+		}
+		return q.DeleteMember(ctx, repo.DeleteMemberParams{
+			OrgID:  orgID,
+			UserID: userID,
+		})
+	})
+}
+
+func roleExists(ctx context.Context, q *repo.Queries, orgID, role string) error {
+	_, err := q.GetRole(ctx, repo.GetRoleParams{OrgID: orgID, Name: role})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrInvalidRole
+	}
+	return err
+}
+
+// EmailAllowed checks userID's email against the org's allowed domains. Every
+// path that adds a member calls it, SCIM provisioning included.
+func EmailAllowed(ctx context.Context, q *repo.Queries, orgID, userID string) error {
+	o, err := q.GetOrg(ctx, orgID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
 	if err != nil {
 		return err
 	}
-	n, _ := res.RowsAffected()
-	if n == 0 {
-		return sql.ErrNoRows
+	var st Settings
+	_ = json.Unmarshal(o.Settings, &st)
+	if len(st.AllowedEmailDomains) == 0 {
+		return nil
+	}
+	u, err := q.GetUserByID(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrEmailDomainForbidden
+	}
+	if err != nil {
+		return err
+	}
+	if !st.AllowsEmail(u.Email) {
+		return ErrEmailDomainForbidden
 	}
 	return nil
 }
 
-func (s *pgService) RemoveMember(orgID, userID string) error {
-	return s.q.DeleteMember(context.Background(), repo.DeleteMemberParams{
-		OrgID:  orgID,
-		UserID: userID,
-	})
+// keepOwner runs before userID stops being an owner of the org. It refuses
+// to drop the last owner and, when userID is organizations.owner_user_id,
+// hands that to another owner.
+func keepOwner(ctx context.Context, q *repo.Queries, orgID, userID string) error {
+	owners, err := q.LockOrgOwners(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	others := slices.DeleteFunc(slices.Clone(owners), func(id string) bool { return id == userID })
+	if len(others) == len(owners) {
+		return nil // not an owner
+	}
+	if len(others) == 0 {
+		return ErrLastOwner
+	}
+	o, err := q.GetOrg(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if o.OwnerUserID != userID {
+		return nil
+	}
+	return q.SetOrgOwner(ctx, repo.SetOrgOwnerParams{ID: orgID, OwnerUserID: others[0]})
+}
+
+func (s *pgService) inTx(fn func(ctx context.Context, q *repo.Queries) error) error {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err := fn(ctx, s.q.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (s *pgService) IsMember(orgID, userID string) (bool, string, error) {
@@ -133,6 +336,54 @@ func (s *pgService) IsMember(orgID, userID string) (bool, string, error) {
 	return err == nil, role, err
 }
 
-func (s *pgService) Delete(id string) error {
-	return s.q.DeleteOrg(context.Background(), id)
+func (s *pgService) GetMembership(orgID, userID string) (Membership, error) {
+	m, err := s.q.GetMembership(context.Background(), repo.GetMembershipParams{
+		OrgID:  orgID,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Membership{}, ErrNotMember
+	}
+	if err != nil {
+		return Membership{}, err
+	}
+	return Membership{OrgID: m.OrgID, UserID: m.UserID, Role: m.Role, Version: m.Version}, nil
+}
+
+func (s *pgService) ListForUser(userID string, limit, offset int) ([]UserOrg, error) {
+	rows, err := s.q.ListUserOrgs(context.Background(), repo.ListUserOrgsParams{
+		UserID:      userID,
+		QueryLimit:  int32(limit),
+		QueryOffset: int32(offset),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]UserOrg, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, UserOrg{ID: r.ID, Name: r.Name, Role: r.Role, CreatedAt: r.CreatedAt})
+	}
+	return out, nil
+}
+
+func (s *pgService) ListMembers(orgID string, limit, offset int) ([]Member, error) {
+	rows, err := s.q.ListOrgMembers(context.Background(), repo.ListOrgMembersParams{
+		OrgID:       orgID,
+		QueryLimit:  int32(limit),
+		QueryOffset: int32(offset),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Member, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, Member{
+			UserID:       r.UserID,
+			Email:        r.Email,
+			DisplayName:  r.DisplayName,
+			AvatarFileID: r.AvatarFileID.String,
+			Role:         r.Role,
+		})
+	}
+	return out, nil
 }
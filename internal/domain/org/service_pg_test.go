@@ -3,10 +3,13 @@
 package org_test
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/Ulpio/vergo/internal/domain/org"
 	"github.com/Ulpio/vergo/internal/domain/user"
+	"github.com/Ulpio/vergo/internal/pkg/precondition"
 	"github.com/Ulpio/vergo/internal/pkg/testutil"
 	"github.com/Ulpio/vergo/internal/repo"
 )
@@ -14,7 +17,7 @@ import (
 func setupOrg(t *testing.T) (org.Service, user.User) {
 	t.Helper()
 	db := testutil.PGContainer(t)
-	userSvc := user.NewPostgresService(db, repo.New(db))
+	userSvc := user.NewPostgresService(db, repo.New(db), nil)
 	u, err := userSvc.Signup("orgowner@test.com", "pass123")
 	if err != nil {
 		t.Fatalf("create user: %v", err)
@@ -58,9 +61,62 @@ func TestPGService_GetOrg_NotFound(t *testing.T) {
 	}
 }
 
+func TestPGService_UpdateProfile(t *testing.T) {
+	svc, owner := setupOrg(t)
+	a, _ := svc.Create("Acme Corp", owner.ID)
+	b, _ := svc.Create("Acme Corp", owner.ID)
+	if a.Slug != "acme-corp" || b.Slug == a.Slug {
+		t.Fatalf("slugs = %q, %q", a.Slug, b.Slug)
+	}
+
+	name, slug, color := "Acme Inc", "acme", "#FF8800"
+	st := org.Settings{DefaultRole: "admin", AllowedEmailDomains: []string{"test.com"}}
+	o, err := svc.UpdateProfile(a.ID, org.ProfileUpdate{Name: &name, Slug: &slug, Settings: &st, PrimaryColor: &color}, a.Version)
+	if err != nil {
+		t.Fatalf("UpdateProfile: %v", err)
+	}
+	if o.Name != name || o.Slug != slug || o.Branding.PrimaryColor != "#ff8800" || o.Settings.DefaultRole != "admin" {
+		t.Errorf("org = %+v", o)
+	}
+	if o.Version != a.Version+1 {
+		t.Errorf("version = %d, want %d", o.Version, a.Version+1)
+	}
+	// a.Version is stale now
+	if _, err := svc.UpdateProfile(a.ID, org.ProfileUpdate{Name: &name}, a.Version); !errors.Is(err, precondition.ErrFailed) {
+		t.Errorf("stale version: err = %v, want precondition.ErrFailed", err)
+	}
+
+	if _, err := svc.UpdateProfile(b.ID, org.ProfileUpdate{Slug: &slug}, precondition.Any); !errors.Is(err, org.ErrSlugTaken) {
+		t.Errorf("taken slug: err = %v", err)
+	}
+	bad := "Not A Slug"
+	if _, err := svc.UpdateProfile(b.ID, org.ProfileUpdate{Slug: &bad}, precondition.Any); !errors.Is(err, org.ErrInvalidSlug) {
+		t.Errorf("invalid slug: err = %v", err)
+	}
+	var se *org.SettingsError
+	if _, err := svc.UpdateProfile(b.ID, org.ProfileUpdate{Settings: &org.Settings{DefaultRole: "ghost"}}, precondition.Any); !errors.As(err, &se) {
+		t.Errorf("unknown default role: err = %v", err)
+	}
+	logo := "missing-file"
+	if _, err := svc.UpdateProfile(b.ID, org.ProfileUpdate{LogoFileID: &logo}, precondition.Any); !errors.Is(err, org.ErrInvalidLogo) {
+		t.Errorf("missing logo: err = %v", err)
+	}
+
+	// only test.com addresses may join a
+	if err := svc.AddMember(a.ID, owner.ID, "owner"); err != nil {
+		t.Errorf("AddMember in allowed domain: %v", err)
+	}
+	if _, err := svc.UpdateProfile(a.ID, org.ProfileUpdate{Settings: &org.Settings{AllowedEmailDomains: []string{"example.com"}}}, precondition.Any); err != nil {
+		t.Fatalf("UpdateProfile: %v", err)
+	}
+	if err := svc.AddMember(a.ID, owner.ID, "owner"); !errors.Is(err, org.ErrEmailDomainForbidden) {
+		t.Errorf("AddMember outside allowed domains: err = %v", err)
+	}
+}
+
 func TestPGService_Membership(t *testing.T) {
 	db := testutil.PGContainer(t)
-	userSvc := user.NewPostgresService(db, repo.New(db))
+	userSvc := user.NewPostgresService(db, repo.New(db), nil)
 	orgSvc := org.NewPostgresService(db, repo.New(db))
 
 	owner, _ := userSvc.Signup("owner@test.com", "pass")
@@ -102,15 +158,193 @@ func TestPGService_Membership(t *testing.T) {
 	}
 }
 
+func TestPGService_Listings(t *testing.T) {
+	db := testutil.PGContainer(t)
+	userSvc := user.NewPostgresService(db, repo.New(db), nil)
+	orgSvc := org.NewPostgresService(db, repo.New(db))
+
+	owner, _ := userSvc.Signup("owner@test.com", "pass")
+	member, _ := userSvc.Signup("member@test.com", "pass")
+	beta, _ := orgSvc.Create("Beta", owner.ID)
+	alpha, _ := orgSvc.Create("Alpha", owner.ID)
+	if err := orgSvc.AddMember(beta.ID, member.ID, "member"); err != nil {
+		t.Fatalf("AddMember: %v", err)
+	}
+
+	orgs, err := orgSvc.ListForUser(owner.ID, 10, 0)
+	if err != nil || len(orgs) != 2 || orgs[0].ID != alpha.ID || orgs[0].Role != "owner" {
+		t.Errorf("ListForUser(owner) = %+v, %v", orgs, err)
+	}
+	if page2, _ := orgSvc.ListForUser(owner.ID, 1, 1); len(page2) != 1 || page2[0].ID != beta.ID {
+		t.Errorf("second page = %+v", page2)
+	}
+	if orgs, _ := orgSvc.ListForUser(member.ID, 10, 0); len(orgs) != 1 || orgs[0].Role != "member" {
+		t.Errorf("ListForUser(member) = %+v", orgs)
+	}
+
+	members, err := orgSvc.ListMembers(beta.ID, 10, 0)
+	if err != nil || len(members) != 2 {
+		t.Fatalf("ListMembers = %+v, %v", members, err)
+	}
+	if members[0].Email != "member@test.com" || members[0].Role != "member" || members[1].UserID != owner.ID {
+		t.Errorf("members = %+v", members)
+	}
+}
+
+func TestPGService_OwnershipTransfer(t *testing.T) {
+	db := testutil.PGContainer(t)
+	userSvc := user.NewPostgresService(db, repo.New(db), nil)
+	orgSvc := org.NewPostgresService(db, repo.New(db))
+
+	owner, _ := userSvc.Signup("owner@test.com", "pass")
+	member, _ := userSvc.Signup("member@test.com", "pass")
+	o, _ := orgSvc.Create("Handover", owner.ID)
+	_ = orgSvc.AddMember(o.ID, member.ID, "member")
+
+	// The only owner cannot step down or leave
+	if err := orgSvc.UpdateMember(o.ID, owner.ID, "admin"); !errors.Is(err, org.ErrLastOwner) {
+		t.Errorf("demote last owner: err = %v, want ErrLastOwner", err)
+	}
+	if err := orgSvc.RemoveMember(o.ID, owner.ID); !errors.Is(err, org.ErrLastOwner) {
+		t.Errorf("remove last owner: err = %v, want ErrLastOwner", err)
+	}
+
+	if _, err := orgSvc.StartOwnershipTransfer(o.ID, member.ID, owner.ID); !errors.Is(err, org.ErrNotOwner) {
+		t.Errorf("non-owner start: err = %v, want ErrNotOwner", err)
+	}
+	if _, err := orgSvc.StartOwnershipTransfer(o.ID, owner.ID, member.ID); err != nil {
+		t.Fatalf("StartOwnershipTransfer: %v", err)
+	}
+	if _, err := orgSvc.AcceptOwnershipTransfer(o.ID, owner.ID); !errors.Is(err, org.ErrNoTransfer) {
+		t.Errorf("accept by proposer: err = %v, want ErrNoTransfer", err)
+	}
+	if _, err := orgSvc.AcceptOwnershipTransfer(o.ID, member.ID); err != nil {
+		t.Fatalf("AcceptOwnershipTransfer: %v", err)
+	}
+
+	got, _ := orgSvc.Get(o.ID)
+	if got.OwnerUser != member.ID {
+		t.Errorf("owner_user_id = %s, want %s", got.OwnerUser, member.ID)
+	}
+	if _, role, _ := orgSvc.IsMember(o.ID, owner.ID); role != "admin" {
+		t.Errorf("previous owner role = %q, want admin", role)
+	}
+	if _, err := orgSvc.GetOwnershipTransfer(o.ID); !errors.Is(err, org.ErrNoTransfer) {
+		t.Errorf("transfer still pending: %v", err)
+	}
+}
+
+func TestPGService_Roles(t *testing.T) {
+	db := testutil.PGContainer(t)
+	userSvc := user.NewPostgresService(db, repo.New(db), nil)
+	orgSvc := org.NewPostgresService(db, repo.New(db))
+
+	owner, _ := userSvc.Signup("owner@test.com", "pass")
+	member, _ := userSvc.Signup("member@test.com", "pass")
+	o, _ := orgSvc.Create("Roles", owner.ID)
+
+	roles, err := orgSvc.ListRoles(o.ID)
+	if err != nil || len(roles) != 3 || !roles[0].Builtin {
+		t.Fatalf("ListRoles = %+v, %v; want the 3 presets", roles, err)
+	}
+
+	auditor, err := orgSvc.CreateRole(o.ID, "admin", org.Role{Name: "auditor", Permissions: []string{org.PermAuditRead}})
+	if err != nil || auditor.Builtin || !auditor.Grants(org.PermAuditRead) {
+		t.Fatalf("CreateRole: %+v, %v", auditor, err)
+	}
+	if _, err := orgSvc.CreateRole(o.ID, "admin", org.Role{Name: "auditor"}); !errors.Is(err, org.ErrRoleExists) {
+		t.Errorf("duplicate: err = %v, want ErrRoleExists", err)
+	}
+	if _, err := orgSvc.CreateRole(o.ID, "owner", org.Role{Name: "admin"}); !errors.Is(err, org.ErrRoleExists) {
+		t.Errorf("preset name: err = %v, want ErrRoleExists", err)
+	}
+	if _, err := orgSvc.CreateRole(o.ID, "member", org.Role{Name: "spy", Permissions: []string{org.PermAuditRead}}); !errors.Is(err, org.ErrPermissionAboveOwn) {
+		t.Errorf("member grants audit.read: err = %v, want ErrPermissionAboveOwn", err)
+	}
+	if _, err := orgSvc.UpdateRole(o.ID, "owner", org.Role{Name: "admin"}); !errors.Is(err, org.ErrBuiltinRole) {
+		t.Errorf("update preset: err = %v, want ErrBuiltinRole", err)
+	}
+
+	if err := orgSvc.AddMember(o.ID, member.ID, "nonexistent"); !errors.Is(err, org.ErrInvalidRole) {
+		t.Errorf("unknown role: err = %v, want ErrInvalidRole", err)
+	}
+	if err := orgSvc.AddMember(o.ID, member.ID, "auditor"); err != nil {
+		t.Fatalf("AddMember with custom role: %v", err)
+	}
+	if err := orgSvc.DeleteRole(o.ID, "admin", "auditor"); !errors.Is(err, org.ErrRoleInUse) {
+		t.Errorf("delete assigned role: err = %v, want ErrRoleInUse", err)
+	}
+	_ = orgSvc.UpdateMember(o.ID, member.ID, "member")
+	if err := orgSvc.DeleteRole(o.ID, "admin", "auditor"); err != nil {
+		t.Errorf("DeleteRole: %v", err)
+	}
+}
+
+func TestPGService_MembershipVersion(t *testing.T) {
+	db := testutil.PGContainer(t)
+	userSvc := user.NewPostgresService(db, repo.New(db), nil)
+	orgSvc := org.NewPostgresService(db, repo.New(db))
+
+	owner, _ := userSvc.Signup("owner@test.com", "pass")
+	member, _ := userSvc.Signup("member@test.com", "pass")
+	o, _ := orgSvc.Create("Versioned", owner.ID)
+
+	_ = orgSvc.AddMember(o.ID, member.ID, "member")
+	m1, err := orgSvc.GetMembership(o.ID, member.ID)
+	if err != nil || m1.Role != "member" {
+		t.Fatalf("GetMembership: %+v, %v", m1, err)
+	}
+
+	_ = orgSvc.UpdateMember(o.ID, member.ID, "admin")
+	m2, _ := orgSvc.GetMembership(o.ID, member.ID)
+	if m2.Version == m1.Version {
+		t.Error("role change did not bump the version")
+	}
+
+	// Removing and re-adding must not reuse an old version.
+	_ = orgSvc.RemoveMember(o.ID, member.ID)
+	if _, err := orgSvc.GetMembership(o.ID, member.ID); err != org.ErrNotMember {
+		t.Errorf("removed member: err = %v, want ErrNotMember", err)
+	}
+	_ = orgSvc.AddMember(o.ID, member.ID, "admin")
+	m3, _ := orgSvc.GetMembership(o.ID, member.ID)
+	if m3.Version == m1.Version || m3.Version == m2.Version {
+		t.Error("re-added membership reused a version")
+	}
+}
+
 func TestPGService_DeleteOrg(t *testing.T) {
 	svc, owner := setupOrg(t)
 	o, _ := svc.Create("DeleteMe", owner.ID)
 
-	if err := svc.Delete(o.ID); err != nil {
+	if err := svc.Delete(o.ID, owner.ID, o.Version+1); !errors.Is(err, precondition.ErrFailed) {
+		t.Errorf("Delete at another version = %v, want precondition.ErrFailed", err)
+	}
+	if err := svc.Delete(o.ID, owner.ID, o.Version); err != nil {
 		t.Fatalf("Delete: %v", err)
 	}
-	_, err := svc.Get(o.ID)
-	if err != org.ErrNotFound {
-		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	got, err := svc.Get(o.ID)
+	if err != nil || got.DeletedAt == nil {
+		t.Fatalf("Get after delete = %+v, %v; want deleted_at set", got, err)
+	}
+	if orgs, _ := svc.ListForUser(owner.ID, 10, 0); len(orgs) != 0 {
+		t.Errorf("deleted org still listed: %+v", orgs)
+	}
+	if err := svc.Delete(o.ID, owner.ID, precondition.Any); !errors.Is(err, org.ErrNotFound) {
+		t.Errorf("second Delete = %v, want ErrNotFound", err)
+	}
+
+	// deleted before the window began
+	if err := svc.Restore(o.ID, time.Now().Add(time.Hour)); !errors.Is(err, org.ErrRestoreExpired) {
+		t.Errorf("Restore past window = %v, want ErrRestoreExpired", err)
+	}
+	if err := svc.Restore(o.ID, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if got, _ := svc.Get(o.ID); got.DeletedAt != nil {
+		t.Error("restored org still marked deleted")
+	}
+	if err := svc.Restore(o.ID, time.Now().Add(-time.Hour)); !errors.Is(err, org.ErrNotDeleted) {
+		t.Errorf("Restore of live org = %v, want ErrNotDeleted", err)
 	}
 }
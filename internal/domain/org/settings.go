@@ -0,0 +1,272 @@
+package org
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Ulpio/vergo/internal/pkg/precondition"
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+var (
+	ErrInvalidSlug          = errors.New("slug must be 1-63 lowercase letters, digits or single hyphens")
+	ErrSlugTaken            = errors.New("slug already in use")
+	ErrInvalidName          = errors.New("org name is required")
+	ErrInvalidColor         = errors.New("primary color must be #RRGGBB")
+	ErrInvalidLogo          = errors.New("logo must be an image file of the org")
+	ErrEmailDomainForbidden = errors.New("email domain not allowed by the org")
+)
+
+var (
+	slugPattern  = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	slugStrip    = regexp.MustCompile(`[^a-z0-9]+`)
+	colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+	domainLabel  = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+)
+
+// Settings is the org's settings document. Unknown fields are rejected.
+type Settings struct {
+	// DefaultRole is given to members added without a role and to users
+	// provisioned by SAML without a mapped role. Empty means member.
+	DefaultRole string `json:"default_role,omitempty"`
+	// AllowedEmailDomains, when set, limits membership to users whose
+	// email is in one of them.
+	AllowedEmailDomains []string `json:"allowed_email_domains,omitempty"`
+	// RequireMFA asks members to use a second factor. Stored for clients;
+	// the API has no second factor to enforce yet.
+	RequireMFA bool `json:"require_mfa,omitempty"`
+	// Session overrides token lifetimes for the org.
+	Session *SessionSettings `json:"session,omitempty"`
+}
+
+type SessionSettings struct {
+	// AccessTokenMinutes is the lifetime of org-scoped access tokens.
+	AccessTokenMinutes int `json:"access_token_minutes,omitempty"`
+}
+
+// SettingsError reports the settings field that failed validation.
+type SettingsError struct {
+	Field  string
+	Reason string
+}
+
+func (e *SettingsError) Error() string {
+	return fmt.Sprintf("settings.%s: %s", e.Field, e.Reason)
+}
+
+// ParseSettings decodes and validates a settings document. Role names are
+// checked against the org by the service.
+func ParseSettings(raw []byte) (Settings, error) {
+	var st Settings
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return st, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&st); err != nil {
+		return Settings{}, &SettingsError{Field: "", Reason: err.Error()}
+	}
+	return st, st.validate()
+}
+
+func (st *Settings) validate() error {
+	if st.DefaultRole == "owner" {
+		return &SettingsError{Field: "default_role", Reason: "cannot be owner"}
+	}
+	if len(st.AllowedEmailDomains) > 50 {
+		return &SettingsError{Field: "allowed_email_domains", Reason: "at most 50 domains"}
+	}
+	for i, d := range st.AllowedEmailDomains {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if !validDomain(d) {
+			return &SettingsError{Field: "allowed_email_domains", Reason: fmt.Sprintf("%q is not a domain", d)}
+		}
+		st.AllowedEmailDomains[i] = d
+	}
+	if s := st.Session; s != nil {
+		if s.AccessTokenMinutes != 0 && (s.AccessTokenMinutes < 5 || s.AccessTokenMinutes > 1440) {
+			return &SettingsError{Field: "session.access_token_minutes", Reason: "must be between 5 and 1440"}
+		}
+		if *s == (SessionSettings{}) {
+			st.Session = nil
+		}
+	}
+	return nil
+}
+
+func validDomain(d string) bool {
+	labels := strings.Split(d, ".")
+	if len(d) > 253 || len(labels) < 2 {
+		return false
+	}
+	for _, l := range labels {
+		if len(l) > 63 || !domainLabel.MatchString(l) {
+			return false
+		}
+	}
+	return true
+}
+
+// AllowsEmail reports whether email may join an org with these settings.
+func (st Settings) AllowsEmail(email string) bool {
+	if len(st.AllowedEmailDomains) == 0 {
+		return true
+	}
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(email[at+1:])
+	for _, d := range st.AllowedEmailDomains {
+		if domain == d {
+			return true
+		}
+	}
+	return false
+}
+
+// MemberRole is the role for a member added without one.
+func (st Settings) MemberRole() string {
+	if st.DefaultRole == "" {
+		return "member"
+	}
+	return st.DefaultRole
+}
+
+// Branding is how the org presents itself in the UI.
+type Branding struct {
+	LogoFileID   string `json:"logo_file_id,omitempty"`
+	PrimaryColor string `json:"primary_color,omitempty"`
+}
+
+// ProfileUpdate changes the fields that are set; Settings replaces the whole
+// document.
+type ProfileUpdate struct {
+	Name         *string
+	Slug         *string
+	Settings     *Settings
+	LogoFileID   *string // "" removes the logo
+	PrimaryColor *string // "" resets to the default
+}
+
+// Slugify derives a slug from an org name.
+func Slugify(name string) string {
+	s := strings.Trim(slugStrip.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(s) > 54 {
+		s = strings.TrimRight(s[:54], "-")
+	}
+	if s == "" {
+		return "org"
+	}
+	return s
+}
+
+// uniqueSlug returns Slugify(name), or that with an id suffix when another
+// org has it.
+func uniqueSlug(ctx context.Context, q *repo.Queries, name, orgID string) (string, error) {
+	slug := Slugify(name)
+	taken, err := q.OrgSlugTaken(ctx, repo.OrgSlugTakenParams{Slug: slug, ID: orgID})
+	if err != nil || !taken {
+		return slug, err
+	}
+	return slug + "-" + orgID[:8], nil
+}
+
+func (s *pgService) UpdateProfile(orgID string, in ProfileUpdate, ifVersion int64) (Organization, error) {
+	ctx := context.Background()
+	o, err := s.Get(orgID)
+	if err != nil {
+		return Organization{}, err
+	}
+	if err := precondition.Check(ifVersion, o.Version); err != nil {
+		return Organization{}, err
+	}
+
+	if in.Name != nil {
+		name := strings.TrimSpace(*in.Name)
+		if name == "" {
+			return Organization{}, ErrInvalidName
+		}
+		o.Name = name
+	}
+	if in.Slug != nil && *in.Slug != o.Slug {
+		if len(*in.Slug) > 63 || !slugPattern.MatchString(*in.Slug) {
+			return Organization{}, ErrInvalidSlug
+		}
+		taken, err := s.q.OrgSlugTaken(ctx, repo.OrgSlugTakenParams{Slug: *in.Slug, ID: orgID})
+		if err != nil {
+			return Organization{}, err
+		}
+		if taken {
+			return Organization{}, ErrSlugTaken
+		}
+		o.Slug = *in.Slug
+	}
+	if in.Settings != nil {
+		st := *in.Settings
+		if err := st.validate(); err != nil {
+			return Organization{}, err
+		}
+		if st.DefaultRole != "" {
+			if err := roleExists(ctx, s.q, orgID, st.DefaultRole); err != nil {
+				if errors.Is(err, ErrInvalidRole) {
+					return Organization{}, &SettingsError{Field: "default_role", Reason: "no such role"}
+				}
+				return Organization{}, err
+			}
+		}
+		o.Settings = st
+	}
+	if in.LogoFileID != nil && *in.LogoFileID != o.Branding.LogoFileID {
+		if *in.LogoFileID != "" {
+			f, err := s.q.GetFile(ctx, repo.GetFileParams{ID: *in.LogoFileID, OrgID: orgID})
+			if errors.Is(err, sql.ErrNoRows) {
+				return Organization{}, ErrInvalidLogo
+			}
+			if err != nil {
+				return Organization{}, err
+			}
+			if f.ContentType.Valid && !strings.HasPrefix(f.ContentType.String, "image/") {
+				return Organization{}, ErrInvalidLogo
+			}
+		}
+		o.Branding.LogoFileID = *in.LogoFileID
+	}
+	if in.PrimaryColor != nil {
+		if *in.PrimaryColor != "" && !colorPattern.MatchString(*in.PrimaryColor) {
+			return Organization{}, ErrInvalidColor
+		}
+		o.Branding.PrimaryColor = strings.ToLower(*in.PrimaryColor)
+	}
+
+	settings, err := json.Marshal(o.Settings)
+	if err != nil {
+		return Organization{}, err
+	}
+	n, err := s.q.UpdateOrgProfile(ctx, repo.UpdateOrgProfileParams{
+		Name:         o.Name,
+		Slug:         o.Slug,
+		Settings:     settings,
+		LogoFileID:   sql.NullString{String: o.Branding.LogoFileID, Valid: o.Branding.LogoFileID != ""},
+		PrimaryColor: o.Branding.PrimaryColor,
+		ID:           orgID,
+		IfVersion:    ifVersion,
+	})
+	if err != nil {
+		return Organization{}, err
+	}
+	if n == 0 {
+		// changed or gone since Get read it
+		if ifVersion != precondition.Any {
+			return Organization{}, precondition.ErrFailed
+		}
+		return Organization{}, ErrNotFound
+	}
+	return s.Get(orgID)
+}
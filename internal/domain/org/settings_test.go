@@ -0,0 +1,58 @@
+package org
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseSettings(t *testing.T) {
+	st, err := ParseSettings([]byte(`{
+		"default_role": "viewer",
+		"allowed_email_domains": [" Example.com ", "corp.example.org"],
+		"require_mfa": true,
+		"session": {"access_token_minutes": 30}
+	}`))
+	if err != nil {
+		t.Fatalf("ParseSettings: %v", err)
+	}
+	if st.MemberRole() != "viewer" || !st.RequireMFA || st.Session.AccessTokenMinutes != 30 {
+		t.Errorf("settings = %+v", st)
+	}
+	if !st.AllowsEmail("ana@EXAMPLE.com") || st.AllowsEmail("ana@other.com") {
+		t.Error("AllowsEmail does not follow allowed_email_domains")
+	}
+
+	cases := map[string]string{
+		`{"theme": "dark"}`:                        "",
+		`{"default_role": "owner"}`:                "default_role",
+		`{"allowed_email_domains": ["localhost"]}`: "allowed_email_domains",
+		`{"allowed_email_domains": ["a@b.com"]}`:   "allowed_email_domains",
+		`{"session": {"access_token_minutes": 2}}`: "session.access_token_minutes",
+		`{"require_mfa": "yes"}`:                   "",
+	}
+	for in, field := range cases {
+		_, err := ParseSettings([]byte(in))
+		var se *SettingsError
+		if !errors.As(err, &se) || se.Field != field {
+			t.Errorf("ParseSettings(%s) = %v, want error on %q", in, err, field)
+		}
+	}
+
+	if st, err := ParseSettings(nil); err != nil || st.MemberRole() != "member" || !st.AllowsEmail("x@y.z") {
+		t.Errorf("empty settings = %+v, %v", st, err)
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	cases := map[string]string{
+		"Acme Corp":          "acme-corp",
+		"  São Paulo Labs! ": "s-o-paulo-labs",
+		"---":                "org",
+		"ACME_2024":          "acme-2024",
+	}
+	for in, want := range cases {
+		if got := Slugify(in); got != want || !slugPattern.MatchString(got) {
+			t.Errorf("Slugify(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
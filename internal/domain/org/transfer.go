@@ -0,0 +1,127 @@
+package org
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+// TransferTTL is how long the new owner has to accept a transfer.
+const TransferTTL = 72 * time.Hour
+
+var (
+	ErrNotOwner       = errors.New("only the org owner can transfer ownership")
+	ErrTransferToSelf = errors.New("cannot transfer ownership to yourself")
+	ErrNoTransfer     = errors.New("no pending ownership transfer")
+)
+
+// Transfer is a pending ownership transfer.
+type Transfer struct {
+	OrgID      string    `json:"org_id"`
+	FromUserID string    `json:"from_user_id"`
+	ToUserID   string    `json:"to_user_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+func toTransfer(t repo.OwnershipTransfer) Transfer {
+	return Transfer{
+		OrgID:      t.OrgID,
+		FromUserID: t.FromUserID,
+		ToUserID:   t.ToUserID,
+		CreatedAt:  t.CreatedAt,
+		ExpiresAt:  t.ExpiresAt,
+	}
+}
+
+func (s *pgService) StartOwnershipTransfer(orgID, fromUserID, toUserID string) (Transfer, error) {
+	if fromUserID == toUserID {
+		return Transfer{}, ErrTransferToSelf
+	}
+	o, err := s.Get(orgID)
+	if err != nil {
+		return Transfer{}, err
+	}
+	if o.OwnerUser != fromUserID {
+		return Transfer{}, ErrNotOwner
+	}
+	if _, err := s.GetMembership(orgID, toUserID); err != nil {
+		return Transfer{}, err
+	}
+	t, err := s.q.UpsertOwnershipTransfer(context.Background(), repo.UpsertOwnershipTransferParams{
+		OrgID:      orgID,
+		FromUserID: fromUserID,
+		ToUserID:   toUserID,
+		ExpiresAt:  time.Now().Add(TransferTTL),
+	})
+	if err != nil {
+		return Transfer{}, err
+	}
+	return toTransfer(t), nil
+}
+
+func (s *pgService) GetOwnershipTransfer(orgID string) (Transfer, error) {
+	t, err := s.q.GetOwnershipTransfer(context.Background(), orgID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Transfer{}, ErrNoTransfer
+	}
+	if err != nil {
+		return Transfer{}, err
+	}
+	return toTransfer(t), nil
+}
+
+func (s *pgService) AcceptOwnershipTransfer(orgID, userID string) (Transfer, error) {
+	var out Transfer
+	err := s.inTx(func(ctx context.Context, q *repo.Queries) error {
+		t, err := q.ClaimOwnershipTransfer(ctx, repo.ClaimOwnershipTransferParams{OrgID: orgID, ToUserID: userID})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoTransfer
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := q.LockOrgOwners(ctx, orgID); err != nil {
+			return err
+		}
+		o, err := q.GetOrg(ctx, orgID)
+		if err != nil {
+			return err
+		}
+		// proposed by someone who is no longer the owner
+		if o.OwnerUserID != t.FromUserID {
+			return ErrNoTransfer
+		}
+		res, err := q.UpdateMemberRole(ctx, repo.UpdateMemberRoleParams{OrgID: orgID, UserID: userID, Role: "owner"})
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrNotMember
+		}
+		if err := q.SetOrgOwner(ctx, repo.SetOrgOwnerParams{ID: orgID, OwnerUserID: userID}); err != nil {
+			return err
+		}
+		// the previous owner may have left already; then there is no one to demote
+		if _, err := q.UpdateMemberRole(ctx, repo.UpdateMemberRoleParams{OrgID: orgID, UserID: t.FromUserID, Role: "admin"}); err != nil {
+			return err
+		}
+		out = toTransfer(t)
+		return nil
+	})
+	return out, err
+}
+
+func (s *pgService) CancelOwnershipTransfer(orgID, userID string) error {
+	n, err := s.q.DeleteOwnershipTransfer(context.Background(), repo.DeleteOwnershipTransferParams{OrgID: orgID, UserID: userID})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNoTransfer
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package platform
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+// ListParams searches orgs or users. Query matches an exact ID or part of
+// a name/email, case-insensitively; empty lists everything.
+type ListParams struct {
+	Query  string
+	Limit  int
+	Offset int
+}
+
+type OrgSummary struct {
+	ID              string     `json:"id"`
+	Name            string     `json:"name"`
+	OwnerUserID     string     `json:"owner_user_id"`
+	Plan            string     `json:"plan"` // effective plan, overrides included
+	MemberCount     int64      `json:"member_count"`
+	SuspendedAt     *time.Time `json:"suspended_at,omitempty"`
+	SuspendedReason string     `json:"suspended_reason,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+type UserSummary struct {
+	ID              string     `json:"id"`
+	Email           string     `json:"email"`
+	DisplayName     string     `json:"display_name"`
+	EmailVerified   bool       `json:"email_verified"`
+	PlatformAdmin   bool       `json:"platform_admin"`
+	SuspendedAt     *time.Time `json:"suspended_at,omitempty"`
+	SuspendedReason string     `json:"suspended_reason,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// ScopedAccess is the state an org-scoped token is checked against on every
+// request. Member is false once the membership is gone, and the org fields
+// are then zero.
+type ScopedAccess struct {
+	UserSuspended      bool
+	Member             bool
+	Role               string
+	MembershipVersion  int64
+	OrgDeleted         bool
+	OrgSuspended       bool
+	AllowImpersonation bool
+}
+
+// Usage is what an org consumes of its plan limits.
+type Usage struct {
+	Projects     int64 `json:"projects"`
+	Members      int64 `json:"members"`
+	StorageBytes int64 `json:"storage_bytes"`
+}
+
+type WebhookBacklog struct {
+	Pending       int64        `json:"pending"` // still being retried
+	Failed        int64        `json:"failed"`  // out of attempts
+	OldestPending *time.Time   `json:"oldest_pending,omitempty"`
+	ByOrg         []OrgBacklog `json:"by_org"`
+}
+
+type OrgBacklog struct {
+	OrgID   string    `json:"org_id"`
+	Pending int64     `json:"pending"`
+	Failed  int64     `json:"failed"`
+	Oldest  time.Time `json:"oldest"`
+}
+
+func (s *pgService) ListOrgs(p ListParams) ([]OrgSummary, error) {
+	rows, err := s.q.AdminListOrgs(context.Background(), repo.AdminListOrgsParams{
+		Search:      p.Query,
+		QueryLimit:  int32(p.Limit),
+		QueryOffset: int32(p.Offset),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]OrgSummary, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, OrgSummary{
+			ID:              r.ID,
+			Name:            r.Name,
+			OwnerUserID:     r.OwnerUserID,
+			Plan:            r.Plan,
+			MemberCount:     r.MemberCount,
+			SuspendedAt:     timePtr(r.SuspendedAt),
+			SuspendedReason: r.SuspendedReason,
+			CreatedAt:       r.CreatedAt,
+		})
+	}
+	return out, nil
+}
+
+func (s *pgService) ListUsers(p ListParams) ([]UserSummary, error) {
+	rows, err := s.q.AdminListUsers(context.Background(), repo.AdminListUsersParams{
+		Search:      p.Query,
+		QueryLimit:  int32(p.Limit),
+		QueryOffset: int32(p.Offset),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]UserSummary, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, UserSummary{
+			ID:              r.ID,
+			Email:           r.Email,
+			DisplayName:     r.DisplayName,
+			EmailVerified:   r.EmailVerifiedAt.Valid,
+			PlatformAdmin:   r.PlatformAdmin,
+			SuspendedAt:     timePtr(r.SuspendedAt),
+			SuspendedReason: r.SuspendedReason,
+			CreatedAt:       r.CreatedAt,
+		})
+	}
+	return out, nil
+}
+
+func (s *pgService) OrgUsage(orgID string) (Usage, error) {
+	r, err := s.q.GetOrgUsage(context.Background(), orgID)
+	if err != nil {
+		return Usage{}, err
+	}
+	return Usage{Projects: r.Projects, Members: r.Members, StorageBytes: r.StorageBytes}, nil
+}
+
+func (s *pgService) SuspendOrg(orgID, reason string) error {
+	n, err := s.q.SuspendOrg(context.Background(), repo.SuspendOrgParams{ID: orgID, SuspendedReason: reason})
+	return affected(n, err)
+}
+
+func (s *pgService) UnsuspendOrg(orgID string) error {
+	return affected(s.q.UnsuspendOrg(context.Background(), orgID))
+}
+
+func (s *pgService) SuspendUser(userID, reason string) error {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := s.q.WithTx(tx)
+
+	n, err := qtx.SuspendUser(ctx, repo.SuspendUserParams{ID: userID, SuspendedReason: reason})
+	if err := affected(n, err); err != nil {
+		return err
+	}
+	if err := qtx.RevokeAllUserTokens(ctx, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *pgService) UnsuspendUser(userID string) error {
+	return affected(s.q.UnsuspendUser(context.Background(), userID))
+}
+
+func (s *pgService) UserSuspended(userID string) (bool, error) {
+	return s.q.IsUserSuspended(context.Background(), userID)
+}
+
+func (s *pgService) ScopedAccess(userID, orgID string) (ScopedAccess, error) {
+	r, err := s.q.GetScopedAccess(context.Background(), repo.GetScopedAccessParams{OrgID: orgID, UserID: userID})
+	if errors.Is(err, sql.ErrNoRows) {
+		// a deleted user is a member of nothing
+		return ScopedAccess{}, nil
+	}
+	if err != nil {
+		return ScopedAccess{}, err
+	}
+	return ScopedAccess{
+		UserSuspended:      r.UserSuspended,
+		Member:             r.Role.Valid,
+		Role:               r.Role.String,
+		MembershipVersion:  r.Version.Int64,
+		OrgDeleted:         r.DeletedAt.Valid,
+		OrgSuspended:       r.SuspendedAt.Valid,
+		AllowImpersonation: r.AllowImpersonation.Bool,
+	}, nil
+}
+
+func (s *pgService) WebhookBacklog(orgLimit int) (WebhookBacklog, error) {
+	ctx := context.Background()
+	total, err := s.q.GetWebhookBacklog(ctx)
+	if err != nil {
+		return WebhookBacklog{}, err
+	}
+	rows, err := s.q.ListWebhookBacklogByOrg(ctx, int32(orgLimit))
+	if err != nil {
+		return WebhookBacklog{}, err
+	}
+	b := WebhookBacklog{
+		Pending:       total.Pending,
+		Failed:        total.Failed,
+		OldestPending: timePtr(total.OldestPending),
+		ByOrg:         make([]OrgBacklog, 0, len(rows)),
+	}
+	for _, r := range rows {
+		b.ByOrg = append(b.ByOrg, OrgBacklog{OrgID: r.OrgID, Pending: r.Pending, Failed: r.Failed, Oldest: r.Oldest})
+	}
+	return b, nil
+}
+
+// affected maps an update that matched no row to ErrNotFound.
+func affected(n int64, err error) error {
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,56 @@
+// Package platform manages platform admins: Vergo operators and support
+// staff whose access spans all orgs. It is separate from org roles, and
+// also backs the operator tooling (search, suspensions, webhook backlog).
+package platform
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+var ErrNotFound = errors.New("not found")
+
+type Service interface {
+	IsAdmin(userID string) (bool, error)
+	// GrantByEmail makes the user with email a platform admin. It reports
+	// false if no such user exists or they already are one.
+	GrantByEmail(email string) (bool, error)
+
+	ListOrgs(p ListParams) ([]OrgSummary, error)
+	ListUsers(p ListParams) ([]UserSummary, error)
+	OrgUsage(orgID string) (Usage, error)
+	SuspendOrg(orgID, reason string) error
+	UnsuspendOrg(orgID string) error
+	// SuspendUser also revokes the user's refresh tokens, ending every
+	// session; access tokens are refused by the Auth middleware.
+	SuspendUser(userID, reason string) error
+	UnsuspendUser(userID string) error
+	UserSuspended(userID string) (bool, error)
+	// ScopedAccess reads the user's suspension, their membership in the org
+	// and the org's state in one query.
+	ScopedAccess(userID, orgID string) (ScopedAccess, error)
+	// WebhookBacklog reports undelivered webhooks, with the orgLimit orgs
+	// holding most of them.
+	WebhookBacklog(orgLimit int) (WebhookBacklog, error)
+}
+
+type pgService struct {
+	db *sql.DB
+	q  *repo.Queries
+}
+
+func NewPostgresService(db *sql.DB, q *repo.Queries) Service {
+	return &pgService{db: db, q: q}
+}
+
+func (s *pgService) IsAdmin(userID string) (bool, error) {
+	return s.q.IsPlatformAdmin(context.Background(), userID)
+}
+
+func (s *pgService) GrantByEmail(email string) (bool, error) {
+	n, err := s.q.GrantPlatformAdminByEmail(context.Background(), email)
+	return n > 0, err
+}
@@ -0,0 +1,150 @@
+//go:build integration
+
+package platform_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ulpio/vergo/internal/auth"
+	"github.com/Ulpio/vergo/internal/domain/billing"
+	"github.com/Ulpio/vergo/internal/domain/org"
+	"github.com/Ulpio/vergo/internal/domain/platform"
+	"github.com/Ulpio/vergo/internal/domain/user"
+	"github.com/Ulpio/vergo/internal/pkg/testutil"
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+func TestPGService_Suspensions(t *testing.T) {
+	db := testutil.PGContainer(t)
+	q := repo.New(db)
+	users := user.NewPostgresService(db, q, nil)
+	orgs := org.NewPostgresService(db, q)
+	svc := platform.NewPostgresService(db, q)
+
+	u, err := users.Signup("ops-target@test.com", "pass123")
+	if err != nil {
+		t.Fatalf("Signup: %v", err)
+	}
+	o, err := orgs.Create("Suspended Corp", u.ID)
+	if err != nil {
+		t.Fatalf("Create org: %v", err)
+	}
+
+	// A live session is revoked by the suspension.
+	rs := auth.NewRefreshStore(db, q)
+	if err := rs.SaveRefresh(context.Background(), "jti-1", u.ID, "rt-1", time.Now().Add(time.Hour), auth.SessionInfo{}); err != nil {
+		t.Fatalf("SaveRefresh: %v", err)
+	}
+	if err := svc.SuspendUser(u.ID, "abuse report"); err != nil {
+		t.Fatalf("SuspendUser: %v", err)
+	}
+	if ok, _ := svc.UserSuspended(u.ID); !ok {
+		t.Error("user not suspended")
+	}
+	if sessions, _ := rs.ListSessions(context.Background(), u.ID); len(sessions) != 0 {
+		t.Errorf("sessions = %+v, want none after suspension", sessions)
+	}
+	if _, err := users.Login("ops-target@test.com", "pass123"); !errors.Is(err, user.ErrSuspended) {
+		t.Errorf("Login err = %v, want ErrSuspended", err)
+	}
+	if _, err := users.Login("ops-target@test.com", "wrong"); !errors.Is(err, user.ErrInvalidLogin) {
+		t.Errorf("Login with bad password err = %v, want ErrInvalidLogin", err)
+	}
+	if err := svc.UnsuspendUser(u.ID); err != nil {
+		t.Fatalf("UnsuspendUser: %v", err)
+	}
+	if _, err := users.Login("ops-target@test.com", "pass123"); err != nil {
+		t.Errorf("Login after unsuspend: %v", err)
+	}
+	if err := svc.SuspendUser("missing", "x"); !errors.Is(err, platform.ErrNotFound) {
+		t.Errorf("SuspendUser(missing) err = %v, want ErrNotFound", err)
+	}
+
+	if err := svc.SuspendOrg(o.ID, "chargeback"); err != nil {
+		t.Fatalf("SuspendOrg: %v", err)
+	}
+	got, _ := orgs.Get(o.ID)
+	if got.SuspendedAt == nil || got.SuspendedReason != "chargeback" {
+		t.Errorf("org = %+v, want suspended", got)
+	}
+	m, _ := orgs.GetMembership(o.ID, u.ID)
+	a, err := svc.ScopedAccess(u.ID, o.ID)
+	if err != nil || !a.Member || a.Role != "owner" || a.MembershipVersion != m.Version || !a.OrgSuspended || a.OrgDeleted || a.UserSuspended {
+		t.Errorf("ScopedAccess = %+v, %v", a, err)
+	}
+	if a, err := svc.ScopedAccess(u.ID, "missing"); err != nil || a.Member {
+		t.Errorf("ScopedAccess(other org) = %+v, %v, want no membership", a, err)
+	}
+	list, err := svc.ListOrgs(platform.ListParams{Query: "suspended corp", Limit: 10})
+	if err != nil || len(list) != 1 || list[0].SuspendedAt == nil || list[0].MemberCount != 1 {
+		t.Errorf("ListOrgs = %+v, %v", list, err)
+	}
+	if err := svc.UnsuspendOrg(o.ID); err != nil {
+		t.Fatalf("UnsuspendOrg: %v", err)
+	}
+	if got, _ := orgs.Get(o.ID); got.SuspendedAt != nil {
+		t.Error("org still suspended")
+	}
+}
+
+func TestPGService_SearchAndUsage(t *testing.T) {
+	db := testutil.PGContainer(t)
+	q := repo.New(db)
+	users := user.NewPostgresService(db, q, nil)
+	orgs := org.NewPostgresService(db, q)
+	svc := platform.NewPostgresService(db, q)
+	bill := billing.NewService(q, "")
+
+	a, _ := users.Signup("alice@search.test", "pass123")
+	if _, err := users.Signup("bob@search.test", "pass123"); err != nil {
+		t.Fatalf("Signup: %v", err)
+	}
+	found, err := svc.ListUsers(platform.ListParams{Query: "ALICE", Limit: 10})
+	if err != nil || len(found) != 1 || found[0].ID != a.ID {
+		t.Errorf("ListUsers(alice) = %+v, %v", found, err)
+	}
+	all, _ := svc.ListUsers(platform.ListParams{Limit: 10})
+	for _, u := range all {
+		if u.ID == user.DeletedUserID {
+			t.Error("placeholder user listed")
+		}
+	}
+
+	o, _ := orgs.Create("Usage Corp", a.ID)
+	usage, err := svc.OrgUsage(o.ID)
+	if err != nil || usage.Members != 1 || usage.Projects != 0 || usage.StorageBytes != 0 {
+		t.Errorf("OrgUsage = %+v, %v", usage, err)
+	}
+
+	// Plan overrides win over the (absent) subscription until cleared.
+	if err := bill.SetPlanOverride(o.ID, "gold", "x", a.ID); !errors.Is(err, billing.ErrUnknownPlan) {
+		t.Errorf("SetPlanOverride(gold) err = %v, want ErrUnknownPlan", err)
+	}
+	if err := bill.SetPlanOverride(o.ID, "enterprise", "design partner", a.ID); err != nil {
+		t.Fatalf("SetPlanOverride: %v", err)
+	}
+	sub, _ := bill.GetSubscription(o.ID)
+	if sub.Plan != "enterprise" || sub.Override == nil || sub.Status != "none" {
+		t.Errorf("subscription = %+v, want enterprise override", sub)
+	}
+	if list, _ := svc.ListOrgs(platform.ListParams{Query: o.ID, Limit: 10}); len(list) != 1 || list[0].Plan != "enterprise" {
+		t.Errorf("ListOrgs(id) = %+v", list)
+	}
+	if err := bill.ClearPlanOverride(o.ID); err != nil {
+		t.Fatalf("ClearPlanOverride: %v", err)
+	}
+	if err := bill.ClearPlanOverride(o.ID); !errors.Is(err, billing.ErrNoOverride) {
+		t.Errorf("second ClearPlanOverride err = %v, want ErrNoOverride", err)
+	}
+	if sub, _ := bill.GetSubscription(o.ID); sub.Plan != "free" || sub.Override != nil {
+		t.Errorf("subscription after clear = %+v", sub)
+	}
+
+	backlog, err := svc.WebhookBacklog(10)
+	if err != nil || backlog.Pending != 0 || backlog.OldestPending != nil || len(backlog.ByOrg) != 0 {
+		t.Errorf("WebhookBacklog = %+v, %v", backlog, err)
+	}
+}
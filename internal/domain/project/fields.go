@@ -0,0 +1,275 @@
+package project
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+const (
+	maxFields       = 50
+	maxOptions      = 100
+	maxTextValue    = 1000
+	maxLabelLength  = 100
+	maxOptionLength = 100
+)
+
+var fieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+
+func repoToField(r repo.ProjectField) Field {
+	return Field{
+		ID:        r.ID,
+		OrgID:     r.OrgID,
+		Key:       r.Key,
+		Label:     r.Label,
+		Type:      r.Type,
+		Options:   r.Options,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+	}
+}
+
+// validOptions checks the options of a field of type typ and returns them
+// trimmed.
+func validOptions(typ string, options []string) ([]string, error) {
+	if typ != FieldEnum {
+		if len(options) > 0 {
+			return nil, &ValidationError{Field: "options", Reason: "only enum fields have options"}
+		}
+		return []string{}, nil
+	}
+	if len(options) == 0 || len(options) > maxOptions {
+		return nil, &ValidationError{Field: "options", Reason: fmt.Sprintf("enum fields need 1-%d options", maxOptions)}
+	}
+	out := make([]string, len(options))
+	for i, o := range options {
+		o = strings.TrimSpace(o)
+		if o == "" || utf8.RuneCountInString(o) > maxOptionLength || slices.Contains(out[:i], o) {
+			return nil, &ValidationError{Field: "options", Reason: fmt.Sprintf("options must be unique and 1-%d characters", maxOptionLength)}
+		}
+		out[i] = o
+	}
+	return out, nil
+}
+
+func validLabel(label string) (string, error) {
+	label = strings.TrimSpace(label)
+	if label == "" || utf8.RuneCountInString(label) > maxLabelLength {
+		return "", &ValidationError{Field: "label", Reason: fmt.Sprintf("must be 1-%d characters", maxLabelLength)}
+	}
+	return label, nil
+}
+
+// normalizeValue checks v against the field and returns it in canonical
+// form: text and enum as strings, numbers as JSON numbers and dates as
+// YYYY-MM-DD.
+func (f Field) normalizeValue(v json.RawMessage) (json.RawMessage, error) {
+	invalid := func(reason string) error {
+		return &ValidationError{Field: "custom_fields." + f.Key, Reason: reason}
+	}
+	if f.Type == FieldNumber {
+		dec := json.NewDecoder(bytes.NewReader(v))
+		dec.UseNumber()
+		var n any
+		if err := dec.Decode(&n); err != nil {
+			return nil, invalid("must be a number")
+		}
+		num, ok := n.(json.Number)
+		if !ok {
+			return nil, invalid("must be a number")
+		}
+		if x, err := num.Float64(); err != nil || math.IsInf(x, 0) {
+			return nil, invalid("must be a number")
+		}
+		return json.RawMessage(num.String()), nil
+	}
+
+	var s string
+	if err := json.Unmarshal(v, &s); err != nil {
+		return nil, invalid("must be a string")
+	}
+	switch f.Type {
+	case FieldText:
+		if utf8.RuneCountInString(s) > maxTextValue {
+			return nil, invalid(fmt.Sprintf("at most %d characters", maxTextValue))
+		}
+	case FieldDate:
+		if _, err := time.Parse(time.DateOnly, s); err != nil {
+			return nil, invalid("must be a date as YYYY-MM-DD")
+		}
+	case FieldEnum:
+		if !slices.Contains(f.Options, s) {
+			return nil, invalid("must be one of " + strings.Join(f.Options, ", "))
+		}
+	}
+	return json.Marshal(s)
+}
+
+// checkFields validates values against the org's field definitions and
+// encodes them for storage.
+func checkFields(ctx context.Context, q *repo.Queries, orgID string, values map[string]json.RawMessage) (json.RawMessage, error) {
+	if len(values) == 0 {
+		return json.RawMessage(`{}`), nil
+	}
+	rows, err := q.ListProjectFields(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defs := make(map[string]Field, len(rows))
+	for _, r := range rows {
+		defs[r.Key] = repoToField(r)
+	}
+	out := make(map[string]json.RawMessage, len(values))
+	for k, v := range values {
+		f, ok := defs[k]
+		if !ok {
+			return nil, &ValidationError{Field: "custom_fields." + k, Reason: "no such field"}
+		}
+		if out[k], err = f.normalizeValue(v); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(out)
+}
+
+func (s *pgService) ListFields(orgID string) ([]Field, error) {
+	rows, err := s.q.ListProjectFields(context.Background(), orgID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Field, len(rows))
+	for i, r := range rows {
+		out[i] = repoToField(r)
+	}
+	return out, nil
+}
+
+func (s *pgService) CreateField(orgID string, f Field) (Field, error) {
+	ctx := context.Background()
+	if !fieldKeyPattern.MatchString(f.Key) {
+		return Field{}, &ValidationError{Field: "key", Reason: "must be 1-40 lowercase letters, digits or underscores, starting with a letter"}
+	}
+	switch f.Type {
+	case FieldText, FieldNumber, FieldDate, FieldEnum:
+	default:
+		return Field{}, &ValidationError{Field: "type", Reason: "must be text, number, date or enum"}
+	}
+	label, err := validLabel(f.Label)
+	if err != nil {
+		return Field{}, err
+	}
+	options, err := validOptions(f.Type, f.Options)
+	if err != nil {
+		return Field{}, err
+	}
+	existing, err := s.q.ListProjectFields(ctx, orgID)
+	if err != nil {
+		return Field{}, err
+	}
+	if len(existing) >= maxFields {
+		return Field{}, &ValidationError{Field: "key", Reason: fmt.Sprintf("an org has at most %d fields", maxFields)}
+	}
+
+	r, err := s.q.CreateProjectField(ctx, repo.CreateProjectFieldParams{
+		OrgID:   orgID,
+		Key:     f.Key,
+		Label:   label,
+		Type:    f.Type,
+		Options: options,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Field{}, ErrFieldKeyTaken
+	}
+	if err != nil {
+		return Field{}, err
+	}
+	return repoToField(r), nil
+}
+
+func (s *pgService) UpdateField(orgID, id string, in FieldUpdate) (Field, error) {
+	ctx := context.Background()
+	r, err := s.q.GetProjectField(ctx, repo.GetProjectFieldParams{ID: id, OrgID: orgID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Field{}, ErrFieldNotFound
+	}
+	if err != nil {
+		return Field{}, err
+	}
+	f := repoToField(r)
+	if in.Label != nil {
+		if f.Label, err = validLabel(*in.Label); err != nil {
+			return Field{}, err
+		}
+	}
+	if in.Options != nil {
+		options, err := validOptions(f.Type, in.Options)
+		if err != nil {
+			return Field{}, err
+		}
+		var removed []string
+		for _, o := range f.Options {
+			if !slices.Contains(options, o) {
+				removed = append(removed, o)
+			}
+		}
+		if len(removed) > 0 {
+			inUse, err := s.q.ProjectFieldValueInUse(ctx, repo.ProjectFieldValueInUseParams{
+				OrgID: orgID, Key: f.Key, FieldValues: removed,
+			})
+			if err != nil {
+				return Field{}, err
+			}
+			if inUse {
+				return Field{}, ErrOptionInUse
+			}
+		}
+		f.Options = options
+	}
+
+	r, err = s.q.UpdateProjectField(ctx, repo.UpdateProjectFieldParams{
+		ID:      id,
+		OrgID:   orgID,
+		Label:   f.Label,
+		Options: f.Options,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Field{}, ErrFieldNotFound
+	}
+	if err != nil {
+		return Field{}, err
+	}
+	return repoToField(r), nil
+}
+
+func (s *pgService) DeleteField(orgID, id string) error {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := s.q.WithTx(tx)
+
+	key, err := qtx.DeleteProjectField(ctx, repo.DeleteProjectFieldParams{ID: id, OrgID: orgID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrFieldNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := qtx.DeleteProjectFieldValues(ctx, repo.DeleteProjectFieldValuesParams{Key: key, OrgID: orgID}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -1,13 +1,106 @@
 package project
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Ulpio/vergo/internal/pkg/pagination"
+)
+
+// Project statuses; archived projects are read-only until unarchived.
+const (
+	StatusActive   = "active"
+	StatusArchived = "archived"
+)
 
 type Project struct {
-	ID          string    `json:"id"`
-	OrgID       string    `json:"org_id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description,omitempty"`
-	CreatedBy   string    `json:"created_by"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_by"`
+	ID           string                     `json:"id"`
+	OrgID        string                     `json:"org_id"`
+	Name         string                     `json:"name"`
+	Description  string                     `json:"description,omitempty"`
+	Status       string                     `json:"status"`
+	Tags         []string                   `json:"tags"`
+	CustomFields map[string]json.RawMessage `json:"custom_fields" swaggertype:"object"`
+	CreatedBy    string                     `json:"created_by"`
+	CreatedAt    time.Time                  `json:"created_at"`
+	UpdatedAt    time.Time                  `json:"updated_by"`
+	ArchivedAt   *time.Time                 `json:"archived_at,omitempty"`
+	Version      int64                      `json:"version"` // served as the ETag
+}
+
+// Sort orders of List; a leading '-' sorts descending. Ties are broken by
+// ID.
+var Sorts = []string{"created_at", "-created_at", "name", "-name", "updated_at", "-updated_at"}
+
+// ListParams filters and pages List. Empty fields do not filter.
+type ListParams struct {
+	Q         string // full-text search over name and description
+	Tag       string
+	CreatedBy string
+	// Archived selects archived (true) or active (false) projects; nil
+	// lists both.
+	Archived *bool
+	Sort     string // one of Sorts; created_at when empty
+	Limit    int
+	Cursor   pagination.Cursor
+}
+
+// Draft is a project to create.
+type Draft struct {
+	Name         string
+	Description  string
+	Tags         []string
+	CustomFields map[string]json.RawMessage
+}
+
+// Field types of custom fields.
+const (
+	FieldText   = "text"
+	FieldNumber = "number"
+	FieldDate   = "date" // YYYY-MM-DD
+	FieldEnum   = "enum" // one of Options
+)
+
+// Field is a custom field the org defines on its projects. Key and Type
+// are fixed once created.
+type Field struct {
+	ID        string    `json:"id"`
+	OrgID     string    `json:"org_id"`
+	Key       string    `json:"key"`
+	Label     string    `json:"label"`
+	Type      string    `json:"type"`
+	Options   []string  `json:"options,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// FieldUpdate changes a field definition; nil members stay as they are.
+type FieldUpdate struct {
+	Label   *string
+	Options []string
+}
+
+// Access levels a project grants, lowest first.
+const (
+	AccessViewer = "viewer"
+	AccessEditor = "editor"
+	AccessAdmin  = "admin"
+)
+
+// Actor is who is asking: a member, or an API key, which holds no grants.
+// AllProjects is set when their role reaches every project of the org.
+type Actor struct {
+	UserID      string
+	APIKey      bool
+	AllProjects bool
+}
+
+// Grant gives a user or a team (exactly one of them) access to a project.
+type Grant struct {
+	ID        string    `json:"id"`
+	ProjectID string    `json:"project_id"`
+	UserID    string    `json:"user_id,omitempty"`
+	TeamID    string    `json:"team_id,omitempty"`
+	Access    string    `json:"access"`
+	CreatedAt time.Time `json:"created_at"`
 }
@@ -0,0 +1,115 @@
+package project
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	maxTags      = 20
+	maxTagLength = 50
+)
+
+// ValidationError reports the project field that failed validation.
+type ValidationError struct {
+	Field  string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Field == "" {
+		return e.Reason
+	}
+	return fmt.Sprintf("%s: %s", e.Field, e.Reason)
+}
+
+// Patch is a JSON Merge Patch (RFC 7396) of a project: absent members stay
+// as they are and null clears them. custom_fields is merged key by key.
+type Patch struct {
+	Name        *string
+	Description *string   // "" clears
+	Tags        *[]string // empty clears
+	// CustomFields holds the keys to set; a nil value removes the key.
+	CustomFields map[string]json.RawMessage
+	// ClearCustomFields drops every value before CustomFields is applied.
+	ClearCustomFields bool
+}
+
+// ParsePatch decodes a merge patch. Unknown members are rejected, and so
+// is status: archiving has its own endpoints.
+func ParsePatch(raw []byte) (Patch, error) {
+	var (
+		p       Patch
+		members map[string]json.RawMessage
+	)
+	if err := json.Unmarshal(raw, &members); err != nil || members == nil {
+		return Patch{}, &ValidationError{Reason: "patch must be a JSON object"}
+	}
+	for k, v := range members {
+		switch k {
+		case "name":
+			var name string
+			if isNull(v) || json.Unmarshal(v, &name) != nil {
+				return Patch{}, &ValidationError{Field: k, Reason: "must be a string"}
+			}
+			p.Name = &name
+		case "description":
+			var desc string
+			if !isNull(v) && json.Unmarshal(v, &desc) != nil {
+				return Patch{}, &ValidationError{Field: k, Reason: "must be a string or null"}
+			}
+			p.Description = &desc
+		case "tags":
+			tags := []string{}
+			if !isNull(v) && json.Unmarshal(v, &tags) != nil {
+				return Patch{}, &ValidationError{Field: k, Reason: "must be an array of strings or null"}
+			}
+			p.Tags = &tags
+		case "custom_fields":
+			if isNull(v) {
+				p.ClearCustomFields = true
+				continue
+			}
+			var values map[string]json.RawMessage
+			if json.Unmarshal(v, &values) != nil || values == nil {
+				return Patch{}, &ValidationError{Field: k, Reason: "must be an object or null"}
+			}
+			for key, val := range values {
+				if isNull(val) {
+					values[key] = nil
+				}
+			}
+			p.CustomFields = values
+		default:
+			return Patch{}, &ValidationError{Field: k, Reason: "cannot be patched"}
+		}
+	}
+	return p, nil
+}
+
+func isNull(v json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(v), []byte("null"))
+}
+
+// normalizeTags trims and lowercases tags and drops duplicates, keeping
+// their order.
+func normalizeTags(tags []string) ([]string, error) {
+	out := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || len(t) > maxTagLength {
+			return nil, &ValidationError{Field: "tags", Reason: fmt.Sprintf("tags must be 1-%d characters", maxTagLength)}
+		}
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	if len(out) > maxTags {
+		return nil, &ValidationError{Field: "tags", Reason: fmt.Sprintf("at most %d tags", maxTags)}
+	}
+	return out, nil
+}
@@ -0,0 +1,102 @@
+package project
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParsePatch(t *testing.T) {
+	p, err := ParsePatch([]byte(`{
+		"name": "New",
+		"description": null,
+		"tags": null,
+		"custom_fields": {"stage": "beta", "budget": null}
+	}`))
+	if err != nil {
+		t.Fatalf("ParsePatch: %v", err)
+	}
+	if *p.Name != "New" || *p.Description != "" || p.Tags == nil || len(*p.Tags) != 0 {
+		t.Errorf("patch = %+v", p)
+	}
+	if v, ok := p.CustomFields["budget"]; !ok || v != nil {
+		t.Errorf("budget = %q, want a removal", v)
+	}
+	if string(p.CustomFields["stage"]) != `"beta"` || p.ClearCustomFields {
+		t.Errorf("custom fields = %+v", p)
+	}
+
+	if p, err := ParsePatch([]byte(`{"custom_fields": null}`)); err != nil || !p.ClearCustomFields || p.Name != nil {
+		t.Errorf("clear custom fields = %+v, %v", p, err)
+	}
+
+	cases := map[string]string{
+		`[]`:                        "",
+		`null`:                      "",
+		`{"name": null}`:            "name",
+		`{"tags": "a"}`:             "tags",
+		`{"custom_fields": [1]}`:    "custom_fields",
+		`{"status": "archived"}`:    "status",
+		`{"created_by": "someone"}`: "created_by",
+	}
+	for in, field := range cases {
+		_, err := ParsePatch([]byte(in))
+		var ve *ValidationError
+		if !errors.As(err, &ve) || ve.Field != field {
+			t.Errorf("ParsePatch(%s) = %v, want error on %q", in, err, field)
+		}
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := normalizeTags([]string{" Web ", "api", "WEB"})
+	if err != nil || len(tags) != 2 || tags[0] != "web" || tags[1] != "api" {
+		t.Errorf("normalizeTags = %v, %v", tags, err)
+	}
+	if _, err := normalizeTags([]string{" "}); err == nil {
+		t.Error("blank tag accepted")
+	}
+	many := make([]string, maxTags+1)
+	for i := range many {
+		many[i] = string(rune('a' + i))
+	}
+	if _, err := normalizeTags(many); err == nil {
+		t.Error("too many tags accepted")
+	}
+}
+
+func TestField_NormalizeValue(t *testing.T) {
+	fields := map[string]Field{
+		FieldText:   {Key: "notes", Type: FieldText},
+		FieldNumber: {Key: "budget", Type: FieldNumber},
+		FieldDate:   {Key: "due", Type: FieldDate},
+		FieldEnum:   {Key: "stage", Type: FieldEnum, Options: []string{"alpha", "beta"}},
+	}
+	valid := []struct{ typ, in, want string }{
+		{FieldText, `"hello"`, `"hello"`},
+		{FieldNumber, `12.50`, `12.50`},
+		{FieldNumber, `-3`, `-3`},
+		{FieldDate, `"2026-02-28"`, `"2026-02-28"`},
+		{FieldEnum, `"beta"`, `"beta"`},
+	}
+	for _, tc := range valid {
+		got, err := fields[tc.typ].normalizeValue(json.RawMessage(tc.in))
+		if err != nil || string(got) != tc.want {
+			t.Errorf("%s %s = %s, %v; want %s", tc.typ, tc.in, got, err, tc.want)
+		}
+	}
+	invalid := []struct{ typ, in string }{
+		{FieldText, `42`},
+		{FieldNumber, `"42"`},
+		{FieldDate, `"2026-02-30"`},
+		{FieldDate, `"28/02/2026"`},
+		{FieldEnum, `"gamma"`},
+	}
+	for _, tc := range invalid {
+		_, err := fields[tc.typ].normalizeValue(json.RawMessage(tc.in))
+		var ve *ValidationError
+		if !errors.As(err, &ve) || ve.Field != "custom_fields."+fields[tc.typ].Key {
+			t.Errorf("%s %s: err = %v, want ValidationError", tc.typ, tc.in, err)
+		}
+	}
+}
@@ -3,23 +3,81 @@ package project
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/Ulpio/vergo/internal/pkg/pagination"
+	"github.com/Ulpio/vergo/internal/pkg/precondition"
 	"github.com/Ulpio/vergo/internal/repo"
 )
 
 var (
-	ErrNotFound = errors.New("project not found")
+	ErrNotFound        = errors.New("project not found")
+	ErrForbidden       = errors.New("insufficient project access")
+	ErrInvalidAccess   = errors.New("access must be viewer, editor or admin")
+	ErrInvalidGrant    = errors.New("grant needs exactly one of user_id and team_id")
+	ErrGranteeNotFound = errors.New("user or team not found in the org")
+	ErrGrantNotFound   = errors.New("grant not found")
+	ErrArchived        = errors.New("project is archived")
+	ErrFieldNotFound   = errors.New("custom field not found")
+	ErrFieldKeyTaken   = errors.New("custom field key already in use")
+	ErrOptionInUse     = errors.New("enum option is in use by a project")
+	ErrInvalidSort     = errors.New("unknown sort order")
 )
 
+// accessLevel ranks the access levels; 0 is no access.
+var accessLevel = map[string]int32{AccessViewer: 1, AccessEditor: 2, AccessAdmin: 3}
+
+// Service filters projects by the actor's effective access: their own
+// grant, their teams' grants, or every project when AllProjects is set.
+// Projects the actor cannot see are ErrNotFound; seen but not enough
+// access is ErrForbidden.
 type Service interface {
-	List(orgID string) ([]Project, error)
-	Create(orgID, name, description, userID string) (Project, error)
-	Get(orgID, id string) (Project, error)
-	Update(orgID, id, name, description string) (Project, error)
-	Delete(orgID, id string) error
+	// List returns a page of the projects a can see. A cursor made for
+	// another sort order is pagination.ErrInvalidCursor.
+	List(orgID string, a Actor, p ListParams) (pagination.Page[Project], error)
+	// GrantedIDs returns the projects a reaches through grants, ignoring
+	// AllProjects.
+	GrantedIDs(orgID string, a Actor) ([]string, error)
+	// Create makes the creator an admin of the new project. Tags and
+	// custom field values are validated as in Update.
+	Create(orgID string, d Draft, a Actor) (Project, error)
+	Get(orgID, id string, a Actor) (Project, error)
+	// Update applies a merge patch (editor) to the project at ifVersion,
+	// or at any version with precondition.Any; otherwise it is
+	// precondition.ErrFailed. Archived projects are ErrArchived; invalid
+	// values are a *ValidationError.
+	Update(orgID, id string, a Actor, p Patch, ifVersion int64) (Project, error)
+	// Archive and Unarchive toggle the read-only archived state (admin).
+	Archive(orgID, id string, a Actor) (Project, error)
+	Unarchive(orgID, id string, a Actor) (Project, error)
+	// Delete soft-deletes the project (admin) at ifVersion, as Update; the
+	// purge worker removes it once the restore window has passed.
+	Delete(orgID, id string, a Actor, ifVersion int64) error
+	// Restore undoes a deletion made after deletedSince (admin). Projects
+	// not deleted, or deleted before that, are ErrNotFound.
+	Restore(orgID, id string, a Actor, deletedSince time.Time) (Project, error)
+	// Authorize checks that a holds at least min access on the project.
+	Authorize(orgID, id string, a Actor, min string) error
+
+	ListGrants(orgID, id string, a Actor) ([]Grant, error)
+	// SetGrant creates or changes the grant of g.UserID or g.TeamID (admin).
+	SetGrant(orgID, id string, a Actor, g Grant) (Grant, error)
+	RemoveGrant(orgID, id string, a Actor, grantID string) error // admin
+
+	// Custom field definitions of the org. Callers check org.manage.
+	ListFields(orgID string) ([]Field, error)
+	CreateField(orgID string, f Field) (Field, error)
+	// UpdateField changes the label or the enum options; options still used
+	// by a project cannot be removed (ErrOptionInUse).
+	UpdateField(orgID, id string, in FieldUpdate) (Field, error)
+	// DeleteField removes the field and its values from every project.
+	DeleteField(orgID, id string) error
 }
 
 type pgService struct {
@@ -32,65 +90,308 @@ func NewPostgresService(db *sql.DB, q *repo.Queries) Service {
 }
 
 func repoToProject(r repo.Project) Project {
-	return Project{
-		ID:          r.ID,
-		OrgID:       r.OrgID,
-		Name:        r.Name,
-		Description: r.Description.String,
-		CreatedBy:   r.CreatedBy,
-		CreatedAt:   r.CreatedAt,
-		UpdatedAt:   r.UpdatedAt,
+	p := Project{
+		ID:           r.ID,
+		OrgID:        r.OrgID,
+		Name:         r.Name,
+		Description:  r.Description.String,
+		Status:       StatusActive,
+		Tags:         r.Tags,
+		CustomFields: map[string]json.RawMessage{},
+		CreatedBy:    r.CreatedBy,
+		CreatedAt:    r.CreatedAt,
+		UpdatedAt:    r.UpdatedAt,
+		Version:      r.Version,
 	}
+	_ = json.Unmarshal(r.CustomFields, &p.CustomFields)
+	if r.ArchivedAt.Valid {
+		p.Status = StatusArchived
+		p.ArchivedAt = &r.ArchivedAt.Time
+	}
+	return p
 }
 
-func (s *pgService) List(orgID string) ([]Project, error) {
-	rows, err := s.q.ListProjects(context.Background(), orgID)
-	if err != nil {
-		return nil, err
+func repoToGrant(r repo.ProjectGrant) Grant {
+	return Grant{
+		ID:        r.ID,
+		ProjectID: r.ProjectID,
+		UserID:    r.UserID.String,
+		TeamID:    r.TeamID.String,
+		Access:    r.Access,
+		CreatedAt: r.CreatedAt,
 	}
-	out := make([]Project, len(rows))
-	for i, r := range rows {
-		out[i] = repoToProject(r)
+}
+
+func (s *pgService) List(orgID string, a Actor, p ListParams) (pagination.Page[Project], error) {
+	if p.Sort == "" {
+		p.Sort = "created_at"
 	}
-	return out, nil
+	if !slices.Contains(Sorts, p.Sort) {
+		return pagination.Page[Project]{}, ErrInvalidSort
+	}
+	if !p.Cursor.IsZero() && p.Cursor.Sort != p.Sort {
+		return pagination.Page[Project]{}, pagination.ErrInvalidCursor
+	}
+	if a.APIKey && !a.AllProjects {
+		return pagination.Page[Project]{Items: []Project{}}, nil
+	}
+	limit := pagination.Limit(p.Limit)
+	rows, err := s.listPage(orgID, a, p, limit)
+	if err != nil {
+		return pagination.Page[Project]{}, err
+	}
+	page := pagination.New(rows, limit, func(r repo.Project) pagination.Cursor {
+		c := pagination.Cursor{Sort: p.Sort, ID: r.ID}
+		switch strings.TrimPrefix(p.Sort, "-") {
+		case "name":
+			c.Key = r.Name
+		case "updated_at":
+			c.Time = r.UpdatedAt
+		default:
+			c.Time = r.CreatedAt
+		}
+		return c
+	})
+	return pagination.Map(page, repoToProject), nil
 }
 
-func (s *pgService) Create(orgID, name, description, userID string) (Project, error) {
-	id := uuid.NewString()
-	r, err := s.q.InsertProject(context.Background(), repo.InsertProjectParams{
-		ID:          id,
+// listPage runs the list query of p.Sort; each sort has its own query so
+// the keyset can use its index.
+func (s *pgService) listPage(orgID string, a Actor, p ListParams, limit int) ([]repo.Project, error) {
+	ctx := context.Background()
+	arg := repo.ListProjectsByCreatedAscParams{
 		OrgID:       orgID,
-		Name:        name,
-		Description: sql.NullString{String: description, Valid: description != ""},
-		CreatedBy:   userID,
+		AllProjects: a.AllProjects,
+		UserID:      sql.NullString{String: a.UserID, Valid: true},
+		Q:           strings.TrimSpace(p.Q),
+		Tag:         strings.ToLower(strings.TrimSpace(p.Tag)),
+		CreatedBy:   p.CreatedBy,
+		AfterID:     p.Cursor.ID,
+		AfterTime:   p.Cursor.Time,
+		QueryLimit:  int32(limit + 1),
+	}
+	if p.Archived != nil {
+		arg.Archived = sql.NullBool{Bool: *p.Archived, Valid: true}
+	}
+	byName := repo.ListProjectsByNameAscParams{
+		OrgID:       arg.OrgID,
+		AllProjects: arg.AllProjects,
+		UserID:      arg.UserID,
+		Q:           arg.Q,
+		Tag:         arg.Tag,
+		CreatedBy:   arg.CreatedBy,
+		Archived:    arg.Archived,
+		AfterID:     arg.AfterID,
+		AfterName:   p.Cursor.Key,
+		QueryLimit:  arg.QueryLimit,
+	}
+	switch p.Sort {
+	case "name":
+		return s.q.ListProjectsByNameAsc(ctx, byName)
+	case "-name":
+		return s.q.ListProjectsByNameDesc(ctx, repo.ListProjectsByNameDescParams(byName))
+	case "updated_at":
+		return s.q.ListProjectsByUpdatedAsc(ctx, repo.ListProjectsByUpdatedAscParams(arg))
+	case "-updated_at":
+		return s.q.ListProjectsByUpdatedDesc(ctx, repo.ListProjectsByUpdatedDescParams(arg))
+	case "-created_at":
+		return s.q.ListProjectsByCreatedDesc(ctx, repo.ListProjectsByCreatedDescParams(arg))
+	default:
+		return s.q.ListProjectsByCreatedAsc(ctx, arg)
+	}
+}
+
+func (s *pgService) GrantedIDs(orgID string, a Actor) ([]string, error) {
+	if a.APIKey {
+		return []string{}, nil
+	}
+	return s.q.ListGrantedProjectIDs(context.Background(), repo.ListGrantedProjectIDsParams{
+		OrgID:  orgID,
+		UserID: sql.NullString{String: a.UserID, Valid: true},
+	})
+}
+
+func (s *pgService) Create(orgID string, d Draft, a Actor) (Project, error) {
+	ctx := context.Background()
+	name := strings.TrimSpace(d.Name)
+	if name == "" {
+		return Project{}, &ValidationError{Field: "name", Reason: "is required"}
+	}
+	tags, err := normalizeTags(d.Tags)
+	if err != nil {
+		return Project{}, err
+	}
+	fields, err := checkFields(ctx, s.q, orgID, d.CustomFields)
+	if err != nil {
+		return Project{}, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Project{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := s.q.WithTx(tx)
+
+	r, err := qtx.InsertProject(ctx, repo.InsertProjectParams{
+		ID:           uuid.NewString(),
+		OrgID:        orgID,
+		Name:         name,
+		Description:  sql.NullString{String: d.Description, Valid: d.Description != ""},
+		CreatedBy:    a.UserID,
+		Tags:         tags,
+		CustomFields: fields,
 	})
 	if err != nil {
 		return Project{}, err
 	}
+	if !a.APIKey {
+		if _, err := qtx.UpsertProjectUserGrant(ctx, repo.UpsertProjectUserGrantParams{
+			ProjectID: r.ID,
+			UserID:    sql.NullString{String: a.UserID, Valid: true},
+			Access:    AccessAdmin,
+		}); err != nil {
+			return Project{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return Project{}, err
+	}
 	return repoToProject(r), nil
 }
 
-func (s *pgService) Get(orgID, id string) (Project, error) {
-	r, err := s.q.GetProject(context.Background(), repo.GetProjectParams{
+// authorize loads the project and checks a holds at least min on it.
+func (s *pgService) authorize(ctx context.Context, orgID, id string, a Actor, min string) (repo.Project, error) {
+	r, err := s.q.GetProject(ctx, repo.GetProjectParams{
 		ID:    id,
 		OrgID: orgID,
 	})
 	if errors.Is(err, sql.ErrNoRows) {
-		return Project{}, ErrNotFound
+		return repo.Project{}, ErrNotFound
+	}
+	if err != nil {
+		return repo.Project{}, err
 	}
+	level, err := s.level(ctx, id, a)
+	if err != nil {
+		return repo.Project{}, err
+	}
+	if level == 0 {
+		return repo.Project{}, ErrNotFound
+	}
+	if level < accessLevel[min] {
+		return repo.Project{}, ErrForbidden
+	}
+	return r, nil
+}
+
+// level is a's access level on the project, 0 for none.
+func (s *pgService) level(ctx context.Context, id string, a Actor) (int32, error) {
+	switch {
+	case a.AllProjects:
+		return accessLevel[AccessAdmin], nil
+	case a.APIKey:
+		return 0, nil
+	}
+	return s.q.GetProjectAccess(ctx, repo.GetProjectAccessParams{
+		ProjectID: id,
+		UserID:    sql.NullString{String: a.UserID, Valid: true},
+	})
+}
+
+func (s *pgService) Authorize(orgID, id string, a Actor, min string) error {
+	_, err := s.authorize(context.Background(), orgID, id, a, min)
+	return err
+}
+
+func (s *pgService) Get(orgID, id string, a Actor) (Project, error) {
+	r, err := s.authorize(context.Background(), orgID, id, a, AccessViewer)
 	if err != nil {
 		return Project{}, err
 	}
 	return repoToProject(r), nil
 }
 
-func (s *pgService) Update(orgID, id, name, description string) (Project, error) {
-	r, err := s.q.UpdateProject(context.Background(), repo.UpdateProjectParams{
-		ID:          id,
-		OrgID:       orgID,
-		Column3:     name,
-		Description: sql.NullString{String: description, Valid: description != ""},
+func (s *pgService) Update(orgID, id string, a Actor, p Patch, ifVersion int64) (Project, error) {
+	ctx := context.Background()
+	if _, err := s.authorize(ctx, orgID, id, a, AccessEditor); err != nil {
+		return Project{}, err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Project{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := s.q.WithTx(tx)
+
+	r, err := qtx.GetProjectForUpdate(ctx, repo.GetProjectForUpdateParams{ID: id, OrgID: orgID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Project{}, ErrNotFound
+	}
+	if err != nil {
+		return Project{}, err
+	}
+	if err := precondition.Check(ifVersion, r.Version); err != nil {
+		return Project{}, err
+	}
+	if r.ArchivedAt.Valid {
+		return Project{}, ErrArchived
+	}
+
+	cur := repoToProject(r)
+	if p.Name != nil {
+		if cur.Name = strings.TrimSpace(*p.Name); cur.Name == "" {
+			return Project{}, &ValidationError{Field: "name", Reason: "is required"}
+		}
+	}
+	if p.Description != nil {
+		cur.Description = *p.Description
+	}
+	if p.Tags != nil {
+		if cur.Tags, err = normalizeTags(*p.Tags); err != nil {
+			return Project{}, err
+		}
+	}
+	fields := r.CustomFields
+	if p.ClearCustomFields || p.CustomFields != nil {
+		if p.ClearCustomFields {
+			cur.CustomFields = map[string]json.RawMessage{}
+		}
+		for k, v := range p.CustomFields {
+			if v == nil {
+				delete(cur.CustomFields, k)
+			} else {
+				cur.CustomFields[k] = v
+			}
+		}
+		if fields, err = checkFields(ctx, qtx, orgID, cur.CustomFields); err != nil {
+			return Project{}, err
+		}
+	}
+
+	r, err = qtx.UpdateProject(ctx, repo.UpdateProjectParams{
+		ID:           id,
+		OrgID:        orgID,
+		Name:         cur.Name,
+		Description:  sql.NullString{String: cur.Description, Valid: cur.Description != ""},
+		Tags:         cur.Tags,
+		CustomFields: fields,
 	})
+	if err != nil {
+		return Project{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Project{}, err
+	}
+	return repoToProject(r), nil
+}
+
+func (s *pgService) Archive(orgID, id string, a Actor) (Project, error) {
+	ctx := context.Background()
+	if _, err := s.authorize(ctx, orgID, id, a, AccessAdmin); err != nil {
+		return Project{}, err
+	}
+	r, err := s.q.ArchiveProject(ctx, repo.ArchiveProjectParams{ID: id, OrgID: orgID})
 	if errors.Is(err, sql.ErrNoRows) {
 		return Project{}, ErrNotFound
 	}
@@ -100,17 +401,148 @@ func (s *pgService) Update(orgID, id, name, description string) (Project, error)
 	return repoToProject(r), nil
 }
 
-func (s *pgService) Delete(orgID, id string) error {
-	res, err := s.q.DeleteProject(context.Background(), repo.DeleteProjectParams{
-		ID:    id,
-		OrgID: orgID,
+func (s *pgService) Unarchive(orgID, id string, a Actor) (Project, error) {
+	ctx := context.Background()
+	if _, err := s.authorize(ctx, orgID, id, a, AccessAdmin); err != nil {
+		return Project{}, err
+	}
+	r, err := s.q.UnarchiveProject(ctx, repo.UnarchiveProjectParams{ID: id, OrgID: orgID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Project{}, ErrNotFound
+	}
+	if err != nil {
+		return Project{}, err
+	}
+	return repoToProject(r), nil
+}
+
+func (s *pgService) Delete(orgID, id string, a Actor, ifVersion int64) error {
+	ctx := context.Background()
+	r, err := s.authorize(ctx, orgID, id, a, AccessAdmin)
+	if err != nil {
+		return err
+	}
+	if err := precondition.Check(ifVersion, r.Version); err != nil {
+		return err
+	}
+	n, err := s.q.SoftDeleteProject(ctx, repo.SoftDeleteProjectParams{
+		ID:        id,
+		OrgID:     orgID,
+		IfVersion: ifVersion,
 	})
 	if err != nil {
 		return err
 	}
-	aff, _ := res.RowsAffected()
-	if aff == 0 {
+	if n == 0 {
+		// changed or deleted since authorize read it
+		if ifVersion != precondition.Any {
+			return precondition.ErrFailed
+		}
 		return ErrNotFound
 	}
 	return nil
 }
+
+func (s *pgService) Restore(orgID, id string, a Actor, deletedSince time.Time) (Project, error) {
+	ctx := context.Background()
+	level, err := s.level(ctx, id, a)
+	if err != nil {
+		return Project{}, err
+	}
+	if level == 0 {
+		return Project{}, ErrNotFound
+	}
+	if level < accessLevel[AccessAdmin] {
+		return Project{}, ErrForbidden
+	}
+	r, err := s.q.RestoreProject(ctx, repo.RestoreProjectParams{
+		ID:           id,
+		OrgID:        orgID,
+		DeletedSince: sql.NullTime{Time: deletedSince, Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Project{}, ErrNotFound
+	}
+	if err != nil {
+		return Project{}, err
+	}
+	return repoToProject(r), nil
+}
+
+func (s *pgService) ListGrants(orgID, id string, a Actor) ([]Grant, error) {
+	ctx := context.Background()
+	if _, err := s.authorize(ctx, orgID, id, a, AccessViewer); err != nil {
+		return nil, err
+	}
+	rows, err := s.q.ListProjectGrants(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Grant, len(rows))
+	for i, r := range rows {
+		out[i] = repoToGrant(r)
+	}
+	return out, nil
+}
+
+func (s *pgService) SetGrant(orgID, id string, a Actor, g Grant) (Grant, error) {
+	ctx := context.Background()
+	if accessLevel[g.Access] == 0 {
+		return Grant{}, ErrInvalidAccess
+	}
+	if (g.UserID == "") == (g.TeamID == "") {
+		return Grant{}, ErrInvalidGrant
+	}
+	if _, err := s.authorize(ctx, orgID, id, a, AccessAdmin); err != nil {
+		return Grant{}, err
+	}
+
+	var (
+		r   repo.ProjectGrant
+		err error
+	)
+	if g.UserID != "" {
+		if _, err := s.q.GetMemberRole(ctx, repo.GetMemberRoleParams{OrgID: orgID, UserID: g.UserID}); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return Grant{}, ErrGranteeNotFound
+			}
+			return Grant{}, err
+		}
+		r, err = s.q.UpsertProjectUserGrant(ctx, repo.UpsertProjectUserGrantParams{
+			ProjectID: id,
+			UserID:    sql.NullString{String: g.UserID, Valid: true},
+			Access:    g.Access,
+		})
+	} else {
+		if _, err := s.q.GetTeam(ctx, repo.GetTeamParams{ID: g.TeamID, OrgID: orgID}); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return Grant{}, ErrGranteeNotFound
+			}
+			return Grant{}, err
+		}
+		r, err = s.q.UpsertProjectTeamGrant(ctx, repo.UpsertProjectTeamGrantParams{
+			ProjectID: id,
+			TeamID:    sql.NullString{String: g.TeamID, Valid: true},
+			Access:    g.Access,
+		})
+	}
+	if err != nil {
+		return Grant{}, err
+	}
+	return repoToGrant(r), nil
+}
+
+func (s *pgService) RemoveGrant(orgID, id string, a Actor, grantID string) error {
+	ctx := context.Background()
+	if _, err := s.authorize(ctx, orgID, id, a, AccessAdmin); err != nil {
+		return err
+	}
+	n, err := s.q.DeleteProjectGrant(ctx, repo.DeleteProjectGrantParams{ID: grantID, ProjectID: id})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrGrantNotFound
+	}
+	return nil
+}
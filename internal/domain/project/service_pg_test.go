@@ -3,11 +3,18 @@
 package project_test
 
 import (
+	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Ulpio/vergo/internal/domain/org"
 	"github.com/Ulpio/vergo/internal/domain/project"
+	"github.com/Ulpio/vergo/internal/domain/team"
 	"github.com/Ulpio/vergo/internal/domain/user"
+	"github.com/Ulpio/vergo/internal/pkg/pagination"
+	"github.com/Ulpio/vergo/internal/pkg/precondition"
 	"github.com/Ulpio/vergo/internal/pkg/testutil"
 	"github.com/Ulpio/vergo/internal/repo"
 )
@@ -15,7 +22,7 @@ import (
 func setup(t *testing.T) (project.Service, string, string) {
 	t.Helper()
 	db := testutil.PGContainer(t)
-	userSvc := user.NewPostgresService(db, repo.New(db))
+	userSvc := user.NewPostgresService(db, repo.New(db), nil)
 	orgSvc := org.NewPostgresService(db, repo.New(db))
 
 	u, _ := userSvc.Signup("projuser@test.com", "pass")
@@ -24,10 +31,13 @@ func setup(t *testing.T) (project.Service, string, string) {
 	return project.NewPostgresService(db, repo.New(db)), o.ID, u.ID
 }
 
+func ptr[T any](v T) *T { return &v }
+
 func TestPGService_CreateProject(t *testing.T) {
 	svc, orgID, userID := setup(t)
+	a := project.Actor{UserID: userID}
 
-	p, err := svc.Create(orgID, "My Project", "A test project", userID)
+	p, err := svc.Create(orgID, project.Draft{Name: "My Project", Description: "A test project"}, a)
 	if err != nil {
 		t.Fatalf("Create: %v", err)
 	}
@@ -41,24 +51,123 @@ func TestPGService_CreateProject(t *testing.T) {
 
 func TestPGService_ListProjects(t *testing.T) {
 	svc, orgID, userID := setup(t)
+	a := project.Actor{UserID: userID}
 
-	svc.Create(orgID, "Proj A", "desc", userID)
-	svc.Create(orgID, "Proj B", "desc", userID)
+	svc.Create(orgID, project.Draft{Name: "Proj A", Description: "desc"}, a)
+	svc.Create(orgID, project.Draft{Name: "Proj B", Description: "desc"}, a)
 
-	list, err := svc.List(orgID)
+	page, err := svc.List(orgID, a, project.ListParams{})
 	if err != nil {
 		t.Fatalf("List: %v", err)
 	}
-	if len(list) != 2 {
-		t.Errorf("expected 2 projects, got %d", len(list))
+	if len(page.Items) != 2 || page.NextCursor != "" {
+		t.Errorf("expected 2 projects on one page, got %+v", page)
+	}
+}
+
+func TestPGService_ListProjectsFilters(t *testing.T) {
+	svc, orgID, userID := setup(t)
+	a := project.Actor{UserID: userID}
+
+	names := []string{"delta", "alpha", "charlie", "bravo", "echo"}
+	for i, n := range names {
+		d := project.Draft{Name: n, Description: "plain"}
+		if i%2 == 0 {
+			d.Tags = []string{"web"}
+		}
+		if n == "charlie" {
+			d.Description = "billing dashboard"
+		}
+		if _, err := svc.Create(orgID, d, a); err != nil {
+			t.Fatalf("Create %s: %v", n, err)
+		}
+	}
+
+	// walk every sort two at a time; each runs its own query
+	for _, sort := range project.Sorts {
+		var got []project.Project
+		params := project.ListParams{Sort: sort, Limit: 2}
+		for {
+			page, err := svc.List(orgID, a, params)
+			if err != nil {
+				t.Fatalf("List %s: %v", sort, err)
+			}
+			got = append(got, page.Items...)
+			if page.NextCursor == "" {
+				break
+			}
+			if params.Cursor, err = pagination.Decode(page.NextCursor); err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+		}
+		if len(got) != len(names) {
+			t.Fatalf("sort=%s walked %d projects, want every project once", sort, len(got))
+		}
+		for i := 1; i < len(got); i++ {
+			prev, cur := got[i-1], got[i]
+			var c int
+			switch strings.TrimPrefix(sort, "-") {
+			case "name":
+				c = strings.Compare(prev.Name, cur.Name)
+			case "updated_at":
+				c = prev.UpdatedAt.Compare(cur.UpdatedAt)
+			default:
+				c = prev.CreatedAt.Compare(cur.CreatedAt)
+			}
+			if strings.HasPrefix(sort, "-") {
+				c = -c
+			}
+			if c > 0 {
+				t.Errorf("sort=%s: %s before %s", sort, prev.Name, cur.Name)
+			}
+		}
+	}
+
+	count := func(p project.ListParams) int {
+		t.Helper()
+		page, err := svc.List(orgID, a, p)
+		if err != nil {
+			t.Fatalf("List %+v: %v", p, err)
+		}
+		return len(page.Items)
+	}
+	if n := count(project.ListParams{Tag: "WEB"}); n != 3 {
+		t.Errorf("tag=web: %d projects, want 3", n)
+	}
+	if n := count(project.ListParams{Q: "billing"}); n != 1 {
+		t.Errorf("q=billing: %d projects, want 1", n)
+	}
+	if n := count(project.ListParams{CreatedBy: "someone-else"}); n != 0 {
+		t.Errorf("created_by=someone-else: %d projects, want 0", n)
+	}
+
+	first, _ := svc.List(orgID, a, project.ListParams{Sort: "-updated_at", Limit: 1})
+	if _, err := svc.Archive(orgID, first.Items[0].ID, a); err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	archived, active := true, false
+	if n := count(project.ListParams{Archived: &archived}); n != 1 {
+		t.Errorf("archived: %d projects, want 1", n)
+	}
+	if n := count(project.ListParams{Archived: &active}); n != 4 {
+		t.Errorf("active: %d projects, want 4", n)
+	}
+
+	if _, err := svc.List(orgID, a, project.ListParams{Sort: "owner"}); !errors.Is(err, project.ErrInvalidSort) {
+		t.Errorf("sort=owner: err = %v, want ErrInvalidSort", err)
+	}
+	cur, _ := pagination.Decode(first.NextCursor)
+	if _, err := svc.List(orgID, a, project.ListParams{Sort: "name", Cursor: cur}); !errors.Is(err, pagination.ErrInvalidCursor) {
+		t.Errorf("cursor of another sort: err = %v, want ErrInvalidCursor", err)
 	}
 }
 
 func TestPGService_GetProject(t *testing.T) {
 	svc, orgID, userID := setup(t)
-	created, _ := svc.Create(orgID, "GetMe", "desc", userID)
+	a := project.Actor{UserID: userID}
+	created, _ := svc.Create(orgID, project.Draft{Name: "GetMe", Description: "desc"}, a)
 
-	found, err := svc.Get(orgID, created.ID)
+	found, err := svc.Get(orgID, created.ID, a)
 	if err != nil {
 		t.Fatalf("Get: %v", err)
 	}
@@ -69,9 +178,10 @@ func TestPGService_GetProject(t *testing.T) {
 
 func TestPGService_UpdateProject(t *testing.T) {
 	svc, orgID, userID := setup(t)
-	created, _ := svc.Create(orgID, "Old Name", "old desc", userID)
+	a := project.Actor{UserID: userID}
+	created, _ := svc.Create(orgID, project.Draft{Name: "Old Name", Description: "old desc"}, a)
 
-	updated, err := svc.Update(orgID, created.ID, "New Name", "new desc")
+	updated, err := svc.Update(orgID, created.ID, a, project.Patch{Name: ptr("New Name"), Description: ptr("new desc")}, created.Version)
 	if err != nil {
 		t.Fatalf("Update: %v", err)
 	}
@@ -81,17 +191,216 @@ func TestPGService_UpdateProject(t *testing.T) {
 	if updated.Description != "new desc" {
 		t.Errorf("desc = %q, want %q", updated.Description, "new desc")
 	}
+	if updated.Version != created.Version+1 {
+		t.Errorf("version = %d, want %d", updated.Version, created.Version+1)
+	}
+
+	// a second writer still holding the created version loses
+	if _, err := svc.Update(orgID, created.ID, a, project.Patch{Name: ptr("Lost")}, created.Version); !errors.Is(err, precondition.ErrFailed) {
+		t.Errorf("stale Update: err = %v, want precondition.ErrFailed", err)
+	}
+	if err := svc.Delete(orgID, created.ID, a, created.Version); !errors.Is(err, precondition.ErrFailed) {
+		t.Errorf("stale Delete: err = %v, want precondition.ErrFailed", err)
+	}
+	if got, _ := svc.Get(orgID, created.ID, a); got.Name != "New Name" {
+		t.Errorf("name after stale writes = %q", got.Name)
+	}
+}
+
+func TestPGService_ProjectMetadata(t *testing.T) {
+	svc, orgID, userID := setup(t)
+	a := project.Actor{UserID: userID}
+
+	if _, err := svc.CreateField(orgID, project.Field{Key: "stage", Label: "Stage", Type: project.FieldEnum, Options: []string{"alpha", "beta"}}); err != nil {
+		t.Fatalf("CreateField enum: %v", err)
+	}
+	budget, err := svc.CreateField(orgID, project.Field{Key: "budget", Label: "Budget", Type: project.FieldNumber})
+	if err != nil {
+		t.Fatalf("CreateField number: %v", err)
+	}
+	if _, err := svc.CreateField(orgID, project.Field{Key: "stage", Label: "Again", Type: project.FieldText}); !errors.Is(err, project.ErrFieldKeyTaken) {
+		t.Errorf("duplicate key: err = %v, want ErrFieldKeyTaken", err)
+	}
+
+	p, err := svc.Create(orgID, project.Draft{
+		Name: "Meta", Description: "desc", Tags: []string{"Web", "web", "api"},
+		CustomFields: map[string]json.RawMessage{"stage": json.RawMessage(`"alpha"`), "budget": json.RawMessage(`1500`)},
+	}, a)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if len(p.Tags) != 2 || p.Status != project.StatusActive || string(p.CustomFields["budget"]) != "1500" {
+		t.Errorf("created = %+v", p)
+	}
+	var ve *project.ValidationError
+	if _, err := svc.Create(orgID, project.Draft{Name: "Bad", CustomFields: map[string]json.RawMessage{"stage": json.RawMessage(`"gamma"`)}}, a); !errors.As(err, &ve) {
+		t.Errorf("invalid enum: err = %v, want ValidationError", err)
+	}
+
+	// null clears, absent members stay, custom_fields merges by key
+	patch, err := project.ParsePatch([]byte(`{"description": null, "custom_fields": {"budget": null, "stage": "beta"}}`))
+	if err != nil {
+		t.Fatalf("ParsePatch: %v", err)
+	}
+	p, err = svc.Update(orgID, p.ID, a, patch, precondition.Any)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if p.Name != "Meta" || p.Description != "" || len(p.Tags) != 2 {
+		t.Errorf("patched = %+v", p)
+	}
+	if _, ok := p.CustomFields["budget"]; ok || string(p.CustomFields["stage"]) != `"beta"` {
+		t.Errorf("custom_fields = %s", p.CustomFields)
+	}
+
+	// options in use stay; deleting a field drops its values
+	if _, err := svc.UpdateField(orgID, budget.ID, project.FieldUpdate{Options: []string{"x"}}); !errors.As(err, &ve) {
+		t.Errorf("options on number: err = %v, want ValidationError", err)
+	}
+	fields, _ := svc.ListFields(orgID)
+	stage := fields[1]
+	if _, err := svc.UpdateField(orgID, stage.ID, project.FieldUpdate{Options: []string{"alpha"}}); !errors.Is(err, project.ErrOptionInUse) {
+		t.Errorf("remove used option: err = %v, want ErrOptionInUse", err)
+	}
+	if err := svc.DeleteField(orgID, stage.ID); err != nil {
+		t.Fatalf("DeleteField: %v", err)
+	}
+	if p, _ = svc.Get(orgID, p.ID, a); len(p.CustomFields) != 0 {
+		t.Errorf("values after DeleteField = %s", p.CustomFields)
+	}
+
+	// archived projects are read-only
+	if p, err = svc.Archive(orgID, p.ID, a); err != nil || p.Status != project.StatusArchived || p.ArchivedAt == nil {
+		t.Fatalf("Archive = %+v, %v", p, err)
+	}
+	if _, err := svc.Update(orgID, p.ID, a, project.Patch{Name: ptr("Nope")}, precondition.Any); !errors.Is(err, project.ErrArchived) {
+		t.Errorf("Update archived: err = %v, want ErrArchived", err)
+	}
+	if p, err = svc.Unarchive(orgID, p.ID, a); err != nil || p.Status != project.StatusActive {
+		t.Fatalf("Unarchive = %+v, %v", p, err)
+	}
 }
 
 func TestPGService_DeleteProject(t *testing.T) {
 	svc, orgID, userID := setup(t)
-	created, _ := svc.Create(orgID, "DeleteMe", "desc", userID)
+	a := project.Actor{UserID: userID}
+	created, _ := svc.Create(orgID, project.Draft{Name: "DeleteMe", Description: "desc"}, a)
 
-	if err := svc.Delete(orgID, created.ID); err != nil {
+	if err := svc.Delete(orgID, created.ID, a, precondition.Any); err != nil {
 		t.Fatalf("Delete: %v", err)
 	}
-	_, err := svc.Get(orgID, created.ID)
+	_, err := svc.Get(orgID, created.ID, a)
 	if err == nil {
 		t.Error("expected error after delete")
 	}
+	if page, _ := svc.List(orgID, a, project.ListParams{}); len(page.Items) != 0 {
+		t.Errorf("deleted project still listed: %+v", page.Items)
+	}
+
+	// deleted before the window began
+	if _, err := svc.Restore(orgID, created.ID, a, time.Now().Add(time.Hour)); !errors.Is(err, project.ErrNotFound) {
+		t.Errorf("Restore past window = %v, want ErrNotFound", err)
+	}
+	restored, err := svc.Restore(orgID, created.ID, a, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if restored.Name != "DeleteMe" {
+		t.Errorf("restored name = %q", restored.Name)
+	}
+	if _, err := svc.Get(orgID, created.ID, a); err != nil {
+		t.Errorf("Get after restore: %v", err)
+	}
+}
+
+func TestPGService_ProjectAccess(t *testing.T) {
+	db := testutil.PGContainer(t)
+	q := repo.New(db)
+	userSvc := user.NewPostgresService(db, q, nil)
+	orgSvc := org.NewPostgresService(db, q)
+	teamSvc := team.NewPostgresService(q)
+	svc := project.NewPostgresService(db, q)
+
+	owner, _ := userSvc.Signup("grant-owner@test.com", "pass")
+	dev, _ := userSvc.Signup("grant-dev@test.com", "pass")
+	o, _ := orgSvc.Create("GrantOrg", owner.ID)
+	if err := orgSvc.AddMember(o.ID, dev.ID, "member"); err != nil {
+		t.Fatalf("AddMember: %v", err)
+	}
+	ownerA := project.Actor{UserID: owner.ID}
+	devA := project.Actor{UserID: dev.ID}
+
+	p, err := svc.Create(o.ID, project.Draft{Name: "Secret"}, ownerA)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// without a grant the project is invisible
+	if page, _ := svc.List(o.ID, devA, project.ListParams{}); len(page.Items) != 0 {
+		t.Errorf("dev sees %d projects, want 0", len(page.Items))
+	}
+	if _, err := svc.Get(o.ID, p.ID, devA); !errors.Is(err, project.ErrNotFound) {
+		t.Errorf("Get without grant: err = %v, want ErrNotFound", err)
+	}
+	if page, _ := svc.List(o.ID, project.Actor{UserID: dev.ID, AllProjects: true}, project.ListParams{}); len(page.Items) != 1 {
+		t.Errorf("all_projects sees %d projects, want 1", len(page.Items))
+	}
+
+	// a team grant gives the team's members access
+	tm, err := teamSvc.Create(o.ID, "devs", "")
+	if err != nil {
+		t.Fatalf("team Create: %v", err)
+	}
+	if err := teamSvc.AddMember(o.ID, tm.ID, dev.ID); err != nil {
+		t.Fatalf("team AddMember: %v", err)
+	}
+	if _, err := svc.SetGrant(o.ID, p.ID, devA, project.Grant{TeamID: tm.ID, Access: project.AccessEditor}); !errors.Is(err, project.ErrNotFound) {
+		t.Errorf("SetGrant by outsider: err = %v, want ErrNotFound", err)
+	}
+	if _, err := svc.SetGrant(o.ID, p.ID, ownerA, project.Grant{TeamID: tm.ID, Access: project.AccessViewer}); err != nil {
+		t.Fatalf("SetGrant: %v", err)
+	}
+	if _, err := svc.Get(o.ID, p.ID, devA); err != nil {
+		t.Errorf("Get as viewer: %v", err)
+	}
+	if _, err := svc.Update(o.ID, p.ID, devA, project.Patch{Name: ptr("Renamed")}, precondition.Any); !errors.Is(err, project.ErrForbidden) {
+		t.Errorf("Update as viewer: err = %v, want ErrForbidden", err)
+	}
+
+	// the stronger of user and team grants wins
+	g, err := svc.SetGrant(o.ID, p.ID, ownerA, project.Grant{UserID: dev.ID, Access: project.AccessEditor})
+	if err != nil {
+		t.Fatalf("SetGrant user: %v", err)
+	}
+	if _, err := svc.Update(o.ID, p.ID, devA, project.Patch{Name: ptr("Renamed")}, precondition.Any); err != nil {
+		t.Errorf("Update as editor: %v", err)
+	}
+	if err := svc.Delete(o.ID, p.ID, devA, precondition.Any); !errors.Is(err, project.ErrForbidden) {
+		t.Errorf("Delete as editor: err = %v, want ErrForbidden", err)
+	}
+
+	grants, _ := svc.ListGrants(o.ID, p.ID, devA)
+	if len(grants) != 3 { // owner, team, dev
+		t.Errorf("grants = %d, want 3", len(grants))
+	}
+	if err := svc.RemoveGrant(o.ID, p.ID, ownerA, g.ID); err != nil {
+		t.Fatalf("RemoveGrant: %v", err)
+	}
+
+	// leaving the team drops the inherited access
+	if err := teamSvc.RemoveMember(o.ID, tm.ID, dev.ID); err != nil {
+		t.Fatalf("team RemoveMember: %v", err)
+	}
+	if _, err := svc.Get(o.ID, p.ID, devA); !errors.Is(err, project.ErrNotFound) {
+		t.Errorf("Get after leaving team: err = %v, want ErrNotFound", err)
+	}
+
+	// grantees must belong to the org
+	stranger, _ := userSvc.Signup("grant-stranger@test.com", "pass")
+	if _, err := svc.SetGrant(o.ID, p.ID, ownerA, project.Grant{UserID: stranger.ID, Access: project.AccessViewer}); !errors.Is(err, project.ErrGranteeNotFound) {
+		t.Errorf("SetGrant to stranger: err = %v, want ErrGranteeNotFound", err)
+	}
+	if _, err := svc.SetGrant(o.ID, p.ID, ownerA, project.Grant{UserID: dev.ID, Access: "root"}); !errors.Is(err, project.ErrInvalidAccess) {
+		t.Errorf("SetGrant bad access: err = %v, want ErrInvalidAccess", err)
+	}
 }
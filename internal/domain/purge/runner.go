@@ -0,0 +1,147 @@
+// Package purge removes soft-deleted orgs and projects for good once
+// their restore window has passed.
+package purge
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Ulpio/vergo/internal/domain/export"
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+// batchSize caps the orgs and the projects one ProcessPending call purges.
+const batchSize = 10
+
+// Storage deletes the S3 objects of purged orgs and projects.
+type Storage interface {
+	DeleteObject(ctx context.Context, bucket, key string) error
+	DeletePrefix(ctx context.Context, bucket, prefix string) error
+}
+
+// Canceler ends an org's subscription with the payment provider.
+type Canceler interface {
+	CancelForOrg(orgID string) error
+}
+
+// Runner purges what was deleted more than window ago.
+type Runner struct {
+	db      *sql.DB
+	q       *repo.Queries
+	store   Storage
+	billing Canceler
+	window  time.Duration
+}
+
+func NewRunner(db *sql.DB, q *repo.Queries, store Storage, billing Canceler, window time.Duration) *Runner {
+	return &Runner{db: db, q: q, store: store, billing: billing, window: window}
+}
+
+// ProcessPending purges a batch of expired orgs, then of expired projects.
+// Failures are logged and retried on the next call; every step can run
+// twice.
+func (r *Runner) ProcessPending() {
+	ctx := context.Background()
+	cutoff := sql.NullTime{Time: time.Now().Add(-r.window), Valid: true}
+
+	orgs, err := r.q.ListPurgeableOrgs(ctx, repo.ListPurgeableOrgsParams{DeletedBefore: cutoff, QueryLimit: batchSize})
+	if err != nil {
+		slog.Error("purge: list orgs", "error", err)
+	}
+	for _, id := range orgs {
+		if err := r.purgeOrg(ctx, id, cutoff); err != nil {
+			slog.Error("purge: org failed", "org_id", id, "error", err)
+		}
+	}
+
+	projects, err := r.q.ListPurgeableProjects(ctx, repo.ListPurgeableProjectsParams{DeletedBefore: cutoff, QueryLimit: batchSize})
+	if err != nil {
+		slog.Error("purge: list projects", "error", err)
+	}
+	for _, p := range projects {
+		if err := r.purgeProject(ctx, p.ID, cutoff); err != nil {
+			slog.Error("purge: project failed", "project_id", p.ID, "org_id", p.OrgID, "error", err)
+		}
+	}
+}
+
+// purgeOrg cancels the subscription and deletes the org's objects before
+// its rows, so a failure leaves the org in place for the next attempt.
+func (r *Runner) purgeOrg(ctx context.Context, orgID string, cutoff sql.NullTime) error {
+	if r.billing != nil {
+		if err := r.billing.CancelForOrg(orgID); err != nil {
+			return fmt.Errorf("cancel subscription: %w", err)
+		}
+	}
+	objs, err := r.q.ListOrgFileObjects(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	for _, o := range objs {
+		if err := r.store.DeleteObject(ctx, o.Bucket, o.ObjectKey); err != nil {
+			return fmt.Errorf("delete object %s: %w", o.ObjectKey, err)
+		}
+	}
+	// uploads that were never registered as files
+	if err := r.store.DeletePrefix(ctx, "", "org/"+orgID+"/"); err != nil {
+		return fmt.Errorf("delete prefix: %w", err)
+	}
+	if err := r.store.DeletePrefix(ctx, "", export.ArchivePrefix(export.KindOrg, orgID)); err != nil {
+		return fmt.Errorf("delete exports: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := r.q.WithTx(tx)
+
+	// These tables reference the legacy orgs table, not organizations, so
+	// nothing cascades to them.
+	steps := []func() error{
+		func() error { return qtx.DeleteOrgWebhookEndpoints(ctx, orgID) },
+		func() error { return qtx.DeleteOrgAPIKeys(ctx, orgID) },
+		func() error { return qtx.DeleteOrgSubscription(ctx, orgID) },
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return err
+		}
+	}
+	n, err := qtx.PurgeOrg(ctx, repo.PurgeOrgParams{ID: orgID, DeletedBefore: cutoff})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return nil // restored meanwhile; the rollback keeps its rows
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	slog.Info("purge: org purged", "org_id", orgID, "objects", len(objs))
+	return nil
+}
+
+func (r *Runner) purgeProject(ctx context.Context, projectID string, cutoff sql.NullTime) error {
+	objs, err := r.q.ListProjectFileObjects(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	for _, o := range objs {
+		if err := r.store.DeleteObject(ctx, o.Bucket, o.ObjectKey); err != nil {
+			return fmt.Errorf("delete object %s: %w", o.ObjectKey, err)
+		}
+	}
+	n, err := r.q.PurgeProject(ctx, repo.PurgeProjectParams{ID: projectID, DeletedBefore: cutoff})
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		slog.Info("purge: project purged", "project_id", projectID, "objects", len(objs))
+	}
+	return nil
+}
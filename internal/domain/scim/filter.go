@@ -0,0 +1,402 @@
+package scim
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Filter is a parsed SCIM filter expression (RFC 7644 §3.4.2.2). It is
+// evaluated against the JSON representation of a resource, so the same
+// implementation serves Users and Groups.
+type Filter interface {
+	match(res map[string]any) bool
+}
+
+type andFilter struct{ l, r Filter }
+type orFilter struct{ l, r Filter }
+type notFilter struct{ f Filter }
+
+// valuePathFilter is attr[filter], e.g. emails[type eq "work"].
+type valuePathFilter struct {
+	path  string
+	inner Filter
+}
+
+type compareFilter struct {
+	path  string
+	op    string
+	value any // string | bool | float64 | nil
+}
+
+func (f andFilter) match(r map[string]any) bool { return f.l.match(r) && f.r.match(r) }
+func (f orFilter) match(r map[string]any) bool  { return f.l.match(r) || f.r.match(r) }
+func (f notFilter) match(r map[string]any) bool { return !f.f.match(r) }
+
+func (f valuePathFilter) match(r map[string]any) bool {
+	for _, v := range resolvePath(r, f.path) {
+		if m, ok := v.(map[string]any); ok && f.inner.match(m) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f compareFilter) match(r map[string]any) bool {
+	values := resolvePath(r, f.path)
+	if f.op == "pr" {
+		for _, v := range values {
+			if !isEmpty(v) {
+				return true
+			}
+		}
+		return false
+	}
+	for i, v := range values {
+		// Comparing a complex multi-valued attribute compares its "value".
+		if m, ok := v.(map[string]any); ok {
+			values[i] = lookupKey(m, "value")
+		}
+	}
+	if f.op == "ne" {
+		for _, v := range values {
+			if compare(v, "eq", f.value) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, v := range values {
+		if compare(v, f.op, f.value) {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmpty(v any) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case string:
+		return t == ""
+	case []any:
+		return len(t) == 0
+	case map[string]any:
+		return len(t) == 0
+	}
+	return false
+}
+
+func compare(actual any, op string, want any) bool {
+	switch w := want.(type) {
+	case nil:
+		return op == "eq" && actual == nil
+	case bool:
+		a, ok := actual.(bool)
+		if !ok {
+			return false
+		}
+		return op == "eq" && a == w
+	case float64:
+		a, ok := actual.(float64)
+		if !ok {
+			return false
+		}
+		switch op {
+		case "eq":
+			return a == w
+		case "gt":
+			return a > w
+		case "ge":
+			return a >= w
+		case "lt":
+			return a < w
+		case "le":
+			return a <= w
+		}
+		return false
+	case string:
+		a, ok := actual.(string)
+		if !ok {
+			return false
+		}
+		a, w = strings.ToLower(a), strings.ToLower(w)
+		switch op {
+		case "eq":
+			return a == w
+		case "co":
+			return strings.Contains(a, w)
+		case "sw":
+			return strings.HasPrefix(a, w)
+		case "ew":
+			return strings.HasSuffix(a, w)
+		case "gt":
+			return a > w
+		case "ge":
+			return a >= w
+		case "lt":
+			return a < w
+		case "le":
+			return a <= w
+		}
+	}
+	return false
+}
+
+// resolvePath returns the values addressed by an attribute path
+// ("userName", "name.givenName", "emails.value", or a schema-qualified
+// "urn:...:User:userName"). Multi-valued attributes are flattened.
+func resolvePath(res map[string]any, path string) []any {
+	cur := []any{res}
+	if strings.HasPrefix(strings.ToLower(path), "urn:") {
+		lp := strings.ToLower(path)
+		matched := false
+		for k, v := range res {
+			if strings.HasPrefix(k, "urn:") && strings.HasPrefix(lp, strings.ToLower(k)+":") {
+				cur = []any{v}
+				path = path[len(k)+1:]
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			path = path[strings.LastIndex(path, ":")+1:]
+		}
+	}
+	for _, seg := range strings.Split(path, ".") {
+		var next []any
+		for _, c := range cur {
+			m, ok := c.(map[string]any)
+			if !ok {
+				continue
+			}
+			switch v := lookupKey(m, seg).(type) {
+			case nil:
+			case []any:
+				next = append(next, v...)
+			default:
+				next = append(next, v)
+			}
+		}
+		cur = next
+	}
+	return cur
+}
+
+// lookupKey finds an attribute case-insensitively, as SCIM attribute names are.
+func lookupKey(m map[string]any, key string) any {
+	if v, ok := m[key]; ok {
+		return v
+	}
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return nil
+}
+
+// toDocument converts a resource into its generic JSON form for filtering.
+func toDocument(v any) map[string]any {
+	b, _ := json.Marshal(v)
+	var m map[string]any
+	_ = json.Unmarshal(b, &m)
+	return m
+}
+
+// ParseFilter parses a SCIM filter expression.
+func ParseFilter(s string) (Filter, error) {
+	toks, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{toks: toks}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.toks) {
+		return nil, errInvalidFilter("unexpected token " + strconv.Quote(p.toks[p.pos].text))
+	}
+	return f, nil
+}
+
+type token struct {
+	text   string
+	quoted bool
+}
+
+func tokenize(s string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')' || c == '[' || c == ']':
+			toks = append(toks, token{text: string(c)})
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) {
+				return nil, errInvalidFilter("unterminated string")
+			}
+			var str string
+			if err := json.Unmarshal([]byte(s[i:j+1]), &str); err != nil {
+				return nil, errInvalidFilter("invalid string literal")
+			}
+			toks = append(toks, token{text: str, quoted: true})
+			i = j + 1
+		default:
+			j := i
+			for j < len(s) && !unicode.IsSpace(rune(s[j])) && !strings.ContainsRune("()[]\"", rune(s[j])) {
+				j++
+			}
+			toks = append(toks, token{text: s[i:j]})
+			i = j
+		}
+	}
+	return toks, nil
+}
+
+type filterParser struct {
+	toks []token
+	pos  int
+}
+
+func (p *filterParser) peekKeyword(kw string) bool {
+	return p.pos < len(p.toks) && !p.toks[p.pos].quoted && strings.EqualFold(p.toks[p.pos].text, kw)
+}
+
+func (p *filterParser) expect(text string) error {
+	if p.pos >= len(p.toks) || p.toks[p.pos].quoted || p.toks[p.pos].text != text {
+		return errInvalidFilter("expected " + strconv.Quote(text))
+	}
+	p.pos++
+	return nil
+}
+
+func (p *filterParser) parseOr() (Filter, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("or") {
+		p.pos++
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l = orFilter{l, r}
+	}
+	return l, nil
+}
+
+func (p *filterParser) parseAnd() (Filter, error) {
+	l, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("and") {
+		p.pos++
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l = andFilter{l, r}
+	}
+	return l, nil
+}
+
+func (p *filterParser) parseUnary() (Filter, error) {
+	if p.peekKeyword("not") {
+		p.pos++
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return notFilter{f}, nil
+	}
+	if p.peekKeyword("(") {
+		p.pos++
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+	return p.parseAttrExpr()
+}
+
+var compareOps = map[string]bool{"eq": true, "ne": true, "co": true, "sw": true, "ew": true, "gt": true, "ge": true, "lt": true, "le": true}
+
+func (p *filterParser) parseAttrExpr() (Filter, error) {
+	if p.pos >= len(p.toks) || p.toks[p.pos].quoted {
+		return nil, errInvalidFilter("expected attribute path")
+	}
+	path := p.toks[p.pos].text
+	p.pos++
+
+	if p.peekKeyword("[") {
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return valuePathFilter{path: path, inner: inner}, nil
+	}
+
+	if p.pos >= len(p.toks) {
+		return nil, errInvalidFilter("expected operator after " + path)
+	}
+	op := strings.ToLower(p.toks[p.pos].text)
+	p.pos++
+	if op == "pr" {
+		return compareFilter{path: path, op: op}, nil
+	}
+	if !compareOps[op] {
+		return nil, errInvalidFilter("unsupported operator " + strconv.Quote(op))
+	}
+	if p.pos >= len(p.toks) {
+		return nil, errInvalidFilter("expected value after " + op)
+	}
+	t := p.toks[p.pos]
+	p.pos++
+
+	var value any
+	switch {
+	case t.quoted:
+		value = t.text
+	case t.text == "true" || t.text == "false":
+		value = t.text == "true"
+	case t.text == "null":
+		value = nil
+	default:
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, errInvalidFilter("invalid value " + strconv.Quote(t.text))
+		}
+		value = n
+	}
+	return compareFilter{path: path, op: op, value: value}, nil
+}
@@ -0,0 +1,105 @@
+package scim
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func testUser() map[string]any {
+	active := true
+	return toDocument(User{
+		Schemas:     []string{SchemaUser},
+		ID:          "u1",
+		UserName:    "Alice@Example.com",
+		ExternalID:  "00u1",
+		DisplayName: "Alice Doe",
+		Name:        &Name{GivenName: "Alice", FamilyName: "Doe"},
+		Emails:      []MultiValue{{Value: "alice@example.com", Type: "work", Primary: true}},
+		Active:      &active,
+		Groups:      []MultiValue{{Value: "g1", Display: "Engineering"}},
+	})
+}
+
+func TestParseFilter_Matches(t *testing.T) {
+	doc := testUser()
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{`userName eq "alice@example.com"`, true},
+		{`userName eq "bob@example.com"`, false},
+		{`userName ne "bob@example.com"`, true},
+		{`externalId eq "00u1"`, true},
+		{`name.familyName co "oe"`, true},
+		{`displayName sw "Ali"`, true},
+		{`displayName ew "Smith"`, false},
+		{`active eq true`, true},
+		{`active eq false`, false},
+		{`title pr`, false},
+		{`emails pr`, true},
+		{`emails.value eq "alice@example.com"`, true},
+		{`emails[type eq "work" and value co "alice"]`, true},
+		{`emails[type eq "home"]`, false},
+		{`groups eq "g1"`, true},
+		{`urn:ietf:params:scim:schemas:core:2.0:User:userName eq "alice@example.com"`, true},
+		{`userName eq "x" or externalId eq "00u1"`, true},
+		{`userName eq "x" and externalId eq "00u1"`, false},
+		{`not (userName eq "x")`, true},
+		{`(userName eq "x" or displayName pr) and active eq true`, true},
+		{`USERNAME EQ "ALICE@EXAMPLE.COM"`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			f, err := ParseFilter(tt.filter)
+			if err != nil {
+				t.Fatalf("ParseFilter: %v", err)
+			}
+			if got := f.match(doc); got != tt.want {
+				t.Errorf("match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilter_Invalid(t *testing.T) {
+	for _, s := range []string{
+		``,
+		`userName`,
+		`userName eq`,
+		`userName xx "a"`,
+		`userName eq "unterminated`,
+		`(userName eq "a"`,
+		`emails[type eq "work"`,
+		`userName eq "a" extra`,
+		`userName eq bogus`,
+	} {
+		_, err := ParseFilter(s)
+		var se *Error
+		if !errors.As(err, &se) || se.Status != http.StatusBadRequest || se.ScimType != "invalidFilter" {
+			t.Errorf("ParseFilter(%q) err = %v, want invalidFilter", s, err)
+		}
+	}
+}
+
+func TestPage(t *testing.T) {
+	all := []any{1, 2, 3, 4, 5}
+
+	res := page(all, ListQuery{StartIndex: 2, Count: 2})
+	if res.TotalResults != 5 || res.StartIndex != 2 || res.ItemsPerPage != 2 {
+		t.Fatalf("page = %+v", res)
+	}
+	if got := res.Resources.([]any); got[0] != 2 || got[1] != 3 {
+		t.Errorf("resources = %v, want [2 3]", got)
+	}
+
+	res = page(all, ListQuery{StartIndex: 10, Count: 2})
+	if res.ItemsPerPage != 0 || res.TotalResults != 5 {
+		t.Errorf("past end: %+v", res)
+	}
+
+	res = page(all, ListQuery{StartIndex: 0, Count: 0})
+	if res.StartIndex != 1 || res.ItemsPerPage != 0 {
+		t.Errorf("count=0 should only report totals: %+v", res)
+	}
+}
@@ -0,0 +1,158 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	SchemaUser        = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup       = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaVergoGroup  = "urn:ietf:params:scim:schemas:extension:vergo:2.0:Group"
+	SchemaEnterprise  = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+	SchemaListResp    = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp     = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError       = "urn:ietf:params:scim:api:messages:2.0:Error"
+	SchemaSPConfig    = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaResourceTyp = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+
+	// MaxPageSize caps the count parameter of list requests.
+	MaxPageSize = 200
+)
+
+// Token is an org-scoped bearer credential for a SCIM client.
+type Token struct {
+	ID          string     `json:"id"`
+	OrgID       string     `json:"org_id"`
+	Name        string     `json:"name"`
+	TokenPrefix string     `json:"token_prefix"`
+	CreatedBy   string     `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+}
+
+type TokenResult struct {
+	Token
+	PlaintextToken string `json:"token"`
+}
+
+// TokenLookup is a valid token; the org flags let SCIMAuth refuse
+// provisioning into a deleted or suspended org.
+type TokenLookup struct {
+	TokenID      string
+	OrgID        string
+	OrgDeleted   bool
+	OrgSuspended bool
+}
+
+type Name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// MultiValue is the generic SCIM multi-valued attribute element.
+type MultiValue struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+type Meta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location,omitempty"`
+}
+
+type User struct {
+	Schemas     []string     `json:"schemas"`
+	ID          string       `json:"id,omitempty"`
+	ExternalID  string       `json:"externalId,omitempty"`
+	UserName    string       `json:"userName"`
+	Name        *Name        `json:"name,omitempty"`
+	DisplayName string       `json:"displayName,omitempty"`
+	Emails      []MultiValue `json:"emails,omitempty"`
+	Active      *bool        `json:"active,omitempty"`
+	Roles       []MultiValue `json:"roles,omitempty"`
+	Groups      []MultiValue `json:"groups,omitempty"`
+	Meta        *Meta        `json:"meta,omitempty"`
+}
+
+// GroupExtension carries the Vergo-specific role granted by a group.
+type GroupExtension struct {
+	Role string `json:"role,omitempty"`
+}
+
+type Group struct {
+	Schemas     []string        `json:"schemas"`
+	ID          string          `json:"id,omitempty"`
+	ExternalID  string          `json:"externalId,omitempty"`
+	DisplayName string          `json:"displayName"`
+	Members     []MultiValue    `json:"members"`
+	Vergo       *GroupExtension `json:"urn:ietf:params:scim:schemas:extension:vergo:2.0:Group,omitempty"`
+	Meta        *Meta           `json:"meta,omitempty"`
+}
+
+type ListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    any      `json:"Resources"`
+}
+
+// ListQuery holds the standard list parameters (RFC 7644 §3.4.2).
+type ListQuery struct {
+	Filter     string
+	StartIndex int // 1-based
+	Count      int
+}
+
+type PatchRequest struct {
+	Schemas    []string  `json:"schemas"`
+	Operations []PatchOp `json:"Operations"`
+}
+
+type PatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Error is a SCIM protocol error (RFC 7644 §3.12).
+type Error struct {
+	Status   int    `json:"-"`
+	ScimType string `json:"scimType,omitempty"`
+	Detail   string `json:"detail"`
+}
+
+func (e *Error) Error() string { return fmt.Sprintf("scim: %d %s: %s", e.Status, e.ScimType, e.Detail) }
+
+func errNotFound(what, id string) *Error {
+	return &Error{Status: http.StatusNotFound, Detail: fmt.Sprintf("%s %s not found", what, id)}
+}
+
+func errUniqueness(detail string) *Error {
+	return &Error{Status: http.StatusConflict, ScimType: "uniqueness", Detail: detail}
+}
+
+func errInvalidValue(detail string) *Error {
+	return &Error{Status: http.StatusBadRequest, ScimType: "invalidValue", Detail: detail}
+}
+
+func errInvalidFilter(detail string) *Error {
+	return &Error{Status: http.StatusBadRequest, ScimType: "invalidFilter", Detail: detail}
+}
+
+func errInvalidPath(detail string) *Error {
+	return &Error{Status: http.StatusBadRequest, ScimType: "invalidPath", Detail: detail}
+}
+
+func errMutability(detail string) *Error {
+	return &Error{Status: http.StatusBadRequest, ScimType: "mutability", Detail: detail}
+}
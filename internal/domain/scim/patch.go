@@ -0,0 +1,405 @@
+package scim
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// userState is the mutable part of a SCIM User as stored by Vergo. Emails
+// are a read-only reflection of the account email and are never changed
+// through SCIM, so one org's IdP cannot take over an account shared with
+// other orgs.
+type userState struct {
+	UserName    string
+	ExternalID  string
+	DisplayName string
+	GivenName   string
+	FamilyName  string
+	Active      bool
+	Role        string
+}
+
+type groupState struct {
+	DisplayName string
+	ExternalID  string
+	Role        string
+	Members     []string // user IDs, insertion ordered
+}
+
+// patchPath is a parsed PATCH "path": attr[filter].sub
+type patchPath struct {
+	attr   string // lower-cased, schema prefix removed
+	filter Filter
+	sub    string // lower-cased
+	ext    string // schema URN for extension attributes
+}
+
+func parsePatchPath(path string) (patchPath, error) {
+	var pp patchPath
+	lp := strings.ToLower(path)
+	for _, urn := range []string{SchemaUser, SchemaGroup, SchemaEnterprise, SchemaVergoGroup} {
+		if strings.HasPrefix(lp, strings.ToLower(urn)+":") {
+			if urn == SchemaEnterprise || urn == SchemaVergoGroup {
+				pp.ext = urn
+			}
+			path = path[len(urn)+1:]
+			break
+		}
+	}
+
+	if i := strings.IndexByte(path, '['); i >= 0 {
+		j := strings.LastIndexByte(path, ']')
+		if j < i {
+			return pp, errInvalidPath("unbalanced brackets in path")
+		}
+		f, err := ParseFilter(path[i+1 : j])
+		if err != nil {
+			return pp, err
+		}
+		pp.filter = f
+		pp.attr = strings.ToLower(path[:i])
+		pp.sub = strings.ToLower(strings.TrimPrefix(path[j+1:], "."))
+		return pp, nil
+	}
+	attr, sub, _ := strings.Cut(path, ".")
+	pp.attr, pp.sub = strings.ToLower(attr), strings.ToLower(sub)
+	return pp, nil
+}
+
+func normalizeOp(op string) (string, error) {
+	switch o := strings.ToLower(op); o {
+	case "add", "replace", "remove":
+		return o, nil
+	}
+	return "", errInvalidValue("unsupported patch op " + op)
+}
+
+// objectOps expands a path-less add/replace into one op per attribute.
+func objectOps(op string, raw json.RawMessage) ([]PatchOp, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, errInvalidValue("value must be an object when path is omitted")
+	}
+	ops := make([]PatchOp, 0, len(obj))
+	for k, v := range obj {
+		if strings.EqualFold(k, "schemas") {
+			continue
+		}
+		ops = append(ops, PatchOp{Op: op, Path: k, Value: v})
+	}
+	return ops, nil
+}
+
+func applyUserPatch(st *userState, req PatchRequest) error {
+	for _, op := range req.Operations {
+		kind, err := normalizeOp(op.Op)
+		if err != nil {
+			return err
+		}
+		if op.Path == "" {
+			if kind == "remove" {
+				return errInvalidPath("remove requires a path")
+			}
+			sub, err := objectOps(kind, op.Value)
+			if err != nil {
+				return err
+			}
+			if err := applyUserPatch(st, PatchRequest{Operations: sub}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := applyUserOp(st, kind, op.Path, op.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyUserOp(st *userState, kind, path string, raw json.RawMessage) error {
+	pp, err := parsePatchPath(path)
+	if err != nil {
+		return err
+	}
+	if pp.ext == SchemaEnterprise {
+		return nil // enterprise attributes are accepted and ignored
+	}
+	if kind == "remove" {
+		switch pp.attr {
+		case "externalid":
+			st.ExternalID = ""
+		case "displayname":
+			st.DisplayName = ""
+		case "name":
+			switch pp.sub {
+			case "givenname":
+				st.GivenName = ""
+			case "familyname":
+				st.FamilyName = ""
+			case "":
+				st.GivenName, st.FamilyName = "", ""
+			}
+		case "roles":
+			st.Role = "member"
+		case "emails", "phonenumbers", "addresses", "title", "nickname", "locale", "timezone", "preferredlanguage", "usertype", "profileurl":
+		default:
+			return errMutability("attribute " + path + " cannot be removed")
+		}
+		return nil
+	}
+
+	switch pp.attr {
+	case "username":
+		return setString(raw, &st.UserName)
+	case "externalid":
+		return setString(raw, &st.ExternalID)
+	case "displayname":
+		return setString(raw, &st.DisplayName)
+	case "active":
+		b, err := valueBool(raw)
+		if err != nil {
+			return err
+		}
+		st.Active = b
+	case "name":
+		switch pp.sub {
+		case "givenname":
+			return setString(raw, &st.GivenName)
+		case "familyname":
+			return setString(raw, &st.FamilyName)
+		case "formatted":
+		case "":
+			var n Name
+			if err := json.Unmarshal(raw, &n); err != nil {
+				return errInvalidValue("name must be an object")
+			}
+			st.GivenName, st.FamilyName = n.GivenName, n.FamilyName
+		}
+	case "roles":
+		role, err := roleFromValue(raw, pp.sub != "")
+		if err != nil {
+			return err
+		}
+		st.Role = role
+	default:
+		// Unknown or read-only attributes (emails, phoneNumbers, ...) are
+		// ignored so IdPs that send their full profile keep working.
+	}
+	return nil
+}
+
+func applyGroupPatch(st *groupState, req PatchRequest) error {
+	for _, op := range req.Operations {
+		kind, err := normalizeOp(op.Op)
+		if err != nil {
+			return err
+		}
+		if op.Path == "" {
+			if kind == "remove" {
+				return errInvalidPath("remove requires a path")
+			}
+			sub, err := objectOps(kind, op.Value)
+			if err != nil {
+				return err
+			}
+			if err := applyGroupPatch(st, PatchRequest{Operations: sub}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := applyGroupOp(st, kind, op.Path, op.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyGroupOp(st *groupState, kind, path string, raw json.RawMessage) error {
+	// The extension object itself ({"urn:...:vergo:2.0:Group": {"role": "admin"}}).
+	if strings.EqualFold(path, SchemaVergoGroup) {
+		if kind == "remove" {
+			st.Role = "member"
+			return nil
+		}
+		var ext GroupExtension
+		if err := json.Unmarshal(raw, &ext); err != nil {
+			return errInvalidValue("invalid extension value")
+		}
+		return setRole(&st.Role, ext.Role)
+	}
+
+	pp, err := parsePatchPath(path)
+	if err != nil {
+		return err
+	}
+	if pp.ext == SchemaVergoGroup && pp.attr == "role" {
+		if kind == "remove" {
+			st.Role = "member"
+			return nil
+		}
+		var role string
+		if err := setString(raw, &role); err != nil {
+			return err
+		}
+		return setRole(&st.Role, role)
+	}
+
+	switch pp.attr {
+	case "displayname":
+		if kind == "remove" {
+			return errMutability("displayName is required")
+		}
+		return setString(raw, &st.DisplayName)
+	case "externalid":
+		if kind == "remove" {
+			st.ExternalID = ""
+			return nil
+		}
+		return setString(raw, &st.ExternalID)
+	case "members":
+		return applyMembersOp(st, kind, pp, raw)
+	}
+	return errInvalidPath("unsupported attribute " + path)
+}
+
+func applyMembersOp(st *groupState, kind string, pp patchPath, raw json.RawMessage) error {
+	if pp.filter != nil {
+		if kind != "remove" {
+			return errInvalidPath("filtered member paths are only supported for remove")
+		}
+		kept := st.Members[:0]
+		for _, id := range st.Members {
+			if !pp.filter.match(map[string]any{"value": id}) {
+				kept = append(kept, id)
+			}
+		}
+		st.Members = kept
+		return nil
+	}
+
+	var values []MultiValue
+	if len(raw) > 0 && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &values); err != nil {
+			var single MultiValue
+			if err := json.Unmarshal(raw, &single); err != nil {
+				return errInvalidValue("members must be an array of {value}")
+			}
+			values = []MultiValue{single}
+		}
+	}
+
+	switch kind {
+	case "add":
+		for _, v := range values {
+			st.Members = appendUnique(st.Members, v.Value)
+		}
+	case "replace":
+		st.Members = nil
+		for _, v := range values {
+			st.Members = appendUnique(st.Members, v.Value)
+		}
+	case "remove":
+		if len(values) == 0 {
+			st.Members = nil
+			return nil
+		}
+		drop := map[string]bool{}
+		for _, v := range values {
+			drop[v.Value] = true
+		}
+		kept := st.Members[:0]
+		for _, id := range st.Members {
+			if !drop[id] {
+				kept = append(kept, id)
+			}
+		}
+		st.Members = kept
+	}
+	return nil
+}
+
+func appendUnique(list []string, v string) []string {
+	if v == "" {
+		return list
+	}
+	for _, x := range list {
+		if x == v {
+			return list
+		}
+	}
+	return append(list, v)
+}
+
+func setString(raw json.RawMessage, dst *string) error {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return errInvalidValue("expected a string value")
+	}
+	*dst = s
+	return nil
+}
+
+// valueBool accepts JSON booleans and the "True"/"False" strings some IdPs send.
+func valueBool(raw json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(raw, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		switch strings.ToLower(s) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return false, errInvalidValue("expected a boolean value")
+}
+
+// roleFromValue reads a roles value: either a plain string (roles.value) or
+// an array of {value, primary}.
+func roleFromValue(raw json.RawMessage, scalar bool) (string, error) {
+	if scalar {
+		var s string
+		if err := setString(raw, &s); err != nil {
+			return "", err
+		}
+		role := "member"
+		return role, setRole(&role, s)
+	}
+	var values []MultiValue
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return "", errInvalidValue("roles must be an array of {value}")
+	}
+	return roleFromMulti(values)
+}
+
+func roleFromMulti(values []MultiValue) (string, error) {
+	role := "member"
+	if len(values) == 0 {
+		return role, nil
+	}
+	pick := values[0]
+	for _, v := range values {
+		if v.Primary {
+			pick = v
+			break
+		}
+	}
+	return role, setRole(&role, pick.Value)
+}
+
+// setRole validates roles grantable through SCIM. Ownership is never
+// provisioned by an IdP.
+func setRole(dst *string, role string) error {
+	switch r := strings.ToLower(strings.TrimSpace(role)); r {
+	case "":
+		*dst = "member"
+	case "member", "admin":
+		*dst = r
+	default:
+		return errInvalidValue("role must be member or admin")
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func patchOf(t *testing.T, body string) PatchRequest {
+	t.Helper()
+	var p PatchRequest
+	if err := json.Unmarshal([]byte(body), &p); err != nil {
+		t.Fatalf("unmarshal patch: %v", err)
+	}
+	return p
+}
+
+func TestApplyUserPatch(t *testing.T) {
+	st := userState{UserName: "alice@example.com", GivenName: "Alice", FamilyName: "Doe", Active: true, Role: "member"}
+
+	err := applyUserPatch(&st, patchOf(t, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [
+			{"op": "Replace", "path": "name.familyName", "value": "Smith"},
+			{"op": "add", "path": "externalId", "value": "00u1"},
+			{"op": "replace", "path": "roles", "value": [{"value": "admin", "primary": true}]},
+			{"op": "replace", "path": "emails[type eq \"work\"].value", "value": "evil@attacker.test"},
+			{"op": "replace", "path": "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department", "value": "R&D"}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("applyUserPatch: %v", err)
+	}
+	want := userState{UserName: "alice@example.com", ExternalID: "00u1", GivenName: "Alice", FamilyName: "Smith", Active: true, Role: "admin"}
+	if st != want {
+		t.Errorf("state = %+v, want %+v", st, want)
+	}
+}
+
+func TestApplyUserPatch_Deactivate(t *testing.T) {
+	for _, body := range []string{
+		`{"Operations": [{"op": "replace", "path": "active", "value": false}]}`,
+		`{"Operations": [{"op": "replace", "path": "active", "value": "False"}]}`,
+		`{"Operations": [{"op": "replace", "value": {"active": false}}]}`,
+	} {
+		st := userState{UserName: "a", Active: true, Role: "member"}
+		if err := applyUserPatch(&st, patchOf(t, body)); err != nil {
+			t.Fatalf("%s: %v", body, err)
+		}
+		if st.Active {
+			t.Errorf("%s: still active", body)
+		}
+	}
+}
+
+func TestApplyUserPatch_Errors(t *testing.T) {
+	for _, body := range []string{
+		`{"Operations": [{"op": "move", "path": "userName", "value": "x"}]}`,
+		`{"Operations": [{"op": "remove"}]}`,
+		`{"Operations": [{"op": "remove", "path": "userName"}]}`,
+		`{"Operations": [{"op": "replace", "path": "active", "value": "maybe"}]}`,
+		`{"Operations": [{"op": "replace", "path": "roles", "value": [{"value": "owner"}]}]}`,
+		`{"Operations": [{"op": "replace", "value": "not-an-object"}]}`,
+	} {
+		st := userState{UserName: "a", Active: true, Role: "member"}
+		var se *Error
+		if err := applyUserPatch(&st, patchOf(t, body)); !errors.As(err, &se) {
+			t.Errorf("%s: err = %v, want *Error", body, err)
+		}
+	}
+}
+
+func TestApplyGroupPatch_Members(t *testing.T) {
+	st := groupState{DisplayName: "Eng", Role: "member", Members: []string{"u1", "u2"}}
+
+	steps := []struct {
+		body string
+		want []string
+	}{
+		{`{"Operations": [{"op": "add", "path": "members", "value": [{"value": "u3"}, {"value": "u1"}]}]}`, []string{"u1", "u2", "u3"}},
+		{`{"Operations": [{"op": "remove", "path": "members[value eq \"u2\"]"}]}`, []string{"u1", "u3"}},
+		{`{"Operations": [{"op": "remove", "path": "members", "value": [{"value": "u1"}]}]}`, []string{"u3"}},
+		{`{"Operations": [{"op": "replace", "path": "members", "value": [{"value": "u4"}, {"value": "u5"}]}]}`, []string{"u4", "u5"}},
+		{`{"Operations": [{"op": "remove", "path": "members"}]}`, nil},
+	}
+	for _, s := range steps {
+		if err := applyGroupPatch(&st, patchOf(t, s.body)); err != nil {
+			t.Fatalf("%s: %v", s.body, err)
+		}
+		if len(st.Members) != len(s.want) || len(s.want) > 0 && !reflect.DeepEqual(st.Members, s.want) {
+			t.Fatalf("%s: members = %v, want %v", s.body, st.Members, s.want)
+		}
+	}
+}
+
+func TestApplyGroupPatch_Attributes(t *testing.T) {
+	st := groupState{DisplayName: "Eng", Role: "member"}
+	err := applyGroupPatch(&st, patchOf(t, `{"Operations": [
+		{"op": "replace", "value": {"displayName": "Engineering", "externalId": "grp-1"}},
+		{"op": "replace", "path": "urn:ietf:params:scim:schemas:extension:vergo:2.0:Group:role", "value": "admin"}
+	]}`))
+	if err != nil {
+		t.Fatalf("applyGroupPatch: %v", err)
+	}
+	if st.DisplayName != "Engineering" || st.ExternalID != "grp-1" || st.Role != "admin" {
+		t.Errorf("state = %+v", st)
+	}
+
+	if err := applyGroupPatch(&st, patchOf(t, `{"Operations": [{"op": "replace", "path": "urn:ietf:params:scim:schemas:extension:vergo:2.0:Group", "value": {"role": "owner"}}]}`)); err == nil {
+		t.Error("owner role accepted through group extension")
+	}
+	if err := applyGroupPatch(&st, patchOf(t, `{"Operations": [{"op": "remove", "path": "displayName"}]}`)); err == nil {
+		t.Error("displayName removal accepted")
+	}
+}
@@ -0,0 +1,908 @@
+package scim
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Ulpio/vergo/internal/domain/org"
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+var ErrTokenNotFound = errors.New("scim token not found")
+
+type Service interface {
+	CreateToken(orgID, userID, name string) (TokenResult, error)
+	ListTokens(orgID string) ([]Token, error)
+	RevokeToken(orgID, tokenID string) error
+	// ValidateToken returns nil for unknown or revoked tokens.
+	ValidateToken(token string) (*TokenLookup, error)
+
+	ListUsers(orgID string, q ListQuery) (ListResponse, error)
+	GetUser(orgID, id string) (User, error)
+	CreateUser(orgID string, u User) (User, error)
+	ReplaceUser(orgID, id string, u User) (User, error)
+	PatchUser(orgID, id string, p PatchRequest) (User, error)
+	DeleteUser(orgID, id string) error
+
+	ListGroups(orgID string, q ListQuery) (ListResponse, error)
+	GetGroup(orgID, id string) (Group, error)
+	CreateGroup(orgID string, g Group) (Group, error)
+	ReplaceGroup(orgID, id string, g Group) (Group, error)
+	PatchGroup(orgID, id string, p PatchRequest) (Group, error)
+	DeleteGroup(orgID, id string) error
+}
+
+type pgService struct {
+	db      *sql.DB
+	q       *repo.Queries
+	baseURL string
+}
+
+// NewPostgresService builds the SCIM service. baseURL is the public URL of
+// the API, used for meta.location.
+func NewPostgresService(db *sql.DB, q *repo.Queries, baseURL string) Service {
+	return &pgService{db: db, q: q, baseURL: strings.TrimRight(baseURL, "/") + "/v1/scim/v2"}
+}
+
+// ── Tokens ───────────────────────────────────────────────────────────
+
+func (s *pgService) CreateToken(orgID, userID, name string) (TokenResult, error) {
+	plaintext, err := generateToken()
+	if err != nil {
+		return TokenResult{}, fmt.Errorf("generate token: %w", err)
+	}
+	row, err := s.q.CreateSCIMToken(context.Background(), repo.CreateSCIMTokenParams{
+		OrgID:       orgID,
+		Name:        name,
+		TokenPrefix: plaintext[:12],
+		TokenHash:   hashToken(plaintext),
+		CreatedBy:   userID,
+	})
+	if err != nil {
+		return TokenResult{}, fmt.Errorf("insert scim token: %w", err)
+	}
+	return TokenResult{
+		Token: Token{
+			ID:          row.ID,
+			OrgID:       row.OrgID,
+			Name:        row.Name,
+			TokenPrefix: row.TokenPrefix,
+			CreatedBy:   row.CreatedBy,
+			CreatedAt:   row.CreatedAt,
+		},
+		PlaintextToken: plaintext,
+	}, nil
+}
+
+func (s *pgService) ListTokens(orgID string) ([]Token, error) {
+	rows, err := s.q.ListSCIMTokens(context.Background(), orgID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Token, len(rows))
+	for i, r := range rows {
+		out[i] = Token{
+			ID:          r.ID,
+			OrgID:       r.OrgID,
+			Name:        r.Name,
+			TokenPrefix: r.TokenPrefix,
+			CreatedBy:   r.CreatedBy,
+			CreatedAt:   r.CreatedAt,
+		}
+		if r.LastUsedAt.Valid {
+			out[i].LastUsedAt = &r.LastUsedAt.Time
+		}
+	}
+	return out, nil
+}
+
+func (s *pgService) RevokeToken(orgID, tokenID string) error {
+	res, err := s.q.RevokeSCIMToken(context.Background(), repo.RevokeSCIMTokenParams{ID: tokenID, OrgID: orgID})
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
+func (s *pgService) ValidateToken(token string) (*TokenLookup, error) {
+	row, err := s.q.GetSCIMTokenByHash(context.Background(), hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// fire-and-forget last_used update
+	go func() {
+		_ = s.q.TouchSCIMTokenLastUsed(context.Background(), row.ID)
+	}()
+
+	return &TokenLookup{TokenID: row.ID, OrgID: row.OrgID, OrgDeleted: row.OrgDeleted, OrgSuspended: row.OrgSuspended}, nil
+}
+
+// ── Users ────────────────────────────────────────────────────────────
+
+func (s *pgService) ListUsers(orgID string, lq ListQuery) (ListResponse, error) {
+	filter, err := parseListFilter(lq.Filter)
+	if err != nil {
+		return ListResponse{}, err
+	}
+	ctx := context.Background()
+	rows, err := s.q.ListSCIMUsers(ctx, orgID)
+	if err != nil {
+		return ListResponse{}, err
+	}
+	groups, members, err := s.groupIndex(ctx, orgID)
+	if err != nil {
+		return ListResponse{}, err
+	}
+	userGroups := map[string][]MultiValue{}
+	for _, m := range members {
+		g := groups[m.GroupID]
+		userGroups[m.UserID] = append(userGroups[m.UserID], s.groupRef(g))
+	}
+
+	var out []any
+	for _, r := range rows {
+		u := s.toUser(repo.ScimUser{
+			OrgID:       r.OrgID,
+			UserID:      r.UserID,
+			UserName:    r.UserName,
+			ExternalID:  r.ExternalID,
+			DisplayName: r.DisplayName,
+			GivenName:   r.GivenName,
+			FamilyName:  r.FamilyName,
+			Role:        r.Role,
+			Active:      r.Active,
+			CreatedAt:   r.CreatedAt,
+			UpdatedAt:   r.UpdatedAt,
+		}, r.Email, userGroups[r.UserID])
+		if filter == nil || filter.match(toDocument(u)) {
+			out = append(out, u)
+		}
+	}
+	return page(out, lq), nil
+}
+
+func (s *pgService) GetUser(orgID, id string) (User, error) {
+	return s.loadUser(context.Background(), s.q, orgID, id)
+}
+
+func (s *pgService) CreateUser(orgID string, in User) (User, error) {
+	if strings.TrimSpace(in.UserName) == "" {
+		return User{}, errInvalidValue("userName is required")
+	}
+	email := primaryEmail(in)
+	if !strings.Contains(email, "@") {
+		return User{}, errInvalidValue("a work email (or an email userName) is required")
+	}
+	st, err := userStateFrom(in)
+	if err != nil {
+		return User{}, err
+	}
+
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return User{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := s.q.WithTx(tx)
+
+	userID, err := s.findOrCreateAccount(ctx, qtx, orgID, email)
+	if err != nil {
+		return User{}, err
+	}
+	if _, err := qtx.GetSCIMUser(ctx, repo.GetSCIMUserParams{OrgID: orgID, UserID: userID}); err == nil {
+		return User{}, errUniqueness("user " + email + " is already provisioned")
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return User{}, err
+	}
+	if err := s.ensureUserNameFree(ctx, qtx, orgID, userID, st.UserName); err != nil {
+		return User{}, err
+	}
+
+	if _, err := qtx.InsertSCIMUser(ctx, userParams(orgID, userID, st)); err != nil {
+		return User{}, err
+	}
+	if err := syncMembership(ctx, qtx, orgID, userID); err != nil {
+		return User{}, err
+	}
+	u, err := s.loadUser(ctx, qtx, orgID, userID)
+	if err != nil {
+		return User{}, err
+	}
+	return u, tx.Commit()
+}
+
+func (s *pgService) ReplaceUser(orgID, id string, in User) (User, error) {
+	if strings.TrimSpace(in.UserName) == "" {
+		return User{}, errInvalidValue("userName is required")
+	}
+	st, err := userStateFrom(in)
+	if err != nil {
+		return User{}, err
+	}
+	return s.updateUser(orgID, id, func(cur *userState) error {
+		*cur = st
+		return nil
+	})
+}
+
+func (s *pgService) PatchUser(orgID, id string, p PatchRequest) (User, error) {
+	return s.updateUser(orgID, id, func(cur *userState) error {
+		return applyUserPatch(cur, p)
+	})
+}
+
+func (s *pgService) updateUser(orgID, id string, mutate func(*userState) error) (User, error) {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return User{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := s.q.WithTx(tx)
+
+	row, err := qtx.GetSCIMUser(ctx, repo.GetSCIMUserParams{OrgID: orgID, UserID: id})
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, errNotFound("User", id)
+	}
+	if err != nil {
+		return User{}, err
+	}
+	st := userState{
+		UserName:    row.UserName,
+		ExternalID:  row.ExternalID.String,
+		DisplayName: row.DisplayName.String,
+		GivenName:   row.GivenName.String,
+		FamilyName:  row.FamilyName.String,
+		Active:      row.Active,
+		Role:        row.Role,
+	}
+	if err := mutate(&st); err != nil {
+		return User{}, err
+	}
+	if strings.TrimSpace(st.UserName) == "" {
+		return User{}, errInvalidValue("userName is required")
+	}
+	if err := s.ensureUserNameFree(ctx, qtx, orgID, id, st.UserName); err != nil {
+		return User{}, err
+	}
+
+	if _, err := qtx.UpdateSCIMUser(ctx, repo.UpdateSCIMUserParams(userParams(orgID, id, st))); err != nil {
+		return User{}, err
+	}
+	if err := syncMembership(ctx, qtx, orgID, id); err != nil {
+		return User{}, err
+	}
+	u, err := s.loadUser(ctx, qtx, orgID, id)
+	if err != nil {
+		return User{}, err
+	}
+	return u, tx.Commit()
+}
+
+// DeleteUser deprovisions the user: the org membership, the SCIM record and
+// its group memberships go away. The Vergo account itself is kept, as it may
+// belong to other orgs.
+func (s *pgService) DeleteUser(orgID, id string) error {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := s.q.WithTx(tx)
+
+	if _, err := qtx.GetSCIMUser(ctx, repo.GetSCIMUserParams{OrgID: orgID, UserID: id}); errors.Is(err, sql.ErrNoRows) {
+		return errNotFound("User", id)
+	} else if err != nil {
+		return err
+	}
+	if err := removeMembership(ctx, qtx, orgID, id); err != nil {
+		return err
+	}
+	if err := qtx.DeleteSCIMGroupMembershipsForUser(ctx, repo.DeleteSCIMGroupMembershipsForUserParams{OrgID: orgID, UserID: id}); err != nil {
+		return err
+	}
+	if _, err := qtx.DeleteSCIMUser(ctx, repo.DeleteSCIMUserParams{OrgID: orgID, UserID: id}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// findOrCreateAccount links the SCIM user to the Vergo account with the same
+// email, creating a password-less account when none exists. New accounts
+// count as verified only when the org has proven it owns the email domain.
+func (s *pgService) findOrCreateAccount(ctx context.Context, q *repo.Queries, orgID, email string) (string, error) {
+	row, err := q.GetUserByEmail(ctx, email)
+	if err == nil {
+		return row.ID, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+
+	id := uuid.NewString()
+	if err := q.InsertUser(ctx, repo.InsertUserParams{ID: id, Email: email, CreatedAt: time.Now()}); err != nil {
+		return "", err
+	}
+	_, domain, _ := strings.Cut(email, "@")
+	owner, err := q.GetVerifiedDomainSSO(ctx, domain)
+	switch {
+	case err == nil && owner.OrgID == orgID:
+		if err := q.MarkUserEmailVerified(ctx, id); err != nil {
+			return "", err
+		}
+	case err != nil && !errors.Is(err, sql.ErrNoRows):
+		return "", err
+	}
+	return id, nil
+}
+
+func (s *pgService) ensureUserNameFree(ctx context.Context, q *repo.Queries, orgID, userID, userName string) error {
+	rows, err := q.ListSCIMUsers(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if r.UserID != userID && strings.EqualFold(r.UserName, userName) {
+			return errUniqueness("userName " + userName + " is already in use")
+		}
+	}
+	return nil
+}
+
+func (s *pgService) loadUser(ctx context.Context, q *repo.Queries, orgID, id string) (User, error) {
+	row, err := q.GetSCIMUser(ctx, repo.GetSCIMUserParams{OrgID: orgID, UserID: id})
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, errNotFound("User", id)
+	}
+	if err != nil {
+		return User{}, err
+	}
+	acct, err := q.GetUserByID(ctx, id)
+	if err != nil {
+		return User{}, err
+	}
+	gs, err := q.ListSCIMGroupsForUser(ctx, repo.ListSCIMGroupsForUserParams{OrgID: orgID, UserID: id})
+	if err != nil {
+		return User{}, err
+	}
+	refs := make([]MultiValue, len(gs))
+	for i, g := range gs {
+		refs[i] = MultiValue{Value: g.ID, Display: g.DisplayName, Ref: s.baseURL + "/Groups/" + g.ID}
+	}
+	return s.toUser(row, acct.Email, refs), nil
+}
+
+func (s *pgService) toUser(r repo.ScimUser, email string, groups []MultiValue) User {
+	active := r.Active
+	u := User{
+		Schemas:     []string{SchemaUser},
+		ID:          r.UserID,
+		ExternalID:  r.ExternalID.String,
+		UserName:    r.UserName,
+		DisplayName: r.DisplayName.String,
+		Emails:      []MultiValue{{Value: email, Type: "work", Primary: true}},
+		Active:      &active,
+		Roles:       []MultiValue{{Value: r.Role, Primary: true}},
+		Groups:      groups,
+		Meta: &Meta{
+			ResourceType: "User",
+			Created:      r.CreatedAt,
+			LastModified: r.UpdatedAt,
+			Location:     s.baseURL + "/Users/" + r.UserID,
+		},
+	}
+	if r.GivenName.Valid || r.FamilyName.Valid {
+		u.Name = &Name{
+			GivenName:  r.GivenName.String,
+			FamilyName: r.FamilyName.String,
+			Formatted:  strings.TrimSpace(r.GivenName.String + " " + r.FamilyName.String),
+		}
+	}
+	return u
+}
+
+func userStateFrom(in User) (userState, error) {
+	st := userState{
+		UserName:    in.UserName,
+		ExternalID:  in.ExternalID,
+		DisplayName: in.DisplayName,
+		Active:      in.Active == nil || *in.Active,
+	}
+	if in.Name != nil {
+		st.GivenName, st.FamilyName = in.Name.GivenName, in.Name.FamilyName
+	}
+	role, err := roleFromMulti(in.Roles)
+	if err != nil {
+		return userState{}, err
+	}
+	st.Role = role
+	return st, nil
+}
+
+func userParams(orgID, userID string, st userState) repo.InsertSCIMUserParams {
+	return repo.InsertSCIMUserParams{
+		OrgID:       orgID,
+		UserID:      userID,
+		UserName:    st.UserName,
+		ExternalID:  nullString(st.ExternalID),
+		DisplayName: nullString(st.DisplayName),
+		GivenName:   nullString(st.GivenName),
+		FamilyName:  nullString(st.FamilyName),
+		Role:        st.Role,
+		Active:      st.Active,
+	}
+}
+
+func primaryEmail(u User) string {
+	for _, e := range u.Emails {
+		if e.Primary {
+			return strings.ToLower(strings.TrimSpace(e.Value))
+		}
+	}
+	if len(u.Emails) > 0 {
+		return strings.ToLower(strings.TrimSpace(u.Emails[0].Value))
+	}
+	return strings.ToLower(strings.TrimSpace(u.UserName))
+}
+
+// ── Groups ───────────────────────────────────────────────────────────
+
+func (s *pgService) ListGroups(orgID string, lq ListQuery) (ListResponse, error) {
+	filter, err := parseListFilter(lq.Filter)
+	if err != nil {
+		return ListResponse{}, err
+	}
+	ctx := context.Background()
+	rows, err := s.q.ListSCIMGroups(ctx, orgID)
+	if err != nil {
+		return ListResponse{}, err
+	}
+	_, members, err := s.groupIndex(ctx, orgID)
+	if err != nil {
+		return ListResponse{}, err
+	}
+	byGroup := map[string][]MultiValue{}
+	for _, m := range members {
+		byGroup[m.GroupID] = append(byGroup[m.GroupID], s.userRef(m.UserID, m.UserName))
+	}
+
+	var out []any
+	for _, r := range rows {
+		g := s.toGroup(r, byGroup[r.ID])
+		if filter == nil || filter.match(toDocument(g)) {
+			out = append(out, g)
+		}
+	}
+	return page(out, lq), nil
+}
+
+func (s *pgService) GetGroup(orgID, id string) (Group, error) {
+	return s.loadGroup(context.Background(), s.q, orgID, id)
+}
+
+func (s *pgService) CreateGroup(orgID string, in Group) (Group, error) {
+	st, err := groupStateFrom(in)
+	if err != nil {
+		return Group{}, err
+	}
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Group{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := s.q.WithTx(tx)
+
+	if err := ensureGroupNameFree(ctx, qtx, orgID, "", st.DisplayName); err != nil {
+		return Group{}, err
+	}
+	row, err := qtx.InsertSCIMGroup(ctx, repo.InsertSCIMGroupParams{
+		OrgID:       orgID,
+		DisplayName: st.DisplayName,
+		ExternalID:  nullString(st.ExternalID),
+		Role:        st.Role,
+	})
+	if err != nil {
+		return Group{}, err
+	}
+	if err := setGroupMembers(ctx, qtx, orgID, row.ID, nil, st.Members); err != nil {
+		return Group{}, err
+	}
+	g, err := s.loadGroup(ctx, qtx, orgID, row.ID)
+	if err != nil {
+		return Group{}, err
+	}
+	return g, tx.Commit()
+}
+
+func (s *pgService) ReplaceGroup(orgID, id string, in Group) (Group, error) {
+	st, err := groupStateFrom(in)
+	if err != nil {
+		return Group{}, err
+	}
+	return s.updateGroup(orgID, id, func(cur *groupState) error {
+		*cur = st
+		return nil
+	})
+}
+
+func (s *pgService) PatchGroup(orgID, id string, p PatchRequest) (Group, error) {
+	return s.updateGroup(orgID, id, func(cur *groupState) error {
+		return applyGroupPatch(cur, p)
+	})
+}
+
+func (s *pgService) updateGroup(orgID, id string, mutate func(*groupState) error) (Group, error) {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Group{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := s.q.WithTx(tx)
+
+	row, err := qtx.GetSCIMGroup(ctx, repo.GetSCIMGroupParams{OrgID: orgID, ID: id})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Group{}, errNotFound("Group", id)
+	}
+	if err != nil {
+		return Group{}, err
+	}
+	before, err := groupMemberIDs(ctx, qtx, orgID, id)
+	if err != nil {
+		return Group{}, err
+	}
+	st := groupState{
+		DisplayName: row.DisplayName,
+		ExternalID:  row.ExternalID.String,
+		Role:        row.Role,
+		Members:     append([]string(nil), before...),
+	}
+	if err := mutate(&st); err != nil {
+		return Group{}, err
+	}
+	if strings.TrimSpace(st.DisplayName) == "" {
+		return Group{}, errInvalidValue("displayName is required")
+	}
+	if err := ensureGroupNameFree(ctx, qtx, orgID, id, st.DisplayName); err != nil {
+		return Group{}, err
+	}
+
+	if _, err := qtx.UpdateSCIMGroup(ctx, repo.UpdateSCIMGroupParams{
+		OrgID:       orgID,
+		ID:          id,
+		DisplayName: st.DisplayName,
+		ExternalID:  nullString(st.ExternalID),
+		Role:        st.Role,
+	}); err != nil {
+		return Group{}, err
+	}
+	if err := setGroupMembers(ctx, qtx, orgID, id, before, st.Members); err != nil {
+		return Group{}, err
+	}
+	// A role change on the group affects members that stayed as well.
+	if row.Role != st.Role {
+		for _, uid := range st.Members {
+			if err := syncMembership(ctx, qtx, orgID, uid); err != nil {
+				return Group{}, err
+			}
+		}
+	}
+	g, err := s.loadGroup(ctx, qtx, orgID, id)
+	if err != nil {
+		return Group{}, err
+	}
+	return g, tx.Commit()
+}
+
+func (s *pgService) DeleteGroup(orgID, id string) error {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := s.q.WithTx(tx)
+
+	members, err := groupMemberIDs(ctx, qtx, orgID, id)
+	if err != nil {
+		return err
+	}
+	res, err := qtx.DeleteSCIMGroup(ctx, repo.DeleteSCIMGroupParams{OrgID: orgID, ID: id})
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errNotFound("Group", id)
+	}
+	for _, uid := range members {
+		if err := syncMembership(ctx, qtx, orgID, uid); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *pgService) loadGroup(ctx context.Context, q *repo.Queries, orgID, id string) (Group, error) {
+	row, err := q.GetSCIMGroup(ctx, repo.GetSCIMGroupParams{OrgID: orgID, ID: id})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Group{}, errNotFound("Group", id)
+	}
+	if err != nil {
+		return Group{}, err
+	}
+	all, err := q.ListSCIMGroupMembers(ctx, orgID)
+	if err != nil {
+		return Group{}, err
+	}
+	var members []MultiValue
+	for _, m := range all {
+		if m.GroupID == id {
+			members = append(members, s.userRef(m.UserID, m.UserName))
+		}
+	}
+	return s.toGroup(row, members), nil
+}
+
+func (s *pgService) toGroup(r repo.ScimGroup, members []MultiValue) Group {
+	if members == nil {
+		members = []MultiValue{}
+	}
+	return Group{
+		Schemas:     []string{SchemaGroup, SchemaVergoGroup},
+		ID:          r.ID,
+		ExternalID:  r.ExternalID.String,
+		DisplayName: r.DisplayName,
+		Members:     members,
+		Vergo:       &GroupExtension{Role: r.Role},
+		Meta: &Meta{
+			ResourceType: "Group",
+			Created:      r.CreatedAt,
+			LastModified: r.UpdatedAt,
+			Location:     s.baseURL + "/Groups/" + r.ID,
+		},
+	}
+}
+
+func (s *pgService) groupIndex(ctx context.Context, orgID string) (map[string]repo.ScimGroup, []repo.ListSCIMGroupMembersRow, error) {
+	groups, err := s.q.ListSCIMGroups(ctx, orgID)
+	if err != nil {
+		return nil, nil, err
+	}
+	members, err := s.q.ListSCIMGroupMembers(ctx, orgID)
+	if err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[string]repo.ScimGroup, len(groups))
+	for _, g := range groups {
+		byID[g.ID] = g
+	}
+	return byID, members, nil
+}
+
+func (s *pgService) groupRef(g repo.ScimGroup) MultiValue {
+	return MultiValue{Value: g.ID, Display: g.DisplayName, Ref: s.baseURL + "/Groups/" + g.ID}
+}
+
+func (s *pgService) userRef(id, userName string) MultiValue {
+	return MultiValue{Value: id, Display: userName, Ref: s.baseURL + "/Users/" + url.PathEscape(id)}
+}
+
+func groupStateFrom(in Group) (groupState, error) {
+	if strings.TrimSpace(in.DisplayName) == "" {
+		return groupState{}, errInvalidValue("displayName is required")
+	}
+	st := groupState{DisplayName: in.DisplayName, ExternalID: in.ExternalID, Role: "member"}
+	if in.Vergo != nil {
+		if err := setRole(&st.Role, in.Vergo.Role); err != nil {
+			return groupState{}, err
+		}
+	}
+	for _, m := range in.Members {
+		st.Members = appendUnique(st.Members, m.Value)
+	}
+	return st, nil
+}
+
+func ensureGroupNameFree(ctx context.Context, q *repo.Queries, orgID, groupID, name string) error {
+	groups, err := q.ListSCIMGroups(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	for _, g := range groups {
+		if g.ID != groupID && g.DisplayName == name {
+			return errUniqueness("group " + name + " already exists")
+		}
+	}
+	return nil
+}
+
+func groupMemberIDs(ctx context.Context, q *repo.Queries, orgID, groupID string) ([]string, error) {
+	all, err := q.ListSCIMGroupMembers(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, m := range all {
+		if m.GroupID == groupID {
+			ids = append(ids, m.UserID)
+		}
+	}
+	return ids, nil
+}
+
+// setGroupMembers applies the difference between two member lists and
+// re-syncs the org role of every user whose group set changed. Only users
+// provisioned through SCIM in this org can be group members.
+func setGroupMembers(ctx context.Context, q *repo.Queries, orgID, groupID string, before, after []string) error {
+	had := make(map[string]bool, len(before))
+	for _, id := range before {
+		had[id] = true
+	}
+	want := make(map[string]bool, len(after))
+	for _, id := range after {
+		want[id] = true
+	}
+
+	for _, id := range after {
+		if had[id] {
+			continue
+		}
+		if _, err := q.GetSCIMUser(ctx, repo.GetSCIMUserParams{OrgID: orgID, UserID: id}); errors.Is(err, sql.ErrNoRows) {
+			return errInvalidValue("member " + id + " is not a provisioned user")
+		} else if err != nil {
+			return err
+		}
+		if err := q.AddSCIMGroupMember(ctx, repo.AddSCIMGroupMemberParams{GroupID: groupID, UserID: id}); err != nil {
+			return err
+		}
+		if err := syncMembership(ctx, q, orgID, id); err != nil {
+			return err
+		}
+	}
+	for _, id := range before {
+		if want[id] {
+			continue
+		}
+		if err := q.RemoveSCIMGroupMember(ctx, repo.RemoveSCIMGroupMemberParams{GroupID: groupID, UserID: id}); err != nil {
+			return err
+		}
+		if err := syncMembership(ctx, q, orgID, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ── Membership sync ──────────────────────────────────────────────────
+
+var roleRank = map[string]int{"member": 1, "admin": 2, "owner": 3}
+
+// syncMembership makes the org membership reflect the SCIM state: inactive
+// users lose it, active ones get the highest of their own role and the roles
+// of their groups. Owners are never changed by the IdP.
+func syncMembership(ctx context.Context, q *repo.Queries, orgID, userID string) error {
+	su, err := q.GetSCIMUser(ctx, repo.GetSCIMUserParams{OrgID: orgID, UserID: userID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !su.Active {
+		return removeMembership(ctx, q, orgID, userID)
+	}
+
+	role := su.Role
+	groups, err := q.ListSCIMGroupsForUser(ctx, repo.ListSCIMGroupsForUserParams{OrgID: orgID, UserID: userID})
+	if err != nil {
+		return err
+	}
+	for _, g := range groups {
+		if roleRank[g.Role] > roleRank[role] {
+			role = g.Role
+		}
+	}
+
+	current, err := q.GetMemberRole(ctx, repo.GetMemberRoleParams{OrgID: orgID, UserID: userID})
+	switch {
+	case err == nil && current == "owner", err == nil && current == role:
+		return nil
+	case err != nil && !errors.Is(err, sql.ErrNoRows):
+		return err
+	}
+	err = org.EmailAllowed(ctx, q, orgID, userID)
+	if errors.Is(err, org.ErrEmailDomainForbidden) {
+		return errInvalidValue("email domain not allowed by the org")
+	}
+	if err != nil {
+		return err
+	}
+	return q.UpsertMember(ctx, repo.UpsertMemberParams{OrgID: orgID, UserID: userID, Role: role})
+}
+
+func removeMembership(ctx context.Context, q *repo.Queries, orgID, userID string) error {
+	current, err := q.GetMemberRole(ctx, repo.GetMemberRoleParams{OrgID: orgID, UserID: userID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if current == "owner" {
+		return &Error{Status: http.StatusConflict, ScimType: "mutability", Detail: "org owners cannot be deprovisioned through SCIM"}
+	}
+	return q.DeleteMember(ctx, repo.DeleteMemberParams{OrgID: orgID, UserID: userID})
+}
+
+// ── helpers ──────────────────────────────────────────────────────────
+
+func parseListFilter(s string) (Filter, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	return ParseFilter(s)
+}
+
+func page(all []any, lq ListQuery) ListResponse {
+	start := lq.StartIndex
+	if start < 1 {
+		start = 1
+	}
+	count := lq.Count
+	if count < 0 || count > MaxPageSize {
+		count = MaxPageSize
+	}
+	resources := []any{}
+	if start <= len(all) {
+		end := start - 1 + count
+		if end > len(all) {
+			end = len(all)
+		}
+		resources = all[start-1 : end]
+	}
+	return ListResponse{
+		Schemas:      []string{SchemaListResp},
+		TotalResults: len(all),
+		StartIndex:   start,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "scim_" + hex.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,199 @@
+//go:build integration
+
+package scim_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Ulpio/vergo/internal/domain/org"
+	"github.com/Ulpio/vergo/internal/domain/platform"
+	"github.com/Ulpio/vergo/internal/domain/scim"
+	"github.com/Ulpio/vergo/internal/domain/user"
+	"github.com/Ulpio/vergo/internal/pkg/precondition"
+	"github.com/Ulpio/vergo/internal/pkg/testutil"
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+func setupSCIM(t *testing.T) (scim.Service, org.Service, org.Organization, user.User) {
+	t.Helper()
+	db := testutil.PGContainer(t)
+	q := repo.New(db)
+	owner, err := user.NewPostgresService(db, q, nil).Signup("owner@test.com", "pass123")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	orgSvc := org.NewPostgresService(db, q)
+	o, err := orgSvc.Create("Acme", owner.ID)
+	if err != nil {
+		t.Fatalf("create org: %v", err)
+	}
+	return scim.NewPostgresService(db, q, "https://api.test"), orgSvc, o, owner
+}
+
+func scimStatus(err error) int {
+	var se *scim.Error
+	if errors.As(err, &se) {
+		return se.Status
+	}
+	return 0
+}
+
+func TestPGService_Tokens(t *testing.T) {
+	svc, _, o, owner := setupSCIM(t)
+
+	tok, err := svc.CreateToken(o.ID, owner.ID, "okta")
+	if err != nil {
+		t.Fatalf("CreateToken: %v", err)
+	}
+	got, err := svc.ValidateToken(tok.PlaintextToken)
+	if err != nil || got == nil || got.OrgID != o.ID {
+		t.Fatalf("ValidateToken = %+v, %v", got, err)
+	}
+	if err := svc.RevokeToken(o.ID, tok.ID); err != nil {
+		t.Fatalf("RevokeToken: %v", err)
+	}
+	if got, _ := svc.ValidateToken(tok.PlaintextToken); got != nil {
+		t.Error("revoked token still valid")
+	}
+	if err := svc.RevokeToken(o.ID, tok.ID); !errors.Is(err, scim.ErrTokenNotFound) {
+		t.Errorf("second revoke: err = %v", err)
+	}
+}
+
+func TestPGService_TokenOrgState(t *testing.T) {
+	db := testutil.PGContainer(t)
+	q := repo.New(db)
+	owner, err := user.NewPostgresService(db, q, nil).Signup("owner@test.com", "pass123")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	orgSvc := org.NewPostgresService(db, q)
+	o, err := orgSvc.Create("Acme", owner.ID)
+	if err != nil {
+		t.Fatalf("create org: %v", err)
+	}
+	svc := scim.NewPostgresService(db, q, "https://api.test")
+	tok, err := svc.CreateToken(o.ID, owner.ID, "okta")
+	if err != nil {
+		t.Fatalf("CreateToken: %v", err)
+	}
+
+	if got, _ := svc.ValidateToken(tok.PlaintextToken); got == nil || got.OrgDeleted || got.OrgSuspended {
+		t.Fatalf("ValidateToken = %+v, want an active org", got)
+	}
+	if err := platform.NewPostgresService(db, q).SuspendOrg(o.ID, "chargeback"); err != nil {
+		t.Fatalf("SuspendOrg: %v", err)
+	}
+	if got, _ := svc.ValidateToken(tok.PlaintextToken); got == nil || !got.OrgSuspended {
+		t.Errorf("ValidateToken after suspension = %+v, want OrgSuspended", got)
+	}
+	if err := orgSvc.Delete(o.ID, owner.ID, precondition.Any); err != nil {
+		t.Fatalf("Delete org: %v", err)
+	}
+	if got, _ := svc.ValidateToken(tok.PlaintextToken); got == nil || !got.OrgDeleted {
+		t.Errorf("ValidateToken after deletion = %+v, want OrgDeleted", got)
+	}
+}
+
+func TestPGService_UserLifecycle(t *testing.T) {
+	svc, orgSvc, o, _ := setupSCIM(t)
+
+	u, err := svc.CreateUser(o.ID, scim.User{
+		UserName: "bob@acme.com",
+		Name:     &scim.Name{GivenName: "Bob", FamilyName: "Smith"},
+		Emails:   []scim.MultiValue{{Value: "bob@acme.com", Primary: true}},
+	})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if ok, role, _ := orgSvc.IsMember(o.ID, u.ID); !ok || role != "member" {
+		t.Fatalf("membership after create: ok=%v role=%q", ok, role)
+	}
+	if _, err := svc.CreateUser(o.ID, scim.User{UserName: "bob@acme.com"}); scimStatus(err) != http.StatusConflict {
+		t.Errorf("duplicate create: err = %v", err)
+	}
+
+	list, err := svc.ListUsers(o.ID, scim.ListQuery{Filter: `userName eq "BOB@acme.com"`, StartIndex: 1, Count: 10})
+	if err != nil || list.TotalResults != 1 {
+		t.Fatalf("ListUsers = %+v, %v", list, err)
+	}
+
+	// A group with the admin role promotes its members.
+	g, err := svc.CreateGroup(o.ID, scim.Group{
+		DisplayName: "Admins",
+		Members:     []scim.MultiValue{{Value: u.ID}},
+		Vergo:       &scim.GroupExtension{Role: "admin"},
+	})
+	if err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+	if _, role, _ := orgSvc.IsMember(o.ID, u.ID); role != "admin" {
+		t.Errorf("role via group = %q, want admin", role)
+	}
+
+	var patch scim.PatchRequest
+	_ = json.Unmarshal([]byte(`{"Operations":[{"op":"remove","path":"members[value eq \"`+u.ID+`\"]"}]}`), &patch)
+	if _, err := svc.PatchGroup(o.ID, g.ID, patch); err != nil {
+		t.Fatalf("PatchGroup: %v", err)
+	}
+	if _, role, _ := orgSvc.IsMember(o.ID, u.ID); role != "member" {
+		t.Errorf("role after leaving group = %q, want member", role)
+	}
+
+	// Deactivation deprovisions the membership but keeps the SCIM record.
+	_ = json.Unmarshal([]byte(`{"Operations":[{"op":"replace","value":{"active":false}}]}`), &patch)
+	if _, err := svc.PatchUser(o.ID, u.ID, patch); err != nil {
+		t.Fatalf("PatchUser: %v", err)
+	}
+	if ok, _, _ := orgSvc.IsMember(o.ID, u.ID); ok {
+		t.Error("inactive user is still a member")
+	}
+
+	if err := svc.DeleteUser(o.ID, u.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if _, err := svc.GetUser(o.ID, u.ID); scimStatus(err) != http.StatusNotFound {
+		t.Errorf("GetUser after delete: err = %v", err)
+	}
+}
+
+func TestPGService_AllowedEmailDomains(t *testing.T) {
+	svc, orgSvc, o, _ := setupSCIM(t)
+	st := org.Settings{AllowedEmailDomains: []string{"acme.com"}}
+	if _, err := orgSvc.UpdateProfile(o.ID, org.ProfileUpdate{Settings: &st}, precondition.Any); err != nil {
+		t.Fatalf("UpdateProfile: %v", err)
+	}
+
+	if _, err := svc.CreateUser(o.ID, scim.User{UserName: "eve@evil.com"}); scimStatus(err) != http.StatusBadRequest {
+		t.Fatalf("create outside allowed domains: err = %v, want 400", err)
+	}
+	list, err := svc.ListUsers(o.ID, scim.ListQuery{Filter: `userName eq "eve@evil.com"`, StartIndex: 1, Count: 10})
+	if err != nil || list.TotalResults != 0 {
+		t.Errorf("ListUsers after refused create = %+v, %v", list, err)
+	}
+	u, err := svc.CreateUser(o.ID, scim.User{UserName: "bob@acme.com"})
+	if err != nil {
+		t.Fatalf("create in allowed domain: %v", err)
+	}
+	if ok, _, _ := orgSvc.IsMember(o.ID, u.ID); !ok {
+		t.Error("bob@acme.com not a member")
+	}
+}
+
+func TestPGService_OwnerIsNotDeprovisioned(t *testing.T) {
+	svc, orgSvc, o, owner := setupSCIM(t)
+
+	u, err := svc.CreateUser(o.ID, scim.User{UserName: owner.Email})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if _, role, _ := orgSvc.IsMember(o.ID, u.ID); role != "owner" {
+		t.Fatalf("owner role changed to %q", role)
+	}
+	if err := svc.DeleteUser(o.ID, u.ID); scimStatus(err) != http.StatusConflict {
+		t.Errorf("delete owner: err = %v", err)
+	}
+}
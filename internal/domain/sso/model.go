@@ -0,0 +1,50 @@
+package sso
+
+import (
+	"time"
+)
+
+// AttributeMapping tells the SP which SAML attributes carry user data.
+type AttributeMapping struct {
+	// Email attribute name; the NameID is used when empty.
+	Email string `json:"email,omitempty"`
+	// Role attribute name and the value -> org role table ("member"/"admin").
+	Role       string            `json:"role,omitempty"`
+	RoleValues map[string]string `json:"role_values,omitempty"`
+}
+
+type Config struct {
+	OrgID            string           `json:"org_id"`
+	IdPEntityID      string           `json:"idp_entity_id"`
+	IdPSSOURL        string           `json:"idp_sso_url"`
+	IdPCertificate   string           `json:"idp_certificate"`
+	AttributeMapping AttributeMapping `json:"attribute_mapping"`
+	EnforceSSO       bool             `json:"enforce_sso"`
+	Enabled          bool             `json:"enabled"`
+	CreatedAt        time.Time        `json:"created_at"`
+	UpdatedAt        time.Time        `json:"updated_at"`
+}
+
+type Domain struct {
+	OrgID             string     `json:"org_id"`
+	Domain            string     `json:"domain"`
+	VerificationToken string     `json:"verification_token"`
+	VerifiedAt        *time.Time `json:"verified_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// TXTRecord is the DNS name the verification token must be published at.
+func (d Domain) TXTRecord() string {
+	return "_vergo-challenge." + d.Domain
+}
+
+// TXTValue is the expected content of the verification TXT record.
+func (d Domain) TXTValue() string {
+	return "vergo-domain-verification=" + d.VerificationToken
+}
+
+// Discovery describes the SSO requirements for an email address.
+type Discovery struct {
+	OrgID    string `json:"org_id"`
+	Enforced bool   `json:"enforced"`
+}
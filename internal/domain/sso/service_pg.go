@@ -0,0 +1,499 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Ulpio/vergo/internal/auth/saml"
+	"github.com/Ulpio/vergo/internal/domain/org"
+	"github.com/Ulpio/vergo/internal/domain/user"
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+var (
+	ErrNotConfigured    = errors.New("saml sso is not configured for this org")
+	ErrInvalidConfig    = errors.New("invalid saml configuration")
+	ErrInvalidDomain    = errors.New("invalid domain")
+	ErrDomainExists     = errors.New("domain already claimed by this org")
+	ErrDomainTaken      = errors.New("domain is verified by another org")
+	ErrDomainNotFound   = errors.New("domain not found")
+	ErrDomainUnverified = errors.New("domain verification record not found")
+	ErrEmailNotInDomain = errors.New("asserted email is not in a verified domain of this org")
+	ErrUnknownRequest   = errors.New("unknown or expired saml request")
+	ErrReplay           = errors.New("saml assertion already used")
+	ErrAccountConflict  = errors.New("account is linked to a different saml subject")
+)
+
+const requestTTL = 10 * time.Minute
+
+// Resolver looks up DNS TXT records; *net.Resolver satisfies it.
+type Resolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+type Service interface {
+	GetConfig(orgID string) (Config, error)
+	SaveConfig(cfg Config) (Config, error)
+
+	ClaimDomain(orgID, domain string) (Domain, error)
+	VerifyDomain(orgID, domain string) (Domain, error)
+	ListDomains(orgID string) ([]Domain, error)
+	DeleteDomain(orgID, domain string) error
+
+	// Discover returns the org whose verified domain matches the email and
+	// which has SAML enabled.
+	Discover(email string) (Discovery, bool, error)
+
+	ServiceProvider(orgID string) saml.ServiceProvider
+	// BeginLogin records an AuthnRequest and returns the IdP redirect URL.
+	BeginLogin(orgID, relayState string) (string, error)
+	// CompleteLogin validates a posted SAMLResponse and returns the
+	// just-in-time provisioned (or linked) user.
+	CompleteLogin(orgID, samlResponse string) (user.User, bool, error) // (user, created)
+}
+
+type pgService struct {
+	db       *sql.DB
+	q        *repo.Queries
+	baseURL  string
+	resolver Resolver
+	now      func() time.Time
+}
+
+// NewPostgresService builds the SSO service. baseURL is the public URL of the
+// API (e.g. https://api.example.com) used for the SP entity ID and ACS URL.
+func NewPostgresService(db *sql.DB, q *repo.Queries, baseURL string, resolver Resolver) Service {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return &pgService{db: db, q: q, baseURL: strings.TrimRight(baseURL, "/"), resolver: resolver, now: time.Now}
+}
+
+func (s *pgService) GetConfig(orgID string) (Config, error) {
+	row, err := s.q.GetSAMLConfig(context.Background(), orgID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Config{}, ErrNotConfigured
+	}
+	if err != nil {
+		return Config{}, err
+	}
+	return toConfig(row), nil
+}
+
+func (s *pgService) SaveConfig(cfg Config) (Config, error) {
+	if cfg.IdPEntityID == "" || cfg.IdPSSOURL == "" || cfg.IdPCertificate == "" {
+		return Config{}, fmt.Errorf("%w: idp_entity_id, idp_sso_url and idp_certificate are required", ErrInvalidConfig)
+	}
+	if u, err := url.Parse(cfg.IdPSSOURL); err != nil || u.Scheme != "https" && u.Scheme != "http" || u.Host == "" {
+		return Config{}, fmt.Errorf("%w: idp_sso_url must be an absolute URL", ErrInvalidConfig)
+	}
+	if _, err := saml.ParseCertificate(cfg.IdPCertificate); err != nil {
+		return Config{}, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	for _, role := range cfg.AttributeMapping.RoleValues {
+		if role != "member" && role != "admin" {
+			return Config{}, fmt.Errorf("%w: mapped roles must be member or admin", ErrInvalidConfig)
+		}
+	}
+	mapping, err := json.Marshal(cfg.AttributeMapping)
+	if err != nil {
+		return Config{}, err
+	}
+
+	row, err := s.q.UpsertSAMLConfig(context.Background(), repo.UpsertSAMLConfigParams{
+		OrgID:            cfg.OrgID,
+		IdpEntityID:      cfg.IdPEntityID,
+		IdpSsoUrl:        cfg.IdPSSOURL,
+		IdpCertificate:   cfg.IdPCertificate,
+		AttributeMapping: mapping,
+		EnforceSso:       cfg.EnforceSSO,
+		Enabled:          cfg.Enabled,
+	})
+	if err != nil {
+		return Config{}, err
+	}
+	return toConfig(row), nil
+}
+
+func (s *pgService) ClaimDomain(orgID, domain string) (Domain, error) {
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return Domain{}, err
+	}
+	ctx := context.Background()
+
+	if _, err := s.q.GetOrgDomain(ctx, repo.GetOrgDomainParams{OrgID: orgID, Domain: domain}); err == nil {
+		return Domain{}, ErrDomainExists
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return Domain{}, err
+	}
+	if owner, err := s.q.GetVerifiedDomainSSO(ctx, domain); err == nil && owner.OrgID != orgID {
+		return Domain{}, ErrDomainTaken
+	} else if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return Domain{}, err
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return Domain{}, err
+	}
+	row, err := s.q.InsertOrgDomain(ctx, repo.InsertOrgDomainParams{OrgID: orgID, Domain: domain, VerificationToken: token})
+	if err != nil {
+		return Domain{}, err
+	}
+	return toDomain(row), nil
+}
+
+func (s *pgService) VerifyDomain(orgID, domain string) (Domain, error) {
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return Domain{}, err
+	}
+	ctx := context.Background()
+
+	row, err := s.q.GetOrgDomain(ctx, repo.GetOrgDomainParams{OrgID: orgID, Domain: domain})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Domain{}, ErrDomainNotFound
+	}
+	if err != nil {
+		return Domain{}, err
+	}
+	d := toDomain(row)
+	if d.VerifiedAt != nil {
+		return d, nil
+	}
+
+	if owner, err := s.q.GetVerifiedDomainSSO(ctx, domain); err == nil && owner.OrgID != orgID {
+		return Domain{}, ErrDomainTaken
+	} else if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return Domain{}, err
+	}
+
+	lctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	records, err := s.resolver.LookupTXT(lctx, d.TXTRecord())
+	if err != nil {
+		return Domain{}, ErrDomainUnverified
+	}
+	found := false
+	for _, r := range records {
+		if strings.TrimSpace(r) == d.TXTValue() {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return Domain{}, ErrDomainUnverified
+	}
+
+	row, err = s.q.MarkOrgDomainVerified(ctx, repo.MarkOrgDomainVerifiedParams{OrgID: orgID, Domain: domain})
+	if err != nil {
+		return Domain{}, err
+	}
+	return toDomain(row), nil
+}
+
+func (s *pgService) ListDomains(orgID string) ([]Domain, error) {
+	rows, err := s.q.ListOrgDomains(context.Background(), orgID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Domain, len(rows))
+	for i, r := range rows {
+		out[i] = toDomain(r)
+	}
+	return out, nil
+}
+
+func (s *pgService) DeleteDomain(orgID, domain string) error {
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return err
+	}
+	res, err := s.q.DeleteOrgDomain(context.Background(), repo.DeleteOrgDomainParams{OrgID: orgID, Domain: domain})
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrDomainNotFound
+	}
+	return nil
+}
+
+func (s *pgService) Discover(email string) (Discovery, bool, error) {
+	domain := emailDomain(email)
+	if domain == "" {
+		return Discovery{}, false, nil
+	}
+	row, err := s.q.GetVerifiedDomainSSO(context.Background(), domain)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Discovery{}, false, nil
+	}
+	if err != nil {
+		return Discovery{}, false, err
+	}
+	if !row.Enabled.Valid || !row.Enabled.Bool {
+		return Discovery{}, false, nil
+	}
+	return Discovery{OrgID: row.OrgID, Enforced: row.EnforceSso.Bool}, true, nil
+}
+
+func (s *pgService) ServiceProvider(orgID string) saml.ServiceProvider {
+	base := s.baseURL + "/v1/sso/saml/" + url.PathEscape(orgID)
+	return saml.ServiceProvider{EntityID: base + "/metadata", ACSURL: base + "/acs"}
+}
+
+func (s *pgService) BeginLogin(orgID, relayState string) (string, error) {
+	cfg, idp, err := s.enabledIdP(orgID)
+	if err != nil {
+		return "", err
+	}
+	id, err := saml.NewRequestID()
+	if err != nil {
+		return "", err
+	}
+	ctx := context.Background()
+
+	// best-effort cleanup of abandoned flows and expired replay entries
+	_ = s.q.DeleteExpiredSAMLRequests(ctx)
+	_ = s.q.DeleteExpiredSAMLAssertions(ctx)
+
+	now := s.now()
+	if err := s.q.InsertSAMLRequest(ctx, repo.InsertSAMLRequestParams{
+		ID:        id,
+		OrgID:     cfg.OrgID,
+		ExpiresAt: now.Add(requestTTL),
+	}); err != nil {
+		return "", err
+	}
+	return saml.AuthnRequestURL(s.ServiceProvider(orgID), idp, id, relayState, now)
+}
+
+func (s *pgService) CompleteLogin(orgID, samlResponse string) (user.User, bool, error) {
+	ctx := context.Background()
+	cfg, idp, err := s.enabledIdP(orgID)
+	if err != nil {
+		return user.User{}, false, err
+	}
+
+	a, err := saml.ParseResponse(samlResponse, s.ServiceProvider(orgID), idp, s.now())
+	if err != nil {
+		return user.User{}, false, err
+	}
+
+	// SP-initiated responses must answer one of our outstanding requests;
+	// IdP-initiated ones carry no InResponseTo.
+	if a.InResponseTo != "" {
+		if _, err := s.q.ConsumeSAMLRequest(ctx, repo.ConsumeSAMLRequestParams{ID: a.InResponseTo, OrgID: orgID}); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return user.User{}, false, ErrUnknownRequest
+			}
+			return user.User{}, false, err
+		}
+	}
+
+	exp := a.NotOnOrAfter
+	if exp.IsZero() {
+		exp = s.now().Add(requestTTL)
+	}
+	res, err := s.q.InsertSAMLAssertionSeen(ctx, repo.InsertSAMLAssertionSeenParams{
+		ID:        orgID + ":" + a.ID,
+		ExpiresAt: exp.Add(saml.MaxClockSkew),
+	})
+	if err != nil {
+		return user.User{}, false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return user.User{}, false, ErrReplay
+	}
+
+	email := a.NameID
+	if cfg.AttributeMapping.Email != "" {
+		email = a.Attribute(cfg.AttributeMapping.Email)
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	// The IdP is only trusted for addresses in domains the org has proven it owns.
+	domain := emailDomain(email)
+	if domain == "" {
+		return user.User{}, false, ErrEmailNotInDomain
+	}
+	owner, err := s.q.GetVerifiedDomainSSO(ctx, domain)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && owner.OrgID != orgID {
+		return user.User{}, false, ErrEmailNotInDomain
+	}
+	if err != nil {
+		return user.User{}, false, err
+	}
+
+	role, err := s.defaultRole(ctx, orgID)
+	if err != nil {
+		return user.User{}, false, err
+	}
+	if cfg.AttributeMapping.Role != "" {
+		if mapped, ok := cfg.AttributeMapping.RoleValues[a.Attribute(cfg.AttributeMapping.Role)]; ok {
+			role = mapped
+		}
+	}
+	return s.provision(ctx, orgID, a.NameID, email, role)
+}
+
+// defaultRole is the org's default_role setting, or member.
+func (s *pgService) defaultRole(ctx context.Context, orgID string) (string, error) {
+	o, err := s.q.GetOrg(ctx, orgID)
+	if err != nil {
+		return "", err
+	}
+	st, _ := org.ParseSettings(o.Settings)
+	return st.MemberRole(), nil
+}
+
+// provision links or creates the local user for a SAML subject and makes sure
+// it is a member of the org. Existing memberships are left untouched so an
+// IdP can never downgrade an owner.
+func (s *pgService) provision(ctx context.Context, orgID, subject, email, role string) (user.User, bool, error) {
+	provider := "saml:" + orgID
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return user.User{}, false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := s.q.WithTx(tx)
+
+	var u user.User
+	created := false
+
+	ident, err := qtx.GetIdentity(ctx, repo.GetIdentityParams{Provider: provider, Subject: subject})
+	switch {
+	case err == nil:
+		row, err := qtx.GetUserByID(ctx, ident.UserID)
+		if err != nil {
+			return user.User{}, false, err
+		}
+		u = user.User{ID: row.ID, Email: row.Email, EmailVerified: row.EmailVerifiedAt.Valid, PasswordHash: row.PasswordHash}
+	case errors.Is(err, sql.ErrNoRows):
+		row, err := qtx.GetUserByEmail(ctx, email)
+		switch {
+		case err == nil:
+			linked, err := qtx.ListIdentitiesByUser(ctx, row.ID)
+			if err != nil {
+				return user.User{}, false, err
+			}
+			for _, existing := range linked {
+				if existing.Provider == provider {
+					return user.User{}, false, ErrAccountConflict
+				}
+			}
+			u = user.User{ID: row.ID, Email: row.Email, EmailVerified: true, PasswordHash: row.PasswordHash}
+		case errors.Is(err, sql.ErrNoRows):
+			u = user.User{ID: uuid.NewString(), Email: email, EmailVerified: true}
+			if err := qtx.InsertUser(ctx, repo.InsertUserParams{ID: u.ID, Email: email, CreatedAt: time.Now()}); err != nil {
+				return user.User{}, false, err
+			}
+			created = true
+		default:
+			return user.User{}, false, err
+		}
+		if err := qtx.MarkUserEmailVerified(ctx, u.ID); err != nil {
+			return user.User{}, false, err
+		}
+		if err := qtx.InsertIdentity(ctx, repo.InsertIdentityParams{
+			UserID:   u.ID,
+			Provider: provider,
+			Subject:  subject,
+			Email:    sql.NullString{String: email, Valid: true},
+		}); err != nil {
+			return user.User{}, false, err
+		}
+	default:
+		return user.User{}, false, err
+	}
+
+	if _, err := qtx.InsertMemberIfAbsent(ctx, repo.InsertMemberIfAbsentParams{OrgID: orgID, UserID: u.ID, Role: role}); err != nil {
+		return user.User{}, false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return user.User{}, false, err
+	}
+	return u, created, nil
+}
+
+func (s *pgService) enabledIdP(orgID string) (Config, saml.IdentityProvider, error) {
+	cfg, err := s.GetConfig(orgID)
+	if err != nil {
+		return Config{}, saml.IdentityProvider{}, err
+	}
+	if !cfg.Enabled {
+		return Config{}, saml.IdentityProvider{}, ErrNotConfigured
+	}
+	cert, err := saml.ParseCertificate(cfg.IdPCertificate)
+	if err != nil {
+		return Config{}, saml.IdentityProvider{}, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	return cfg, saml.IdentityProvider{EntityID: cfg.IdPEntityID, SSOURL: cfg.IdPSSOURL, Certificate: cert}, nil
+}
+
+func toConfig(r repo.OrgSamlConfig) Config {
+	c := Config{
+		OrgID:          r.OrgID,
+		IdPEntityID:    r.IdpEntityID,
+		IdPSSOURL:      r.IdpSsoUrl,
+		IdPCertificate: r.IdpCertificate,
+		EnforceSSO:     r.EnforceSso,
+		Enabled:        r.Enabled,
+		CreatedAt:      r.CreatedAt,
+		UpdatedAt:      r.UpdatedAt,
+	}
+	_ = json.Unmarshal(r.AttributeMapping, &c.AttributeMapping)
+	return c
+}
+
+func toDomain(r repo.OrgDomain) Domain {
+	d := Domain{
+		OrgID:             r.OrgID,
+		Domain:            r.Domain,
+		VerificationToken: r.VerificationToken,
+		CreatedAt:         r.CreatedAt,
+	}
+	if r.VerifiedAt.Valid {
+		t := r.VerifiedAt.Time
+		d.VerifiedAt = &t
+	}
+	return d
+}
+
+func normalizeDomain(d string) (string, error) {
+	d = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(d)), ".")
+	if len(d) < 3 || len(d) > 253 || !strings.Contains(d, ".") || strings.ContainsAny(d, "@/: ") {
+		return "", ErrInvalidDomain
+	}
+	return d, nil
+}
+
+func emailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 1 || at == len(email)-1 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(email[at+1:]))
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
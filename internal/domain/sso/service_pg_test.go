@@ -0,0 +1,88 @@
+//go:build integration
+
+package sso_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Ulpio/vergo/internal/domain/org"
+	"github.com/Ulpio/vergo/internal/domain/sso"
+	"github.com/Ulpio/vergo/internal/domain/user"
+	"github.com/Ulpio/vergo/internal/pkg/testutil"
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+type fakeResolver map[string][]string
+
+func (f fakeResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	if v, ok := f[name]; ok {
+		return v, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func setupSSO(t *testing.T) (sso.Service, fakeResolver, org.Organization, org.Organization) {
+	t.Helper()
+	db := testutil.PGContainer(t)
+	q := repo.New(db)
+	u, err := user.NewPostgresService(db, q, nil).Signup("admin@test.com", "pass123")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	orgSvc := org.NewPostgresService(db, q)
+	a, _ := orgSvc.Create("Acme", u.ID)
+	b, _ := orgSvc.Create("Other", u.ID)
+
+	res := fakeResolver{}
+	return sso.NewPostgresService(db, q, "https://api.test", res), res, a, b
+}
+
+func TestPGService_DomainVerificationAndDiscovery(t *testing.T) {
+	svc, res, acme, other := setupSSO(t)
+
+	d, err := svc.ClaimDomain(acme.ID, "Acme.COM")
+	if err != nil {
+		t.Fatalf("ClaimDomain: %v", err)
+	}
+	if d.Domain != "acme.com" || d.VerifiedAt != nil {
+		t.Fatalf("unexpected domain: %+v", d)
+	}
+
+	if _, err := svc.VerifyDomain(acme.ID, "acme.com"); !errors.Is(err, sso.ErrDomainUnverified) {
+		t.Fatalf("verify without record: err = %v", err)
+	}
+	res[d.TXTRecord()] = []string{"unrelated", d.TXTValue()}
+	if d, err = svc.VerifyDomain(acme.ID, "acme.com"); err != nil || d.VerifiedAt == nil {
+		t.Fatalf("VerifyDomain: %+v, %v", d, err)
+	}
+
+	// Another org cannot claim a verified domain.
+	if _, err := svc.ClaimDomain(other.ID, "acme.com"); !errors.Is(err, sso.ErrDomainTaken) {
+		t.Errorf("claim taken domain: err = %v", err)
+	}
+
+	// No SAML config yet: no SSO for the domain.
+	if _, ok, _ := svc.Discover("bob@acme.com"); ok {
+		t.Error("discovered SSO without configuration")
+	}
+}
+
+func TestPGService_SaveConfigValidates(t *testing.T) {
+	svc, _, acme, _ := setupSSO(t)
+
+	_, err := svc.SaveConfig(sso.Config{
+		OrgID:          acme.ID,
+		IdPEntityID:    "https://idp",
+		IdPSSOURL:      "https://idp/sso",
+		IdPCertificate: "not a certificate",
+		Enabled:        true,
+	})
+	if !errors.Is(err, sso.ErrInvalidConfig) {
+		t.Errorf("err = %v, want ErrInvalidConfig", err)
+	}
+	if _, err := svc.GetConfig(acme.ID); !errors.Is(err, sso.ErrNotConfigured) {
+		t.Errorf("GetConfig err = %v, want ErrNotConfigured", err)
+	}
+}
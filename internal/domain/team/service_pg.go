@@ -0,0 +1,150 @@
+package team
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Ulpio/vergo/internal/repo"
+)
+
+var (
+	ErrNotFound  = errors.New("team not found")
+	ErrNameTaken = errors.New("team name already in use")
+	ErrNotMember = errors.New("user is not a member of the org")
+	ErrNotInTeam = errors.New("user is not in the team")
+	ErrEmptyName = errors.New("team name is required")
+)
+
+type Service interface {
+	List(orgID string) ([]Team, error)
+	Create(orgID, name, description string) (Team, error)
+	Get(orgID, id string) (Team, error)
+	Delete(orgID, id string) error
+
+	// AddMember adds userID, who must belong to the org, to the team.
+	AddMember(orgID, teamID, userID string) error
+	RemoveMember(orgID, teamID, userID string) error
+	ListMembers(orgID, teamID string) ([]Member, error)
+}
+
+type pgService struct {
+	q *repo.Queries
+}
+
+func NewPostgresService(q *repo.Queries) Service {
+	return &pgService{q: q}
+}
+
+func repoToTeam(r repo.Team) Team {
+	return Team{
+		ID:          r.ID,
+		OrgID:       r.OrgID,
+		Name:        r.Name,
+		Description: r.Description,
+		CreatedAt:   r.CreatedAt,
+	}
+}
+
+func (s *pgService) List(orgID string) ([]Team, error) {
+	rows, err := s.q.ListTeams(context.Background(), orgID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Team, len(rows))
+	for i, r := range rows {
+		out[i] = Team{
+			ID:          r.ID,
+			OrgID:       r.OrgID,
+			Name:        r.Name,
+			Description: r.Description,
+			MemberCount: r.MemberCount,
+			CreatedAt:   r.CreatedAt,
+		}
+	}
+	return out, nil
+}
+
+func (s *pgService) Create(orgID, name, description string) (Team, error) {
+	if name == "" {
+		return Team{}, ErrEmptyName
+	}
+	r, err := s.q.CreateTeam(context.Background(), repo.CreateTeamParams{
+		OrgID:       orgID,
+		Name:        name,
+		Description: description,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Team{}, ErrNameTaken
+	}
+	if err != nil {
+		return Team{}, err
+	}
+	return repoToTeam(r), nil
+}
+
+func (s *pgService) Get(orgID, id string) (Team, error) {
+	r, err := s.q.GetTeam(context.Background(), repo.GetTeamParams{ID: id, OrgID: orgID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Team{}, ErrNotFound
+	}
+	if err != nil {
+		return Team{}, err
+	}
+	return repoToTeam(r), nil
+}
+
+func (s *pgService) Delete(orgID, id string) error {
+	n, err := s.q.DeleteTeam(context.Background(), repo.DeleteTeamParams{ID: id, OrgID: orgID})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *pgService) AddMember(orgID, teamID, userID string) error {
+	ctx := context.Background()
+	if _, err := s.Get(orgID, teamID); err != nil {
+		return err
+	}
+	_, err := s.q.GetMemberRole(ctx, repo.GetMemberRoleParams{OrgID: orgID, UserID: userID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotMember
+	}
+	if err != nil {
+		return err
+	}
+	return s.q.AddTeamMember(ctx, repo.AddTeamMemberParams{TeamID: teamID, UserID: userID})
+}
+
+func (s *pgService) RemoveMember(orgID, teamID, userID string) error {
+	if _, err := s.Get(orgID, teamID); err != nil {
+		return err
+	}
+	n, err := s.q.RemoveTeamMember(context.Background(), repo.RemoveTeamMemberParams{TeamID: teamID, UserID: userID})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotInTeam
+	}
+	return nil
+}
+
+func (s *pgService) ListMembers(orgID, teamID string) ([]Member, error) {
+	if _, err := s.Get(orgID, teamID); err != nil {
+		return nil, err
+	}
+	rows, err := s.q.ListTeamMembers(context.Background(), teamID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Member, len(rows))
+	for i, r := range rows {
+		out[i] = Member{UserID: r.UserID, Email: r.Email, DisplayName: r.DisplayName, AddedAt: r.CreatedAt}
+	}
+	return out, nil
+}
@@ -1,7 +1,24 @@
 package user
 
 type User struct {
-	ID           string `json:"id"`
-	Email        string `json:"email"`
-	PasswordHash string `json:"-"`
+	ID            string `json:"id"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	PasswordHash  string `json:"-"`
+	Suspended     bool   `json:"-"` // by a platform admin; sign-in is refused
+
+	// Profile
+	DisplayName  string `json:"display_name"`
+	AvatarFileID string `json:"avatar_file_id,omitempty"` // files.id uploaded by the user
+	Locale       string `json:"locale,omitempty"`         // BCP 47, e.g. "pt-BR"
+	Timezone     string `json:"timezone,omitempty"`       // IANA, e.g. "America/Sao_Paulo"
+}
+
+// ProfileUpdate is a partial profile change; nil fields are left as they
+// are and an empty string clears the field.
+type ProfileUpdate struct {
+	DisplayName  *string
+	AvatarFileID *string
+	Locale       *string
+	Timezone     *string
 }
@@ -2,40 +2,118 @@ package user
 
 import (
 	"errors"
+	"regexp"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Ulpio/vergo/internal/auth/password"
 )
 
 var (
-	ErrEmailInUse   = errors.New("email already in use")
-	ErrInvalidLogin = errors.New("invalid email or password")
-	ErrNotFound     = errors.New("user not found")
+	ErrEmailInUse      = errors.New("email already in use")
+	ErrInvalidLogin    = errors.New("invalid email or password")
+	ErrSuspended       = errors.New("account suspended")
+	ErrNotFound        = errors.New("user not found")
+	ErrAvatarNotFound  = errors.New("avatar file not found")
+	ErrInvalidLocale   = errors.New("invalid locale")
+	ErrInvalidTimezone = errors.New("invalid timezone")
 )
 
+// DeletedUserID is the placeholder that projects, files, API keys and audit
+// logs of deleted accounts are reassigned to.
+const DeletedUserID = "deleted-user"
+
+// SoleOwnerError blocks account deletion while the user is the only owner
+// of some orgs; ownership must be transferred or the orgs deleted first.
+type SoleOwnerError struct {
+	OrgIDs []string
+}
+
+func (e *SoleOwnerError) Error() string {
+	return "user is the sole owner of " + strings.Join(e.OrgIDs, ", ")
+}
+
 type Service interface {
 	Signup(email, password string) (User, error)
 	Login(email, password string) (User, error)
 	GetByID(id string) (User, error)
 	GetByEmail(email string) (User, error)
-	UpdatePassword(userID, newPasswordHash string) error
+	// SetPassword hashes and stores a new password for the user.
+	SetPassword(userID, newPassword string) error
+
+	UpdateProfile(userID string, p ProfileUpdate) (User, error)
+	// ChangeEmail replaces the (already verified) email address.
+	ChangeEmail(userID, newEmail string) error
+	// Delete removes the account. It fails with *SoleOwnerError while the
+	// user is the only owner of an org.
+	Delete(userID string) error
+}
+
+var localeRe = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
+// validateProfile checks the fields of p that do not need storage.
+func validateProfile(p ProfileUpdate) error {
+	if p.Locale != nil && *p.Locale != "" && !localeRe.MatchString(*p.Locale) {
+		return ErrInvalidLocale
+	}
+	if p.Timezone != nil && *p.Timezone != "" {
+		if _, err := time.LoadLocation(*p.Timezone); err != nil || *p.Timezone == "Local" {
+			return ErrInvalidTimezone
+		}
+	}
+	return nil
+}
+
+// apply merges p into u.
+func (p ProfileUpdate) apply(u *User) {
+	if p.DisplayName != nil {
+		u.DisplayName = strings.TrimSpace(*p.DisplayName)
+	}
+	if p.AvatarFileID != nil {
+		u.AvatarFileID = *p.AvatarFileID
+	}
+	if p.Locale != nil {
+		u.Locale = *p.Locale
+	}
+	if p.Timezone != nil {
+		u.Timezone = *p.Timezone
+	}
+}
+
+// defaultHasher is used when a service is built with a nil hasher.
+func defaultHasher(h password.Hasher) password.Hasher {
+	if h == nil {
+		h, _ = password.NewHasher(password.DefaultArgon2Params)
+	}
+	return h
 }
 
 type memoryRepo struct {
 	mu      sync.RWMutex
+	hasher  password.Hasher
 	byID    map[string]User
 	byEmail map[string]string // email -> id
 }
 
-func NewMemoryService() Service {
+// NewMemoryService returns an in-memory Service; a nil hasher uses argon2id
+// with the default parameters.
+func NewMemoryService(h password.Hasher) Service {
 	return &memoryRepo{
+		hasher:  defaultHasher(h),
 		byID:    make(map[string]User),
 		byEmail: make(map[string]string),
 	}
 }
 
-func (m *memoryRepo) Signup(email, password string) (User, error) {
+func (m *memoryRepo) Signup(email, pw string) (User, error) {
+	hash, err := m.hasher.Hash(pw)
+	if err != nil {
+		return User{}, err
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -43,26 +121,33 @@ func (m *memoryRepo) Signup(email, password string) (User, error) {
 		return User{}, ErrEmailInUse
 	}
 	id := uuid.NewString()
-	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-
-	u := User{ID: id, Email: email, PasswordHash: string(hash)}
+	u := User{ID: id, Email: email, PasswordHash: hash}
 	m.byID[id] = u
 	m.byEmail[email] = id
 	return u, nil
 }
 
-func (m *memoryRepo) Login(email, password string) (User, error) {
+func (m *memoryRepo) Login(email, pw string) (User, error) {
 	m.mu.RLock()
-	defer m.mu.RUnlock()
-
 	id, ok := m.byEmail[email]
+	u := m.byID[id]
+	m.mu.RUnlock()
 	if !ok {
 		return User{}, ErrInvalidLogin
 	}
-	u := m.byID[id]
-	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)); err != nil {
+
+	valid, rehash, err := m.hasher.Verify(pw, u.PasswordHash)
+	if err != nil || !valid {
 		return User{}, ErrInvalidLogin
 	}
+	if rehash {
+		if hash, err := m.hasher.Hash(pw); err == nil {
+			m.mu.Lock()
+			u.PasswordHash = hash
+			m.byID[id] = u
+			m.mu.Unlock()
+		}
+	}
 	return u, nil
 }
 
@@ -86,14 +171,66 @@ func (m *memoryRepo) GetByEmail(email string) (User, error) {
 	return m.byID[id], nil
 }
 
-func (m *memoryRepo) UpdatePassword(userID, newPasswordHash string) error {
+func (m *memoryRepo) SetPassword(userID, newPassword string) error {
+	hash, err := m.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	u, ok := m.byID[userID]
 	if !ok {
 		return ErrNotFound
 	}
-	u.PasswordHash = newPasswordHash
+	u.PasswordHash = hash
 	m.byID[userID] = u
 	return nil
 }
+
+func (m *memoryRepo) UpdateProfile(userID string, p ProfileUpdate) (User, error) {
+	if err := validateProfile(p); err != nil {
+		return User{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.byID[userID]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	if p.AvatarFileID != nil && *p.AvatarFileID != "" {
+		return User{}, ErrAvatarNotFound // no file storage in memory
+	}
+	p.apply(&u)
+	m.byID[userID] = u
+	return u, nil
+}
+
+func (m *memoryRepo) ChangeEmail(userID, newEmail string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.byID[userID]
+	if !ok {
+		return ErrNotFound
+	}
+	if id, taken := m.byEmail[newEmail]; taken && id != userID {
+		return ErrEmailInUse
+	}
+	delete(m.byEmail, u.Email)
+	u.Email, u.EmailVerified = newEmail, true
+	m.byID[userID] = u
+	m.byEmail[newEmail] = userID
+	return nil
+}
+
+func (m *memoryRepo) Delete(userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.byID[userID]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(m.byID, userID)
+	delete(m.byEmail, u.Email)
+	return nil
+}
@@ -4,25 +4,29 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 
+	"github.com/Ulpio/vergo/internal/auth/password"
 	"github.com/Ulpio/vergo/internal/repo"
 )
 
 type pgService struct {
-	db *sql.DB
-	q  *repo.Queries
+	db     *sql.DB
+	q      *repo.Queries
+	hasher password.Hasher
 }
 
-func NewPostgresService(db *sql.DB, q *repo.Queries) Service {
-	return &pgService{db: db, q: q}
+// NewPostgresService returns a Postgres-backed Service; a nil hasher uses
+// argon2id with the default parameters.
+func NewPostgresService(db *sql.DB, q *repo.Queries, h password.Hasher) Service {
+	return &pgService{db: db, q: q, hasher: defaultHasher(h)}
 }
 
-func (s *pgService) Signup(email, password string) (User, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+func (s *pgService) Signup(email, pw string) (User, error) {
+	hash, err := s.hasher.Hash(pw)
 	if err != nil {
 		return User{}, err
 	}
@@ -30,16 +34,18 @@ func (s *pgService) Signup(email, password string) (User, error) {
 	err = s.q.InsertUser(context.Background(), repo.InsertUserParams{
 		ID:           id,
 		Email:        email,
-		PasswordHash: string(hash),
+		PasswordHash: hash,
 		CreatedAt:    time.Now(),
 	})
 	if err != nil {
 		return User{}, err
 	}
-	return User{ID: id, Email: email, PasswordHash: string(hash)}, nil
+	return User{ID: id, Email: email, PasswordHash: hash}, nil
 }
 
-func (s *pgService) Login(email, password string) (User, error) {
+// Login verifies the password and, when the stored hash uses an outdated
+// algorithm or parameters (e.g. legacy bcrypt), replaces it with a fresh one.
+func (s *pgService) Login(email, pw string) (User, error) {
 	row, err := s.q.GetUserByEmail(context.Background(), email)
 	if errors.Is(err, sql.ErrNoRows) {
 		return User{}, ErrInvalidLogin
@@ -47,10 +53,29 @@ func (s *pgService) Login(email, password string) (User, error) {
 	if err != nil {
 		return User{}, err
 	}
-	if err := bcrypt.CompareHashAndPassword([]byte(row.PasswordHash), []byte(password)); err != nil {
+	valid, rehash, err := s.hasher.Verify(pw, row.PasswordHash)
+	if err != nil {
+		slog.Error("login: verify password hash", "user_id", row.ID, "error", err)
+		return User{}, ErrInvalidLogin
+	}
+	if !valid {
 		return User{}, ErrInvalidLogin
 	}
-	return User{ID: row.ID, Email: row.Email, PasswordHash: row.PasswordHash}, nil
+	// only revealed once the password checked out
+	if row.SuspendedAt.Valid {
+		return User{}, ErrSuspended
+	}
+	if rehash {
+		if hash, err := s.hasher.Hash(pw); err == nil {
+			err = s.q.UpdateUserPassword(context.Background(), repo.UpdateUserPasswordParams{ID: row.ID, PasswordHash: hash})
+			if err == nil {
+				row.PasswordHash = hash
+			} else {
+				slog.Error("login: rehash password", "user_id", row.ID, "error", err)
+			}
+		}
+	}
+	return toUser(repo.GetUserByIDRow(row)), nil
 }
 
 func (s *pgService) GetByID(id string) (User, error) {
@@ -61,7 +86,7 @@ func (s *pgService) GetByID(id string) (User, error) {
 	if err != nil {
 		return User{}, err
 	}
-	return User{ID: row.ID, Email: row.Email, PasswordHash: row.PasswordHash}, nil
+	return toUser(row), nil
 }
 
 func (s *pgService) GetByEmail(email string) (User, error) {
@@ -72,12 +97,124 @@ func (s *pgService) GetByEmail(email string) (User, error) {
 	if err != nil {
 		return User{}, err
 	}
-	return User{ID: row.ID, Email: row.Email, PasswordHash: row.PasswordHash}, nil
+	return toUser(repo.GetUserByIDRow(row)), nil
 }
 
-func (s *pgService) UpdatePassword(userID, newPasswordHash string) error {
+func (s *pgService) SetPassword(userID, newPassword string) error {
+	hash, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
 	return s.q.UpdateUserPassword(context.Background(), repo.UpdateUserPasswordParams{
 		ID:           userID,
-		PasswordHash: newPasswordHash,
+		PasswordHash: hash,
+	})
+}
+
+func toUser(r repo.GetUserByIDRow) User {
+	return User{
+		ID:            r.ID,
+		Email:         r.Email,
+		EmailVerified: r.EmailVerifiedAt.Valid,
+		PasswordHash:  r.PasswordHash,
+		DisplayName:   r.DisplayName,
+		AvatarFileID:  r.AvatarFileID.String,
+		Locale:        r.Locale,
+		Timezone:      r.Timezone,
+		Suspended:     r.SuspendedAt.Valid,
+	}
+}
+
+func (s *pgService) UpdateProfile(userID string, p ProfileUpdate) (User, error) {
+	if err := validateProfile(p); err != nil {
+		return User{}, err
+	}
+	ctx := context.Background()
+	u, err := s.GetByID(userID)
+	if err != nil {
+		return User{}, err
+	}
+	if p.AvatarFileID != nil && *p.AvatarFileID != "" {
+		ok, err := s.q.IsFileUploadedBy(ctx, repo.IsFileUploadedByParams{ID: *p.AvatarFileID, UploadedBy: userID})
+		if err != nil {
+			return User{}, err
+		}
+		if !ok {
+			return User{}, ErrAvatarNotFound
+		}
+	}
+	p.apply(&u)
+	err = s.q.UpdateUserProfile(ctx, repo.UpdateUserProfileParams{
+		ID:           userID,
+		DisplayName:  u.DisplayName,
+		AvatarFileID: sql.NullString{String: u.AvatarFileID, Valid: u.AvatarFileID != ""},
+		Locale:       u.Locale,
+		Timezone:     u.Timezone,
 	})
+	if err != nil {
+		return User{}, err
+	}
+	return u, nil
+}
+
+func (s *pgService) ChangeEmail(userID, newEmail string) error {
+	ctx := context.Background()
+	other, err := s.q.GetUserByEmail(ctx, newEmail)
+	if err == nil && other.ID != userID {
+		return ErrEmailInUse
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	return s.q.UpdateUserEmail(ctx, repo.UpdateUserEmailParams{ID: userID, Email: newEmail})
+}
+
+// Delete removes the user in one transaction. Projects, files, API keys and
+// audit logs are kept for their orgs but reassigned to DeletedUserID, and
+// the IP and user agent recorded in the user's audit events are dropped;
+// memberships, tokens, sessions and identities cascade with the user row.
+func (s *pgService) Delete(userID string) error {
+	if userID == DeletedUserID {
+		return ErrNotFound
+	}
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := s.q.WithTx(tx)
+
+	row, err := qtx.GetUserByID(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	sole, err := qtx.ListSoleOwnedOrgs(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if len(sole) > 0 {
+		return &SoleOwnerError{OrgIDs: sole}
+	}
+
+	steps := []func() error{
+		func() error { return qtx.ReassignOrgOwner(ctx, userID) },
+		func() error { return qtx.AnonymizeProjectsCreatedBy(ctx, userID) },
+		func() error { return qtx.AnonymizeFilesUploadedBy(ctx, userID) },
+		func() error { return qtx.AnonymizeAPIKeysCreatedBy(ctx, userID) },
+		func() error { return qtx.AnonymizeAuditActor(ctx, userID) },
+		func() error {
+			return qtx.AnonymizeAuditSubject(ctx, repo.AnonymizeAuditSubjectParams{UserID: userID, Email: row.Email})
+		},
+		func() error { return qtx.DeleteUser(ctx, userID) },
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
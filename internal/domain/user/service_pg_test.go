@@ -3,8 +3,17 @@
 package user_test
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
 
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Ulpio/vergo/internal/domain/apikey"
+	"github.com/Ulpio/vergo/internal/domain/file"
+	"github.com/Ulpio/vergo/internal/domain/org"
+	"github.com/Ulpio/vergo/internal/domain/project"
 	"github.com/Ulpio/vergo/internal/domain/user"
 	"github.com/Ulpio/vergo/internal/pkg/testutil"
 	"github.com/Ulpio/vergo/internal/repo"
@@ -12,7 +21,7 @@ import (
 
 func TestPGService_Signup(t *testing.T) {
 	db := testutil.PGContainer(t)
-	svc := user.NewPostgresService(db, repo.New(db))
+	svc := user.NewPostgresService(db, repo.New(db), nil)
 
 	u, err := svc.Signup("alice@example.com", "password123")
 	if err != nil {
@@ -28,7 +37,7 @@ func TestPGService_Signup(t *testing.T) {
 
 func TestPGService_SignupDuplicate(t *testing.T) {
 	db := testutil.PGContainer(t)
-	svc := user.NewPostgresService(db, repo.New(db))
+	svc := user.NewPostgresService(db, repo.New(db), nil)
 
 	_, err := svc.Signup("dup@example.com", "pass123")
 	if err != nil {
@@ -42,7 +51,7 @@ func TestPGService_SignupDuplicate(t *testing.T) {
 
 func TestPGService_Login(t *testing.T) {
 	db := testutil.PGContainer(t)
-	svc := user.NewPostgresService(db, repo.New(db))
+	svc := user.NewPostgresService(db, repo.New(db), nil)
 
 	_, err := svc.Signup("bob@example.com", "secretpass")
 	if err != nil {
@@ -60,7 +69,7 @@ func TestPGService_Login(t *testing.T) {
 
 func TestPGService_LoginWrongPassword(t *testing.T) {
 	db := testutil.PGContainer(t)
-	svc := user.NewPostgresService(db, repo.New(db))
+	svc := user.NewPostgresService(db, repo.New(db), nil)
 
 	_, _ = svc.Signup("charlie@example.com", "correct")
 	_, err := svc.Login("charlie@example.com", "wrong")
@@ -71,7 +80,7 @@ func TestPGService_LoginWrongPassword(t *testing.T) {
 
 func TestPGService_GetByID(t *testing.T) {
 	db := testutil.PGContainer(t)
-	svc := user.NewPostgresService(db, repo.New(db))
+	svc := user.NewPostgresService(db, repo.New(db), nil)
 
 	created, _ := svc.Signup("dave@example.com", "pass")
 	found, err := svc.GetByID(created.ID)
@@ -85,10 +94,120 @@ func TestPGService_GetByID(t *testing.T) {
 
 func TestPGService_GetByID_NotFound(t *testing.T) {
 	db := testutil.PGContainer(t)
-	svc := user.NewPostgresService(db, repo.New(db))
+	svc := user.NewPostgresService(db, repo.New(db), nil)
 
 	_, err := svc.GetByID("nonexistent-id")
 	if err == nil {
 		t.Error("expected error for nonexistent user")
 	}
 }
+
+func TestPGService_LoginUpgradesBcrypt(t *testing.T) {
+	db := testutil.PGContainer(t)
+	q := repo.New(db)
+	svc := user.NewPostgresService(db, q, nil)
+
+	u, _ := svc.Signup("legacy@example.com", "old-password")
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("old-password"), bcrypt.DefaultCost)
+	_ = q.UpdateUserPassword(context.Background(), repo.UpdateUserPasswordParams{ID: u.ID, PasswordHash: string(legacy)})
+
+	if _, err := svc.Login("legacy@example.com", "old-password"); err != nil {
+		t.Fatalf("Login with bcrypt hash: %v", err)
+	}
+	got, _ := svc.GetByID(u.ID)
+	if !strings.HasPrefix(got.PasswordHash, "$argon2id$") {
+		t.Errorf("hash after login = %q, want argon2id", got.PasswordHash)
+	}
+}
+
+func TestPGService_Delete(t *testing.T) {
+	db := testutil.PGContainer(t)
+	q := repo.New(db)
+	svc := user.NewPostgresService(db, q, nil)
+	orgs := org.NewPostgresService(db, q)
+	projects := project.NewPostgresService(db, q)
+
+	owner, _ := svc.Signup("owner@example.com", "password")
+	other, _ := svc.Signup("other@example.com", "password")
+	o, err := orgs.Create("Acme", owner.ID)
+	if err != nil {
+		t.Fatalf("create org: %v", err)
+	}
+	p, err := projects.Create(o.ID, project.Draft{Name: "Site"}, project.Actor{UserID: owner.ID})
+	if err != nil {
+		t.Fatalf("create project: %v", err)
+	}
+	keys := apikey.NewService(q)
+	key, err := keys.Create(o.ID, owner.ID, "ci", "member", nil)
+	if err != nil {
+		t.Fatalf("create api key: %v", err)
+	}
+	files := file.NewPostgresService(db, q)
+	f, err := files.Create(o.ID, owner.ID, p.ID, "bucket", o.ID+"/logo.png", nil, "image/png", nil)
+	if err != nil {
+		t.Fatalf("create file: %v", err)
+	}
+
+	// Sole owner: blocked.
+	err = svc.Delete(owner.ID)
+	var sole *user.SoleOwnerError
+	if !errors.As(err, &sole) || len(sole.OrgIDs) != 1 || sole.OrgIDs[0] != o.ID {
+		t.Fatalf("Delete sole owner: err = %v", err)
+	}
+
+	// With a second owner the account goes and its data is reassigned.
+	_ = orgs.AddMember(o.ID, other.ID, "owner")
+	if err := svc.Delete(owner.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := svc.GetByID(owner.ID); err != user.ErrNotFound {
+		t.Errorf("GetByID after delete: err = %v", err)
+	}
+	// reassigned rows move to a new version, so stale If-Match writes fail
+	if got, _ := projects.Get(o.ID, p.ID, project.Actor{AllProjects: true}); got.CreatedBy != user.DeletedUserID || got.Version <= p.Version {
+		t.Errorf("project created_by = %q version %d, want %q after version %d", got.CreatedBy, got.Version, user.DeletedUserID, p.Version)
+	}
+	if got, _ := keys.Get(o.ID, key.ID); got.CreatedBy != user.DeletedUserID {
+		t.Errorf("api key created_by = %q, want %q", got.CreatedBy, user.DeletedUserID)
+	}
+	if got, _ := files.Get(o.ID, f.ID); got.UploadedBy != user.DeletedUserID || got.Version <= f.Version {
+		t.Errorf("file uploaded_by = %q version %d, want %q after version %d", got.UploadedBy, got.Version, user.DeletedUserID, f.Version)
+	}
+	if got, _ := orgs.Get(o.ID); got.OwnerUser != other.ID || got.Version <= o.Version {
+		t.Errorf("org owner = %q version %d, want %q after version %d", got.OwnerUser, got.Version, other.ID, o.Version)
+	}
+	if err := svc.Delete(user.DeletedUserID); err != user.ErrNotFound {
+		t.Errorf("Delete placeholder: err = %v", err)
+	}
+}
+
+func TestPGService_ProfileAndEmail(t *testing.T) {
+	db := testutil.PGContainer(t)
+	svc := user.NewPostgresService(db, repo.New(db), nil)
+
+	u, _ := svc.Signup("eve@example.com", "password")
+	_, _ = svc.Signup("taken@example.com", "password")
+
+	name, tz := "Eve", "Europe/Lisbon"
+	if _, err := svc.UpdateProfile(u.ID, user.ProfileUpdate{DisplayName: &name, Timezone: &tz}); err != nil {
+		t.Fatalf("UpdateProfile: %v", err)
+	}
+	avatar := "missing-file"
+	if _, err := svc.UpdateProfile(u.ID, user.ProfileUpdate{AvatarFileID: &avatar}); err != user.ErrAvatarNotFound {
+		t.Errorf("foreign avatar: err = %v, want ErrAvatarNotFound", err)
+	}
+	got, _ := svc.GetByID(u.ID)
+	if got.DisplayName != "Eve" || got.Timezone != "Europe/Lisbon" {
+		t.Errorf("profile = %+v", got)
+	}
+
+	if err := svc.ChangeEmail(u.ID, "taken@example.com"); err != user.ErrEmailInUse {
+		t.Errorf("taken email: err = %v, want ErrEmailInUse", err)
+	}
+	if err := svc.ChangeEmail(u.ID, "eve@new.example.com"); err != nil {
+		t.Fatalf("ChangeEmail: %v", err)
+	}
+	if got, _ := svc.GetByID(u.ID); got.Email != "eve@new.example.com" || !got.EmailVerified {
+		t.Errorf("after change = %+v", got)
+	}
+}
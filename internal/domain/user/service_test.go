@@ -0,0 +1,126 @@
+package user
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Ulpio/vergo/internal/auth/password"
+)
+
+var testHasher, _ = password.NewHasher(password.Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLen: 16, KeyLen: 32})
+
+func TestMemoryService_LoginUpgradesBcrypt(t *testing.T) {
+	svc := NewMemoryService(testHasher)
+	u, err := svc.Signup("legacy@example.com", "old-password")
+	if err != nil {
+		t.Fatalf("Signup: %v", err)
+	}
+	if !strings.HasPrefix(u.PasswordHash, "$argon2id$") {
+		t.Fatalf("signup hash = %q, want argon2id", u.PasswordHash)
+	}
+
+	// Simulate a user created before argon2id.
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("old-password"), bcrypt.MinCost)
+	m := svc.(*memoryRepo)
+	stored := m.byID[u.ID]
+	stored.PasswordHash = string(legacy)
+	m.byID[u.ID] = stored
+
+	if _, err := svc.Login("legacy@example.com", "wrong"); err != ErrInvalidLogin {
+		t.Fatalf("wrong password: err = %v", err)
+	}
+	if got, _ := svc.GetByID(u.ID); got.PasswordHash != string(legacy) {
+		t.Fatal("failed login rehashed the password")
+	}
+	if _, err := svc.Login("legacy@example.com", "old-password"); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	got, _ := svc.GetByID(u.ID)
+	if !strings.HasPrefix(got.PasswordHash, "$argon2id$") {
+		t.Errorf("hash after login = %q, want argon2id", got.PasswordHash)
+	}
+	if _, err := svc.Login("legacy@example.com", "old-password"); err != nil {
+		t.Errorf("Login after upgrade: %v", err)
+	}
+}
+
+func TestMemoryService_SetPassword(t *testing.T) {
+	svc := NewMemoryService(testHasher)
+	u, _ := svc.Signup("reset@example.com", "first-password")
+	if err := svc.SetPassword(u.ID, "second-password"); err != nil {
+		t.Fatalf("SetPassword: %v", err)
+	}
+	if _, err := svc.Login("reset@example.com", "first-password"); err == nil {
+		t.Error("old password still accepted")
+	}
+	if _, err := svc.Login("reset@example.com", "second-password"); err != nil {
+		t.Errorf("new password rejected: %v", err)
+	}
+	if err := svc.SetPassword("missing", "x"); err != ErrNotFound {
+		t.Errorf("unknown user: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemoryService_UpdateProfile(t *testing.T) {
+	svc := NewMemoryService(testHasher)
+	u, _ := svc.Signup("profile@example.com", "password")
+
+	name, locale, tz := "  Ana Silva ", "pt-BR", "America/Sao_Paulo"
+	got, err := svc.UpdateProfile(u.ID, ProfileUpdate{DisplayName: &name, Locale: &locale, Timezone: &tz})
+	if err != nil {
+		t.Fatalf("UpdateProfile: %v", err)
+	}
+	if got.DisplayName != "Ana Silva" || got.Locale != "pt-BR" || got.Timezone != "America/Sao_Paulo" {
+		t.Errorf("profile = %+v", got)
+	}
+
+	// Omitted fields are kept, empty strings clear.
+	empty := ""
+	got, _ = svc.UpdateProfile(u.ID, ProfileUpdate{Locale: &empty})
+	if got.DisplayName != "Ana Silva" || got.Locale != "" {
+		t.Errorf("partial update = %+v", got)
+	}
+
+	for _, tc := range []struct {
+		p    ProfileUpdate
+		want error
+	}{
+		{ProfileUpdate{Locale: ptr("not a locale")}, ErrInvalidLocale},
+		{ProfileUpdate{Timezone: ptr("Mars/Olympus")}, ErrInvalidTimezone},
+		{ProfileUpdate{Timezone: ptr("Local")}, ErrInvalidTimezone},
+	} {
+		if _, err := svc.UpdateProfile(u.ID, tc.p); err != tc.want {
+			t.Errorf("UpdateProfile(%+v) err = %v, want %v", tc.p, err, tc.want)
+		}
+	}
+}
+
+func TestMemoryService_ChangeEmailAndDelete(t *testing.T) {
+	svc := NewMemoryService(testHasher)
+	a, _ := svc.Signup("a@example.com", "password")
+	_, _ = svc.Signup("b@example.com", "password")
+
+	if err := svc.ChangeEmail(a.ID, "b@example.com"); err != ErrEmailInUse {
+		t.Errorf("taken email: err = %v, want ErrEmailInUse", err)
+	}
+	if err := svc.ChangeEmail(a.ID, "new@example.com"); err != nil {
+		t.Fatalf("ChangeEmail: %v", err)
+	}
+	if _, err := svc.Login("new@example.com", "password"); err != nil {
+		t.Errorf("login with new email: %v", err)
+	}
+	if _, err := svc.GetByEmail("a@example.com"); err != ErrNotFound {
+		t.Errorf("old email still resolves: err = %v", err)
+	}
+
+	if err := svc.Delete(a.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := svc.GetByID(a.ID); err != ErrNotFound {
+		t.Errorf("deleted user still found: err = %v", err)
+	}
+}
+
+func ptr(s string) *string { return &s }
@@ -5,8 +5,10 @@ import (
 	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
+	"database/sql"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -14,6 +16,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Ulpio/vergo/internal/pkg/precondition"
 	"github.com/Ulpio/vergo/internal/repo"
 	"github.com/lib/pq"
 )
@@ -26,12 +29,18 @@ type Endpoint struct {
 	Active    bool      `json:"active"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	Version   int64     `json:"version"` // served as the ETag
 }
 
+var ErrNotFound = errors.New("webhook endpoint not found")
+
 type Service interface {
 	CreateEndpoint(orgID, url string, events []string) (Endpoint, error)
 	ListEndpoints(orgID string) ([]Endpoint, error)
-	UpdateEndpoint(orgID, id, url string, events []string, active bool) error
+	GetEndpoint(orgID, id string) (Endpoint, error)
+	// UpdateEndpoint changes the endpoint at ifVersion, or at any version
+	// with precondition.Any; otherwise it is precondition.ErrFailed.
+	UpdateEndpoint(orgID, id, url string, events []string, active bool, ifVersion int64) error
 	Dispatch(orgID, event string, payload json.RawMessage) error
 	TestEndpoint(orgID, endpointID string) error
 }
@@ -72,6 +81,7 @@ func (s *service) CreateEndpoint(orgID, url string, events []string) (Endpoint,
 		Active:    row.Active,
 		CreatedAt: row.CreatedAt,
 		UpdatedAt: row.UpdatedAt,
+		Version:   row.Version,
 	}, nil
 }
 
@@ -90,19 +100,54 @@ func (s *service) ListEndpoints(orgID string) ([]Endpoint, error) {
 			Active:    r.Active,
 			CreatedAt: r.CreatedAt,
 			UpdatedAt: r.UpdatedAt,
+			Version:   r.Version,
 		}
 	}
 	return out, nil
 }
 
-func (s *service) UpdateEndpoint(orgID, id, url string, events []string, active bool) error {
-	return s.q.UpdateWebhookEndpoint(context.Background(), repo.UpdateWebhookEndpointParams{
-		ID:     id,
-		OrgID:  orgID,
-		Url:    url,
-		Events: events,
-		Active: active,
+func (s *service) GetEndpoint(orgID, id string) (Endpoint, error) {
+	r, err := s.q.GetWebhookEndpoint(context.Background(), repo.GetWebhookEndpointParams{
+		ID:    id,
+		OrgID: orgID,
 	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Endpoint{}, ErrNotFound
+	}
+	if err != nil {
+		return Endpoint{}, err
+	}
+	return Endpoint{
+		ID:        r.ID,
+		OrgID:     r.OrgID,
+		URL:       r.Url,
+		Events:    r.Events,
+		Active:    r.Active,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+		Version:   r.Version,
+	}, nil
+}
+
+func (s *service) UpdateEndpoint(orgID, id, url string, events []string, active bool, ifVersion int64) error {
+	n, err := s.q.UpdateWebhookEndpoint(context.Background(), repo.UpdateWebhookEndpointParams{
+		Url:       url,
+		Events:    events,
+		Active:    active,
+		ID:        id,
+		OrgID:     orgID,
+		IfVersion: ifVersion,
+	})
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	if _, err := s.GetEndpoint(orgID, id); err != nil {
+		return err
+	}
+	return precondition.ErrFailed
 }
 
 func (s *service) Dispatch(orgID, event string, payload json.RawMessage) error {
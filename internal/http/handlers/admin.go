@@ -0,0 +1,381 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ulpio/vergo/internal/domain/audit"
+	"github.com/Ulpio/vergo/internal/domain/billing"
+	"github.com/Ulpio/vergo/internal/domain/org"
+	"github.com/Ulpio/vergo/internal/domain/platform"
+	"github.com/Ulpio/vergo/internal/http/middleware"
+)
+
+// AdminHandler serves the platform admin API: cross-org search, suspensions,
+// plan overrides and operational views. Routes sit behind
+// RequirePlatformAdmin.
+type AdminHandler struct {
+	ps platform.Service
+	os org.Service
+	bs billing.Service
+	as audit.Service
+}
+
+func NewAdminHandler(ps platform.Service, os org.Service, bs billing.Service, as audit.Service) *AdminHandler {
+	return &AdminHandler{ps: ps, os: os, bs: bs, as: as}
+}
+
+// ListOrgs searches all orgs.
+// @Summary List orgs (platform admin)
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param q query string false "Org ID or part of the name"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Items per page (max 100)" default(20)
+// @Success 200 {object} map[string]interface{} "items, page, page_size"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/orgs [get]
+func (h *AdminHandler) ListOrgs(c *gin.Context) {
+	page, pageSize := pageParams(c)
+	items, err := h.ps.ListOrgs(platform.ListParams{Query: c.Query("q"), Limit: pageSize, Offset: (page - 1) * pageSize})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "list_failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items, "page": page, "page_size": pageSize})
+}
+
+// GetOrg returns an org with its subscription and usage against plan limits.
+// @Summary Get org (platform admin)
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Org ID"
+// @Success 200 {object} AdminOrgResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/orgs/{id} [get]
+func (h *AdminHandler) GetOrg(c *gin.Context) {
+	o, ok := h.loadOrg(c)
+	if !ok {
+		return
+	}
+	sub, err := h.bs.GetSubscription(o.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "fetch_failed"})
+		return
+	}
+	usage, err := h.ps.OrgUsage(o.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "fetch_failed"})
+		return
+	}
+	c.JSON(http.StatusOK, AdminOrgResponse{
+		Org:             o,
+		SuspendedReason: o.SuspendedReason,
+		Subscription:    sub,
+		Usage:           usage,
+		Limits:          billing.GetLimits(sub.Plan),
+	})
+}
+
+type suspendIn struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// SuspendOrg blocks all tenant access to an org until it is unsuspended.
+// @Summary Suspend org
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Org ID"
+// @Param body body suspendIn true "Reason"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/orgs/{id}/suspend [post]
+func (h *AdminHandler) SuspendOrg(c *gin.Context) {
+	var in suspendIn
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+	orgID := c.Param("id")
+	if !adminResult(c, h.ps.SuspendOrg(orgID, in.Reason), "org_not_found") {
+		return
+	}
+	h.recordOrg(c, orgID, "org.suspended", map[string]any{"reason": in.Reason})
+	c.Status(http.StatusNoContent)
+}
+
+// UnsuspendOrg lifts an org suspension.
+// @Summary Unsuspend org
+// @Tags Admin
+// @Security BearerAuth
+// @Param id path string true "Org ID"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/orgs/{id}/unsuspend [post]
+func (h *AdminHandler) UnsuspendOrg(c *gin.Context) {
+	orgID := c.Param("id")
+	if !adminResult(c, h.ps.UnsuspendOrg(orgID), "org_not_found") {
+		return
+	}
+	h.recordOrg(c, orgID, "org.unsuspended", nil)
+	c.Status(http.StatusNoContent)
+}
+
+type planOverrideIn struct {
+	Plan   string `json:"plan" binding:"required"`
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// SetPlan forces an org onto a plan, regardless of its Stripe subscription.
+// @Summary Override org plan
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Org ID"
+// @Param body body planOverrideIn true "Plan and reason"
+// @Success 200 {object} billing.Subscription
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/orgs/{id}/plan [put]
+func (h *AdminHandler) SetPlan(c *gin.Context) {
+	var in planOverrideIn
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+	o, ok := h.loadOrg(c)
+	if !ok {
+		return
+	}
+	adminID, _ := middleware.UserID(c)
+	err := h.bs.SetPlanOverride(o.ID, in.Plan, in.Reason, adminID)
+	if errors.Is(err, billing.ErrUnknownPlan) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "unknown_plan"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "update_failed"})
+		return
+	}
+	h.recordOrg(c, o.ID, "org.plan_overridden", map[string]any{"plan": in.Plan, "reason": in.Reason})
+	h.respondSubscription(c, o.ID)
+}
+
+// ClearPlan removes a plan override; the Stripe subscription applies again.
+// @Summary Remove org plan override
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Org ID"
+// @Success 200 {object} billing.Subscription
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/orgs/{id}/plan [delete]
+func (h *AdminHandler) ClearPlan(c *gin.Context) {
+	orgID := c.Param("id")
+	err := h.bs.ClearPlanOverride(orgID)
+	if errors.Is(err, billing.ErrNoOverride) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no_override"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "update_failed"})
+		return
+	}
+	h.recordOrg(c, orgID, "org.plan_override_cleared", nil)
+	h.respondSubscription(c, orgID)
+}
+
+// ListUsers searches all users.
+// @Summary List users (platform admin)
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param q query string false "User ID or part of the email or display name"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Items per page (max 100)" default(20)
+// @Success 200 {object} map[string]interface{} "items, page, page_size"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/users [get]
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	page, pageSize := pageParams(c)
+	items, err := h.ps.ListUsers(platform.ListParams{Query: c.Query("q"), Limit: pageSize, Offset: (page - 1) * pageSize})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "list_failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items, "page": page, "page_size": pageSize})
+}
+
+// SuspendUser signs a user out everywhere and refuses their tokens and
+// logins until they are unsuspended.
+// @Summary Suspend user
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param body body suspendIn true "Reason"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/users/{id}/suspend [post]
+func (h *AdminHandler) SuspendUser(c *gin.Context) {
+	var in suspendIn
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+	adminID, _ := middleware.UserID(c)
+	userID := c.Param("id")
+	if userID == adminID {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "cannot_suspend_self"})
+		return
+	}
+	if !adminResult(c, h.ps.SuspendUser(userID, in.Reason), "user_not_found") {
+		return
+	}
+	h.recordUser(c, userID, "user.suspended", map[string]any{"reason": in.Reason})
+	c.Status(http.StatusNoContent)
+}
+
+// UnsuspendUser lifts a user suspension.
+// @Summary Unsuspend user
+// @Tags Admin
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/users/{id}/unsuspend [post]
+func (h *AdminHandler) UnsuspendUser(c *gin.Context) {
+	userID := c.Param("id")
+	if !adminResult(c, h.ps.UnsuspendUser(userID), "user_not_found") {
+		return
+	}
+	h.recordUser(c, userID, "user.unsuspended", nil)
+	c.Status(http.StatusNoContent)
+}
+
+// WebhookBacklog reports undelivered webhooks across all orgs.
+// @Summary Global webhook backlog
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param orgs query int false "Orgs to break down (max 100)" default(20)
+// @Success 200 {object} platform.WebhookBacklog
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/webhooks/backlog [get]
+func (h *AdminHandler) WebhookBacklog(c *gin.Context) {
+	n := min(max(parseInt(c.Query("orgs"), 20), 1), 100)
+	b, err := h.ps.WebhookBacklog(n)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "fetch_failed"})
+		return
+	}
+	c.JSON(http.StatusOK, b)
+}
+
+func (h *AdminHandler) loadOrg(c *gin.Context) (org.Organization, bool) {
+	o, err := h.os.Get(c.Param("id"))
+	if errors.Is(err, org.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "org_not_found"})
+		return o, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "fetch_failed"})
+		return o, false
+	}
+	return o, true
+}
+
+func (h *AdminHandler) respondSubscription(c *gin.Context, orgID string) {
+	sub, err := h.bs.GetSubscription(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "fetch_failed"})
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+// adminResult writes the error response for a failed update, reporting
+// whether it succeeded.
+func adminResult(c *gin.Context, err error, notFound string) bool {
+	if errors.Is(err, platform.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "update_failed"})
+		return false
+	}
+	return true
+}
+
+// recordOrg audits an admin action in the target org's log, so its own
+// admins see it too.
+func (h *AdminHandler) recordOrg(c *gin.Context, orgID, action string, meta map[string]any) {
+	adminID, _ := middleware.UserID(c)
+	_ = h.as.Record(audit.Event{
+		OrgID:    orgID,
+		ActorID:  adminID,
+		Action:   action,
+		Entity:   "org",
+		EntityID: orgID,
+		Metadata: toAuditMeta(withClient(c, meta)),
+	})
+}
+
+func (h *AdminHandler) recordUser(c *gin.Context, userID, action string, meta map[string]any) {
+	adminID, _ := middleware.UserID(c)
+	// Evento de conta (sem org)
+	_ = h.as.Record(audit.Event{
+		ActorID:  adminID,
+		Action:   action,
+		Entity:   "user",
+		EntityID: userID,
+		Metadata: toAuditMeta(withClient(c, meta)),
+	})
+}
+
+func withClient(c *gin.Context, meta map[string]any) map[string]any {
+	if meta == nil {
+		meta = map[string]any{}
+	}
+	meta["ip"] = c.ClientIP()
+	meta["user_agent"] = c.Request.UserAgent()
+	return meta
+}
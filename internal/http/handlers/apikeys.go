@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -10,33 +11,38 @@ import (
 
 	"github.com/Ulpio/vergo/internal/domain/apikey"
 	"github.com/Ulpio/vergo/internal/domain/audit"
+	"github.com/Ulpio/vergo/internal/domain/org"
 	"github.com/Ulpio/vergo/internal/http/middleware"
 )
 
 type APIKeysHandler struct {
 	ks apikey.Service
+	os org.Service
 	as audit.Service
 }
 
-func NewAPIKeysHandler(ks apikey.Service, as audit.Service) *APIKeysHandler {
-	return &APIKeysHandler{ks: ks, as: as}
+func NewAPIKeysHandler(ks apikey.Service, os org.Service, as audit.Service) *APIKeysHandler {
+	return &APIKeysHandler{ks: ks, os: os, as: as}
 }
 
 type createKeyIn struct {
 	Name      string     `json:"name" binding:"required"`
+	Role      string     `json:"role"` // default: member
 	ExpiresAt *time.Time `json:"expires_at"`
 }
 
-// Create creates a new API key.
+// Create creates a new API key acting with the given role, which cannot be
+// above the caller's own.
 // @Summary Create API key
 // @Tags API Keys
 // @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param X-Org-ID header string true "Organization ID"
-// @Param body body createKeyIn true "Key name and optional expiration"
+// @Param body body createKeyIn true "Key name, role and optional expiration"
 // @Success 201 {object} apikey.CreateResult
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 422 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api-keys [post]
@@ -50,20 +56,29 @@ func (h *APIKeysHandler) Create(c *gin.Context) {
 		return
 	}
 
-	result, err := h.ks.Create(orgID, uid, in.Name, in.ExpiresAt)
+	if in.Role == "" {
+		in.Role = "member"
+	}
+	actorRole, _ := middleware.Role(c)
+	if err := h.os.AuthorizeRoleChange(orgID, actorRole, "", in.Role); err != nil {
+		respondKeyRoleError(c, err)
+		return
+	}
+
+	result, err := h.ks.Create(orgID, uid, in.Name, in.Role, in.ExpiresAt)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "create_failed"})
 		return
 	}
 
-	_ = h.as.Record(audit.Event{
+	_ = h.as.Record(auditEvent(c, audit.Event{
 		OrgID:    orgID,
 		ActorID:  uid,
 		Action:   "api_key.created",
 		Entity:   "api_key",
 		EntityID: result.ID,
-		Metadata: toAuditMeta(map[string]string{"name": in.Name}),
-	})
+		Metadata: toAuditMeta(map[string]string{"name": in.Name, "role": in.Role}),
+	}))
 
 	c.JSON(http.StatusCreated, result)
 }
@@ -89,6 +104,67 @@ func (h *APIKeysHandler) List(c *gin.Context) {
 	c.JSON(http.StatusOK, keys)
 }
 
+type keyRoleIn struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// SetRole assigns an API key to another role. Neither the key's current
+// role nor the new one can be above the caller's own.
+// @Summary Change API key role
+// @Tags API Keys
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "API Key ID"
+// @Param body body keyRoleIn true "New role"
+// @Success 200 {object} apikey.APIKey
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api-keys/{id} [patch]
+func (h *APIKeysHandler) SetRole(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	orgID, _ := middleware.OrgID(c)
+	keyID := c.Param("id")
+
+	var in keyRoleIn
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+	key, err := h.ks.Get(orgID, keyID)
+	if err != nil {
+		respondKeyRoleError(c, err)
+		return
+	}
+	actorRole, _ := middleware.Role(c)
+	if err := h.os.AuthorizeRoleChange(orgID, actorRole, key.Role, in.Role); err != nil {
+		respondKeyRoleError(c, err)
+		return
+	}
+	updated, err := h.ks.SetRole(orgID, keyID, in.Role)
+	if err != nil {
+		respondKeyRoleError(c, err)
+		return
+	}
+
+	before, _ := json.Marshal(gin.H{"role": key.Role})
+	after, _ := json.Marshal(gin.H{"role": in.Role})
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID:    orgID,
+		ActorID:  uid,
+		Action:   "api_key.role_changed",
+		Entity:   "api_key",
+		EntityID: keyID,
+		Metadata: audit.Metadata{Before: before, After: after},
+	}))
+
+	c.JSON(http.StatusOK, updated)
+}
+
 // Revoke revokes an API key.
 // @Summary Revoke API key
 // @Tags API Keys
@@ -109,17 +185,32 @@ func (h *APIKeysHandler) Revoke(c *gin.Context) {
 		return
 	}
 
-	_ = h.as.Record(audit.Event{
+	_ = h.as.Record(auditEvent(c, audit.Event{
 		OrgID:    orgID,
 		ActorID:  uid,
 		Action:   "api_key.revoked",
 		Entity:   "api_key",
 		EntityID: keyID,
-	})
+	}))
 
 	c.Status(http.StatusNoContent)
 }
 
+func respondKeyRoleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, apikey.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "api_key_not_found"})
+	case errors.Is(err, org.ErrInvalidRole):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_role"})
+	case errors.Is(err, org.ErrRoleAboveActor):
+		c.JSON(http.StatusForbidden, gin.H{"error": "role_above_own"})
+	case errors.Is(err, org.ErrMemberOutranks):
+		c.JSON(http.StatusForbidden, gin.H{"error": "key_outranks_you"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "update_failed"})
+	}
+}
+
 func toAuditMeta(data any) audit.Metadata {
 	b, _ := json.Marshal(data)
 	return audit.Metadata{After: b}
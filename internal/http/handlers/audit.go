@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/Ulpio/vergo/internal/domain/audit"
 	"github.com/Ulpio/vergo/internal/http/middleware"
+	"github.com/Ulpio/vergo/internal/pkg/pagination"
 	"github.com/gin-gonic/gin"
 )
 
@@ -22,14 +24,14 @@ func NewAuditHandler(as audit.Service) *AuditHandler { return &AuditHandler{as:
 // @Security BearerAuth
 // @Produce json
 // @Param X-Org-ID header string true "Organization ID"
-// @Param page query int false "Page number" default(1)
-// @Param page_size query int false "Items per page (max 100)" default(20)
+// @Param limit query int false "Items per page (max 100)" default(20)
+// @Param cursor query string false "next_cursor of the previous page"
 // @Param actor_id query string false "Filter by actor"
 // @Param action query string false "Filter by action"
 // @Param entity query string false "Filter by entity type"
 // @Param since query string false "Filter from date (RFC3339)"
 // @Param until query string false "Filter to date (RFC3339)"
-// @Success 200 {object} map[string]interface{} "items, page, page_size, next_offset"
+// @Success 200 {object} pagination.Page[audit.Event]
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 403 {object} ErrorResponse
@@ -42,24 +44,15 @@ func (h *AuditHandler) List(c *gin.Context) {
 		return
 	}
 
-	pageSize := parseInt(c.Query("page_size"), 20)
-	page := parseInt(c.Query("page"), 1)
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 {
-		pageSize = 20
-	}
-	if pageSize > 100 {
-		pageSize = 100
+	limit, cursor, ok := cursorParams(c)
+	if !ok {
+		return
 	}
 
-	offset := (page - 1) * pageSize
-
 	params := audit.ListParams{
 		OrgID:  orgID,
-		Limit:  pageSize,
-		Offset: offset,
+		Limit:  limit,
+		Cursor: cursor,
 	}
 
 	// filtros opcionais
@@ -83,18 +76,17 @@ func (h *AuditHandler) List(c *gin.Context) {
 		}
 	}
 
-	items, err := h.as.List(params)
+	page, err := h.as.List(params)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_cursor"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "list_failed"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"items":       items,
-		"page":        page,
-		"page_size":   pageSize,
-		"next_offset": offset + len(items),
-	})
+	c.JSON(http.StatusOK, page)
 }
 
 func parseInt(s string, def int) int {
@@ -106,3 +98,26 @@ func parseInt(s string, def int) int {
 	}
 	return def
 }
+
+// pageParams reads page (from 1) and page_size (default 20, max 100) for
+// offset-paginated lists.
+func pageParams(c *gin.Context) (page, pageSize int) {
+	page = max(parseInt(c.Query("page"), 1), 1)
+	pageSize = parseInt(c.Query("page_size"), 20)
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	return page, min(pageSize, 100)
+}
+
+// cursorParams reads limit (default 20, max 100) and cursor for
+// cursor-paginated lists, answering 400 invalid_cursor when the cursor
+// does not parse.
+func cursorParams(c *gin.Context) (limit int, cursor pagination.Cursor, ok bool) {
+	cursor, err := pagination.Decode(c.Query("cursor"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_cursor"})
+		return 0, cursor, false
+	}
+	return pagination.Limit(parseInt(c.Query("limit"), 0)), cursor, true
+}
@@ -2,42 +2,88 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 
 	"github.com/Ulpio/vergo/internal/auth"
+	"github.com/Ulpio/vergo/internal/auth/password"
+	"github.com/Ulpio/vergo/internal/domain/audit"
+	"github.com/Ulpio/vergo/internal/domain/org"
+	"github.com/Ulpio/vergo/internal/domain/sso"
 	"github.com/Ulpio/vergo/internal/domain/user"
 	"github.com/Ulpio/vergo/internal/pkg/config"
+	"github.com/Ulpio/vergo/internal/pkg/notify"
 )
 
 type AuthHandler struct {
 	cfg    config.Config
+	keys   *auth.Keyring
 	us     user.Service
 	rs     auth.RefreshStore
 	resets auth.ResetStore
+	emails auth.EmailChangeStore
+	sso    sso.Service
+	os     org.Service
+	as     audit.Service
+	lock   auth.LockoutTracker
+	policy password.Policy
+	notify notify.Notifier // optional security alerts
+	mail   notify.Notifier // account mail: verification links and change notices
 }
 
-func NewAuthHandler(cfg config.Config, us user.Service, rs auth.RefreshStore, resets auth.ResetStore) *AuthHandler {
-	return &AuthHandler{cfg: cfg, us: us, rs: rs, resets: resets}
+func NewAuthHandler(cfg config.Config, keys *auth.Keyring, us user.Service, rs auth.RefreshStore, resets auth.ResetStore, emails auth.EmailChangeStore, ss sso.Service, os org.Service, as audit.Service, lock auth.LockoutTracker, policy password.Policy, n, mail notify.Notifier) *AuthHandler {
+	return &AuthHandler{cfg: cfg, keys: keys, us: us, rs: rs, resets: resets, emails: emails, sso: ss, os: os, as: as, lock: lock, policy: policy, notify: n, mail: mail}
+}
+
+// weakPassword rejects a password that fails the policy with 422 and the
+// reasons, e.g. ["too_short","breached"].
+func (h *AuthHandler) weakPassword(c *gin.Context, pw, email string) bool {
+	err := h.policy.Check(pw, email)
+	if err == nil {
+		return false
+	}
+	reasons, _ := password.Reasons(err)
+	c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "weak_password", "reasons": reasons})
+	return true
+}
+
+// ssoEnforced rejects password-based auth for emails in a domain whose org
+// enforces SSO, pointing the client at the org's SSO login instead.
+func (h *AuthHandler) ssoEnforced(c *gin.Context, email string) bool {
+	d, ok, err := h.sso.Discover(email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "sso_lookup_failed"})
+		return true
+	}
+	if !ok || !d.Enforced {
+		return false
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "sso_required", "login_url": ssoLoginURL(d.OrgID)})
+	return true
 }
 
 type creds struct {
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
 }
 
-// Signup registers a new user.
+// Signup registers a new user and mails a link verifying the address;
+// OIDC logins only link to accounts whose email is verified.
 // @Summary Register a new user
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param body body creds true "User credentials"
 // @Success 201 {object} AuthResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 409 {object} ErrorResponse
-// @Failure 422 {object} ErrorResponse
+// @Failure 422 {object} WeakPasswordResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /auth/signup [post]
 func (h *AuthHandler) Signup(c *gin.Context) {
@@ -46,32 +92,30 @@ func (h *AuthHandler) Signup(c *gin.Context) {
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
 		return
 	}
-	u, err := h.us.Signup(in.Email, in.Password)
-	if err != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	if h.ssoEnforced(c, in.Email) {
 		return
 	}
-
-	at, err := auth.NewAccessToken(u.ID, h.cfg.JWTAccessSecret, h.cfg.JWTAccessTTLMinutes)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "token_error"})
+	if h.weakPassword(c, in.Password, in.Email) {
 		return
 	}
-	rt, jti, exp, err := auth.NewRefreshToken(u.ID, h.cfg.JWTRefreshSecret, h.cfg.JWTRefreshTTLDays)
+	u, err := h.us.Signup(in.Email, in.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "token_error"})
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.rs.SaveRefresh(context.Background(), jti, u.ID, rt, exp, nil); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "store_error"})
+	h.sendVerification(c, u.ID, u.Email)
+
+	pair, err := h.issueTokens(c, u.ID)
+	if err != nil {
+		respondTokenError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
 		"user":          gin.H{"id": u.ID, "email": u.Email},
-		"access_token":  at,
-		"refresh_token": rt,
+		"access_token":  pair.AcessToken,
+		"refresh_token": pair.RefreshToken,
 	})
 }
 
@@ -82,8 +126,10 @@ func (h *AuthHandler) Signup(c *gin.Context) {
 // @Produce json
 // @Param body body creds true "User credentials"
 // @Success 200 {object} AuthResponse
-// @Failure 401 {object} ErrorResponse
+// @Failure 401 {object} LockoutErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 422 {object} ErrorResponse
+// @Failure 429 {object} LockoutErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -92,32 +138,36 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
 		return
 	}
-	u, err := h.us.Login(in.Email, in.Password)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_credentials"})
+	if h.ssoEnforced(c, in.Email) {
 		return
 	}
-
-	at, err := auth.NewAccessToken(u.ID, h.cfg.JWTAccessSecret, h.cfg.JWTAccessTTLMinutes)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "token_error"})
+	if h.lockedOut(c, auth.ScopeLogin, in.Email) {
+		return
+	}
+	u, err := h.us.Login(in.Email, in.Password)
+	if errors.Is(err, user.ErrSuspended) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "user_suspended"})
 		return
 	}
-	rt, jti, exp, err := auth.NewRefreshToken(u.ID, h.cfg.JWTRefreshSecret, h.cfg.JWTRefreshTTLDays)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "token_error"})
+		att := h.failAttempt(c, auth.ScopeLogin, in.Email)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_credentials", "captcha_required": att.CaptchaRequired})
 		return
 	}
+	if err := h.lock.Reset(c.Request.Context(), auth.ScopeLogin, in.Email); err != nil {
+		slog.Error("login: reset lockout", "error", err)
+	}
 
-	if err := h.rs.SaveRefresh(context.Background(), jti, u.ID, rt, exp, nil); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "store_error"})
+	pair, err := h.issueTokens(c, u.ID)
+	if err != nil {
+		respondTokenError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"user":          gin.H{"id": u.ID, "email": u.Email},
-		"access_token":  at,
-		"refresh_token": rt,
+		"access_token":  pair.AcessToken,
+		"refresh_token": pair.RefreshToken,
 	})
 }
 
@@ -142,41 +192,38 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
 		return
 	}
-	claims, err := auth.Parse(in.RefreshToken, h.cfg.JWTRefreshSecret)
+	claims, err := auth.ParseRefresh(in.RefreshToken, h.cfg.JWTRefreshSecret)
 	if err != nil || claims.TokenType != "refresh" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_refresh"})
 		return
 	}
 
-	ok, userID, _, err := h.rs.IsValid(context.Background(), claims.ID, in.RefreshToken)
-	if err != nil || !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_refresh"})
-		return
-	}
-
-	// rotate: revoga o antigo, emite novo par
-	_ = h.rs.Revoke(context.Background(), claims.ID)
-
-	at, err := auth.NewAccessToken(userID, h.cfg.JWTAccessSecret, h.cfg.JWTAccessTTLMinutes)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "token_error"})
+	// rotate: revoga o antigo e grava o novo na mesma transação
+	var access string
+	rot, err := h.rs.Rotate(context.Background(), claims.ID, in.RefreshToken, h.sessionInfo(c), func(userID string) (string, string, time.Time, error) {
+		var err error
+		if access, err = auth.NewAccessToken(userID, h.keys, h.cfg.JWTAccessTTLMinutes); err != nil {
+			return "", "", time.Time{}, err
+		}
+		rt, jti, exp, err := auth.NewRefreshToken(userID, h.cfg.JWTRefreshSecret, h.cfg.JWTRefreshTTLDays)
+		return jti, rt, exp, err
+	})
+	switch {
+	case errors.Is(err, auth.ErrRefreshReused):
+		h.refreshReused(c, rot)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh_reused"})
 		return
-	}
-	newRT, newJTI, exp, err := auth.NewRefreshToken(userID, h.cfg.JWTRefreshSecret, h.cfg.JWTRefreshTTLDays)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "token_error"})
+	case errors.Is(err, auth.ErrRefreshInvalid):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_refresh"})
 		return
-	}
-
-	rotFrom := claims.ID
-	if err := h.rs.SaveRefresh(context.Background(), newJTI, userID, newRT, exp, &rotFrom); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "store_error"})
+	case err != nil:
+		respondTokenError(c, fmt.Errorf("%w: %v", errTokenStore, err))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"access_token":  at,
-		"refresh_token": newRT,
+		"access_token":  access,
+		"refresh_token": rot.Token,
 	})
 }
 
@@ -196,7 +243,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
 		return
 	}
-	claims, err := auth.Parse(in.RefreshToken, h.cfg.JWTRefreshSecret)
+	claims, err := auth.ParseRefresh(in.RefreshToken, h.cfg.JWTRefreshSecret)
 	if err != nil || claims.TokenType != "refresh" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_refresh"})
 		return
@@ -222,6 +269,69 @@ func (h *AuthHandler) LogoutAll(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+type orgTokenIn struct {
+	OrgID string `json:"org_id" binding:"required"`
+}
+
+// OrgToken mints an access token scoped to one org. It carries org_id, role
+// and the membership version, so tenant routes need no X-Org-ID header and
+// the token stops working as soon as the membership changes. Its lifetime
+// follows the org's session.access_token_minutes setting when set.
+// @Summary Issue an org-scoped access token
+// @Tags Auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body orgTokenIn true "Org to scope the token to"
+// @Success 200 {object} OrgTokenResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/org-token [post]
+func (h *AuthHandler) OrgToken(c *gin.Context) {
+	uid, ok := middlewareUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing_user"})
+		return
+	}
+	var in orgTokenIn
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+	m, err := h.os.GetMembership(in.OrgID, uid)
+	if errors.Is(err, org.ErrNotMember) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not_a_member"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "membership_lookup_failed"})
+		return
+	}
+	// the org may override the access token lifetime
+	ttl := h.cfg.JWTAccessTTLMinutes
+	o, err := h.os.Get(m.OrgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "membership_lookup_failed"})
+		return
+	}
+	if s := o.Settings.Session; s != nil && s.AccessTokenMinutes > 0 {
+		ttl = s.AccessTokenMinutes
+	}
+	at, err := auth.NewOrgAccessToken(uid, m.OrgID, m.Role, m.Version, h.keys, ttl)
+	if err != nil {
+		respondTokenError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, OrgTokenResponse{
+		AccessToken: at,
+		OrgID:       m.OrgID,
+		Role:        m.Role,
+		ExpiresIn:   ttl * 60,
+	})
+}
+
 type forgotIn struct {
 	Email string `json:"email" binding:"required,email"`
 }
@@ -234,6 +344,7 @@ type forgotIn struct {
 // @Param body body forgotIn true "User email"
 // @Success 200 {object} map[string]string
 // @Failure 422 {object} ErrorResponse
+// @Failure 429 {object} LockoutErrorResponse
 // @Router /auth/forgot-password [post]
 func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 	var in forgotIn
@@ -241,6 +352,11 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
 		return
 	}
+	// Every request counts: the limit caps reset tokens per account and IP
+	if h.lockedOut(c, auth.ScopeForgotPassword, in.Email) {
+		return
+	}
+	h.failAttempt(c, auth.ScopeForgotPassword, in.Email)
 
 	// Always return success to prevent email enumeration
 	u, err := h.us.GetByEmail(in.Email)
@@ -260,7 +376,7 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 	slog.Info("password reset token generated",
 		"email", in.Email,
 		"token", token,
-		"reset_url", "/auth/reset-password?token="+token,
+		"reset_url", h.frontendLink("/reset-password", token),
 	)
 
 	c.JSON(http.StatusOK, gin.H{"message": "if the email exists, a reset link was sent"})
@@ -268,7 +384,7 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 
 type resetIn struct {
 	Token       string `json:"token" binding:"required"`
-	NewPassword string `json:"new_password" binding:"required,min=6"`
+	NewPassword string `json:"new_password" binding:"required"`
 }
 
 // ResetPassword resets the user's password using a valid token.
@@ -279,7 +395,7 @@ type resetIn struct {
 // @Param body body resetIn true "Reset token and new password"
 // @Success 200 {object} map[string]string
 // @Failure 401 {object} ErrorResponse
-// @Failure 422 {object} ErrorResponse
+// @Failure 422 {object} WeakPasswordResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /auth/reset-password [post]
 func (h *AuthHandler) ResetPassword(c *gin.Context) {
@@ -289,19 +405,26 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 		return
 	}
 
-	userID, err := h.resets.ValidateAndConsume(in.Token)
+	// Check the policy before consuming, so a rejected password keeps the token
+	userID, err := h.resets.Lookup(in.Token)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_or_expired_token"})
 		return
 	}
-
-	hash, err := bcrypt.GenerateFromPassword([]byte(in.NewPassword), bcrypt.DefaultCost)
+	u, err := h.us.GetByID(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "hash_error"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_or_expired_token"})
+		return
+	}
+	if h.weakPassword(c, in.NewPassword, u.Email) {
+		return
+	}
+	if _, err := h.resets.ValidateAndConsume(in.Token); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_or_expired_token"})
 		return
 	}
 
-	if err := h.us.UpdatePassword(userID, string(hash)); err != nil {
+	if err := h.us.SetPassword(userID, in.NewPassword); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "update_failed"})
 		return
 	}
@@ -312,6 +435,254 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "password_reset_successful"})
 }
 
+type changePasswordIn struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// ChangePassword replaces the authenticated user's password. Every session
+// is revoked and a new token pair is returned for the caller.
+// @Summary Change password
+// @Tags User
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body changePasswordIn true "Current and new password"
+// @Success 200 {object} TokenResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 422 {object} WeakPasswordResponse
+// @Failure 429 {object} LockoutErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /me/password [post]
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	uid, ok := middlewareUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing_user"})
+		return
+	}
+	var in changePasswordIn
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+	u, err := h.us.GetByID(uid)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_user"})
+		return
+	}
+	// Wrong current passwords count as failed logins
+	if h.lockedOut(c, auth.ScopeLogin, u.Email) {
+		return
+	}
+	if _, err := h.us.Login(u.Email, in.CurrentPassword); err != nil {
+		h.failAttempt(c, auth.ScopeLogin, u.Email)
+		c.JSON(http.StatusForbidden, gin.H{"error": "invalid_current_password"})
+		return
+	}
+	if h.weakPassword(c, in.NewPassword, u.Email) {
+		return
+	}
+
+	if err := h.us.SetPassword(uid, in.NewPassword); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "update_failed"})
+		return
+	}
+	if err := h.rs.RevokeAllForUser(context.Background(), uid); err != nil {
+		slog.Error("change-password: revoke sessions", "error", err)
+	}
+
+	// Evento de conta (sem org)
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		ActorID:  uid,
+		Action:   "user.password_changed",
+		Entity:   "user",
+		EntityID: uid,
+		Metadata: toAuditMeta(map[string]any{"ip": c.ClientIP()}),
+	}))
+
+	pair, err := h.issueTokens(c, uid)
+	if err != nil {
+		respondTokenError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"access_token":  pair.AcessToken,
+		"refresh_token": pair.RefreshToken,
+	})
+}
+
+var errTokenStore = errors.New("refresh token store failed")
+
+// issueTokens mints an access/refresh pair for the user and persists the
+// refresh token as the start of a new token family (a session for the
+// requesting client).
+func (h *AuthHandler) issueTokens(c *gin.Context, userID string) (auth.TokenPair, error) {
+	at, err := auth.NewAccessToken(userID, h.keys, h.cfg.JWTAccessTTLMinutes)
+	if err != nil {
+		return auth.TokenPair{}, err
+	}
+	rt, jti, exp, err := auth.NewRefreshToken(userID, h.cfg.JWTRefreshSecret, h.cfg.JWTRefreshTTLDays)
+	if err != nil {
+		return auth.TokenPair{}, err
+	}
+	if err := h.rs.SaveRefresh(context.Background(), jti, userID, rt, exp, h.sessionInfo(c)); err != nil {
+		return auth.TokenPair{}, fmt.Errorf("%w: %v", errTokenStore, err)
+	}
+	return auth.TokenPair{AcessToken: at, RefreshToken: rt}, nil
+}
+
+// sessionInfo describes the client of the current request for the session
+// list. The location label comes from the edge proxy header, if configured.
+func (h *AuthHandler) sessionInfo(c *gin.Context) auth.SessionInfo {
+	info := auth.SessionInfo{
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	}
+	if h.cfg.GeoHeader != "" {
+		info.GeoLabel = c.GetHeader(h.cfg.GeoHeader)
+	}
+	return info
+}
+
+// lockedOut rejects the request with 429 while its account or IP is locked
+// for scope. Tracker errors fail open: the global rate limit still applies.
+func (h *AuthHandler) lockedOut(c *gin.Context, scope, email string) bool {
+	att, err := h.lock.Check(c.Request.Context(), scope, email, c.ClientIP())
+	if err != nil {
+		slog.Error("lockout: check", "scope", scope, "error", err)
+		return false
+	}
+	now := time.Now()
+	if !att.Locked(now) {
+		return false
+	}
+	retry := int(att.LockedUntil.Sub(now).Seconds()) + 1
+	c.Header("Retry-After", strconv.Itoa(retry))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":            "too_many_attempts",
+		"retry_after":      retry,
+		"captcha_required": att.CaptchaRequired,
+	})
+	return true
+}
+
+// failAttempt counts a failed attempt and reports any lockout it causes.
+func (h *AuthHandler) failAttempt(c *gin.Context, scope, email string) auth.Attempt {
+	att, err := h.lock.Fail(c.Request.Context(), scope, email, c.ClientIP())
+	if err != nil {
+		slog.Error("lockout: record failure", "scope", scope, "error", err)
+		return auth.Attempt{}
+	}
+	for _, l := range att.NewLocks {
+		h.lockoutStarted(c, scope, email, l)
+	}
+	return att
+}
+
+// lockoutStarted records a lockout as a security event and, for account
+// lockouts, alerts the account owner if configured.
+func (h *AuthHandler) lockoutStarted(c *gin.Context, scope, email string, l auth.Lock) {
+	slog.Warn("auth lockout",
+		"scope", scope,
+		"kind", l.Kind,
+		"email", email,
+		"ip", c.ClientIP(),
+		"failures", l.Failures,
+		"locked_until", l.Until,
+	)
+	var actorID string
+	u, err := h.us.GetByEmail(email)
+	if err == nil {
+		actorID = u.ID
+	}
+	entityID := email
+	if l.Kind == "ip" {
+		entityID = c.ClientIP()
+	}
+	// Evento de conta (sem org)
+	err = h.as.Record(auditEvent(c, audit.Event{
+		ActorID:  actorID,
+		Action:   "auth.lockout",
+		Entity:   l.Kind,
+		EntityID: entityID,
+		Metadata: toAuditMeta(map[string]any{
+			"scope":        scope,
+			"failures":     l.Failures,
+			"locked_until": l.Until,
+			"ip":           c.ClientIP(),
+			"user_agent":   c.Request.UserAgent(),
+		}),
+	}))
+	if err != nil {
+		slog.Error("lockout: audit", "error", err)
+	}
+	if h.notify == nil || actorID == "" || l.Kind != "account" || scope != auth.ScopeLogin {
+		return
+	}
+	err = h.notify.Notify(c.Request.Context(), notify.Notice{
+		UserID:  u.ID,
+		Email:   u.Email,
+		Subject: "Sign-in temporarily locked",
+		Body: "There were too many failed sign-in attempts on your account, most recently from " + c.ClientIP() +
+			". Sign-in is locked until " + l.Until.UTC().Format(time.RFC1123) + ". If this was not you, consider changing your password.",
+	})
+	if err != nil {
+		slog.Error("lockout: notify", "error", err)
+	}
+}
+
+// refreshReused records a reused refresh token as a security event and, if
+// configured, alerts the account owner. The family is already revoked.
+func (h *AuthHandler) refreshReused(c *gin.Context, rot auth.Rotation) {
+	slog.Warn("refresh token reuse detected",
+		"user_id", rot.UserID,
+		"family_id", rot.FamilyID,
+		"revoked", rot.Revoked,
+		"ip", c.ClientIP(),
+	)
+	// Evento de conta (sem org)
+	err := h.as.Record(auditEvent(c, audit.Event{
+		ActorID:  rot.UserID,
+		Action:   "auth.refresh_reuse_detected",
+		Entity:   "refresh_token_family",
+		EntityID: rot.FamilyID,
+		Metadata: toAuditMeta(map[string]any{
+			"revoked":    rot.Revoked,
+			"ip":         c.ClientIP(),
+			"user_agent": c.Request.UserAgent(),
+		}),
+	}))
+	if err != nil {
+		slog.Error("refresh reuse: audit", "error", err)
+	}
+	if h.notify == nil {
+		return
+	}
+	u, err := h.us.GetByID(rot.UserID)
+	if err != nil {
+		return
+	}
+	err = h.notify.Notify(c.Request.Context(), notify.Notice{
+		UserID:  u.ID,
+		Email:   u.Email,
+		Subject: "Your sessions were signed out",
+		Body: "A refresh token that had already been used was presented again from " + c.ClientIP() +
+			". As a precaution all sessions started from that login were revoked. If this was not you, change your password.",
+	})
+	if err != nil {
+		slog.Error("refresh reuse: notify", "error", err)
+	}
+}
+
+func respondTokenError(c *gin.Context, err error) {
+	if errors.Is(err, errTokenStore) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "store_error"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "token_error"})
+}
+
 // pequena função para ler user_id sem importar o middleware (evita dependência cruzada)
 func middlewareUserID(c *gin.Context) (string, bool) {
 	v, ok := c.Get("user_id")
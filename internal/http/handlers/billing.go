@@ -37,9 +37,11 @@ type checkoutIn struct {
 // @Accept json
 // @Produce json
 // @Param X-Org-ID header string true "Organization ID"
+// @Param Idempotency-Key header string false "Replays the first response to retries with the same key"
 // @Param body body checkoutIn true "Checkout parameters"
 // @Success 200 {object} map[string]string
 // @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 422 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /billing/checkout-session [post]
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ulpio/vergo/internal/auth"
+	"github.com/Ulpio/vergo/internal/domain/audit"
+	"github.com/Ulpio/vergo/internal/domain/user"
+	"github.com/Ulpio/vergo/internal/pkg/notify"
+)
+
+type changeEmailIn struct {
+	NewEmail        string `json:"new_email" binding:"required,email"`
+	CurrentPassword string `json:"current_password" binding:"required"`
+}
+
+// RequestEmailChange starts an email change. The new address gets a
+// verification link and the old one a notice; the email only changes once
+// the link is followed.
+// @Summary Request email change
+// @Tags User
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body changeEmailIn true "New email and current password"
+// @Success 202 {object} map[string]string
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 429 {object} LockoutErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /me/email [post]
+func (h *AuthHandler) RequestEmailChange(c *gin.Context) {
+	uid, ok := middlewareUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing_user"})
+		return
+	}
+	var in changeEmailIn
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+	newEmail := strings.TrimSpace(in.NewEmail)
+	u, err := h.us.GetByID(uid)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_user"})
+		return
+	}
+	if strings.EqualFold(newEmail, u.Email) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "same_email"})
+		return
+	}
+	// Wrong current passwords count as failed logins
+	if h.lockedOut(c, auth.ScopeLogin, u.Email) {
+		return
+	}
+	if _, err := h.us.Login(u.Email, in.CurrentPassword); err != nil {
+		h.failAttempt(c, auth.ScopeLogin, u.Email)
+		c.JSON(http.StatusForbidden, gin.H{"error": "invalid_current_password"})
+		return
+	}
+	if _, err := h.us.GetByEmail(newEmail); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "email_in_use"})
+		return
+	}
+
+	token, err := h.emails.Create(uid, newEmail)
+	if err != nil {
+		slog.Error("change-email: create token", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "create_failed"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	h.sendMail(ctx, notify.Notice{
+		UserID:  uid,
+		Email:   newEmail,
+		Subject: "Confirm your new email address",
+		Body:    "Follow " + h.frontendLink("/verify-email", token) + " within 24 hours to use this address for your account.",
+	})
+	h.sendMail(ctx, notify.Notice{
+		UserID:  uid,
+		Email:   u.Email,
+		Subject: "Email change requested",
+		Body: "A change of your account email to " + newEmail + " was requested from " + c.ClientIP() +
+			". Nothing changes until the new address is verified. If this was not you, change your password.",
+	})
+
+	// Evento de conta (sem org)
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		ActorID:  uid,
+		Action:   "user.email_change_requested",
+		Entity:   "user",
+		EntityID: uid,
+		Metadata: toAuditMeta(map[string]any{"ip": c.ClientIP()}),
+	}))
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "verification_sent"})
+}
+
+type verifyEmailIn struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// VerifyEmail applies a pending email change once the new address has
+// followed its verification link, or verifies the address of a new account.
+// Invalid tokens count towards the IP lockout.
+// @Summary Verify new email address
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body verifyEmailIn true "Verification token"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 429 {object} LockoutErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/verify-email [post]
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var in verifyEmailIn
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+	if h.lockedOut(c, auth.ScopeVerifyEmail, "") {
+		return
+	}
+	ch, err := h.emails.Consume(in.Token)
+	if errors.Is(err, auth.ErrEmailChangeTokenInvalid) {
+		h.failAttempt(c, auth.ScopeVerifyEmail, "")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_or_expired_token"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "verify_failed"})
+		return
+	}
+	old, err := h.us.GetByID(ch.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_or_expired_token"})
+		return
+	}
+	err = h.us.ChangeEmail(ch.UserID, ch.NewEmail)
+	if errors.Is(err, user.ErrEmailInUse) {
+		c.JSON(http.StatusConflict, gin.H{"error": "email_in_use"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "update_failed"})
+		return
+	}
+
+	// Evento de conta (sem org)
+	if strings.EqualFold(old.Email, ch.NewEmail) {
+		// signup verification: the address stays, now verified
+		_ = h.as.Record(auditEvent(c, audit.Event{
+			ActorID:  ch.UserID,
+			Action:   "user.email_verified",
+			Entity:   "user",
+			EntityID: ch.UserID,
+			Metadata: toAuditMeta(map[string]any{"ip": c.ClientIP()}),
+		}))
+		c.JSON(http.StatusOK, gin.H{"email": ch.NewEmail})
+		return
+	}
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		ActorID:  ch.UserID,
+		Action:   "user.email_changed",
+		Entity:   "user",
+		EntityID: ch.UserID,
+		Metadata: toAuditMeta(map[string]any{"ip": c.ClientIP()}),
+	}))
+	h.sendMail(c.Request.Context(), notify.Notice{
+		UserID:  ch.UserID,
+		Email:   old.Email,
+		Subject: "Your email address was changed",
+		Body: "Your account email is now " + ch.NewEmail + " and this address no longer signs in. " +
+			"If this was not you, contact support.",
+	})
+
+	c.JSON(http.StatusOK, gin.H{"email": ch.NewEmail})
+}
+
+// sendVerification mails a link verifying the account's own address. It
+// reuses email change tokens: confirming the address it already has only
+// marks it verified.
+func (h *AuthHandler) sendVerification(c *gin.Context, userID, email string) {
+	token, err := h.emails.Create(userID, email)
+	if err != nil {
+		slog.Error("signup: create verification token", "error", err)
+		return
+	}
+	h.sendMail(c.Request.Context(), notify.Notice{
+		UserID:  userID,
+		Email:   email,
+		Subject: "Verify your email address",
+		Body:    "Follow " + h.frontendLink("/verify-email", token) + " within 24 hours to verify this address.",
+	})
+}
+
+// frontendLink is the web app page at path for a mailed token. The page
+// submits the token to the matching POST /v1/auth endpoint.
+func (h *AuthHandler) frontendLink(path, token string) string {
+	return strings.TrimRight(h.cfg.FrontendURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+// sendMail delivers account mail. Delivery failures are logged only: the
+// request already succeeded.
+func (h *AuthHandler) sendMail(ctx context.Context, n notify.Notice) {
+	if h.mail == nil {
+		return
+	}
+	if err := h.mail.Notify(ctx, n); err != nil {
+		slog.Error("account mail", "subject", n.Subject, "error", err)
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ulpio/vergo/internal/domain/audit"
+	"github.com/Ulpio/vergo/internal/domain/export"
+	"github.com/Ulpio/vergo/internal/http/middleware"
+)
+
+type ExportsHandler struct {
+	svc export.Service
+	as  audit.Service
+}
+
+func NewExportsHandler(svc export.Service, as audit.Service) *ExportsHandler {
+	return &ExportsHandler{svc: svc, as: as}
+}
+
+// RequestUser queues an export of the authenticated user's data. The
+// archive is built in the background; the user is sent a download link
+// and can poll GET /me/exports/:id.
+// @Summary Export my data
+// @Tags User
+// @Security BearerAuth
+// @Produce json
+// @Success 202 {object} export.Job
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /me/export [post]
+func (h *ExportsHandler) RequestUser(c *gin.Context) {
+	uid, ok := middleware.UserID(c)
+	if !ok || uid == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing_user"})
+		return
+	}
+	j, err := h.svc.RequestUser(uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "export_failed"})
+		return
+	}
+
+	// Evento de conta (sem org)
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		ActorID:  uid,
+		Action:   "user.export_requested",
+		Entity:   "export",
+		EntityID: j.ID,
+	}))
+
+	c.JSON(http.StatusAccepted, j)
+}
+
+// RequestOrg queues an export of the org's data for the requesting owner.
+// @Summary Export organization data
+// @Tags Organizations
+// @Security BearerAuth
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Organization ID"
+// @Success 202 {object} export.Job
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orgs/{id}/export [post]
+func (h *ExportsHandler) RequestOrg(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	uid, _ := middleware.UserID(c)
+
+	j, err := h.svc.RequestOrg(orgID, uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "export_failed"})
+		return
+	}
+
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID:    orgID,
+		ActorID:  uid,
+		Action:   "org.export_requested",
+		Entity:   "export",
+		EntityID: j.ID,
+	}))
+
+	c.JSON(http.StatusAccepted, j)
+}
+
+// Get returns the status of an export requested by the authenticated user
+// and, once done, a fresh presigned download link.
+// @Summary Get export status
+// @Tags User
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Export ID"
+// @Success 200 {object} ExportResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /me/exports/{id} [get]
+func (h *ExportsHandler) Get(c *gin.Context) {
+	uid, ok := middleware.UserID(c)
+	if !ok || uid == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing_user"})
+		return
+	}
+	j, err := h.svc.Get(c.Param("id"), uid)
+	if errors.Is(err, export.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "lookup_failed"})
+		return
+	}
+	out := gin.H{"export": j}
+	if j.Status == export.StatusDone {
+		url, err := h.svc.DownloadURL(c.Request.Context(), j)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "presign_failed"})
+			return
+		}
+		out["download_url"] = url
+		out["expires_in"] = int(export.LinkTTL.Seconds())
+	}
+	c.JSON(http.StatusOK, out)
+}
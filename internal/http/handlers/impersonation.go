@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ulpio/vergo/internal/auth"
+	"github.com/Ulpio/vergo/internal/domain/audit"
+	"github.com/Ulpio/vergo/internal/domain/platform"
+	"github.com/Ulpio/vergo/internal/domain/user"
+	"github.com/Ulpio/vergo/internal/http/middleware"
+	"github.com/Ulpio/vergo/internal/pkg/config"
+)
+
+// auditEvent tags e with the platform admin impersonating the actor, if the
+// request carries an impersonation token.
+func auditEvent(c *gin.Context, e audit.Event) audit.Event {
+	if id, ok := middleware.Impersonator(c); ok {
+		e.ImpersonatorID = id
+	}
+	return e
+}
+
+type ImpersonationHandler struct {
+	cfg  config.Config
+	keys *auth.Keyring
+	us   user.Service
+	ps   platform.Service
+	as   audit.Service
+}
+
+func NewImpersonationHandler(cfg config.Config, keys *auth.Keyring, us user.Service, ps platform.Service, as audit.Service) *ImpersonationHandler {
+	return &ImpersonationHandler{cfg: cfg, keys: keys, us: us, ps: ps, as: as}
+}
+
+type impersonateIn struct {
+	UserID string `json:"user_id" binding:"required"`
+	Reason string `json:"reason" binding:"required,max=500"` // e.g. the support ticket
+}
+
+// Start mints a short-lived access token that lets a platform admin act as
+// a user. The token carries the admin in its act claim, cannot be refreshed
+// and is rejected by orgs that block impersonation.
+// @Summary Impersonate a user
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body impersonateIn true "Target user and reason"
+// @Success 200 {object} ImpersonationResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/impersonate [post]
+func (h *ImpersonationHandler) Start(c *gin.Context) {
+	adminID, _ := middleware.UserID(c)
+	var in impersonateIn
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+	if in.UserID == adminID {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "cannot_impersonate_self"})
+		return
+	}
+	target, err := h.us.GetByID(in.UserID)
+	if errors.Is(err, user.ErrNotFound) || target.ID == user.DeletedUserID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user_not_found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "lookup_failed"})
+		return
+	}
+	// Admins cannot borrow each other's platform access
+	if isAdmin, err := h.ps.IsAdmin(target.ID); err != nil || isAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "cannot_impersonate_admin"})
+		return
+	}
+
+	ttl := h.cfg.ImpersonationTTLMinutes
+	token, err := auth.NewImpersonationToken(target.ID, adminID, h.keys, ttl)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "token_error"})
+		return
+	}
+
+	slog.Warn("impersonation started", "admin_id", adminID, "user_id", target.ID, "ip", c.ClientIP())
+	// Evento de conta (sem org)
+	_ = h.as.Record(audit.Event{
+		ActorID:  adminID,
+		Action:   "user.impersonation_started",
+		Entity:   "user",
+		EntityID: target.ID,
+		Metadata: toAuditMeta(map[string]any{
+			"reason":      in.Reason,
+			"ttl_minutes": ttl,
+			"ip":          c.ClientIP(),
+			"user_agent":  c.Request.UserAgent(),
+		}),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"access_token":    token,
+		"expires_in":      ttl * 60,
+		"user_id":         target.ID,
+		"impersonator_id": adminID,
+	})
+}
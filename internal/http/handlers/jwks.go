@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ulpio/vergo/internal/auth"
+)
+
+type JWKSHandler struct {
+	keys *auth.Keyring
+}
+
+func NewJWKSHandler(keys *auth.Keyring) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// Get serves the public keys that verify Vergo access tokens, so other
+// services can validate them without a shared secret.
+// @Summary JSON Web Key Set
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} auth.JWKSet
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) Get(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
@@ -1,22 +1,37 @@
 package handlers
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
+	"strings"
 
+	"github.com/Ulpio/vergo/internal/domain/audit"
+	"github.com/Ulpio/vergo/internal/domain/export"
 	"github.com/Ulpio/vergo/internal/domain/org"
 	"github.com/Ulpio/vergo/internal/domain/user"
+	"github.com/Ulpio/vergo/internal/domain/userctx"
 	"github.com/Ulpio/vergo/internal/http/middleware"
 	"github.com/gin-gonic/gin"
 )
 
 type MeHandler struct {
-	us user.Service
-	os org.Service
+	us  user.Service
+	os  org.Service
+	ctx userctx.Service
+	as  audit.Service
+	es  export.Service
 }
 
-func NewMeHandler(us user.Service, os org.Service) *MeHandler { return &MeHandler{us: us, os: os} }
+func NewMeHandler(us user.Service, os org.Service, ctx userctx.Service, as audit.Service, es export.Service) *MeHandler {
+	return &MeHandler{us: us, os: os, ctx: ctx, as: as, es: es}
+}
+
+// meOrgsLimit caps the memberships embedded in /me; GET /orgs pages the rest.
+const meOrgsLimit = 100
 
-// Get returns the authenticated user's profile.
+// Get returns the authenticated user's profile, their memberships and the
+// persisted active org.
 // @Summary Get current user
 // @Tags User
 // @Security BearerAuth
@@ -38,9 +53,9 @@ func (h *MeHandler) Get(c *gin.Context) {
 	}
 	// Opcional: se vier X-Org-ID, retornamos também a role do membership
 	orgID := c.GetHeader("X-Org-ID")
-	out := gin.H{
-		"id":    u.ID,
-		"email": u.Email,
+	out := meJSON(u)
+	if actor, ok := middleware.Impersonator(c); ok {
+		out["impersonator_id"] = actor
 	}
 	if orgID != "" && h.os != nil {
 		if ok, role, _ := h.os.IsMember(orgID, uid); ok {
@@ -48,5 +63,176 @@ func (h *MeHandler) Get(c *gin.Context) {
 			out["role"] = role
 		}
 	}
-	c.JSON(http.StatusOK, gin.H{"user": out})
+	resp := gin.H{"user": out}
+	if h.os != nil {
+		orgs, err := h.os.ListForUser(uid, meOrgsLimit, 0)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "fetch_failed"})
+			return
+		}
+		resp["memberships"] = orgs
+	}
+	// org ativa persistida (POST /context), se ainda for membro
+	if h.ctx != nil {
+		if id, ok, _ := h.ctx.GetActiveOrg(uid); ok {
+			if member, _, _ := h.os.IsMember(id, uid); member {
+				resp["active_org_id"] = id
+			}
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func meJSON(u user.User) gin.H {
+	return gin.H{
+		"id":             u.ID,
+		"email":          u.Email,
+		"email_verified": u.EmailVerified,
+		"display_name":   u.DisplayName,
+		"avatar_file_id": u.AvatarFileID,
+		"locale":         u.Locale,
+		"timezone":       u.Timezone,
+	}
+}
+
+type updateMeIn struct {
+	DisplayName  *string `json:"display_name" binding:"omitempty,max=100"`
+	AvatarFileID *string `json:"avatar_file_id"`
+	Locale       *string `json:"locale" binding:"omitempty,max=35"`
+	Timezone     *string `json:"timezone" binding:"omitempty,max=64"`
+}
+
+// Update changes profile fields; omitted fields are kept and empty strings
+// clear them. The avatar must be a file the user uploaded.
+// @Summary Update current user profile
+// @Tags User
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body updateMeIn true "Profile fields"
+// @Success 200 {object} MeResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /me [patch]
+func (h *MeHandler) Update(c *gin.Context) {
+	uid, ok := middleware.UserID(c)
+	if !ok || uid == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing_user"})
+		return
+	}
+	var in updateMeIn
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+	u, err := h.us.UpdateProfile(uid, user.ProfileUpdate{
+		DisplayName:  in.DisplayName,
+		AvatarFileID: in.AvatarFileID,
+		Locale:       in.Locale,
+		Timezone:     in.Timezone,
+	})
+	switch {
+	case errors.Is(err, user.ErrNotFound):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_user"})
+		return
+	case errors.Is(err, user.ErrAvatarNotFound):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "avatar_not_found"})
+		return
+	case errors.Is(err, user.ErrInvalidLocale):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_locale"})
+		return
+	case errors.Is(err, user.ErrInvalidTimezone):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_timezone"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "update_failed"})
+		return
+	}
+
+	// Evento de conta (sem org)
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		ActorID:  uid,
+		Action:   "user.profile_updated",
+		Entity:   "user",
+		EntityID: uid,
+		Metadata: toAuditMeta(in),
+	}))
+
+	c.JSON(http.StatusOK, gin.H{"user": meJSON(u)})
+}
+
+type deleteMeIn struct {
+	ConfirmEmail string `json:"confirm_email" binding:"required"`
+}
+
+// Delete permanently deletes the authenticated user's account. The caller
+// confirms by repeating their email. It is refused while the user is the
+// sole owner of an org; otherwise memberships, tokens and sessions are
+// removed and projects, files, API keys and audit entries are kept but
+// attributed to a "deleted-user" placeholder.
+// @Summary Delete current user account
+// @Tags User
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body deleteMeIn true "Email confirmation"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} SoleOwnerResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /me [delete]
+func (h *MeHandler) Delete(c *gin.Context) {
+	uid, ok := middleware.UserID(c)
+	if !ok || uid == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing_user"})
+		return
+	}
+	var in deleteMeIn
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+	u, err := h.us.GetByID(uid)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_user"})
+		return
+	}
+	if !strings.EqualFold(strings.TrimSpace(in.ConfirmEmail), u.Email) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "confirmation_mismatch"})
+		return
+	}
+
+	// Os arquivos de exportação saem antes da conta: depois dela nenhum job
+	// aponta para eles e a limpeza periódica não os encontraria
+	if err := h.es.DeleteArchives(c.Request.Context(), export.KindUser, uid); err != nil {
+		slog.Error("delete account exports", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "delete_failed"})
+		return
+	}
+	err = h.us.Delete(uid)
+	var sole *user.SoleOwnerError
+	switch {
+	case errors.As(err, &sole):
+		c.JSON(http.StatusConflict, gin.H{"error": "sole_owner", "org_ids": sole.OrgIDs})
+		return
+	case errors.Is(err, user.ErrNotFound):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_user"})
+		return
+	case err != nil:
+		slog.Error("delete account", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "delete_failed"})
+		return
+	}
+
+	// Evento de conta (sem org); o usuário já não existe
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		ActorID:  user.DeletedUserID,
+		Action:   "user.deleted",
+		Entity:   "user",
+		EntityID: user.DeletedUserID,
+	}))
+
+	c.Status(http.StatusNoContent)
 }
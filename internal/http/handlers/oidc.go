@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ulpio/vergo/internal/auth/oidc"
+	"github.com/Ulpio/vergo/internal/domain/identity"
+)
+
+const oidcStateTTL = 10 * time.Minute
+
+type OIDCHandler struct {
+	tokens *AuthHandler
+	reg    *oidc.Registry
+	states oidc.StateStore
+	ids    identity.Service
+}
+
+func NewOIDCHandler(tokens *AuthHandler, reg *oidc.Registry, states oidc.StateStore, ids identity.Service) *OIDCHandler {
+	return &OIDCHandler{tokens: tokens, reg: reg, states: states, ids: ids}
+}
+
+// Providers lists the enabled external identity providers.
+// @Summary List identity providers
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} map[string][]string
+// @Router /auth/oidc/providers [get]
+func (h *OIDCHandler) Providers(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": h.reg.Names()})
+}
+
+// Start redirects the browser to the provider's authorization endpoint.
+// @Summary Start social/enterprise login
+// @Tags Auth
+// @Param provider path string true "Provider name (e.g. google, github)"
+// @Success 302 "Redirect to identity provider"
+// @Failure 404 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Router /auth/oidc/{provider}/start [get]
+func (h *OIDCHandler) Start(c *gin.Context) {
+	p, err := h.reg.Get(c.Param("provider"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown_provider"})
+		return
+	}
+
+	state, err := oidc.RandomToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "state_error"})
+		return
+	}
+	nonce, err := oidc.RandomToken(16)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "state_error"})
+		return
+	}
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "state_error"})
+		return
+	}
+
+	pending := oidc.PendingAuth{Provider: p.Name(), CodeVerifier: verifier, Nonce: nonce}
+	if err := h.states.Save(c.Request.Context(), state, pending, oidcStateTTL); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "store_error"})
+		return
+	}
+
+	u, err := p.AuthCodeURL(c.Request.Context(), state, nonce, challenge)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "oidc: auth url", "provider", p.Name(), "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "provider_unavailable"})
+		return
+	}
+	c.Redirect(http.StatusFound, u)
+}
+
+// Callback completes the code exchange and returns a Vergo token pair.
+// @Summary Complete social/enterprise login
+// @Tags Auth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State returned by the provider"
+// @Success 200 {object} AuthResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/oidc/{provider}/callback [get]
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	p, err := h.reg.Get(c.Param("provider"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown_provider"})
+		return
+	}
+	if e := c.Query("error"); e != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "oidc_denied", "detail": e})
+		return
+	}
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+
+	pending, err := h.states.Consume(c.Request.Context(), state)
+	if err != nil || pending.Provider != p.Name() {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_state"})
+		return
+	}
+
+	ext, err := p.Exchange(c.Request.Context(), code, pending.CodeVerifier, pending.Nonce)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "oidc: exchange failed", "provider", p.Name(), "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "oidc_exchange_failed"})
+		return
+	}
+
+	u, created, err := h.ids.Resolve(identity.External{
+		Provider:      ext.Provider,
+		Subject:       ext.Subject,
+		Email:         ext.Email,
+		EmailVerified: ext.EmailVerified,
+	})
+	switch {
+	case errors.Is(err, identity.ErrAccountExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "account_exists"})
+		return
+	case errors.Is(err, identity.ErrEmailRequired):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "email_required"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "resolve_failed"})
+		return
+	}
+
+	pair, err := h.tokens.issueTokens(c, u.ID)
+	if err != nil {
+		respondTokenError(c, err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, gin.H{
+		"user":          gin.H{"id": u.ID, "email": u.Email},
+		"access_token":  pair.AcessToken,
+		"refresh_token": pair.RefreshToken,
+	})
+}
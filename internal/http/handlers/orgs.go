@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
@@ -10,15 +11,17 @@ import (
 	"github.com/Ulpio/vergo/internal/domain/audit"
 	"github.com/Ulpio/vergo/internal/domain/org"
 	"github.com/Ulpio/vergo/internal/http/middleware"
+	"github.com/Ulpio/vergo/internal/pkg/precondition"
 )
 
 type OrgsHandler struct {
-	os org.Service
-	as audit.Service
+	os            org.Service
+	as            audit.Service
+	restoreWindow time.Duration // deleted orgs can be restored for this long
 }
 
-func NewOrgsHandler(os org.Service, as audit.Service) *OrgsHandler {
-	return &OrgsHandler{os: os, as: as}
+func NewOrgsHandler(os org.Service, as audit.Service, restoreWindow time.Duration) *OrgsHandler {
+	return &OrgsHandler{os: os, as: as, restoreWindow: restoreWindow}
 }
 
 type createOrgIn struct {
@@ -55,40 +58,193 @@ func (h *OrgsHandler) Create(c *gin.Context) {
 	}
 
 	after, _ := json.Marshal(o)
-	_ = h.as.Record(audit.Event{
+	_ = h.as.Record(auditEvent(c, audit.Event{
 		OrgID: o.ID, ActorID: uid, Action: "org.created",
 		Entity: "org", EntityID: o.ID, Timestamp: time.Now(),
 		Metadata: audit.Metadata{After: after},
-	})
+	}))
 
 	c.JSON(http.StatusCreated, o)
 }
 
-// Get returns an organization by ID.
+// Get returns the organization the caller is a member of, with its
+// version as the ETag.
 // @Summary Get organization
 // @Tags Organizations
 // @Security BearerAuth
 // @Produce json
+// @Param If-None-Match header string false "ETag the client holds"
 // @Param id path string true "Organization ID"
 // @Success 200 {object} org.Organization
+// @Header 200 {string} ETag "Organization version"
+// @Success 304 "Not Modified"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Router /orgs/{id} [get]
 func (h *OrgsHandler) Get(c *gin.Context) {
-	id := c.Param("id")
-	o, err := h.os.Get(id)
+	orgID, _ := middleware.OrgID(c)
+	o, err := h.os.Get(orgID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+		return
+	}
+	if middleware.SetETag(c, o.Version) {
+		return
+	}
+	c.JSON(http.StatusOK, o)
+}
+
+type updateOrgIn struct {
+	Name         *string         `json:"name"`
+	Slug         *string         `json:"slug"`
+	Settings     json.RawMessage `json:"settings" swaggertype:"object"` // replaces the whole document
+	LogoFileID   *string         `json:"logo_file_id"`                  // "" removes the logo
+	PrimaryColor *string         `json:"primary_color"`                 // #RRGGBB, "" resets
+}
+
+// Update changes the org's name, slug, settings and branding. Omitted
+// fields stay as they are. With If-Match the update only applies to that
+// version.
+// @Summary Update organization
+// @Tags Organizations
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param If-Match header string false "ETag the update applies to"
+// @Param id path string true "Organization ID"
+// @Param body body updateOrgIn true "Fields to change"
+// @Success 200 {object} org.Organization
+// @Header 200 {string} ETag "New organization version"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orgs/{id} [patch]
+func (h *OrgsHandler) Update(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	actorID, _ := middleware.UserID(c)
+	var in updateOrgIn
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+	upd := org.ProfileUpdate{Name: in.Name, Slug: in.Slug, LogoFileID: in.LogoFileID, PrimaryColor: in.PrimaryColor}
+	if len(in.Settings) > 0 {
+		st, err := org.ParseSettings(in.Settings)
+		if err != nil {
+			respondOrgUpdateError(c, err)
+			return
+		}
+		upd.Settings = &st
+	}
+
+	before, err := h.os.Get(orgID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
 		return
 	}
+	o, err := h.os.UpdateProfile(orgID, upd, middleware.IfMatch(c))
+	if err != nil {
+		respondOrgUpdateError(c, err)
+		return
+	}
+
+	b, _ := json.Marshal(before)
+	after, _ := json.Marshal(o)
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID: orgID, ActorID: actorID, Action: "org.updated",
+		Entity: "org", EntityID: orgID, Timestamp: time.Now(),
+		Metadata: audit.Metadata{Before: b, After: after},
+	}))
+
+	middleware.SetETag(c, o.Version)
 	c.JSON(http.StatusOK, o)
 }
 
+func respondOrgUpdateError(c *gin.Context, err error) {
+	var se *org.SettingsError
+	switch {
+	case errors.As(err, &se):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_settings", "field": se.Field, "detail": se.Reason})
+	case errors.Is(err, org.ErrSlugTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "slug_taken"})
+	case errors.Is(err, org.ErrInvalidSlug):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_slug"})
+	case errors.Is(err, org.ErrInvalidName):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_name"})
+	case errors.Is(err, org.ErrInvalidColor):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_color"})
+	case errors.Is(err, org.ErrInvalidLogo):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_logo"})
+	case errors.Is(err, org.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+	case errors.Is(err, precondition.ErrFailed):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "precondition_failed"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "update_failed"})
+	}
+}
+
+// List returns the orgs the user belongs to, with their role in each, for
+// an org switcher (see POST /context).
+// @Summary List my organizations
+// @Tags Organizations
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Items per page (max 100)" default(20)
+// @Success 200 {object} map[string]interface{} "items, page, page_size"
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orgs [get]
+func (h *OrgsHandler) List(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	page, pageSize := pageParams(c)
+	items, err := h.os.ListForUser(uid, pageSize, (page-1)*pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "list_failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items, "page": page, "page_size": pageSize})
+}
+
+// ListMembers returns the org's members with their email and profile.
+// @Summary List members
+// @Tags Organizations
+// @Security BearerAuth
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Organization ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Items per page (max 100)" default(20)
+// @Success 200 {object} map[string]interface{} "items, page, page_size"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orgs/{id}/members [get]
+func (h *OrgsHandler) ListMembers(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	page, pageSize := pageParams(c)
+	items, err := h.os.ListMembers(orgID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "list_failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items, "page": page, "page_size": pageSize})
+}
+
 type memberIn struct {
 	UserID string `json:"user_id" binding:"required"`
-	Role   string `json:"role" binding:"required"` // owner|admin|member
+	Role   string `json:"role"` // owner|admin|member or a custom role; default from settings
 }
 
-// AddMember adds a user to an organization.
+// AddMember adds a user to an organization, or changes the role of an
+// existing member. Nobody grants a role above their own. Without a role
+// the org's default_role setting applies.
 // @Summary Add member
 // @Tags Organizations
 // @Security BearerAuth
@@ -100,33 +256,51 @@ type memberIn struct {
 // @Success 204 "No Content"
 // @Failure 401 {object} ErrorResponse
 // @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 422 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /orgs/{id}/members [post]
 func (h *OrgsHandler) AddMember(c *gin.Context) {
-	orgID := c.Param("id")
+	orgID, _ := middleware.OrgID(c)
 	actorID, _ := middleware.UserID(c)
 	var in memberIn
 	if err := c.ShouldBindJSON(&in); err != nil {
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
 		return
 	}
+	if in.Role == "" {
+		o, err := h.os.Get(orgID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "update_failed"})
+			return
+		}
+		in.Role = o.Settings.MemberRole()
+	}
+	current, ok := h.checkMemberChange(c, orgID, in.UserID, in.Role)
+	if !ok {
+		return
+	}
 	if err := h.os.AddMember(orgID, in.UserID, in.Role); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "add_failed"})
+		respondMemberError(c, err)
 		return
 	}
 
 	after, _ := json.Marshal(gin.H{"user_id": in.UserID, "role": in.Role})
-	_ = h.as.Record(audit.Event{
+	meta := audit.Metadata{After: after}
+	if current != "" {
+		meta.Before, _ = json.Marshal(gin.H{"user_id": in.UserID, "role": current})
+	}
+	_ = h.as.Record(auditEvent(c, audit.Event{
 		OrgID: orgID, ActorID: actorID, Action: "member.added",
 		Entity: "membership", EntityID: in.UserID, Timestamp: time.Now(),
-		Metadata: audit.Metadata{After: after},
-	})
+		Metadata: meta,
+	}))
 
 	c.Status(http.StatusNoContent)
 }
 
-// UpdateMember changes a member's role.
+// UpdateMember changes a member's role. Nobody grants a role above their
+// own or changes a member who outranks them, and the last owner stays.
 // @Summary Update member role
 // @Tags Organizations
 // @Security BearerAuth
@@ -139,12 +313,14 @@ func (h *OrgsHandler) AddMember(c *gin.Context) {
 // @Success 204 "No Content"
 // @Failure 401 {object} ErrorResponse
 // @Failure 403 {object} ErrorResponse
-// @Failure 404 {object} ErrorDetailResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Router /orgs/{id}/members/{userId} [patch]
 func (h *OrgsHandler) UpdateMember(c *gin.Context) {
-	orgID := c.Param("id")
-	userID := c.Param("userID")
+	orgID, _ := middleware.OrgID(c)
+	userID := c.Param("userId")
 	actorID, _ := middleware.UserID(c)
 	var in struct {
 		Role string `json:"role" binding:"required"`
@@ -153,22 +329,28 @@ func (h *OrgsHandler) UpdateMember(c *gin.Context) {
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
 		return
 	}
+	current, ok := h.checkMemberChange(c, orgID, userID, in.Role)
+	if !ok {
+		return
+	}
 	if err := h.os.UpdateMember(orgID, userID, in.Role); err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "updated_failed", "detail": err.Error()})
+		respondMemberError(c, err)
 		return
 	}
 
+	before, _ := json.Marshal(gin.H{"user_id": userID, "role": current})
 	after, _ := json.Marshal(gin.H{"user_id": userID, "role": in.Role})
-	_ = h.as.Record(audit.Event{
+	_ = h.as.Record(auditEvent(c, audit.Event{
 		OrgID: orgID, ActorID: actorID, Action: "member.updated",
 		Entity: "membership", EntityID: userID, Timestamp: time.Now(),
-		Metadata: audit.Metadata{After: after},
-	})
+		Metadata: audit.Metadata{Before: before, After: after},
+	}))
 
 	c.Status(http.StatusNoContent)
 }
 
-// RemoveMember removes a user from an organization.
+// RemoveMember removes a user from an organization. Members who outrank the
+// caller and the last owner cannot be removed.
 // @Summary Remove member
 // @Tags Organizations
 // @Security BearerAuth
@@ -179,55 +361,375 @@ func (h *OrgsHandler) UpdateMember(c *gin.Context) {
 // @Success 204 "No Content"
 // @Failure 401 {object} ErrorResponse
 // @Failure 403 {object} ErrorResponse
-// @Failure 404 {object} ErrorDetailResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Router /orgs/{id}/members/{userId} [delete]
 func (h *OrgsHandler) RemoveMember(c *gin.Context) {
-	orgID := c.Param("id")
-	userID := c.Param("userID")
+	orgID, _ := middleware.OrgID(c)
+	userID := c.Param("userId")
 	actorID, _ := middleware.UserID(c)
 
+	current, ok := h.checkMemberChange(c, orgID, userID, "")
+	if !ok {
+		return
+	}
 	if err := h.os.RemoveMember(orgID, userID); err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "failed_remove_member", "detail": err.Error()})
+		respondMemberError(c, err)
 		return
 	}
 
-	before, _ := json.Marshal(gin.H{"user_id": userID})
-	_ = h.as.Record(audit.Event{
+	before, _ := json.Marshal(gin.H{"user_id": userID, "role": current})
+	_ = h.as.Record(auditEvent(c, audit.Event{
 		OrgID: orgID, ActorID: actorID, Action: "member.removed",
 		Entity: "membership", EntityID: userID, Timestamp: time.Now(),
 		Metadata: audit.Metadata{Before: before},
-	})
+	}))
 
 	c.Status(http.StatusNoContent)
 }
 
-// Delete removes an organization (owner only).
+// checkMemberChange applies the membership policy to the caller moving
+// userID to role next ("" removes them), writing the error response when it
+// refuses. It returns the member's current role, "" for non-members.
+func (h *OrgsHandler) checkMemberChange(c *gin.Context, orgID, userID, next string) (string, bool) {
+	_, current, err := h.os.IsMember(orgID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "lookup_failed"})
+		return "", false
+	}
+	actorRole, _ := middleware.Role(c)
+	if err := h.os.AuthorizeRoleChange(orgID, actorRole, current, next); err != nil {
+		respondMemberError(c, err)
+		return "", false
+	}
+	return current, true
+}
+
+func respondMemberError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, org.ErrInvalidRole):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_role"})
+	case errors.Is(err, org.ErrRoleAboveActor):
+		c.JSON(http.StatusForbidden, gin.H{"error": "role_above_own"})
+	case errors.Is(err, org.ErrMemberOutranks):
+		c.JSON(http.StatusForbidden, gin.H{"error": "member_outranks_you"})
+	case errors.Is(err, org.ErrLastOwner):
+		c.JSON(http.StatusConflict, gin.H{"error": "last_owner"})
+	case errors.Is(err, org.ErrNotMember):
+		c.JSON(http.StatusNotFound, gin.H{"error": "member_not_found"})
+	case errors.Is(err, org.ErrEmailDomainForbidden):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "email_domain_not_allowed"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "update_failed"})
+	}
+}
+
+type deleteOrgOut struct {
+	DeletedAt    time.Time `json:"deleted_at"`
+	RestoreUntil time.Time `json:"restore_until"` // purged for good afterwards
+}
+
+// Delete soft-deletes an organization (owner only). Its members lose access
+// at once; the owner can restore it until restore_until, after which the
+// purge worker removes its projects, files, webhooks, API keys and
+// subscription. With If-Match only that version is deleted.
 // @Summary Delete organization
 // @Tags Organizations
 // @Security BearerAuth
 // @Produce json
 // @Param X-Org-ID header string true "Organization ID"
+// @Param If-Match header string false "ETag the deletion applies to"
 // @Param id path string true "Organization ID"
-// @Success 204 "No Content"
+// @Success 202 {object} deleteOrgOut
 // @Failure 401 {object} ErrorResponse
 // @Failure 403 {object} ErrorResponse
-// @Failure 404 {object} ErrorDetailResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Router /orgs/{id} [delete]
 func (h *OrgsHandler) Delete(c *gin.Context) {
-	orgID := c.Param("id")
+	orgID, _ := middleware.OrgID(c)
 	actorID, _ := middleware.UserID(c)
 
-	if err := h.os.Delete(orgID); err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "failed_delete_organization", "detail": err.Error()})
+	if err := h.os.Delete(orgID, actorID, middleware.IfMatch(c)); err != nil {
+		if errors.Is(err, org.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+			return
+		}
+		if errors.Is(err, precondition.ErrFailed) {
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": "precondition_failed"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "delete_failed"})
+		return
+	}
+	o, err := h.os.Get(orgID)
+	if err != nil || o.DeletedAt == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "delete_failed"})
 		return
 	}
 
-	before, _ := json.Marshal(gin.H{"org_id": orgID})
-	_ = h.as.Record(audit.Event{
+	after, _ := json.Marshal(gin.H{"deleted_at": o.DeletedAt})
+	_ = h.as.Record(auditEvent(c, audit.Event{
 		OrgID: orgID, ActorID: actorID, Action: "org.deleted",
 		Entity: "org", EntityID: orgID, Timestamp: time.Now(),
-		Metadata: audit.Metadata{Before: before},
+		Metadata: audit.Metadata{After: after},
+	}))
+
+	c.JSON(http.StatusAccepted, deleteOrgOut{
+		DeletedAt:    *o.DeletedAt,
+		RestoreUntil: o.DeletedAt.Add(h.restoreWindow),
 	})
+}
+
+// Restore undoes the deletion of an organization within the restore
+// window. Only its owners may; no tenant is needed since the org is
+// inaccessible while deleted.
+// @Summary Restore deleted organization
+// @Tags Organizations
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Success 200 {object} org.Organization
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 410 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orgs/{id}/restore [post]
+func (h *OrgsHandler) Restore(c *gin.Context) {
+	orgID := c.Param("id")
+	actorID, _ := middleware.UserID(c)
+
+	m, err := h.os.GetMembership(orgID, actorID)
+	if errors.Is(err, org.ErrNotMember) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "restore_failed"})
+		return
+	}
+	if m.Role != "owner" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient_role"})
+		return
+	}
+
+	if err := h.os.Restore(orgID, time.Now().Add(-h.restoreWindow)); err != nil {
+		switch {
+		case errors.Is(err, org.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+		case errors.Is(err, org.ErrNotDeleted):
+			c.JSON(http.StatusConflict, gin.H{"error": "org_not_deleted"})
+		case errors.Is(err, org.ErrRestoreExpired):
+			c.JSON(http.StatusGone, gin.H{"error": "restore_window_expired"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "restore_failed"})
+		}
+		return
+	}
+	o, err := h.os.Get(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "restore_failed"})
+		return
+	}
+
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID: orgID, ActorID: actorID, Action: "org.restored",
+		Entity: "org", EntityID: orgID, Timestamp: time.Now(),
+	}))
+
+	c.JSON(http.StatusOK, o)
+}
+
+type impersonationIn struct {
+	Allowed *bool `json:"allowed" binding:"required"`
+}
+
+// SetImpersonation lets or stops platform admins acting as members of the
+// org (owner only). Blocking takes effect on the next request.
+// @Summary Allow or block impersonation
+// @Tags Organizations
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Organization ID"
+// @Param body body impersonationIn true "Whether impersonation is allowed"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orgs/{id}/impersonation [put]
+func (h *OrgsHandler) SetImpersonation(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	actorID, _ := middleware.UserID(c)
+	var in impersonationIn
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+	if err := h.os.SetImpersonationAllowed(orgID, *in.Allowed); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "update_failed"})
+		return
+	}
+
+	after, _ := json.Marshal(gin.H{"allow_impersonation": *in.Allowed})
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID: orgID, ActorID: actorID, Action: "org.impersonation_updated",
+		Entity: "org", EntityID: orgID, Timestamp: time.Now(),
+		Metadata: audit.Metadata{After: after},
+	}))
 
 	c.Status(http.StatusNoContent)
 }
+
+type transferIn struct {
+	UserID string `json:"user_id" binding:"required"`
+}
+
+// StartTransfer proposes handing the org to another member (owner only).
+// Nothing changes until that member accepts; a new proposal replaces the
+// pending one.
+// @Summary Start ownership transfer
+// @Tags Organizations
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Organization ID"
+// @Param body body transferIn true "New owner"
+// @Success 202 {object} org.Transfer
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orgs/{id}/transfer-ownership [post]
+func (h *OrgsHandler) StartTransfer(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	actorID, _ := middleware.UserID(c)
+	var in transferIn
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+	t, err := h.os.StartOwnershipTransfer(orgID, actorID, in.UserID)
+	if err != nil {
+		respondTransferError(c, err)
+		return
+	}
+
+	after, _ := json.Marshal(t)
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID: orgID, ActorID: actorID, Action: "org.ownership_transfer_started",
+		Entity: "org", EntityID: orgID, Timestamp: time.Now(),
+		Metadata: audit.Metadata{After: after},
+	}))
+
+	c.JSON(http.StatusAccepted, t)
+}
+
+// GetTransfer returns the pending ownership transfer, if any.
+// @Summary Get pending ownership transfer
+// @Tags Organizations
+// @Security BearerAuth
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Organization ID"
+// @Success 200 {object} org.Transfer
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orgs/{id}/transfer-ownership [get]
+func (h *OrgsHandler) GetTransfer(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	t, err := h.os.GetOwnershipTransfer(orgID)
+	if err != nil {
+		respondTransferError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+// AcceptTransfer makes the caller the owner, if they are the proposed new
+// owner. The previous owner stays on as admin.
+// @Summary Accept ownership transfer
+// @Tags Organizations
+// @Security BearerAuth
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Organization ID"
+// @Success 200 {object} org.Transfer
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orgs/{id}/transfer-ownership/accept [post]
+func (h *OrgsHandler) AcceptTransfer(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	actorID, _ := middleware.UserID(c)
+	t, err := h.os.AcceptOwnershipTransfer(orgID, actorID)
+	if err != nil {
+		respondTransferError(c, err)
+		return
+	}
+
+	before, _ := json.Marshal(gin.H{"owner_user_id": t.FromUserID})
+	after, _ := json.Marshal(gin.H{"owner_user_id": t.ToUserID})
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID: orgID, ActorID: actorID, Action: "org.ownership_transfer_accepted",
+		Entity: "org", EntityID: orgID, Timestamp: time.Now(),
+		Metadata: audit.Metadata{Before: before, After: after},
+	}))
+
+	c.JSON(http.StatusOK, t)
+}
+
+// CancelTransfer withdraws (owner) or declines (proposed owner) the pending
+// ownership transfer.
+// @Summary Cancel ownership transfer
+// @Tags Organizations
+// @Security BearerAuth
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Organization ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orgs/{id}/transfer-ownership [delete]
+func (h *OrgsHandler) CancelTransfer(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	actorID, _ := middleware.UserID(c)
+	if err := h.os.CancelOwnershipTransfer(orgID, actorID); err != nil {
+		respondTransferError(c, err)
+		return
+	}
+
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID: orgID, ActorID: actorID, Action: "org.ownership_transfer_canceled",
+		Entity: "org", EntityID: orgID, Timestamp: time.Now(),
+	}))
+
+	c.Status(http.StatusNoContent)
+}
+
+func respondTransferError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, org.ErrNotOwner):
+		c.JSON(http.StatusForbidden, gin.H{"error": "not_owner"})
+	case errors.Is(err, org.ErrTransferToSelf):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "transfer_to_self"})
+	case errors.Is(err, org.ErrNotMember):
+		c.JSON(http.StatusNotFound, gin.H{"error": "member_not_found"})
+	case errors.Is(err, org.ErrNoTransfer):
+		c.JSON(http.StatusNotFound, gin.H{"error": "no_pending_transfer"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "transfer_failed"})
+	}
+}
@@ -2,31 +2,88 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/Ulpio/vergo/internal/domain/audit"
+	"github.com/Ulpio/vergo/internal/domain/org"
 	"github.com/Ulpio/vergo/internal/domain/project"
 	"github.com/Ulpio/vergo/internal/http/middleware"
+	"github.com/Ulpio/vergo/internal/pkg/pagination"
+	"github.com/Ulpio/vergo/internal/pkg/precondition"
 	"github.com/gin-gonic/gin"
 )
 
 type ProjectsHandler struct {
-	ps project.Service
-	as audit.Service
+	ps            project.Service
+	os            org.Service
+	as            audit.Service
+	restoreWindow time.Duration // deleted projects can be restored for this long
 }
 
-func NewProjectsHandler(ps project.Service, as audit.Service) *ProjectsHandler {
-	return &ProjectsHandler{ps: ps, as: as}
+func NewProjectsHandler(ps project.Service, os org.Service, as audit.Service, restoreWindow time.Duration) *ProjectsHandler {
+	return &ProjectsHandler{ps: ps, os: os, as: as, restoreWindow: restoreWindow}
 }
 
-// List returns all projects in the organization.
+// projectActor describes the caller for project access checks. Roles with
+// projects.access_all see every project of the org.
+func projectActor(c *gin.Context, os org.Service) (project.Actor, error) {
+	userID, _ := middleware.UserID(c)
+	all, err := middleware.HasPermission(c, os, org.PermProjectsAll)
+	if err != nil {
+		return project.Actor{}, err
+	}
+	return project.Actor{UserID: userID, APIKey: middleware.IsAPIKey(c), AllProjects: all}, nil
+}
+
+func respondProjectError(c *gin.Context, err error, fallback string) {
+	var ve *project.ValidationError
+	switch {
+	case errors.Is(err, project.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+	case errors.Is(err, project.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient_project_access"})
+	case errors.Is(err, project.ErrInvalidAccess):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_access"})
+	case errors.Is(err, project.ErrInvalidGrant):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_grant"})
+	case errors.Is(err, project.ErrGranteeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "grantee_not_found"})
+	case errors.Is(err, project.ErrGrantNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "grant_not_found"})
+	case errors.Is(err, project.ErrArchived):
+		c.JSON(http.StatusConflict, gin.H{"error": "project_archived"})
+	case errors.Is(err, project.ErrFieldNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "field_not_found"})
+	case errors.Is(err, project.ErrFieldKeyTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "field_key_taken"})
+	case errors.Is(err, project.ErrOptionInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": "option_in_use"})
+	case errors.Is(err, precondition.ErrFailed):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "precondition_failed"})
+	case errors.As(err, &ve):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "validation_failed", "field": ve.Field, "detail": ve.Reason})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback, "detail": err.Error()})
+	}
+}
+
+// List returns a page of the projects of the organization the caller can
+// access. Archived projects are left out unless archived is true or all.
 // @Summary List projects
 // @Tags Projects
 // @Security BearerAuth
 // @Produce json
 // @Param X-Org-ID header string true "Organization ID"
-// @Success 200 {object} map[string][]project.Project "items"
+// @Param q query string false "Full-text search over name and description"
+// @Param tag query string false "Only projects with this tag"
+// @Param created_by query string false "Only projects created by this user"
+// @Param archived query string false "false, true or all" default(false)
+// @Param sort query string false "created_at, name or updated_at; prefix - for descending" default(created_at)
+// @Param limit query int false "Items per page (max 100)" default(20)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} pagination.Page[project.Project]
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 500 {object} ErrorDetailResponse
@@ -37,30 +94,68 @@ func (h *ProjectsHandler) List(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "missing_org_id"})
 		return
 	}
-	items, err := h.ps.List(orgID)
+	a, err := projectActor(c, h.os)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "list_failed", "detail": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "permission_check_failed"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"items": items})
+	limit, cursor, ok := cursorParams(c)
+	if !ok {
+		return
+	}
+	p := project.ListParams{
+		Q:         c.Query("q"),
+		Tag:       c.Query("tag"),
+		CreatedBy: c.Query("created_by"),
+		Sort:      c.Query("sort"),
+		Limit:     limit,
+		Cursor:    cursor,
+	}
+	switch c.DefaultQuery("archived", "false") {
+	case "false":
+		p.Archived = new(bool)
+	case "true":
+		archived := true
+		p.Archived = &archived
+	case "all":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_archived"})
+		return
+	}
+
+	page, err := h.ps.List(orgID, a, p)
+	switch {
+	case errors.Is(err, project.ErrInvalidSort):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_sort"})
+	case errors.Is(err, pagination.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_cursor"})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "list_failed", "detail": err.Error()})
+	default:
+		c.JSON(http.StatusOK, page)
+	}
 }
 
 type ProjectIn struct {
-	Name        string `json:"name" binding:"required"`
-	Description string `json:"description"`
+	Name         string                     `json:"name" binding:"required"`
+	Description  string                     `json:"description"`
+	Tags         []string                   `json:"tags"`
+	CustomFields map[string]json.RawMessage `json:"custom_fields" swaggertype:"object"` // by field key
 }
 
-// Create creates a new project.
+// Create creates a new project with optional tags and custom field values.
 // @Summary Create project
 // @Tags Projects
 // @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param X-Org-ID header string true "Organization ID"
+// @Param Idempotency-Key header string false "Replays the first response to retries with the same key"
 // @Param body body ProjectIn true "Project data"
 // @Success 201 {object} project.Project
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 422 {object} ErrorResponse
 // @Failure 500 {object} ErrorDetailResponse
 // @Router /projects [post]
@@ -80,30 +175,37 @@ func (h *ProjectsHandler) Create(c *gin.Context) {
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
 		return
 	}
-	p, err := h.ps.Create(orgID, in.Name, in.Description, userID)
+	a := project.Actor{UserID: userID, APIKey: middleware.IsAPIKey(c)}
+	p, err := h.ps.Create(orgID, project.Draft{
+		Name: in.Name, Description: in.Description, Tags: in.Tags, CustomFields: in.CustomFields,
+	}, a)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "create_fail", "detail": err.Error()})
+		respondProjectError(c, err, "create_fail")
 		return
 	}
 	after, _ := json.Marshal(p)
-	_ = h.as.Record(audit.Event{
+	_ = h.as.Record(auditEvent(c, audit.Event{
 		OrgID: orgID, ActorID: userID, Action: "project.created",
 		Entity: "project", EntityID: p.ID, Timestamp: time.Now(),
 		Metadata: audit.Metadata{After: after},
-	})
+	}))
 	c.JSON(http.StatusCreated, p)
 }
 
-// Get returns a project by ID.
+// Get returns a project by ID, with its version as the ETag.
 // @Summary Get project
 // @Tags Projects
 // @Security BearerAuth
 // @Produce json
 // @Param X-Org-ID header string true "Organization ID"
+// @Param If-None-Match header string false "ETag the client holds"
 // @Param id path string true "Project ID"
 // @Success 200 {object} project.Project
+// @Header 200 {string} ETag "Project version"
+// @Success 304 "Not Modified"
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorDetailResponse
 // @Router /projects/{id} [get]
 func (h *ProjectsHandler) Get(c *gin.Context) {
 	orgID, ok := middleware.OrgID(c)
@@ -111,27 +213,45 @@ func (h *ProjectsHandler) Get(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "missing_org_id"})
 		return
 	}
-	id := c.Param("id")
-	p, err := h.ps.Get(orgID, id)
+	a, err := projectActor(c, h.os)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "permission_check_failed"})
+		return
+	}
+	p, err := h.ps.Get(orgID, c.Param("id"), a)
+	if err != nil {
+		respondProjectError(c, err, "get_failed")
+		return
+	}
+	if middleware.SetETag(c, p.Version) {
 		return
 	}
 	c.JSON(http.StatusOK, p)
 }
 
-// Update modifies a project.
+// Update applies a JSON Merge Patch (RFC 7396) to a project: omitted
+// members stay, null clears description, tags or custom_fields, and
+// custom_fields merges key by key. Requires editor access; archived
+// projects must be unarchived first. With If-Match the patch only applies
+// to that version.
 // @Summary Update project
 // @Tags Projects
 // @Security BearerAuth
 // @Accept json
+// @Accept application/merge-patch+json
 // @Produce json
 // @Param X-Org-ID header string true "Organization ID"
+// @Param If-Match header string false "ETag the patch applies to"
 // @Param id path string true "Project ID"
-// @Param body body ProjectIn true "Updated project data"
+// @Param body body object true "Merge patch of name, description, tags and custom_fields"
 // @Success 200 {object} project.Project
+// @Header 200 {string} ETag "New project version"
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
 // @Failure 422 {object} ErrorResponse
 // @Failure 500 {object} ErrorDetailResponse
 // @Router /projects/{id} [patch]
@@ -143,42 +263,133 @@ func (h *ProjectsHandler) Update(c *gin.Context) {
 	}
 	userID, _ := middleware.UserID(c)
 	id := c.Param("id")
+	a, err := projectActor(c, h.os)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "permission_check_failed"})
+		return
+	}
 
 	// Capture before state
-	old, _ := h.ps.Get(orgID, id)
+	old, _ := h.ps.Get(orgID, id, a)
 	before, _ := json.Marshal(old)
 
-	var in ProjectIn
-	if err := c.ShouldBindJSON(&in); err != nil {
-		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+	raw, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_payload"})
 		return
 	}
-	p, err := h.ps.Update(orgID, id, in.Name, in.Description)
+	patch, err := project.ParsePatch(raw)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "not_found", "detail": err.Error()})
+		respondProjectError(c, err, "invalid_payload")
+		return
+	}
+	p, err := h.ps.Update(orgID, id, a, patch, middleware.IfMatch(c))
+	if err != nil {
+		respondProjectError(c, err, "update_failed")
 		return
 	}
 
 	after, _ := json.Marshal(p)
-	_ = h.as.Record(audit.Event{
+	_ = h.as.Record(auditEvent(c, audit.Event{
 		OrgID: orgID, ActorID: userID, Action: "project.updated",
 		Entity: "project", EntityID: id, Timestamp: time.Now(),
 		Metadata: audit.Metadata{Before: before, After: after},
-	})
+	}))
+
+	middleware.SetETag(c, p.Version)
+	c.JSON(http.StatusOK, p)
+}
+
+// Archive makes a project read-only; it stays listed with status
+// "archived". Requires admin access.
+// @Summary Archive project
+// @Tags Projects
+// @Security BearerAuth
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Project ID"
+// @Success 200 {object} project.Project
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorDetailResponse
+// @Router /projects/{id}/archive [post]
+func (h *ProjectsHandler) Archive(c *gin.Context) {
+	h.setArchived(c, true)
+}
+
+// Unarchive makes an archived project editable again. Requires admin
+// access.
+// @Summary Unarchive project
+// @Tags Projects
+// @Security BearerAuth
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Project ID"
+// @Success 200 {object} project.Project
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorDetailResponse
+// @Router /projects/{id}/unarchive [post]
+func (h *ProjectsHandler) Unarchive(c *gin.Context) {
+	h.setArchived(c, false)
+}
+
+func (h *ProjectsHandler) setArchived(c *gin.Context, archived bool) {
+	orgID, ok := middleware.OrgID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing_org_id"})
+		return
+	}
+	userID, _ := middleware.UserID(c)
+	id := c.Param("id")
+	a, err := projectActor(c, h.os)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "permission_check_failed"})
+		return
+	}
+
+	var (
+		p      project.Project
+		action = "project.archived"
+	)
+	if archived {
+		p, err = h.ps.Archive(orgID, id, a)
+	} else {
+		p, err = h.ps.Unarchive(orgID, id, a)
+		action = "project.unarchived"
+	}
+	if err != nil {
+		respondProjectError(c, err, "update_failed")
+		return
+	}
 
+	after, _ := json.Marshal(p)
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID: orgID, ActorID: userID, Action: action,
+		Entity: "project", EntityID: id, Timestamp: time.Now(),
+		Metadata: audit.Metadata{After: after},
+	}))
 	c.JSON(http.StatusOK, p)
 }
 
-// Delete removes a project.
+// Delete soft-deletes a project. Requires admin access. It can be restored
+// until the purge worker removes it with its files. With If-Match only
+// that version is deleted.
 // @Summary Delete project
 // @Tags Projects
 // @Security BearerAuth
 // @Produce json
 // @Param X-Org-ID header string true "Organization ID"
+// @Param If-Match header string false "ETag the deletion applies to"
 // @Param id path string true "Project ID"
 // @Success 204 "No Content"
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 500 {object} ErrorDetailResponse
 // @Router /projects/{id} [delete]
 func (h *ProjectsHandler) Delete(c *gin.Context) {
 	orgID, ok := middleware.OrgID(c)
@@ -188,20 +399,343 @@ func (h *ProjectsHandler) Delete(c *gin.Context) {
 	}
 	userID, _ := middleware.UserID(c)
 	id := c.Param("id")
+	a, err := projectActor(c, h.os)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "permission_check_failed"})
+		return
+	}
 
 	// Capture before state
-	old, _ := h.ps.Get(orgID, id)
+	old, _ := h.ps.Get(orgID, id, a)
 	before, _ := json.Marshal(old)
 
-	if err := h.ps.Delete(orgID, id); err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+	if err := h.ps.Delete(orgID, id, a, middleware.IfMatch(c)); err != nil {
+		respondProjectError(c, err, "delete_failed")
 		return
 	}
 
-	_ = h.as.Record(audit.Event{
+	_ = h.as.Record(auditEvent(c, audit.Event{
 		OrgID: orgID, ActorID: userID, Action: "project.deleted",
 		Entity: "project", EntityID: id, Timestamp: time.Now(),
 		Metadata: audit.Metadata{Before: before},
-	})
+	}))
+	c.Status(http.StatusNoContent)
+}
+
+// Restore undoes the deletion of a project within the restore window.
+// Requires admin access.
+// @Summary Restore deleted project
+// @Tags Projects
+// @Security BearerAuth
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Project ID"
+// @Success 200 {object} project.Project
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /projects/{id}/restore [post]
+func (h *ProjectsHandler) Restore(c *gin.Context) {
+	orgID, ok := middleware.OrgID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing_org_id"})
+		return
+	}
+	userID, _ := middleware.UserID(c)
+	id := c.Param("id")
+	a, err := projectActor(c, h.os)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "permission_check_failed"})
+		return
+	}
+
+	p, err := h.ps.Restore(orgID, id, a, time.Now().Add(-h.restoreWindow))
+	if err != nil {
+		respondProjectError(c, err, "restore_failed")
+		return
+	}
+
+	after, _ := json.Marshal(p)
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID: orgID, ActorID: userID, Action: "project.restored",
+		Entity: "project", EntityID: id, Timestamp: time.Now(),
+		Metadata: audit.Metadata{After: after},
+	}))
+	c.JSON(http.StatusOK, p)
+}
+
+// ListGrants returns who has access to a project.
+// @Summary List project grants
+// @Tags Projects
+// @Security BearerAuth
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Project ID"
+// @Success 200 {object} map[string][]project.Grant "items"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorDetailResponse
+// @Router /projects/{id}/grants [get]
+func (h *ProjectsHandler) ListGrants(c *gin.Context) {
+	orgID, ok := middleware.OrgID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing_org_id"})
+		return
+	}
+	a, err := projectActor(c, h.os)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "permission_check_failed"})
+		return
+	}
+	items, err := h.ps.ListGrants(orgID, c.Param("id"), a)
+	if err != nil {
+		respondProjectError(c, err, "list_failed")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+type GrantIn struct {
+	UserID string `json:"user_id"`
+	TeamID string `json:"team_id"`
+	Access string `json:"access" binding:"required"`
+}
+
+// SetGrant gives a user or a team viewer, editor or admin access to a
+// project, replacing their previous grant. Requires admin access.
+// @Summary Set project grant
+// @Tags Projects
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Project ID"
+// @Param body body GrantIn true "Grantee and access"
+// @Success 200 {object} project.Grant
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorDetailResponse
+// @Router /projects/{id}/grants [post]
+func (h *ProjectsHandler) SetGrant(c *gin.Context) {
+	orgID, ok := middleware.OrgID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing_org_id"})
+		return
+	}
+	var in GrantIn
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+	a, err := projectActor(c, h.os)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "permission_check_failed"})
+		return
+	}
+	g, err := h.ps.SetGrant(orgID, c.Param("id"), a, project.Grant{UserID: in.UserID, TeamID: in.TeamID, Access: in.Access})
+	if err != nil {
+		respondProjectError(c, err, "grant_failed")
+		return
+	}
+	after, _ := json.Marshal(g)
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID: orgID, ActorID: a.UserID, Action: "project.grant_set",
+		Entity: "project_grant", EntityID: g.ID, Timestamp: time.Now(),
+		Metadata: audit.Metadata{After: after},
+	}))
+	c.JSON(http.StatusOK, g)
+}
+
+// RemoveGrant revokes a project grant. Requires admin access.
+// @Summary Remove project grant
+// @Tags Projects
+// @Security BearerAuth
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Project ID"
+// @Param grantId path string true "Grant ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorDetailResponse
+// @Router /projects/{id}/grants/{grantId} [delete]
+func (h *ProjectsHandler) RemoveGrant(c *gin.Context) {
+	orgID, ok := middleware.OrgID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing_org_id"})
+		return
+	}
+	a, err := projectActor(c, h.os)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "permission_check_failed"})
+		return
+	}
+	id, grantID := c.Param("id"), c.Param("grantId")
+	if err := h.ps.RemoveGrant(orgID, id, a, grantID); err != nil {
+		respondProjectError(c, err, "revoke_failed")
+		return
+	}
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID: orgID, ActorID: a.UserID, Action: "project.grant_removed",
+		Entity: "project_grant", EntityID: grantID, Timestamp: time.Now(),
+	}))
+	c.Status(http.StatusNoContent)
+}
+
+// ListFields returns the custom fields the org defines on its projects.
+// @Summary List project custom fields
+// @Tags Projects
+// @Security BearerAuth
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Success 200 {object} map[string][]project.Field "items"
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorDetailResponse
+// @Router /project-fields [get]
+func (h *ProjectsHandler) ListFields(c *gin.Context) {
+	orgID, ok := middleware.OrgID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing_org_id"})
+		return
+	}
+	items, err := h.ps.ListFields(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "list_failed", "detail": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+type FieldIn struct {
+	Key     string   `json:"key" binding:"required"`
+	Label   string   `json:"label" binding:"required"`
+	Type    string   `json:"type" binding:"required" enums:"text,number,date,enum"`
+	Options []string `json:"options"` // enum only
+}
+
+// CreateField defines a custom field for the org's projects. Requires
+// org.manage.
+// @Summary Create project custom field
+// @Tags Projects
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param body body FieldIn true "Field definition"
+// @Success 201 {object} project.Field
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorDetailResponse
+// @Router /project-fields [post]
+func (h *ProjectsHandler) CreateField(c *gin.Context) {
+	orgID, ok := middleware.OrgID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing_org_id"})
+		return
+	}
+	userID, _ := middleware.UserID(c)
+	var in FieldIn
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+	f, err := h.ps.CreateField(orgID, project.Field{Key: in.Key, Label: in.Label, Type: in.Type, Options: in.Options})
+	if err != nil {
+		respondProjectError(c, err, "create_fail")
+		return
+	}
+	after, _ := json.Marshal(f)
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID: orgID, ActorID: userID, Action: "project_field.created",
+		Entity: "project_field", EntityID: f.ID, Timestamp: time.Now(),
+		Metadata: audit.Metadata{After: after},
+	}))
+	c.JSON(http.StatusCreated, f)
+}
+
+type FieldUpdateIn struct {
+	Label   *string  `json:"label"`
+	Options []string `json:"options"` // replaces the enum options
+}
+
+// UpdateField renames a custom field or changes its enum options. Options
+// still used by a project cannot be removed. Requires org.manage.
+// @Summary Update project custom field
+// @Tags Projects
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param fieldId path string true "Field ID"
+// @Param body body FieldUpdateIn true "Fields to change"
+// @Success 200 {object} project.Field
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorDetailResponse
+// @Router /project-fields/{fieldId} [patch]
+func (h *ProjectsHandler) UpdateField(c *gin.Context) {
+	orgID, ok := middleware.OrgID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing_org_id"})
+		return
+	}
+	userID, _ := middleware.UserID(c)
+	id := c.Param("fieldId")
+	var in FieldUpdateIn
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+	f, err := h.ps.UpdateField(orgID, id, project.FieldUpdate{Label: in.Label, Options: in.Options})
+	if err != nil {
+		respondProjectError(c, err, "update_failed")
+		return
+	}
+	after, _ := json.Marshal(f)
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID: orgID, ActorID: userID, Action: "project_field.updated",
+		Entity: "project_field", EntityID: id, Timestamp: time.Now(),
+		Metadata: audit.Metadata{After: after},
+	}))
+	c.JSON(http.StatusOK, f)
+}
+
+// DeleteField removes a custom field and its values from every project.
+// Requires org.manage.
+// @Summary Delete project custom field
+// @Tags Projects
+// @Security BearerAuth
+// @Param X-Org-ID header string true "Organization ID"
+// @Param fieldId path string true "Field ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorDetailResponse
+// @Router /project-fields/{fieldId} [delete]
+func (h *ProjectsHandler) DeleteField(c *gin.Context) {
+	orgID, ok := middleware.OrgID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing_org_id"})
+		return
+	}
+	userID, _ := middleware.UserID(c)
+	id := c.Param("fieldId")
+	if err := h.ps.DeleteField(orgID, id); err != nil {
+		respondProjectError(c, err, "delete_failed")
+		return
+	}
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID: orgID, ActorID: userID, Action: "project_field.deleted",
+		Entity: "project_field", EntityID: id, Timestamp: time.Now(),
+	}))
 	c.Status(http.StatusNoContent)
 }
@@ -1,5 +1,12 @@
 package handlers
 
+import (
+	"github.com/Ulpio/vergo/internal/domain/billing"
+	"github.com/Ulpio/vergo/internal/domain/export"
+	"github.com/Ulpio/vergo/internal/domain/org"
+	"github.com/Ulpio/vergo/internal/domain/platform"
+)
+
 // ErrorResponse is the standard error envelope.
 // @Description Standard error response
 type ErrorResponse struct {
@@ -13,6 +20,21 @@ type ErrorDetailResponse struct {
 	Detail string `json:"detail,omitempty" example:"duplicate key"`
 }
 
+// LockoutErrorResponse is returned on failed or locked-out auth attempts.
+// @Description Auth error with lockout state
+type LockoutErrorResponse struct {
+	Error           string `json:"error" example:"too_many_attempts"`
+	RetryAfter      int    `json:"retry_after,omitempty" example:"120"`
+	CaptchaRequired bool   `json:"captcha_required" example:"false"`
+}
+
+// WeakPasswordResponse is returned when a new password fails the policy.
+// @Description Password policy rejection
+type WeakPasswordResponse struct {
+	Error   string   `json:"error" example:"weak_password"`
+	Reasons []string `json:"reasons" example:"too_short,breached"`
+}
+
 // AuthResponse is returned on signup and login.
 // @Description Authentication response with tokens
 type AuthResponse struct {
@@ -34,18 +56,54 @@ type TokenResponse struct {
 	RefreshToken string `json:"refresh_token" example:"eyJhbGciOiJIUzI1NiIs..."`
 }
 
+// OrgTokenResponse is returned when minting an org-scoped access token.
+// @Description Org-scoped access token
+type OrgTokenResponse struct {
+	AccessToken string `json:"access_token" example:"eyJhbGciOiJFZERTQSIs..."`
+	OrgID       string `json:"org_id" example:"org-uuid"`
+	Role        string `json:"role" example:"admin"`
+	ExpiresIn   int    `json:"expires_in" example:"900"`
+}
+
+// ImpersonationResponse is an access token for acting as another user.
+// @Description Impersonation access token (no refresh token)
+type ImpersonationResponse struct {
+	AccessToken    string `json:"access_token" example:"eyJhbGciOiJFZERTQSIs..."`
+	ExpiresIn      int    `json:"expires_in" example:"900"`
+	UserID         string `json:"user_id" example:"user-uuid"`
+	ImpersonatorID string `json:"impersonator_id" example:"admin-uuid"`
+}
+
 // MeResponse wraps the /me endpoint response.
 // @Description Current user info
 type MeResponse struct {
 	User MeUser `json:"user"`
+	// GET /me only: the user's orgs (first 100; see GET /orgs)
+	Memberships []org.UserOrg `json:"memberships,omitempty"`
+	ActiveOrgID string        `json:"active_org_id,omitempty" example:"org-uuid"`
 }
 
 // MeUser is the user object inside /me response.
 type MeUser struct {
-	ID    string `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
-	Email string `json:"email" example:"user@example.com"`
-	OrgID string `json:"org_id,omitempty" example:"org-uuid"`
-	Role  string `json:"role,omitempty" example:"admin"`
+	ID            string `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Email         string `json:"email" example:"user@example.com"`
+	EmailVerified bool   `json:"email_verified" example:"true"`
+	DisplayName   string `json:"display_name" example:"Ana Silva"`
+	AvatarFileID  string `json:"avatar_file_id" example:"file-uuid"`
+	Locale        string `json:"locale" example:"pt-BR"`
+	Timezone      string `json:"timezone" example:"America/Sao_Paulo"`
+	OrgID         string `json:"org_id,omitempty" example:"org-uuid"`
+	Role          string `json:"role,omitempty" example:"admin"`
+	// Set while a platform admin is impersonating this user
+	ImpersonatorID string `json:"impersonator_id,omitempty" example:"admin-uuid"`
+}
+
+// SoleOwnerResponse is returned when an account cannot be deleted because
+// it is the only owner of some orgs.
+// @Description Account deletion blocked by org ownership
+type SoleOwnerResponse struct {
+	Error  string   `json:"error" example:"sole_owner"`
+	OrgIDs []string `json:"org_ids" example:"org-uuid"`
 }
 
 // ContextResponse is the active org context.
@@ -61,6 +119,7 @@ type PresignPutResponse struct {
 	Method  string            `json:"method" example:"PUT"`
 	URL     string            `json:"url" example:"https://s3.amazonaws.com/bucket/key?..."`
 	Headers map[string]string `json:"headers"`
+	Key     string            `json:"key" example:"org/org-uuid/users/user-uuid/logo.png"`
 }
 
 // PresignGetResponse is the presigned GET URL response.
@@ -69,3 +128,21 @@ type PresignGetResponse struct {
 	Method string `json:"method" example:"GET"`
 	URL    string `json:"url" example:"https://s3.amazonaws.com/bucket/key?..."`
 }
+
+// ExportResponse is an export job, with a download link once it is done.
+// @Description Data export status
+type ExportResponse struct {
+	Export      export.Job `json:"export"`
+	DownloadURL string     `json:"download_url,omitempty" example:"https://s3.amazonaws.com/bucket/exports/...zip?..."`
+	ExpiresIn   int        `json:"expires_in,omitempty" example:"3600"`
+}
+
+// AdminOrgResponse is an org as seen by platform admins.
+// @Description Org with subscription, usage and plan limits
+type AdminOrgResponse struct {
+	Org             org.Organization      `json:"org"`
+	SuspendedReason string                `json:"suspended_reason,omitempty" example:"chargeback"`
+	Subscription    *billing.Subscription `json:"subscription"`
+	Usage           platform.Usage        `json:"usage"`
+	Limits          billing.PlanLimits    `json:"limits"`
+}
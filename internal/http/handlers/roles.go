@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ulpio/vergo/internal/domain/audit"
+	"github.com/Ulpio/vergo/internal/domain/org"
+	"github.com/Ulpio/vergo/internal/http/middleware"
+)
+
+type RolesHandler struct {
+	os org.Service
+	as audit.Service
+}
+
+func NewRolesHandler(os org.Service, as audit.Service) *RolesHandler {
+	return &RolesHandler{os: os, as: as}
+}
+
+type roleIn struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type roleUpdateIn struct {
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// List returns the built-in presets and the org's custom roles.
+// @Summary List roles
+// @Tags Roles
+// @Security BearerAuth
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Success 200 {array} org.Role
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /roles [get]
+func (h *RolesHandler) List(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	roles, err := h.os.ListRoles(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "list_failed"})
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+// Permissions lists the permissions roles can grant.
+// @Summary List permissions
+// @Tags Roles
+// @Security BearerAuth
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Success 200 {array} string
+// @Failure 401 {object} ErrorResponse
+// @Router /roles/permissions [get]
+func (h *RolesHandler) Permissions(c *gin.Context) {
+	c.JSON(http.StatusOK, org.Permissions)
+}
+
+// Get returns a role by name.
+// @Summary Get role
+// @Tags Roles
+// @Security BearerAuth
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param name path string true "Role name"
+// @Success 200 {object} org.Role
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /roles/{name} [get]
+func (h *RolesHandler) Get(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	r, err := h.os.GetRole(orgID, c.Param("name"))
+	if err != nil {
+		respondRoleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+// Create adds a custom role. Nobody grants a permission they lack.
+// @Summary Create role
+// @Tags Roles
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param body body roleIn true "Role name, description and permissions"
+// @Success 201 {object} org.Role
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /roles [post]
+func (h *RolesHandler) Create(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	actorID, _ := middleware.UserID(c)
+	actorRole, _ := middleware.Role(c)
+	var in roleIn
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+	r, err := h.os.CreateRole(orgID, actorRole, org.Role{
+		Name: in.Name, Description: in.Description, Permissions: in.Permissions,
+	})
+	if err != nil {
+		respondRoleError(c, err)
+		return
+	}
+
+	after, _ := json.Marshal(r)
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID: orgID, ActorID: actorID, Action: "role.created",
+		Entity: "role", EntityID: r.ID, Timestamp: time.Now(),
+		Metadata: audit.Metadata{After: after},
+	}))
+
+	c.JSON(http.StatusCreated, r)
+}
+
+// Update replaces a custom role's description and permissions; members and
+// API keys holding it get the new permissions on their next request.
+// @Summary Update role
+// @Tags Roles
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param name path string true "Role name"
+// @Param body body roleUpdateIn true "Description and permissions"
+// @Success 200 {object} org.Role
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /roles/{name} [put]
+func (h *RolesHandler) Update(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	actorID, _ := middleware.UserID(c)
+	actorRole, _ := middleware.Role(c)
+	name := c.Param("name")
+	var in roleUpdateIn
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+	before, err := h.os.GetRole(orgID, name)
+	if err != nil {
+		respondRoleError(c, err)
+		return
+	}
+	r, err := h.os.UpdateRole(orgID, actorRole, org.Role{
+		Name: name, Description: in.Description, Permissions: in.Permissions,
+	})
+	if err != nil {
+		respondRoleError(c, err)
+		return
+	}
+
+	b, _ := json.Marshal(before)
+	after, _ := json.Marshal(r)
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID: orgID, ActorID: actorID, Action: "role.updated",
+		Entity: "role", EntityID: r.ID, Timestamp: time.Now(),
+		Metadata: audit.Metadata{Before: b, After: after},
+	}))
+
+	c.JSON(http.StatusOK, r)
+}
+
+// Delete removes a custom role nothing is assigned to.
+// @Summary Delete role
+// @Tags Roles
+// @Security BearerAuth
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param name path string true "Role name"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /roles/{name} [delete]
+func (h *RolesHandler) Delete(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	actorID, _ := middleware.UserID(c)
+	actorRole, _ := middleware.Role(c)
+	name := c.Param("name")
+	before, err := h.os.GetRole(orgID, name)
+	if err != nil {
+		respondRoleError(c, err)
+		return
+	}
+	if err := h.os.DeleteRole(orgID, actorRole, name); err != nil {
+		respondRoleError(c, err)
+		return
+	}
+
+	b, _ := json.Marshal(before)
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID: orgID, ActorID: actorID, Action: "role.deleted",
+		Entity: "role", EntityID: before.ID, Timestamp: time.Now(),
+		Metadata: audit.Metadata{Before: b},
+	}))
+
+	c.Status(http.StatusNoContent)
+}
+
+func respondRoleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, org.ErrRoleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "role_not_found"})
+	case errors.Is(err, org.ErrRoleExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "role_exists"})
+	case errors.Is(err, org.ErrRoleInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": "role_in_use"})
+	case errors.Is(err, org.ErrBuiltinRole):
+		c.JSON(http.StatusForbidden, gin.H{"error": "builtin_role"})
+	case errors.Is(err, org.ErrPermissionAboveOwn):
+		c.JSON(http.StatusForbidden, gin.H{"error": "permission_above_own"})
+	case errors.Is(err, org.ErrInvalidRoleName):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_role_name"})
+	case errors.Is(err, org.ErrUnknownPermission):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "unknown_permission"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "role_update_failed"})
+	}
+}
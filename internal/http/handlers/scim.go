@@ -0,0 +1,555 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ulpio/vergo/internal/domain/audit"
+	"github.com/Ulpio/vergo/internal/domain/scim"
+	"github.com/Ulpio/vergo/internal/http/middleware"
+)
+
+const scimContentType = "application/scim+json"
+
+type SCIMHandler struct {
+	ss scim.Service
+	as audit.Service
+}
+
+func NewSCIMHandler(ss scim.Service, as audit.Service) *SCIMHandler {
+	return &SCIMHandler{ss: ss, as: as}
+}
+
+// ── Token management (org admins) ────────────────────────────────────
+
+type createSCIMTokenIn struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// CreateToken issues a SCIM bearer token for the org. The plaintext token is
+// only returned once.
+// @Summary Create SCIM token
+// @Tags SCIM
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Organization ID"
+// @Param body body createSCIMTokenIn true "Token name"
+// @Success 201 {object} scim.TokenResult
+// @Failure 422 {object} ErrorResponse
+// @Router /orgs/{id}/scim/tokens [post]
+func (h *SCIMHandler) CreateToken(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	orgID, _ := middleware.OrgID(c)
+
+	var in createSCIMTokenIn
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+	result, err := h.ss.CreateToken(orgID, uid, in.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "create_failed"})
+		return
+	}
+
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID:    orgID,
+		ActorID:  uid,
+		Action:   "scim_token.created",
+		Entity:   "scim_token",
+		EntityID: result.ID,
+		Metadata: toAuditMeta(map[string]string{"name": in.Name}),
+	}))
+	c.JSON(http.StatusCreated, result)
+}
+
+// ListTokens lists the org's active SCIM tokens.
+// @Summary List SCIM tokens
+// @Tags SCIM
+// @Security BearerAuth
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Organization ID"
+// @Success 200 {array} scim.Token
+// @Router /orgs/{id}/scim/tokens [get]
+func (h *SCIMHandler) ListTokens(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	tokens, err := h.ss.ListTokens(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "list_failed"})
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// RevokeToken revokes a SCIM token.
+// @Summary Revoke SCIM token
+// @Tags SCIM
+// @Security BearerAuth
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Organization ID"
+// @Param tokenId path string true "Token ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /orgs/{id}/scim/tokens/{tokenId} [delete]
+func (h *SCIMHandler) RevokeToken(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	orgID, _ := middleware.OrgID(c)
+	tokenID := c.Param("tokenId")
+
+	err := h.ss.RevokeToken(orgID, tokenID)
+	if errors.Is(err, scim.ErrTokenNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "revoke_failed"})
+		return
+	}
+
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID:    orgID,
+		ActorID:  uid,
+		Action:   "scim_token.revoked",
+		Entity:   "scim_token",
+		EntityID: tokenID,
+	}))
+	c.Status(http.StatusNoContent)
+}
+
+// ── SCIM protocol (/scim/v2) ─────────────────────────────────────────
+
+// ServiceProviderConfig advertises the supported SCIM features.
+// @Summary SCIM service provider configuration
+// @Tags SCIM
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /scim/v2/ServiceProviderConfig [get]
+func (h *SCIMHandler) ServiceProviderConfig(c *gin.Context) {
+	supported := func(ok bool) gin.H { return gin.H{"supported": ok} }
+	writeSCIM(c, http.StatusOK, gin.H{
+		"schemas":        []string{scim.SchemaSPConfig},
+		"patch":          supported(true),
+		"bulk":           gin.H{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         gin.H{"supported": true, "maxResults": scim.MaxPageSize},
+		"changePassword": supported(false),
+		"sort":           supported(false),
+		"etag":           supported(false),
+		"authenticationSchemes": []gin.H{{
+			"type":        "oauthbearertoken",
+			"name":        "OAuth Bearer Token",
+			"description": "Org-scoped SCIM token issued at /v1/orgs/{id}/scim/tokens",
+			"primary":     true,
+		}},
+	})
+}
+
+// ResourceTypes lists the User and Group resource types.
+// @Summary SCIM resource types
+// @Tags SCIM
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} scim.ListResponse
+// @Router /scim/v2/ResourceTypes [get]
+func (h *SCIMHandler) ResourceTypes(c *gin.Context) {
+	types := []any{
+		gin.H{
+			"schemas":  []string{scim.SchemaResourceTyp},
+			"id":       "User",
+			"name":     "User",
+			"endpoint": "/Users",
+			"schema":   scim.SchemaUser,
+			"schemaExtensions": []gin.H{
+				{"schema": scim.SchemaEnterprise, "required": false},
+			},
+		},
+		gin.H{
+			"schemas":  []string{scim.SchemaResourceTyp},
+			"id":       "Group",
+			"name":     "Group",
+			"endpoint": "/Groups",
+			"schema":   scim.SchemaGroup,
+			"schemaExtensions": []gin.H{
+				{"schema": scim.SchemaVergoGroup, "required": false},
+			},
+		},
+	}
+	writeSCIM(c, http.StatusOK, scim.ListResponse{
+		Schemas:      []string{scim.SchemaListResp},
+		TotalResults: len(types),
+		StartIndex:   1,
+		ItemsPerPage: len(types),
+		Resources:    types,
+	})
+}
+
+// ListUsers lists provisioned users, with optional filter and pagination.
+// @Summary List SCIM users
+// @Tags SCIM
+// @Security BearerAuth
+// @Produce json
+// @Param filter query string false "SCIM filter, e.g. userName eq \"a@b.com\""
+// @Param startIndex query int false "1-based start index"
+// @Param count query int false "Page size"
+// @Success 200 {object} scim.ListResponse
+// @Failure 400 {object} map[string]interface{}
+// @Router /scim/v2/Users [get]
+func (h *SCIMHandler) ListUsers(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	res, err := h.ss.ListUsers(orgID, listQuery(c))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	writeSCIM(c, http.StatusOK, res)
+}
+
+// GetUser returns a provisioned user.
+// @Summary Get SCIM user
+// @Tags SCIM
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} scim.User
+// @Failure 404 {object} map[string]interface{}
+// @Router /scim/v2/Users/{id} [get]
+func (h *SCIMHandler) GetUser(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	u, err := h.ss.GetUser(orgID, c.Param("id"))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	writeSCIM(c, http.StatusOK, u)
+}
+
+// CreateUser provisions a user into the org. An existing account with the
+// same email is linked; otherwise a password-less account is created.
+// @Summary Create SCIM user
+// @Tags SCIM
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body scim.User true "User"
+// @Success 201 {object} scim.User
+// @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /scim/v2/Users [post]
+func (h *SCIMHandler) CreateUser(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	var in scim.User
+	if !bindSCIM(c, &in) {
+		return
+	}
+	u, err := h.ss.CreateUser(orgID, in)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.record(c, "scim.user_created", "user", u.ID, nil, u)
+	writeSCIM(c, http.StatusCreated, u)
+}
+
+// ReplaceUser replaces a user's attributes (PUT).
+// @Summary Replace SCIM user
+// @Tags SCIM
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param body body scim.User true "User"
+// @Success 200 {object} scim.User
+// @Failure 404 {object} map[string]interface{}
+// @Router /scim/v2/Users/{id} [put]
+func (h *SCIMHandler) ReplaceUser(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	var in scim.User
+	if !bindSCIM(c, &in) {
+		return
+	}
+	before, _ := h.ss.GetUser(orgID, c.Param("id"))
+	u, err := h.ss.ReplaceUser(orgID, c.Param("id"), in)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.record(c, "scim.user_updated", "user", u.ID, before, u)
+	writeSCIM(c, http.StatusOK, u)
+}
+
+// PatchUser applies SCIM PATCH operations to a user. Setting active=false
+// removes the org membership.
+// @Summary Patch SCIM user
+// @Tags SCIM
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param body body scim.PatchRequest true "Patch operations"
+// @Success 200 {object} scim.User
+// @Failure 400 {object} map[string]interface{}
+// @Router /scim/v2/Users/{id} [patch]
+func (h *SCIMHandler) PatchUser(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	var in scim.PatchRequest
+	if !bindSCIM(c, &in) {
+		return
+	}
+	before, _ := h.ss.GetUser(orgID, c.Param("id"))
+	u, err := h.ss.PatchUser(orgID, c.Param("id"), in)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.record(c, "scim.user_updated", "user", u.ID, before, u)
+	writeSCIM(c, http.StatusOK, u)
+}
+
+// DeleteUser deprovisions a user from the org.
+// @Summary Delete SCIM user
+// @Tags SCIM
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 204
+// @Failure 404 {object} map[string]interface{}
+// @Router /scim/v2/Users/{id} [delete]
+func (h *SCIMHandler) DeleteUser(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	id := c.Param("id")
+	before, _ := h.ss.GetUser(orgID, id)
+	if err := h.ss.DeleteUser(orgID, id); err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.record(c, "scim.user_deprovisioned", "user", id, before, nil)
+	c.Status(http.StatusNoContent)
+}
+
+// ListGroups lists groups, with optional filter and pagination.
+// @Summary List SCIM groups
+// @Tags SCIM
+// @Security BearerAuth
+// @Produce json
+// @Param filter query string false "SCIM filter, e.g. displayName eq \"Engineering\""
+// @Param startIndex query int false "1-based start index"
+// @Param count query int false "Page size"
+// @Success 200 {object} scim.ListResponse
+// @Router /scim/v2/Groups [get]
+func (h *SCIMHandler) ListGroups(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	res, err := h.ss.ListGroups(orgID, listQuery(c))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	writeSCIM(c, http.StatusOK, res)
+}
+
+// GetGroup returns a group and its members.
+// @Summary Get SCIM group
+// @Tags SCIM
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Group ID"
+// @Success 200 {object} scim.Group
+// @Failure 404 {object} map[string]interface{}
+// @Router /scim/v2/Groups/{id} [get]
+func (h *SCIMHandler) GetGroup(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	g, err := h.ss.GetGroup(orgID, c.Param("id"))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	writeSCIM(c, http.StatusOK, g)
+}
+
+// CreateGroup creates a group. The Vergo extension role (member or admin) is
+// granted to every member of the group.
+// @Summary Create SCIM group
+// @Tags SCIM
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body scim.Group true "Group"
+// @Success 201 {object} scim.Group
+// @Failure 409 {object} map[string]interface{}
+// @Router /scim/v2/Groups [post]
+func (h *SCIMHandler) CreateGroup(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	var in scim.Group
+	if !bindSCIM(c, &in) {
+		return
+	}
+	g, err := h.ss.CreateGroup(orgID, in)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.record(c, "scim.group_created", "scim_group", g.ID, nil, g)
+	writeSCIM(c, http.StatusCreated, g)
+}
+
+// ReplaceGroup replaces a group, including its member list (PUT).
+// @Summary Replace SCIM group
+// @Tags SCIM
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param body body scim.Group true "Group"
+// @Success 200 {object} scim.Group
+// @Failure 404 {object} map[string]interface{}
+// @Router /scim/v2/Groups/{id} [put]
+func (h *SCIMHandler) ReplaceGroup(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	var in scim.Group
+	if !bindSCIM(c, &in) {
+		return
+	}
+	before, _ := h.ss.GetGroup(orgID, c.Param("id"))
+	g, err := h.ss.ReplaceGroup(orgID, c.Param("id"), in)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.record(c, "scim.group_updated", "scim_group", g.ID, before, g)
+	writeSCIM(c, http.StatusOK, g)
+}
+
+// PatchGroup applies SCIM PATCH operations to a group (member add/remove,
+// rename, role).
+// @Summary Patch SCIM group
+// @Tags SCIM
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param body body scim.PatchRequest true "Patch operations"
+// @Success 200 {object} scim.Group
+// @Failure 400 {object} map[string]interface{}
+// @Router /scim/v2/Groups/{id} [patch]
+func (h *SCIMHandler) PatchGroup(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	var in scim.PatchRequest
+	if !bindSCIM(c, &in) {
+		return
+	}
+	before, _ := h.ss.GetGroup(orgID, c.Param("id"))
+	g, err := h.ss.PatchGroup(orgID, c.Param("id"), in)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.record(c, "scim.group_updated", "scim_group", g.ID, before, g)
+	writeSCIM(c, http.StatusOK, g)
+}
+
+// DeleteGroup deletes a group; roles granted through it are recomputed.
+// @Summary Delete SCIM group
+// @Tags SCIM
+// @Security BearerAuth
+// @Param id path string true "Group ID"
+// @Success 204
+// @Failure 404 {object} map[string]interface{}
+// @Router /scim/v2/Groups/{id} [delete]
+func (h *SCIMHandler) DeleteGroup(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	id := c.Param("id")
+	before, _ := h.ss.GetGroup(orgID, id)
+	if err := h.ss.DeleteGroup(orgID, id); err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.record(c, "scim.group_deleted", "scim_group", id, before, nil)
+	c.Status(http.StatusNoContent)
+}
+
+// record writes an audit event attributed to the SCIM token.
+func (h *SCIMHandler) record(c *gin.Context, action, entity, id string, before, after any) {
+	orgID, _ := middleware.OrgID(c)
+	tokenID, _ := middleware.UserID(c)
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID:    orgID,
+		ActorID:  tokenID,
+		Action:   action,
+		Entity:   entity,
+		EntityID: id,
+		Metadata: audit.Metadata{Before: scimAuditView(before), After: scimAuditView(after)},
+	}))
+}
+
+func scimAuditView(v any) json.RawMessage {
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case scim.User:
+		if t.ID == "" {
+			return nil
+		}
+	case scim.Group:
+		if t.ID == "" {
+			return nil
+		}
+	}
+	b, _ := json.Marshal(v)
+	return b
+}
+
+func (h *SCIMHandler) fail(c *gin.Context, err error) {
+	var se *scim.Error
+	if !errors.As(err, &se) {
+		slog.Error("scim request failed", "path", c.FullPath(), "error", err)
+		se = &scim.Error{Status: http.StatusInternalServerError, Detail: "internal error"}
+	}
+	body := gin.H{
+		"schemas": []string{scim.SchemaError},
+		"status":  strconv.Itoa(se.Status),
+		"detail":  se.Detail,
+	}
+	if se.ScimType != "" {
+		body["scimType"] = se.ScimType
+	}
+	writeSCIM(c, se.Status, body)
+}
+
+func bindSCIM(c *gin.Context, dst any) bool {
+	if err := json.NewDecoder(c.Request.Body).Decode(dst); err != nil {
+		writeSCIM(c, http.StatusBadRequest, gin.H{
+			"schemas":  []string{scim.SchemaError},
+			"status":   strconv.Itoa(http.StatusBadRequest),
+			"scimType": "invalidSyntax",
+			"detail":   "request body is not valid JSON",
+		})
+		return false
+	}
+	return true
+}
+
+func writeSCIM(c *gin.Context, status int, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.Data(status, scimContentType, b)
+}
+
+func listQuery(c *gin.Context) scim.ListQuery {
+	q := scim.ListQuery{Filter: c.Query("filter"), StartIndex: 1, Count: scim.MaxPageSize}
+	if v, err := strconv.Atoi(c.Query("startIndex")); err == nil {
+		q.StartIndex = v
+	}
+	if v, err := strconv.Atoi(c.Query("count")); err == nil {
+		q.Count = v
+	}
+	return q
+}
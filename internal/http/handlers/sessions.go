@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ulpio/vergo/internal/auth"
+	"github.com/Ulpio/vergo/internal/domain/audit"
+	"github.com/Ulpio/vergo/internal/domain/org"
+	"github.com/Ulpio/vergo/internal/http/middleware"
+)
+
+// SessionsHandler lists and revokes login sessions (refresh token families).
+type SessionsHandler struct {
+	rs auth.RefreshStore
+	os org.Service
+	as audit.Service
+}
+
+func NewSessionsHandler(rs auth.RefreshStore, os org.Service, as audit.Service) *SessionsHandler {
+	return &SessionsHandler{rs: rs, os: os, as: as}
+}
+
+// List returns the authenticated user's active sessions.
+// @Summary List active sessions
+// @Tags User
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} auth.Session
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /me/sessions [get]
+func (h *SessionsHandler) List(c *gin.Context) {
+	uid, ok := middleware.UserID(c)
+	if !ok || uid == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing_user"})
+		return
+	}
+	sessions, err := h.rs.ListSessions(context.Background(), uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "list_failed"})
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// Revoke signs one of the user's sessions out remotely.
+// @Summary Revoke a session
+// @Tags User
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /me/sessions/{id} [delete]
+func (h *SessionsHandler) Revoke(c *gin.Context) {
+	uid, ok := middleware.UserID(c)
+	if !ok || uid == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing_user"})
+		return
+	}
+	sessionID := c.Param("id")
+	err := h.rs.RevokeSession(context.Background(), uid, sessionID)
+	if errors.Is(err, auth.ErrSessionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "session_not_found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "revoke_failed"})
+		return
+	}
+
+	// Evento de conta (sem org)
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		ActorID:  uid,
+		Action:   "session.revoked",
+		Entity:   "session",
+		EntityID: sessionID,
+		Metadata: toAuditMeta(map[string]any{"ip": c.ClientIP()}),
+	}))
+
+	c.Status(http.StatusNoContent)
+}
+
+// ForceLogoutMember revokes every session of an org member. Sessions are not
+// org-scoped, so the member is signed out of all devices.
+// @Summary Force-logout a member
+// @Tags Organizations
+// @Security BearerAuth
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Organization ID"
+// @Param userId path string true "User ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orgs/{id}/members/{userId}/logout [post]
+func (h *SessionsHandler) ForceLogoutMember(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	userID := c.Param("userId")
+	actorID, _ := middleware.UserID(c)
+
+	isMember, _, err := h.os.IsMember(orgID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "membership_lookup_failed"})
+		return
+	}
+	if !isMember {
+		c.JSON(http.StatusNotFound, gin.H{"error": "member_not_found"})
+		return
+	}
+	if err := h.rs.RevokeAllForUser(context.Background(), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "revoke_failed"})
+		return
+	}
+
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID:    orgID,
+		ActorID:  actorID,
+		Action:   "member.force_logout",
+		Entity:   "membership",
+		EntityID: userID,
+	}))
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,437 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ulpio/vergo/internal/auth/saml"
+	"github.com/Ulpio/vergo/internal/domain/audit"
+	"github.com/Ulpio/vergo/internal/domain/sso"
+	"github.com/Ulpio/vergo/internal/http/middleware"
+)
+
+type SSOHandler struct {
+	tokens *AuthHandler
+	ss     sso.Service
+	as     audit.Service
+}
+
+func NewSSOHandler(tokens *AuthHandler, ss sso.Service, as audit.Service) *SSOHandler {
+	return &SSOHandler{tokens: tokens, ss: ss, as: as}
+}
+
+// ssoLoginURL is the public SP-initiated login entry point for an org.
+func ssoLoginURL(orgID string) string {
+	return "/v1/sso/saml/" + url.PathEscape(orgID) + "/login"
+}
+
+type samlConfigIn struct {
+	IdPMetadataXML   string               `json:"idp_metadata_xml"`
+	IdPEntityID      string               `json:"idp_entity_id"`
+	IdPSSOURL        string               `json:"idp_sso_url"`
+	IdPCertificate   string               `json:"idp_certificate"`
+	AttributeMapping sso.AttributeMapping `json:"attribute_mapping"`
+	EnforceSSO       bool                 `json:"enforce_sso"`
+	Enabled          *bool                `json:"enabled"`
+}
+
+// GetConfig returns the org's SAML configuration and SP details.
+// @Summary Get SAML SSO configuration
+// @Tags SSO
+// @Security BearerAuth
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Organization ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Router /orgs/{id}/sso/saml [get]
+func (h *SSOHandler) GetConfig(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	cfg, err := h.ss.GetConfig(orgID)
+	if errors.Is(err, sso.ErrNotConfigured) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not_configured", "service_provider": h.ss.ServiceProvider(orgID)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "get_failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"config": cfg, "service_provider": h.ss.ServiceProvider(orgID)})
+}
+
+// PutConfig creates or replaces the org's SAML configuration. The IdP can be
+// given as a metadata document or as explicit entity ID / SSO URL / certificate.
+// @Summary Configure SAML SSO
+// @Tags SSO
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Organization ID"
+// @Param body body samlConfigIn true "IdP configuration"
+// @Success 200 {object} sso.Config
+// @Failure 402 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Router /orgs/{id}/sso/saml [put]
+func (h *SSOHandler) PutConfig(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	orgID, _ := middleware.OrgID(c)
+
+	var in samlConfigIn
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+	cfg := sso.Config{
+		OrgID:            orgID,
+		IdPEntityID:      in.IdPEntityID,
+		IdPSSOURL:        in.IdPSSOURL,
+		IdPCertificate:   in.IdPCertificate,
+		AttributeMapping: in.AttributeMapping,
+		EnforceSSO:       in.EnforceSSO,
+		Enabled:          in.Enabled == nil || *in.Enabled,
+	}
+	if in.IdPMetadataXML != "" {
+		idp, certPEM, err := saml.ParseIdPMetadata([]byte(in.IdPMetadataXML))
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_metadata", "detail": err.Error()})
+			return
+		}
+		cfg.IdPEntityID, cfg.IdPSSOURL, cfg.IdPCertificate = idp.EntityID, idp.SSOURL, certPEM
+	}
+
+	before, _ := h.ss.GetConfig(orgID)
+	saved, err := h.ss.SaveConfig(cfg)
+	if errors.Is(err, sso.ErrInvalidConfig) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_config", "detail": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "save_failed"})
+		return
+	}
+
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID:    orgID,
+		ActorID:  uid,
+		Action:   "sso.saml_configured",
+		Entity:   "org",
+		EntityID: orgID,
+		Metadata: audit.Metadata{Before: ssoAuditView(before), After: ssoAuditView(saved)},
+	}))
+	c.JSON(http.StatusOK, saved)
+}
+
+func ssoAuditView(cfg sso.Config) json.RawMessage {
+	if cfg.OrgID == "" {
+		return nil
+	}
+	b, _ := json.Marshal(gin.H{
+		"idp_entity_id": cfg.IdPEntityID,
+		"idp_sso_url":   cfg.IdPSSOURL,
+		"enforce_sso":   cfg.EnforceSSO,
+		"enabled":       cfg.Enabled,
+	})
+	return b
+}
+
+type domainIn struct {
+	Domain string `json:"domain" binding:"required"`
+}
+
+// ClaimDomain registers an email domain for the org and returns the DNS TXT
+// record that proves ownership.
+// @Summary Claim email domain
+// @Tags SSO
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Organization ID"
+// @Param body body domainIn true "Domain"
+// @Success 201 {object} map[string]interface{}
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Router /orgs/{id}/domains [post]
+func (h *SSOHandler) ClaimDomain(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	orgID, _ := middleware.OrgID(c)
+
+	var in domainIn
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+	d, err := h.ss.ClaimDomain(orgID, in.Domain)
+	if !respondDomainError(c, err) {
+		return
+	}
+
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID:    orgID,
+		ActorID:  uid,
+		Action:   "domain.claimed",
+		Entity:   "domain",
+		EntityID: d.Domain,
+	}))
+	c.JSON(http.StatusCreated, domainView(d))
+}
+
+// ListDomains lists the org's claimed domains.
+// @Summary List claimed domains
+// @Tags SSO
+// @Security BearerAuth
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Organization ID"
+// @Success 200 {array} map[string]interface{}
+// @Router /orgs/{id}/domains [get]
+func (h *SSOHandler) ListDomains(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	ds, err := h.ss.ListDomains(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "list_failed"})
+		return
+	}
+	out := make([]gin.H, len(ds))
+	for i, d := range ds {
+		out[i] = domainView(d)
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// VerifyDomain checks the DNS TXT record and marks the domain as verified.
+// @Summary Verify claimed domain
+// @Tags SSO
+// @Security BearerAuth
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Organization ID"
+// @Param domain path string true "Domain"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Router /orgs/{id}/domains/{domain}/verify [post]
+func (h *SSOHandler) VerifyDomain(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	orgID, _ := middleware.OrgID(c)
+
+	d, err := h.ss.VerifyDomain(orgID, c.Param("domain"))
+	if !respondDomainError(c, err) {
+		return
+	}
+
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID:    orgID,
+		ActorID:  uid,
+		Action:   "domain.verified",
+		Entity:   "domain",
+		EntityID: d.Domain,
+	}))
+	c.JSON(http.StatusOK, domainView(d))
+}
+
+// DeleteDomain releases a claimed domain.
+// @Summary Delete claimed domain
+// @Tags SSO
+// @Security BearerAuth
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Organization ID"
+// @Param domain path string true "Domain"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /orgs/{id}/domains/{domain} [delete]
+func (h *SSOHandler) DeleteDomain(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	orgID, _ := middleware.OrgID(c)
+
+	if !respondDomainError(c, h.ss.DeleteDomain(orgID, c.Param("domain"))) {
+		return
+	}
+
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID:    orgID,
+		ActorID:  uid,
+		Action:   "domain.deleted",
+		Entity:   "domain",
+		EntityID: c.Param("domain"),
+	}))
+	c.Status(http.StatusNoContent)
+}
+
+func domainView(d sso.Domain) gin.H {
+	return gin.H{
+		"domain":      d.Domain,
+		"verified":    d.VerifiedAt != nil,
+		"verified_at": d.VerifiedAt,
+		"created_at":  d.CreatedAt,
+		"dns_record": gin.H{
+			"type":  "TXT",
+			"name":  d.TXTRecord(),
+			"value": d.TXTValue(),
+		},
+	}
+}
+
+// respondDomainError writes the error response and reports whether the
+// request may continue.
+func respondDomainError(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, sso.ErrInvalidDomain):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_domain"})
+	case errors.Is(err, sso.ErrDomainExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "domain_exists"})
+	case errors.Is(err, sso.ErrDomainTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "domain_taken"})
+	case errors.Is(err, sso.ErrDomainNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "domain_not_found"})
+	case errors.Is(err, sso.ErrDomainUnverified):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "verification_failed"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "domain_error"})
+	}
+	return false
+}
+
+// Metadata serves the SP metadata document for the org's IdP.
+// @Summary SAML SP metadata
+// @Tags SSO
+// @Produce xml
+// @Param orgId path string true "Organization ID"
+// @Success 200 {string} string "SP EntityDescriptor"
+// @Router /sso/saml/{orgId}/metadata [get]
+func (h *SSOHandler) Metadata(c *gin.Context) {
+	c.Data(http.StatusOK, "application/samlmetadata+xml", saml.Metadata(h.ss.ServiceProvider(c.Param("orgId"))))
+}
+
+// Login starts SP-initiated SSO by redirecting to the org's IdP.
+// @Summary Start SAML login
+// @Tags SSO
+// @Param orgId path string true "Organization ID"
+// @Param relay_state query string false "Opaque value echoed back after login"
+// @Success 302 "Redirect to identity provider"
+// @Failure 404 {object} ErrorResponse
+// @Router /sso/saml/{orgId}/login [get]
+func (h *SSOHandler) Login(c *gin.Context) {
+	u, err := h.ss.BeginLogin(c.Param("orgId"), c.Query("relay_state"))
+	if errors.Is(err, sso.ErrNotConfigured) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "sso_not_configured"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "sso_error"})
+		return
+	}
+	c.Redirect(http.StatusFound, u)
+}
+
+// ACS is the Assertion Consumer Service (HTTP-POST binding). It validates the
+// IdP response, provisions the user just-in-time and returns a token pair.
+// @Summary SAML assertion consumer service
+// @Tags SSO
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param SAMLResponse formData string true "Base64 SAML response"
+// @Param RelayState formData string false "Relay state"
+// @Success 200 {object} AuthResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /sso/saml/{orgId}/acs [post]
+func (h *SSOHandler) ACS(c *gin.Context) {
+	orgID := c.Param("orgId")
+	resp := c.PostForm("SAMLResponse")
+	if resp == "" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+
+	u, created, err := h.ss.CompleteLogin(orgID, resp)
+	switch {
+	case errors.Is(err, sso.ErrNotConfigured):
+		c.JSON(http.StatusNotFound, gin.H{"error": "sso_not_configured"})
+		return
+	case errors.Is(err, sso.ErrReplay):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "assertion_replayed"})
+		return
+	case errors.Is(err, sso.ErrUnknownRequest):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unknown_request"})
+		return
+	case errors.Is(err, sso.ErrEmailNotInDomain):
+		c.JSON(http.StatusForbidden, gin.H{"error": "email_domain_not_verified"})
+		return
+	case errors.Is(err, sso.ErrAccountConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": "account_conflict"})
+		return
+	case err != nil:
+		slog.WarnContext(c.Request.Context(), "saml: login rejected", "org_id", orgID, "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_saml_response"})
+		return
+	}
+
+	pair, err := h.tokens.issueTokens(c, u.ID)
+	if err != nil {
+		respondTokenError(c, err)
+		return
+	}
+
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID:    orgID,
+		ActorID:  u.ID,
+		Action:   "sso.login",
+		Entity:   "user",
+		EntityID: u.ID,
+	}))
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, gin.H{
+		"user":          gin.H{"id": u.ID, "email": u.Email},
+		"org_id":        orgID,
+		"relay_state":   c.PostForm("RelayState"),
+		"access_token":  pair.AcessToken,
+		"refresh_token": pair.RefreshToken,
+	})
+}
+
+type discoverIn struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// Discover tells a login form whether the email must use SSO.
+// @Summary Discover SSO for an email
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body discoverIn true "Email"
+// @Success 200 {object} map[string]interface{}
+// @Failure 422 {object} ErrorResponse
+// @Router /auth/sso/discover [post]
+func (h *SSOHandler) Discover(c *gin.Context) {
+	var in discoverIn
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+	d, ok, err := h.ss.Discover(in.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "discover_failed"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusOK, gin.H{"sso": false})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sso": true, "enforced": d.Enforced, "login_url": ssoLoginURL(d.OrgID)})
+}
@@ -1,39 +1,61 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
-	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/Ulpio/vergo/internal/domain/file"
+	"github.com/Ulpio/vergo/internal/domain/org"
+	"github.com/Ulpio/vergo/internal/domain/project"
 	"github.com/Ulpio/vergo/internal/http/middleware"
 	"github.com/Ulpio/vergo/internal/pkg/config"
+	"github.com/Ulpio/vergo/internal/pkg/precondition"
 	s3store "github.com/Ulpio/vergo/internal/storage/s3"
 )
 
 type StorageHandler struct {
 	s3 *s3store.S3
 	fs file.Service
+	ps project.Service
+	os org.Service
 }
 
-func NewStorageHandler(s3c *s3store.S3, fs file.Service) *StorageHandler {
-	return &StorageHandler{s3: s3c, fs: fs}
+func NewStorageHandler(s3c *s3store.S3, fs file.Service, ps project.Service, os org.Service) *StorageHandler {
+	return &StorageHandler{s3: s3c, fs: fs, ps: ps, os: os}
+}
+
+// authorizeProject checks the caller holds min access on a project a file
+// belongs to, answering the request when not.
+func (h *StorageHandler) authorizeProject(c *gin.Context, orgID, projectID, min string) bool {
+	a, err := projectActor(c, h.os)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "permission_check_failed"})
+		return false
+	}
+	if err := h.ps.Authorize(orgID, projectID, a, min); err != nil {
+		respondProjectError(c, err, "permission_check_failed")
+		return false
+	}
+	return true
 }
 
 // ---------- Presign (PUT) ----------
 
 type presignPutIn struct {
-	Bucket      string `json:"bucket"`
 	Key         string `json:"key" binding:"required"`
 	ContentType string `json:"content_type" binding:"required"`
 	ExpiresSec  int64  `json:"expires,omitempty"`
 }
 
-// PresignPut generates a presigned URL for S3 upload.
+// PresignPut generates a presigned URL for S3 upload. Keys outside the
+// org/<org_id>/ prefix are placed under the caller's folder, as CreateFile
+// does; overwriting an object registered in projects needs editor access on
+// one of them.
 // @Summary Get presigned upload URL
 // @Tags Storage
 // @Security BearerAuth
@@ -43,6 +65,8 @@ type presignPutIn struct {
 // @Param body body presignPutIn true "Upload parameters"
 // @Success 200 {object} PresignPutResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Failure 422 {object} ErrorResponse
 // @Failure 500 {object} ErrorDetailResponse
 // @Router /storage/presign [post]
@@ -52,23 +76,38 @@ func (h *StorageHandler) PresignPut(c *gin.Context) {
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
 		return
 	}
-	url, headers, err := h.s3.PresignPut(c.Request.Context(), in.Bucket, in.Key, in.ContentType, in.ExpiresSec)
+	orgID, _ := middleware.OrgID(c)
+	uid, _ := middleware.UserID(c)
+	key := objectKey(orgID, uid, in.Key)
+	if !inOrg(orgID, key) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "key_outside_org"})
+		return
+	}
+	projectIDs, err := h.fs.ProjectsForKey(orgID, key)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "presign_failed", "detail": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"method": "PUT", "url": url, "headers": headers})
+	if len(projectIDs) > 0 && !h.canAccessAny(c, orgID, projectIDs, project.AccessEditor) {
+		return
+	}
+	url, headers, err := h.s3.PresignPut(c.Request.Context(), "", key, in.ContentType, in.ExpiresSec)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "presign_failed", "detail": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"method": "PUT", "url": url, "headers": headers, "key": key})
 }
 
 // ---------- Presign (GET) ----------
 
 type presignGetIn struct {
-	Bucket     string `json:"bucket"`
 	Key        string `json:"key" binding:"required"`
 	ExpiresSec int64  `json:"expires,omitempty"`
 }
 
-// PresignGet generates a presigned URL for S3 download.
+// PresignGet generates a presigned URL for S3 download. Only keys under the
+// org/<org_id>/ prefix are served.
 // @Summary Get presigned download URL
 // @Tags Storage
 // @Security BearerAuth
@@ -78,6 +117,8 @@ type presignGetIn struct {
 // @Param body body presignGetIn true "Download parameters"
 // @Success 200 {object} PresignGetResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Failure 422 {object} ErrorResponse
 // @Failure 500 {object} ErrorDetailResponse
 // @Router /storage/presign-download [post]
@@ -87,7 +128,21 @@ func (h *StorageHandler) PresignGet(c *gin.Context) {
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
 		return
 	}
-	url, err := h.s3.PresignGet(c.Request.Context(), in.Bucket, in.Key, in.ExpiresSec)
+	orgID, _ := middleware.OrgID(c)
+	if !inOrg(orgID, in.Key) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "key_outside_org"})
+		return
+	}
+	// objects registered in projects need viewer access to one of them
+	projectIDs, err := h.fs.ProjectsForKey(orgID, in.Key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "presign_failed", "detail": err.Error()})
+		return
+	}
+	if len(projectIDs) > 0 && !h.canAccessAny(c, orgID, projectIDs, project.AccessViewer) {
+		return
+	}
+	url, err := h.s3.PresignGet(c.Request.Context(), "", in.Key, in.ExpiresSec)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "presign_failed", "detail": err.Error()})
 		return
@@ -95,25 +150,61 @@ func (h *StorageHandler) PresignGet(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"method": "GET", "url": url})
 }
 
+// canAccessAny reports whether the caller holds min access on one of the
+// projects, answering the request when not.
+func (h *StorageHandler) canAccessAny(c *gin.Context, orgID string, projectIDs []string, min string) bool {
+	a, err := projectActor(c, h.os)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "permission_check_failed"})
+		return false
+	}
+	for _, id := range projectIDs {
+		err = h.ps.Authorize(orgID, id, a, min)
+		if err == nil {
+			return true
+		}
+	}
+	respondProjectError(c, err, "permission_check_failed")
+	return false
+}
+
+// objectKey places keys without the org/ prefix under the user's folder.
+func objectKey(orgID, userID, key string) string {
+	if strings.HasPrefix(key, "org/") {
+		return key
+	}
+	return fmt.Sprintf("org/%s/users/%s/%s", orgID, userID, strings.TrimLeft(key, "/"))
+}
+
+// inOrg reports whether the object key lies under the org's prefix.
+func inOrg(orgID, key string) bool {
+	return orgID != "" && strings.HasPrefix(key, "org/"+orgID+"/") && !strings.Contains(key, "..")
+}
+
 type fileCreateIn struct {
-	Bucket      string      `json:"bucket,omitempty"`
+	ProjectID   string      `json:"project_id,omitempty"`
 	Key         string      `json:"key" binding:"required"`
 	SizeBytes   *int64      `json:"size_bytes,omitempty"`
 	ContentType string      `json:"content_type,omitempty"`
 	Metadata    interface{} `json:"metadata,omitempty"`
 }
 
-// CreateFile registers file metadata after upload.
+// CreateFile registers file metadata after upload, always in S3_BUCKET.
+// Attaching the file to a project requires editor access on it.
 // @Summary Register uploaded file
 // @Tags Storage
 // @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param X-Org-ID header string true "Organization ID"
+// @Param Idempotency-Key header string false "Replays the first response to retries with the same key"
 // @Param body body fileCreateIn true "File metadata"
 // @Success 201 {object} file.File
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 422 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /storage/files [post]
@@ -134,6 +225,9 @@ func (h *StorageHandler) CreateFile(c *gin.Context) {
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
 		return
 	}
+	if in.ProjectID != "" && !h.authorizeProject(c, orgID, in.ProjectID, project.AccessEditor) {
+		return
+	}
 
 	cfg := config.Load()
 	if len(cfg.StorageAllowedTypes) > 0 && in.ContentType != "" {
@@ -157,12 +251,13 @@ func (h *StorageHandler) CreateFile(c *gin.Context) {
 		}
 	}
 
-	key := in.Key
-	if !strings.HasPrefix(key, "org/") {
-		key = fmt.Sprintf("org/%s/users/%s/%s", orgID, uid, strings.TrimLeft(in.Key, "/"))
+	key := objectKey(orgID, uid, in.Key)
+	if !inOrg(orgID, key) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "key_outside_org"})
+		return
 	}
 
-	f, err := h.fs.Create(orgID, uid, in.Bucket, key, in.SizeBytes, in.ContentType, in.Metadata)
+	f, err := h.fs.Create(orgID, uid, in.ProjectID, h.s3.DefaultBucket, key, in.SizeBytes, in.ContentType, in.Metadata)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "create_failed"})
 		return
@@ -170,15 +265,17 @@ func (h *StorageHandler) CreateFile(c *gin.Context) {
 	c.JSON(http.StatusCreated, f)
 }
 
-// ListFiles returns files in the organization.
+// ListFiles returns the org's files outside projects plus those in projects
+// the caller can view.
 // @Summary List files
 // @Tags Storage
 // @Security BearerAuth
 // @Produce json
 // @Param X-Org-ID header string true "Organization ID"
 // @Param limit query int false "Items per page (max 100)" default(20)
-// @Param offset query int false "Offset for pagination" default(0)
-// @Success 200 {object} map[string]interface{} "items, next_offset"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param project_id query string false "Only files of this project"
+// @Success 200 {object} pagination.Page[file.File]
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
@@ -190,36 +287,47 @@ func (h *StorageHandler) ListFiles(c *gin.Context) {
 		return
 	}
 
-	limit := 20
-	if v := c.Query("limit"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 100 {
-			limit = n
-		}
+	limit, cursor, ok := cursorParams(c)
+	if !ok {
+		return
 	}
-	offset := 0
-	if v := c.Query("offset"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
-			offset = n
+
+	p := file.ListParams{OrgID: orgID, ProjectID: c.Query("project_id"), Limit: limit, Cursor: cursor}
+	a, err := projectActor(c, h.os)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "permission_check_failed"})
+		return
+	}
+	if a.AllProjects {
+		p.AllProjects = true
+	} else {
+		if p.ProjectIDs, err = h.ps.GrantedIDs(orgID, a); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "list_failed"})
+			return
 		}
 	}
 
-	items, err := h.fs.List(file.ListParams{OrgID: orgID, Limit: limit, Offset: offset})
+	page, err := h.fs.List(p)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "list_failed"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"items": items, "next_offset": offset + len(items)})
+	c.JSON(http.StatusOK, page)
 }
 
-// GetFile returns file metadata by ID.
+// GetFile returns file metadata by ID, with its version as the ETag.
 // @Summary Get file
 // @Tags Storage
 // @Security BearerAuth
 // @Produce json
 // @Param X-Org-ID header string true "Organization ID"
+// @Param If-None-Match header string false "ETag the client holds"
 // @Param id path string true "File ID"
 // @Success 200 {object} file.File
+// @Header 200 {string} ETag "File version"
+// @Success 304 "Not Modified"
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Router /storage/files/{id} [get]
 func (h *StorageHandler) GetFile(c *gin.Context) {
@@ -235,19 +343,30 @@ func (h *StorageHandler) GetFile(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
 		return
 	}
+	if f.ProjectID != "" && !h.authorizeProject(c, orgID, f.ProjectID, project.AccessViewer) {
+		return
+	}
+	if middleware.SetETag(c, f.Version) {
+		return
+	}
 	c.JSON(http.StatusOK, f)
 }
 
-// DeleteFile removes a file from S3 and database.
+// DeleteFile removes a file from the database and then from S3. Project
+// files require editor access. With If-Match only that version is deleted,
+// and the object stays when the precondition fails.
 // @Summary Delete file
 // @Tags Storage
 // @Security BearerAuth
 // @Produce json
 // @Param X-Org-ID header string true "Organization ID"
+// @Param If-Match header string false "ETag the deletion applies to"
 // @Param id path string true "File ID"
 // @Success 204 "No Content"
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /storage/files/{id} [delete]
 func (h *StorageHandler) DeleteFile(c *gin.Context) {
@@ -264,16 +383,27 @@ func (h *StorageHandler) DeleteFile(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
 		return
 	}
+	if f.ProjectID != "" && !h.authorizeProject(c, orgID, f.ProjectID, project.AccessEditor) {
+		return
+	}
+	// Remove metadados primeiro: o delete condicional decide a corrida com
+	// outros writers e só então o objeto some
+	ifVersion := middleware.IfMatch(c)
+	if err := h.fs.Delete(orgID, id, ifVersion); err != nil {
+		switch {
+		case errors.Is(err, precondition.ErrFailed):
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": "precondition_failed"})
+		case errors.Is(err, file.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "delete_failed"})
+		}
+		return
+	}
 
-	// Deleta no S3 (best-effort)
+	// Deleta no S3 (best-effort); um objeto órfão só ocupa espaço
 	if err := h.s3.DeleteObject(c.Request.Context(), f.Bucket, f.ObjectKey); err != nil {
 		slog.ErrorContext(c.Request.Context(), "s3 delete failed", "error", err)
 	}
-
-	// Remove metadados
-	if err := h.fs.Delete(orgID, id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "delete_failed"})
-		return
-	}
 	c.Status(http.StatusNoContent)
 }
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ulpio/vergo/internal/domain/audit"
+	"github.com/Ulpio/vergo/internal/domain/team"
+	"github.com/Ulpio/vergo/internal/http/middleware"
+)
+
+type TeamsHandler struct {
+	ts team.Service
+	as audit.Service
+}
+
+func NewTeamsHandler(ts team.Service, as audit.Service) *TeamsHandler {
+	return &TeamsHandler{ts: ts, as: as}
+}
+
+type teamIn struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+type teamMemberIn struct {
+	UserID string `json:"user_id" binding:"required"`
+}
+
+// List returns the org's teams with their member counts.
+// @Summary List teams
+// @Tags Teams
+// @Security BearerAuth
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Success 200 {object} map[string][]team.Team "items"
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /teams [get]
+func (h *TeamsHandler) List(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	items, err := h.ts.List(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "list_failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// Create adds a team.
+// @Summary Create team
+// @Tags Teams
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param body body teamIn true "Team name and description"
+// @Success 201 {object} team.Team
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /teams [post]
+func (h *TeamsHandler) Create(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	actorID, _ := middleware.UserID(c)
+	var in teamIn
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+	t, err := h.ts.Create(orgID, in.Name, in.Description)
+	if err != nil {
+		respondTeamError(c, err)
+		return
+	}
+
+	after, _ := json.Marshal(t)
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID: orgID, ActorID: actorID, Action: "team.created",
+		Entity: "team", EntityID: t.ID, Timestamp: time.Now(),
+		Metadata: audit.Metadata{After: after},
+	}))
+
+	c.JSON(http.StatusCreated, t)
+}
+
+// Get returns a team.
+// @Summary Get team
+// @Tags Teams
+// @Security BearerAuth
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Team ID"
+// @Success 200 {object} team.Team
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /teams/{id} [get]
+func (h *TeamsHandler) Get(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	t, err := h.ts.Get(orgID, c.Param("id"))
+	if err != nil {
+		respondTeamError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+// Delete removes a team and the project grants it held.
+// @Summary Delete team
+// @Tags Teams
+// @Security BearerAuth
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Team ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /teams/{id} [delete]
+func (h *TeamsHandler) Delete(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	actorID, _ := middleware.UserID(c)
+	id := c.Param("id")
+	before, err := h.ts.Get(orgID, id)
+	if err != nil {
+		respondTeamError(c, err)
+		return
+	}
+	if err := h.ts.Delete(orgID, id); err != nil {
+		respondTeamError(c, err)
+		return
+	}
+
+	b, _ := json.Marshal(before)
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID: orgID, ActorID: actorID, Action: "team.deleted",
+		Entity: "team", EntityID: id, Timestamp: time.Now(),
+		Metadata: audit.Metadata{Before: b},
+	}))
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListMembers returns the members of a team.
+// @Summary List team members
+// @Tags Teams
+// @Security BearerAuth
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Team ID"
+// @Success 200 {object} map[string][]team.Member "items"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /teams/{id}/members [get]
+func (h *TeamsHandler) ListMembers(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	items, err := h.ts.ListMembers(orgID, c.Param("id"))
+	if err != nil {
+		respondTeamError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// AddMember puts an org member in a team.
+// @Summary Add team member
+// @Tags Teams
+// @Security BearerAuth
+// @Accept json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Team ID"
+// @Param body body teamMemberIn true "Org member to add"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /teams/{id}/members [post]
+func (h *TeamsHandler) AddMember(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	actorID, _ := middleware.UserID(c)
+	id := c.Param("id")
+	var in teamMemberIn
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+		return
+	}
+	if err := h.ts.AddMember(orgID, id, in.UserID); err != nil {
+		respondTeamError(c, err)
+		return
+	}
+
+	after, _ := json.Marshal(gin.H{"user_id": in.UserID})
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID: orgID, ActorID: actorID, Action: "team.member_added",
+		Entity: "team", EntityID: id, Timestamp: time.Now(),
+		Metadata: audit.Metadata{After: after},
+	}))
+
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveMember takes a user out of a team.
+// @Summary Remove team member
+// @Tags Teams
+// @Security BearerAuth
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Team ID"
+// @Param userId path string true "User ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /teams/{id}/members/{userId} [delete]
+func (h *TeamsHandler) RemoveMember(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+	actorID, _ := middleware.UserID(c)
+	id, userID := c.Param("id"), c.Param("userId")
+	if err := h.ts.RemoveMember(orgID, id, userID); err != nil {
+		respondTeamError(c, err)
+		return
+	}
+
+	before, _ := json.Marshal(gin.H{"user_id": userID})
+	_ = h.as.Record(auditEvent(c, audit.Event{
+		OrgID: orgID, ActorID: actorID, Action: "team.member_removed",
+		Entity: "team", EntityID: id, Timestamp: time.Now(),
+		Metadata: audit.Metadata{Before: before},
+	}))
+
+	c.Status(http.StatusNoContent)
+}
+
+func respondTeamError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, team.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "team_not_found"})
+	case errors.Is(err, team.ErrNameTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "team_name_taken"})
+	case errors.Is(err, team.ErrNotMember):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "not_org_member"})
+	case errors.Is(err, team.ErrNotInTeam):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not_in_team"})
+	case errors.Is(err, team.ErrEmptyName):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_payload"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "team_update_failed"})
+	}
+}
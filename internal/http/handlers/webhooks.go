@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/Ulpio/vergo/internal/domain/webhook"
 	"github.com/Ulpio/vergo/internal/http/middleware"
+	"github.com/Ulpio/vergo/internal/pkg/precondition"
 )
 
 type WebhooksHandler struct {
@@ -73,23 +75,60 @@ func (h *WebhooksHandler) ListEndpoints(c *gin.Context) {
 	c.JSON(http.StatusOK, eps)
 }
 
+// GetEndpoint returns a webhook endpoint, with its version as the ETag.
+// @Summary Get webhook endpoint
+// @Tags Webhooks
+// @Security BearerAuth
+// @Produce json
+// @Param X-Org-ID header string true "Organization ID"
+// @Param If-None-Match header string false "ETag the client holds"
+// @Param id path string true "Endpoint ID"
+// @Success 200 {object} webhook.Endpoint
+// @Header 200 {string} ETag "Endpoint version"
+// @Success 304 "Not Modified"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /webhooks/endpoints/{id} [get]
+func (h *WebhooksHandler) GetEndpoint(c *gin.Context) {
+	orgID, _ := middleware.OrgID(c)
+
+	ep, err := h.ws.GetEndpoint(orgID, c.Param("id"))
+	if errors.Is(err, webhook.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "get_failed"})
+		return
+	}
+	if middleware.SetETag(c, ep.Version) {
+		return
+	}
+	c.JSON(http.StatusOK, ep)
+}
+
 type updateEndpointIn struct {
 	URL    string   `json:"url" binding:"required"`
 	Events []string `json:"events" binding:"required"`
 	Active bool     `json:"active"`
 }
 
-// UpdateEndpoint updates a webhook endpoint.
+// UpdateEndpoint updates a webhook endpoint. With If-Match the update only
+// applies to that version.
 // @Summary Update webhook endpoint
 // @Tags Webhooks
 // @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param X-Org-ID header string true "Organization ID"
+// @Param If-Match header string false "ETag the update applies to"
 // @Param id path string true "Endpoint ID"
 // @Param body body updateEndpointIn true "Updated endpoint config"
 // @Success 204
 // @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
 // @Failure 422 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /webhooks/endpoints/{id} [patch]
@@ -103,7 +142,15 @@ func (h *WebhooksHandler) UpdateEndpoint(c *gin.Context) {
 		return
 	}
 
-	if err := h.ws.UpdateEndpoint(orgID, id, in.URL, in.Events, in.Active); err != nil {
+	err := h.ws.UpdateEndpoint(orgID, id, in.URL, in.Events, in.Active, middleware.IfMatch(c))
+	switch {
+	case errors.Is(err, webhook.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+		return
+	case errors.Is(err, precondition.ErrFailed):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "precondition_failed"})
+		return
+	case err != nil:
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "update_failed"})
 		return
 	}
@@ -6,18 +6,23 @@ import (
 
 	"github.com/Ulpio/vergo/internal/auth"
 	"github.com/Ulpio/vergo/internal/domain/apikey"
-	"github.com/Ulpio/vergo/internal/pkg/config"
+	"github.com/Ulpio/vergo/internal/domain/platform"
 	"github.com/gin-gonic/gin"
 )
 
 const ctxUserID = "user_id"
 const ctxAPIKeyAuth = "api_key_auth"
+const ctxOrgClaims = "org_claims"
+const ctxImpersonator = "impersonator_id"
+const ctxScopedAccess = "scoped_access"
 
-func Auth(cfg config.Config) gin.HandlerFunc {
-	return AuthWithAPIKeys(cfg, nil)
+// Auth authenticates a JWT bearer token. With ps set, tokens of suspended
+// users are refused.
+func Auth(keys *auth.Keyring, ps platform.Service) gin.HandlerFunc {
+	return AuthWithAPIKeys(keys, nil, ps)
 }
 
-func AuthWithAPIKeys(cfg config.Config, keySvc apikey.Service) gin.HandlerFunc {
+func AuthWithAPIKeys(keys *auth.Keyring, keySvc apikey.Service, ps platform.Service) gin.HandlerFunc {
 	const prefix = "bearer "
 	return func(c *gin.Context) {
 		h := c.GetHeader("Authorization")
@@ -44,22 +49,65 @@ func AuthWithAPIKeys(cfg config.Config, keySvc apikey.Service) gin.HandlerFunc {
 			}
 			c.Set(ctxUserID, result.KeyID)
 			c.Set(ctxOrgID, result.OrgID)
+			c.Set(ctxRole, result.Role)
 			c.Set(ctxAPIKeyAuth, true)
 			c.Next()
 			return
 		}
 
 		// JWT authentication
-		claims, err := auth.Parse(token, cfg.JWTAccessSecret)
+		claims, err := auth.Parse(token, keys)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid_token"})
 			return
 		}
+		if ps != nil {
+			suspended, err := userSuspended(c, ps, claims)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "suspension_check_failed"})
+				return
+			}
+			if suspended {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "user_suspended"})
+				return
+			}
+		}
 		c.Set(ctxUserID, claims.UserID)
+		if claims.Impersonated() {
+			c.Set(ctxImpersonator, claims.Act.Subject)
+		}
+		if claims.OrgScoped() {
+			c.Set(ctxOrgClaims, claims)
+		}
 		c.Next()
 	}
 }
 
+// userSuspended checks the token's user. For org-scoped tokens the same
+// query loads what Tenant checks the token against, kept for it.
+func userSuspended(c *gin.Context, ps platform.Service, claims *auth.Claims) (bool, error) {
+	if !claims.OrgScoped() {
+		return ps.UserSuspended(claims.UserID)
+	}
+	a, err := ps.ScopedAccess(claims.UserID, claims.OrgID)
+	if err != nil {
+		return false, err
+	}
+	c.Set(ctxScopedAccess, a)
+	return a.UserSuspended, nil
+}
+
+// OrgClaims returns the claims of an org-scoped access token, if the request
+// carried one.
+func OrgClaims(c *gin.Context) (*auth.Claims, bool) {
+	v, ok := c.Get(ctxOrgClaims)
+	if !ok {
+		return nil, false
+	}
+	claims, _ := v.(*auth.Claims)
+	return claims, claims != nil
+}
+
 func UserID(c *gin.Context) (string, bool) {
 	v, ok := c.Get(ctxUserID)
 	if !ok {
@@ -68,3 +116,33 @@ func UserID(c *gin.Context) (string, bool) {
 	id, _ := v.(string)
 	return id, id != ""
 }
+
+// IsAPIKey reports whether the request authenticated with an API key, in
+// which case UserID holds the key ID.
+func IsAPIKey(c *gin.Context) bool {
+	_, ok := c.Get(ctxAPIKeyAuth)
+	return ok
+}
+
+// Impersonator returns the platform admin acting as UserID when the request
+// carries an impersonation token.
+func Impersonator(c *gin.Context) (string, bool) {
+	v, ok := c.Get(ctxImpersonator)
+	if !ok {
+		return "", false
+	}
+	id, _ := v.(string)
+	return id, id != ""
+}
+
+// NoImpersonation rejects impersonated requests, for account-security routes
+// (credentials, email, deletion) that only the user may use.
+func NoImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := Impersonator(c); ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "not_allowed_while_impersonating"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
-	"github.com/Ulpio/vergo/internal/pkg/config"
+	"github.com/Ulpio/vergo/internal/auth"
 	"github.com/gin-gonic/gin"
 )
 
@@ -23,12 +23,16 @@ func FuzzAuthHeader(f *testing.F) {
 	f.Add("\x00\xff\xfe")
 	f.Add("Bearer " + string(make([]byte, 10000)))
 
+	keys, err := auth.GenerateKeyring()
+	if err != nil {
+		f.Fatalf("GenerateKeyring: %v", err)
+	}
+
 	f.Fuzz(func(t *testing.T, header string) {
 		w := httptest.NewRecorder()
 		_, r := gin.CreateTestContext(w)
 
-		cfg := config.Config{JWTAccessSecret: "fuzz-secret"}
-		r.GET("/test", Auth(cfg), func(c *gin.Context) {
+		r.GET("/test", Auth(keys, nil), func(c *gin.Context) {
 			c.Status(http.StatusOK)
 		})
 
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ulpio/vergo/internal/pkg/precondition"
+)
+
+const ctxIfMatch = "if_match"
+
+// Preconditions reads If-Match for the handlers below it, which pass
+// IfMatch(c) on to their service so the write only applies to that
+// version. The header holds "*" or one ETag as sent by SetETag; anything
+// else is 400 invalid_if_match. Weak tags never match a write (RFC 9110
+// 13.1.1) and are 412 precondition_failed.
+func Preconditions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h := strings.TrimSpace(c.GetHeader("If-Match"))
+		if h == "" || h == "*" {
+			c.Next()
+			return
+		}
+		if strings.HasPrefix(h, "W/") {
+			c.AbortWithStatusJSON(http.StatusPreconditionFailed, gin.H{"error": "precondition_failed"})
+			return
+		}
+		v, ok := precondition.ParseETag(h, false)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid_if_match"})
+			return
+		}
+		c.Set(ctxIfMatch, v)
+		c.Next()
+	}
+}
+
+// IfMatch is the version If-Match asks for, or precondition.Any.
+func IfMatch(c *gin.Context) int64 {
+	v, _ := c.Get(ctxIfMatch)
+	n, _ := v.(int64)
+	return n
+}
+
+// SetETag sets the ETag of a resource at version. On GET and HEAD it
+// answers 304 and returns true when If-None-Match already names it.
+func SetETag(c *gin.Context, version int64) bool {
+	c.Header("ETag", precondition.ETag(version))
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		return false
+	}
+	for _, tag := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		if v, ok := precondition.ParseETag(tag, true); (ok && v == version) || strings.TrimSpace(tag) == "*" {
+			c.AbortWithStatus(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ulpio/vergo/internal/pkg/precondition"
+)
+
+func conditionalRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Preconditions())
+	r.GET("/thing", func(c *gin.Context) {
+		if SetETag(c, 3) {
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"version": 3})
+	})
+	r.PATCH("/thing", func(c *gin.Context) {
+		if err := precondition.Check(IfMatch(c), 3); err != nil {
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": "precondition_failed"})
+			return
+		}
+		SetETag(c, 4)
+		c.JSON(http.StatusOK, gin.H{"version": 4})
+	})
+	return r
+}
+
+func TestConditional_IfNoneMatch(t *testing.T) {
+	r := conditionalRouter()
+	cases := []struct {
+		header string
+		want   int
+	}{
+		{"", http.StatusOK},
+		{`"2"`, http.StatusOK},
+		{`"3"`, http.StatusNotModified},
+		{`W/"3"`, http.StatusNotModified},
+		{`"1", "3"`, http.StatusNotModified},
+		{"*", http.StatusNotModified},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/thing", nil)
+		if tc.header != "" {
+			req.Header.Set("If-None-Match", tc.header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("If-None-Match %q: status = %d, want %d", tc.header, w.Code, tc.want)
+		}
+		if got := w.Header().Get("ETag"); got != `"3"` {
+			t.Errorf("If-None-Match %q: ETag = %q", tc.header, got)
+		}
+		if tc.want == http.StatusNotModified && w.Body.Len() > 0 {
+			t.Errorf("If-None-Match %q: 304 with body %q", tc.header, w.Body.String())
+		}
+	}
+}
+
+func TestConditional_IfMatch(t *testing.T) {
+	r := conditionalRouter()
+	cases := []struct {
+		header string
+		want   int
+	}{
+		{"", http.StatusOK},
+		{"*", http.StatusOK},
+		{`"3"`, http.StatusOK},
+		{`"2"`, http.StatusPreconditionFailed},
+		{`W/"3"`, http.StatusPreconditionFailed},
+		{`"2", "3"`, http.StatusBadRequest},
+		{"3", http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPatch, "/thing", nil)
+		if tc.header != "" {
+			req.Header.Set("If-Match", tc.header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("If-Match %q: status = %d, want %d: %s", tc.header, w.Code, tc.want, w.Body.String())
+		}
+	}
+}
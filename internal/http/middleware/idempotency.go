@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ulpio/vergo/internal/domain/idempotency"
+)
+
+const maxIdempotencyKeyLen = 255
+
+// Idempotency replays the stored response when a request repeats an
+// Idempotency-Key already used by the same principal in the same org, so
+// retried POSTs do not create duplicates. Reusing a key for a different
+// request is 409 idempotency_key_mismatch, and retrying while the first
+// request still runs is 409 idempotency_key_in_use. Responses with a 5xx
+// status are not stored, so the client may retry those with the same key.
+// It must run after AuthWithAPIKeys and Tenant.
+func Idempotency(svc idempotency.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid_idempotency_key"})
+			return
+		}
+		orgID, ok := OrgID(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "missing_org_id"})
+			return
+		}
+		uid, ok := UserID(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing_user"})
+			return
+		}
+		principal := "user:" + uid
+		if IsAPIKey(c) {
+			principal = "key:" + uid
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid_body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		k := idempotency.Key{Principal: principal, OrgID: orgID, Key: key}
+		stored, err := svc.Begin(k, fingerprint(c.Request, body))
+		switch {
+		case errors.Is(err, idempotency.ErrMismatch):
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "idempotency_key_mismatch"})
+			return
+		case errors.Is(err, idempotency.ErrInFlight):
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "idempotency_key_in_use"})
+			return
+		case err != nil:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "idempotency_failed"})
+			return
+		case stored != nil:
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(stored.Status, stored.ContentType, stored.Body)
+			c.Abort()
+			return
+		}
+
+		rec := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = rec
+		done := false
+		// A panic unwinds past the Complete below; free the key so the
+		// request can be retried.
+		defer func() {
+			if !done {
+				if err := svc.Release(k); err != nil {
+					slog.Error("idempotency: release", "error", err)
+				}
+			}
+		}()
+
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			return
+		}
+		err = svc.Complete(k, idempotency.Response{
+			Status:      c.Writer.Status(),
+			ContentType: c.Writer.Header().Get("Content-Type"),
+			Body:        rec.body.Bytes(),
+		})
+		if err != nil {
+			slog.Error("idempotency: complete", "error", err)
+			return
+		}
+		done = true
+	}
+}
+
+// fingerprint identifies a request by its method, URI and body.
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordingWriter keeps a copy of the response body.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ulpio/vergo/internal/domain/idempotency"
+)
+
+// memIdempotency is an in-memory idempotency.Service.
+type memIdempotency struct {
+	mu   sync.Mutex
+	fps  map[idempotency.Key]string
+	done map[idempotency.Key]idempotency.Response
+}
+
+func newMemIdempotency() *memIdempotency {
+	return &memIdempotency{fps: map[idempotency.Key]string{}, done: map[idempotency.Key]idempotency.Response{}}
+}
+
+func (s *memIdempotency) Begin(k idempotency.Key, fp string) (*idempotency.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	have, ok := s.fps[k]
+	if !ok {
+		s.fps[k] = fp
+		return nil, nil
+	}
+	if have != fp {
+		return nil, idempotency.ErrMismatch
+	}
+	r, ok := s.done[k]
+	if !ok {
+		return nil, idempotency.ErrInFlight
+	}
+	return &r, nil
+}
+
+func (s *memIdempotency) Complete(k idempotency.Key, r idempotency.Response) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.done[k] = r
+	return nil
+}
+
+func (s *memIdempotency) Release(k idempotency.Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.fps, k)
+	delete(s.done, k)
+	return nil
+}
+
+func (s *memIdempotency) PurgeExpired() (int64, error) { return 0, nil }
+
+func TestIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc := newMemIdempotency()
+	calls := 0
+	fail := false
+
+	r := gin.New()
+	r.POST("/things", func(c *gin.Context) {
+		c.Set(ctxUserID, c.GetHeader("X-User"))
+		c.Set(ctxOrgID, "o1")
+	}, Idempotency(svc), func(c *gin.Context) {
+		calls++
+		if fail {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "boom"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"n": calls})
+	})
+
+	post := func(user, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/things", strings.NewReader(body))
+		req.Header.Set("X-User", user)
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	first := post("u1", "k1", `{"a":1}`)
+	if first.Code != http.StatusCreated || first.Body.String() != `{"n":1}` {
+		t.Fatalf("first: %d %s", first.Code, first.Body.String())
+	}
+
+	replay := post("u1", "k1", `{"a":1}`)
+	if replay.Code != http.StatusCreated || replay.Body.String() != `{"n":1}` {
+		t.Errorf("replay: %d %s", replay.Code, replay.Body.String())
+	}
+	if replay.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("replay: missing Idempotent-Replayed header")
+	}
+	if ct := replay.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("replay: Content-Type = %q", ct)
+	}
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want 1", calls)
+	}
+
+	if w := post("u1", "k1", `{"a":2}`); w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "idempotency_key_mismatch") {
+		t.Errorf("mismatch: %d %s", w.Code, w.Body.String())
+	}
+
+	// Keys are scoped to the principal.
+	if w := post("u2", "k1", `{"a":1}`); w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("other user: %d %s", w.Code, w.Body.String())
+	}
+
+	// Without a key every request runs.
+	post("u1", "", `{"a":1}`)
+	if calls != 3 {
+		t.Errorf("handler ran %d times, want 3", calls)
+	}
+
+	// 5xx responses are not stored and the key can be retried.
+	fail = true
+	if w := post("u1", "k2", `{}`); w.Code != http.StatusInternalServerError {
+		t.Errorf("failing: %d", w.Code)
+	}
+	fail = false
+	if w := post("u1", "k2", `{}`); w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("retry after 5xx: %d %s", w.Code, w.Body.String())
+	}
+
+	if w := post("u1", strings.Repeat("k", maxIdempotencyKeyLen+1), `{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("long key: %d", w.Code)
+	}
+}
+
+func TestIdempotency_InFlight(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc := newMemIdempotency()
+	k := idempotency.Key{Principal: "user:u1", OrgID: "o1", Key: "k1"}
+	req := httptest.NewRequest(http.MethodPost, "/things", strings.NewReader(`{}`))
+	if _, err := svc.Begin(k, fingerprint(req, []byte(`{}`))); err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.POST("/things", func(c *gin.Context) {
+		c.Set(ctxUserID, "u1")
+		c.Set(ctxOrgID, "o1")
+	}, Idempotency(svc), func(c *gin.Context) {
+		t.Error("handler ran while the key was in flight")
+	})
+	req.Header.Set("Idempotency-Key", "k1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "idempotency_key_in_use") {
+		t.Errorf("in flight: %d %s", w.Code, w.Body.String())
+	}
+}
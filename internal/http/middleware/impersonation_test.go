@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ulpio/vergo/internal/auth"
+	"github.com/Ulpio/vergo/internal/domain/org"
+	"github.com/Ulpio/vergo/internal/domain/platform"
+)
+
+// orgsStub answers IsMember and Get for the tenant check.
+type orgsStub struct {
+	org.Service
+	orgs map[string]org.Organization
+}
+
+func (s orgsStub) IsMember(orgID, userID string) (bool, string, error) {
+	_, ok := s.orgs[orgID]
+	return ok, "member", nil
+}
+
+func (s orgsStub) Get(id string) (org.Organization, error) {
+	o, ok := s.orgs[id]
+	if !ok {
+		return org.Organization{}, org.ErrNotFound
+	}
+	return o, nil
+}
+
+// platformStub is a platform.Service with fixed admin and suspended sets.
+type platformStub struct {
+	platform.Service
+	admins, suspended map[string]bool
+}
+
+func (s platformStub) IsAdmin(userID string) (bool, error)       { return s.admins[userID], nil }
+func (s platformStub) UserSuspended(userID string) (bool, error) { return s.suspended[userID], nil }
+
+func TestImpersonation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys, err := auth.GenerateKeyring()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orgs := orgsStub{orgs: map[string]org.Organization{
+		"open":   {ID: "open", AllowImpersonation: true},
+		"closed": {ID: "closed", AllowImpersonation: false},
+	}}
+
+	r := gin.New()
+	r.GET("/tenant", Auth(keys, nil), Tenant(orgs, nil), func(c *gin.Context) {
+		uid, _ := UserID(c)
+		actor, _ := Impersonator(c)
+		c.JSON(http.StatusOK, gin.H{"uid": uid, "actor": actor})
+	})
+	r.GET("/private", Auth(keys, nil), NoImpersonation(), func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/admin", Auth(keys, nil), RequirePlatformAdmin(platformStub{admins: map[string]bool{"admin": true}}), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	plain, _ := auth.NewAccessToken("u1", keys, 15)
+	admin, _ := auth.NewAccessToken("admin", keys, 15)
+	imp, _ := auth.NewImpersonationToken("u1", "admin", keys, 15)
+	// an impersonated admin account still is not a platform admin session
+	impAdmin, _ := auth.NewImpersonationToken("admin", "other", keys, 15)
+
+	cases := []struct {
+		name, path, org, token string
+		want                   int
+	}{
+		{"impersonated, org allows", "/tenant", "open", imp, http.StatusOK},
+		{"impersonated, org blocks", "/tenant", "closed", imp, http.StatusForbidden},
+		{"user, org blocks impersonation", "/tenant", "closed", plain, http.StatusOK},
+		{"private route, user", "/private", "", plain, http.StatusOK},
+		{"private route, impersonated", "/private", "", imp, http.StatusForbidden},
+		{"admin route, admin", "/admin", "", admin, http.StatusOK},
+		{"admin route, user", "/admin", "", plain, http.StatusForbidden},
+		{"admin route, impersonated admin", "/admin", "", impAdmin, http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			if tc.org != "" {
+				req.Header.Set("X-Org-ID", tc.org)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tc.want {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tc.want, w.Body.String())
+			}
+		})
+	}
+
+	// The act claim survives a round trip.
+	claims, err := auth.Parse(imp, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !claims.Impersonated() || claims.Act.Subject != "admin" || claims.UserID != "u1" {
+		t.Errorf("claims = %+v", claims)
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/Ulpio/vergo/internal/domain/platform"
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePlatformAdmin lets only platform admins through. API keys and
+// impersonation tokens never qualify, even for an admin's own account.
+func RequirePlatformAdmin(ps platform.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := UserID(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing_user"})
+			return
+		}
+		if _, isKey := c.Get(ctxAPIKeyAuth); isKey {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "not_platform_admin"})
+			return
+		}
+		if _, ok := Impersonator(c); ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "not_allowed_while_impersonating"})
+			return
+		}
+		isAdmin, err := ps.IsAdmin(uid)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "admin_check_failed"})
+			return
+		}
+		if !isAdmin {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "not_platform_admin"})
+			return
+		}
+		c.Next()
+	}
+}
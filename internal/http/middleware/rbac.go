@@ -1,12 +1,16 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/Ulpio/vergo/internal/domain/org"
 )
 
 const ctxRole = "role"
+const ctxRolePerms = "role_permissions"
 
 var roleOrder = map[string]int{
 	"member": 1,
@@ -14,6 +18,8 @@ var roleOrder = map[string]int{
 	"owner":  3,
 }
 
+// RequireRole checks the built-in member < admin < owner ladder. Custom
+// roles rank as member; use RequirePermission for anything finer.
 func RequireRole(minRole string) gin.HandlerFunc {
 	min := roleOrder[minRole]
 	return func(c *gin.Context) {
@@ -23,10 +29,66 @@ func RequireRole(minRole string) gin.HandlerFunc {
 			return
 		}
 		role, _ := v.(string)
-		if roleOrder[role] < min {
+		level := roleOrder[role]
+		if level == 0 && role != "" {
+			level = roleOrder["member"] // custom role
+		}
+		if level < min {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient_role"})
 			return
 		}
 		c.Next()
 	}
 }
+
+// Role returns the caller's role in the tenant org resolved by Tenant.
+func Role(c *gin.Context) (string, bool) {
+	v, ok := c.Get(ctxRole)
+	if !ok {
+		return "", false
+	}
+	role, _ := v.(string)
+	return role, role != ""
+}
+
+// RequirePermission lets through callers whose role, built-in or custom,
+// grants perm.
+func RequirePermission(orgSvc org.Service, perm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := Role(c); !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		ok, err := HasPermission(c, orgSvc, perm)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "permission_check_failed"})
+			return
+		}
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "missing_permission", "permission": perm})
+			return
+		}
+		c.Next()
+	}
+}
+
+// HasPermission reports whether the caller's role grants perm, for handlers
+// that branch on it. The role is looked up once per request.
+func HasPermission(c *gin.Context, orgSvc org.Service, perm string) (bool, error) {
+	role, ok := Role(c)
+	if !ok {
+		return false, nil
+	}
+	r, cached := c.Get(ctxRolePerms)
+	if !cached {
+		orgID, _ := OrgID(c)
+		found, err := orgSvc.GetRole(orgID, role)
+		if err != nil && !errors.Is(err, org.ErrRoleNotFound) {
+			return false, err
+		}
+		// a role deleted meanwhile grants nothing
+		r = found
+		c.Set(ctxRolePerms, found)
+	}
+	return r.(org.Role).Grants(perm), nil
+}
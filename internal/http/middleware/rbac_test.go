@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ulpio/vergo/internal/domain/org"
+)
+
+// roles is an org.Service stub that only answers GetRole.
+type roles struct {
+	org.Service
+	byName map[string]org.Role
+	calls  *int
+}
+
+func (s roles) GetRole(orgID, name string) (org.Role, error) {
+	*s.calls++
+	r, ok := s.byName[name]
+	if !ok {
+		return org.Role{}, org.ErrRoleNotFound
+	}
+	return r, nil
+}
+
+func TestRequirePermission(t *testing.T) {
+	calls := 0
+	svc := roles{calls: &calls, byName: map[string]org.Role{
+		"member":  {Name: "member", Permissions: []string{org.PermProjectsWrite}},
+		"auditor": {Name: "auditor", Permissions: []string{org.PermAuditRead}},
+	}}
+
+	cases := []struct {
+		role string
+		want int
+	}{
+		{"auditor", http.StatusOK},
+		{"member", http.StatusForbidden},
+		{"deleted", http.StatusForbidden},
+		{"", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		r := gin.New()
+		r.GET("/t", func(c *gin.Context) {
+			if tc.role != "" {
+				c.Set(ctxRole, tc.role)
+			}
+			c.Set(ctxOrgID, "o1")
+		},
+			RequirePermission(svc, org.PermAuditRead),
+			RequirePermission(svc, org.PermAuditRead),
+			func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/t", nil))
+		if w.Code != tc.want {
+			t.Errorf("role %q: status = %d, want %d", tc.role, w.Code, tc.want)
+		}
+	}
+	// the role is resolved once per request, however many checks run
+	if calls != 3 {
+		t.Errorf("GetRole calls = %d, want 3", calls)
+	}
+}
+
+func TestRequireRole_CustomRoleRanksAsMember(t *testing.T) {
+	for minRole, want := range map[string]int{"member": http.StatusOK, "admin": http.StatusForbidden} {
+		r := gin.New()
+		r.GET("/t", func(c *gin.Context) { c.Set(ctxRole, "auditor") },
+			RequireRole(minRole),
+			func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/t", nil))
+		if w.Code != want {
+			t.Errorf("RequireRole(%q): status = %d, want %d", minRole, w.Code, want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ulpio/vergo/internal/domain/scim"
+)
+
+// SCIMAuth authenticates SCIM clients with an org-scoped bearer token
+// (scim_...). The token ID is set as the acting user and the token's org as
+// the tenant; errors use the SCIM error format. Deleted and suspended orgs
+// are refused like on the tenant routes.
+func SCIMAuth(scimSvc scim.Service) gin.HandlerFunc {
+	const prefix = "bearer "
+	return func(c *gin.Context) {
+		h := c.GetHeader("Authorization")
+		token := ""
+		if strings.HasPrefix(strings.ToLower(h), prefix) {
+			token = strings.TrimSpace(h[len(prefix):])
+		}
+		if !strings.HasPrefix(token, "scim_") {
+			abortSCIM(c, http.StatusUnauthorized, "missing or malformed bearer token")
+			return
+		}
+		result, err := scimSvc.ValidateToken(token)
+		if err != nil {
+			abortSCIM(c, http.StatusInternalServerError, "token validation failed")
+			return
+		}
+		if result == nil {
+			abortSCIM(c, http.StatusUnauthorized, "invalid token")
+			return
+		}
+		// same answers as rejectOrg gives the tenant routes
+		if result.OrgDeleted {
+			abortSCIM(c, http.StatusGone, "organization deleted")
+			return
+		}
+		if result.OrgSuspended {
+			abortSCIM(c, http.StatusForbidden, "organization suspended")
+			return
+		}
+		c.Set(ctxUserID, result.TokenID)
+		c.Set(ctxOrgID, result.OrgID)
+		c.Next()
+	}
+}
+
+func abortSCIM(c *gin.Context, status int, detail string) {
+	b, _ := json.Marshal(gin.H{
+		"schemas": []string{scim.SchemaError},
+		"status":  strconv.Itoa(status),
+		"detail":  detail,
+	})
+	c.Data(status, "application/scim+json", b)
+	c.Abort()
+}
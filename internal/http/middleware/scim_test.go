@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ulpio/vergo/internal/domain/scim"
+)
+
+// scimTokens is a scim.Service stub that only answers ValidateToken.
+type scimTokens struct {
+	scim.Service
+	byToken map[string]scim.TokenLookup
+}
+
+func (s scimTokens) ValidateToken(token string) (*scim.TokenLookup, error) {
+	l, ok := s.byToken[token]
+	if !ok {
+		return nil, nil
+	}
+	return &l, nil
+}
+
+func TestSCIMAuth_OrgState(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc := scimTokens{byToken: map[string]scim.TokenLookup{
+		"scim_active":    {TokenID: "t1", OrgID: "o1"},
+		"scim_suspended": {TokenID: "t2", OrgID: "o2", OrgSuspended: true},
+		"scim_deleted":   {TokenID: "t3", OrgID: "o3", OrgDeleted: true, OrgSuspended: true},
+	}}
+
+	r := gin.New()
+	r.GET("/scim", SCIMAuth(svc), func(c *gin.Context) {
+		orgID, _ := OrgID(c)
+		c.String(http.StatusOK, orgID)
+	})
+
+	cases := []struct {
+		token string
+		want  int
+	}{
+		{"scim_active", http.StatusOK},
+		{"scim_unknown", http.StatusUnauthorized},
+		{"scim_suspended", http.StatusForbidden},
+		{"scim_deleted", http.StatusGone},
+	}
+	for _, tc := range cases {
+		t.Run(tc.token, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/scim", nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tc.want {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tc.want, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ulpio/vergo/internal/auth"
+	"github.com/Ulpio/vergo/internal/domain/org"
+)
+
+func TestSuspension(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys, err := auth.GenerateKeyring()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	orgs := orgsStub{orgs: map[string]org.Organization{
+		"active":    {ID: "active", AllowImpersonation: true},
+		"suspended": {ID: "suspended", AllowImpersonation: true, SuspendedAt: &now},
+	}}
+	ps := platformStub{suspended: map[string]bool{"banned": true}}
+
+	r := gin.New()
+	r.GET("/me", Auth(keys, ps), func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/tenant", Auth(keys, ps), Tenant(orgs, nil), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	user, _ := auth.NewAccessToken("u1", keys, 15)
+	banned, _ := auth.NewAccessToken("banned", keys, 15)
+	// impersonating a suspended user is refused as well
+	impBanned, _ := auth.NewImpersonationToken("banned", "admin", keys, 15)
+
+	cases := []struct {
+		name, path, org, token string
+		want                   int
+		err                    string
+	}{
+		{"active user", "/me", "", user, http.StatusOK, ""},
+		{"suspended user", "/me", "", banned, http.StatusForbidden, "user_suspended"},
+		{"impersonated suspended user", "/me", "", impBanned, http.StatusForbidden, "user_suspended"},
+		{"active org", "/tenant", "active", user, http.StatusOK, ""},
+		{"suspended org", "/tenant", "suspended", user, http.StatusForbidden, "org_suspended"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			if tc.org != "" {
+				req.Header.Set("X-Org-ID", tc.org)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tc.want {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tc.want, w.Body.String())
+			}
+			if tc.err != "" && !strings.Contains(w.Body.String(), tc.err) {
+				t.Errorf("body = %s, want error %q", w.Body.String(), tc.err)
+			}
+		})
+	}
+}
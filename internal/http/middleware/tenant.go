@@ -1,15 +1,47 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
+	"github.com/Ulpio/vergo/internal/auth"
 	"github.com/Ulpio/vergo/internal/domain/org"
+	"github.com/Ulpio/vergo/internal/domain/platform"
 	"github.com/Ulpio/vergo/internal/domain/userctx"
 	"github.com/gin-gonic/gin"
 )
 
-const ctxOrgID = "org_id"
+const (
+	ctxOrgID   = "org_id"
+	ctxPathOrg = "path_org_id"
+)
+
+// PathOrg makes the :param path segment name the tenant for the Tenant
+// middleware that follows. X-Org-ID, org-scoped tokens and API keys must
+// then agree with it, so a route never acts on one org while authorizing
+// against another.
+func PathOrg(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(ctxPathOrg, c.Param(param))
+		c.Next()
+	}
+}
+
+// requestedOrg is the org the request names: the PathOrg segment or else
+// X-Org-ID. It aborts with org_mismatch when both are given and differ.
+func requestedOrg(c *gin.Context) (string, bool) {
+	header := strings.TrimSpace(c.GetHeader("X-Org-ID"))
+	path := c.GetString(ctxPathOrg)
+	if path == "" {
+		return header, true
+	}
+	if header != "" && header != path {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "org_mismatch"})
+		return "", false
+	}
+	return path, true
+}
 
 func Tenant(orgSvc org.Service, ctxSvc userctx.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -18,8 +50,21 @@ func Tenant(orgSvc org.Service, ctxSvc userctx.Service) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing_user"})
 			return
 		}
-		// 1) tenta header
-		orgID := strings.TrimSpace(c.GetHeader("X-Org-ID"))
+		// 1) tenta path (PathOrg) ou header
+		orgID, ok := requestedOrg(c)
+		if !ok {
+			return
+		}
+		// API key: org e role vêm da própria chave
+		if _, isKey := c.Get(ctxAPIKeyAuth); isKey {
+			tenantFromAPIKey(c, orgSvc, orgID)
+			return
+		}
+		// token org-scoped: a org vem do token; só confere a versão
+		if claims, ok := OrgClaims(c); ok {
+			tenantFromClaims(c, orgSvc, uid, orgID, claims)
+			return
+		}
 		// 2) fallback para contexto persistido
 		if orgID == "" && ctxSvc != nil {
 			if id, ok2, _ := ctxSvc.GetActiveOrg(uid); ok2 {
@@ -39,6 +84,9 @@ func Tenant(orgSvc org.Service, ctxSvc userctx.Service) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "not_a_member"})
 			return
 		}
+		if orgBlocked(c, orgSvc, orgID) {
+			return
+		}
 		c.Set(ctxOrgID, orgID)
 		if role != "" {
 			c.Set(ctxRole, role)
@@ -46,6 +94,102 @@ func Tenant(orgSvc org.Service, ctxSvc userctx.Service) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// tenantFromClaims resolves the tenant of an org-scoped access token. The
+// token is stale, and rejected, once the membership it was minted against
+// changed role or was removed. Auth usually loaded the state already.
+func tenantFromClaims(c *gin.Context, orgSvc org.Service, uid, wantOrg string, claims *auth.Claims) {
+	if wantOrg != "" && wantOrg != claims.OrgID {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "org_mismatch"})
+		return
+	}
+	v, _ := c.Get(ctxScopedAccess)
+	a, ok := v.(platform.ScopedAccess)
+	if !ok {
+		var err error
+		if a, err = loadScopedAccess(orgSvc, claims.OrgID, uid); err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "tenant_check_failed"})
+			return
+		}
+	}
+	if !a.Member || a.MembershipVersion != claims.MembershipVersion {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "stale_token"})
+		return
+	}
+	if rejectOrg(c, a.OrgDeleted, a.OrgSuspended, a.AllowImpersonation) {
+		return
+	}
+	c.Set(ctxOrgID, claims.OrgID)
+	c.Set(ctxRole, a.Role)
+	c.Next()
+}
+
+// loadScopedAccess reads the membership and org state through orgSvc, for
+// Auth set up without a platform service.
+func loadScopedAccess(orgSvc org.Service, orgID, uid string) (platform.ScopedAccess, error) {
+	m, err := orgSvc.GetMembership(orgID, uid)
+	if errors.Is(err, org.ErrNotMember) {
+		return platform.ScopedAccess{}, nil
+	}
+	if err != nil {
+		return platform.ScopedAccess{}, err
+	}
+	o, err := orgSvc.Get(orgID)
+	if err != nil {
+		return platform.ScopedAccess{}, err
+	}
+	return platform.ScopedAccess{
+		Member:             true,
+		Role:               m.Role,
+		MembershipVersion:  m.Version,
+		OrgDeleted:         o.DeletedAt != nil,
+		OrgSuspended:       o.SuspendedAt != nil,
+		AllowImpersonation: o.AllowImpersonation,
+	}, nil
+}
+
+// tenantFromAPIKey resolves the tenant of an API key, whose org and role
+// AuthWithAPIKeys already set.
+func tenantFromAPIKey(c *gin.Context, orgSvc org.Service, wantOrg string) {
+	orgID, _ := OrgID(c)
+	if wantOrg != "" && wantOrg != orgID {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "org_mismatch"})
+		return
+	}
+	if orgBlocked(c, orgSvc, orgID) {
+		return
+	}
+	c.Next()
+}
+
+// orgBlocked loads the org and applies rejectOrg to it.
+func orgBlocked(c *gin.Context, orgSvc org.Service, orgID string) bool {
+	o, err := orgSvc.Get(orgID)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "tenant_check_failed"})
+		return true
+	}
+	return rejectOrg(c, o.DeletedAt != nil, o.SuspendedAt != nil, o.AllowImpersonation)
+}
+
+// rejectOrg answers requests to a deleted or suspended org, and
+// impersonated requests to an org that does not allow impersonation.
+func rejectOrg(c *gin.Context, deleted, suspended, allowImpersonation bool) bool {
+	if deleted {
+		c.AbortWithStatusJSON(http.StatusGone, gin.H{"error": "org_deleted"})
+		return true
+	}
+	if suspended {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "org_suspended"})
+		return true
+	}
+	if _, ok := Impersonator(c); ok && !allowImpersonation {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "impersonation_blocked"})
+		return true
+	}
+	return false
+}
+
 func OrgID(c *gin.Context) (string, bool) {
 	v, ok := c.Get(ctxOrgID)
 	if !ok {
@@ -0,0 +1,298 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ulpio/vergo/internal/auth"
+	"github.com/Ulpio/vergo/internal/domain/apikey"
+	"github.com/Ulpio/vergo/internal/domain/org"
+	"github.com/Ulpio/vergo/internal/domain/platform"
+)
+
+// memberships is an org.Service stub that only answers GetMembership, and
+// Get with an active org.
+type memberships struct {
+	org.Service
+	m map[string]org.Membership // key: orgID + "/" + userID
+}
+
+func (s memberships) Get(id string) (org.Organization, error) {
+	return org.Organization{ID: id, AllowImpersonation: true}, nil
+}
+
+func (s memberships) GetMembership(orgID, userID string) (org.Membership, error) {
+	m, ok := s.m[orgID+"/"+userID]
+	if !ok {
+		return org.Membership{}, org.ErrNotMember
+	}
+	return m, nil
+}
+
+func TestTenant_OrgScopedToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys, err := auth.GenerateKeyring()
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := memberships{m: map[string]org.Membership{
+		"o1/u1": {OrgID: "o1", UserID: "u1", Role: "admin", Version: 7},
+	}}
+
+	r := gin.New()
+	r.GET("/t", Auth(keys, nil), Tenant(svc, nil), func(c *gin.Context) {
+		orgID, _ := OrgID(c)
+		role, _ := c.Get(ctxRole)
+		c.JSON(http.StatusOK, gin.H{"org": orgID, "role": role})
+	})
+
+	mint := func(orgID string, version int64) string {
+		t.Helper()
+		tok, err := auth.NewOrgAccessToken("u1", orgID, "admin", version, keys, 15)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+
+	cases := []struct {
+		name   string
+		token  string
+		header string
+		want   int
+	}{
+		{"current version", mint("o1", 7), "", http.StatusOK},
+		{"matching header", mint("o1", 7), "o1", http.StatusOK},
+		{"other org in header", mint("o1", 7), "o2", http.StatusForbidden},
+		{"stale version", mint("o1", 6), "", http.StatusUnauthorized},
+		{"membership removed", mint("o2", 7), "", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/t", nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			if tc.header != "" {
+				req.Header.Set("X-Org-ID", tc.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tc.want {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tc.want, w.Body.String())
+			}
+		})
+	}
+}
+
+// keys is an apikey.Service stub that only answers Validate.
+type keys struct {
+	apikey.Service
+	byKey map[string]apikey.LookupResult
+}
+
+func (s keys) Validate(key string) (*apikey.LookupResult, error) {
+	r, ok := s.byKey[key]
+	if !ok {
+		return nil, nil
+	}
+	return &r, nil
+}
+
+func TestTenant_APIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keyring, err := auth.GenerateKeyring()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks := keys{byKey: map[string]apikey.LookupResult{
+		"sk_test": {KeyID: "k1", OrgID: "o1", Role: "deployer"},
+	}}
+
+	r := gin.New()
+	r.GET("/t", AuthWithAPIKeys(keyring, ks, nil), Tenant(memberships{}, nil), func(c *gin.Context) {
+		orgID, _ := OrgID(c)
+		role, _ := Role(c)
+		c.JSON(http.StatusOK, gin.H{"org": orgID, "role": role})
+	})
+
+	cases := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"key org", "", http.StatusOK},
+		{"matching header", "o1", http.StatusOK},
+		{"other org in header", "o2", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/t", nil)
+			req.Header.Set("Authorization", "Bearer sk_test")
+			if tc.header != "" {
+				req.Header.Set("X-Org-ID", tc.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tc.want {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tc.want, w.Body.String())
+			}
+			if tc.want == http.StatusOK && w.Body.String() != `{"org":"o1","role":"deployer"}` {
+				t.Errorf("body = %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestTenant_PathOrg(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keyring, err := auth.GenerateKeyring()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks := keys{byKey: map[string]apikey.LookupResult{
+		"sk_test": {KeyID: "k1", OrgID: "o1", Role: "admin"},
+	}}
+	svc := memberships{m: map[string]org.Membership{
+		"o1/u1": {OrgID: "o1", UserID: "u1", Role: "admin", Version: 1},
+	}}
+	tok, err := auth.NewOrgAccessToken("u1", "o1", "admin", 1, keyring, 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.GET("/orgs/:id", AuthWithAPIKeys(keyring, ks, nil), PathOrg("id"), Tenant(svc, nil), func(c *gin.Context) {
+		orgID, _ := OrgID(c)
+		c.String(http.StatusOK, orgID)
+	})
+
+	cases := []struct {
+		name   string
+		bearer string
+		path   string
+		header string
+		want   int
+	}{
+		{"api key, own org", "sk_test", "/orgs/o1", "", http.StatusOK},
+		{"api key, other org", "sk_test", "/orgs/o2", "", http.StatusForbidden},
+		{"api key, header disagrees", "sk_test", "/orgs/o1", "o2", http.StatusForbidden},
+		{"org token, own org", tok, "/orgs/o1", "o1", http.StatusOK},
+		{"org token, other org", tok, "/orgs/o2", "", http.StatusForbidden},
+		{"org token, other org named in both", tok, "/orgs/o2", "o2", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tc.bearer)
+			if tc.header != "" {
+				req.Header.Set("X-Org-ID", tc.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tc.want {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tc.want, w.Body.String())
+			}
+			if tc.want == http.StatusOK && w.Body.String() != "o1" {
+				t.Errorf("tenant = %s, want o1", w.Body.String())
+			}
+		})
+	}
+}
+
+// lookups counts the org and platform lookups a request makes; each is one
+// query in the Postgres services.
+type lookups struct {
+	n      int
+	access map[string]platform.ScopedAccess // key: orgID + "/" + userID
+}
+
+type countingPlatform struct {
+	platform.Service
+	l *lookups
+}
+
+func (s countingPlatform) UserSuspended(string) (bool, error) {
+	s.l.n++
+	return false, nil
+}
+
+func (s countingPlatform) ScopedAccess(userID, orgID string) (platform.ScopedAccess, error) {
+	s.l.n++
+	return s.l.access[orgID+"/"+userID], nil
+}
+
+type countingOrgs struct {
+	org.Service
+	l *lookups
+}
+
+func (s countingOrgs) Get(id string) (org.Organization, error) {
+	s.l.n++
+	return org.Organization{ID: id}, nil
+}
+
+func (s countingOrgs) GetMembership(orgID, userID string) (org.Membership, error) {
+	s.l.n++
+	return org.Membership{}, org.ErrNotMember
+}
+
+func (s countingOrgs) IsMember(orgID, userID string) (bool, string, error) {
+	s.l.n++
+	return false, "", nil
+}
+
+func TestTenant_OrgScopedTokenQueries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keyring, err := auth.GenerateKeyring()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &lookups{access: map[string]platform.ScopedAccess{
+		"o1/u1":     {Member: true, Role: "admin", MembershipVersion: 7},
+		"o1/banned": {UserSuspended: true, Member: true, Role: "admin", MembershipVersion: 7},
+		"o2/u1":     {Member: true, Role: "member", MembershipVersion: 7, OrgSuspended: true},
+		"o3/u1":     {Member: true, Role: "member", MembershipVersion: 7, OrgDeleted: true},
+	}}
+
+	r := gin.New()
+	r.GET("/t", Auth(keyring, countingPlatform{l: l}), Tenant(countingOrgs{l: l}, nil), func(c *gin.Context) {
+		role, _ := Role(c)
+		c.String(http.StatusOK, role)
+	})
+
+	cases := []struct {
+		name, user, org string
+		version         int64
+		want            int
+		body            string
+	}{
+		{"current membership", "u1", "o1", 7, http.StatusOK, "admin"},
+		{"stale version", "u1", "o1", 6, http.StatusUnauthorized, "stale_token"},
+		{"membership removed", "u1", "o9", 7, http.StatusUnauthorized, "stale_token"},
+		{"suspended user", "banned", "o1", 7, http.StatusForbidden, "user_suspended"},
+		{"suspended org", "u1", "o2", 7, http.StatusForbidden, "org_suspended"},
+		{"deleted org", "u1", "o3", 7, http.StatusGone, "org_deleted"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tok, err := auth.NewOrgAccessToken(tc.user, tc.org, "admin", tc.version, keyring, 15)
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.n = 0
+			req := httptest.NewRequest(http.MethodGet, "/t", nil)
+			req.Header.Set("Authorization", "Bearer "+tok)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tc.want || !strings.Contains(w.Body.String(), tc.body) {
+				t.Errorf("got %d %s, want %d %s", w.Code, w.Body.String(), tc.want, tc.body)
+			}
+			if l.n != 1 {
+				t.Errorf("lookups = %d, want 1", l.n)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+//go:build integration
+
+package router_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ulpio/vergo/internal/http/router"
+	"github.com/Ulpio/vergo/internal/pkg/testutil"
+)
+
+// Routes open to anyone, or authenticated by something other than a user
+// token (SCIM bearer, Stripe signature).
+var publicRoutes = map[string]bool{
+	"GET /.well-known/jwks.json":           true,
+	"POST /v1/auth/signup":                 true,
+	"POST /v1/auth/login":                  true,
+	"POST /v1/auth/refresh":                true,
+	"POST /v1/auth/logout":                 true,
+	"POST /v1/auth/forgot-password":        true,
+	"POST /v1/auth/reset-password":         true,
+	"POST /v1/auth/verify-email":           true,
+	"GET /v1/auth/oidc/providers":          true,
+	"GET /v1/auth/oidc/:provider/start":    true,
+	"GET /v1/auth/oidc/:provider/callback": true,
+	"POST /v1/auth/sso/discover":           true,
+	"GET /v1/sso/saml/:orgId/metadata":     true,
+	"GET /v1/sso/saml/:orgId/login":        true,
+	"POST /v1/sso/saml/:orgId/acs":         true,
+	"POST /v1/billing/webhook":             true,
+}
+
+// Routes that need a user but no tenant.
+var authOnlyRoutes = map[string]bool{
+	"GET /v1/me":                 true,
+	"PATCH /v1/me":               true,
+	"DELETE /v1/me":              true,
+	"POST /v1/me/email":          true,
+	"POST /v1/me/export":         true,
+	"GET /v1/me/exports/:id":     true,
+	"POST /v1/me/password":       true,
+	"GET /v1/me/sessions":        true,
+	"DELETE /v1/me/sessions/:id": true,
+	"POST /v1/auth/logout-all":   true,
+	"POST /v1/auth/org-token":    true,
+	"GET /v1/context":            true,
+	"POST /v1/context":           true,
+	"GET /v1/orgs":               true,
+	"POST /v1/orgs":              true,
+	"POST /v1/orgs/:id/restore":  true,
+}
+
+var pathParam = regexp.MustCompile(`:[A-Za-z]+`)
+
+type client struct {
+	t *testing.T
+	e *gin.Engine
+}
+
+func (cl client) do(method, path, token, orgHeader string, body any) *httptest.ResponseRecorder {
+	cl.t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		_ = json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if orgHeader != "" {
+		req.Header.Set("X-Org-ID", orgHeader)
+	}
+	w := httptest.NewRecorder()
+	cl.e.ServeHTTP(w, req)
+	return w
+}
+
+func (cl client) json(w *httptest.ResponseRecorder, want int, out any) {
+	cl.t.Helper()
+	if w.Code != want {
+		cl.t.Fatalf("status = %d, want %d: %s", w.Code, want, w.Body.String())
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+		cl.t.Fatalf("decode %s: %v", w.Body.String(), err)
+	}
+}
+
+// signup returns an access token and an org the new user owns.
+func (cl client) signup(email string) (token, orgID string) {
+	cl.t.Helper()
+	var auth struct {
+		AccessToken string `json:"access_token"`
+	}
+	cl.json(cl.do(http.MethodPost, "/v1/auth/signup", "", "", gin.H{
+		"email": email, "password": "correct-horse-battery-staple-42",
+	}), http.StatusCreated, &auth)
+	var o struct {
+		ID string `json:"id"`
+	}
+	cl.json(cl.do(http.MethodPost, "/v1/orgs", auth.AccessToken, "", gin.H{"name": email}), http.StatusCreated, &o)
+	return auth.AccessToken, o.ID
+}
+
+func (cl client) orgToken(token, orgID string) string {
+	cl.t.Helper()
+	var out struct {
+		AccessToken string `json:"access_token"`
+	}
+	cl.json(cl.do(http.MethodPost, "/v1/auth/org-token", token, "", gin.H{"org_id": orgID}), http.StatusOK, &out)
+	return out.AccessToken
+}
+
+func errorCode(w *httptest.ResponseRecorder) string {
+	var body struct {
+		Error string `json:"error"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+	return body.Error
+}
+
+// TestRoutes_TenantIsolation walks every registered route and checks that
+// nobody reaches another org's data, whichever way the org is named.
+func TestRoutes_TenantIsolation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	testutil.PGEnv(t)
+	t.Setenv("JWT_KEYS_FILE", "")
+	t.Setenv("PLATFORM_ADMIN_EMAILS", "")
+
+	e := gin.New()
+	router.Register(e, e.Group("/v1"))
+	cl := client{t: t, e: e}
+
+	alice, orgA := cl.signup("alice@authz.test")
+	bob, orgB := cl.signup("bob@authz.test")
+	bobScoped := cl.orgToken(bob, orgB)
+
+	// the path names the tenant; no header needed
+	var got struct {
+		ID string `json:"id"`
+	}
+	cl.json(cl.do(http.MethodGet, "/v1/orgs/"+orgA, alice, "", nil), http.StatusOK, &got)
+	if got.ID != orgA {
+		t.Fatalf("GET /orgs/%s returned org %s", orgA, got.ID)
+	}
+
+	for _, r := range e.Routes() {
+		route := r.Method + " " + r.Path
+		t.Run(route, func(t *testing.T) {
+			cl := client{t: t, e: e}
+			path := strings.Replace(r.Path, "/orgs/:id", "/orgs/"+orgA, 1)
+			path = pathParam.ReplaceAllString(path, "x")
+
+			if publicRoutes[route] {
+				return
+			}
+			if w := cl.do(r.Method, path, "", "", nil); w.Code != http.StatusUnauthorized {
+				t.Errorf("no token: status = %d, want 401: %s", w.Code, w.Body.String())
+			}
+
+			switch {
+			case strings.HasPrefix(r.Path, "/v1/scim/"):
+				// SCIM takes only SCIM tokens
+				if w := cl.do(r.Method, path, bob, "", nil); w.Code != http.StatusUnauthorized {
+					t.Errorf("user token: status = %d, want 401", w.Code)
+				}
+			case strings.HasPrefix(r.Path, "/v1/admin/"):
+				if w := cl.do(r.Method, path, bob, orgA, nil); w.Code != http.StatusForbidden {
+					t.Errorf("non-admin: status = %d, want 403", w.Code)
+				}
+			case authOnlyRoutes[route]:
+				// no tenant; handlers scope to the caller
+			case strings.HasPrefix(r.Path, "/v1/orgs/:id"):
+				cases := []struct {
+					name, token, header, want string
+				}{
+					{"non-member", bob, "", "not_a_member"},
+					{"own org in header", bob, orgB, "org_mismatch"},
+					{"own org-scoped token", bobScoped, "", "org_mismatch"},
+					{"member, other org in header", alice, orgB, "org_mismatch"},
+				}
+				for _, tc := range cases {
+					w := cl.do(r.Method, path, tc.token, tc.header, nil)
+					if w.Code != http.StatusForbidden || errorCode(w) != tc.want {
+						t.Errorf("%s: status = %d %s, want 403 %s", tc.name, w.Code, w.Body.String(), tc.want)
+					}
+				}
+			default:
+				cases := []struct {
+					name, token, header, want string
+				}{
+					{"other org in header", bob, orgA, "not_a_member"},
+					{"org-scoped token, other org in header", bobScoped, orgA, "org_mismatch"},
+				}
+				for _, tc := range cases {
+					w := cl.do(r.Method, path, tc.token, tc.header, nil)
+					if w.Code != http.StatusForbidden || errorCode(w) != tc.want {
+						t.Errorf("%s: status = %d %s, want 403 %s", tc.name, w.Code, w.Body.String(), tc.want)
+					}
+				}
+			}
+		})
+	}
+
+	// restoring someone else's org does not reveal it exists
+	if w := cl.do(http.MethodPost, "/v1/orgs/"+orgA+"/restore", bob, "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("restore other org: status = %d, want 404", w.Code)
+	}
+}
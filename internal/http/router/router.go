@@ -1,30 +1,59 @@
 package router
 
 import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
 	"github.com/gin-gonic/gin"
 
 	"github.com/Ulpio/vergo/internal/auth"
+	"github.com/Ulpio/vergo/internal/auth/oidc"
+	"github.com/Ulpio/vergo/internal/auth/password"
 	"github.com/Ulpio/vergo/internal/domain/apikey"
 	"github.com/Ulpio/vergo/internal/domain/audit"
 	"github.com/Ulpio/vergo/internal/domain/billing"
-	"github.com/Ulpio/vergo/internal/domain/webhook"
+	"github.com/Ulpio/vergo/internal/domain/export"
 	"github.com/Ulpio/vergo/internal/domain/file"
+	"github.com/Ulpio/vergo/internal/domain/idempotency"
+	"github.com/Ulpio/vergo/internal/domain/identity"
 	"github.com/Ulpio/vergo/internal/domain/org"
+	"github.com/Ulpio/vergo/internal/domain/platform"
 	"github.com/Ulpio/vergo/internal/domain/project"
+	"github.com/Ulpio/vergo/internal/domain/scim"
+	"github.com/Ulpio/vergo/internal/domain/sso"
+	"github.com/Ulpio/vergo/internal/domain/team"
 	"github.com/Ulpio/vergo/internal/domain/user"
 	"github.com/Ulpio/vergo/internal/domain/userctx"
+	"github.com/Ulpio/vergo/internal/domain/webhook"
 	"github.com/Ulpio/vergo/internal/http/handlers"
 	"github.com/Ulpio/vergo/internal/http/middleware"
 	"github.com/Ulpio/vergo/internal/pkg/config"
 	"github.com/Ulpio/vergo/internal/pkg/db"
+	"github.com/Ulpio/vergo/internal/pkg/notify"
 	"github.com/Ulpio/vergo/internal/repo"
 	s3store "github.com/Ulpio/vergo/internal/storage/s3"
 )
 
-// Register registra todas as rotas v1.
-func Register(v1 *gin.RouterGroup) {
+// Register registra todas as rotas v1 e os endpoints públicos na raiz
+// (/.well-known).
+func Register(root gin.IRouter, v1 *gin.RouterGroup) {
 	cfg := config.Load()
 
+	// Chaves de assinatura dos access tokens (RS256/EdDSA)
+	keyring, err := loadKeyring(cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	// Política de senha (+ corpus de senhas vazadas, opcional)
+	pwPolicy, err := loadPasswordPolicy(cfg)
+	if err != nil {
+		panic(err)
+	}
+
 	// DB
 	sqlDB, err := db.Open(cfg)
 	if err != nil {
@@ -35,26 +64,73 @@ func Register(v1 *gin.RouterGroup) {
 	queries := repo.New(sqlDB)
 
 	// Services
-	userSvc := user.NewPostgresService(sqlDB, queries)
+	hasher, err := loadHasher(cfg)
+	if err != nil {
+		panic(err)
+	}
+	userSvc := user.NewPostgresService(sqlDB, queries, hasher)
 	orgSvc := org.NewPostgresService(sqlDB, queries)
 	projSvc := project.NewPostgresService(sqlDB, queries)
+	teamSvc := team.NewPostgresService(queries)
 	auditSvc := audit.NewPostgresService(sqlDB, queries)
 	rfStore := auth.NewRefreshStore(sqlDB, queries)
 	resetStore := auth.NewResetStore(queries)
+	emailStore := auth.NewEmailChangeStore(queries)
+	lockout := auth.NewLockoutTracker(queries, auth.LockoutPolicy{
+		AccountMaxFailures: cfg.LockoutMaxFailures,
+		IPMaxFailures:      cfg.LockoutIPMaxFailures,
+		CaptchaAfter:       cfg.LockoutCaptchaAfter,
+		BaseLockout:        time.Duration(cfg.LockoutBaseSeconds) * time.Second,
+		MaxLockout:         time.Duration(cfg.LockoutMaxMinutes) * time.Minute,
+		Window:             time.Duration(cfg.LockoutWindowMinutes) * time.Minute,
+	})
 	ctxSvc := userctx.NewPostgresService(sqlDB, queries)
 	fileSvc := file.NewPostgresService(sqlDB, queries)
 	keySvc := apikey.NewService(queries)
 	whSvc := webhook.NewService(queries)
 	billSvc := billing.NewService(queries, cfg.StripeSecretKey)
+	idSvc := identity.NewPostgresService(sqlDB, queries)
+	ssoSvc := sso.NewPostgresService(sqlDB, queries, cfg.PublicURL, nil)
+	scimSvc := scim.NewPostgresService(sqlDB, queries, cfg.PublicURL)
+	platformSvc := platform.NewPostgresService(sqlDB, queries)
+	idemSvc := idempotency.NewPostgresService(queries, time.Duration(cfg.IdempotencyTTLHours)*time.Hour)
+
+	// Admins da plataforma via PLATFORM_ADMIN_EMAILS (usuários já existentes)
+	for _, email := range cfg.PlatformAdminEmails {
+		granted, err := platformSvc.GrantByEmail(email)
+		switch {
+		case err != nil:
+			slog.Error("platform admin grant failed", "email", email, "error", err)
+		case granted:
+			slog.Info("platform admin granted", "email", email)
+		}
+	}
+
+	// Alertas de segurança ao usuário (opcional)
+	var notifier notify.Notifier
+	if cfg.NotifyOnRefreshReuse {
+		notifier = notify.NewLogNotifier()
+	}
+	// E-mails de conta (verificação de e-mail, avisos de alteração)
+	mailer := notify.NewLogNotifier()
 
 	// Handler
-	authH := handlers.NewAuthHandler(cfg, userSvc, rfStore, resetStore)
-	orgH := handlers.NewOrgsHandler(orgSvc, auditSvc)
-	projH := handlers.NewProjectsHandler(projSvc, auditSvc)
-	meH := handlers.NewMeHandler(userSvc, orgSvc)
+	authH := handlers.NewAuthHandler(cfg, keyring, userSvc, rfStore, resetStore, emailStore, ssoSvc, orgSvc, auditSvc, lockout, pwPolicy, notifier, mailer)
+	oidcH := handlers.NewOIDCHandler(authH, oidc.NewRegistry(cfg.OIDCProviders), oidc.NewStateStore(queries), idSvc)
+	ssoH := handlers.NewSSOHandler(authH, ssoSvc, auditSvc)
+	scimH := handlers.NewSCIMHandler(scimSvc, auditSvc)
+	jwksH := handlers.NewJWKSHandler(keyring)
+	restoreWindow := time.Duration(cfg.DeletionRestoreDays) * 24 * time.Hour
+	orgH := handlers.NewOrgsHandler(orgSvc, auditSvc, restoreWindow)
+	projH := handlers.NewProjectsHandler(projSvc, orgSvc, auditSvc, restoreWindow)
+	teamH := handlers.NewTeamsHandler(teamSvc, auditSvc)
+	sessH := handlers.NewSessionsHandler(rfStore, orgSvc, auditSvc)
+	impH := handlers.NewImpersonationHandler(cfg, keyring, userSvc, platformSvc, auditSvc)
+	adminH := handlers.NewAdminHandler(platformSvc, orgSvc, billSvc, auditSvc)
 	auditH := handlers.NewAuditHandler(auditSvc)
 	ctxH := handlers.NewContextHandler(ctxSvc, orgSvc)
-	keyH := handlers.NewAPIKeysHandler(keySvc, auditSvc)
+	keyH := handlers.NewAPIKeysHandler(keySvc, orgSvc, auditSvc)
+	roleH := handlers.NewRolesHandler(orgSvc, auditSvc)
 	whH := handlers.NewWebhooksHandler(whSvc)
 	billH := handlers.NewBillingHandler(billSvc, cfg.StripeWebhookSecret)
 
@@ -62,9 +138,14 @@ func Register(v1 *gin.RouterGroup) {
 	if err != nil {
 		panic(err)
 	}
-	storH := handlers.NewStorageHandler(s3c, fileSvc)
+	storH := handlers.NewStorageHandler(s3c, fileSvc, projSvc, orgSvc)
+	exportSvc := export.NewService(queries, s3c)
+	exportH := handlers.NewExportsHandler(exportSvc, auditSvc)
+	meH := handlers.NewMeHandler(userSvc, orgSvc, ctxSvc, auditSvc, exportSvc)
 
 	// ── Público (sem token) ───────────────────────────────────────────
+	root.GET("/.well-known/jwks.json", jwksH.Get)
+
 	auth := v1.Group("/auth")
 	{
 		auth.POST("/signup", authH.Signup)
@@ -73,6 +154,44 @@ func Register(v1 *gin.RouterGroup) {
 		auth.POST("/logout", authH.Logout) // revoga um refresh específico
 		auth.POST("/forgot-password", authH.ForgotPassword)
 		auth.POST("/reset-password", authH.ResetPassword)
+		auth.POST("/verify-email", authH.VerifyEmail) // confirma troca de e-mail
+
+		// Login social / enterprise (OIDC + PKCE)
+		auth.GET("/oidc/providers", oidcH.Providers)
+		auth.GET("/oidc/:provider/start", oidcH.Start)
+		auth.GET("/oidc/:provider/callback", oidcH.Callback)
+
+		// SSO discovery (domínio reivindicado por uma org)
+		auth.POST("/sso/discover", ssoH.Discover)
+	}
+
+	// SAML 2.0 SP endpoints por org (públicos — o IdP posta no ACS)
+	saml := v1.Group("/sso/saml/:orgId")
+	{
+		saml.GET("/metadata", ssoH.Metadata)
+		saml.GET("/login", ssoH.Login)
+		saml.POST("/acs", ssoH.ACS)
+	}
+
+	// SCIM 2.0 (provisionamento pelo IdP; token SCIM por org, sem X-Org-ID)
+	scimG := v1.Group("/scim/v2", middleware.SCIMAuth(scimSvc))
+	{
+		scimG.GET("/ServiceProviderConfig", scimH.ServiceProviderConfig)
+		scimG.GET("/ResourceTypes", scimH.ResourceTypes)
+
+		scimG.GET("/Users", scimH.ListUsers)
+		scimG.POST("/Users", scimH.CreateUser)
+		scimG.GET("/Users/:id", scimH.GetUser)
+		scimG.PUT("/Users/:id", scimH.ReplaceUser)
+		scimG.PATCH("/Users/:id", scimH.PatchUser)
+		scimG.DELETE("/Users/:id", scimH.DeleteUser)
+
+		scimG.GET("/Groups", scimH.ListGroups)
+		scimG.POST("/Groups", scimH.CreateGroup)
+		scimG.GET("/Groups/:id", scimH.GetGroup)
+		scimG.PUT("/Groups/:id", scimH.ReplaceGroup)
+		scimG.PATCH("/Groups/:id", scimH.PatchGroup)
+		scimG.DELETE("/Groups/:id", scimH.DeleteGroup)
 	}
 
 	// Stripe webhook (público, sem auth — verifica assinatura Stripe)
@@ -80,63 +199,133 @@ func Register(v1 *gin.RouterGroup) {
 
 	// ── Apenas autenticado (NÃO exige X-Org-ID) ───────────────────────
 	authOnly := v1.Group("/")
-	authOnly.Use(middleware.Auth(cfg))
+	authOnly.Use(middleware.Auth(keyring, platformSvc))
 	{
 		authOnly.GET("/me", meH.Get)
+		authOnly.PATCH("/me", meH.Update)
+		// rotas de segurança da conta: nunca durante impersonação
+		authOnly.DELETE("/me", middleware.NoImpersonation(), meH.Delete) // exclui a conta (bloqueado se único owner de org)
+		authOnly.POST("/me/email", middleware.NoImpersonation(), authH.RequestEmailChange)
+		// exportação de dados (LGPD/GDPR), gerada em background
+		authOnly.POST("/me/export", middleware.NoImpersonation(), exportH.RequestUser)
+		authOnly.GET("/me/exports/:id", middleware.NoImpersonation(), exportH.Get)
+		authOnly.POST("/me/password", middleware.NoImpersonation(), authH.ChangePassword)
+		// sessões (devices) do usuário logado
+		authOnly.GET("/me/sessions", sessH.List)
+		authOnly.DELETE("/me/sessions/:id", middleware.NoImpersonation(), sessH.Revoke)
 		// logout de todos os devices do usuário logado
-		authOnly.POST("/auth/logout-all", authH.LogoutAll)
+		authOnly.POST("/auth/logout-all", middleware.NoImpersonation(), authH.LogoutAll)
+		// access token restrito a uma org (org_id/role/versão no token)
+		authOnly.POST("/auth/org-token", middleware.NoImpersonation(), authH.OrgToken)
 		authOnly.GET("/context", ctxH.Get)
 		authOnly.POST("/context", ctxH.Set)
 
 		orgs := authOnly.Group("/orgs")
 		{
+			orgs.GET("", orgH.List)    // orgs do usuário (switcher)
 			orgs.POST("", orgH.Create) // criar org não exige tenant
-			orgs.GET("/:id", orgH.Get)
+			// restaurar org excluída: somente owner (a org excluída não passa no Tenant)
+			orgs.POST("/:id/restore", middleware.NoImpersonation(), orgH.Restore)
 		}
 	}
 
+	// ── Admin da plataforma (sem tenant) ──────────────────────────────
+	admin := v1.Group("/admin", middleware.Auth(keyring, platformSvc), middleware.RequirePlatformAdmin(platformSvc))
+	{
+		// "login as": access token curto com claim act
+		admin.POST("/impersonate", impH.Start)
+
+		// orgs: busca, assinatura + uso, suspensão e plano forçado
+		admin.GET("/orgs", adminH.ListOrgs)
+		admin.GET("/orgs/:id", adminH.GetOrg)
+		admin.POST("/orgs/:id/suspend", adminH.SuspendOrg)
+		admin.POST("/orgs/:id/unsuspend", adminH.UnsuspendOrg)
+		admin.PUT("/orgs/:id/plan", adminH.SetPlan)
+		admin.DELETE("/orgs/:id/plan", adminH.ClearPlan)
+
+		// usuários: busca e suspensão (encerra todas as sessões)
+		admin.GET("/users", adminH.ListUsers)
+		admin.POST("/users/:id/suspend", adminH.SuspendUser)
+		admin.POST("/users/:id/unsuspend", adminH.UnsuspendUser)
+
+		// backlog global de webhooks
+		admin.GET("/webhooks/backlog", adminH.WebhookBacklog)
+	}
+
 	// ── Autenticado + Tenant (exige X-Org-ID e membership) ────────────
 	protected := v1.Group("/")
-	protected.Use(middleware.AuthWithAPIKeys(cfg, keySvc), middleware.Tenant(orgSvc, ctxSvc))
+	// Preconditions lê If-Match para PATCH/DELETE condicionais (ETag = versão)
+	protected.Use(middleware.AuthWithAPIKeys(keyring, keySvc, platformSvc), middleware.Tenant(orgSvc, ctxSvc), middleware.Preconditions())
 	{
-		// Orgs (rotas sensíveis com RBAC)
-		orgs := protected.Group("/orgs")
+		// Projects (filtrados por grant: viewer lê, editor altera, admin
+		// arquiva, exclui e concede; projects.access_all vê todos)
+		projects := protected.Group("/projects", middleware.RequireRole("member"))
 		{
-			// gestão de membros: admin ou owner
-			orgs.POST("/:id/members", middleware.RequireRole("admin"), orgH.AddMember)
-			orgs.PATCH("/:id/members/:userId", middleware.RequireRole("admin"), orgH.UpdateMember)
-			orgs.DELETE("/:id/members/:userId", middleware.RequireRole("admin"), orgH.RemoveMember)
+			projects.GET("", projH.List)
+			projects.POST("", middleware.RequirePermission(orgSvc, org.PermProjectsWrite), middleware.Idempotency(idemSvc), projH.Create)
+			projects.GET("/:id", projH.Get)
+			projects.PATCH("/:id", middleware.RequirePermission(orgSvc, org.PermProjectsWrite), projH.Update)
+			projects.DELETE("/:id", middleware.RequirePermission(orgSvc, org.PermProjectsDelete), projH.Delete)
+			projects.POST("/:id/restore", middleware.RequirePermission(orgSvc, org.PermProjectsDelete), projH.Restore)
+			projects.POST("/:id/archive", middleware.RequirePermission(orgSvc, org.PermProjectsWrite), projH.Archive)
+			projects.POST("/:id/unarchive", middleware.RequirePermission(orgSvc, org.PermProjectsWrite), projH.Unarchive)
 
-			// excluir org: somente owner
-			orgs.DELETE("/:id", middleware.RequireRole("owner"), orgH.Delete)
+			projects.GET("/:id/grants", projH.ListGrants)
+			projects.POST("/:id/grants", projH.SetGrant)
+			projects.DELETE("/:id/grants/:grantId", projH.RemoveGrant)
 		}
 
-		// Projects (qualquer member+ pode criar/editar)
-		projects := protected.Group("/projects", middleware.RequireRole("member"))
+		// Campos customizados dos projects (qualquer member lê; definir
+		// exige org.manage)
+		fields := protected.Group("/project-fields", middleware.RequireRole("member"))
 		{
-			projects.GET("", projH.List)
-			projects.POST("", projH.Create)
-			projects.GET("/:id", projH.Get)
-			projects.PATCH("/:id", projH.Update)
-			projects.DELETE("/:id", projH.Delete)
+			fields.GET("", projH.ListFields)
+			fields.POST("", middleware.RequirePermission(orgSvc, org.PermOrgManage), projH.CreateField)
+			fields.PATCH("/:fieldId", middleware.RequirePermission(orgSvc, org.PermOrgManage), projH.UpdateField)
+			fields.DELETE("/:fieldId", middleware.RequirePermission(orgSvc, org.PermOrgManage), projH.DeleteField)
+		}
+
+		// Teams (qualquer member lê; gerir exige teams.manage)
+		teams := protected.Group("/teams", middleware.RequireRole("member"))
+		{
+			teams.GET("", teamH.List)
+			teams.POST("", middleware.RequirePermission(orgSvc, org.PermTeamsManage), teamH.Create)
+			teams.GET("/:id", teamH.Get)
+			teams.DELETE("/:id", middleware.RequirePermission(orgSvc, org.PermTeamsManage), teamH.Delete)
+			teams.GET("/:id/members", teamH.ListMembers)
+			teams.POST("/:id/members", middleware.RequirePermission(orgSvc, org.PermTeamsManage), teamH.AddMember)
+			teams.DELETE("/:id/members/:userId", middleware.RequirePermission(orgSvc, org.PermTeamsManage), teamH.RemoveMember)
+		}
+
+		// Roles: presets + roles customizados da org
+		roles := protected.Group("/roles", middleware.RequireRole("member"))
+		{
+			roles.GET("", roleH.List)
+			roles.GET("/permissions", roleH.Permissions)
+			roles.GET("/:name", roleH.Get)
+			roles.POST("", middleware.RequirePermission(orgSvc, org.PermRolesManage), roleH.Create)
+			roles.PUT("/:name", middleware.RequirePermission(orgSvc, org.PermRolesManage), roleH.Update)
+			roles.DELETE("/:name", middleware.RequirePermission(orgSvc, org.PermRolesManage), roleH.Delete)
 		}
 
 		// Auditoria
-		protected.GET("/audit", middleware.RequireRole("admin"), auditH.List)
+		protected.GET("/audit", middleware.RequirePermission(orgSvc, org.PermAuditRead), auditH.List)
 
 		// API Keys
-		keys := protected.Group("/api-keys")
+		keys := protected.Group("/api-keys", middleware.RequirePermission(orgSvc, org.PermAPIKeysManage))
 		{
 			keys.POST("", keyH.Create)
 			keys.GET("", keyH.List)
+			keys.PATCH("/:id", keyH.SetRole)
 			keys.DELETE("/:id", keyH.Revoke)
 		}
 
 		// Webhooks
-		wh := protected.Group("/webhooks")
+		wh := protected.Group("/webhooks", middleware.RequirePermission(orgSvc, org.PermWebhooksManage))
 		{
 			wh.POST("/endpoints", whH.CreateEndpoint)
 			wh.GET("/endpoints", whH.ListEndpoints)
+			wh.GET("/endpoints/:id", whH.GetEndpoint)
 			wh.PATCH("/endpoints/:id", whH.UpdateEndpoint)
 			wh.POST("/test", whH.Test)
 		}
@@ -144,7 +333,7 @@ func Register(v1 *gin.RouterGroup) {
 		// Billing (webhook is registered as public above)
 		billingG := protected.Group("/billing")
 		{
-			billingG.POST("/checkout-session", billH.CreateCheckoutSession)
+			billingG.POST("/checkout-session", middleware.RequirePermission(orgSvc, org.PermBillingManage), middleware.Idempotency(idemSvc), billH.CreateCheckoutSession)
 			billingG.GET("/subscription", billH.GetSubscription)
 			billingG.GET("/usage", billH.GetUsage)
 		}
@@ -156,10 +345,110 @@ func Register(v1 *gin.RouterGroup) {
 			storage.POST("/presign-download", storH.PresignGet) // GET download
 
 			storage.GET("/files", storH.ListFiles)
-			storage.POST("/files", storH.CreateFile) // registra metadados após upload
+			storage.POST("/files", middleware.Idempotency(idemSvc), storH.CreateFile) // registra metadados após upload
 			storage.GET("/files/:id", storH.GetFile)
 			storage.DELETE("/files/:id", storH.DeleteFile)
 		}
 	}
 
+	// ── Org pelo path (/orgs/:id): o tenant é o :id; X-Org-ID, token
+	// org-scoped ou API key precisam coincidir com ele ────────────────
+	orgs := v1.Group("/orgs/:id")
+	orgs.Use(middleware.AuthWithAPIKeys(keyring, keySvc, platformSvc), middleware.PathOrg("id"), middleware.Tenant(orgSvc, ctxSvc), middleware.Preconditions())
+	{
+		orgs.GET("", middleware.RequireRole("member"), orgH.Get)
+
+		// membros: qualquer member lista; convidar e gerir exigem permissão
+		orgs.GET("/members", middleware.RequireRole("member"), orgH.ListMembers)
+		orgs.POST("/members", middleware.RequirePermission(orgSvc, org.PermMembersInvite), orgH.AddMember)
+		orgs.PATCH("/members/:userId", middleware.RequirePermission(orgSvc, org.PermMembersManage), orgH.UpdateMember)
+		orgs.DELETE("/members/:userId", middleware.RequirePermission(orgSvc, org.PermMembersManage), orgH.RemoveMember)
+		orgs.POST("/members/:userId/logout", middleware.RequirePermission(orgSvc, org.PermMembersManage), sessH.ForceLogoutMember)
+
+		// SSO: domínios + SAML (sso.manage; configurar IdP exige plano enterprise)
+		orgs.GET("/domains", middleware.RequirePermission(orgSvc, org.PermSSOManage), ssoH.ListDomains)
+		orgs.POST("/domains", middleware.RequirePermission(orgSvc, org.PermSSOManage), ssoH.ClaimDomain)
+		orgs.POST("/domains/:domain/verify", middleware.RequirePermission(orgSvc, org.PermSSOManage), ssoH.VerifyDomain)
+		orgs.DELETE("/domains/:domain", middleware.RequirePermission(orgSvc, org.PermSSOManage), ssoH.DeleteDomain)
+		orgs.GET("/sso/saml", middleware.RequirePermission(orgSvc, org.PermSSOManage), ssoH.GetConfig)
+		orgs.PUT("/sso/saml", middleware.RequirePermission(orgSvc, org.PermSSOManage), middleware.RequirePlan(billSvc, "enterprise"), ssoH.PutConfig)
+
+		// SCIM: tokens do IdP (sso.manage; emitir exige plano enterprise)
+		orgs.GET("/scim/tokens", middleware.RequirePermission(orgSvc, org.PermSSOManage), scimH.ListTokens)
+		orgs.POST("/scim/tokens", middleware.RequirePermission(orgSvc, org.PermSSOManage), middleware.RequirePlan(billSvc, "enterprise"), scimH.CreateToken)
+		orgs.DELETE("/scim/tokens/:tokenId", middleware.RequirePermission(orgSvc, org.PermSSOManage), scimH.RevokeToken)
+
+		// exportar dados da org: somente owner
+		orgs.POST("/export", middleware.RequireRole("owner"), middleware.NoImpersonation(), exportH.RequestOrg)
+		// permitir/bloquear impersonação pelo suporte: somente owner
+		orgs.PUT("/impersonation", middleware.RequireRole("owner"), middleware.NoImpersonation(), orgH.SetImpersonation)
+		// transferência de propriedade: owner propõe, o novo owner aceita
+		orgs.POST("/transfer-ownership", middleware.RequireRole("owner"), middleware.NoImpersonation(), orgH.StartTransfer)
+		orgs.GET("/transfer-ownership", middleware.RequireRole("member"), orgH.GetTransfer)
+		orgs.POST("/transfer-ownership/accept", middleware.RequireRole("member"), middleware.NoImpersonation(), orgH.AcceptTransfer)
+		orgs.DELETE("/transfer-ownership", middleware.RequireRole("member"), middleware.NoImpersonation(), orgH.CancelTransfer)
+
+		// nome, slug, settings e branding
+		orgs.PATCH("", middleware.RequirePermission(orgSvc, org.PermOrgManage), orgH.Update)
+		// excluir org (soft delete, restaurável até o purge): somente owner
+		orgs.DELETE("", middleware.RequireRole("owner"), middleware.NoImpersonation(), orgH.Delete)
+	}
+}
+
+// loadKeyring lê o keyring de JWT_KEYS_FILE. Sem arquivo, gera uma chave
+// efêmera (apenas fora de produção).
+// iss/aud vêm de JWT_ISSUER e JWT_AUDIENCE.
+func loadKeyring(cfg config.Config) (*auth.Keyring, error) {
+	var (
+		kr  *auth.Keyring
+		err error
+	)
+	switch {
+	case cfg.JWTKeysFile != "":
+		kr, err = auth.LoadKeyring(cfg.JWTKeysFile)
+	case cfg.AppEnv == "production":
+		return nil, errors.New("JWT_KEYS_FILE is required in production")
+	default:
+		slog.Warn("JWT_KEYS_FILE not set; using an ephemeral signing key")
+		kr, err = auth.GenerateKeyring()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return kr.WithIssuer(cfg.JWTIssuer, cfg.JWTAudience...), nil
+}
+
+// loadPasswordPolicy monta a política de senha; o corpus de senhas vazadas
+// é carregado de PASSWORD_BREACH_FILE, se definido.
+// loadHasher builds the argon2id hasher from PASSWORD_ARGON2_*, refusing
+// values that would wrap when narrowed.
+func loadHasher(cfg config.Config) (password.Hasher, error) {
+	if cfg.Argon2Parallelism < 1 || cfg.Argon2Parallelism > math.MaxUint8 {
+		return nil, fmt.Errorf("%w: PASSWORD_ARGON2_PARALLELISM must be 1-255", password.ErrInvalidParams)
+	}
+	if cfg.Argon2Iterations < 1 || int64(cfg.Argon2Iterations) > math.MaxUint32 ||
+		cfg.Argon2MemoryKB < 1 || int64(cfg.Argon2MemoryKB) > math.MaxUint32 {
+		return nil, fmt.Errorf("%w: PASSWORD_ARGON2_ITERATIONS and _MEMORY_KB must be positive 32-bit values", password.ErrInvalidParams)
+	}
+	return password.NewHasher(password.Argon2Params{
+		Memory:      uint32(cfg.Argon2MemoryKB),
+		Iterations:  uint32(cfg.Argon2Iterations),
+		Parallelism: uint8(cfg.Argon2Parallelism),
+		SaltLen:     password.DefaultArgon2Params.SaltLen,
+		KeyLen:      password.DefaultArgon2Params.KeyLen,
+	})
+}
+
+func loadPasswordPolicy(cfg config.Config) (password.Policy, error) {
+	p := password.Policy{MinLength: cfg.PasswordMinLength, MinScore: cfg.PasswordMinScore}
+	if cfg.PasswordBreachFile == "" {
+		return p, nil
+	}
+	corpus, err := password.LoadCorpus(cfg.PasswordBreachFile)
+	if err != nil {
+		return p, err
+	}
+	slog.Info("breached password corpus loaded", "hashes", corpus.Len())
+	p.Breached = corpus
+	return p, nil
 }
@@ -0,0 +1,141 @@
+//go:build integration
+
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ulpio/vergo/internal/http/router"
+	"github.com/Ulpio/vergo/internal/pkg/precondition"
+	"github.com/Ulpio/vergo/internal/pkg/testutil"
+)
+
+// TestStorage_PresignAccess checks presigned URLs follow the org prefix and
+// the project grants of the objects they point at.
+func TestStorage_PresignAccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	testutil.PGEnv(t)
+	t.Setenv("JWT_KEYS_FILE", "")
+	t.Setenv("PLATFORM_ADMIN_EMAILS", "")
+	// presigning is local; nothing talks to this endpoint
+	t.Setenv("S3_BUCKET", "uploads")
+	t.Setenv("S3_ENDPOINT", "http://127.0.0.1:9")
+	t.Setenv("S3_FORCE_PATH_STYLE", "true")
+	t.Setenv("S3_ACCESS_KEY_ID", "test")
+	t.Setenv("S3_SECRET_ACCESS_KEY", "test")
+
+	e := gin.New()
+	router.Register(e, e.Group("/v1"))
+	cl := client{t: t, e: e}
+
+	alice, orgA := cl.signup("alice@storage.test")
+	bob, orgB := cl.signup("bob@storage.test")
+	var me struct {
+		ID string `json:"id"`
+	}
+	cl.json(cl.do(http.MethodGet, "/v1/me", bob, "", nil), http.StatusOK, &me)
+
+	if w := cl.do(http.MethodPost, "/v1/orgs/"+orgA+"/members", alice, "", gin.H{"user_id": me.ID, "role": "member"}); w.Code != http.StatusNoContent {
+		t.Fatalf("add member: status = %d: %s", w.Code, w.Body.String())
+	}
+	var p struct {
+		ID string `json:"id"`
+	}
+	cl.json(cl.do(http.MethodPost, "/v1/projects", alice, orgA, gin.H{"name": "Specs"}), http.StatusCreated, &p)
+	if w := cl.do(http.MethodPost, "/v1/projects/"+p.ID+"/grants", alice, orgA, gin.H{"user_id": me.ID, "access": "viewer"}); w.Code >= 300 {
+		t.Fatalf("grant: status = %d: %s", w.Code, w.Body.String())
+	}
+	specKey := "org/" + orgA + "/projects/" + p.ID + "/spec.pdf"
+	if w := cl.do(http.MethodPost, "/v1/storage/files", alice, orgA, gin.H{"project_id": p.ID, "key": specKey}); w.Code != http.StatusCreated {
+		t.Fatalf("register file: status = %d: %s", w.Code, w.Body.String())
+	}
+
+	cases := []struct {
+		name, path, token, key string
+		status                 int
+		errCode                string
+	}{
+		{"viewer downloads project file", "/v1/storage/presign-download", bob, specKey, http.StatusOK, ""},
+		{"viewer overwrites project file", "/v1/storage/presign", bob, specKey, http.StatusForbidden, "insufficient_project_access"},
+		{"editor overwrites project file", "/v1/storage/presign", alice, specKey, http.StatusOK, ""},
+		{"upload to own folder", "/v1/storage/presign", bob, "avatar.png", http.StatusOK, ""},
+		{"download other org's object", "/v1/storage/presign-download", bob, "org/" + orgB + "/secret.pdf", http.StatusForbidden, "key_outside_org"},
+		{"download unprefixed key", "/v1/storage/presign-download", bob, "secret.pdf", http.StatusForbidden, "key_outside_org"},
+		{"download with traversal", "/v1/storage/presign-download", bob, "org/" + orgA + "/../" + orgB + "/secret.pdf", http.StatusForbidden, "key_outside_org"},
+		{"upload to other org", "/v1/storage/presign", bob, "org/" + orgB + "/users/x/evil.png", http.StatusForbidden, "key_outside_org"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := cl.do(http.MethodPost, tc.path, tc.token, orgA, gin.H{"key": tc.key, "content_type": "application/pdf"})
+			if w.Code != tc.status || errorCode(w) != tc.errCode {
+				t.Fatalf("status = %d %s, want %d %q", w.Code, w.Body.String(), tc.status, tc.errCode)
+			}
+		})
+	}
+
+	// unprefixed uploads land where CreateFile will register them
+	var put struct {
+		Key string `json:"key"`
+	}
+	cl.json(cl.do(http.MethodPost, "/v1/storage/presign", bob, orgA, gin.H{"key": "avatar.png", "content_type": "image/png"}), http.StatusOK, &put)
+	if want := "org/" + orgA + "/users/" + me.ID + "/avatar.png"; put.Key != want {
+		t.Errorf("key = %q, want %q", put.Key, want)
+	}
+}
+
+// TestStorage_DeleteFileIfMatch checks a stale If-Match leaves the file, and
+// its object, alone.
+func TestStorage_DeleteFileIfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	testutil.PGEnv(t)
+	t.Setenv("JWT_KEYS_FILE", "")
+	t.Setenv("PLATFORM_ADMIN_EMAILS", "")
+	// unreachable: the object delete is best-effort and only logs
+	t.Setenv("S3_BUCKET", "uploads")
+	t.Setenv("S3_ENDPOINT", "http://127.0.0.1:9")
+	t.Setenv("S3_FORCE_PATH_STYLE", "true")
+	t.Setenv("S3_ACCESS_KEY_ID", "test")
+	t.Setenv("S3_SECRET_ACCESS_KEY", "test")
+
+	e := gin.New()
+	router.Register(e, e.Group("/v1"))
+	cl := client{t: t, e: e}
+
+	alice, orgA := cl.signup("alice@delete.test")
+	var f struct {
+		ID      string `json:"id"`
+		Bucket  string `json:"bucket"`
+		Version int64  `json:"version"`
+	}
+	// the bucket is the server's, whatever the client asks for
+	cl.json(cl.do(http.MethodPost, "/v1/storage/files", alice, orgA, gin.H{"key": "report.pdf", "bucket": "other"}), http.StatusCreated, &f)
+	if f.Bucket != "uploads" {
+		t.Errorf("bucket = %q, want uploads", f.Bucket)
+	}
+
+	del := func(etag string) int {
+		req := httptest.NewRequest(http.MethodDelete, "/v1/storage/files/"+f.ID, nil)
+		req.Header.Set("Authorization", "Bearer "+alice)
+		req.Header.Set("X-Org-ID", orgA)
+		req.Header.Set("If-Match", etag)
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := del(precondition.ETag(f.Version + 1)); code != http.StatusPreconditionFailed {
+		t.Fatalf("stale If-Match: status = %d, want 412", code)
+	}
+	if w := cl.do(http.MethodGet, "/v1/storage/files/"+f.ID, alice, orgA, nil); w.Code != http.StatusOK {
+		t.Fatalf("file after failed delete: status = %d, want 200", w.Code)
+	}
+	if code := del(precondition.ETag(f.Version)); code != http.StatusNoContent {
+		t.Fatalf("current If-Match: status = %d, want 204", code)
+	}
+	if w := cl.do(http.MethodGet, "/v1/storage/files/"+f.ID, alice, orgA, nil); w.Code != http.StatusNotFound {
+		t.Errorf("file after delete: status = %d, want 404", w.Code)
+	}
+}
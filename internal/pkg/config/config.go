@@ -11,12 +11,48 @@ type Config struct {
 	AppPort    int
 	AppEnv     string
 	AppVersion string
+	PublicURL  string // externally reachable base URL (SAML entity IDs, redirects)
+
+	// Web app base URL for links in account mail; its pages POST the
+	// token to the API (default: APP_PUBLIC_URL)
+	FrontendURL string
 
 	// JWT
 	JWTAccessTTLMinutes int
 	JWTRefreshTTLDays   int
-	JWTAccessSecret     string
+	JWTKeysFile         string // JSON keyring for access tokens (RS256/EdDSA); empty = ephemeral dev key
 	JWTRefreshSecret    string
+	JWTIssuer           string   // iss claim of access tokens (default: APP_PUBLIC_URL)
+	JWTAudience         []string // aud claim of access tokens; Parse accepts any of them
+
+	// Alert users when a rotated refresh token is reused (family revoked)
+	NotifyOnRefreshReuse bool
+
+	// Request header set by the edge proxy with the client's approximate
+	// location (e.g. CF-IPCountry); shown in the session list. Empty = off.
+	GeoHeader string
+
+	// Password policy (signup, reset, change)
+	PasswordMinLength  int
+	PasswordMinScore   int    // 0..4 strength score
+	PasswordBreachFile string // SHA-1 hash corpus (HIBP format); empty = no breach check
+
+	// Password hashing (argon2id)
+	Argon2MemoryKB    int
+	Argon2Iterations  int
+	Argon2Parallelism int
+
+	// Platform admins (support / operators)
+	PlatformAdminEmails     []string // granted platform admin at startup
+	ImpersonationTTLMinutes int      // lifetime of impersonation access tokens
+
+	// Failed-attempt lockout (login, forgot-password)
+	LockoutMaxFailures   int // per account before the first lockout
+	LockoutIPMaxFailures int // per client IP before the first lockout
+	LockoutCaptchaAfter  int // account failures before signalling captcha_required (0 = never)
+	LockoutBaseSeconds   int // first lockout; doubles per further failure
+	LockoutMaxMinutes    int // lockout cap
+	LockoutWindowMinutes int // failures are forgotten after this long
 
 	// Database (Postgres)
 	DBHost string
@@ -39,6 +75,14 @@ type Config struct {
 	StorageAllowedTypes []string
 	StorageMaxMB        int
 
+	// Deleted orgs and projects can be restored for this long before the
+	// purge worker removes them, S3 objects included
+	DeletionRestoreDays int
+
+	// Responses to POSTs sent with an Idempotency-Key are replayed for
+	// this long
+	IdempotencyTTLHours int
+
 	// Rate limiting
 	RateLimitRPS   int // requests per second per key
 	RateLimitBurst int // max burst size
@@ -51,6 +95,21 @@ type Config struct {
 	// Stripe
 	StripeSecretKey    string
 	StripeWebhookSecret string
+
+	// OIDC / social login
+	OIDCProviders []OIDCProvider
+}
+
+// OIDCProvider configures an external identity provider. Providers are
+// enabled via OIDC_PROVIDERS (CSV) and read from OIDC_<NAME>_* variables.
+type OIDCProvider struct {
+	Name         string
+	Type         string // "oidc" (default) | "github"
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
 }
 
 func getenv(key, def string) string {
@@ -96,12 +155,42 @@ func Load() Config {
 		AppPort:    getint("APP_PORT", 8080),
 		AppEnv:     getenv("APP_ENV", "dev"),
 		AppVersion: getenv("APP_VERSION", "0.1.0"),
+		PublicURL:  getenv("APP_PUBLIC_URL", "http://localhost:8080"),
+
+		FrontendURL: getenv("APP_FRONTEND_URL", getenv("APP_PUBLIC_URL", "http://localhost:8080")),
 
 		// JWT
 		JWTAccessTTLMinutes: getint("JWT_ACCESS_TTL_MINUTES", 15),
 		JWTRefreshTTLDays:   getint("JWT_REFRESH_TTL_DAYS", 14),
-		JWTAccessSecret:     getenv("JWT_ACCESS_SECRET", "dev-access"),
+		JWTKeysFile:         getenv("JWT_KEYS_FILE", ""),
 		JWTRefreshSecret:    getenv("JWT_REFRESH_SECRET", "dev-refresh"),
+		JWTIssuer:           getenv("JWT_ISSUER", getenv("APP_PUBLIC_URL", "http://localhost:8080")),
+		JWTAudience:         splitCSV(getenv("JWT_AUDIENCE", "vergo-api")),
+
+		NotifyOnRefreshReuse: getbool("NOTIFY_ON_REFRESH_REUSE", true),
+		GeoHeader:            getenv("GEO_HEADER", ""),
+
+		// Password policy
+		PasswordMinLength:  getint("PASSWORD_MIN_LENGTH", 8),
+		PasswordMinScore:   getint("PASSWORD_MIN_SCORE", 2),
+		PasswordBreachFile: getenv("PASSWORD_BREACH_FILE", ""),
+
+		// Password hashing
+		Argon2MemoryKB:    getint("PASSWORD_ARGON2_MEMORY_KB", 64*1024),
+		Argon2Iterations:  getint("PASSWORD_ARGON2_ITERATIONS", 3),
+		Argon2Parallelism: getint("PASSWORD_ARGON2_PARALLELISM", 2),
+
+		// Platform admins
+		PlatformAdminEmails:     splitCSV(getenv("PLATFORM_ADMIN_EMAILS", "")),
+		ImpersonationTTLMinutes: getint("IMPERSONATION_TTL_MINUTES", 15),
+
+		// Lockout
+		LockoutMaxFailures:   getint("LOCKOUT_MAX_FAILURES", 5),
+		LockoutIPMaxFailures: getint("LOCKOUT_IP_MAX_FAILURES", 50),
+		LockoutCaptchaAfter:  getint("LOCKOUT_CAPTCHA_AFTER", 3),
+		LockoutBaseSeconds:   getint("LOCKOUT_BASE_SECONDS", 60),
+		LockoutMaxMinutes:    getint("LOCKOUT_MAX_MINUTES", 60),
+		LockoutWindowMinutes: getint("LOCKOUT_WINDOW_MINUTES", 15),
 
 		// DB
 		DBHost: getenv("DB_HOST", "localhost"),
@@ -124,6 +213,12 @@ func Load() Config {
 		StorageAllowedTypes: splitCSV(getenv("STORAGE_ALLOWED_TYPES", "")),
 		StorageMaxMB:        getint("STORAGE_MAX_MB", 25),
 
+		// Deletion
+		DeletionRestoreDays: getint("DELETION_RESTORE_DAYS", 30),
+
+		// Idempotency
+		IdempotencyTTLHours: getint("IDEMPOTENCY_TTL_HOURS", 24),
+
 		// Rate limiting
 		RateLimitRPS:   getint("RATE_LIMIT_RPS", 20),
 		RateLimitBurst: getint("RATE_LIMIT_BURST", 40),
@@ -136,5 +231,39 @@ func Load() Config {
 		// Stripe
 		StripeSecretKey:    getenv("STRIPE_SECRET_KEY", ""),
 		StripeWebhookSecret: getenv("STRIPE_WEBHOOK_SECRET", ""),
+
+		// OIDC
+		OIDCProviders: loadOIDCProviders(),
+	}
+}
+
+func loadOIDCProviders() []OIDCProvider {
+	names := splitCSV(getenv("OIDC_PROVIDERS", ""))
+	out := make([]OIDCProvider, 0, len(names))
+	for _, name := range names {
+		name = strings.ToLower(name)
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+
+		typ, issuer := "oidc", ""
+		switch name {
+		case "google":
+			issuer = "https://accounts.google.com"
+		case "github":
+			typ = "github"
+		}
+		p := OIDCProvider{
+			Name:         name,
+			Type:         getenv(prefix+"TYPE", typ),
+			Issuer:       getenv(prefix+"ISSUER", issuer),
+			ClientID:     getenv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getenv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  getenv(prefix+"REDIRECT_URL", ""),
+			Scopes:       splitCSV(getenv(prefix+"SCOPES", "")),
+		}
+		if p.ClientID == "" {
+			continue
+		}
+		out = append(out, p)
 	}
+	return out
 }
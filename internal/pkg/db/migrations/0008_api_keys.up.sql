@@ -0,0 +1,15 @@
+CREATE TABLE api_keys (
+  id TEXT PRIMARY KEY DEFAULT gen_random_uuid()::TEXT,
+  org_id TEXT NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  key_prefix TEXT NOT NULL,           -- first 8 chars of sk_... for display
+  key_hash TEXT NOT NULL UNIQUE,     -- SHA-256 of full key
+  created_by TEXT NOT NULL REFERENCES users (id),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  expires_at TIMESTAMPTZ,
+  last_used_at TIMESTAMPTZ,
+  revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_api_keys_org ON api_keys (org_id) WHERE revoked_at IS NULL;
+CREATE INDEX idx_api_keys_hash ON api_keys (key_hash) WHERE revoked_at IS NULL;
@@ -0,0 +1,13 @@
+CREATE TABLE subscriptions (
+  id TEXT PRIMARY KEY DEFAULT gen_random_uuid()::TEXT,
+  org_id TEXT NOT NULL UNIQUE REFERENCES organizations (id) ON DELETE CASCADE,
+  stripe_customer_id TEXT NOT NULL,
+  stripe_subscription_id TEXT UNIQUE,
+  status TEXT NOT NULL DEFAULT 'incomplete',
+  plan TEXT NOT NULL DEFAULT 'free',
+  current_period_end TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_subscriptions_stripe_customer ON subscriptions (stripe_customer_id);
@@ -0,0 +1,40 @@
+-- Per-organization SAML 2.0 identity provider
+CREATE TABLE IF NOT EXISTS org_saml_configs (
+  org_id TEXT PRIMARY KEY REFERENCES organizations (id) ON DELETE CASCADE,
+  idp_entity_id TEXT NOT NULL,
+  idp_sso_url TEXT NOT NULL,
+  idp_certificate TEXT NOT NULL,   -- PEM
+  attribute_mapping JSONB NOT NULL DEFAULT '{}',
+  enforce_sso BOOLEAN NOT NULL DEFAULT false,
+  enabled BOOLEAN NOT NULL DEFAULT true,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Email domains claimed by an organization (verified via DNS TXT)
+CREATE TABLE IF NOT EXISTS org_domains (
+  org_id TEXT NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+  domain TEXT NOT NULL,
+  verification_token TEXT NOT NULL,
+  verified_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (org_id, domain)
+);
+
+-- A domain can be verified by a single organization only
+CREATE UNIQUE INDEX IF NOT EXISTS idx_org_domains_verified
+ON org_domains (domain) WHERE verified_at IS NOT NULL;
+
+-- Outstanding SP-initiated AuthnRequests (InResponseTo validation)
+CREATE TABLE IF NOT EXISTS saml_requests (
+  id TEXT PRIMARY KEY,
+  org_id TEXT NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+  expires_at TIMESTAMPTZ NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Consumed assertion IDs (replay protection)
+CREATE TABLE IF NOT EXISTS saml_assertions_seen (
+  id TEXT PRIMARY KEY,
+  expires_at TIMESTAMPTZ NOT NULL
+);
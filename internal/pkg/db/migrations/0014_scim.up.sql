@@ -0,0 +1,53 @@
+-- Org-scoped bearer tokens used by SCIM clients (Okta, Azure AD, ...)
+CREATE TABLE IF NOT EXISTS scim_tokens (
+  id TEXT PRIMARY KEY DEFAULT gen_random_uuid()::TEXT,
+  org_id TEXT NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  token_prefix TEXT NOT NULL,
+  token_hash TEXT NOT NULL UNIQUE,
+  created_by TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  last_used_at TIMESTAMPTZ,
+  revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_scim_tokens_org ON scim_tokens (org_id);
+
+-- SCIM User resources: the IdP-managed view of a member
+CREATE TABLE IF NOT EXISTS scim_users (
+  org_id TEXT NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+  user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  user_name TEXT NOT NULL,
+  external_id TEXT,
+  display_name TEXT,
+  given_name TEXT,
+  family_name TEXT,
+  role TEXT NOT NULL DEFAULT 'member',
+  active BOOLEAN NOT NULL DEFAULT true,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (org_id, user_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_scim_users_user_name
+ON scim_users (org_id, lower(user_name));
+
+-- SCIM Group resources; role is granted to every member of the group
+CREATE TABLE IF NOT EXISTS scim_groups (
+  id TEXT PRIMARY KEY DEFAULT gen_random_uuid()::TEXT,
+  org_id TEXT NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+  display_name TEXT NOT NULL,
+  external_id TEXT,
+  role TEXT NOT NULL DEFAULT 'member',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (org_id, display_name)
+);
+
+CREATE TABLE IF NOT EXISTS scim_group_members (
+  group_id TEXT NOT NULL REFERENCES scim_groups (id) ON DELETE CASCADE,
+  user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  PRIMARY KEY (group_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_scim_group_members_user ON scim_group_members (user_id);
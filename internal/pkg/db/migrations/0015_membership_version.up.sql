@@ -0,0 +1,8 @@
+-- Membership version: bumped on every role change or re-add so org-scoped
+-- access tokens minted against an older version are rejected. A global
+-- sequence keeps versions unique even if a membership is deleted and
+-- re-created.
+CREATE SEQUENCE IF NOT EXISTS membership_version_seq;
+
+ALTER TABLE memberships
+  ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT nextval('membership_version_seq');
@@ -0,0 +1,12 @@
+-- Failed authentication attempts per scope (login, forgot_password) and key
+-- ("account:<email>" or "ip:<addr>"), driving exponential lockout.
+CREATE TABLE IF NOT EXISTS auth_attempts (
+  scope TEXT NOT NULL,
+  key TEXT NOT NULL,
+  failures INT NOT NULL DEFAULT 0,
+  last_failure_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  locked_until TIMESTAMPTZ,
+  PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_attempts_last_failure ON auth_attempts (last_failure_at);
@@ -0,0 +1,23 @@
+-- Profile fields shown and edited through /me.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS display_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_file_id TEXT REFERENCES files (id) ON DELETE SET NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT '';
+
+-- Pending email changes: the new address is applied once its owner follows
+-- the verification link.
+CREATE TABLE IF NOT EXISTS email_change_tokens (
+  id TEXT PRIMARY KEY DEFAULT gen_random_uuid()::TEXT,
+  user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  new_email TEXT NOT NULL,
+  token_hash TEXT NOT NULL UNIQUE,
+  expires_at TIMESTAMPTZ NOT NULL,
+  used_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Deleted accounts: rows that must outlive the user (projects, files, audit
+-- logs, API keys) are reassigned to this placeholder, which cannot sign in.
+INSERT INTO users (id, email, password_hash)
+VALUES ('deleted-user', 'deleted-user@deleted.invalid', '')
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,11 @@
+-- Platform admins (Vergo operators / support), separate from org roles.
+CREATE TABLE IF NOT EXISTS platform_admins (
+  user_id TEXT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Orgs can refuse impersonated sessions.
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS allow_impersonation BOOLEAN NOT NULL DEFAULT true;
+
+-- Real actor of events recorded while a platform admin impersonated actor_id.
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS impersonator_id TEXT NOT NULL DEFAULT '';
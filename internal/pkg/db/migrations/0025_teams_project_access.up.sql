@@ -0,0 +1,49 @@
+-- Teams group members of an org; projects grant viewer, editor or admin
+-- access to users or teams. Roles with projects.access_all (the owner and
+-- admin presets) reach every project without a grant.
+CREATE TABLE IF NOT EXISTS teams (
+  id TEXT PRIMARY KEY DEFAULT gen_random_uuid()::TEXT,
+  org_id TEXT NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  UNIQUE (org_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS team_members (
+  team_id TEXT NOT NULL REFERENCES teams (id) ON DELETE CASCADE,
+  user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (team_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_team_members_user ON team_members (user_id);
+
+CREATE TABLE IF NOT EXISTS project_grants (
+  id TEXT PRIMARY KEY DEFAULT gen_random_uuid()::TEXT,
+  project_id TEXT NOT NULL REFERENCES projects (id) ON DELETE CASCADE,
+  user_id TEXT REFERENCES users (id) ON DELETE CASCADE,
+  team_id TEXT REFERENCES teams (id) ON DELETE CASCADE,
+  access TEXT NOT NULL CHECK (access IN ('viewer', 'editor', 'admin')),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  CHECK ((user_id IS NULL) <> (team_id IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_project_grants_user ON project_grants (project_id, user_id) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_project_grants_team ON project_grants (project_id, team_id) WHERE team_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_project_grants_team ON project_grants (team_id);
+
+-- Creators keep admin access to the projects they already have.
+INSERT INTO project_grants (project_id, user_id, access)
+SELECT p.id, p.created_by, 'admin'
+FROM projects p JOIN users u ON u.id = p.created_by
+ON CONFLICT DO NOTHING;
+
+UPDATE roles SET permissions = array_append(permissions, 'projects.access_all'), updated_at = NOW()
+WHERE org_id IS NULL AND name IN ('owner', 'admin') AND NOT 'projects.access_all' = ANY(permissions);
+UPDATE roles SET permissions = array_append(permissions, 'teams.manage'), updated_at = NOW()
+WHERE org_id IS NULL AND name IN ('owner', 'admin') AND NOT 'teams.manage' = ANY(permissions);
+
+-- Files may belong to a project and then follow its access.
+ALTER TABLE files ADD COLUMN IF NOT EXISTS project_id TEXT REFERENCES projects (id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS idx_files_project ON files (project_id) WHERE project_id IS NOT NULL;
@@ -0,0 +1,28 @@
+-- Org profile: a URL-safe unique slug, a settings document validated by the
+-- API (org.Settings) and branding.
+ALTER TABLE organizations
+  ADD COLUMN IF NOT EXISTS slug TEXT,
+  ADD COLUMN IF NOT EXISTS settings JSONB NOT NULL DEFAULT '{}',
+  ADD COLUMN IF NOT EXISTS logo_file_id TEXT REFERENCES files (id) ON DELETE SET NULL,
+  ADD COLUMN IF NOT EXISTS primary_color TEXT NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+-- Existing orgs get a slug from their name; repeated names get an id suffix.
+WITH base AS (
+  SELECT id, LEFT(COALESCE(NULLIF(TRIM(BOTH '-' FROM LOWER(REGEXP_REPLACE(name, '[^a-zA-Z0-9]+', '-', 'g'))), ''), 'org'), 54) AS s
+  FROM organizations
+  WHERE slug IS NULL
+), numbered AS (
+  SELECT id, TRIM(TRAILING '-' FROM s) AS s, ROW_NUMBER() OVER (PARTITION BY s ORDER BY id) AS n
+  FROM base
+)
+UPDATE organizations o
+SET slug = CASE WHEN n.n = 1 THEN n.s ELSE n.s || '-' || LEFT(o.id, 8) END
+FROM numbered n
+WHERE o.id = n.id;
+
+ALTER TABLE organizations ALTER COLUMN slug SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_organizations_slug ON organizations (slug);
+
+UPDATE roles SET permissions = array_append(permissions, 'org.manage'), updated_at = NOW()
+WHERE org_id IS NULL AND name IN ('owner', 'admin') AND NOT 'org.manage' = ANY(permissions);
@@ -0,0 +1,15 @@
+-- Orgs and projects are soft-deleted and can be restored until the purge
+-- worker removes them for good (DELETION_RESTORE_DAYS).
+ALTER TABLE organizations
+  ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ,
+  ADD COLUMN IF NOT EXISTS deleted_by TEXT;
+CREATE INDEX IF NOT EXISTS idx_organizations_deleted ON organizations (deleted_at) WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_projects_deleted ON projects (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- projects.org_id never had a foreign key: drop the projects orphaned by
+-- earlier org deletions and cascade from now on.
+DELETE FROM projects p WHERE NOT EXISTS (SELECT 1 FROM organizations o WHERE o.id = p.org_id);
+ALTER TABLE projects ADD CONSTRAINT projects_org_id_fkey
+  FOREIGN KEY (org_id) REFERENCES organizations (id) ON DELETE CASCADE;
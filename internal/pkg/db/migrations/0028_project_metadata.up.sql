@@ -0,0 +1,21 @@
+-- Project metadata: free-form tags, an archived state and values for the
+-- custom fields each org defines (text, number, date or enum). Values live
+-- in projects.custom_fields keyed by field key and are validated by the
+-- API against project_fields.
+ALTER TABLE projects
+  ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}',
+  ADD COLUMN IF NOT EXISTS custom_fields JSONB NOT NULL DEFAULT '{}',
+  ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_projects_tags ON projects USING GIN (tags);
+
+CREATE TABLE IF NOT EXISTS project_fields (
+  id TEXT PRIMARY KEY DEFAULT gen_random_uuid()::TEXT,
+  org_id TEXT NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+  key TEXT NOT NULL,
+  label TEXT NOT NULL,
+  type TEXT NOT NULL CHECK (type IN ('text', 'number', 'date', 'enum')),
+  options TEXT[] NOT NULL DEFAULT '{}',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  UNIQUE (org_id, key)
+);
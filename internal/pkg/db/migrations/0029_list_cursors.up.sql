@@ -0,0 +1,9 @@
+-- Project search matches q against name and description through this
+-- expression; queries must repeat it verbatim to use the index.
+CREATE INDEX IF NOT EXISTS idx_projects_search ON projects
+  USING GIN (to_tsvector('simple', name || ' ' || COALESCE(description, '')));
+
+-- Keyset pagination walks (created_at, id) within an org.
+CREATE INDEX IF NOT EXISTS idx_projects_org_created ON projects (org_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_files_org_created ON files (org_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_org_created ON audit_logs (org_id, created_at DESC, id DESC);
@@ -0,0 +1,7 @@
+-- Row versions for optimistic concurrency: every write bumps them and the
+-- API serves them as ETags, so a write sent with If-Match only applies
+-- while the row is still at that version.
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE webhook_endpoints ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE files ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
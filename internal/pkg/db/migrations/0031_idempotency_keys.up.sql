@@ -0,0 +1,17 @@
+-- Idempotency-Key support for POST endpoints: the first request with a key
+-- claims it (status_code NULL while it runs) and stores its response,
+-- which retries with the same key and fingerprint get back instead of
+-- running again. Entries expire after IDEMPOTENCY_TTL_HOURS.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  principal TEXT NOT NULL, -- user:<id> or key:<api key id>
+  org_id TEXT NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+  key TEXT NOT NULL,
+  fingerprint TEXT NOT NULL, -- SHA-256 of method, URI and body
+  status_code INT,
+  content_type TEXT NOT NULL DEFAULT '',
+  response_body BYTEA NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  expires_at TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (principal, org_id, key)
+);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires ON idempotency_keys (expires_at);
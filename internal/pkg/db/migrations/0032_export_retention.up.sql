@@ -0,0 +1,5 @@
+-- Export archives are deleted ARCHIVE_TTL after the job finishes, and a
+-- job whose runner died is claimed again once started_at is old enough.
+ALTER TABLE data_exports ADD COLUMN IF NOT EXISTS started_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_data_exports_finished ON data_exports (finished_at) WHERE finished_at IS NOT NULL;
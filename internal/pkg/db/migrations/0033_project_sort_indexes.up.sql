@@ -0,0 +1,4 @@
+-- Each project sort has its own list query walking (column, id) within an
+-- org; created_at already has idx_projects_org_created.
+CREATE INDEX IF NOT EXISTS idx_projects_org_name ON projects (org_id, name, id);
+CREATE INDEX IF NOT EXISTS idx_projects_org_updated ON projects (org_id, updated_at, id);
@@ -0,0 +1,39 @@
+// Package notify delivers account notices, such as security alerts, to
+// users. There is no mail transport yet, so the default notifier writes the
+// notice to the structured log, like password reset links.
+package notify
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Notice is a message addressed to one user.
+type Notice struct {
+	UserID  string
+	Email   string
+	Subject string
+	Body    string
+}
+
+// Notifier sends notices. Implementations must be safe for concurrent use.
+type Notifier interface {
+	Notify(ctx context.Context, n Notice) error
+}
+
+type logNotifier struct{}
+
+// NewLogNotifier returns a Notifier that logs each notice.
+func NewLogNotifier() Notifier {
+	return logNotifier{}
+}
+
+func (logNotifier) Notify(ctx context.Context, n Notice) error {
+	slog.InfoContext(ctx, "user notice",
+		"user_id", n.UserID,
+		"email", n.Email,
+		"subject", n.Subject,
+		"body", n.Body,
+	)
+	return nil
+}
@@ -0,0 +1,84 @@
+// Package pagination holds the envelope and the opaque keyset cursors of
+// list endpoints. A cursor is the sort key and ID of the last item of a
+// page; the next page starts right after it.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Page is the envelope of every paginated list. NextCursor is empty on the
+// last page.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Cursor is a position in a list sorted by Sort, then by ID. Key holds
+// text sort keys and Time time ones.
+type Cursor struct {
+	Sort string    `json:"s,omitempty"`
+	Key  string    `json:"k,omitempty"`
+	Time time.Time `json:"t"`
+	ID   string    `json:"i"`
+}
+
+// IsZero reports whether c is the start of the list.
+func (c Cursor) IsZero() bool { return c.ID == "" }
+
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Decode parses a cursor from Encode; "" is the start of the list.
+func Decode(s string) (Cursor, error) {
+	var c Cursor
+	if s == "" {
+		return c, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(b, &c) != nil || c.ID == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// Limit clamps a requested page size to 1-MaxLimit, DefaultLimit when
+// unset.
+func Limit(n int) int {
+	if n < 1 {
+		return DefaultLimit
+	}
+	return min(n, MaxLimit)
+}
+
+// New builds a page from rows fetched with a limit of limit+1: the extra
+// row only tells that another page follows. next returns the cursor of a
+// row.
+func New[T any](rows []T, limit int, next func(T) Cursor) Page[T] {
+	if len(rows) <= limit {
+		return Page[T]{Items: rows}
+	}
+	rows = rows[:limit]
+	return Page[T]{Items: rows, NextCursor: next(rows[limit-1]).Encode()}
+}
+
+// Map converts the items of a page, keeping its cursor.
+func Map[T, U any](p Page[T], f func(T) U) Page[U] {
+	out := Page[U]{Items: make([]U, len(p.Items)), NextCursor: p.NextCursor}
+	for i, v := range p.Items {
+		out.Items[i] = f(v)
+	}
+	return out
+}
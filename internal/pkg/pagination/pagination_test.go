@@ -0,0 +1,56 @@
+package pagination
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	c := Cursor{Sort: "-updated_at", Time: time.Date(2026, 3, 1, 12, 0, 0, 123456000, time.UTC), ID: "p-1"}
+	got, err := Decode(c.Encode())
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if got.Sort != c.Sort || !got.Time.Equal(c.Time) || got.ID != c.ID {
+		t.Errorf("round trip = %+v, want %+v", got, c)
+	}
+
+	if c, err := Decode(""); err != nil || !c.IsZero() {
+		t.Errorf(`Decode("") = %+v, %v`, c, err)
+	}
+	for _, s := range []string{"!!", "bm90IGpzb24", Cursor{Sort: "name"}.Encode()} {
+		if _, err := Decode(s); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Decode(%q) = %v, want ErrInvalidCursor", s, err)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	next := func(n int) Cursor { return Cursor{ID: string(rune('a' + n))} }
+
+	p := New([]int{0, 1, 2}, 2, next)
+	if len(p.Items) != 2 || p.NextCursor == "" {
+		t.Fatalf("page = %+v, want 2 items and a cursor", p)
+	}
+	if c, _ := Decode(p.NextCursor); c.ID != "b" {
+		t.Errorf("next cursor after %q, want after the last item", c.ID)
+	}
+
+	if p := New([]int{0, 1}, 2, next); len(p.Items) != 2 || p.NextCursor != "" {
+		t.Errorf("last page = %+v, want no cursor", p)
+	}
+
+	doubled := Map(New([]int{1, 2, 3}, 2, next), func(n int) int { return n * 2 })
+	if doubled.Items[1] != 4 || doubled.NextCursor == "" {
+		t.Errorf("Map = %+v", doubled)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	for in, want := range map[int]int{0: DefaultLimit, -5: DefaultLimit, 7: 7, 1000: MaxLimit} {
+		if got := Limit(in); got != want {
+			t.Errorf("Limit(%d) = %d, want %d", in, got, want)
+		}
+	}
+}
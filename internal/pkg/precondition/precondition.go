@@ -0,0 +1,46 @@
+// Package precondition backs optimistic concurrency. Mutable rows carry a
+// version, bumped on every write, that the API exposes as the ETag of the
+// resource; writes sent with If-Match only apply while it still matches.
+package precondition
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Any is the expected version of an unconditional write.
+const Any int64 = 0
+
+// ErrFailed means the row changed since the client read it.
+var ErrFailed = errors.New("precondition failed: resource was modified")
+
+// Check returns ErrFailed unless want is Any or equals got.
+func Check(want, got int64) error {
+	if want != Any && want != got {
+		return ErrFailed
+	}
+	return nil
+}
+
+// ETag formats version as a strong entity tag.
+func ETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ParseETag returns the version of a tag made by ETag. Weak tags ("W/")
+// are only accepted when weak is set, as If-None-Match allows.
+func ParseETag(tag string, weak bool) (int64, bool) {
+	tag = strings.TrimSpace(tag)
+	if weak {
+		tag = strings.TrimPrefix(tag, "W/")
+	}
+	if len(tag) < 3 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	if err != nil || v <= 0 {
+		return 0, false
+	}
+	return v, true
+}
@@ -0,0 +1,43 @@
+package precondition
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	if err := Check(Any, 7); err != nil {
+		t.Errorf("Check(Any, 7) = %v", err)
+	}
+	if err := Check(7, 7); err != nil {
+		t.Errorf("Check(7, 7) = %v", err)
+	}
+	if err := Check(6, 7); !errors.Is(err, ErrFailed) {
+		t.Errorf("Check(6, 7) = %v, want ErrFailed", err)
+	}
+}
+
+func TestParseETag(t *testing.T) {
+	cases := []struct {
+		tag  string
+		weak bool
+		want int64
+		ok   bool
+	}{
+		{ETag(42), false, 42, true},
+		{` "3" `, false, 3, true},
+		{`W/"3"`, false, 0, false},
+		{`W/"3"`, true, 3, true},
+		{`3`, false, 0, false},
+		{`""`, false, 0, false},
+		{`"0"`, false, 0, false},
+		{`"-1"`, false, 0, false},
+		{`"abc"`, true, 0, false},
+	}
+	for _, tc := range cases {
+		got, ok := ParseETag(tc.tag, tc.weak)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("ParseETag(%q, %v) = %d, %v; want %d, %v", tc.tag, tc.weak, got, ok, tc.want, tc.ok)
+		}
+	}
+}
@@ -20,6 +20,26 @@ import (
 // with all migrations applied. The container is automatically terminated when
 // the test finishes.
 func PGContainer(t *testing.T) *sql.DB {
+	t.Helper()
+	database, _, _ := startPG(t)
+	return database
+}
+
+// PGEnv is PGContainer that also points the DB_* variables read by
+// config.Load at the container, for tests that wire the whole app.
+func PGEnv(t *testing.T) *sql.DB {
+	t.Helper()
+	database, host, port := startPG(t)
+	t.Setenv("DB_HOST", host)
+	t.Setenv("DB_PORT", port)
+	t.Setenv("DB_USER", "test")
+	t.Setenv("DB_PASSWORD", "test")
+	t.Setenv("DB_NAME", "vergo_test")
+	t.Setenv("DB_SSLMODE", "disable")
+	return database
+}
+
+func startPG(t *testing.T) (*sql.DB, string, string) {
 	t.Helper()
 	ctx := context.Background()
 
@@ -74,5 +94,5 @@ func PGContainer(t *testing.T) *sql.DB {
 		t.Fatalf("run migrations: %v", err)
 	}
 
-	return database
+	return database, host, port.Port()
 }
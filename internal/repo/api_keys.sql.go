@@ -12,9 +12,9 @@ import (
 )
 
 const createAPIKey = `-- name: CreateAPIKey :one
-INSERT INTO api_keys (org_id, name, key_prefix, key_hash, created_by, expires_at)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, org_id, name, key_prefix, created_by, created_at, expires_at
+INSERT INTO api_keys (org_id, name, key_prefix, key_hash, created_by, expires_at, role)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, org_id, name, key_prefix, created_by, created_at, expires_at, role
 `
 
 type CreateAPIKeyParams struct {
@@ -24,6 +24,7 @@ type CreateAPIKeyParams struct {
 	KeyHash   string       `json:"key_hash"`
 	CreatedBy string       `json:"created_by"`
 	ExpiresAt sql.NullTime `json:"expires_at"`
+	Role      string       `json:"role"`
 }
 
 type CreateAPIKeyRow struct {
@@ -34,6 +35,7 @@ type CreateAPIKeyRow struct {
 	CreatedBy string       `json:"created_by"`
 	CreatedAt time.Time    `json:"created_at"`
 	ExpiresAt sql.NullTime `json:"expires_at"`
+	Role      string       `json:"role"`
 }
 
 func (q *Queries) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (CreateAPIKeyRow, error) {
@@ -44,6 +46,7 @@ func (q *Queries) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (Cre
 		arg.KeyHash,
 		arg.CreatedBy,
 		arg.ExpiresAt,
+		arg.Role,
 	)
 	var i CreateAPIKeyRow
 	err := row.Scan(
@@ -54,12 +57,63 @@ func (q *Queries) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (Cre
 		&i.CreatedBy,
 		&i.CreatedAt,
 		&i.ExpiresAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const deleteOrgAPIKeys = `-- name: DeleteOrgAPIKeys :exec
+DELETE FROM api_keys
+WHERE org_id = $1
+`
+
+func (q *Queries) DeleteOrgAPIKeys(ctx context.Context, orgID string) error {
+	_, err := q.db.ExecContext(ctx, deleteOrgAPIKeys, orgID)
+	return err
+}
+
+const getAPIKey = `-- name: GetAPIKey :one
+SELECT id, org_id, name, key_prefix, created_by, created_at, expires_at, last_used_at, role
+FROM api_keys
+WHERE id = $1 AND org_id = $2 AND revoked_at IS NULL
+`
+
+type GetAPIKeyParams struct {
+	ID    string `json:"id"`
+	OrgID string `json:"org_id"`
+}
+
+type GetAPIKeyRow struct {
+	ID         string       `json:"id"`
+	OrgID      string       `json:"org_id"`
+	Name       string       `json:"name"`
+	KeyPrefix  string       `json:"key_prefix"`
+	CreatedBy  string       `json:"created_by"`
+	CreatedAt  time.Time    `json:"created_at"`
+	ExpiresAt  sql.NullTime `json:"expires_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	Role       string       `json:"role"`
+}
+
+func (q *Queries) GetAPIKey(ctx context.Context, arg GetAPIKeyParams) (GetAPIKeyRow, error) {
+	row := q.db.QueryRowContext(ctx, getAPIKey, arg.ID, arg.OrgID)
+	var i GetAPIKeyRow
+	err := row.Scan(
+		&i.ID,
+		&i.OrgID,
+		&i.Name,
+		&i.KeyPrefix,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.Role,
 	)
 	return i, err
 }
 
 const getAPIKeyByHash = `-- name: GetAPIKeyByHash :one
-SELECT id, org_id, name, key_prefix, key_hash, created_by, created_at, expires_at, last_used_at, revoked_at
+SELECT id, org_id, name, key_prefix, key_hash, created_by, created_at, expires_at, last_used_at, revoked_at, role
 FROM api_keys
 WHERE key_hash = $1 AND revoked_at IS NULL
 `
@@ -78,12 +132,13 @@ func (q *Queries) GetAPIKeyByHash(ctx context.Context, keyHash string) (ApiKey,
 		&i.ExpiresAt,
 		&i.LastUsedAt,
 		&i.RevokedAt,
+		&i.Role,
 	)
 	return i, err
 }
 
 const listAPIKeysByOrg = `-- name: ListAPIKeysByOrg :many
-SELECT id, org_id, name, key_prefix, created_by, created_at, expires_at, last_used_at
+SELECT id, org_id, name, key_prefix, created_by, created_at, expires_at, last_used_at, role
 FROM api_keys
 WHERE org_id = $1 AND revoked_at IS NULL
 ORDER BY created_at DESC
@@ -98,6 +153,7 @@ type ListAPIKeysByOrgRow struct {
 	CreatedAt  time.Time    `json:"created_at"`
 	ExpiresAt  sql.NullTime `json:"expires_at"`
 	LastUsedAt sql.NullTime `json:"last_used_at"`
+	Role       string       `json:"role"`
 }
 
 func (q *Queries) ListAPIKeysByOrg(ctx context.Context, orgID string) ([]ListAPIKeysByOrgRow, error) {
@@ -118,6 +174,7 @@ func (q *Queries) ListAPIKeysByOrg(ctx context.Context, orgID string) ([]ListAPI
 			&i.CreatedAt,
 			&i.ExpiresAt,
 			&i.LastUsedAt,
+			&i.Role,
 		); err != nil {
 			return nil, err
 		}
@@ -147,6 +204,47 @@ func (q *Queries) RevokeAPIKey(ctx context.Context, arg RevokeAPIKeyParams) erro
 	return err
 }
 
+const setAPIKeyRole = `-- name: SetAPIKeyRole :one
+UPDATE api_keys SET role = $3
+WHERE id = $1 AND org_id = $2 AND revoked_at IS NULL
+RETURNING id, org_id, name, key_prefix, created_by, created_at, expires_at, last_used_at, role
+`
+
+type SetAPIKeyRoleParams struct {
+	ID    string `json:"id"`
+	OrgID string `json:"org_id"`
+	Role  string `json:"role"`
+}
+
+type SetAPIKeyRoleRow struct {
+	ID         string       `json:"id"`
+	OrgID      string       `json:"org_id"`
+	Name       string       `json:"name"`
+	KeyPrefix  string       `json:"key_prefix"`
+	CreatedBy  string       `json:"created_by"`
+	CreatedAt  time.Time    `json:"created_at"`
+	ExpiresAt  sql.NullTime `json:"expires_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	Role       string       `json:"role"`
+}
+
+func (q *Queries) SetAPIKeyRole(ctx context.Context, arg SetAPIKeyRoleParams) (SetAPIKeyRoleRow, error) {
+	row := q.db.QueryRowContext(ctx, setAPIKeyRole, arg.ID, arg.OrgID, arg.Role)
+	var i SetAPIKeyRoleRow
+	err := row.Scan(
+		&i.ID,
+		&i.OrgID,
+		&i.Name,
+		&i.KeyPrefix,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.Role,
+	)
+	return i, err
+}
+
 const touchAPIKeyLastUsed = `-- name: TouchAPIKeyLastUsed :exec
 UPDATE api_keys SET last_used_at = now() WHERE id = $1
 `
@@ -15,18 +15,19 @@ import (
 )
 
 const insertAuditLog = `-- name: InsertAuditLog :exec
-INSERT INTO audit_logs (org_id, actor_id, action, entity, entity_id, metadata, created_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7)
+INSERT INTO audit_logs (org_id, actor_id, action, entity, entity_id, metadata, created_at, impersonator_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 `
 
 type InsertAuditLogParams struct {
-	OrgID     string                `json:"org_id"`
-	ActorID   string                `json:"actor_id"`
-	Action    string                `json:"action"`
-	Entity    string                `json:"entity"`
-	EntityID  string                `json:"entity_id"`
-	Metadata  pqtype.NullRawMessage `json:"metadata"`
-	CreatedAt time.Time             `json:"created_at"`
+	OrgID          string                `json:"org_id"`
+	ActorID        string                `json:"actor_id"`
+	Action         string                `json:"action"`
+	Entity         string                `json:"entity"`
+	EntityID       string                `json:"entity_id"`
+	Metadata       pqtype.NullRawMessage `json:"metadata"`
+	CreatedAt      time.Time             `json:"created_at"`
+	ImpersonatorID string                `json:"impersonator_id"`
 }
 
 func (q *Queries) InsertAuditLog(ctx context.Context, arg InsertAuditLogParams) error {
@@ -38,14 +39,15 @@ func (q *Queries) InsertAuditLog(ctx context.Context, arg InsertAuditLogParams)
 		arg.EntityID,
 		arg.Metadata,
 		arg.CreatedAt,
+		arg.ImpersonatorID,
 	)
 	return err
 }
 
 const listAuditLogs = `-- name: ListAuditLogs :many
-SELECT org_id, actor_id, action, entity, entity_id,
+SELECT id, org_id, actor_id, action, entity, entity_id,
        COALESCE(metadata, '{}') AS metadata,
-       created_at
+       created_at, impersonator_id
 FROM audit_logs
 WHERE org_id = $1
   AND (CAST($2 AS text) IS NULL OR actor_id = CAST($2 AS text))
@@ -53,8 +55,9 @@ WHERE org_id = $1
   AND (CAST($4 AS text) IS NULL OR entity = CAST($4 AS text))
   AND (CAST($5 AS timestamptz) IS NULL OR created_at >= CAST($5 AS timestamptz))
   AND (CAST($6 AS timestamptz) IS NULL OR created_at <= CAST($6 AS timestamptz))
-ORDER BY created_at DESC
-LIMIT $8 OFFSET $7
+  AND ($7::BIGINT = 0 OR (created_at, id) < ($8::TIMESTAMPTZ, $7::BIGINT))
+ORDER BY created_at DESC, id DESC
+LIMIT $9
 `
 
 type ListAuditLogsParams struct {
@@ -64,20 +67,25 @@ type ListAuditLogsParams struct {
 	FilterEntity  sql.NullString `json:"filter_entity"`
 	FilterSince   sql.NullTime   `json:"filter_since"`
 	FilterUntil   sql.NullTime   `json:"filter_until"`
-	QueryOffset   int32          `json:"query_offset"`
+	AfterID       int64          `json:"after_id"`
+	AfterTime     time.Time      `json:"after_time"`
 	QueryLimit    int32          `json:"query_limit"`
 }
 
 type ListAuditLogsRow struct {
-	OrgID     string          `json:"org_id"`
-	ActorID   string          `json:"actor_id"`
-	Action    string          `json:"action"`
-	Entity    string          `json:"entity"`
-	EntityID  string          `json:"entity_id"`
-	Metadata  json.RawMessage `json:"metadata"`
-	CreatedAt time.Time       `json:"created_at"`
+	ID             int64           `json:"id"`
+	OrgID          string          `json:"org_id"`
+	ActorID        string          `json:"actor_id"`
+	Action         string          `json:"action"`
+	Entity         string          `json:"entity"`
+	EntityID       string          `json:"entity_id"`
+	Metadata       json.RawMessage `json:"metadata"`
+	CreatedAt      time.Time       `json:"created_at"`
+	ImpersonatorID string          `json:"impersonator_id"`
 }
 
+// Newest first; the page starts after the cursor (@after_time, @after_id)
+// unless @after_id is 0.
 func (q *Queries) ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([]ListAuditLogsRow, error) {
 	rows, err := q.db.QueryContext(ctx, listAuditLogs,
 		arg.OrgID,
@@ -86,7 +94,8 @@ func (q *Queries) ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([
 		arg.FilterEntity,
 		arg.FilterSince,
 		arg.FilterUntil,
-		arg.QueryOffset,
+		arg.AfterID,
+		arg.AfterTime,
 		arg.QueryLimit,
 	)
 	if err != nil {
@@ -97,6 +106,7 @@ func (q *Queries) ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([
 	for rows.Next() {
 		var i ListAuditLogsRow
 		if err := rows.Scan(
+			&i.ID,
 			&i.OrgID,
 			&i.ActorID,
 			&i.Action,
@@ -104,6 +114,7 @@ func (q *Queries) ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([
 			&i.EntityID,
 			&i.Metadata,
 			&i.CreatedAt,
+			&i.ImpersonatorID,
 		); err != nil {
 			return nil, err
 		}
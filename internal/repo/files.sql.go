@@ -10,25 +10,28 @@ import (
 	"database/sql"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/sqlc-dev/pqtype"
 )
 
 const deleteFile = `-- name: DeleteFile :execresult
 DELETE FROM files
-WHERE id = $1 AND org_id = $2
+WHERE id = $1 AND org_id = $2 AND ($3::BIGINT = 0 OR version = $3)
 `
 
 type DeleteFileParams struct {
-	ID    string `json:"id"`
-	OrgID string `json:"org_id"`
+	ID        string `json:"id"`
+	OrgID     string `json:"org_id"`
+	IfVersion int64  `json:"if_version"`
 }
 
+// Applies only while the file is at @if_version, unless that is 0.
 func (q *Queries) DeleteFile(ctx context.Context, arg DeleteFileParams) (sql.Result, error) {
-	return q.db.ExecContext(ctx, deleteFile, arg.ID, arg.OrgID)
+	return q.db.ExecContext(ctx, deleteFile, arg.ID, arg.OrgID, arg.IfVersion)
 }
 
 const getFile = `-- name: GetFile :one
-SELECT id, org_id, uploaded_by, bucket, object_key, size_bytes, content_type, created_at, metadata
+SELECT id, org_id, uploaded_by, bucket, object_key, size_bytes, content_type, created_at, metadata, project_id, version
 FROM files
 WHERE id = $1 AND org_id = $2
 `
@@ -51,14 +54,16 @@ func (q *Queries) GetFile(ctx context.Context, arg GetFileParams) (File, error)
 		&i.ContentType,
 		&i.CreatedAt,
 		&i.Metadata,
+		&i.ProjectID,
+		&i.Version,
 	)
 	return i, err
 }
 
 const insertFile = `-- name: InsertFile :one
-INSERT INTO files (id, org_id, uploaded_by, bucket, object_key, size_bytes, content_type, created_at, metadata)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-RETURNING id, org_id, uploaded_by, bucket, object_key, size_bytes, content_type, created_at, metadata
+INSERT INTO files (id, org_id, uploaded_by, bucket, object_key, size_bytes, content_type, created_at, metadata, project_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id, org_id, uploaded_by, bucket, object_key, size_bytes, content_type, created_at, metadata, project_id, version
 `
 
 type InsertFileParams struct {
@@ -71,6 +76,7 @@ type InsertFileParams struct {
 	ContentType sql.NullString        `json:"content_type"`
 	CreatedAt   time.Time             `json:"created_at"`
 	Metadata    pqtype.NullRawMessage `json:"metadata"`
+	ProjectID   sql.NullString        `json:"project_id"`
 }
 
 func (q *Queries) InsertFile(ctx context.Context, arg InsertFileParams) (File, error) {
@@ -84,6 +90,7 @@ func (q *Queries) InsertFile(ctx context.Context, arg InsertFileParams) (File, e
 		arg.ContentType,
 		arg.CreatedAt,
 		arg.Metadata,
+		arg.ProjectID,
 	)
 	var i File
 	err := row.Scan(
@@ -96,26 +103,102 @@ func (q *Queries) InsertFile(ctx context.Context, arg InsertFileParams) (File, e
 		&i.ContentType,
 		&i.CreatedAt,
 		&i.Metadata,
+		&i.ProjectID,
+		&i.Version,
 	)
 	return i, err
 }
 
+const isFileUploadedBy = `-- name: IsFileUploadedBy :one
+SELECT EXISTS (
+  SELECT 1 FROM files WHERE id = $1 AND uploaded_by = $2
+)
+`
+
+type IsFileUploadedByParams struct {
+	ID         string `json:"id"`
+	UploadedBy string `json:"uploaded_by"`
+}
+
+func (q *Queries) IsFileUploadedBy(ctx context.Context, arg IsFileUploadedByParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isFileUploadedBy, arg.ID, arg.UploadedBy)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listFileProjectsByKey = `-- name: ListFileProjectsByKey :many
+SELECT DISTINCT project_id::TEXT
+FROM files
+WHERE org_id = $1 AND object_key = $2 AND project_id IS NOT NULL
+`
+
+type ListFileProjectsByKeyParams struct {
+	OrgID     string `json:"org_id"`
+	ObjectKey string `json:"object_key"`
+}
+
+// Projects holding a registered file with this object key.
+func (q *Queries) ListFileProjectsByKey(ctx context.Context, arg ListFileProjectsByKeyParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listFileProjectsByKey, arg.OrgID, arg.ObjectKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var project_id string
+		if err := rows.Scan(&project_id); err != nil {
+			return nil, err
+		}
+		items = append(items, project_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listFiles = `-- name: ListFiles :many
-SELECT id, org_id, uploaded_by, bucket, object_key, size_bytes, content_type, created_at, metadata
+SELECT id, org_id, uploaded_by, bucket, object_key, size_bytes, content_type, created_at, metadata, project_id, version
 FROM files
 WHERE org_id = $1
-ORDER BY created_at DESC
-LIMIT $2 OFFSET $3
+  AND (project_id IS NULL OR $2::BOOLEAN OR project_id = ANY($3::TEXT[]))
+  AND NOT EXISTS (SELECT 1 FROM projects p WHERE p.id = files.project_id AND p.deleted_at IS NOT NULL)
+  AND ($4::TEXT = '' OR project_id = $4)
+  AND ($5::TEXT = '' OR (created_at, id) < ($6::TIMESTAMPTZ, $5::TEXT))
+ORDER BY created_at DESC, id DESC
+LIMIT $7
 `
 
 type ListFilesParams struct {
-	OrgID  string `json:"org_id"`
-	Limit  int32  `json:"limit"`
-	Offset int32  `json:"offset"`
+	OrgID       string    `json:"org_id"`
+	AllProjects bool      `json:"all_projects"`
+	ProjectIds  []string  `json:"project_ids"`
+	ProjectID   string    `json:"project_id"`
+	AfterID     string    `json:"after_id"`
+	AfterTime   time.Time `json:"after_time"`
+	QueryLimit  int32     `json:"query_limit"`
 }
 
+// Files outside projects, plus those in project_ids (or in any project
+// with all_projects). A non-empty project_id narrows to that project.
+// Files of soft-deleted projects are hidden. Newest first; the page
+// starts after the cursor (@after_time, @after_id) unless @after_id is
+// empty.
 func (q *Queries) ListFiles(ctx context.Context, arg ListFilesParams) ([]File, error) {
-	rows, err := q.db.QueryContext(ctx, listFiles, arg.OrgID, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listFiles,
+		arg.OrgID,
+		arg.AllProjects,
+		pq.Array(arg.ProjectIds),
+		arg.ProjectID,
+		arg.AfterID,
+		arg.AfterTime,
+		arg.QueryLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -133,6 +216,8 @@ func (q *Queries) ListFiles(ctx context.Context, arg ListFilesParams) ([]File, e
 			&i.ContentType,
 			&i.CreatedAt,
 			&i.Metadata,
+			&i.ProjectID,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -146,3 +231,73 @@ func (q *Queries) ListFiles(ctx context.Context, arg ListFilesParams) ([]File, e
 	}
 	return items, nil
 }
+
+const listOrgFileObjects = `-- name: ListOrgFileObjects :many
+SELECT bucket, object_key
+FROM files
+WHERE org_id = $1
+`
+
+type ListOrgFileObjectsRow struct {
+	Bucket    string `json:"bucket"`
+	ObjectKey string `json:"object_key"`
+}
+
+// Every stored object of the org, for the purge worker.
+func (q *Queries) ListOrgFileObjects(ctx context.Context, orgID string) ([]ListOrgFileObjectsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOrgFileObjects, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListOrgFileObjectsRow{}
+	for rows.Next() {
+		var i ListOrgFileObjectsRow
+		if err := rows.Scan(&i.Bucket, &i.ObjectKey); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProjectFileObjects = `-- name: ListProjectFileObjects :many
+SELECT bucket, object_key
+FROM files
+WHERE project_id = $1
+`
+
+type ListProjectFileObjectsRow struct {
+	Bucket    string `json:"bucket"`
+	ObjectKey string `json:"object_key"`
+}
+
+// Every stored object of the project, for the purge worker.
+func (q *Queries) ListProjectFileObjects(ctx context.Context, projectID string) ([]ListProjectFileObjectsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listProjectFileObjects, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListProjectFileObjectsRow{}
+	for rows.Next() {
+		var i ListProjectFileObjectsRow
+		if err := rows.Scan(&i.Bucket, &i.ObjectKey); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -8,6 +8,7 @@ package repo
 import (
 	"context"
 	"database/sql"
+	"time"
 )
 
 const deleteMember = `-- name: DeleteMember :exec
@@ -43,9 +44,182 @@ func (q *Queries) GetMemberRole(ctx context.Context, arg GetMemberRoleParams) (s
 	return role, err
 }
 
+const getMembership = `-- name: GetMembership :one
+SELECT org_id, user_id, role, version
+FROM memberships
+WHERE org_id = $1 AND user_id = $2
+`
+
+type GetMembershipParams struct {
+	OrgID  string `json:"org_id"`
+	UserID string `json:"user_id"`
+}
+
+func (q *Queries) GetMembership(ctx context.Context, arg GetMembershipParams) (Membership, error) {
+	row := q.db.QueryRowContext(ctx, getMembership, arg.OrgID, arg.UserID)
+	var i Membership
+	err := row.Scan(
+		&i.OrgID,
+		&i.UserID,
+		&i.Role,
+		&i.Version,
+	)
+	return i, err
+}
+
+const insertMemberIfAbsent = `-- name: InsertMemberIfAbsent :execresult
+INSERT INTO memberships (org_id, user_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (org_id, user_id) DO NOTHING
+`
+
+type InsertMemberIfAbsentParams struct {
+	OrgID  string `json:"org_id"`
+	UserID string `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+func (q *Queries) InsertMemberIfAbsent(ctx context.Context, arg InsertMemberIfAbsentParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, insertMemberIfAbsent, arg.OrgID, arg.UserID, arg.Role)
+}
+
+const listOrgMembers = `-- name: ListOrgMembers :many
+SELECT m.user_id, u.email, u.display_name, u.avatar_file_id, m.role
+FROM memberships m
+JOIN users u ON u.id = m.user_id
+WHERE m.org_id = $1
+ORDER BY u.email
+LIMIT $2 OFFSET $3
+`
+
+type ListOrgMembersParams struct {
+	OrgID       string `json:"org_id"`
+	QueryLimit  int32  `json:"query_limit"`
+	QueryOffset int32  `json:"query_offset"`
+}
+
+type ListOrgMembersRow struct {
+	UserID       string         `json:"user_id"`
+	Email        string         `json:"email"`
+	DisplayName  string         `json:"display_name"`
+	AvatarFileID sql.NullString `json:"avatar_file_id"`
+	Role         string         `json:"role"`
+}
+
+func (q *Queries) ListOrgMembers(ctx context.Context, arg ListOrgMembersParams) ([]ListOrgMembersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOrgMembers, arg.OrgID, arg.QueryLimit, arg.QueryOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListOrgMembersRow{}
+	for rows.Next() {
+		var i ListOrgMembersRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Email,
+			&i.DisplayName,
+			&i.AvatarFileID,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserOrgs = `-- name: ListUserOrgs :many
+SELECT o.id, o.name, m.role, o.created_at
+FROM memberships m
+JOIN organizations o ON o.id = m.org_id
+WHERE m.user_id = $1 AND o.deleted_at IS NULL
+ORDER BY o.name, o.id
+LIMIT $2 OFFSET $3
+`
+
+type ListUserOrgsParams struct {
+	UserID      string `json:"user_id"`
+	QueryLimit  int32  `json:"query_limit"`
+	QueryOffset int32  `json:"query_offset"`
+}
+
+type ListUserOrgsRow struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (q *Queries) ListUserOrgs(ctx context.Context, arg ListUserOrgsParams) ([]ListUserOrgsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUserOrgs, arg.UserID, arg.QueryLimit, arg.QueryOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListUserOrgsRow{}
+	for rows.Next() {
+		var i ListUserOrgsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Role,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockOrgOwners = `-- name: LockOrgOwners :many
+SELECT user_id
+FROM memberships
+WHERE org_id = $1 AND role = 'owner'
+ORDER BY user_id
+FOR UPDATE
+`
+
+// Serializes changes that could leave the org without an owner.
+func (q *Queries) LockOrgOwners(ctx context.Context, orgID string) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, lockOrgOwners, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var user_id string
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateMemberRole = `-- name: UpdateMemberRole :execresult
 UPDATE memberships
-SET role = $3
+SET role = $3, version = nextval('membership_version_seq')
 WHERE org_id = $1 AND user_id = $2
 `
 
@@ -62,7 +236,8 @@ func (q *Queries) UpdateMemberRole(ctx context.Context, arg UpdateMemberRolePara
 const upsertMember = `-- name: UpsertMember :exec
 INSERT INTO memberships (org_id, user_id, role)
 VALUES ($1, $2, $3)
-ON CONFLICT (org_id, user_id) DO UPDATE SET role = EXCLUDED.role
+ON CONFLICT (org_id, user_id) DO UPDATE
+SET role = EXCLUDED.role, version = nextval('membership_version_seq')
 `
 
 type UpsertMemberParams struct {
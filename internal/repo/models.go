@@ -23,17 +23,50 @@ type ApiKey struct {
 	ExpiresAt  sql.NullTime `json:"expires_at"`
 	LastUsedAt sql.NullTime `json:"last_used_at"`
 	RevokedAt  sql.NullTime `json:"revoked_at"`
+	Role       string       `json:"role"`
 }
 
 type AuditLog struct {
-	ID        int64                 `json:"id"`
-	OrgID     string                `json:"org_id"`
-	ActorID   string                `json:"actor_id"`
-	Action    string                `json:"action"`
-	Entity    string                `json:"entity"`
-	EntityID  string                `json:"entity_id"`
-	CreatedAt time.Time             `json:"created_at"`
-	Metadata  pqtype.NullRawMessage `json:"metadata"`
+	ID             int64                 `json:"id"`
+	OrgID          string                `json:"org_id"`
+	ActorID        string                `json:"actor_id"`
+	Action         string                `json:"action"`
+	Entity         string                `json:"entity"`
+	EntityID       string                `json:"entity_id"`
+	CreatedAt      time.Time             `json:"created_at"`
+	Metadata       pqtype.NullRawMessage `json:"metadata"`
+	ImpersonatorID string                `json:"impersonator_id"`
+}
+
+type AuthAttempt struct {
+	Scope         string       `json:"scope"`
+	Key           string       `json:"key"`
+	Failures      int32        `json:"failures"`
+	LastFailureAt time.Time    `json:"last_failure_at"`
+	LockedUntil   sql.NullTime `json:"locked_until"`
+}
+
+type DataExport struct {
+	ID          string       `json:"id"`
+	Kind        string       `json:"kind"`
+	SubjectID   string       `json:"subject_id"`
+	RequestedBy string       `json:"requested_by"`
+	Status      string       `json:"status"`
+	ObjectKey   string       `json:"object_key"`
+	Error       string       `json:"error"`
+	CreatedAt   time.Time    `json:"created_at"`
+	FinishedAt  sql.NullTime `json:"finished_at"`
+	StartedAt   sql.NullTime `json:"started_at"`
+}
+
+type EmailChangeToken struct {
+	ID        string       `json:"id"`
+	UserID    string       `json:"user_id"`
+	NewEmail  string       `json:"new_email"`
+	TokenHash string       `json:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
 }
 
 type File struct {
@@ -46,19 +79,104 @@ type File struct {
 	ContentType sql.NullString        `json:"content_type"`
 	CreatedAt   time.Time             `json:"created_at"`
 	Metadata    pqtype.NullRawMessage `json:"metadata"`
+	ProjectID   sql.NullString        `json:"project_id"`
+	Version     int64                 `json:"version"`
+}
+
+type IdempotencyKey struct {
+	Principal    string        `json:"principal"`
+	OrgID        string        `json:"org_id"`
+	Key          string        `json:"key"`
+	Fingerprint  string        `json:"fingerprint"`
+	StatusCode   sql.NullInt32 `json:"status_code"`
+	ContentType  string        `json:"content_type"`
+	ResponseBody []byte        `json:"response_body"`
+	CreatedAt    time.Time     `json:"created_at"`
+	ExpiresAt    time.Time     `json:"expires_at"`
+}
+
+type Identity struct {
+	ID        string         `json:"id"`
+	UserID    string         `json:"user_id"`
+	Provider  string         `json:"provider"`
+	Subject   string         `json:"subject"`
+	Email     sql.NullString `json:"email"`
+	CreatedAt time.Time      `json:"created_at"`
 }
 
 type Membership struct {
-	OrgID  string `json:"org_id"`
-	UserID string `json:"user_id"`
-	Role   string `json:"role"`
+	OrgID   string `json:"org_id"`
+	UserID  string `json:"user_id"`
+	Role    string `json:"role"`
+	Version int64  `json:"version"`
+}
+
+type OidcAuthRequest struct {
+	StateHash    string    `json:"state_hash"`
+	Provider     string    `json:"provider"`
+	CodeVerifier string    `json:"code_verifier"`
+	Nonce        string    `json:"nonce"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type OrgDomain struct {
+	OrgID             string       `json:"org_id"`
+	Domain            string       `json:"domain"`
+	VerificationToken string       `json:"verification_token"`
+	VerifiedAt        sql.NullTime `json:"verified_at"`
+	CreatedAt         time.Time    `json:"created_at"`
+}
+
+type OrgSamlConfig struct {
+	OrgID            string          `json:"org_id"`
+	IdpEntityID      string          `json:"idp_entity_id"`
+	IdpSsoUrl        string          `json:"idp_sso_url"`
+	IdpCertificate   string          `json:"idp_certificate"`
+	AttributeMapping json.RawMessage `json:"attribute_mapping"`
+	EnforceSso       bool            `json:"enforce_sso"`
+	Enabled          bool            `json:"enabled"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
 }
 
 type Organization struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	OwnerUserID string    `json:"owner_user_id"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID                 string          `json:"id"`
+	Name               string          `json:"name"`
+	OwnerUserID        string          `json:"owner_user_id"`
+	CreatedAt          time.Time       `json:"created_at"`
+	AllowImpersonation bool            `json:"allow_impersonation"`
+	SuspendedAt        sql.NullTime    `json:"suspended_at"`
+	SuspendedReason    string          `json:"suspended_reason"`
+	Slug               string          `json:"slug"`
+	Settings           json.RawMessage `json:"settings"`
+	LogoFileID         sql.NullString  `json:"logo_file_id"`
+	PrimaryColor       string          `json:"primary_color"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+	DeletedAt          sql.NullTime    `json:"deleted_at"`
+	DeletedBy          sql.NullString  `json:"deleted_by"`
+	Version            int64           `json:"version"`
+}
+
+type OwnershipTransfer struct {
+	OrgID      string    `json:"org_id"`
+	FromUserID string    `json:"from_user_id"`
+	ToUserID   string    `json:"to_user_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+type PlanOverride struct {
+	OrgID     string    `json:"org_id"`
+	Plan      string    `json:"plan"`
+	Reason    string    `json:"reason"`
+	SetBy     string    `json:"set_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PlatformAdmin struct {
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type PasswordResetToken struct {
@@ -71,13 +189,38 @@ type PasswordResetToken struct {
 }
 
 type Project struct {
-	ID          string         `json:"id"`
-	OrgID       string         `json:"org_id"`
-	Name        string         `json:"name"`
-	Description sql.NullString `json:"description"`
-	CreatedBy   string         `json:"created_by"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
+	ID           string          `json:"id"`
+	OrgID        string          `json:"org_id"`
+	Name         string          `json:"name"`
+	Description  sql.NullString  `json:"description"`
+	CreatedBy    string          `json:"created_by"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+	DeletedAt    sql.NullTime    `json:"deleted_at"`
+	Tags         []string        `json:"tags"`
+	CustomFields json.RawMessage `json:"custom_fields"`
+	ArchivedAt   sql.NullTime    `json:"archived_at"`
+	Version      int64           `json:"version"`
+}
+
+type ProjectField struct {
+	ID        string    `json:"id"`
+	OrgID     string    `json:"org_id"`
+	Key       string    `json:"key"`
+	Label     string    `json:"label"`
+	Type      string    `json:"type"`
+	Options   []string  `json:"options"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ProjectGrant struct {
+	ID        string         `json:"id"`
+	ProjectID string         `json:"project_id"`
+	UserID    sql.NullString `json:"user_id"`
+	TeamID    sql.NullString `json:"team_id"`
+	Access    string         `json:"access"`
+	CreatedAt time.Time      `json:"created_at"`
 }
 
 type RefreshToken struct {
@@ -88,6 +231,80 @@ type RefreshToken struct {
 	RevokedAt   sql.NullTime   `json:"revoked_at"`
 	RotatedFrom sql.NullString `json:"rotated_from"`
 	CreatedAt   time.Time      `json:"created_at"`
+	FamilyID    string         `json:"family_id"`
+}
+
+type Role struct {
+	ID          string         `json:"id"`
+	OrgID       sql.NullString `json:"org_id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Permissions []string       `json:"permissions"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+type SamlAssertionsSeen struct {
+	ID        string    `json:"id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type SamlRequest struct {
+	ID        string    `json:"id"`
+	OrgID     string    `json:"org_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ScimGroup struct {
+	ID          string         `json:"id"`
+	OrgID       string         `json:"org_id"`
+	DisplayName string         `json:"display_name"`
+	ExternalID  sql.NullString `json:"external_id"`
+	Role        string         `json:"role"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+type ScimGroupMember struct {
+	GroupID string `json:"group_id"`
+	UserID  string `json:"user_id"`
+}
+
+type ScimToken struct {
+	ID          string       `json:"id"`
+	OrgID       string       `json:"org_id"`
+	Name        string       `json:"name"`
+	TokenPrefix string       `json:"token_prefix"`
+	TokenHash   string       `json:"token_hash"`
+	CreatedBy   string       `json:"created_by"`
+	CreatedAt   time.Time    `json:"created_at"`
+	LastUsedAt  sql.NullTime `json:"last_used_at"`
+	RevokedAt   sql.NullTime `json:"revoked_at"`
+}
+
+type ScimUser struct {
+	OrgID       string         `json:"org_id"`
+	UserID      string         `json:"user_id"`
+	UserName    string         `json:"user_name"`
+	ExternalID  sql.NullString `json:"external_id"`
+	DisplayName sql.NullString `json:"display_name"`
+	GivenName   sql.NullString `json:"given_name"`
+	FamilyName  sql.NullString `json:"family_name"`
+	Role        string         `json:"role"`
+	Active      bool           `json:"active"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	GeoLabel   string    `json:"geo_label"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
 }
 
 type Subscription struct {
@@ -102,11 +319,32 @@ type Subscription struct {
 	UpdatedAt            time.Time      `json:"updated_at"`
 }
 
+type Team struct {
+	ID          string    `json:"id"`
+	OrgID       string    `json:"org_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type TeamMember struct {
+	TeamID    string    `json:"team_id"`
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type User struct {
-	ID           string    `json:"id"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"password_hash"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID              string         `json:"id"`
+	Email           string         `json:"email"`
+	PasswordHash    string         `json:"password_hash"`
+	CreatedAt       time.Time      `json:"created_at"`
+	EmailVerifiedAt sql.NullTime   `json:"email_verified_at"`
+	DisplayName     string         `json:"display_name"`
+	AvatarFileID    sql.NullString `json:"avatar_file_id"`
+	Locale          string         `json:"locale"`
+	Timezone        string         `json:"timezone"`
+	SuspendedAt     sql.NullTime   `json:"suspended_at"`
+	SuspendedReason string         `json:"suspended_reason"`
 }
 
 type UserContext struct {
@@ -137,4 +375,5 @@ type WebhookEndpoint struct {
 	Active    bool      `json:"active"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	Version   int64     `json:"version"`
 }
@@ -7,21 +7,14 @@ package repo
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"time"
 )
 
-const deleteOrg = `-- name: DeleteOrg :exec
-DELETE FROM organizations
-WHERE id = $1
-`
-
-func (q *Queries) DeleteOrg(ctx context.Context, id string) error {
-	_, err := q.db.ExecContext(ctx, deleteOrg, id)
-	return err
-}
-
 const getOrg = `-- name: GetOrg :one
-SELECT id, name, owner_user_id, created_at
+SELECT id, name, owner_user_id, created_at, allow_impersonation, suspended_at, suspended_reason,
+       slug, settings, logo_file_id, primary_color, updated_at, deleted_at, deleted_by, version
 FROM organizations
 WHERE id = $1
 `
@@ -34,13 +27,24 @@ func (q *Queries) GetOrg(ctx context.Context, id string) (Organization, error) {
 		&i.Name,
 		&i.OwnerUserID,
 		&i.CreatedAt,
+		&i.AllowImpersonation,
+		&i.SuspendedAt,
+		&i.SuspendedReason,
+		&i.Slug,
+		&i.Settings,
+		&i.LogoFileID,
+		&i.PrimaryColor,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.Version,
 	)
 	return i, err
 }
 
 const insertOrg = `-- name: InsertOrg :exec
-INSERT INTO organizations (id, name, owner_user_id, created_at)
-VALUES ($1, $2, $3, $4)
+INSERT INTO organizations (id, name, owner_user_id, created_at, slug)
+VALUES ($1, $2, $3, $4, $5)
 `
 
 type InsertOrgParams struct {
@@ -48,6 +52,7 @@ type InsertOrgParams struct {
 	Name        string    `json:"name"`
 	OwnerUserID string    `json:"owner_user_id"`
 	CreatedAt   time.Time `json:"created_at"`
+	Slug        string    `json:"slug"`
 }
 
 func (q *Queries) InsertOrg(ctx context.Context, arg InsertOrgParams) error {
@@ -56,6 +61,241 @@ func (q *Queries) InsertOrg(ctx context.Context, arg InsertOrgParams) error {
 		arg.Name,
 		arg.OwnerUserID,
 		arg.CreatedAt,
+		arg.Slug,
 	)
 	return err
 }
+
+const listPurgeableOrgs = `-- name: ListPurgeableOrgs :many
+SELECT id
+FROM organizations
+WHERE deleted_at < $1
+ORDER BY deleted_at
+LIMIT $2
+`
+
+type ListPurgeableOrgsParams struct {
+	DeletedBefore sql.NullTime `json:"deleted_before"`
+	QueryLimit    int32        `json:"query_limit"`
+}
+
+// Orgs soft-deleted before @deleted_before, oldest first.
+func (q *Queries) ListPurgeableOrgs(ctx context.Context, arg ListPurgeableOrgsParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listPurgeableOrgs, arg.DeletedBefore, arg.QueryLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSoleOwnedOrgs = `-- name: ListSoleOwnedOrgs :many
+SELECT o.id
+FROM organizations o
+WHERE (o.owner_user_id = $1
+       OR EXISTS (SELECT 1 FROM memberships m WHERE m.org_id = o.id AND m.user_id = $1 AND m.role = 'owner'))
+  AND NOT EXISTS (
+    SELECT 1 FROM memberships m WHERE m.org_id = o.id AND m.role = 'owner' AND m.user_id <> $1
+  )
+ORDER BY o.id
+`
+
+// Orgs the user owns with no other member holding the owner role.
+func (q *Queries) ListSoleOwnedOrgs(ctx context.Context, ownerUserID string) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listSoleOwnedOrgs, ownerUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const orgSlugTaken = `-- name: OrgSlugTaken :one
+SELECT EXISTS (
+  SELECT 1 FROM organizations WHERE slug = $1 AND id <> $2
+)
+`
+
+type OrgSlugTakenParams struct {
+	Slug string `json:"slug"`
+	ID   string `json:"id"`
+}
+
+// Whether another org already uses the slug.
+func (q *Queries) OrgSlugTaken(ctx context.Context, arg OrgSlugTakenParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, orgSlugTaken, arg.Slug, arg.ID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const purgeOrg = `-- name: PurgeOrg :execrows
+DELETE FROM organizations
+WHERE id = $1 AND deleted_at < $2
+`
+
+type PurgeOrgParams struct {
+	ID            string       `json:"id"`
+	DeletedBefore sql.NullTime `json:"deleted_before"`
+}
+
+// Removes an org soft-deleted before @deleted_before. Memberships,
+// projects and files cascade.
+func (q *Queries) PurgeOrg(ctx context.Context, arg PurgeOrgParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeOrg, arg.ID, arg.DeletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const reassignOrgOwner = `-- name: ReassignOrgOwner :exec
+UPDATE organizations o
+SET owner_user_id = (
+  SELECT m.user_id FROM memberships m
+  WHERE m.org_id = o.id AND m.role = 'owner' AND m.user_id <> $1
+  ORDER BY m.user_id
+  LIMIT 1
+), version = version + 1
+WHERE o.owner_user_id = $1
+`
+
+// Hands organizations.owner_user_id to another owner before the user leaves.
+func (q *Queries) ReassignOrgOwner(ctx context.Context, ownerUserID string) error {
+	_, err := q.db.ExecContext(ctx, reassignOrgOwner, ownerUserID)
+	return err
+}
+
+const restoreOrg = `-- name: RestoreOrg :execrows
+UPDATE organizations
+SET deleted_at = NULL, deleted_by = NULL, updated_at = NOW(), version = version + 1
+WHERE id = $1 AND deleted_at > $2
+`
+
+type RestoreOrgParams struct {
+	ID           string       `json:"id"`
+	DeletedSince sql.NullTime `json:"deleted_since"`
+}
+
+// Undoes a soft delete made after @deleted_since.
+func (q *Queries) RestoreOrg(ctx context.Context, arg RestoreOrgParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restoreOrg, arg.ID, arg.DeletedSince)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setOrgAllowImpersonation = `-- name: SetOrgAllowImpersonation :execrows
+UPDATE organizations SET allow_impersonation = $2, version = version + 1 WHERE id = $1
+`
+
+type SetOrgAllowImpersonationParams struct {
+	ID                 string `json:"id"`
+	AllowImpersonation bool   `json:"allow_impersonation"`
+}
+
+func (q *Queries) SetOrgAllowImpersonation(ctx context.Context, arg SetOrgAllowImpersonationParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setOrgAllowImpersonation, arg.ID, arg.AllowImpersonation)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setOrgOwner = `-- name: SetOrgOwner :exec
+UPDATE organizations SET owner_user_id = $2, version = version + 1 WHERE id = $1
+`
+
+type SetOrgOwnerParams struct {
+	ID          string `json:"id"`
+	OwnerUserID string `json:"owner_user_id"`
+}
+
+func (q *Queries) SetOrgOwner(ctx context.Context, arg SetOrgOwnerParams) error {
+	_, err := q.db.ExecContext(ctx, setOrgOwner, arg.ID, arg.OwnerUserID)
+	return err
+}
+
+const softDeleteOrg = `-- name: SoftDeleteOrg :execrows
+UPDATE organizations
+SET deleted_at = NOW(), deleted_by = $1, version = version + 1
+WHERE id = $2 AND deleted_at IS NULL AND ($3::BIGINT = 0 OR version = $3)
+`
+
+type SoftDeleteOrgParams struct {
+	DeletedBy sql.NullString `json:"deleted_by"`
+	ID        string         `json:"id"`
+	IfVersion int64          `json:"if_version"`
+}
+
+// Applies only while the org is at @if_version, unless that is 0.
+func (q *Queries) SoftDeleteOrg(ctx context.Context, arg SoftDeleteOrgParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, softDeleteOrg, arg.DeletedBy, arg.ID, arg.IfVersion)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateOrgProfile = `-- name: UpdateOrgProfile :execrows
+UPDATE organizations
+SET name = $1, slug = $2, settings = $3, logo_file_id = $4, primary_color = $5,
+    updated_at = NOW(), version = version + 1
+WHERE id = $6 AND ($7::BIGINT = 0 OR version = $7)
+`
+
+type UpdateOrgProfileParams struct {
+	Name         string          `json:"name"`
+	Slug         string          `json:"slug"`
+	Settings     json.RawMessage `json:"settings"`
+	LogoFileID   sql.NullString  `json:"logo_file_id"`
+	PrimaryColor string          `json:"primary_color"`
+	ID           string          `json:"id"`
+	IfVersion    int64           `json:"if_version"`
+}
+
+// Applies only while the org is at @if_version, unless that is 0.
+func (q *Queries) UpdateOrgProfile(ctx context.Context, arg UpdateOrgProfileParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateOrgProfile,
+		arg.Name,
+		arg.Slug,
+		arg.Settings,
+		arg.LogoFileID,
+		arg.PrimaryColor,
+		arg.ID,
+		arg.IfVersion,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -8,26 +8,84 @@ package repo
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
 )
 
-const deleteProject = `-- name: DeleteProject :execresult
-DELETE FROM projects
-WHERE id = $1 AND org_id = $2
+const archiveProject = `-- name: ArchiveProject :one
+UPDATE projects
+SET archived_at = COALESCE(archived_at, NOW()), updated_at = NOW(), version = version + 1
+WHERE id = $1 AND org_id = $2 AND deleted_at IS NULL
+RETURNING id, org_id, name, description, created_by, created_at, updated_at, deleted_at, tags, custom_fields, archived_at, version
 `
 
-type DeleteProjectParams struct {
+type ArchiveProjectParams struct {
 	ID    string `json:"id"`
 	OrgID string `json:"org_id"`
 }
 
-func (q *Queries) DeleteProject(ctx context.Context, arg DeleteProjectParams) (sql.Result, error) {
-	return q.db.ExecContext(ctx, deleteProject, arg.ID, arg.OrgID)
+// Archiving an archived project keeps its first archived_at.
+func (q *Queries) ArchiveProject(ctx context.Context, arg ArchiveProjectParams) (Project, error) {
+	row := q.db.QueryRowContext(ctx, archiveProject, arg.ID, arg.OrgID)
+	var i Project
+	err := row.Scan(
+		&i.ID,
+		&i.OrgID,
+		&i.Name,
+		&i.Description,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		pq.Array(&i.Tags),
+		&i.CustomFields,
+		&i.ArchivedAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const deleteProjectGrant = `-- name: DeleteProjectGrant :execrows
+DELETE FROM project_grants
+WHERE id = $1 AND project_id = $2
+`
+
+type DeleteProjectGrantParams struct {
+	ID        string `json:"id"`
+	ProjectID string `json:"project_id"`
+}
+
+func (q *Queries) DeleteProjectGrant(ctx context.Context, arg DeleteProjectGrantParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteProjectGrant, arg.ID, arg.ProjectID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserProjectGrants = `-- name: DeleteUserProjectGrants :exec
+DELETE FROM project_grants g
+USING projects p
+WHERE g.project_id = p.id AND p.org_id = $1 AND g.user_id = $2
+`
+
+type DeleteUserProjectGrantsParams struct {
+	OrgID  string         `json:"org_id"`
+	UserID sql.NullString `json:"user_id"`
+}
+
+// Drops the user's grants on the org's projects, once they leave it.
+func (q *Queries) DeleteUserProjectGrants(ctx context.Context, arg DeleteUserProjectGrantsParams) error {
+	_, err := q.db.ExecContext(ctx, deleteUserProjectGrants, arg.OrgID, arg.UserID)
+	return err
 }
 
 const getProject = `-- name: GetProject :one
-SELECT id, org_id, name, description, created_by, created_at, updated_at
+SELECT id, org_id, name, description, created_by, created_at, updated_at, deleted_at, tags, custom_fields, archived_at, version
 FROM projects
-WHERE id = $1 AND org_id = $2
+WHERE id = $1 AND org_id = $2 AND deleted_at IS NULL
 `
 
 type GetProjectParams struct {
@@ -46,22 +104,83 @@ func (q *Queries) GetProject(ctx context.Context, arg GetProjectParams) (Project
 		&i.CreatedBy,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
+		pq.Array(&i.Tags),
+		&i.CustomFields,
+		&i.ArchivedAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const getProjectAccess = `-- name: GetProjectAccess :one
+SELECT COALESCE(MAX(CASE g.access WHEN 'admin' THEN 3 WHEN 'editor' THEN 2 ELSE 1 END), 0)::INT AS level
+FROM project_grants g
+WHERE g.project_id = $1
+  AND (g.user_id = $2 OR g.team_id IN (SELECT tm.team_id FROM team_members tm WHERE tm.user_id = $2))
+`
+
+type GetProjectAccessParams struct {
+	ProjectID string         `json:"project_id"`
+	UserID    sql.NullString `json:"user_id"`
+}
+
+// The highest grant the user holds on the project, directly or through a
+// team: 0 none, 1 viewer, 2 editor, 3 admin.
+func (q *Queries) GetProjectAccess(ctx context.Context, arg GetProjectAccessParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getProjectAccess, arg.ProjectID, arg.UserID)
+	var level int32
+	err := row.Scan(&level)
+	return level, err
+}
+
+const getProjectForUpdate = `-- name: GetProjectForUpdate :one
+SELECT id, org_id, name, description, created_by, created_at, updated_at, deleted_at, tags, custom_fields, archived_at, version
+FROM projects
+WHERE id = $1 AND org_id = $2 AND deleted_at IS NULL
+FOR UPDATE
+`
+
+type GetProjectForUpdateParams struct {
+	ID    string `json:"id"`
+	OrgID string `json:"org_id"`
+}
+
+// Locks the project until the end of the transaction.
+func (q *Queries) GetProjectForUpdate(ctx context.Context, arg GetProjectForUpdateParams) (Project, error) {
+	row := q.db.QueryRowContext(ctx, getProjectForUpdate, arg.ID, arg.OrgID)
+	var i Project
+	err := row.Scan(
+		&i.ID,
+		&i.OrgID,
+		&i.Name,
+		&i.Description,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		pq.Array(&i.Tags),
+		&i.CustomFields,
+		&i.ArchivedAt,
+		&i.Version,
 	)
 	return i, err
 }
 
 const insertProject = `-- name: InsertProject :one
-INSERT INTO projects (id, org_id, name, description, created_by, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
-RETURNING id, org_id, name, description, created_by, created_at, updated_at
+INSERT INTO projects (id, org_id, name, description, created_by, tags, custom_fields, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+RETURNING id, org_id, name, description, created_by, created_at, updated_at, deleted_at, tags, custom_fields, archived_at, version
 `
 
 type InsertProjectParams struct {
-	ID          string         `json:"id"`
-	OrgID       string         `json:"org_id"`
-	Name        string         `json:"name"`
-	Description sql.NullString `json:"description"`
-	CreatedBy   string         `json:"created_by"`
+	ID           string          `json:"id"`
+	OrgID        string          `json:"org_id"`
+	Name         string          `json:"name"`
+	Description  sql.NullString  `json:"description"`
+	CreatedBy    string          `json:"created_by"`
+	Tags         []string        `json:"tags"`
+	CustomFields json.RawMessage `json:"custom_fields"`
 }
 
 func (q *Queries) InsertProject(ctx context.Context, arg InsertProjectParams) (Project, error) {
@@ -71,6 +190,8 @@ func (q *Queries) InsertProject(ctx context.Context, arg InsertProjectParams) (P
 		arg.Name,
 		arg.Description,
 		arg.CreatedBy,
+		pq.Array(arg.Tags),
+		arg.CustomFields,
 	)
 	var i Project
 	err := row.Scan(
@@ -81,19 +202,298 @@ func (q *Queries) InsertProject(ctx context.Context, arg InsertProjectParams) (P
 		&i.CreatedBy,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
+		pq.Array(&i.Tags),
+		&i.CustomFields,
+		&i.ArchivedAt,
+		&i.Version,
 	)
 	return i, err
 }
 
-const listProjects = `-- name: ListProjects :many
-SELECT id, org_id, name, description, created_by, created_at, updated_at
-FROM projects
-WHERE org_id = $1
-ORDER BY created_at ASC
+const listGrantedProjectIDs = `-- name: ListGrantedProjectIDs :many
+SELECT p.id
+FROM projects p
+WHERE p.org_id = $1 AND p.deleted_at IS NULL AND EXISTS (
+  SELECT 1 FROM project_grants g
+  WHERE g.project_id = p.id
+    AND (g.user_id = $2 OR g.team_id IN (SELECT tm.team_id FROM team_members tm WHERE tm.user_id = $2))
+)
+`
+
+type ListGrantedProjectIDsParams struct {
+	OrgID  string         `json:"org_id"`
+	UserID sql.NullString `json:"user_id"`
+}
+
+// Projects granted to the user directly or through one of their teams.
+func (q *Queries) ListGrantedProjectIDs(ctx context.Context, arg ListGrantedProjectIDsParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listGrantedProjectIDs, arg.OrgID, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProjectGrants = `-- name: ListProjectGrants :many
+SELECT id, project_id, user_id, team_id, access, created_at
+FROM project_grants
+WHERE project_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) ListProjectGrants(ctx context.Context, projectID string) ([]ProjectGrant, error) {
+	rows, err := q.db.QueryContext(ctx, listProjectGrants, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ProjectGrant{}
+	for rows.Next() {
+		var i ProjectGrant
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.UserID,
+			&i.TeamID,
+			&i.Access,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProjectsByCreatedAsc = `-- name: ListProjectsByCreatedAsc :many
+SELECT p.id, p.org_id, p.name, p.description, p.created_by, p.created_at, p.updated_at, p.deleted_at, p.tags, p.custom_fields, p.archived_at, p.version
+FROM projects p
+WHERE p.org_id = $1 AND p.deleted_at IS NULL
+  AND ($2::BOOLEAN OR EXISTS (
+    SELECT 1 FROM project_grants g
+    WHERE g.project_id = p.id
+      AND (g.user_id = $3 OR g.team_id IN (SELECT tm.team_id FROM team_members tm WHERE tm.user_id = $3))
+  ))
+  AND ($4::TEXT = '' OR to_tsvector('simple', p.name || ' ' || COALESCE(p.description, '')) @@ websearch_to_tsquery('simple', $4::TEXT))
+  AND ($5::TEXT = '' OR p.tags @> ARRAY[$5::TEXT])
+  AND ($6::TEXT = '' OR p.created_by = $6::TEXT)
+  AND ($7::BOOLEAN IS NULL OR (p.archived_at IS NOT NULL) = $7::BOOLEAN)
+  AND ($8::TEXT = '' OR (p.created_at, p.id) > ($9::TIMESTAMPTZ, $8::TEXT))
+ORDER BY p.created_at ASC, p.id ASC
+LIMIT $10
+`
+
+type ListProjectsByCreatedAscParams struct {
+	OrgID       string         `json:"org_id"`
+	AllProjects bool           `json:"all_projects"`
+	UserID      sql.NullString `json:"user_id"`
+	Q           string         `json:"q"`
+	Tag         string         `json:"tag"`
+	CreatedBy   string         `json:"created_by"`
+	Archived    sql.NullBool   `json:"archived"`
+	AfterID     string         `json:"after_id"`
+	AfterTime   time.Time      `json:"after_time"`
+	QueryLimit  int32          `json:"query_limit"`
+}
+
+// Like ListProjectsByNameAsc, in created_at ASC order.
+func (q *Queries) ListProjectsByCreatedAsc(ctx context.Context, arg ListProjectsByCreatedAscParams) ([]Project, error) {
+	rows, err := q.db.QueryContext(ctx, listProjectsByCreatedAsc,
+		arg.OrgID,
+		arg.AllProjects,
+		arg.UserID,
+		arg.Q,
+		arg.Tag,
+		arg.CreatedBy,
+		arg.Archived,
+		arg.AfterID,
+		arg.AfterTime,
+		arg.QueryLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Project{}
+	for rows.Next() {
+		var i Project
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrgID,
+			&i.Name,
+			&i.Description,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			pq.Array(&i.Tags),
+			&i.CustomFields,
+			&i.ArchivedAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProjectsByCreatedDesc = `-- name: ListProjectsByCreatedDesc :many
+SELECT p.id, p.org_id, p.name, p.description, p.created_by, p.created_at, p.updated_at, p.deleted_at, p.tags, p.custom_fields, p.archived_at, p.version
+FROM projects p
+WHERE p.org_id = $1 AND p.deleted_at IS NULL
+  AND ($2::BOOLEAN OR EXISTS (
+    SELECT 1 FROM project_grants g
+    WHERE g.project_id = p.id
+      AND (g.user_id = $3 OR g.team_id IN (SELECT tm.team_id FROM team_members tm WHERE tm.user_id = $3))
+  ))
+  AND ($4::TEXT = '' OR to_tsvector('simple', p.name || ' ' || COALESCE(p.description, '')) @@ websearch_to_tsquery('simple', $4::TEXT))
+  AND ($5::TEXT = '' OR p.tags @> ARRAY[$5::TEXT])
+  AND ($6::TEXT = '' OR p.created_by = $6::TEXT)
+  AND ($7::BOOLEAN IS NULL OR (p.archived_at IS NOT NULL) = $7::BOOLEAN)
+  AND ($8::TEXT = '' OR (p.created_at, p.id) < ($9::TIMESTAMPTZ, $8::TEXT))
+ORDER BY p.created_at DESC, p.id DESC
+LIMIT $10
+`
+
+type ListProjectsByCreatedDescParams struct {
+	OrgID       string         `json:"org_id"`
+	AllProjects bool           `json:"all_projects"`
+	UserID      sql.NullString `json:"user_id"`
+	Q           string         `json:"q"`
+	Tag         string         `json:"tag"`
+	CreatedBy   string         `json:"created_by"`
+	Archived    sql.NullBool   `json:"archived"`
+	AfterID     string         `json:"after_id"`
+	AfterTime   time.Time      `json:"after_time"`
+	QueryLimit  int32          `json:"query_limit"`
+}
+
+// Like ListProjectsByNameAsc, in created_at DESC order.
+func (q *Queries) ListProjectsByCreatedDesc(ctx context.Context, arg ListProjectsByCreatedDescParams) ([]Project, error) {
+	rows, err := q.db.QueryContext(ctx, listProjectsByCreatedDesc,
+		arg.OrgID,
+		arg.AllProjects,
+		arg.UserID,
+		arg.Q,
+		arg.Tag,
+		arg.CreatedBy,
+		arg.Archived,
+		arg.AfterID,
+		arg.AfterTime,
+		arg.QueryLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Project{}
+	for rows.Next() {
+		var i Project
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrgID,
+			&i.Name,
+			&i.Description,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			pq.Array(&i.Tags),
+			&i.CustomFields,
+			&i.ArchivedAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProjectsByNameAsc = `-- name: ListProjectsByNameAsc :many
+SELECT p.id, p.org_id, p.name, p.description, p.created_by, p.created_at, p.updated_at, p.deleted_at, p.tags, p.custom_fields, p.archived_at, p.version
+FROM projects p
+WHERE p.org_id = $1 AND p.deleted_at IS NULL
+  AND ($2::BOOLEAN OR EXISTS (
+    SELECT 1 FROM project_grants g
+    WHERE g.project_id = p.id
+      AND (g.user_id = $3 OR g.team_id IN (SELECT tm.team_id FROM team_members tm WHERE tm.user_id = $3))
+  ))
+  AND ($4::TEXT = '' OR to_tsvector('simple', p.name || ' ' || COALESCE(p.description, '')) @@ websearch_to_tsquery('simple', $4::TEXT))
+  AND ($5::TEXT = '' OR p.tags @> ARRAY[$5::TEXT])
+  AND ($6::TEXT = '' OR p.created_by = $6::TEXT)
+  AND ($7::BOOLEAN IS NULL OR (p.archived_at IS NOT NULL) = $7::BOOLEAN)
+  AND ($8::TEXT = '' OR (p.name, p.id) > ($9::TEXT, $8::TEXT))
+ORDER BY p.name ASC, p.id ASC
+LIMIT $10
 `
 
-func (q *Queries) ListProjects(ctx context.Context, orgID string) ([]Project, error) {
-	rows, err := q.db.QueryContext(ctx, listProjects, orgID)
+type ListProjectsByNameAscParams struct {
+	OrgID       string         `json:"org_id"`
+	AllProjects bool           `json:"all_projects"`
+	UserID      sql.NullString `json:"user_id"`
+	Q           string         `json:"q"`
+	Tag         string         `json:"tag"`
+	CreatedBy   string         `json:"created_by"`
+	Archived    sql.NullBool   `json:"archived"`
+	AfterID     string         `json:"after_id"`
+	AfterName   string         `json:"after_name"`
+	QueryLimit  int32          `json:"query_limit"`
+}
+
+// One page of the projects the user can see (every one with
+// all_projects), filtered, in name ASC order then by id. The page
+// starts after the cursor (@after_id, @after_name) unless @after_id is empty.
+// Each sort has its own query so the keyset and the ORDER BY can use the
+// matching (org_id, column, id) index.
+func (q *Queries) ListProjectsByNameAsc(ctx context.Context, arg ListProjectsByNameAscParams) ([]Project, error) {
+	rows, err := q.db.QueryContext(ctx, listProjectsByNameAsc,
+		arg.OrgID,
+		arg.AllProjects,
+		arg.UserID,
+		arg.Q,
+		arg.Tag,
+		arg.CreatedBy,
+		arg.Archived,
+		arg.AfterID,
+		arg.AfterName,
+		arg.QueryLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -109,6 +509,11 @@ func (q *Queries) ListProjects(ctx context.Context, orgID string) ([]Project, er
 			&i.CreatedBy,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DeletedAt,
+			pq.Array(&i.Tags),
+			&i.CustomFields,
+			&i.ArchivedAt,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -123,28 +528,422 @@ func (q *Queries) ListProjects(ctx context.Context, orgID string) ([]Project, er
 	return items, nil
 }
 
+const listProjectsByNameDesc = `-- name: ListProjectsByNameDesc :many
+SELECT p.id, p.org_id, p.name, p.description, p.created_by, p.created_at, p.updated_at, p.deleted_at, p.tags, p.custom_fields, p.archived_at, p.version
+FROM projects p
+WHERE p.org_id = $1 AND p.deleted_at IS NULL
+  AND ($2::BOOLEAN OR EXISTS (
+    SELECT 1 FROM project_grants g
+    WHERE g.project_id = p.id
+      AND (g.user_id = $3 OR g.team_id IN (SELECT tm.team_id FROM team_members tm WHERE tm.user_id = $3))
+  ))
+  AND ($4::TEXT = '' OR to_tsvector('simple', p.name || ' ' || COALESCE(p.description, '')) @@ websearch_to_tsquery('simple', $4::TEXT))
+  AND ($5::TEXT = '' OR p.tags @> ARRAY[$5::TEXT])
+  AND ($6::TEXT = '' OR p.created_by = $6::TEXT)
+  AND ($7::BOOLEAN IS NULL OR (p.archived_at IS NOT NULL) = $7::BOOLEAN)
+  AND ($8::TEXT = '' OR (p.name, p.id) < ($9::TEXT, $8::TEXT))
+ORDER BY p.name DESC, p.id DESC
+LIMIT $10
+`
+
+type ListProjectsByNameDescParams struct {
+	OrgID       string         `json:"org_id"`
+	AllProjects bool           `json:"all_projects"`
+	UserID      sql.NullString `json:"user_id"`
+	Q           string         `json:"q"`
+	Tag         string         `json:"tag"`
+	CreatedBy   string         `json:"created_by"`
+	Archived    sql.NullBool   `json:"archived"`
+	AfterID     string         `json:"after_id"`
+	AfterName   string         `json:"after_name"`
+	QueryLimit  int32          `json:"query_limit"`
+}
+
+// Like ListProjectsByNameAsc, in name DESC order.
+func (q *Queries) ListProjectsByNameDesc(ctx context.Context, arg ListProjectsByNameDescParams) ([]Project, error) {
+	rows, err := q.db.QueryContext(ctx, listProjectsByNameDesc,
+		arg.OrgID,
+		arg.AllProjects,
+		arg.UserID,
+		arg.Q,
+		arg.Tag,
+		arg.CreatedBy,
+		arg.Archived,
+		arg.AfterID,
+		arg.AfterName,
+		arg.QueryLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Project{}
+	for rows.Next() {
+		var i Project
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrgID,
+			&i.Name,
+			&i.Description,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			pq.Array(&i.Tags),
+			&i.CustomFields,
+			&i.ArchivedAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProjectsByUpdatedAsc = `-- name: ListProjectsByUpdatedAsc :many
+SELECT p.id, p.org_id, p.name, p.description, p.created_by, p.created_at, p.updated_at, p.deleted_at, p.tags, p.custom_fields, p.archived_at, p.version
+FROM projects p
+WHERE p.org_id = $1 AND p.deleted_at IS NULL
+  AND ($2::BOOLEAN OR EXISTS (
+    SELECT 1 FROM project_grants g
+    WHERE g.project_id = p.id
+      AND (g.user_id = $3 OR g.team_id IN (SELECT tm.team_id FROM team_members tm WHERE tm.user_id = $3))
+  ))
+  AND ($4::TEXT = '' OR to_tsvector('simple', p.name || ' ' || COALESCE(p.description, '')) @@ websearch_to_tsquery('simple', $4::TEXT))
+  AND ($5::TEXT = '' OR p.tags @> ARRAY[$5::TEXT])
+  AND ($6::TEXT = '' OR p.created_by = $6::TEXT)
+  AND ($7::BOOLEAN IS NULL OR (p.archived_at IS NOT NULL) = $7::BOOLEAN)
+  AND ($8::TEXT = '' OR (p.updated_at, p.id) > ($9::TIMESTAMPTZ, $8::TEXT))
+ORDER BY p.updated_at ASC, p.id ASC
+LIMIT $10
+`
+
+type ListProjectsByUpdatedAscParams struct {
+	OrgID       string         `json:"org_id"`
+	AllProjects bool           `json:"all_projects"`
+	UserID      sql.NullString `json:"user_id"`
+	Q           string         `json:"q"`
+	Tag         string         `json:"tag"`
+	CreatedBy   string         `json:"created_by"`
+	Archived    sql.NullBool   `json:"archived"`
+	AfterID     string         `json:"after_id"`
+	AfterTime   time.Time      `json:"after_time"`
+	QueryLimit  int32          `json:"query_limit"`
+}
+
+// Like ListProjectsByNameAsc, in updated_at ASC order.
+func (q *Queries) ListProjectsByUpdatedAsc(ctx context.Context, arg ListProjectsByUpdatedAscParams) ([]Project, error) {
+	rows, err := q.db.QueryContext(ctx, listProjectsByUpdatedAsc,
+		arg.OrgID,
+		arg.AllProjects,
+		arg.UserID,
+		arg.Q,
+		arg.Tag,
+		arg.CreatedBy,
+		arg.Archived,
+		arg.AfterID,
+		arg.AfterTime,
+		arg.QueryLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Project{}
+	for rows.Next() {
+		var i Project
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrgID,
+			&i.Name,
+			&i.Description,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			pq.Array(&i.Tags),
+			&i.CustomFields,
+			&i.ArchivedAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProjectsByUpdatedDesc = `-- name: ListProjectsByUpdatedDesc :many
+SELECT p.id, p.org_id, p.name, p.description, p.created_by, p.created_at, p.updated_at, p.deleted_at, p.tags, p.custom_fields, p.archived_at, p.version
+FROM projects p
+WHERE p.org_id = $1 AND p.deleted_at IS NULL
+  AND ($2::BOOLEAN OR EXISTS (
+    SELECT 1 FROM project_grants g
+    WHERE g.project_id = p.id
+      AND (g.user_id = $3 OR g.team_id IN (SELECT tm.team_id FROM team_members tm WHERE tm.user_id = $3))
+  ))
+  AND ($4::TEXT = '' OR to_tsvector('simple', p.name || ' ' || COALESCE(p.description, '')) @@ websearch_to_tsquery('simple', $4::TEXT))
+  AND ($5::TEXT = '' OR p.tags @> ARRAY[$5::TEXT])
+  AND ($6::TEXT = '' OR p.created_by = $6::TEXT)
+  AND ($7::BOOLEAN IS NULL OR (p.archived_at IS NOT NULL) = $7::BOOLEAN)
+  AND ($8::TEXT = '' OR (p.updated_at, p.id) < ($9::TIMESTAMPTZ, $8::TEXT))
+ORDER BY p.updated_at DESC, p.id DESC
+LIMIT $10
+`
+
+type ListProjectsByUpdatedDescParams struct {
+	OrgID       string         `json:"org_id"`
+	AllProjects bool           `json:"all_projects"`
+	UserID      sql.NullString `json:"user_id"`
+	Q           string         `json:"q"`
+	Tag         string         `json:"tag"`
+	CreatedBy   string         `json:"created_by"`
+	Archived    sql.NullBool   `json:"archived"`
+	AfterID     string         `json:"after_id"`
+	AfterTime   time.Time      `json:"after_time"`
+	QueryLimit  int32          `json:"query_limit"`
+}
+
+// Like ListProjectsByNameAsc, in updated_at DESC order.
+func (q *Queries) ListProjectsByUpdatedDesc(ctx context.Context, arg ListProjectsByUpdatedDescParams) ([]Project, error) {
+	rows, err := q.db.QueryContext(ctx, listProjectsByUpdatedDesc,
+		arg.OrgID,
+		arg.AllProjects,
+		arg.UserID,
+		arg.Q,
+		arg.Tag,
+		arg.CreatedBy,
+		arg.Archived,
+		arg.AfterID,
+		arg.AfterTime,
+		arg.QueryLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Project{}
+	for rows.Next() {
+		var i Project
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrgID,
+			&i.Name,
+			&i.Description,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			pq.Array(&i.Tags),
+			&i.CustomFields,
+			&i.ArchivedAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPurgeableProjects = `-- name: ListPurgeableProjects :many
+SELECT id, org_id
+FROM projects
+WHERE deleted_at < $1
+ORDER BY deleted_at
+LIMIT $2
+`
+
+type ListPurgeableProjectsParams struct {
+	DeletedBefore sql.NullTime `json:"deleted_before"`
+	QueryLimit    int32        `json:"query_limit"`
+}
+
+type ListPurgeableProjectsRow struct {
+	ID    string `json:"id"`
+	OrgID string `json:"org_id"`
+}
+
+// Projects soft-deleted before @deleted_before, oldest first.
+func (q *Queries) ListPurgeableProjects(ctx context.Context, arg ListPurgeableProjectsParams) ([]ListPurgeableProjectsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPurgeableProjects, arg.DeletedBefore, arg.QueryLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListPurgeableProjectsRow{}
+	for rows.Next() {
+		var i ListPurgeableProjectsRow
+		if err := rows.Scan(&i.ID, &i.OrgID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeProject = `-- name: PurgeProject :execrows
+DELETE FROM projects
+WHERE id = $1 AND deleted_at < $2
+`
+
+type PurgeProjectParams struct {
+	ID            string       `json:"id"`
+	DeletedBefore sql.NullTime `json:"deleted_before"`
+}
+
+// Removes a project soft-deleted before @deleted_before; its files and
+// grants cascade.
+func (q *Queries) PurgeProject(ctx context.Context, arg PurgeProjectParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeProject, arg.ID, arg.DeletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreProject = `-- name: RestoreProject :one
+UPDATE projects
+SET deleted_at = NULL, updated_at = NOW(), version = version + 1
+WHERE id = $1 AND org_id = $2 AND deleted_at > $3
+RETURNING id, org_id, name, description, created_by, created_at, updated_at, deleted_at, tags, custom_fields, archived_at, version
+`
+
+type RestoreProjectParams struct {
+	ID           string       `json:"id"`
+	OrgID        string       `json:"org_id"`
+	DeletedSince sql.NullTime `json:"deleted_since"`
+}
+
+// Undoes a soft delete made after @deleted_since.
+func (q *Queries) RestoreProject(ctx context.Context, arg RestoreProjectParams) (Project, error) {
+	row := q.db.QueryRowContext(ctx, restoreProject, arg.ID, arg.OrgID, arg.DeletedSince)
+	var i Project
+	err := row.Scan(
+		&i.ID,
+		&i.OrgID,
+		&i.Name,
+		&i.Description,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		pq.Array(&i.Tags),
+		&i.CustomFields,
+		&i.ArchivedAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const softDeleteProject = `-- name: SoftDeleteProject :execrows
+UPDATE projects
+SET deleted_at = NOW(), version = version + 1
+WHERE id = $1 AND org_id = $2 AND deleted_at IS NULL
+  AND ($3::BIGINT = 0 OR version = $3)
+`
+
+type SoftDeleteProjectParams struct {
+	ID        string `json:"id"`
+	OrgID     string `json:"org_id"`
+	IfVersion int64  `json:"if_version"`
+}
+
+// Applies only while the project is at @if_version, unless that is 0.
+func (q *Queries) SoftDeleteProject(ctx context.Context, arg SoftDeleteProjectParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, softDeleteProject, arg.ID, arg.OrgID, arg.IfVersion)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const unarchiveProject = `-- name: UnarchiveProject :one
+UPDATE projects
+SET archived_at = NULL, updated_at = NOW(), version = version + 1
+WHERE id = $1 AND org_id = $2 AND deleted_at IS NULL
+RETURNING id, org_id, name, description, created_by, created_at, updated_at, deleted_at, tags, custom_fields, archived_at, version
+`
+
+type UnarchiveProjectParams struct {
+	ID    string `json:"id"`
+	OrgID string `json:"org_id"`
+}
+
+func (q *Queries) UnarchiveProject(ctx context.Context, arg UnarchiveProjectParams) (Project, error) {
+	row := q.db.QueryRowContext(ctx, unarchiveProject, arg.ID, arg.OrgID)
+	var i Project
+	err := row.Scan(
+		&i.ID,
+		&i.OrgID,
+		&i.Name,
+		&i.Description,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		pq.Array(&i.Tags),
+		&i.CustomFields,
+		&i.ArchivedAt,
+		&i.Version,
+	)
+	return i, err
+}
+
 const updateProject = `-- name: UpdateProject :one
 UPDATE projects
-SET name = COALESCE(NULLIF($3, ''), name),
+SET name = $3,
     description = $4,
-    updated_at = NOW()
-WHERE id = $1 AND org_id = $2
-RETURNING id, org_id, name, description, created_by, created_at, updated_at
+    tags = $5,
+    custom_fields = $6,
+    updated_at = NOW(),
+    version = version + 1
+WHERE id = $1 AND org_id = $2 AND deleted_at IS NULL
+RETURNING id, org_id, name, description, created_by, created_at, updated_at, deleted_at, tags, custom_fields, archived_at, version
 `
 
 type UpdateProjectParams struct {
-	ID          string         `json:"id"`
-	OrgID       string         `json:"org_id"`
-	Column3     interface{}    `json:"column_3"`
-	Description sql.NullString `json:"description"`
+	ID           string          `json:"id"`
+	OrgID        string          `json:"org_id"`
+	Name         string          `json:"name"`
+	Description  sql.NullString  `json:"description"`
+	Tags         []string        `json:"tags"`
+	CustomFields json.RawMessage `json:"custom_fields"`
 }
 
 func (q *Queries) UpdateProject(ctx context.Context, arg UpdateProjectParams) (Project, error) {
 	row := q.db.QueryRowContext(ctx, updateProject,
 		arg.ID,
 		arg.OrgID,
-		arg.Column3,
+		arg.Name,
 		arg.Description,
+		pq.Array(arg.Tags),
+		arg.CustomFields,
 	)
 	var i Project
 	err := row.Scan(
@@ -155,6 +954,65 @@ func (q *Queries) UpdateProject(ctx context.Context, arg UpdateProjectParams) (P
 		&i.CreatedBy,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
+		pq.Array(&i.Tags),
+		&i.CustomFields,
+		&i.ArchivedAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const upsertProjectTeamGrant = `-- name: UpsertProjectTeamGrant :one
+INSERT INTO project_grants (project_id, team_id, access)
+VALUES ($1, $2, $3)
+ON CONFLICT (project_id, team_id) WHERE team_id IS NOT NULL DO UPDATE SET access = EXCLUDED.access
+RETURNING id, project_id, user_id, team_id, access, created_at
+`
+
+type UpsertProjectTeamGrantParams struct {
+	ProjectID string         `json:"project_id"`
+	TeamID    sql.NullString `json:"team_id"`
+	Access    string         `json:"access"`
+}
+
+func (q *Queries) UpsertProjectTeamGrant(ctx context.Context, arg UpsertProjectTeamGrantParams) (ProjectGrant, error) {
+	row := q.db.QueryRowContext(ctx, upsertProjectTeamGrant, arg.ProjectID, arg.TeamID, arg.Access)
+	var i ProjectGrant
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.UserID,
+		&i.TeamID,
+		&i.Access,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const upsertProjectUserGrant = `-- name: UpsertProjectUserGrant :one
+INSERT INTO project_grants (project_id, user_id, access)
+VALUES ($1, $2, $3)
+ON CONFLICT (project_id, user_id) WHERE user_id IS NOT NULL DO UPDATE SET access = EXCLUDED.access
+RETURNING id, project_id, user_id, team_id, access, created_at
+`
+
+type UpsertProjectUserGrantParams struct {
+	ProjectID string         `json:"project_id"`
+	UserID    sql.NullString `json:"user_id"`
+	Access    string         `json:"access"`
+}
+
+func (q *Queries) UpsertProjectUserGrant(ctx context.Context, arg UpsertProjectUserGrantParams) (ProjectGrant, error) {
+	row := q.db.QueryRowContext(ctx, upsertProjectUserGrant, arg.ProjectID, arg.UserID, arg.Access)
+	var i ProjectGrant
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.UserID,
+		&i.TeamID,
+		&i.Access,
+		&i.CreatedAt,
 	)
 	return i, err
 }
@@ -11,34 +11,40 @@ import (
 	"time"
 )
 
-const getRefreshToken = `-- name: GetRefreshToken :one
-SELECT user_id, token_hash, expires_at, revoked_at
-FROM refresh_tokens
-WHERE id = $1
+const getRefreshTokenForUpdate = `-- name: GetRefreshTokenForUpdate :one
+SELECT r.user_id, r.token_hash, r.expires_at, r.revoked_at, r.family_id,
+       EXISTS (SELECT 1 FROM refresh_tokens c WHERE c.rotated_from = r.id) AS rotated
+FROM refresh_tokens r
+WHERE r.id = $1
+FOR UPDATE
 `
 
-type GetRefreshTokenRow struct {
+type GetRefreshTokenForUpdateRow struct {
 	UserID    string       `json:"user_id"`
 	TokenHash string       `json:"token_hash"`
 	ExpiresAt time.Time    `json:"expires_at"`
 	RevokedAt sql.NullTime `json:"revoked_at"`
+	FamilyID  string       `json:"family_id"`
+	Rotated   bool         `json:"rotated"`
 }
 
-func (q *Queries) GetRefreshToken(ctx context.Context, id string) (GetRefreshTokenRow, error) {
-	row := q.db.QueryRowContext(ctx, getRefreshToken, id)
-	var i GetRefreshTokenRow
+func (q *Queries) GetRefreshTokenForUpdate(ctx context.Context, id string) (GetRefreshTokenForUpdateRow, error) {
+	row := q.db.QueryRowContext(ctx, getRefreshTokenForUpdate, id)
+	var i GetRefreshTokenForUpdateRow
 	err := row.Scan(
 		&i.UserID,
 		&i.TokenHash,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.FamilyID,
+		&i.Rotated,
 	)
 	return i, err
 }
 
 const insertRefreshToken = `-- name: InsertRefreshToken :exec
-INSERT INTO refresh_tokens (id, user_id, token_hash, expires_at, rotated_from)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO refresh_tokens (id, user_id, token_hash, expires_at, rotated_from, family_id)
+VALUES ($1, $2, $3, $4, $5, $6)
 `
 
 type InsertRefreshTokenParams struct {
@@ -47,6 +53,7 @@ type InsertRefreshTokenParams struct {
 	TokenHash   string         `json:"token_hash"`
 	ExpiresAt   time.Time      `json:"expires_at"`
 	RotatedFrom sql.NullString `json:"rotated_from"`
+	FamilyID    string         `json:"family_id"`
 }
 
 func (q *Queries) InsertRefreshToken(ctx context.Context, arg InsertRefreshTokenParams) error {
@@ -56,6 +63,7 @@ func (q *Queries) InsertRefreshToken(ctx context.Context, arg InsertRefreshToken
 		arg.TokenHash,
 		arg.ExpiresAt,
 		arg.RotatedFrom,
+		arg.FamilyID,
 	)
 	return err
 }
@@ -81,3 +89,13 @@ func (q *Queries) RevokeToken(ctx context.Context, id string) error {
 	_, err := q.db.ExecContext(ctx, revokeToken, id)
 	return err
 }
+
+const revokeTokenFamily = `-- name: RevokeTokenFamily :execresult
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE family_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeTokenFamily(ctx context.Context, familyID string) (sql.Result, error) {
+	return q.db.ExecContext(ctx, revokeTokenFamily, familyID)
+}
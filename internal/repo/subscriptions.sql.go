@@ -21,6 +21,47 @@ func (q *Queries) CancelSubscription(ctx context.Context, stripeSubscriptionID s
 	return err
 }
 
+const deleteOrgSubscription = `-- name: DeleteOrgSubscription :exec
+DELETE FROM subscriptions
+WHERE org_id = $1
+`
+
+func (q *Queries) DeleteOrgSubscription(ctx context.Context, orgID string) error {
+	_, err := q.db.ExecContext(ctx, deleteOrgSubscription, orgID)
+	return err
+}
+
+const deletePlanOverride = `-- name: DeletePlanOverride :execrows
+DELETE FROM plan_overrides WHERE org_id = $1
+`
+
+func (q *Queries) DeletePlanOverride(ctx context.Context, orgID string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePlanOverride, orgID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getPlanOverride = `-- name: GetPlanOverride :one
+SELECT org_id, plan, reason, set_by, created_at
+FROM plan_overrides
+WHERE org_id = $1
+`
+
+func (q *Queries) GetPlanOverride(ctx context.Context, orgID string) (PlanOverride, error) {
+	row := q.db.QueryRowContext(ctx, getPlanOverride, orgID)
+	var i PlanOverride
+	err := row.Scan(
+		&i.OrgID,
+		&i.Plan,
+		&i.Reason,
+		&i.SetBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const getSubscriptionByOrg = `-- name: GetSubscriptionByOrg :one
 SELECT id, org_id, stripe_customer_id, stripe_subscription_id, status, plan, current_period_end, created_at, updated_at
 FROM subscriptions
@@ -90,6 +131,33 @@ func (q *Queries) UpdateSubscriptionStatus(ctx context.Context, arg UpdateSubscr
 	return err
 }
 
+const upsertPlanOverride = `-- name: UpsertPlanOverride :exec
+INSERT INTO plan_overrides (org_id, plan, reason, set_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (org_id) DO UPDATE SET
+    plan = EXCLUDED.plan,
+    reason = EXCLUDED.reason,
+    set_by = EXCLUDED.set_by,
+    created_at = now()
+`
+
+type UpsertPlanOverrideParams struct {
+	OrgID  string `json:"org_id"`
+	Plan   string `json:"plan"`
+	Reason string `json:"reason"`
+	SetBy  string `json:"set_by"`
+}
+
+func (q *Queries) UpsertPlanOverride(ctx context.Context, arg UpsertPlanOverrideParams) error {
+	_, err := q.db.ExecContext(ctx, upsertPlanOverride,
+		arg.OrgID,
+		arg.Plan,
+		arg.Reason,
+		arg.SetBy,
+	)
+	return err
+}
+
 const upsertSubscription = `-- name: UpsertSubscription :exec
 INSERT INTO subscriptions (org_id, stripe_customer_id, stripe_subscription_id, status, plan, current_period_end)
 VALUES ($1, $2, $3, $4, $5, $6)
@@ -7,44 +7,143 @@ package repo
 
 import (
 	"context"
+	"database/sql"
 	"time"
 )
 
+const anonymizeAPIKeysCreatedBy = `-- name: AnonymizeAPIKeysCreatedBy :exec
+UPDATE api_keys SET created_by = 'deleted-user' WHERE created_by = $1
+`
+
+func (q *Queries) AnonymizeAPIKeysCreatedBy(ctx context.Context, createdBy string) error {
+	_, err := q.db.ExecContext(ctx, anonymizeAPIKeysCreatedBy, createdBy)
+	return err
+}
+
+const anonymizeAuditActor = `-- name: AnonymizeAuditActor :exec
+UPDATE audit_logs
+SET actor_id = 'deleted-user',
+    metadata = metadata #- '{before,ip}' #- '{before,user_agent}' #- '{after,ip}' #- '{after,user_agent}'
+WHERE actor_id = $1
+`
+
+func (q *Queries) AnonymizeAuditActor(ctx context.Context, actorID string) error {
+	_, err := q.db.ExecContext(ctx, anonymizeAuditActor, actorID)
+	return err
+}
+
+const anonymizeAuditSubject = `-- name: AnonymizeAuditSubject :exec
+UPDATE audit_logs
+SET entity_id = 'deleted-user'
+WHERE entity_id = $1 OR (entity = 'account' AND entity_id = $2)
+`
+
+type AnonymizeAuditSubjectParams struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+func (q *Queries) AnonymizeAuditSubject(ctx context.Context, arg AnonymizeAuditSubjectParams) error {
+	_, err := q.db.ExecContext(ctx, anonymizeAuditSubject, arg.UserID, arg.Email)
+	return err
+}
+
+const anonymizeFilesUploadedBy = `-- name: AnonymizeFilesUploadedBy :exec
+UPDATE files SET uploaded_by = 'deleted-user', version = version + 1 WHERE uploaded_by = $1
+`
+
+func (q *Queries) AnonymizeFilesUploadedBy(ctx context.Context, uploadedBy string) error {
+	_, err := q.db.ExecContext(ctx, anonymizeFilesUploadedBy, uploadedBy)
+	return err
+}
+
+const anonymizeProjectsCreatedBy = `-- name: AnonymizeProjectsCreatedBy :exec
+UPDATE projects SET created_by = 'deleted-user', version = version + 1 WHERE created_by = $1
+`
+
+// Account deletion: rows that outlive the user move to the 'deleted-user'
+// placeholder; everything else cascades from DELETE FROM users.
+func (q *Queries) AnonymizeProjectsCreatedBy(ctx context.Context, createdBy string) error {
+	_, err := q.db.ExecContext(ctx, anonymizeProjectsCreatedBy, createdBy)
+	return err
+}
+
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM users WHERE id = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, deleteUser, id)
+	return err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, password_hash
+SELECT id, email, password_hash, email_verified_at, display_name, avatar_file_id, locale, timezone, suspended_at
 FROM users
 WHERE email = $1
 `
 
 type GetUserByEmailRow struct {
-	ID           string `json:"id"`
-	Email        string `json:"email"`
-	PasswordHash string `json:"password_hash"`
+	ID              string         `json:"id"`
+	Email           string         `json:"email"`
+	PasswordHash    string         `json:"password_hash"`
+	EmailVerifiedAt sql.NullTime   `json:"email_verified_at"`
+	DisplayName     string         `json:"display_name"`
+	AvatarFileID    sql.NullString `json:"avatar_file_id"`
+	Locale          string         `json:"locale"`
+	Timezone        string         `json:"timezone"`
+	SuspendedAt     sql.NullTime   `json:"suspended_at"`
 }
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (GetUserByEmailRow, error) {
 	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
 	var i GetUserByEmailRow
-	err := row.Scan(&i.ID, &i.Email, &i.PasswordHash)
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.EmailVerifiedAt,
+		&i.DisplayName,
+		&i.AvatarFileID,
+		&i.Locale,
+		&i.Timezone,
+		&i.SuspendedAt,
+	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, email, password_hash
+SELECT id, email, password_hash, email_verified_at, display_name, avatar_file_id, locale, timezone, suspended_at
 FROM users
 WHERE id = $1
 `
 
 type GetUserByIDRow struct {
-	ID           string `json:"id"`
-	Email        string `json:"email"`
-	PasswordHash string `json:"password_hash"`
+	ID              string         `json:"id"`
+	Email           string         `json:"email"`
+	PasswordHash    string         `json:"password_hash"`
+	EmailVerifiedAt sql.NullTime   `json:"email_verified_at"`
+	DisplayName     string         `json:"display_name"`
+	AvatarFileID    sql.NullString `json:"avatar_file_id"`
+	Locale          string         `json:"locale"`
+	Timezone        string         `json:"timezone"`
+	SuspendedAt     sql.NullTime   `json:"suspended_at"`
 }
 
 func (q *Queries) GetUserByID(ctx context.Context, id string) (GetUserByIDRow, error) {
 	row := q.db.QueryRowContext(ctx, getUserByID, id)
 	var i GetUserByIDRow
-	err := row.Scan(&i.ID, &i.Email, &i.PasswordHash)
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.EmailVerifiedAt,
+		&i.DisplayName,
+		&i.AvatarFileID,
+		&i.Locale,
+		&i.Timezone,
+		&i.SuspendedAt,
+	)
 	return i, err
 }
 
@@ -69,3 +168,51 @@ func (q *Queries) InsertUser(ctx context.Context, arg InsertUserParams) error {
 	)
 	return err
 }
+
+const markUserEmailVerified = `-- name: MarkUserEmailVerified :exec
+UPDATE users SET email_verified_at = COALESCE(email_verified_at, now()) WHERE id = $1
+`
+
+func (q *Queries) MarkUserEmailVerified(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, markUserEmailVerified, id)
+	return err
+}
+
+const updateUserEmail = `-- name: UpdateUserEmail :exec
+UPDATE users SET email = $2, email_verified_at = now() WHERE id = $1
+`
+
+type UpdateUserEmailParams struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+}
+
+func (q *Queries) UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserEmail, arg.ID, arg.Email)
+	return err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :exec
+UPDATE users
+SET display_name = $2, avatar_file_id = $3, locale = $4, timezone = $5
+WHERE id = $1
+`
+
+type UpdateUserProfileParams struct {
+	ID           string         `json:"id"`
+	DisplayName  string         `json:"display_name"`
+	AvatarFileID sql.NullString `json:"avatar_file_id"`
+	Locale       string         `json:"locale"`
+	Timezone     string         `json:"timezone"`
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserProfile,
+		arg.ID,
+		arg.DisplayName,
+		arg.AvatarFileID,
+		arg.Locale,
+		arg.Timezone,
+	)
+	return err
+}
@@ -17,7 +17,7 @@ import (
 const createWebhookEndpoint = `-- name: CreateWebhookEndpoint :one
 INSERT INTO webhook_endpoints (org_id, url, secret, events)
 VALUES ($1, $2, $3, $4)
-RETURNING id, org_id, url, events, active, created_at, updated_at
+RETURNING id, org_id, url, events, active, created_at, updated_at, version
 `
 
 type CreateWebhookEndpointParams struct {
@@ -35,6 +35,7 @@ type CreateWebhookEndpointRow struct {
 	Active    bool      `json:"active"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	Version   int64     `json:"version"`
 }
 
 func (q *Queries) CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (CreateWebhookEndpointRow, error) {
@@ -53,10 +54,22 @@ func (q *Queries) CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEn
 		&i.Active,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
 	)
 	return i, err
 }
 
+const deleteOrgWebhookEndpoints = `-- name: DeleteOrgWebhookEndpoints :exec
+DELETE FROM webhook_endpoints
+WHERE org_id = $1
+`
+
+// Their deliveries cascade.
+func (q *Queries) DeleteOrgWebhookEndpoints(ctx context.Context, orgID string) error {
+	_, err := q.db.ExecContext(ctx, deleteOrgWebhookEndpoints, orgID)
+	return err
+}
+
 const getActiveEndpointsForEvent = `-- name: GetActiveEndpointsForEvent :many
 SELECT id, org_id, url, secret, events
 FROM webhook_endpoints
@@ -157,7 +170,7 @@ func (q *Queries) GetPendingDeliveries(ctx context.Context, limit int32) ([]GetP
 }
 
 const getWebhookEndpoint = `-- name: GetWebhookEndpoint :one
-SELECT id, org_id, url, secret, events, active, created_at, updated_at
+SELECT id, org_id, url, secret, events, active, created_at, updated_at, version
 FROM webhook_endpoints
 WHERE id = $1 AND org_id = $2
 `
@@ -179,6 +192,7 @@ func (q *Queries) GetWebhookEndpoint(ctx context.Context, arg GetWebhookEndpoint
 		&i.Active,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
 	)
 	return i, err
 }
@@ -203,7 +217,7 @@ func (q *Queries) InsertDelivery(ctx context.Context, arg InsertDeliveryParams)
 }
 
 const listWebhookEndpoints = `-- name: ListWebhookEndpoints :many
-SELECT id, org_id, url, events, active, created_at, updated_at
+SELECT id, org_id, url, events, active, created_at, updated_at, version
 FROM webhook_endpoints
 WHERE org_id = $1
 ORDER BY created_at DESC
@@ -217,6 +231,7 @@ type ListWebhookEndpointsRow struct {
 	Active    bool      `json:"active"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	Version   int64     `json:"version"`
 }
 
 func (q *Queries) ListWebhookEndpoints(ctx context.Context, orgID string) ([]ListWebhookEndpointsRow, error) {
@@ -236,6 +251,7 @@ func (q *Queries) ListWebhookEndpoints(ctx context.Context, orgID string) ([]Lis
 			&i.Active,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -290,27 +306,33 @@ func (q *Queries) MarkRetry(ctx context.Context, arg MarkRetryParams) error {
 	return err
 }
 
-const updateWebhookEndpoint = `-- name: UpdateWebhookEndpoint :exec
+const updateWebhookEndpoint = `-- name: UpdateWebhookEndpoint :execrows
 UPDATE webhook_endpoints
-SET url = $3, events = $4, active = $5, updated_at = now()
-WHERE id = $1 AND org_id = $2
+SET url = $1, events = $2, active = $3, updated_at = now(), version = version + 1
+WHERE id = $4 AND org_id = $5 AND ($6::BIGINT = 0 OR version = $6)
 `
 
 type UpdateWebhookEndpointParams struct {
-	ID     string   `json:"id"`
-	OrgID  string   `json:"org_id"`
-	Url    string   `json:"url"`
-	Events []string `json:"events"`
-	Active bool     `json:"active"`
+	Url       string   `json:"url"`
+	Events    []string `json:"events"`
+	Active    bool     `json:"active"`
+	ID        string   `json:"id"`
+	OrgID     string   `json:"org_id"`
+	IfVersion int64    `json:"if_version"`
 }
 
-func (q *Queries) UpdateWebhookEndpoint(ctx context.Context, arg UpdateWebhookEndpointParams) error {
-	_, err := q.db.ExecContext(ctx, updateWebhookEndpoint,
-		arg.ID,
-		arg.OrgID,
+// Applies only while the endpoint is at @if_version, unless that is 0.
+func (q *Queries) UpdateWebhookEndpoint(ctx context.Context, arg UpdateWebhookEndpointParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateWebhookEndpoint,
 		arg.Url,
 		pq.Array(arg.Events),
 		arg.Active,
+		arg.ID,
+		arg.OrgID,
+		arg.IfVersion,
 	)
-	return err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
@@ -1,7 +1,9 @@
 package s3store
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -116,3 +118,57 @@ func (s *S3) DeleteObject(ctx context.Context, bucket, key string) error {
 	})
 	return err
 }
+
+// PutObject uploads body from the server, for objects the API generates
+// itself (e.g. data exports).
+func (s *S3) PutObject(ctx context.Context, bucket, key, contentType string, body []byte) error {
+	if bucket == "" {
+		bucket = s.DefaultBucket
+	}
+	_, err := s.Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(bucket),
+		Key:           aws.String(key),
+		ContentType:   aws.String(contentType),
+		ContentLength: aws.Int64(int64(len(body))),
+		Body:          bytes.NewReader(body),
+		ACL:           s3types.ObjectCannedACLPrivate,
+	})
+	return err
+}
+
+// DeletePrefix removes every object whose key starts with prefix, a page
+// of up to 1000 keys at a time.
+func (s *S3) DeletePrefix(ctx context.Context, bucket, prefix string) error {
+	if bucket == "" {
+		bucket = s.DefaultBucket
+	}
+	p := s3.NewListObjectsV2Paginator(s.Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		if len(page.Contents) == 0 {
+			continue
+		}
+		objs := make([]s3types.ObjectIdentifier, len(page.Contents))
+		for i, o := range page.Contents {
+			objs[i] = s3types.ObjectIdentifier{Key: o.Key}
+		}
+		out, err := s.Client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(bucket),
+			Delete: &s3types.Delete{Objects: objs, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return err
+		}
+		if len(out.Errors) > 0 {
+			e := out.Errors[0]
+			return fmt.Errorf("delete %s: %s", aws.ToString(e.Key), aws.ToString(e.Message))
+		}
+	}
+	return nil
+}